@@ -120,6 +120,24 @@ internal static partial class Native
     [LibraryImport(LibName, EntryPoint = "redstr_bash_obfuscate", StringMarshalling = StringMarshalling.Utf8)]
     internal static partial IntPtr BashObfuscate(string input);
 
+    // ========================================================================
+    // Named Transforms
+    // ========================================================================
+
+    [LibraryImport(LibName, EntryPoint = "redstr_apply_pipeline", StringMarshalling = StringMarshalling.Utf8)]
+    internal static partial IntPtr ApplyPipeline(string pipeline, string input);
+
+    // ========================================================================
+    // Feature Detection
+    // ========================================================================
+
+    [LibraryImport(LibName, EntryPoint = "redstr_has_transform", StringMarshalling = StringMarshalling.Utf8)]
+    [return: MarshalAs(UnmanagedType.U1)]
+    internal static partial bool HasTransform(string spec);
+
+    [LibraryImport(LibName, EntryPoint = "redstr_capabilities")]
+    internal static partial ulong Capabilities();
+
     // ========================================================================
     // Helper Methods
     // ========================================================================
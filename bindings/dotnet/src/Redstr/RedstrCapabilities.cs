@@ -0,0 +1,24 @@
+namespace Redstr;
+
+/// <summary>
+/// Optional features of the native library, mirroring the <c>REDSTR_CAP_*</c> flags.
+/// </summary>
+[Flags]
+public enum RedstrCapabilities : ulong
+{
+    None = 0,
+    NamedTransforms = 1 << 0,
+    Pipeline = 1 << 1,
+    Batch = 1 << 2,
+    Transformer = 1 << 3,
+    Buffer = 1 << 4,
+    LastError = 1 << 5,
+    Utf8Policy = 1 << 6,
+    RandSource = 1 << 7,
+    Catalog = 1 << 8,
+    Deprecation = 1 << 9,
+    Events = 1 << 10,
+    Tracing = 1 << 11,
+    Metrics = 1 << 12,
+    BatchContext = 1 << 13,
+}
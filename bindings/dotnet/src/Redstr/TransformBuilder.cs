@@ -14,7 +14,9 @@ namespace Redstr;
 /// </example>
 public class TransformBuilder
 {
-    private string _text;
+    private readonly string _input;
+    private readonly List<string> _steps = new();
+    private string? _result;
 
     /// <summary>
     /// Create a new TransformBuilder with the given input.
@@ -22,17 +24,21 @@ public class TransformBuilder
     /// <param name="input">The initial string to transform.</param>
     public TransformBuilder(string input)
     {
-        _text = input ?? throw new ArgumentNullException(nameof(input));
+        _input = input ?? throw new ArgumentNullException(nameof(input));
     }
 
+    /// <summary>
+    /// The recorded steps as a <c>|</c>-separated pipeline, e.g. <c>"leetspeak | base64_encode"</c>.
+    /// </summary>
+    public string Pipeline => string.Join(" | ", _steps);
+
     /// <summary>
     /// Apply leetspeak transformation.
     /// </summary>
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder Leetspeak()
     {
-        _text = Transforms.Leetspeak(_text);
-        return this;
+        return Step("leetspeak");
     }
 
     /// <summary>
@@ -41,8 +47,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder Base64()
     {
-        _text = Transforms.Base64Encode(_text);
-        return this;
+        return Step("base64_encode");
     }
 
     /// <summary>
@@ -51,8 +56,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder UrlEncode()
     {
-        _text = Transforms.UrlEncode(_text);
-        return this;
+        return Step("url_encode");
     }
 
     /// <summary>
@@ -61,8 +65,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder CaseSwap()
     {
-        _text = Transforms.CaseSwap(_text);
-        return this;
+        return Step("case_swap");
     }
 
     /// <summary>
@@ -71,8 +74,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder Rot13()
     {
-        _text = Transforms.Rot13(_text);
-        return this;
+        return Step("rot13");
     }
 
     /// <summary>
@@ -81,8 +83,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder HexEncode()
     {
-        _text = Transforms.HexEncode(_text);
-        return this;
+        return Step("hex_encode");
     }
 
     /// <summary>
@@ -91,8 +92,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder Homoglyphs()
     {
-        _text = Transforms.HomoglyphSubstitution(_text);
-        return this;
+        return Step("homoglyph_substitution");
     }
 
     /// <summary>
@@ -101,8 +101,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder Reverse()
     {
-        _text = Transforms.ReverseString(_text);
-        return this;
+        return Step("reverse_string");
     }
 
     /// <summary>
@@ -111,8 +110,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder HtmlEncode()
     {
-        _text = Transforms.HtmlEntityEncode(_text);
-        return this;
+        return Step("html_entity_encode");
     }
 
     /// <summary>
@@ -121,8 +119,7 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder XssVariations()
     {
-        _text = Transforms.XssTagVariations(_text);
-        return this;
+        return Step("xss_tag_variations");
     }
 
     /// <summary>
@@ -131,15 +128,26 @@ public class TransformBuilder
     /// <returns>This builder for chaining.</returns>
     public TransformBuilder SqlComments()
     {
-        _text = Transforms.SqlCommentInjection(_text);
-        return this;
+        return Step("sql_comment_injection");
     }
 
     /// <summary>
     /// Build and return the final transformed string.
     /// </summary>
+    /// <remarks>
+    /// All recorded steps run in a single native call, so intermediate results
+    /// never cross the boundary. The result is cached until another step is added.
+    /// </remarks>
     /// <returns>The transformed string.</returns>
-    public string Build() => _text;
+    public string Build()
+        => _result ??= Native.PtrToStringAndFree(Native.ApplyPipeline(Pipeline, _input));
+
+    private TransformBuilder Step(string name)
+    {
+        _steps.Add(name);
+        _result = null;
+        return this;
+    }
 
     /// <summary>
     /// Implicit conversion to string.
@@ -147,5 +155,5 @@ public class TransformBuilder
     public static implicit operator string(TransformBuilder builder) => builder.Build();
 
     /// <inheritdoc/>
-    public override string ToString() => _text;
+    public override string ToString() => Build();
 }
@@ -219,4 +219,41 @@ public static class Transforms
     /// <returns>The obfuscated command.</returns>
     public static string BashObfuscate(string input)
         => Native.PtrToStringAndFree(Native.BashObfuscate(input));
+
+    // ========================================================================
+    // Feature Detection
+    // ========================================================================
+
+    /// <summary>
+    /// Check whether the loaded native library knows a transform.
+    /// </summary>
+    /// <param name="spec">A transform name, optionally pinned as <c>name@version</c>.</param>
+    /// <returns><c>false</c> if the transform is unknown or the library predates this check.</returns>
+    public static bool HasTransform(string spec)
+    {
+        try
+        {
+            return Native.HasTransform(spec);
+        }
+        catch (EntryPointNotFoundException)
+        {
+            return false;
+        }
+    }
+
+    /// <summary>
+    /// Optional features exported by the loaded native library.
+    /// </summary>
+    /// <returns><see cref="RedstrCapabilities.None"/> if the library predates capability flags.</returns>
+    public static RedstrCapabilities Capabilities()
+    {
+        try
+        {
+            return (RedstrCapabilities)Native.Capabilities();
+        }
+        catch (EntryPointNotFoundException)
+        {
+            return RedstrCapabilities.None;
+        }
+    }
 }
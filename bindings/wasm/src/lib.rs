@@ -337,6 +337,62 @@ pub fn jwt_payload_obfuscate(input: &str) -> String {
     redstr::jwt_payload_obfuscate(input)
 }
 
+// ============================================================================
+// Named Transforms
+// ============================================================================
+
+/// Apply a transform by name, optionally pinned with `@version`.
+#[wasm_bindgen]
+pub fn apply_transform(spec: &str, input: &str) -> Result<String, JsError> {
+    Ok(redstr::apply_transform(spec, input)?)
+}
+
+/// Apply a `|`-separated recipe of transforms.
+#[wasm_bindgen]
+pub fn apply_recipe(recipe: &str, input: &str) -> Result<String, JsError> {
+    Ok(redstr::apply_recipe(recipe, input)?)
+}
+
+/// List the names accepted by `apply_transform`.
+#[wasm_bindgen]
+pub fn transform_names() -> Vec<String> {
+    redstr::transform_names()
+        .into_iter()
+        .map(String::from)
+        .collect()
+}
+
+// ============================================================================
+// Randomness
+// ============================================================================
+
+#[wasm_bindgen]
+extern "C" {
+    #[wasm_bindgen(js_namespace = Math)]
+    fn random() -> f64;
+}
+
+/// Draws a seed from two `Math.random()` calls.
+fn math_random_seed() -> u64 {
+    let draw = || (random() * (1u64 << 32) as f64) as u64;
+    (draw() << 32) | draw()
+}
+
+/// Seed generators from `Math.random()`, since the browser has no clock
+/// for the core's default seed source.
+#[wasm_bindgen(start)]
+pub fn start() {
+    redstr::set_rand_source(redstr::RandSource::Custom(std::sync::Arc::new(
+        math_random_seed,
+    )));
+}
+
+/// Make randomized transforms reproducible from a fixed seed.
+#[wasm_bindgen]
+pub fn set_rand_seed(seed: u64) {
+    redstr::set_rand_source(redstr::RandSource::Seeded(seed));
+}
+
 // ============================================================================
 // Tests
 // ============================================================================
@@ -364,6 +420,13 @@ mod tests {
         assert_eq!(result, "uryyb");
     }
 
+    #[wasm_bindgen_test]
+    fn test_apply_recipe() {
+        let result = apply_recipe("rot13 | base64_encode", "hello").ok();
+        assert_eq!(result.as_deref(), Some("dXJ5eWI="));
+        assert!(transform_names().iter().any(|name| name == "rot13"));
+    }
+
     #[wasm_bindgen_test]
     fn test_random_user_agent() {
         let result = random_user_agent();
@@ -283,6 +283,52 @@ fn bench_builder_pattern(c: &mut Criterion) {
     group.finish();
 }
 
+fn bench_parallel_apply(c: &mut Criterion) {
+    let mut group = c.benchmark_group("parallel_apply");
+    let recipe = TransformBuilder::new("").leetspeak().base64().recipe();
+    let wordlist: Vec<String> = (0..100_000)
+        .map(|i| format!("{} {}", MEDIUM_INPUT, i))
+        .collect();
+
+    group.bench_function("sequential", |b| {
+        b.iter(|| apply_recipe_each(black_box(&wordlist), &recipe))
+    });
+
+    for workers in [1, 2, 4, 8] {
+        group.bench_with_input(
+            BenchmarkId::new("workers", workers),
+            &workers,
+            |b, &workers| b.iter(|| parallel_apply(black_box(&wordlist), &recipe, workers)),
+        );
+    }
+
+    group.finish();
+}
+
+fn apply_recipe_each(inputs: &[String], recipe: &str) -> Vec<String> {
+    inputs
+        .iter()
+        .map(|input| apply_recipe(recipe, input).unwrap())
+        .collect()
+}
+
+fn bench_registered_transforms(c: &mut Criterion) {
+    let mut group = c.benchmark_group("registered_transforms");
+
+    // Every named transform, so newly registered ones are covered without
+    // adding a dedicated benchmark.
+    for name in transform_names() {
+        let transform = Transform::resolve(name).unwrap();
+        group.bench_with_input(
+            BenchmarkId::new(name, MEDIUM_INPUT.len()),
+            MEDIUM_INPUT,
+            |b, i| b.iter(|| transform.apply(black_box(i))),
+        );
+    }
+
+    group.finish();
+}
+
 criterion_group!(
     benches,
     bench_case_transformations,
@@ -294,6 +340,8 @@ criterion_group!(
     bench_web_security_transformations,
     bench_bot_detection_transformations,
     bench_builder_pattern,
+    bench_parallel_apply,
+    bench_registered_transforms,
 );
 
 criterion_main!(benches);
@@ -0,0 +1,541 @@
+use std::fmt;
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::Arc;
+use std::thread;
+use std::time::{Duration, Instant};
+
+use crate::registry::{resolve_recipe, run_steps, steps_recipe, Transform, TransformError};
+use crate::trace::{Operation, SpanStatus, TraceSpan};
+
+/// Cancellation and deadline for long-running batch work.
+///
+/// Clones share the cancellation flag, so a server can hand a clone to a
+/// batch and cancel it from another thread when the client disconnects.
+/// Batches check the context before every input and stop early once it is
+/// cancelled or past its deadline.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::BatchContext;
+/// use std::time::Duration;
+///
+/// let ctx = BatchContext::new().with_timeout(Duration::from_secs(5));
+/// let handle = ctx.clone();
+/// handle.cancel();
+/// assert!(ctx.is_cancelled());
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct BatchContext {
+    cancelled: Arc<AtomicBool>,
+    deadline: Option<Instant>,
+}
+
+impl BatchContext {
+    /// Creates a context with no deadline that is not cancelled.
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Returns a context sharing this one's cancellation, bounded by `deadline`.
+    ///
+    /// An earlier existing deadline is kept.
+    pub fn with_deadline(mut self, deadline: Instant) -> Self {
+        self.deadline = Some(match self.deadline {
+            Some(current) => current.min(deadline),
+            None => deadline,
+        });
+        self
+    }
+
+    /// Returns a context sharing this one's cancellation that expires after `timeout`.
+    pub fn with_timeout(self, timeout: Duration) -> Self {
+        match Instant::now().checked_add(timeout) {
+            Some(deadline) => self.with_deadline(deadline),
+            None => self,
+        }
+    }
+
+    /// Cancels this context and every clone of it.
+    pub fn cancel(&self) {
+        self.cancelled.store(true, Ordering::Relaxed);
+    }
+
+    /// Returns whether [`cancel`](Self::cancel) was called on this context or a clone.
+    pub fn is_cancelled(&self) -> bool {
+        self.cancelled.load(Ordering::Relaxed)
+    }
+
+    /// Returns the deadline, if one was set.
+    pub fn deadline(&self) -> Option<Instant> {
+        self.deadline
+    }
+
+    /// Returns whether the context is cancelled or past its deadline.
+    ///
+    /// Custom loops over long-running work can poll this between items.
+    pub fn is_done(&self) -> bool {
+        self.stop_reason().is_some()
+    }
+
+    /// Returns why work should stop, or `None` if it may continue.
+    fn stop_reason(&self) -> Option<StopReason> {
+        if self.is_cancelled() {
+            Some(StopReason::Cancelled)
+        } else if self
+            .deadline
+            .is_some_and(|deadline| Instant::now() >= deadline)
+        {
+            Some(StopReason::DeadlineExceeded)
+        } else {
+            None
+        }
+    }
+}
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum StopReason {
+    Cancelled,
+    DeadlineExceeded,
+}
+
+/// Errors returned by batch operations.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum BatchError {
+    /// The transform spec could not be resolved; no input was processed.
+    Transform(TransformError),
+    /// The context was cancelled; holds the outputs completed before that.
+    Cancelled(Vec<String>),
+    /// The deadline passed; holds the outputs completed before that.
+    DeadlineExceeded(Vec<String>),
+}
+
+impl BatchError {
+    /// Returns the outputs completed before the batch stopped.
+    pub fn completed(&self) -> &[String] {
+        match self {
+            BatchError::Transform(_) => &[],
+            BatchError::Cancelled(done) | BatchError::DeadlineExceeded(done) => done,
+        }
+    }
+}
+
+impl fmt::Display for BatchError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            BatchError::Transform(err) => write!(f, "{}", err),
+            BatchError::Cancelled(done) => {
+                write!(f, "batch cancelled after {} inputs", done.len())
+            }
+            BatchError::DeadlineExceeded(done) => {
+                write!(f, "batch deadline exceeded after {} inputs", done.len())
+            }
+        }
+    }
+}
+
+impl std::error::Error for BatchError {}
+
+impl From<TransformError> for BatchError {
+    fn from(err: TransformError) -> Self {
+        BatchError::Transform(err)
+    }
+}
+
+/// Applies one named transform to every input, resolving the spec once.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Mutate a whole wordlist with one transform
+/// - **Blue Team**: Generate a test corpus from a list of known-bad payloads
+///
+/// # Examples
+///
+/// ```
+/// use redstr::apply_batch;
+/// let outputs = apply_batch("hex_encode", &["a", "b"]).unwrap();
+/// assert_eq!(outputs, vec!["61", "62"]);
+/// ```
+pub fn apply_batch<S: AsRef<str>>(spec: &str, inputs: &[S]) -> Result<Vec<String>, TransformError> {
+    let transform = Transform::resolve(spec)?;
+    let span = TraceSpan::start(
+        Operation::Batch,
+        || transform.spec().to_string(),
+        inputs.len(),
+    );
+    let outputs: Vec<String> = inputs
+        .iter()
+        .map(|input| transform.apply(input.as_ref()))
+        .collect();
+    span.finish(SpanStatus::Ok, &outputs);
+    Ok(outputs)
+}
+
+/// Like [`apply_batch`], stopping early when `ctx` is cancelled or expires.
+///
+/// The context is checked before each input, so a batch stops within one
+/// transform call of being cancelled. Outputs completed up to that point
+/// are returned inside the error.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bound corpus generation to a time budget inside a scanner
+/// - **Blue Team**: Abort generation when a test-harness request is cancelled
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{apply_batch_ctx, BatchContext, BatchError};
+///
+/// let ctx = BatchContext::new();
+/// assert_eq!(apply_batch_ctx(&ctx, "rot13", &["abc"]).unwrap(), vec!["nop"]);
+///
+/// ctx.cancel();
+/// let err = apply_batch_ctx(&ctx, "rot13", &["abc"]).unwrap_err();
+/// assert_eq!(err, BatchError::Cancelled(Vec::new()));
+/// ```
+pub fn apply_batch_ctx<S: AsRef<str>>(
+    ctx: &BatchContext,
+    spec: &str,
+    inputs: &[S],
+) -> Result<Vec<String>, BatchError> {
+    let transform = Transform::resolve(spec)?;
+    let span = TraceSpan::start(
+        Operation::Batch,
+        || transform.spec().to_string(),
+        inputs.len(),
+    );
+    let mut outputs = Vec::with_capacity(inputs.len());
+    for input in inputs {
+        match ctx.stop_reason() {
+            Some(StopReason::Cancelled) => {
+                span.finish(SpanStatus::Cancelled, &outputs);
+                return Err(BatchError::Cancelled(outputs));
+            }
+            Some(StopReason::DeadlineExceeded) => {
+                span.finish(SpanStatus::DeadlineExceeded, &outputs);
+                return Err(BatchError::DeadlineExceeded(outputs));
+            }
+            None => outputs.push(transform.apply(input.as_ref())),
+        }
+    }
+    span.finish(SpanStatus::Ok, &outputs);
+    Ok(outputs)
+}
+
+/// Applies a recipe to every input, sharding the work across `workers` threads.
+///
+/// The recipe is resolved once, as with [`apply_recipe`](crate::apply_recipe),
+/// and inputs are split into contiguous shards so outputs come back in input
+/// order. A `workers` of 0 uses the available parallelism of the host, and
+/// shards run on the calling thread where threads cannot be spawned (as on
+/// WASI without the threads proposal). Pass
+/// [`TransformBuilder::recipe`](crate::TransformBuilder::recipe) to run a
+/// builder chain over a whole wordlist.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Mutate million-entry wordlists using every core
+/// - **Blue Team**: Generate large evasion corpora for detection benchmarks
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{parallel_apply, TransformBuilder};
+/// let recipe = TransformBuilder::new("").rot13().hex_encode().recipe();
+/// let words: Vec<String> = (0..1000).map(|i| format!("user{}", i)).collect();
+/// let outputs = parallel_apply(&words, &recipe, 4).unwrap();
+/// assert_eq!(outputs.len(), 1000);
+/// assert_eq!(outputs[0], "6866726530");
+/// ```
+pub fn parallel_apply<S: AsRef<str> + Sync>(
+    inputs: &[S],
+    recipe: &str,
+    workers: usize,
+) -> Result<Vec<String>, TransformError> {
+    let steps = resolve_recipe(recipe)?;
+    let span = TraceSpan::start(
+        Operation::ParallelBatch,
+        || steps_recipe(&steps),
+        inputs.len(),
+    );
+    let (outputs, _) = run_sharded(inputs, &steps, workers, None);
+    span.finish(SpanStatus::Ok, &outputs);
+    Ok(outputs)
+}
+
+/// Like [`parallel_apply`], stopping early when `ctx` is cancelled or expires.
+///
+/// Every shard checks the context before each of its inputs. When the batch
+/// stops, the error holds the longest run of outputs completed in input
+/// order; work finished by later shards past the first gap is discarded.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bound wordlist mutation to a time budget on every core
+/// - **Blue Team**: Abort corpus generation when a harness request is cancelled
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{parallel_apply_ctx, BatchContext, BatchError};
+///
+/// let ctx = BatchContext::new();
+/// let words = ["abc", "xyz"];
+/// assert_eq!(
+///     parallel_apply_ctx(&ctx, &words, "rot13", 2).unwrap(),
+///     vec!["nop", "klm"]
+/// );
+///
+/// ctx.cancel();
+/// let err = parallel_apply_ctx(&ctx, &words, "rot13", 2).unwrap_err();
+/// assert_eq!(err, BatchError::Cancelled(Vec::new()));
+/// ```
+pub fn parallel_apply_ctx<S: AsRef<str> + Sync>(
+    ctx: &BatchContext,
+    inputs: &[S],
+    recipe: &str,
+    workers: usize,
+) -> Result<Vec<String>, BatchError> {
+    let steps = resolve_recipe(recipe)?;
+    let span = TraceSpan::start(
+        Operation::ParallelBatch,
+        || steps_recipe(&steps),
+        inputs.len(),
+    );
+    let (outputs, stopped) = run_sharded(inputs, &steps, workers, Some(ctx));
+    match stopped {
+        None => {
+            span.finish(SpanStatus::Ok, &outputs);
+            Ok(outputs)
+        }
+        Some(StopReason::Cancelled) => {
+            span.finish(SpanStatus::Cancelled, &outputs);
+            Err(BatchError::Cancelled(outputs))
+        }
+        Some(StopReason::DeadlineExceeded) => {
+            span.finish(SpanStatus::DeadlineExceeded, &outputs);
+            Err(BatchError::DeadlineExceeded(outputs))
+        }
+    }
+}
+
+/// Runs resolved steps over `inputs` on up to `workers` scoped threads.
+///
+/// With a context, each shard stops at its first input after the context is
+/// done, and the outputs are cut at the first shard that stopped, along with
+/// the reason it stopped.
+fn run_sharded<S: AsRef<str> + Sync>(
+    inputs: &[S],
+    steps: &[Transform],
+    workers: usize,
+    ctx: Option<&BatchContext>,
+) -> (Vec<String>, Option<StopReason>) {
+    let run_shard = move |shard: &[S]| {
+        let mut done = Vec::with_capacity(shard.len());
+        for input in shard {
+            if let Some(reason) = ctx.and_then(BatchContext::stop_reason) {
+                return (done, Some(reason));
+            }
+            done.push(run_steps(steps, input.as_ref()));
+        }
+        (done, None)
+    };
+    let workers = match workers {
+        0 => thread::available_parallelism().map_or(1, |n| n.get()),
+        n => n,
+    }
+    .min(inputs.len());
+    if workers <= 1 {
+        return run_shard(inputs);
+    }
+
+    let shard_len = inputs.len().div_ceil(workers);
+    thread::scope(|scope| {
+        let shards: Vec<_> = inputs
+            .chunks(shard_len)
+            .map(|shard| {
+                thread::Builder::new()
+                    .spawn_scoped(scope, move || run_shard(shard))
+                    .map_err(|_| run_shard(shard))
+            })
+            .collect();
+        let mut outputs = Vec::with_capacity(inputs.len());
+        let mut stopped = None;
+        for shard in shards {
+            let (done, reason) = match shard {
+                Ok(worker) => worker.join().expect("transform worker panicked"),
+                // Spawning failed, so the shard already ran on this thread.
+                Err(done) => done,
+            };
+            // Later shards may have finished, but only an in-order prefix is kept.
+            if stopped.is_none() {
+                outputs.extend(done);
+                stopped = reason;
+            }
+        }
+        (outputs, stopped)
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_apply_batch() {
+        let inputs = vec!["hello".to_string(), String::new()];
+        assert_eq!(
+            apply_batch("base64_encode@1", &inputs).unwrap(),
+            vec!["aGVsbG8=", ""]
+        );
+        assert!(apply_batch::<&str>("rot13", &[]).unwrap().is_empty());
+        assert_eq!(
+            apply_batch("nope", &["x"]),
+            Err(TransformError::UnknownTransform("nope".to_string()))
+        );
+    }
+
+    #[test]
+    fn test_apply_batch_ctx_completes() {
+        let ctx = BatchContext::new().with_timeout(Duration::from_secs(60));
+        assert_eq!(
+            apply_batch_ctx(&ctx, "hex_encode", &["a", "b", "c"]).unwrap(),
+            vec!["61", "62", "63"]
+        );
+    }
+
+    #[test]
+    fn test_apply_batch_ctx_deadline() {
+        let ctx = BatchContext::new().with_deadline(Instant::now());
+        let err = apply_batch_ctx(&ctx, "hex_encode", &["a"]).unwrap_err();
+        assert_eq!(err, BatchError::DeadlineExceeded(Vec::new()));
+        assert_eq!(err.to_string(), "batch deadline exceeded after 0 inputs");
+        assert!(ctx.is_done());
+        assert!(!ctx.is_cancelled());
+    }
+
+    #[test]
+    fn test_apply_batch_ctx_cancel_from_other_thread() {
+        let ctx = BatchContext::new();
+        let inputs: Vec<String> = (0..200_000).map(|i| format!("payload {}", i)).collect();
+        let handle = ctx.clone();
+        let worker = std::thread::spawn(move || apply_batch_ctx(&handle, "leetspeak", &inputs));
+        ctx.cancel();
+        match worker.join().unwrap() {
+            Err(BatchError::Cancelled(done)) => assert!(done.len() < 200_000),
+            other => panic!("expected cancellation, got {:?}", other.map(|v| v.len())),
+        }
+    }
+
+    #[test]
+    fn test_apply_batch_ctx_unknown_spec() {
+        let err = apply_batch_ctx(&BatchContext::new(), "nope", &["x"]).unwrap_err();
+        assert!(err.completed().is_empty());
+        assert_eq!(err.to_string(), "unknown transform: nope");
+    }
+
+    #[test]
+    fn test_batch_context_keeps_earliest_deadline() {
+        let soon = Instant::now() + Duration::from_secs(1);
+        let later = soon + Duration::from_secs(10);
+        let ctx = BatchContext::new().with_deadline(soon).with_deadline(later);
+        assert_eq!(ctx.deadline(), Some(soon));
+        let ctx = ctx.with_timeout(Duration::from_secs(3600));
+        assert_eq!(ctx.deadline(), Some(soon));
+        assert_eq!(BatchContext::new().deadline(), None);
+    }
+
+    #[test]
+    fn test_batch_context_clones_share_cancellation() {
+        let ctx = BatchContext::new();
+        let child = ctx.clone().with_timeout(Duration::from_secs(1));
+        assert!(!child.is_cancelled());
+        assert!(!child.is_done());
+        ctx.cancel();
+        assert!(child.is_cancelled());
+        assert!(child.is_done());
+    }
+
+    #[test]
+    fn test_parallel_apply_matches_sequential() {
+        let inputs: Vec<String> = (0..1003).map(|i| format!("entry {}", i)).collect();
+        let sequential = apply_batch("rot13", &inputs).unwrap();
+        for workers in [0, 1, 2, 7, 64, 5000] {
+            assert_eq!(
+                parallel_apply(&inputs, "rot13", workers).unwrap(),
+                sequential
+            );
+        }
+    }
+
+    #[test]
+    fn test_parallel_apply_recipe() {
+        let recipe = crate::TransformBuilder::new("").rot13().base64().recipe();
+        assert_eq!(
+            parallel_apply(&["hello", "world"], &recipe, 2).unwrap(),
+            vec!["dXJ5eWI=", "amJleXE="]
+        );
+        assert_eq!(parallel_apply(&["same"], "", 4).unwrap(), vec!["same"]);
+        assert!(parallel_apply::<&str>(&[], "rot13", 4).unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_parallel_apply_random_transforms() {
+        let inputs = vec!["password"; 500];
+        let outputs = parallel_apply(&inputs, "randomize_capitalization", 4).unwrap();
+        assert_eq!(outputs.len(), 500);
+        assert!(outputs.iter().all(|o| o.eq_ignore_ascii_case("password")));
+    }
+
+    #[test]
+    fn test_parallel_apply_unknown_step() {
+        assert_eq!(
+            parallel_apply(&["x"], "rot13 | nope", 2),
+            Err(TransformError::UnknownTransform("nope".to_string()))
+        );
+    }
+
+    #[test]
+    fn test_parallel_apply_ctx_completes() {
+        let words: Vec<String> = (0..500).map(|i| format!("user{}", i)).collect();
+        let ctx = BatchContext::new().with_timeout(Duration::from_secs(60));
+        assert_eq!(
+            parallel_apply_ctx(&ctx, &words, "rot13 | hex_encode", 4).unwrap(),
+            parallel_apply(&words, "rot13 | hex_encode", 1).unwrap()
+        );
+    }
+
+    #[test]
+    fn test_parallel_apply_ctx_deadline() {
+        let ctx = BatchContext::new().with_deadline(Instant::now());
+        assert_eq!(
+            parallel_apply_ctx(&ctx, &["a", "b", "c"], "hex_encode", 2),
+            Err(BatchError::DeadlineExceeded(Vec::new()))
+        );
+        assert_eq!(
+            parallel_apply_ctx(&ctx, &["x"], "rot13 | nope", 2),
+            Err(BatchError::Transform(TransformError::UnknownTransform(
+                "nope".to_string()
+            )))
+        );
+    }
+
+    #[test]
+    fn test_parallel_apply_ctx_cancel_keeps_ordered_prefix() {
+        let ctx = BatchContext::new();
+        let inputs: Vec<String> = (0..200_000).map(|i| format!("payload {}", i)).collect();
+        let handle = ctx.clone();
+        let shared = inputs.clone();
+        let worker = std::thread::spawn(move || parallel_apply_ctx(&handle, &shared, "rot13", 4));
+        ctx.cancel();
+        match worker.join().unwrap() {
+            Err(BatchError::Cancelled(done)) => {
+                assert!(done.len() < inputs.len());
+                let expected = apply_batch("rot13", &inputs[..done.len()]).unwrap();
+                assert_eq!(done, expected);
+            }
+            other => panic!("expected cancellation, got {:?}", other.map(|v| v.len())),
+        }
+    }
+}
@@ -1,9 +1,11 @@
+use crate::registry::{format_recipe, Transform, TransformError, TransformFn, TransformSpec};
 use crate::transformations::bot_detection::cloudflare_challenge_variation;
 use crate::transformations::case::{case_swap, randomize_capitalization};
 use crate::transformations::cloudflare::{
     cloudflare_challenge_response, cloudflare_turnstile_variation,
 };
 use crate::transformations::encoding::{base64_encode, hex_encode, url_encode};
+use crate::transformations::injection::crlf_injection_variant;
 use crate::transformations::obfuscation::{leetspeak, rot13};
 use crate::transformations::phishing::{advanced_domain_spoof, email_obfuscation};
 use crate::transformations::shell::{bash_obfuscate, powershell_obfuscate};
@@ -24,6 +26,7 @@ use crate::transformations::web_security::graphql_obfuscate;
 /// ```
 pub struct TransformBuilder {
     text: String,
+    steps: Vec<TransformSpec>,
 }
 
 impl TransformBuilder {
@@ -31,102 +34,152 @@ impl TransformBuilder {
     pub fn new(input: &str) -> Self {
         Self {
             text: input.to_string(),
+            steps: Vec::new(),
         }
     }
 
+    /// Applies a named transform, optionally pinned to a behavior version.
+    ///
+    /// # Examples
+    ///
+    /// ```
+    /// use redstr::TransformBuilder;
+    /// let result = TransformBuilder::new("hello")
+    ///     .apply("rot13@1")
+    ///     .unwrap()
+    ///     .build();
+    /// assert_eq!(result, "uryyb");
+    /// ```
+    pub fn apply(mut self, spec: &str) -> Result<Self, TransformError> {
+        let transform = Transform::resolve(spec)?;
+        self.text = transform.apply(&self.text);
+        self.steps.push(transform.spec());
+        Ok(self)
+    }
+
+    /// Returns the applied steps as a version-pinned recipe.
+    ///
+    /// The recipe can be stored and replayed later with [`apply_recipe`](crate::apply_recipe).
+    ///
+    /// # Examples
+    ///
+    /// ```
+    /// use redstr::TransformBuilder;
+    /// let builder = TransformBuilder::new("test").leetspeak().base64();
+    /// assert_eq!(builder.recipe(), "leetspeak@1 | base64_encode@1");
+    /// ```
+    pub fn recipe(&self) -> String {
+        format_recipe(&self.steps)
+    }
+
     /// Applies leetspeak transformation.
-    pub fn leetspeak(mut self) -> Self {
-        self.text = leetspeak(&self.text);
-        self
+    pub fn leetspeak(self) -> Self {
+        self.step("leetspeak", 1, leetspeak)
     }
 
     /// Applies base64 encoding.
-    pub fn base64(mut self) -> Self {
-        self.text = base64_encode(&self.text);
-        self
+    pub fn base64(self) -> Self {
+        self.step("base64_encode", 1, base64_encode)
     }
 
     /// Applies URL encoding.
-    pub fn url_encode(mut self) -> Self {
-        self.text = url_encode(&self.text);
-        self
+    pub fn url_encode(self) -> Self {
+        self.step("url_encode", 1, url_encode)
     }
 
     /// Applies random capitalization.
-    pub fn redstrs(mut self) -> Self {
-        self.text = randomize_capitalization(&self.text);
-        self
+    pub fn redstrs(self) -> Self {
+        self.step("randomize_capitalization", 1, randomize_capitalization)
     }
 
     /// Applies homoglyph substitution.
-    pub fn homoglyphs(mut self) -> Self {
-        self.text = homoglyph_substitution(&self.text);
-        self
+    pub fn homoglyphs(self) -> Self {
+        self.step("homoglyph_substitution", 1, homoglyph_substitution)
     }
 
     /// Applies case swapping.
-    pub fn case_swap(mut self) -> Self {
-        self.text = case_swap(&self.text);
-        self
+    pub fn case_swap(self) -> Self {
+        self.step("case_swap", 1, case_swap)
     }
 
     /// Applies hex encoding.
-    pub fn hex_encode(mut self) -> Self {
-        self.text = hex_encode(&self.text);
-        self
+    pub fn hex_encode(self) -> Self {
+        self.step("hex_encode", 1, hex_encode)
     }
 
     /// Applies ROT13 cipher.
-    pub fn rot13(mut self) -> Self {
-        self.text = rot13(&self.text);
-        self
+    pub fn rot13(self) -> Self {
+        self.step("rot13", 1, rot13)
     }
 
     /// Applies advanced domain spoofing (for EvilJinx).
-    pub fn advanced_domain_spoof(mut self) -> Self {
-        self.text = advanced_domain_spoof(&self.text);
-        self
+    pub fn advanced_domain_spoof(self) -> Self {
+        self.step("advanced_domain_spoof", 1, advanced_domain_spoof)
     }
 
     /// Applies email obfuscation (for EvilJinx).
-    pub fn email_obfuscation(mut self) -> Self {
-        self.text = email_obfuscation(&self.text);
-        self
+    pub fn email_obfuscation(self) -> Self {
+        self.step("email_obfuscation", 1, email_obfuscation)
     }
 
     /// Applies PowerShell obfuscation (for Windows pentesting).
-    pub fn powershell_obfuscate(mut self) -> Self {
-        self.text = powershell_obfuscate(&self.text);
-        self
+    pub fn powershell_obfuscate(self) -> Self {
+        self.step("powershell_obfuscate", 1, powershell_obfuscate)
     }
 
     /// Applies bash obfuscation (for Linux pentesting).
-    pub fn bash_obfuscate(mut self) -> Self {
-        self.text = bash_obfuscate(&self.text);
-        self
+    pub fn bash_obfuscate(self) -> Self {
+        self.step("bash_obfuscate", 1, bash_obfuscate)
     }
 
     /// Applies Cloudflare challenge variation.
-    pub fn cloudflare_challenge(mut self) -> Self {
-        self.text = cloudflare_challenge_variation(&self.text);
-        self
+    pub fn cloudflare_challenge(self) -> Self {
+        self.step(
+            "cloudflare_challenge_variation",
+            1,
+            cloudflare_challenge_variation,
+        )
     }
 
     /// Applies Cloudflare Turnstile challenge variation.
-    pub fn cloudflare_turnstile(mut self) -> Self {
-        self.text = cloudflare_turnstile_variation(&self.text);
-        self
+    pub fn cloudflare_turnstile(self) -> Self {
+        self.step(
+            "cloudflare_turnstile_variation",
+            1,
+            cloudflare_turnstile_variation,
+        )
     }
 
     /// Applies Cloudflare challenge response pattern.
-    pub fn cloudflare_challenge_response(mut self) -> Self {
-        self.text = cloudflare_challenge_response(&self.text);
-        self
+    pub fn cloudflare_challenge_response(self) -> Self {
+        self.step(
+            "cloudflare_challenge_response",
+            1,
+            cloudflare_challenge_response,
+        )
     }
 
     /// Applies GraphQL obfuscation (for Caido).
-    pub fn graphql_obfuscate(mut self) -> Self {
-        self.text = graphql_obfuscate(&self.text);
+    pub fn graphql_obfuscate(self) -> Self {
+        self.step("graphql_obfuscate", 1, graphql_obfuscate)
+    }
+
+    /// Applies a random CRLF injection encoding (for header injection testing).
+    pub fn crlf_injection(self) -> Self {
+        self.step("crlf_injection_variant", 1, crlf_injection_variant)
+    }
+
+    /// Applies `transform` and records it as `name@version`.
+    ///
+    /// The version is the one `transform` implements, not the latest
+    /// registered one, so the recipe still replays this step after the
+    /// registry gains a newer behavior under the same name.
+    fn step(mut self, name: &str, version: u32, transform: TransformFn) -> Self {
+        self.text = transform(&self.text);
+        self.steps.push(TransformSpec {
+            name: name.to_string(),
+            version: Some(version),
+        });
         self
     }
 
@@ -149,6 +202,58 @@ mod tests {
         assert!(result2.len() > 0);
     }
 
+    #[test]
+    fn test_transform_builder_apply() {
+        let result = TransformBuilder::new("abc")
+            .apply("rot13")
+            .unwrap()
+            .apply("reverse_string@1")
+            .unwrap()
+            .build();
+        assert_eq!(result, "pon");
+
+        assert!(TransformBuilder::new("abc").apply("missing").is_err());
+    }
+
+    #[test]
+    fn test_transform_builder_recipe() {
+        let builder = TransformBuilder::new("hello")
+            .rot13()
+            .apply("hex_encode")
+            .unwrap();
+        let recipe = builder.recipe();
+        assert_eq!(recipe, "rot13@1 | hex_encode@1");
+        assert_eq!(
+            crate::apply_recipe(&recipe, "hello").unwrap(),
+            builder.build()
+        );
+    }
+
+    #[test]
+    fn test_transform_builder_steps_are_registered() {
+        let builder = TransformBuilder::new("paypal.com")
+            .leetspeak()
+            .base64()
+            .url_encode()
+            .redstrs()
+            .homoglyphs()
+            .case_swap()
+            .hex_encode()
+            .rot13()
+            .advanced_domain_spoof()
+            .email_obfuscation()
+            .powershell_obfuscate()
+            .bash_obfuscate()
+            .cloudflare_challenge()
+            .cloudflare_turnstile()
+            .cloudflare_challenge_response()
+            .graphql_obfuscate()
+            .crlf_injection();
+        for spec in builder.recipe().split(" | ") {
+            assert!(Transform::resolve(spec).is_ok(), "{}", spec);
+        }
+    }
+
     #[test]
     fn test_transform_builder_new_functions() {
         let result = TransformBuilder::new("paypal.com")
@@ -167,6 +272,15 @@ mod tests {
         assert!(result3.len() > 0);
     }
 
+    #[test]
+    fn test_transform_builder_crlf_injection() {
+        let builder = TransformBuilder::new("Set-Cookie: a=b").crlf_injection();
+        assert_eq!(builder.recipe(), "crlf_injection_variant@1");
+        let result = builder.build();
+        assert!(result.ends_with("Set-Cookie: a=b"));
+        assert!(result.len() > "Set-Cookie: a=b".len());
+    }
+
     #[test]
     fn test_transform_builder_cloudflare_functions() {
         let result = TransformBuilder::new("challenge-token")
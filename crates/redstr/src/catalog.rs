@@ -0,0 +1,241 @@
+use std::fmt;
+
+use crate::json::json_string;
+use crate::registry::{transform_names, Transform};
+
+/// Family a registered transform belongs to.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum TransformCategory {
+    /// Capitalization and identifier case changes.
+    Case,
+    /// Reversible encodings such as Base64, hex and URL encoding.
+    Encoding,
+    /// Homoglyphs, combining marks and other Unicode tricks.
+    Unicode,
+    /// SQL, NoSQL, template, XSS and command injection payloads.
+    Injection,
+    /// General-purpose text obfuscation.
+    Obfuscation,
+    /// Lookalike domains, emails and URLs.
+    Phishing,
+    /// Browser and client fingerprint variation.
+    BotDetection,
+    /// Cloudflare challenge and fingerprint variation.
+    Cloudflare,
+    /// JWT, GraphQL, HTML form and API request variation.
+    WebSecurity,
+    /// SAML message encoding.
+    Saml,
+    /// PHP payload obfuscation.
+    Php,
+    /// Scheduled task and autorun obfuscation.
+    Persistence,
+    /// Shell command obfuscation (bash, cmd.exe, PowerShell, AppleScript).
+    Shell,
+    /// SQL Server and SQL literal re-encoding.
+    Sql,
+    /// VBA macro obfuscation.
+    Vba,
+    /// XML and SOAP obfuscation.
+    Xml,
+}
+
+impl TransformCategory {
+    /// Lowercase snake_case category name, e.g. `"bot_detection"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            TransformCategory::Case => "case",
+            TransformCategory::Encoding => "encoding",
+            TransformCategory::Unicode => "unicode",
+            TransformCategory::Injection => "injection",
+            TransformCategory::Obfuscation => "obfuscation",
+            TransformCategory::Phishing => "phishing",
+            TransformCategory::BotDetection => "bot_detection",
+            TransformCategory::Cloudflare => "cloudflare",
+            TransformCategory::WebSecurity => "web_security",
+            TransformCategory::Saml => "saml",
+            TransformCategory::Php => "php",
+            TransformCategory::Persistence => "persistence",
+            TransformCategory::Shell => "shell",
+            TransformCategory::Sql => "sql",
+            TransformCategory::Vba => "vba",
+            TransformCategory::Xml => "xml",
+        }
+    }
+}
+
+impl fmt::Display for TransformCategory {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// Whether a transform's name is settled.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum Stability {
+    /// Kept under its name; behavior changes get a new version.
+    Stable,
+    /// May still be renamed or removed in a minor release.
+    Experimental,
+}
+
+impl Stability {
+    /// Lowercase stability name, e.g. `"experimental"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            Stability::Stable => "stable",
+            Stability::Experimental => "experimental",
+        }
+    }
+}
+
+impl fmt::Display for Stability {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// What a transform accepts as its single `input` parameter.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum TransformInput {
+    /// A UTF-8 string.
+    Text,
+    /// A UTF-8 string or arbitrary bytes, see [`Transform::apply_bytes`].
+    TextOrBytes,
+}
+
+impl TransformInput {
+    /// Lowercase input kind name, e.g. `"text_or_bytes"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            TransformInput::Text => "text",
+            TransformInput::TextOrBytes => "text_or_bytes",
+        }
+    }
+}
+
+impl fmt::Display for TransformInput {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// Catalog entry describing one registered transform at its latest version.
+///
+/// Every registered transform takes a single input and returns a string;
+/// [`input`](TransformInfo::input) says whether that input may be raw bytes.
+/// A spec can still pin an older behavior with an `@<version>` suffix
+/// (`rot13@1`), which selects a version rather than passing an argument.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct TransformInfo {
+    /// Registry name, as accepted by [`apply_transform`](crate::apply_transform).
+    pub name: &'static str,
+    /// Latest behavior version.
+    pub version: u32,
+    /// Family the transform belongs to.
+    pub category: TransformCategory,
+    /// Whether the name is settled.
+    pub stability: Stability,
+    /// Whether the output depends only on the input, never on the seed.
+    pub deterministic: bool,
+    /// Whether the original input can be recovered from the output alone.
+    pub reversible: bool,
+    /// What the transform accepts as input.
+    pub input: TransformInput,
+}
+
+impl TransformInfo {
+    /// Serializes the entry as a compact JSON object, e.g.
+    /// `{"name":"rot13","version":1,"category":"obfuscation","stability":"stable",`
+    /// `"deterministic":true,"reversible":true,"input":"text"}`.
+    pub fn to_json(&self) -> String {
+        format!(
+            "{{\"name\":{},\"version\":{},\"category\":{},\"stability\":{},\"deterministic\":{},\"reversible\":{},\"input\":{}}}",
+            json_string(self.name),
+            self.version,
+            json_string(self.category.name()),
+            json_string(self.stability.name()),
+            self.deterministic,
+            self.reversible,
+            json_string(self.input.name())
+        )
+    }
+}
+
+/// Describes every registered transform at its latest version, in registry order.
+///
+/// Intended for generated documentation, dynamic UIs and bindings that need
+/// to know what a library version offers without hard-coding names.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Offer only deterministic transforms when a payload must be reproducible
+/// - **Blue Team**: Generate per-category test matrices for detection rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{catalog, TransformCategory};
+/// let encoders: Vec<_> = catalog()
+///     .into_iter()
+///     .filter(|info| info.category == TransformCategory::Encoding && info.deterministic)
+///     .map(|info| info.name)
+///     .collect();
+/// assert!(encoders.contains(&"base64_encode"));
+/// ```
+pub fn catalog() -> Vec<TransformInfo> {
+    transform_names()
+        .into_iter()
+        .filter_map(|name| Transform::resolve(name).ok())
+        .map(|transform| transform.info())
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::apply_transform;
+    use crate::rng::with_seed;
+
+    #[test]
+    fn test_catalog_covers_every_transform() {
+        let catalog = catalog();
+        assert_eq!(catalog.len(), transform_names().len());
+        let rot13 = catalog.iter().find(|info| info.name == "rot13").unwrap();
+        assert_eq!(rot13.category, TransformCategory::Obfuscation);
+        assert_eq!(rot13.stability, Stability::Stable);
+        assert!(rot13.deterministic && rot13.reversible);
+        assert_eq!(rot13.input, TransformInput::Text);
+    }
+
+    #[test]
+    fn test_catalog_input_kinds() {
+        let hex = Transform::resolve("hex_encode").unwrap().info();
+        assert_eq!(hex.input, TransformInput::TextOrBytes);
+        assert_eq!(hex.category.to_string(), "encoding");
+        let php = Transform::resolve("php_obfuscate").unwrap().info();
+        assert_eq!(php.stability.to_string(), "experimental");
+    }
+
+    #[test]
+    fn test_transform_info_to_json() {
+        assert_eq!(
+            Transform::resolve("rot13").unwrap().info().to_json(),
+            r#"{"name":"rot13","version":1,"category":"obfuscation","stability":"stable","deterministic":true,"reversible":true,"input":"text"}"#
+        );
+        let url = Transform::resolve("url_encode").unwrap().info().to_json();
+        assert!(url.ends_with(r#""input":"text_or_bytes"}"#));
+    }
+
+    #[test]
+    fn test_deterministic_transforms_ignore_the_seed() {
+        let inputs = ["hello world", "SELECT * FROM users", "<a href=x>y</a>"];
+        for info in catalog().iter().filter(|info| info.deterministic) {
+            for input in inputs {
+                let first = with_seed(1, || apply_transform(info.name, input));
+                let second = with_seed(2, || apply_transform(info.name, input));
+                assert_eq!(first, second, "{}", info.name);
+            }
+        }
+    }
+}
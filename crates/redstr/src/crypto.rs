@@ -0,0 +1,678 @@
+use crate::rng::SimpleRng;
+
+const SHA256_K: [u32; 64] = [
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+];
+
+/// Computes the SHA-256 digest of `data`.
+///
+/// Not constant-time; only used to sign test tokens.
+pub(crate) fn sha256(data: &[u8]) -> [u8; 32] {
+    let mut state: [u32; 8] = [
+        0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab,
+        0x5be0cd19,
+    ];
+
+    let mut message = data.to_vec();
+    let bit_len = (data.len() as u64).wrapping_mul(8);
+    message.push(0x80);
+    while message.len() % 64 != 56 {
+        message.push(0);
+    }
+    message.extend_from_slice(&bit_len.to_be_bytes());
+
+    for block in message.chunks(64) {
+        let mut w = [0u32; 64];
+        for (i, word) in block.chunks(4).enumerate() {
+            w[i] = u32::from_be_bytes([word[0], word[1], word[2], word[3]]);
+        }
+        for i in 16..64 {
+            let s0 = w[i - 15].rotate_right(7) ^ w[i - 15].rotate_right(18) ^ (w[i - 15] >> 3);
+            let s1 = w[i - 2].rotate_right(17) ^ w[i - 2].rotate_right(19) ^ (w[i - 2] >> 10);
+            w[i] = w[i - 16]
+                .wrapping_add(s0)
+                .wrapping_add(w[i - 7])
+                .wrapping_add(s1);
+        }
+
+        let [mut a, mut b, mut c, mut d, mut e, mut f, mut g, mut h] = state;
+        for i in 0..64 {
+            let s1 = e.rotate_right(6) ^ e.rotate_right(11) ^ e.rotate_right(25);
+            let ch = (e & f) ^ (!e & g);
+            let t1 = h
+                .wrapping_add(s1)
+                .wrapping_add(ch)
+                .wrapping_add(SHA256_K[i])
+                .wrapping_add(w[i]);
+            let s0 = a.rotate_right(2) ^ a.rotate_right(13) ^ a.rotate_right(22);
+            let maj = (a & b) ^ (a & c) ^ (b & c);
+            let t2 = s0.wrapping_add(maj);
+            h = g;
+            g = f;
+            f = e;
+            e = d.wrapping_add(t1);
+            d = c;
+            c = b;
+            b = a;
+            a = t1.wrapping_add(t2);
+        }
+
+        for (slot, value) in state.iter_mut().zip([a, b, c, d, e, f, g, h]) {
+            *slot = slot.wrapping_add(value);
+        }
+    }
+
+    let mut digest = [0u8; 32];
+    for (chunk, word) in digest.chunks_mut(4).zip(state) {
+        chunk.copy_from_slice(&word.to_be_bytes());
+    }
+    digest
+}
+
+/// Computes HMAC-SHA256 (RFC 2104) of `message` under `key`.
+pub(crate) fn hmac_sha256(key: &[u8], message: &[u8]) -> [u8; 32] {
+    let mut block = [0u8; 64];
+    if key.len() > 64 {
+        block[..32].copy_from_slice(&sha256(key));
+    } else {
+        block[..key.len()].copy_from_slice(key);
+    }
+
+    let mut inner: Vec<u8> = block.iter().map(|b| b ^ 0x36).collect();
+    inner.extend_from_slice(message);
+    let mut outer: Vec<u8> = block.iter().map(|b| b ^ 0x5c).collect();
+    outer.extend_from_slice(&sha256(&inner));
+    sha256(&outer)
+}
+
+/// Unsigned big integer with little-endian 64-bit limbs.
+///
+/// Just enough arithmetic to generate RSA test keys and sign with them.
+/// Values are kept normalized (no high zero limbs), so zero has no limbs.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(crate) struct BigUint {
+    limbs: Vec<u64>,
+}
+
+impl BigUint {
+    fn from_limbs(mut limbs: Vec<u64>) -> Self {
+        while limbs.last() == Some(&0) {
+            limbs.pop();
+        }
+        BigUint { limbs }
+    }
+
+    pub(crate) fn from_u64(value: u64) -> Self {
+        BigUint::from_limbs(vec![value])
+    }
+
+    pub(crate) fn from_bytes_be(bytes: &[u8]) -> Self {
+        let limbs = bytes
+            .rchunks(8)
+            .map(|chunk| chunk.iter().fold(0u64, |acc, &b| acc << 8 | b as u64))
+            .collect();
+        BigUint::from_limbs(limbs)
+    }
+
+    /// Minimal big-endian bytes; zero encodes as a single `0x00`.
+    pub(crate) fn to_bytes_be(&self) -> Vec<u8> {
+        let mut bytes: Vec<u8> = self
+            .limbs
+            .iter()
+            .rev()
+            .flat_map(|limb| limb.to_be_bytes())
+            .skip_while(|&b| b == 0)
+            .collect();
+        if bytes.is_empty() {
+            bytes.push(0);
+        }
+        bytes
+    }
+
+    fn bits(&self) -> usize {
+        match self.limbs.last() {
+            Some(top) => self.limbs.len() * 64 - top.leading_zeros() as usize,
+            None => 0,
+        }
+    }
+
+    fn bit(&self, i: usize) -> bool {
+        self.limbs
+            .get(i / 64)
+            .is_some_and(|limb| limb >> (i % 64) & 1 == 1)
+    }
+
+    fn is_zero(&self) -> bool {
+        self.limbs.is_empty()
+    }
+
+    pub(crate) fn add(&self, other: &Self) -> Self {
+        let len = self.limbs.len().max(other.limbs.len());
+        let mut limbs = Vec::with_capacity(len + 1);
+        let mut carry = 0u64;
+        for i in 0..len {
+            let a = self.limbs.get(i).copied().unwrap_or(0);
+            let b = other.limbs.get(i).copied().unwrap_or(0);
+            let (sum, c1) = a.overflowing_add(b);
+            let (sum, c2) = sum.overflowing_add(carry);
+            limbs.push(sum);
+            carry = (c1 as u64) + (c2 as u64);
+        }
+        limbs.push(carry);
+        BigUint::from_limbs(limbs)
+    }
+
+    /// `self - other`; panics if `other > self`.
+    pub(crate) fn sub(&self, other: &Self) -> Self {
+        assert!(*self >= *other, "BigUint subtraction underflow");
+        let mut limbs = Vec::with_capacity(self.limbs.len());
+        let mut borrow = 0u64;
+        for (i, &a) in self.limbs.iter().enumerate() {
+            let b = other.limbs.get(i).copied().unwrap_or(0);
+            let (diff, b1) = a.overflowing_sub(b);
+            let (diff, b2) = diff.overflowing_sub(borrow);
+            limbs.push(diff);
+            borrow = (b1 as u64) + (b2 as u64);
+        }
+        BigUint::from_limbs(limbs)
+    }
+
+    pub(crate) fn mul(&self, other: &Self) -> Self {
+        let mut limbs = vec![0u64; self.limbs.len() + other.limbs.len()];
+        for (i, &a) in self.limbs.iter().enumerate() {
+            let mut carry = 0u128;
+            for (j, &b) in other.limbs.iter().enumerate() {
+                let t = limbs[i + j] as u128 + a as u128 * b as u128 + carry;
+                limbs[i + j] = t as u64;
+                carry = t >> 64;
+            }
+            limbs[i + other.limbs.len()] = carry as u64;
+        }
+        BigUint::from_limbs(limbs)
+    }
+
+    /// Returns `(self / divisor, self % divisor)` for a single-limb divisor.
+    fn div_rem_u64(&self, divisor: u64) -> (Self, u64) {
+        let mut quotient = vec![0u64; self.limbs.len()];
+        let mut rem = 0u128;
+        for (i, &limb) in self.limbs.iter().enumerate().rev() {
+            let cur = rem << 64 | limb as u128;
+            quotient[i] = (cur / divisor as u128) as u64;
+            rem = cur % divisor as u128;
+        }
+        (BigUint::from_limbs(quotient), rem as u64)
+    }
+
+    /// `self % modulus` by binary long division. Slow, but only used for
+    /// one-off key setup, never per multiplication.
+    pub(crate) fn rem(&self, modulus: &Self) -> Self {
+        assert!(!modulus.is_zero(), "BigUint remainder by zero");
+        let mut r = vec![0u64; modulus.limbs.len() + 1];
+        for i in (0..self.bits()).rev() {
+            let mut carry = self.bit(i) as u64;
+            for limb in r.iter_mut() {
+                let next = *limb >> 63;
+                *limb = *limb << 1 | carry;
+                carry = next;
+            }
+            if !limbs_lt(&r, &modulus.limbs) {
+                limbs_sub_assign(&mut r, &modulus.limbs);
+            }
+        }
+        BigUint::from_limbs(r)
+    }
+
+    /// `self^exponent mod modulus`; `modulus` must be odd.
+    pub(crate) fn modpow(&self, exponent: &Self, modulus: &Self) -> Self {
+        let ctx = Montgomery::new(modulus);
+        ctx.decode(&ctx.pow(&ctx.encode(self), exponent))
+    }
+}
+
+/// `a < b` for little-endian limb slices of any length.
+fn limbs_lt(a: &[u64], b: &[u64]) -> bool {
+    let len = a.len().max(b.len());
+    for i in (0..len).rev() {
+        let (x, y) = (
+            a.get(i).copied().unwrap_or(0),
+            b.get(i).copied().unwrap_or(0),
+        );
+        if x != y {
+            return x < y;
+        }
+    }
+    false
+}
+
+/// `a -= b` in place; the caller guarantees `a >= b`.
+fn limbs_sub_assign(a: &mut [u64], b: &[u64]) {
+    let mut borrow = 0u64;
+    for (i, limb) in a.iter_mut().enumerate() {
+        let (diff, b1) = limb.overflowing_sub(b.get(i).copied().unwrap_or(0));
+        let (diff, b2) = diff.overflowing_sub(borrow);
+        *limb = diff;
+        borrow = (b1 as u64) + (b2 as u64);
+    }
+}
+
+impl PartialOrd for BigUint {
+    fn partial_cmp(&self, other: &Self) -> Option<std::cmp::Ordering> {
+        Some(self.cmp(other))
+    }
+}
+
+impl Ord for BigUint {
+    fn cmp(&self, other: &Self) -> std::cmp::Ordering {
+        self.limbs
+            .len()
+            .cmp(&other.limbs.len())
+            .then_with(|| self.limbs.iter().rev().cmp(other.limbs.iter().rev()))
+    }
+}
+
+/// Montgomery multiplication context for a fixed odd modulus.
+struct Montgomery {
+    n: Vec<u64>,
+    n0_inv: u64,
+    r2: Vec<u64>,
+}
+
+impl Montgomery {
+    fn new(modulus: &BigUint) -> Self {
+        assert!(modulus.bit(0), "Montgomery modulus must be odd");
+        let n = modulus.limbs.clone();
+
+        // Newton iteration for n[0]^-1 mod 2^64, negated.
+        let mut inv = 1u64;
+        for _ in 0..6 {
+            inv = inv.wrapping_mul(2u64.wrapping_sub(n[0].wrapping_mul(inv)));
+        }
+
+        let mut r2 = vec![0u64; 2 * n.len()];
+        r2.push(1);
+        let r2 = Montgomery::pad(&BigUint::from_limbs(r2).rem(modulus), n.len());
+        Montgomery {
+            n0_inv: inv.wrapping_neg(),
+            n,
+            r2,
+        }
+    }
+
+    fn pad(value: &BigUint, len: usize) -> Vec<u64> {
+        let mut limbs = value.limbs.clone();
+        limbs.resize(len, 0);
+        limbs
+    }
+
+    /// Computes `a * b * R^-1 mod n` (CIOS).
+    fn mul(&self, a: &[u64], b: &[u64]) -> Vec<u64> {
+        let k = self.n.len();
+        let mut t = vec![0u64; k + 2];
+        for &bi in b {
+            // The products below cannot overflow u128; wrapping ops just skip
+            // the overflow checks that make debug builds crawl.
+            let mut carry = 0u64;
+            for (tj, &aj) in t.iter_mut().zip(a) {
+                let s = (aj as u128)
+                    .wrapping_mul(bi as u128)
+                    .wrapping_add(*tj as u128)
+                    .wrapping_add(carry as u128);
+                *tj = s as u64;
+                carry = (s >> 64) as u64;
+            }
+            let (sum, overflow) = t[k].overflowing_add(carry);
+            t[k] = sum;
+            t[k + 1] = overflow as u64;
+
+            let m = t[0].wrapping_mul(self.n0_inv);
+            let mut carry = ((m as u128)
+                .wrapping_mul(self.n[0] as u128)
+                .wrapping_add(t[0] as u128)
+                >> 64) as u64;
+            for j in 1..k {
+                let s = (m as u128)
+                    .wrapping_mul(self.n[j] as u128)
+                    .wrapping_add(t[j] as u128)
+                    .wrapping_add(carry as u128);
+                t[j - 1] = s as u64;
+                carry = (s >> 64) as u64;
+            }
+            let (sum, overflow) = t[k].overflowing_add(carry);
+            t[k - 1] = sum;
+            t[k] = t[k + 1] + overflow as u64;
+        }
+
+        t.truncate(k + 1);
+        if !limbs_lt(&t, &self.n) {
+            limbs_sub_assign(&mut t, &self.n);
+        }
+        t.truncate(k);
+        t
+    }
+
+    fn encode(&self, value: &BigUint) -> Vec<u64> {
+        let n = BigUint {
+            limbs: self.n.clone(),
+        };
+        let reduced = if *value >= n {
+            value.rem(&n)
+        } else {
+            value.clone()
+        };
+        self.mul(&Montgomery::pad(&reduced, self.n.len()), &self.r2)
+    }
+
+    fn decode(&self, value: &[u64]) -> BigUint {
+        let mut one = vec![0u64; self.n.len()];
+        one[0] = 1;
+        BigUint::from_limbs(self.mul(value, &one))
+    }
+
+    fn pow(&self, base: &[u64], exponent: &BigUint) -> Vec<u64> {
+        let mut acc = self.encode(&BigUint::from_u64(1));
+        for i in (0..exponent.bits()).rev() {
+            acc = self.mul(&acc, &acc);
+            if exponent.bit(i) {
+                acc = self.mul(&acc, base);
+            }
+        }
+        acc
+    }
+}
+
+/// Odd primes below 10000, for trial division before Miller-Rabin.
+fn small_primes() -> Vec<u64> {
+    (3..10_000u64)
+        .step_by(2)
+        .filter(|&n| {
+            (3..)
+                .step_by(2)
+                .take_while(|d| d * d <= n)
+                .all(|d| n % d != 0)
+        })
+        .collect()
+}
+
+/// Uniformly random odd `bits`-bit integer with the top two bits set.
+fn random_candidate(bits: usize, rng: &mut SimpleRng) -> BigUint {
+    let mut limbs: Vec<u64> = (0..bits.div_ceil(64)).map(|_| rng.next()).collect();
+    let top = (bits - 1) % 64;
+    let last = limbs.len() - 1;
+    limbs[last] &= u64::MAX >> (63 - top);
+    limbs[last] |= 1 << top;
+    if top > 0 {
+        limbs[last] |= 1 << (top - 1);
+    } else {
+        limbs[last - 1] |= 1 << 63;
+    }
+    limbs[0] |= 1;
+    BigUint::from_limbs(limbs)
+}
+
+/// Miller-Rabin with base 2 followed by `rounds` random bases.
+fn is_probable_prime(n: &BigUint, rounds: usize, rng: &mut SimpleRng) -> bool {
+    let ctx = Montgomery::new(n);
+    let one = ctx.encode(&BigUint::from_u64(1));
+    let n_minus_one = n.sub(&BigUint::from_u64(1));
+    let minus_one = ctx.encode(&n_minus_one);
+
+    let mut d = n_minus_one.clone();
+    let mut s = 0;
+    while !d.bit(0) {
+        d = d.div_rem_u64(2).0;
+        s += 1;
+    }
+
+    (0..=rounds).all(|round| {
+        let base = if round == 0 {
+            BigUint::from_u64(2)
+        } else {
+            let limbs = (0..n.limbs.len()).map(|_| rng.next()).collect();
+            BigUint::from_limbs(limbs)
+                .rem(&n_minus_one.sub(&BigUint::from_u64(2)))
+                .add(&BigUint::from_u64(2))
+        };
+        let mut x = ctx.pow(&ctx.encode(&base), &d);
+        if x == one || x == minus_one {
+            return true;
+        }
+        for _ in 1..s {
+            x = ctx.mul(&x, &x);
+            if x == minus_one {
+                return true;
+            }
+        }
+        false
+    })
+}
+
+/// Generates a `bits`-bit prime `p` with `gcd(p - 1, e) == 1`.
+///
+/// `bits` must be large enough that no candidate is itself a small prime.
+fn random_prime(bits: usize, e: u64, rng: &mut SimpleRng) -> BigUint {
+    let primes = small_primes();
+    loop {
+        let mut candidate = random_candidate(bits, rng);
+        // Walk forward from the random start, stepping the small-prime
+        // residues along with it instead of re-dividing each candidate.
+        let mut residues: Vec<u64> = primes.iter().map(|&p| candidate.div_rem_u64(p).1).collect();
+        let mut e_residue = candidate.div_rem_u64(e).1;
+        for _ in 0..4096 {
+            if residues.iter().all(|&r| r != 0)
+                && e_residue != 1
+                && is_probable_prime(&candidate, 4, rng)
+            {
+                return candidate;
+            }
+            candidate = candidate.add(&BigUint::from_u64(2));
+            for (r, &p) in residues.iter_mut().zip(&primes) {
+                *r = (*r + 2) % p;
+            }
+            e_residue = (e_residue + 2) % e;
+        }
+    }
+}
+
+/// Inverse of `a` modulo `m` for single-limb values, if it exists.
+fn mod_inverse_u64(a: u64, m: u64) -> Option<u64> {
+    let (mut old_r, mut r) = (a as i128, m as i128);
+    let (mut old_s, mut s) = (1i128, 0i128);
+    while r != 0 {
+        let q = old_r / r;
+        (old_r, r) = (r, old_r - q * r);
+        (old_s, s) = (s, old_s - q * s);
+    }
+    (old_r == 1).then(|| old_s.rem_euclid(m as i128) as u64)
+}
+
+/// RSA private key with CRT parameters, named as in RFC 7518 §6.3.
+#[derive(Debug, Clone)]
+pub(crate) struct RsaKey {
+    pub(crate) n: BigUint,
+    pub(crate) e: BigUint,
+    pub(crate) d: BigUint,
+    pub(crate) p: BigUint,
+    pub(crate) q: BigUint,
+    pub(crate) dp: BigUint,
+    pub(crate) dq: BigUint,
+    pub(crate) qi: BigUint,
+}
+
+/// Generates an RSA key with public exponent 65537.
+///
+/// Randomness comes from [`SimpleRng`], so these keys are for signing test
+/// tokens only and must never protect anything.
+pub(crate) fn rsa_generate(bits: usize) -> RsaKey {
+    const E: u64 = 65537;
+    let mut rng = SimpleRng::new();
+    let one = BigUint::from_u64(1);
+
+    let p = random_prime(bits / 2, E, &mut rng);
+    let q = loop {
+        let q = random_prime(bits - bits / 2, E, &mut rng);
+        if q != p {
+            break q;
+        }
+    };
+    let (p_1, q_1) = (p.sub(&one), q.sub(&one));
+    let phi = p_1.mul(&q_1);
+
+    // d = (k * phi + 1) / e, with k chosen so that e divides the numerator.
+    let inv = mod_inverse_u64(phi.div_rem_u64(E).1, E).expect("p and q are chosen coprime to e");
+    let k = (E - inv) % E;
+    let d = phi.mul(&BigUint::from_u64(k)).add(&one).div_rem_u64(E).0;
+
+    RsaKey {
+        n: p.mul(&q),
+        e: BigUint::from_u64(E),
+        dp: d.rem(&p_1),
+        dq: d.rem(&q_1),
+        qi: q.modpow(&p.sub(&BigUint::from_u64(2)), &p),
+        d,
+        p,
+        q,
+    }
+}
+
+/// EMSA-PKCS1-v1_5 encoding of SHA-256(`message`) into `len` bytes.
+pub(crate) fn pkcs1_sha256_encode(message: &[u8], len: usize) -> Vec<u8> {
+    const DIGEST_INFO: [u8; 19] = [
+        0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01,
+        0x05, 0x00, 0x04, 0x20,
+    ];
+    let t_len = DIGEST_INFO.len() + 32;
+    let mut encoded = vec![0x00, 0x01];
+    encoded.resize(len.saturating_sub(t_len + 1).max(2), 0xff);
+    encoded.push(0x00);
+    encoded.extend_from_slice(&DIGEST_INFO);
+    encoded.extend_from_slice(&sha256(message));
+    encoded
+}
+
+/// RSASSA-PKCS1-v1_5 signature with SHA-256 (the JWS `RS256` algorithm).
+pub(crate) fn rsa_sign_sha256(key: &RsaKey, message: &[u8]) -> Vec<u8> {
+    let len = key.n.to_bytes_be().len();
+    let m = BigUint::from_bytes_be(&pkcs1_sha256_encode(message, len));
+
+    // CRT: combine m^dp mod p and m^dq mod q.
+    let m1 = m.modpow(&key.dp, &key.p);
+    let m2 = m.modpow(&key.dq, &key.q);
+    let diff = m1.add(&key.p).sub(&m2.rem(&key.p)).rem(&key.p);
+    let h = key.qi.mul(&diff).rem(&key.p);
+    let s = m2.add(&h.mul(&key.q));
+
+    let mut signature = s.to_bytes_be();
+    while signature.len() < len {
+        signature.insert(0, 0);
+    }
+    signature
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn hex(bytes: &[u8]) -> String {
+        bytes.iter().map(|b| format!("{:02x}", b)).collect()
+    }
+
+    #[test]
+    fn test_sha256_vectors() {
+        assert_eq!(
+            hex(&sha256(b"")),
+            "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+        );
+        assert_eq!(
+            hex(&sha256(b"abc")),
+            "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+        );
+        assert_eq!(
+            hex(&sha256(
+                b"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq"
+            )),
+            "248d6a61d20638b8e5c026930c3e6039a33ce45964ff2167f6ecedd419db06c1"
+        );
+    }
+
+    #[test]
+    fn test_hmac_sha256_rfc4231() {
+        assert_eq!(
+            hex(&hmac_sha256(b"Jefe", b"what do ya want for nothing?")),
+            "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+        );
+        // Key longer than the block size is hashed first
+        assert_eq!(
+            hex(&hmac_sha256(
+                &[0xaa; 131],
+                b"Test Using Larger Than Block-Size Key - Hash Key First"
+            )),
+            "60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54"
+        );
+    }
+
+    #[test]
+    fn test_biguint_bytes_roundtrip() {
+        let bytes = [0x01, 0x00, 0xff, 0x10, 0x20, 0x30, 0x40, 0x50, 0x60, 0x70];
+        assert_eq!(BigUint::from_bytes_be(&bytes).to_bytes_be(), bytes);
+        assert_eq!(BigUint::from_bytes_be(&[0, 0, 7]).to_bytes_be(), [7]);
+        assert_eq!(BigUint::from_bytes_be(&[]).to_bytes_be(), [0]);
+    }
+
+    #[test]
+    fn test_biguint_arithmetic_matches_u128() {
+        let a: u128 = 0xfedc_ba98_7654_3210_0123_4567_89ab_cdef;
+        let b: u128 = 0x0000_0000_ffff_ffff_ffff_ffff_0000_0001;
+        let big = |v: u128| BigUint::from_bytes_be(&v.to_be_bytes());
+        assert_eq!(big(a).add(&big(b)), big(a.wrapping_add(b)));
+        assert_eq!(big(a).sub(&big(b)), big(a - b));
+        assert_eq!(big(a).rem(&big(b)), big(a % b));
+        assert_eq!(
+            BigUint::from_u64(u64::MAX).mul(&BigUint::from_u64(u64::MAX)),
+            big(u64::MAX as u128 * u64::MAX as u128)
+        );
+    }
+
+    #[test]
+    fn test_biguint_modpow() {
+        // 4^13 mod 497 = 445
+        let r = BigUint::from_u64(4).modpow(&BigUint::from_u64(13), &BigUint::from_u64(497));
+        assert_eq!(r, BigUint::from_u64(445));
+        // Fermat: a^(p-1) = 1 mod p for the Mersenne prime 2^127 - 1
+        let p = BigUint::from_bytes_be(&(u128::MAX >> 1).to_be_bytes());
+        let e = p.sub(&BigUint::from_u64(1));
+        assert_eq!(BigUint::from_u64(3).modpow(&e, &p), BigUint::from_u64(1));
+    }
+
+    #[test]
+    fn test_is_probable_prime() {
+        let mut rng = SimpleRng::new();
+        let mersenne = BigUint::from_bytes_be(&(u128::MAX >> 1).to_be_bytes());
+        assert!(is_probable_prime(&mersenne, 4, &mut rng));
+        // 2^128 - 1 and the Carmichael number 561 are composite
+        let composite = BigUint::from_bytes_be(&u128::MAX.to_be_bytes());
+        assert!(!is_probable_prime(&composite, 4, &mut rng));
+        assert!(!is_probable_prime(&BigUint::from_u64(561), 4, &mut rng));
+    }
+
+    #[test]
+    fn test_rsa_generate_and_sign() {
+        let key = rsa_generate(512);
+        assert_eq!(key.n.bits(), 512);
+        assert_eq!(key.p.mul(&key.q), key.n);
+
+        let signature = rsa_sign_sha256(&key, b"header.payload");
+        assert_eq!(signature.len(), 64);
+        let recovered = BigUint::from_bytes_be(&signature).modpow(&key.e, &key.n);
+        assert_eq!(
+            recovered,
+            BigUint::from_bytes_be(&pkcs1_sha256_encode(b"header.payload", 64))
+        );
+    }
+}
@@ -0,0 +1,412 @@
+use std::collections::HashMap;
+
+/// Base lengths for length codes 257..=285 (RFC 1951 §3.2.5).
+const LENGTH_BASE: [u16; 29] = [
+    3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131,
+    163, 195, 227, 258,
+];
+const LENGTH_EXTRA: [u8; 29] = [
+    0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0,
+];
+/// Base distances for distance codes 0..=29.
+const DIST_BASE: [u16; 30] = [
+    1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537,
+    2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577,
+];
+const DIST_EXTRA: [u8; 30] = [
+    0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13,
+    13,
+];
+/// Order in which code length code lengths are stored in a dynamic block.
+const CODE_LENGTH_ORDER: [usize; 19] = [
+    16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15,
+];
+
+const WINDOW: usize = 32 * 1024;
+const MAX_MATCH: usize = 258;
+/// Candidates examined per position; trades ratio for speed.
+const MAX_CHAIN: usize = 64;
+
+struct BitWriter {
+    bytes: Vec<u8>,
+    bit: u32,
+    bits: u32,
+}
+
+impl BitWriter {
+    fn new() -> Self {
+        BitWriter {
+            bytes: Vec::new(),
+            bit: 0,
+            bits: 0,
+        }
+    }
+
+    /// Writes `count` bits of `value`, least significant first.
+    fn write(&mut self, value: u32, count: u32) {
+        self.bits |= value << self.bit;
+        self.bit += count;
+        while self.bit >= 8 {
+            self.bytes.push(self.bits as u8);
+            self.bits >>= 8;
+            self.bit -= 8;
+        }
+    }
+
+    /// Writes a Huffman code, which is packed most significant bit first.
+    fn write_code(&mut self, code: u32, len: u32) {
+        self.write(code.reverse_bits() >> (32 - len), len);
+    }
+
+    fn finish(mut self) -> Vec<u8> {
+        if self.bit > 0 {
+            self.bytes.push(self.bits as u8);
+        }
+        self.bytes
+    }
+}
+
+/// Emits a literal/length symbol using the fixed Huffman code.
+fn write_fixed_symbol(out: &mut BitWriter, symbol: u16) {
+    let symbol = symbol as u32;
+    match symbol {
+        0..=143 => out.write_code(0x30 + symbol, 8),
+        144..=255 => out.write_code(0x190 + symbol - 144, 9),
+        256..=279 => out.write_code(symbol - 256, 7),
+        _ => out.write_code(0xc0 + symbol - 280, 8),
+    }
+}
+
+/// Finds the table index whose base covers `value`.
+fn code_index(bases: &[u16], value: usize) -> usize {
+    bases
+        .iter()
+        .rposition(|&base| base as usize <= value)
+        .unwrap_or(0)
+}
+
+/// Compresses `data` as a single fixed-Huffman raw DEFLATE block.
+///
+/// Greedy LZ77 over a hash chain; not zlib's ratio, but valid output any
+/// inflater accepts and good enough for XML that repeats itself.
+pub(crate) fn deflate(data: &[u8]) -> Vec<u8> {
+    let mut out = BitWriter::new();
+    out.write(1, 1); // BFINAL
+    out.write(1, 2); // BTYPE = fixed Huffman
+
+    let mut chains: HashMap<[u8; 3], Vec<usize>> = HashMap::new();
+    let mut i = 0;
+    while i < data.len() {
+        let mut best = (0, 0);
+        if i + 3 <= data.len() {
+            let key = [data[i], data[i + 1], data[i + 2]];
+            if let Some(candidates) = chains.get(&key) {
+                for &start in candidates.iter().rev().take(MAX_CHAIN) {
+                    if i - start > WINDOW {
+                        break;
+                    }
+                    let len = data[start..]
+                        .iter()
+                        .zip(&data[i..])
+                        .take(MAX_MATCH)
+                        .take_while(|(a, b)| a == b)
+                        .count();
+                    if len > best.0 {
+                        best = (len, i - start);
+                    }
+                }
+            }
+        }
+
+        let step = if best.0 >= 3 {
+            let (len, dist) = best;
+            let li = code_index(&LENGTH_BASE, len);
+            write_fixed_symbol(&mut out, 257 + li as u16);
+            out.write(
+                (len - LENGTH_BASE[li] as usize) as u32,
+                LENGTH_EXTRA[li] as u32,
+            );
+            let di = code_index(&DIST_BASE, dist);
+            out.write_code(di as u32, 5);
+            out.write(
+                (dist - DIST_BASE[di] as usize) as u32,
+                DIST_EXTRA[di] as u32,
+            );
+            len
+        } else {
+            write_fixed_symbol(&mut out, data[i] as u16);
+            1
+        };
+
+        for pos in i..i + step {
+            if pos + 3 <= data.len() {
+                let key = [data[pos], data[pos + 1], data[pos + 2]];
+                chains.entry(key).or_default().push(pos);
+            }
+        }
+        i += step;
+    }
+
+    write_fixed_symbol(&mut out, 256);
+    out.finish()
+}
+
+/// CRC-32 (IEEE 802.3, reflected), as used by gzip.
+fn crc32(data: &[u8]) -> u32 {
+    let mut crc = !0u32;
+    for &byte in data {
+        crc ^= byte as u32;
+        for _ in 0..8 {
+            crc = (crc >> 1) ^ (0xedb8_8320 & (crc & 1).wrapping_neg());
+        }
+    }
+    !crc
+}
+
+/// Wraps [`deflate`] output in a gzip member (RFC 1952) with no file name
+/// and a zero timestamp, so equal input gives equal output.
+pub(crate) fn gzip(data: &[u8]) -> Vec<u8> {
+    // magic, CM = deflate, no flags, MTIME = 0, XFL = 0, OS = unknown
+    let mut out = vec![0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 0xff];
+    out.extend(deflate(data));
+    out.extend(crc32(data).to_le_bytes());
+    out.extend((data.len() as u32).to_le_bytes());
+    out
+}
+
+struct BitReader<'a> {
+    data: &'a [u8],
+    pos: usize,
+}
+
+impl BitReader<'_> {
+    fn bit(&mut self) -> Option<u32> {
+        let byte = *self.data.get(self.pos / 8)?;
+        let bit = (byte >> (self.pos % 8)) & 1;
+        self.pos += 1;
+        Some(bit as u32)
+    }
+
+    fn bits(&mut self, count: u8) -> Option<u32> {
+        (0..count).try_fold(0, |acc, i| Some(acc | self.bit()? << i))
+    }
+}
+
+/// Canonical Huffman decoding table, as in zlib's `puff.c`.
+struct Huffman {
+    counts: [u16; 16],
+    symbols: Vec<u16>,
+}
+
+impl Huffman {
+    fn new(lengths: &[u8]) -> Self {
+        let mut counts = [0u16; 16];
+        for &len in lengths {
+            counts[len as usize] += 1;
+        }
+        counts[0] = 0;
+
+        let mut offsets = [0u16; 16];
+        for len in 1..15 {
+            offsets[len + 1] = offsets[len] + counts[len];
+        }
+        let mut symbols = vec![0u16; lengths.len()];
+        for (symbol, &len) in lengths.iter().enumerate() {
+            if len != 0 {
+                symbols[offsets[len as usize] as usize] = symbol as u16;
+                offsets[len as usize] += 1;
+            }
+        }
+        Huffman { counts, symbols }
+    }
+
+    fn decode(&self, input: &mut BitReader) -> Option<u16> {
+        let (mut code, mut first, mut index) = (0i32, 0i32, 0i32);
+        for len in 1..16 {
+            code |= input.bit()? as i32;
+            let count = self.counts[len] as i32;
+            if code - count < first {
+                return self.symbols.get((index + code - first) as usize).copied();
+            }
+            index += count;
+            first = (first + count) << 1;
+            code <<= 1;
+        }
+        None
+    }
+}
+
+fn fixed_tables() -> (Huffman, Huffman) {
+    let mut lengths = [0u8; 288];
+    lengths[..144].fill(8);
+    lengths[144..256].fill(9);
+    lengths[256..280].fill(7);
+    lengths[280..].fill(8);
+    (Huffman::new(&lengths), Huffman::new(&[5; 30]))
+}
+
+fn dynamic_tables(input: &mut BitReader) -> Option<(Huffman, Huffman)> {
+    let nlen = input.bits(5)? as usize + 257;
+    let ndist = input.bits(5)? as usize + 1;
+    let ncode = input.bits(4)? as usize + 4;
+
+    let mut code_lengths = [0u8; 19];
+    for &index in &CODE_LENGTH_ORDER[..ncode] {
+        code_lengths[index] = input.bits(3)? as u8;
+    }
+    let code_table = Huffman::new(&code_lengths);
+
+    let mut lengths = Vec::with_capacity(nlen + ndist);
+    while lengths.len() < nlen + ndist {
+        let (value, repeat) = match code_table.decode(input)? {
+            symbol @ 0..=15 => (symbol as u8, 1),
+            16 => (*lengths.last()?, 3 + input.bits(2)?),
+            17 => (0, 3 + input.bits(3)?),
+            18 => (0, 11 + input.bits(7)?),
+            _ => return None,
+        };
+        lengths.extend(std::iter::repeat_n(value, repeat as usize));
+    }
+    if lengths.len() != nlen + ndist {
+        return None;
+    }
+    Some((
+        Huffman::new(&lengths[..nlen]),
+        Huffman::new(&lengths[nlen..]),
+    ))
+}
+
+/// Decompresses raw DEFLATE (RFC 1951) data; `None` on malformed input or
+/// when the output would grow past `limit` bytes.
+pub(crate) fn inflate(data: &[u8], limit: usize) -> Option<Vec<u8>> {
+    let mut input = BitReader { data, pos: 0 };
+    let mut out = Vec::with_capacity(data.len().saturating_mul(4).min(limit));
+
+    loop {
+        let last = input.bit()? == 1;
+        match input.bits(2)? {
+            0 => {
+                input.pos = input.pos.div_ceil(8) * 8;
+                let start = input.pos / 8;
+                let header = data.get(start..start + 4)?;
+                let len = u16::from_le_bytes([header[0], header[1]]);
+                let nlen = u16::from_le_bytes([header[2], header[3]]);
+                if len != !nlen || out.len() + len as usize > limit {
+                    return None;
+                }
+                out.extend_from_slice(data.get(start + 4..start + 4 + len as usize)?);
+                input.pos = (start + 4 + len as usize) * 8;
+            }
+            btype @ (1 | 2) => {
+                let (lit, dist) = if btype == 1 {
+                    fixed_tables()
+                } else {
+                    dynamic_tables(&mut input)?
+                };
+                loop {
+                    let symbol = lit.decode(&mut input)? as usize;
+                    match symbol {
+                        0..=255 if out.len() >= limit => return None,
+                        0..=255 => out.push(symbol as u8),
+                        256 => break,
+                        _ => {
+                            let li = symbol - 257;
+                            let len = *LENGTH_BASE.get(li)? as usize
+                                + input.bits(LENGTH_EXTRA[li])? as usize;
+                            let di = dist.decode(&mut input)? as usize;
+                            let distance =
+                                *DIST_BASE.get(di)? as usize + input.bits(DIST_EXTRA[di])? as usize;
+                            let start = out.len().checked_sub(distance)?;
+                            if out.len() + len > limit {
+                                return None;
+                            }
+                            for k in 0..len {
+                                out.push(out[start + k]);
+                            }
+                        }
+                    }
+                }
+            }
+            _ => return None,
+        }
+        if last {
+            return Some(out);
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_deflate_roundtrip() {
+        let long = "<saml:Attribute>value</saml:Attribute>".repeat(200);
+        for input in ["", "a", "hello hello hello hello", long.as_str()] {
+            let compressed = deflate(input.as_bytes());
+            assert_eq!(inflate(&compressed, usize::MAX).unwrap(), input.as_bytes());
+        }
+    }
+
+    #[test]
+    fn test_deflate_compresses_repetition() {
+        let input = "<saml:Attribute>value</saml:Attribute>".repeat(200);
+        assert!(deflate(input.as_bytes()).len() < input.len() / 10);
+    }
+
+    #[test]
+    fn test_deflate_roundtrip_binary() {
+        let input: Vec<u8> = (0..5000u32).map(|i| (i * 7 % 251) as u8).collect();
+        assert_eq!(inflate(&deflate(&input), usize::MAX).unwrap(), input);
+    }
+
+    #[test]
+    fn test_inflate_zlib_fixed_block() {
+        // zlib raw deflate of "hello hello hello hello"
+        let data = [203, 72, 205, 201, 201, 87, 200, 64, 39, 1];
+        assert_eq!(
+            inflate(&data, usize::MAX).unwrap(),
+            b"hello hello hello hello"
+        );
+    }
+
+    #[test]
+    fn test_inflate_stored_block() {
+        let data = [1, 3, 0, 0xfc, 0xff, b'a', b'b', b'c'];
+        assert_eq!(inflate(&data, usize::MAX).unwrap(), b"abc");
+    }
+
+    #[test]
+    fn test_inflate_limit() {
+        let data = [203, 72, 205, 201, 201, 87, 200, 64, 39, 1];
+        assert_eq!(inflate(&data, 23).unwrap(), b"hello hello hello hello");
+        assert!(inflate(&data, 22).is_none());
+        assert!(inflate(&data, 3).is_none());
+        assert!(inflate(&[1, 3, 0, 0xfc, 0xff, b'a', b'b', b'c'], 2).is_none());
+    }
+
+    #[test]
+    fn test_inflate_rejects_malformed() {
+        assert!(inflate(&[], usize::MAX).is_none());
+        assert!(inflate(&[0x07], usize::MAX).is_none()); // reserved block type
+        assert!(inflate(&[1, 3, 0, 0, 0, b'a'], usize::MAX).is_none()); // bad NLEN
+    }
+
+    #[test]
+    fn test_crc32_check_value() {
+        assert_eq!(crc32(b"123456789"), 0xcbf4_3926);
+        assert_eq!(crc32(b""), 0);
+    }
+
+    #[test]
+    fn test_gzip_member_layout() {
+        let data = b"hello hello hello hello";
+        let member = gzip(data);
+        assert_eq!(member[..4], [0x1f, 0x8b, 8, 0]);
+        let body = &member[10..member.len() - 8];
+        assert_eq!(inflate(body, usize::MAX).unwrap(), data);
+        let trailer = &member[member.len() - 8..];
+        assert_eq!(trailer[..4], crc32(data).to_le_bytes());
+        assert_eq!(trailer[4..], (data.len() as u32).to_le_bytes());
+    }
+}
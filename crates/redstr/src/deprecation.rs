@@ -0,0 +1,57 @@
+use std::sync::{Arc, RwLock};
+
+/// A deprecated transform name that was resolved to its replacement.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct Deprecation {
+    /// The name as written in the spec or recipe.
+    pub name: &'static str,
+    /// The registry name it now resolves to.
+    pub replacement: &'static str,
+}
+
+/// Callback that receives a [`Deprecation`] each time a deprecated name is resolved.
+pub type DeprecationHook = Arc<dyn Fn(&Deprecation) + Send + Sync>;
+
+/// The process-wide deprecation hook, if any.
+static DEPRECATION_HOOK: RwLock<Option<DeprecationHook>> = RwLock::new(None);
+
+/// Installs a hook that is called whenever a deprecated transform name is
+/// resolved, or removes it with `None`.
+///
+/// Deprecated names keep working, so pipelines that store transforms by
+/// name do not break when one is renamed; the hook is how they find out.
+/// It runs once per resolution, on the resolving thread, so a recipe
+/// resolved once by [`Transformer`](crate::Transformer) reports once however
+/// many inputs it processes. The setting is process-wide.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Log which stored payload recipes need updating after an upgrade
+/// - **Blue Team**: Fail CI when a detection corpus still uses deprecated names
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{apply_transform, set_deprecation_hook};
+/// use std::sync::Arc;
+///
+/// set_deprecation_hook(Some(Arc::new(|notice| {
+///     eprintln!("{} is deprecated, use {}", notice.name, notice.replacement);
+/// })));
+/// assert!(apply_transform("cloudflare_challenge", "cf-chl").is_ok());
+/// set_deprecation_hook(None);
+/// ```
+pub fn set_deprecation_hook(hook: Option<DeprecationHook>) {
+    *DEPRECATION_HOOK.write().unwrap_or_else(|e| e.into_inner()) = hook;
+}
+
+/// Reports a resolved deprecated name to the installed hook.
+pub(crate) fn notify(deprecation: &Deprecation) {
+    let hook = DEPRECATION_HOOK
+        .read()
+        .unwrap_or_else(|e| e.into_inner())
+        .clone();
+    if let Some(hook) = hook {
+        hook(deprecation);
+    }
+}
@@ -0,0 +1,132 @@
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::{Arc, RwLock};
+use std::time::{Duration, Instant};
+
+use crate::metrics;
+use crate::rng::capture_seed;
+
+/// One call of a named transform, as reported to the event hook.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct TransformEvent {
+    /// Registry name of the transform.
+    pub name: &'static str,
+    /// Behavior version that ran.
+    pub version: u32,
+    /// Input length in bytes.
+    pub input_len: usize,
+    /// Output length in bytes.
+    pub output_len: usize,
+    /// Wall-clock time spent in the transform (zero on targets without a clock).
+    pub duration: Duration,
+    /// Seed of the first generator the call created, or `None` if it drew
+    /// no randomness.
+    pub seed: Option<u64>,
+}
+
+/// Callback that receives a [`TransformEvent`] after every named transform call.
+pub type EventHook = Arc<dyn Fn(&TransformEvent) + Send + Sync>;
+
+/// The process-wide event hook, if any.
+static EVENT_HOOK: RwLock<Option<EventHook>> = RwLock::new(None);
+
+/// Whether a hook is installed, so calls without one skip the lock.
+static EVENTS_ENABLED: AtomicBool = AtomicBool::new(false);
+
+/// Installs a hook that receives an event after every named transform call,
+/// or removes it with `None`.
+///
+/// Events cover everything that runs through the registry: [`Transform`],
+/// [`apply_transform`], recipes, batches, [`Transformer`] and the FFI. Direct
+/// calls such as `redstr::leetspeak()` are not reported. The hook runs on the
+/// calling thread after the transform returns, so it can log with the
+/// caller's request context; keep it cheap, since it runs once per step.
+/// Without a hook, the only cost is one atomic load per call.
+///
+/// [`Transform`]: crate::Transform
+/// [`apply_transform`]: crate::apply_transform
+/// [`Transformer`]: crate::Transformer
+///
+/// # Use Cases
+///
+/// - **Red Team**: Correlate generated payloads with the requests that carried them
+/// - **Blue Team**: Trace which transforms a long-running test service applies
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{apply_recipe, set_event_hook};
+/// use std::sync::Arc;
+///
+/// set_event_hook(Some(Arc::new(|event| {
+///     eprintln!(
+///         "transform={} version={} input_len={} duration_us={} seed={:?}",
+///         event.name,
+///         event.version,
+///         event.input_len,
+///         event.duration.as_micros(),
+///         event.seed
+///     );
+/// })));
+/// apply_recipe("leetspeak | base64_encode", "password").unwrap();
+/// set_event_hook(None);
+/// ```
+pub fn set_event_hook(hook: Option<EventHook>) {
+    let mut current = EVENT_HOOK.write().unwrap_or_else(|e| e.into_inner());
+    EVENTS_ENABLED.store(hook.is_some(), Ordering::Release);
+    *current = hook;
+}
+
+/// Runs one transform call and reports it to the hook and the metrics
+/// registry, if either is installed. `slot` is the entry's registry index.
+pub(crate) fn observe(
+    slot: usize,
+    name: &'static str,
+    version: u32,
+    input_len: usize,
+    apply: impl FnOnce() -> String,
+) -> String {
+    let mut output = String::new();
+    observe_into(slot, name, version, input_len, &mut output, |out| {
+        *out = apply()
+    });
+    output
+}
+
+/// Like [`observe`], for a call that appends its output to `out`. The
+/// reported output length counts only the appended bytes.
+pub(crate) fn observe_into(
+    slot: usize,
+    name: &'static str,
+    version: u32,
+    input_len: usize,
+    out: &mut String,
+    apply: impl FnOnce(&mut String),
+) {
+    let hook = EVENTS_ENABLED
+        .load(Ordering::Acquire)
+        .then(|| EVENT_HOOK.read().unwrap_or_else(|e| e.into_inner()).clone())
+        .flatten();
+    let metrics = metrics::installed();
+    if hook.is_none() && metrics.is_none() {
+        return apply(out);
+    }
+
+    // `wasm32-unknown-unknown` has no clock; `Instant::now` panics there.
+    let start = (!cfg!(all(target_arch = "wasm32", target_os = "unknown"))).then(Instant::now);
+    let before = out.len();
+    let ((), seed) = capture_seed(|| apply(out));
+    let event = TransformEvent {
+        name,
+        version,
+        input_len,
+        output_len: out.len() - before,
+        duration: start.map_or(Duration::ZERO, |start| start.elapsed()),
+        seed,
+    };
+    if let Some(metrics) = metrics {
+        metrics.record_slot(slot, &event);
+    }
+    if let Some(hook) = hook {
+        hook(&event);
+    }
+}
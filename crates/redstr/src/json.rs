@@ -0,0 +1,199 @@
+/// Quotes and escapes `s` as a JSON string.
+pub(crate) fn json_string(s: &str) -> String {
+    let mut out = String::with_capacity(s.len() + 2);
+    out.push('"');
+    for c in s.chars() {
+        match c {
+            '"' => out.push_str("\\\""),
+            '\\' => out.push_str("\\\\"),
+            '\n' => out.push_str("\\n"),
+            '\r' => out.push_str("\\r"),
+            '\t' => out.push_str("\\t"),
+            c if (c as u32) < 0x20 => out.push_str(&format!("\\u{:04x}", c as u32)),
+            c => out.push(c),
+        }
+    }
+    out.push('"');
+    out
+}
+
+/// Decodes a raw JSON string value, quotes included.
+///
+/// Returns `None` if `raw` is not a single well-formed string.
+pub(crate) fn json_unquote(raw: &str) -> Option<String> {
+    let inner = raw.trim().strip_prefix('"')?.strip_suffix('"')?;
+    let mut out = String::with_capacity(inner.len());
+    let mut chars = inner.chars();
+    while let Some(c) = chars.next() {
+        match c {
+            '"' => return None,
+            '\\' => match chars.next()? {
+                '"' => out.push('"'),
+                '\\' => out.push('\\'),
+                '/' => out.push('/'),
+                'b' => out.push('\u{8}'),
+                'f' => out.push('\u{c}'),
+                'n' => out.push('\n'),
+                'r' => out.push('\r'),
+                't' => out.push('\t'),
+                'u' => {
+                    let hex: String = chars.by_ref().take(4).collect();
+                    let unit = u16::from_str_radix(&hex, 16).ok()?;
+                    if (0xD800..0xDC00).contains(&unit) {
+                        // High surrogate: the low half follows as another \u escape
+                        if chars.next()? != '\\' || chars.next()? != 'u' {
+                            return None;
+                        }
+                        let hex: String = chars.by_ref().take(4).collect();
+                        let low = u16::from_str_radix(&hex, 16).ok()?;
+                        out.push(char::decode_utf16([unit, low]).next()?.ok()?);
+                    } else {
+                        out.push(char::from_u32(unit as u32)?);
+                    }
+                }
+                _ => return None,
+            },
+            c => out.push(c),
+        }
+    }
+    Some(out)
+}
+
+/// Splits the inside of a JSON object or array at its top-level commas.
+///
+/// Returns `None` on unbalanced brackets or an unterminated string.
+fn json_split(inner: &str) -> Option<Vec<&str>> {
+    let mut parts = Vec::new();
+    let mut depth = 0usize;
+    let mut in_string = false;
+    let mut escaped = false;
+    let mut start = 0;
+    for (i, c) in inner.char_indices() {
+        if in_string {
+            match c {
+                _ if escaped => escaped = false,
+                '\\' => escaped = true,
+                '"' => in_string = false,
+                _ => {}
+            }
+            continue;
+        }
+        match c {
+            '"' => in_string = true,
+            '{' | '[' => depth += 1,
+            '}' | ']' => depth = depth.checked_sub(1)?,
+            ',' if depth == 0 => {
+                parts.push(&inner[start..i]);
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    if in_string || depth != 0 {
+        return None;
+    }
+    if !inner[start..].trim().is_empty() || !parts.is_empty() {
+        parts.push(&inner[start..]);
+    }
+    Some(parts)
+}
+
+/// Splits a JSON object into its top-level `(raw key, raw value)` members.
+///
+/// Keys keep their quotes so they can be written back verbatim. Returns
+/// `None` if `object` is not a brace-delimited object with string keys.
+pub(crate) fn json_members(object: &str) -> Option<Vec<(String, String)>> {
+    let inner = object.trim().strip_prefix('{')?.strip_suffix('}')?;
+
+    json_split(inner)?
+        .into_iter()
+        .map(|part| {
+            let part = part.trim();
+            if !part.starts_with('"') {
+                return None;
+            }
+            // The key is a string, so the first quote-terminated run ends it.
+            let mut escaped = false;
+            let key_end = part[1..].char_indices().find_map(|(i, c)| match c {
+                _ if escaped => {
+                    escaped = false;
+                    None
+                }
+                '\\' => {
+                    escaped = true;
+                    None
+                }
+                '"' => Some(i + 2),
+                _ => None,
+            })?;
+            let value = part[key_end..].trim_start().strip_prefix(':')?.trim();
+            Some((part[..key_end].to_string(), value.to_string()))
+        })
+        .collect()
+}
+
+/// Splits a JSON array into its raw top-level elements.
+pub(crate) fn json_elements(array: &str) -> Option<Vec<String>> {
+    let inner = array.trim().strip_prefix('[')?.strip_suffix(']')?;
+    Some(
+        json_split(inner)?
+            .into_iter()
+            .map(|part| part.trim().to_string())
+            .collect(),
+    )
+}
+
+/// Renders members back into a compact JSON object.
+pub(crate) fn json_object(members: &[(String, String)]) -> String {
+    let body: Vec<String> = members
+        .iter()
+        .map(|(key, value)| format!("{}:{}", key, value))
+        .collect();
+    format!("{{{}}}", body.join(","))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_json_members_rejects_non_objects() {
+        assert!(json_members("[]").is_none());
+        assert!(json_members(r#"{"a":1"#).is_none());
+        assert!(json_members(r#"{a:1}"#).is_none());
+        assert_eq!(json_members("{}").unwrap(), Vec::new());
+    }
+
+    #[test]
+    fn test_json_string_round_trip() {
+        for text in [
+            "plain",
+            "quo\"te \\ back",
+            "line\nbreak\ttab",
+            "\u{1}ctl",
+            "ünï €",
+        ] {
+            assert_eq!(json_unquote(&json_string(text)).unwrap(), text);
+        }
+    }
+
+    #[test]
+    fn test_json_unquote_escapes() {
+        assert_eq!(json_unquote(r#""a\/b\u0041""#).unwrap(), "a/bA");
+        assert_eq!(json_unquote(r#""\ud83d\ude00""#).unwrap(), "\u{1f600}");
+        assert!(json_unquote(r#""bad\q""#).is_none());
+        assert!(json_unquote(r#""a"b""#).is_none());
+        assert!(json_unquote("12").is_none());
+    }
+
+    #[test]
+    fn test_json_elements() {
+        assert_eq!(
+            json_elements(r#"[1, "a,b", {"c":[2,3]}]"#).unwrap(),
+            vec!["1", "\"a,b\"", "{\"c\":[2,3]}"]
+        );
+        assert_eq!(json_elements("[]").unwrap(), Vec::<String>::new());
+        assert!(json_elements("[1, [2]").is_none());
+        assert!(json_elements("{}").is_none());
+    }
+}
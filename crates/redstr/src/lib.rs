@@ -70,15 +70,171 @@
 //!
 //! ## Transformation Categories
 //!
-//! See individual function documentation for detailed use cases and examples.
+//! See individual function documentation for detailed use cases. The examples
+//! below are compiled and run as doctests, one per transformation family.
+//! Randomized transforms are checked by property rather than exact output.
+//!
+//! ### Case
+//!
+//! ```rust
+//! use redstr::{alternate_case, inverse_case, randomize_capitalization, to_camel_case, to_snake_case};
+//!
+//! assert_eq!(alternate_case("admin"), "AdMiN");
+//! assert_eq!(inverse_case("Admin"), "aDMIN");
+//! assert_eq!(to_camel_case("hello world test"), "helloWorldTest");
+//! assert_eq!(to_snake_case("hello world test"), "hello_world_test");
+//! assert_eq!(randomize_capitalization("select").to_lowercase(), "select");
+//! ```
+//!
+//! ### Encoding
+//!
+//! ```rust
+//! use redstr::{base64_encode, hex_encode, url_encode};
+//!
+//! assert_eq!(base64_encode("hello"), "aGVsbG8=");
+//! assert_eq!(hex_encode("<>"), "3c3e");
+//! assert_eq!(url_encode("' OR 1=1--"), "%27%20OR%201%3D1--");
+//! ```
+//!
+//! ### Unicode
+//!
+//! ```rust
+//! use redstr::{homoglyph_substitution, zalgo_text};
+//!
+//! let spoofed = homoglyph_substitution("admin");
+//! assert_eq!(spoofed.chars().count(), 5);
+//! assert!(zalgo_text("test").chars().count() >= 4);
+//! ```
+//!
+//! ### Injection
+//!
+//! ```rust
+//! use redstr::{path_traversal, sql_comment_injection, xss_tag_variations};
+//!
+//! assert!(sql_comment_injection("SELECT * FROM users").contains("SELECT"));
+//! assert!(!xss_tag_variations("<script>alert(1)</script>").is_empty());
+//! assert!(path_traversal("/etc/passwd").contains("etc"));
+//! ```
+//!
+//! ### Obfuscation
+//!
+//! ```rust
+//! use redstr::{leetspeak, reverse_string, rot13};
+//!
+//! assert_eq!(rot13(&rot13("secret")), "secret");
+//! assert_eq!(reverse_string("cmd"), "dmc");
+//! assert_eq!(leetspeak("password").chars().count(), 8);
+//! ```
+//!
+//! ### Phishing
+//!
+//! ```rust
+//! use redstr::{combosquat, domain_typosquat, keyboard_typos, KeyboardLayout};
+//!
+//! assert!(domain_typosquat("example.com").ends_with(".com"));
+//! assert!(keyboard_typos("go", KeyboardLayout::Qwerty).contains(&"fo".to_string()));
+//! assert!(combosquat("paypal.com", &["login"]).contains(&"paypal-login.com".to_string()));
+//! ```
+//!
+//! ### Bot Detection
+//!
+//! ```rust
+//! use redstr::{accept_language_variation, random_user_agent};
+//!
+//! assert!(random_user_agent().starts_with("Mozilla/5.0"));
+//! assert!(!accept_language_variation("en-US").is_empty());
+//! ```
+//!
+//! ### Web Security
+//!
+//! ```rust
+//! use redstr::{graphql_obfuscate, jwt_signature_bypass};
+//!
+//! assert!(jwt_signature_bypass("header.payload.signature").starts_with("header.payload."));
+//! assert!(graphql_obfuscate("{ users { name } }").to_lowercase().contains("users"));
+//! ```
+//!
+//! ### Shell
+//!
+//! ```rust
+//! use redstr::{bash_obfuscate, powershell_obfuscate};
+//!
+//! assert!(!powershell_obfuscate("Get-Process").is_empty());
+//! assert!(!bash_obfuscate("cat /etc/passwd").is_empty());
+//! ```
+//!
+//! ### Pipelines and Recipes
+//!
+//! ```rust
+//! use redstr::{apply_recipe, TransformBuilder};
+//!
+//! let builder = TransformBuilder::new("hello").rot13().hex_encode();
+//! assert_eq!(builder.recipe(), "rot13@1 | hex_encode@1");
+//! assert_eq!(apply_recipe(&builder.recipe(), "hello").unwrap(), builder.build());
+//! ```
 
+mod batch;
 mod builder;
+mod catalog;
+mod crypto;
+mod deflate;
+mod deprecation;
+mod events;
+mod json;
+mod metrics;
+mod registry;
+mod result;
 mod rng;
+mod score;
+mod session;
+mod trace;
 mod transformations;
+mod transformer;
 
 // Re-export all public functions and types
 pub use builder::TransformBuilder;
 
+// Re-export cancellable and parallel batch transforms
+pub use batch::{
+    apply_batch, apply_batch_ctx, parallel_apply, parallel_apply_ctx, BatchContext, BatchError,
+};
+
+// Re-export reusable-buffer transformers
+pub use transformer::Transformer;
+
+// Re-export named transform lookup and behavior versioning
+pub use registry::{
+    apply_recipe, apply_transform, deprecated_name, transform_names, transform_version, Transform,
+    TransformError, TransformSpec,
+};
+
+// Re-export deprecated-name reporting
+pub use deprecation::{set_deprecation_hook, Deprecation, DeprecationHook};
+
+// Re-export the transform catalog
+pub use catalog::{catalog, Stability, TransformCategory, TransformInfo, TransformInput};
+
+// Re-export per-call transform events
+pub use events::{set_event_hook, EventHook, TransformEvent};
+
+// Re-export per-transform usage metrics
+pub use metrics::{set_metrics_registry, TransformMetrics, TransformStats, DURATION_BUCKETS};
+
+// Re-export pipeline and batch tracing
+pub use trace::{set_tracer, Operation, SpanEnd, SpanStart, SpanStatus, TraceSpan, Tracer};
+
+// Re-export detailed transform results
+pub use result::TransformResult;
+
+// Re-export randomness source selection
+pub use rng::{set_rand_source, RandSource};
+
+// Re-export seeded sessions and audit log replay
+pub use session::{replay_session, Session, SessionCall, SessionError};
+
+// Re-export detectability scoring
+pub use score::{detectability_score, rank_by_detectability, DetectabilityScore, PayloadContext};
+
 // Re-export case transformations
 pub use transformations::case::{
     alternate_case, case_swap, inverse_case, randomize_capitalization, to_camel_case,
@@ -92,15 +248,19 @@ pub use transformations::encoding::{
 
 // Re-export unicode transformations
 pub use transformations::unicode::{
-    homoglyph_substitution, space_variants, unicode_normalize_variants, unicode_variations,
-    zalgo_text,
+    homoglyph_substitution, homoglyph_substitution_detailed, space_variants,
+    unicode_normalize_variants, unicode_variations, zalgo_text,
 };
 
 // Re-export injection transformations
 pub use transformations::injection::{
-    command_injection, couchdb_injection, dynamodb_obfuscate, mongodb_injection,
-    nosql_operator_injection, null_byte_injection, path_traversal, sql_comment_injection,
-    ssti_framework_variation, ssti_injection, ssti_syntax_obfuscate, xss_tag_variations,
+    command_injection, couchdb_injection, cql_injection, crlf_injection, crlf_injection_variant,
+    csv_formula_injection, dangling_markup, dynamodb_obfuscate, elasticsearch_injection,
+    mongo_where_injection, mongodb_injection, mutation_xss_payloads, nosql_operator_injection,
+    null_byte_injection, path_traversal, redis_injection, sql_comment_injection,
+    sql_comment_injection_detailed, ssti_engine_variation, ssti_framework_variation,
+    ssti_injection, ssti_syntax_obfuscate, supported_engines, svg_xss_data_uris, svg_xss_payloads,
+    xpath_injection, xss_polyglot, xss_tag_variations, yaml_injection, TemplateEngine,
 };
 
 // Re-export obfuscation transformations
@@ -111,13 +271,16 @@ pub use transformations::obfuscation::{
 
 // Re-export phishing transformations
 pub use transformations::phishing::{
-    advanced_domain_spoof, domain_typosquat, email_obfuscation, url_shortening_pattern,
+    advanced_domain_spoof, combosquat, combosquat_with_options, display_name_spoof,
+    domain_typosquat, email_header_injection, email_obfuscation, keyboard_typos,
+    url_shortening_pattern, url_visual_spoof, CombosquatOptions, KeyboardLayout, KeywordPosition,
 };
 
 // Re-export bot detection transformations
 pub use transformations::bot_detection::{
-    accept_language_variation, cloudflare_challenge_variation, http2_header_order,
-    random_user_agent, tls_fingerprint_variation,
+    accept_language_variation, client_headers_for, cloudflare_challenge_variation,
+    http2_header_order, http2_header_order_set, random_user_agent, request_timing,
+    tls_fingerprint_variation, BrowserProfile, RequestTiming, TimingProfile,
 };
 
 // Re-export cloudflare transformations
@@ -128,14 +291,91 @@ pub use transformations::cloudflare::{
 
 // Re-export web security transformations
 pub use transformations::web_security::{
-    api_endpoint_variation, graphql_introspection_bypass, graphql_obfuscate,
-    graphql_variable_injection, html_form_action_variation, html_form_field_obfuscate,
-    html_input_attribute_variation, html_input_type_variation, html_input_value_obfuscate,
-    http_header_variation, jwt_algorithm_confusion, jwt_header_manipulation, jwt_payload_obfuscate,
-    jwt_signature_bypass, session_token_variation,
+    api_endpoint_variants, api_endpoint_variation, cookie_mutations, format_preserving_mutate,
+    graphql_introspection_bypass, graphql_obfuscate, graphql_variable_injection,
+    html_form_action_variation, html_form_field_obfuscate, html_input_attribute_variation,
+    html_input_type_variation, html_input_value_obfuscate, http_header_variation,
+    jwt_algorithm_confusion, jwt_header_manipulation, jwt_payload_obfuscate, jwt_signature_bypass,
+    session_token_variation, uuid_variants,
+};
+
+// Re-export dialect-aware SQL obfuscation
+pub use transformations::sql::{
+    mssql_bracket_identifiers, mssql_exec_string, mssql_unicode_literals,
+    mssql_whitespace_variants, oracle_obfuscate, postgres_obfuscate, sql_char_concat,
+    sql_inline_comment_obfuscate, sql_string_to_hex_literal, sql_whitespace_alternatives,
+    SqlDialect,
+};
+
+// Re-export Java-stack payload helpers
+pub use transformations::java::{
+    expression_injection, java_serialized_mutate, jndi_variations, ElDialect,
+};
+
+// Re-export JWT forging
+pub use transformations::jwt::{
+    forge_jwt, forge_jwt_with_options, jwt_embed_jwk, jwt_header_inject, jwt_header_injections,
+    jwt_none_variants, jwt_psychic_signatures, jwt_strip_signature, jwt_truncate_signature,
+    EmbeddedJwk, JwtAlgorithm, JwtForgeOptions,
+};
+
+// Re-export SAML manipulation helpers
+pub use transformations::saml::{
+    saml_decode, saml_encode, saml_nameid_comment, saml_signature_wrapping, XswVariant,
+};
+
+// Re-export XML/SOAP obfuscation
+pub use transformations::xml::xml_obfuscate;
+
+// Re-export GraphQL query generators
+pub use transformations::graphql::{
+    graphql_deep_nest, graphql_deep_nest_with_options, graphql_obfuscate_with_options,
+    GraphqlDeepNestOptions, GraphqlObfuscateOptions,
 };
 
 // Re-export shell transformations
 pub use transformations::shell::{
-    bash_obfuscate, env_var_obfuscate, file_path_obfuscate, powershell_obfuscate,
+    ads_path, ads_variants, bash_base64_wrap, bash_brace_expansion, bash_glob_resolve,
+    bash_ifs_obfuscate, bash_obfuscate, bash_rev_wrap, bash_var_slice, bash_wildcard_path,
+    bash_wildcard_path_unique, cmd_env_substring, cmd_obfuscate, curl_obfuscate,
+    curl_obfuscate_detailed, double_extension_filenames, env_var_obfuscate, file_path_obfuscate,
+    osascript_obfuscate, osascript_shell_wrap, powershell_obfuscate, shell_one_liner,
+    windows_path_obfuscate, windows_reserved_names, Shell, ShellEncoding,
+};
+
+// Re-export DNS exfiltration encoding
+pub use transformations::dns::{
+    dns_exfil_decode, dns_exfil_encode, dns_exfil_encode_with_options, DnsExfilEncoding,
+    DnsExfilError, DnsExfilOptions,
+};
+
+// Re-export LOLBin command templates
+pub use transformations::lolbin::{lolbin, lolbin_names, lolbin_with, LolbinError};
+
+// Re-export persistence artifact obfuscation
+pub use transformations::persistence::{
+    crontab_obfuscate, crontab_obfuscate_detailed, registry_run_key_obfuscate,
+    registry_run_key_obfuscate_detailed,
+};
+
+// Re-export PHP payload obfuscation
+pub use transformations::php::php_obfuscate;
+
+// Re-export VBA macro obfuscation
+pub use transformations::vba::vba_obfuscate;
+
+// Re-export file upload helpers
+pub use transformations::upload::{gif_js_polyglot, prepend_magic_bytes, FileFormat};
+
+// Re-export HTTP protocol attack generators
+pub use transformations::http::{
+    cache_buster_param, cache_poison_headers, chunked_body, chunked_body_with_options,
+    host_header_payloads, ip_spoof_headers, method_override_headers, method_override_params,
+    multipart_mutations, plausible_referer, referer_variants, smuggling_headers, ChunkedOptions,
+    Header, HeaderMutation, HeaderSet, MultipartVariant, SmugglingStyle,
+};
+
+// Re-export SSRF and URL testing transformations
+pub use transformations::url::{
+    open_redirect_payloads, path_bypass_variants, ssrf_variations, url_confusion,
 };
@@ -0,0 +1,371 @@
+use std::collections::BTreeMap;
+use std::fmt::Write;
+use std::sync::atomic::{AtomicBool, AtomicU64, Ordering};
+use std::sync::{Arc, RwLock};
+use std::time::Duration;
+
+use crate::events::TransformEvent;
+use crate::json::json_string;
+use crate::registry;
+
+/// Upper bounds, in seconds, of the transform duration histogram buckets.
+///
+/// A final `+Inf` bucket is implied, as in Prometheus histograms.
+pub const DURATION_BUCKETS: [f64; 7] = [0.000001, 0.00001, 0.0001, 0.001, 0.01, 0.1, 1.0];
+
+/// A counter's name suffix, help text and value.
+type Counter = (&'static str, &'static str, fn(&TransformStats) -> u64);
+
+/// Usage totals for one transform.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct TransformStats {
+    /// Registry name of the transform.
+    pub name: &'static str,
+    /// Number of calls.
+    pub calls: u64,
+    /// Total input size in bytes.
+    pub input_bytes: u64,
+    /// Total output size in bytes.
+    pub output_bytes: u64,
+    /// Total time spent in the transform.
+    pub duration: Duration,
+    /// Calls that took at most the matching [`DURATION_BUCKETS`] bound,
+    /// cumulative like Prometheus buckets.
+    pub duration_buckets: [u64; DURATION_BUCKETS.len()],
+}
+
+/// Lock-free counters for one registry entry.
+#[derive(Debug, Default)]
+struct Slot {
+    calls: AtomicU64,
+    input_bytes: AtomicU64,
+    output_bytes: AtomicU64,
+    duration_nanos: AtomicU64,
+    duration_buckets: [AtomicU64; DURATION_BUCKETS.len()],
+}
+
+/// Per-transform call counters and latency histograms.
+///
+/// Install one with [`set_metrics_registry`] to count every named transform
+/// call, then scrape it with [`to_prometheus`](TransformMetrics::to_prometheus)
+/// or publish [`to_json`](TransformMetrics::to_json) as an expvar-style
+/// variable. A registry can also be fed by hand from an
+/// [`EventHook`](crate::EventHook) with [`record`](TransformMetrics::record).
+///
+/// Each registered transform version has its own atomic counters, so
+/// concurrent calls such as [`parallel_apply`](crate::parallel_apply)
+/// workers never wait on each other. A snapshot taken while calls are
+/// running may see one counter of a call updated before another.
+#[derive(Debug)]
+pub struct TransformMetrics {
+    slots: Box<[Slot]>,
+}
+
+impl Default for TransformMetrics {
+    fn default() -> Self {
+        TransformMetrics::new()
+    }
+}
+
+impl TransformMetrics {
+    /// Creates an empty registry.
+    pub fn new() -> Self {
+        TransformMetrics {
+            slots: (0..registry::entry_count())
+                .map(|_| Slot::default())
+                .collect(),
+        }
+    }
+
+    /// Adds one transform call to the totals.
+    ///
+    /// Events for names and versions that are not registered are ignored.
+    pub fn record(&self, event: &TransformEvent) {
+        if let Some(slot) = registry::entry_slot(event.name, event.version) {
+            self.record_slot(slot, event);
+        }
+    }
+
+    /// Adds one call to the counters of the registry entry at `slot`.
+    pub(crate) fn record_slot(&self, slot: usize, event: &TransformEvent) {
+        let counters = &self.slots[slot];
+        counters.calls.fetch_add(1, Ordering::Relaxed);
+        counters
+            .input_bytes
+            .fetch_add(event.input_len as u64, Ordering::Relaxed);
+        counters
+            .output_bytes
+            .fetch_add(event.output_len as u64, Ordering::Relaxed);
+        counters
+            .duration_nanos
+            .fetch_add(event.duration.as_nanos() as u64, Ordering::Relaxed);
+        let seconds = event.duration.as_secs_f64();
+        for (count, bound) in counters.duration_buckets.iter().zip(DURATION_BUCKETS) {
+            if seconds <= bound {
+                count.fetch_add(1, Ordering::Relaxed);
+            }
+        }
+    }
+
+    /// Returns the totals of every transform called so far, sorted by name.
+    ///
+    /// Versions of the same transform are added together.
+    pub fn snapshot(&self) -> Vec<TransformStats> {
+        let mut stats: BTreeMap<&'static str, TransformStats> = BTreeMap::new();
+        for (slot, counters) in self.slots.iter().enumerate() {
+            let calls = counters.calls.load(Ordering::Relaxed);
+            if calls == 0 {
+                continue;
+            }
+            let name = registry::slot_name(slot);
+            let entry = stats.entry(name).or_insert(TransformStats {
+                name,
+                calls: 0,
+                input_bytes: 0,
+                output_bytes: 0,
+                duration: Duration::ZERO,
+                duration_buckets: [0; DURATION_BUCKETS.len()],
+            });
+            entry.calls += calls;
+            entry.input_bytes += counters.input_bytes.load(Ordering::Relaxed);
+            entry.output_bytes += counters.output_bytes.load(Ordering::Relaxed);
+            entry.duration += Duration::from_nanos(counters.duration_nanos.load(Ordering::Relaxed));
+            for (total, count) in entry
+                .duration_buckets
+                .iter_mut()
+                .zip(&counters.duration_buckets)
+            {
+                *total += count.load(Ordering::Relaxed);
+            }
+        }
+        stats.into_values().collect()
+    }
+
+    /// Renders the totals in the Prometheus text exposition format.
+    ///
+    /// Exposes `redstr_transform_calls_total`,
+    /// `redstr_transform_input_bytes_total`,
+    /// `redstr_transform_output_bytes_total` and the
+    /// `redstr_transform_duration_seconds` histogram, each labelled with the
+    /// transform `name`.
+    pub fn to_prometheus(&self) -> String {
+        let snapshot = self.snapshot();
+        let mut out = String::new();
+        let counters: [Counter; 3] = [
+            ("calls_total", "Named transform calls.", |s| s.calls),
+            (
+                "input_bytes_total",
+                "Bytes passed to named transforms.",
+                |s| s.input_bytes,
+            ),
+            (
+                "output_bytes_total",
+                "Bytes returned by named transforms.",
+                |s| s.output_bytes,
+            ),
+        ];
+        for (metric, help, value) in counters {
+            let _ = writeln!(out, "# HELP redstr_transform_{} {}", metric, help);
+            let _ = writeln!(out, "# TYPE redstr_transform_{} counter", metric);
+            for stats in &snapshot {
+                let _ = writeln!(
+                    out,
+                    "redstr_transform_{}{{name=\"{}\"}} {}",
+                    metric,
+                    stats.name,
+                    value(stats)
+                );
+            }
+        }
+
+        out.push_str("# HELP redstr_transform_duration_seconds Time spent in named transforms.\n");
+        out.push_str("# TYPE redstr_transform_duration_seconds histogram\n");
+        for stats in &snapshot {
+            for (count, bound) in stats.duration_buckets.iter().zip(DURATION_BUCKETS) {
+                let _ = writeln!(
+                    out,
+                    "redstr_transform_duration_seconds_bucket{{name=\"{}\",le=\"{}\"}} {}",
+                    stats.name, bound, count
+                );
+            }
+            let _ = writeln!(
+                out,
+                "redstr_transform_duration_seconds_bucket{{name=\"{}\",le=\"+Inf\"}} {}",
+                stats.name, stats.calls
+            );
+            let _ = writeln!(
+                out,
+                "redstr_transform_duration_seconds_sum{{name=\"{}\"}} {}",
+                stats.name,
+                stats.duration.as_secs_f64()
+            );
+            let _ = writeln!(
+                out,
+                "redstr_transform_duration_seconds_count{{name=\"{}\"}} {}",
+                stats.name, stats.calls
+            );
+        }
+        out
+    }
+
+    /// Renders the totals as a compact JSON object keyed by transform name,
+    /// e.g. `{"rot13":{"calls":2,"input_bytes":10,"output_bytes":10,"duration_seconds":0.000003}}`.
+    pub fn to_json(&self) -> String {
+        let entries: Vec<String> = self
+            .snapshot()
+            .iter()
+            .map(|stats| {
+                format!(
+                    "{}:{{\"calls\":{},\"input_bytes\":{},\"output_bytes\":{},\"duration_seconds\":{}}}",
+                    json_string(stats.name),
+                    stats.calls,
+                    stats.input_bytes,
+                    stats.output_bytes,
+                    stats.duration.as_secs_f64()
+                )
+            })
+            .collect();
+        format!("{{{}}}", entries.join(","))
+    }
+}
+
+/// The process-wide metrics registry, if any.
+static METRICS: RwLock<Option<Arc<TransformMetrics>>> = RwLock::new(None);
+
+/// Whether a registry is installed, so calls without one skip the lock.
+static METRICS_ENABLED: AtomicBool = AtomicBool::new(false);
+
+/// Installs a registry that counts every named transform call, or removes
+/// it with `None`.
+///
+/// Counts cover the same calls as [`set_event_hook`](crate::set_event_hook)
+/// and work alongside it. The registry keeps its totals when removed, so
+/// reinstalling the same one continues its counters. Without a registry,
+/// the only cost is one atomic load per call.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Monitor fuzzing throughput per transform
+/// - **Blue Team**: Alert when a test service's transform latency regresses
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{apply_recipe, set_metrics_registry, TransformMetrics};
+/// use std::sync::Arc;
+///
+/// let metrics = Arc::new(TransformMetrics::new());
+/// set_metrics_registry(Some(metrics.clone()));
+/// apply_recipe("rot13 | base64_encode", "hello").unwrap();
+/// set_metrics_registry(None);
+///
+/// let text = metrics.to_prometheus();
+/// assert!(text.contains("redstr_transform_calls_total{name=\"rot13\"} 1"));
+/// ```
+pub fn set_metrics_registry(registry: Option<Arc<TransformMetrics>>) {
+    let mut current = METRICS.write().unwrap_or_else(|e| e.into_inner());
+    METRICS_ENABLED.store(registry.is_some(), Ordering::Release);
+    *current = registry;
+}
+
+/// Returns the installed registry, if any.
+pub(crate) fn installed() -> Option<Arc<TransformMetrics>> {
+    if !METRICS_ENABLED.load(Ordering::Acquire) {
+        return None;
+    }
+    METRICS.read().unwrap_or_else(|e| e.into_inner()).clone()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn event(name: &'static str, micros: u64) -> TransformEvent {
+        TransformEvent {
+            name,
+            version: 1,
+            input_len: 5,
+            output_len: 8,
+            duration: Duration::from_micros(micros),
+            seed: None,
+        }
+    }
+
+    #[test]
+    fn test_record_accumulates_per_transform() {
+        let metrics = TransformMetrics::new();
+        metrics.record(&event("rot13", 5));
+        metrics.record(&event("rot13", 500));
+        metrics.record(&event("base64_encode", 2_000_000));
+        let snapshot = metrics.snapshot();
+        assert_eq!(snapshot.len(), 2);
+        assert_eq!(snapshot[0].name, "base64_encode");
+        assert_eq!(snapshot[0].duration_buckets, [0; 7]);
+        let rot13 = snapshot[1];
+        assert_eq!(
+            (rot13.calls, rot13.input_bytes, rot13.output_bytes),
+            (2, 10, 16)
+        );
+        assert_eq!(rot13.duration, Duration::from_micros(505));
+        assert_eq!(rot13.duration_buckets, [0, 1, 1, 2, 2, 2, 2]);
+    }
+
+    #[test]
+    fn test_record_ignores_unregistered_transforms() {
+        let metrics = TransformMetrics::new();
+        metrics.record(&event("no_such_transform", 5));
+        metrics.record(&TransformEvent {
+            version: 99,
+            ..event("rot13", 5)
+        });
+        assert!(metrics.snapshot().is_empty());
+    }
+
+    #[test]
+    fn test_record_from_many_threads() {
+        let metrics = TransformMetrics::new();
+        std::thread::scope(|scope| {
+            for _ in 0..4 {
+                scope.spawn(|| {
+                    for _ in 0..1000 {
+                        metrics.record(&event("rot13", 5));
+                    }
+                });
+            }
+        });
+        let rot13 = metrics.snapshot()[0];
+        assert_eq!((rot13.calls, rot13.input_bytes), (4000, 20_000));
+        assert_eq!(rot13.duration_buckets[1], 4000);
+    }
+
+    #[test]
+    fn test_to_prometheus() {
+        let metrics = TransformMetrics::new();
+        assert!(!metrics.to_prometheus().contains("{name="));
+        metrics.record(&event("rot13", 50));
+        let text = metrics.to_prometheus();
+        assert!(text.contains("# TYPE redstr_transform_duration_seconds histogram\n"));
+        assert!(text.contains("redstr_transform_calls_total{name=\"rot13\"} 1\n"));
+        assert!(text.contains("redstr_transform_output_bytes_total{name=\"rot13\"} 8\n"));
+        assert!(text.contains(
+            "redstr_transform_duration_seconds_bucket{name=\"rot13\",le=\"0.00001\"} 0\n"
+        ));
+        assert!(text.contains(
+            "redstr_transform_duration_seconds_bucket{name=\"rot13\",le=\"0.0001\"} 1\n"
+        ));
+        assert!(text
+            .contains("redstr_transform_duration_seconds_bucket{name=\"rot13\",le=\"+Inf\"} 1\n"));
+        assert!(text.contains("redstr_transform_duration_seconds_sum{name=\"rot13\"} 0.00005\n"));
+    }
+
+    #[test]
+    fn test_to_json() {
+        let metrics = TransformMetrics::new();
+        assert_eq!(metrics.to_json(), "{}");
+        metrics.record(&event("rot13", 3));
+        assert_eq!(
+            metrics.to_json(),
+            r#"{"rot13":{"calls":1,"input_bytes":5,"output_bytes":8,"duration_seconds":0.000003}}"#
+        );
+    }
+}
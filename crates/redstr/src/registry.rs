@@ -0,0 +1,1184 @@
+use std::fmt;
+
+use crate::catalog::{Stability, TransformCategory, TransformInfo, TransformInput};
+use crate::deprecation::{notify, Deprecation};
+use crate::events::{observe, observe_into};
+use crate::result::TransformResult;
+use crate::trace::{Operation, SpanStatus, TraceSpan};
+use crate::transformations::bot_detection::{
+    accept_language_variation, cloudflare_challenge_variation, http2_header_order,
+    tls_fingerprint_variation,
+};
+use crate::transformations::case::{
+    alternate_case, case_swap, inverse_case, randomize_capitalization, to_camel_case,
+    to_kebab_case, to_snake_case,
+};
+use crate::transformations::cloudflare::{
+    canvas_fingerprint_variation, cloudflare_challenge_response, cloudflare_turnstile_variation,
+    font_fingerprint_consistency, tls_handshake_pattern, webgl_fingerprint_obfuscate,
+};
+use crate::transformations::encoding::{
+    base64_encode, base64_encode_bytes, base64_encode_into, hex_encode, hex_encode_bytes,
+    hex_encode_into, hex_encode_mixed, hex_encode_mixed_bytes, html_entity_encode, mixed_encoding,
+    url_encode, url_encode_bytes, url_encode_into,
+};
+use crate::transformations::injection::{
+    command_injection, couchdb_injection, crlf_injection_variant, dynamodb_obfuscate,
+    mongodb_injection, nosql_operator_injection, null_byte_injection, path_traversal,
+    sql_comment_injection, ssti_injection, ssti_syntax_obfuscate, xss_tag_variations,
+};
+use crate::transformations::jwt::{forge_jwt, jwt_strip_signature};
+use crate::transformations::obfuscation::{
+    double_characters, js_string_concat, leetspeak, reverse_string, reverse_string_into, rot13,
+    rot13_into, vowel_swap, whitespace_padding,
+};
+use crate::transformations::persistence::{crontab_obfuscate, registry_run_key_obfuscate};
+use crate::transformations::phishing::{
+    advanced_domain_spoof, domain_typosquat, email_obfuscation, url_shortening_pattern,
+};
+use crate::transformations::php::php_obfuscate;
+use crate::transformations::saml::saml_encode;
+use crate::transformations::shell::{
+    bash_base64_wrap, bash_brace_expansion, bash_ifs_obfuscate, bash_obfuscate, bash_rev_wrap,
+    bash_var_slice, bash_wildcard_path, cmd_env_substring, cmd_obfuscate, curl_obfuscate,
+    env_var_obfuscate, file_path_obfuscate, osascript_obfuscate, osascript_shell_wrap,
+    powershell_obfuscate,
+};
+use crate::transformations::sql::{
+    mssql_bracket_identifiers, mssql_exec_string, mssql_unicode_literals, sql_bytes_to_hex_literal,
+    sql_string_to_hex_literal,
+};
+use crate::transformations::unicode::{
+    homoglyph_substitution, space_variants, unicode_normalize_variants, unicode_variations,
+    zalgo_text,
+};
+use crate::transformations::vba::vba_obfuscate;
+use crate::transformations::web_security::{
+    api_endpoint_variation, format_preserving_mutate, graphql_introspection_bypass,
+    graphql_obfuscate, graphql_variable_injection, html_form_action_variation,
+    html_form_field_obfuscate, html_input_attribute_variation, html_input_type_variation,
+    html_input_value_obfuscate, http_header_variation, jwt_algorithm_confusion,
+    jwt_header_manipulation, jwt_payload_obfuscate, jwt_signature_bypass, session_token_variation,
+};
+use crate::transformations::xml::xml_obfuscate;
+
+/// Signature shared by every single-input transform.
+pub(crate) type TransformFn = fn(&str) -> String;
+
+/// Byte-level implementation of a transform, for input that is not UTF-8.
+type ByteTransformFn = fn(&[u8]) -> String;
+
+/// Implementation of a transform that appends to an existing buffer.
+type WriteTransformFn = fn(&str, &mut String);
+
+/// A single behavior version of a named transform.
+///
+/// When the observable behavior of a transform changes, the previous
+/// implementation stays registered under its old version number and the new
+/// one is added with the next version, so pinned recipes keep reproducing the
+/// output they were tuned against.
+#[derive(Debug)]
+struct TransformEntry {
+    category: TransformCategory,
+    name: &'static str,
+    version: u32,
+    apply: TransformFn,
+}
+
+const fn entry(
+    category: TransformCategory,
+    name: &'static str,
+    version: u32,
+    apply: TransformFn,
+) -> TransformEntry {
+    TransformEntry {
+        category,
+        name,
+        version,
+        apply,
+    }
+}
+
+const TRANSFORMS: &[TransformEntry] = {
+    use TransformCategory::*;
+    &[
+        // Case
+        entry(Case, "alternate_case", 1, alternate_case),
+        entry(Case, "case_swap", 1, case_swap),
+        entry(Case, "inverse_case", 1, inverse_case),
+        entry(
+            Case,
+            "randomize_capitalization",
+            1,
+            randomize_capitalization,
+        ),
+        entry(Case, "to_camel_case", 1, to_camel_case),
+        entry(Case, "to_kebab_case", 1, to_kebab_case),
+        entry(Case, "to_snake_case", 1, to_snake_case),
+        // Encoding
+        entry(Encoding, "base64_encode", 1, base64_encode),
+        entry(Encoding, "hex_encode", 1, hex_encode),
+        entry(Encoding, "hex_encode_mixed", 1, hex_encode_mixed),
+        entry(Encoding, "html_entity_encode", 1, html_entity_encode),
+        entry(Encoding, "mixed_encoding", 1, mixed_encoding),
+        entry(Encoding, "url_encode", 1, url_encode),
+        // Unicode
+        entry(Unicode, "homoglyph_substitution", 1, homoglyph_substitution),
+        entry(Unicode, "space_variants", 1, space_variants),
+        entry(
+            Unicode,
+            "unicode_normalize_variants",
+            1,
+            unicode_normalize_variants,
+        ),
+        entry(Unicode, "unicode_variations", 1, unicode_variations),
+        entry(Unicode, "zalgo_text", 1, zalgo_text),
+        // Injection
+        entry(Injection, "command_injection", 1, command_injection),
+        entry(Injection, "couchdb_injection", 1, couchdb_injection),
+        entry(
+            Injection,
+            "crlf_injection_variant",
+            1,
+            crlf_injection_variant,
+        ),
+        entry(Injection, "dynamodb_obfuscate", 1, dynamodb_obfuscate),
+        entry(Injection, "mongodb_injection", 1, mongodb_injection),
+        entry(
+            Injection,
+            "nosql_operator_injection",
+            1,
+            nosql_operator_injection,
+        ),
+        entry(Injection, "null_byte_injection", 1, null_byte_injection),
+        entry(Injection, "path_traversal", 1, path_traversal),
+        entry(Injection, "sql_comment_injection", 1, sql_comment_injection),
+        entry(Injection, "ssti_injection", 1, ssti_injection),
+        entry(Injection, "ssti_syntax_obfuscate", 1, ssti_syntax_obfuscate),
+        entry(Injection, "xss_tag_variations", 1, xss_tag_variations),
+        // Obfuscation
+        entry(Obfuscation, "double_characters", 1, double_characters),
+        entry(Obfuscation, "js_string_concat", 1, js_string_concat),
+        entry(Obfuscation, "leetspeak", 1, leetspeak),
+        entry(Obfuscation, "reverse_string", 1, reverse_string),
+        entry(Obfuscation, "rot13", 1, rot13),
+        entry(Obfuscation, "vowel_swap", 1, vowel_swap),
+        entry(Obfuscation, "whitespace_padding", 1, whitespace_padding),
+        // Phishing
+        entry(Phishing, "advanced_domain_spoof", 1, advanced_domain_spoof),
+        entry(Phishing, "domain_typosquat", 1, domain_typosquat),
+        entry(Phishing, "email_obfuscation", 1, email_obfuscation),
+        entry(
+            Phishing,
+            "url_shortening_pattern",
+            1,
+            url_shortening_pattern,
+        ),
+        // Bot detection
+        entry(
+            BotDetection,
+            "accept_language_variation",
+            1,
+            accept_language_variation,
+        ),
+        entry(
+            BotDetection,
+            "cloudflare_challenge_variation",
+            1,
+            cloudflare_challenge_variation,
+        ),
+        entry(BotDetection, "http2_header_order", 1, http2_header_order),
+        entry(
+            BotDetection,
+            "tls_fingerprint_variation",
+            1,
+            tls_fingerprint_variation,
+        ),
+        // Cloudflare
+        entry(
+            Cloudflare,
+            "canvas_fingerprint_variation",
+            1,
+            canvas_fingerprint_variation,
+        ),
+        entry(
+            Cloudflare,
+            "cloudflare_challenge_response",
+            1,
+            cloudflare_challenge_response,
+        ),
+        entry(
+            Cloudflare,
+            "cloudflare_turnstile_variation",
+            1,
+            cloudflare_turnstile_variation,
+        ),
+        entry(
+            Cloudflare,
+            "font_fingerprint_consistency",
+            1,
+            font_fingerprint_consistency,
+        ),
+        entry(
+            Cloudflare,
+            "tls_handshake_pattern",
+            1,
+            tls_handshake_pattern,
+        ),
+        entry(
+            Cloudflare,
+            "webgl_fingerprint_obfuscate",
+            1,
+            webgl_fingerprint_obfuscate,
+        ),
+        // Web security
+        entry(
+            WebSecurity,
+            "api_endpoint_variation",
+            1,
+            api_endpoint_variation,
+        ),
+        entry(WebSecurity, "forge_jwt", 1, forge_jwt),
+        entry(
+            WebSecurity,
+            "format_preserving_mutate",
+            1,
+            format_preserving_mutate,
+        ),
+        entry(
+            WebSecurity,
+            "graphql_introspection_bypass",
+            1,
+            graphql_introspection_bypass,
+        ),
+        entry(WebSecurity, "graphql_obfuscate", 1, graphql_obfuscate),
+        entry(
+            WebSecurity,
+            "graphql_variable_injection",
+            1,
+            graphql_variable_injection,
+        ),
+        entry(
+            WebSecurity,
+            "html_form_action_variation",
+            1,
+            html_form_action_variation,
+        ),
+        entry(
+            WebSecurity,
+            "html_form_field_obfuscate",
+            1,
+            html_form_field_obfuscate,
+        ),
+        entry(
+            WebSecurity,
+            "html_input_attribute_variation",
+            1,
+            html_input_attribute_variation,
+        ),
+        entry(
+            WebSecurity,
+            "html_input_type_variation",
+            1,
+            html_input_type_variation,
+        ),
+        entry(
+            WebSecurity,
+            "html_input_value_obfuscate",
+            1,
+            html_input_value_obfuscate,
+        ),
+        entry(
+            WebSecurity,
+            "http_header_variation",
+            1,
+            http_header_variation,
+        ),
+        entry(
+            WebSecurity,
+            "jwt_algorithm_confusion",
+            1,
+            jwt_algorithm_confusion,
+        ),
+        entry(
+            WebSecurity,
+            "jwt_header_manipulation",
+            1,
+            jwt_header_manipulation,
+        ),
+        entry(
+            WebSecurity,
+            "jwt_payload_obfuscate",
+            1,
+            jwt_payload_obfuscate,
+        ),
+        entry(WebSecurity, "jwt_signature_bypass", 1, jwt_signature_bypass),
+        entry(WebSecurity, "jwt_strip_signature", 1, jwt_strip_signature),
+        entry(
+            WebSecurity,
+            "session_token_variation",
+            1,
+            session_token_variation,
+        ),
+        // SAML
+        entry(Saml, "saml_encode", 1, saml_encode),
+        // PHP
+        entry(Php, "php_obfuscate", 1, php_obfuscate),
+        // Persistence
+        entry(Persistence, "crontab_obfuscate", 1, crontab_obfuscate),
+        entry(
+            Persistence,
+            "registry_run_key_obfuscate",
+            1,
+            registry_run_key_obfuscate,
+        ),
+        // Shell
+        entry(Shell, "bash_base64_wrap", 1, bash_base64_wrap),
+        entry(Shell, "bash_brace_expansion", 1, bash_brace_expansion),
+        entry(Shell, "bash_ifs_obfuscate", 1, bash_ifs_obfuscate),
+        entry(Shell, "bash_obfuscate", 1, bash_obfuscate),
+        entry(Shell, "bash_rev_wrap", 1, bash_rev_wrap),
+        entry(Shell, "bash_var_slice", 1, bash_var_slice),
+        entry(Shell, "bash_wildcard_path", 1, bash_wildcard_path),
+        entry(Shell, "cmd_env_substring", 1, cmd_env_substring),
+        entry(Shell, "cmd_obfuscate", 1, cmd_obfuscate),
+        entry(Shell, "curl_obfuscate", 1, curl_obfuscate),
+        entry(Shell, "env_var_obfuscate", 1, env_var_obfuscate),
+        entry(Shell, "file_path_obfuscate", 1, file_path_obfuscate),
+        entry(Shell, "osascript_obfuscate", 1, osascript_obfuscate),
+        entry(Shell, "osascript_shell_wrap", 1, osascript_shell_wrap),
+        entry(Shell, "powershell_obfuscate", 1, powershell_obfuscate),
+        // SQL
+        entry(
+            Sql,
+            "mssql_bracket_identifiers",
+            1,
+            mssql_bracket_identifiers,
+        ),
+        entry(Sql, "mssql_exec_string", 1, mssql_exec_string),
+        entry(Sql, "mssql_unicode_literals", 1, mssql_unicode_literals),
+        entry(
+            Sql,
+            "sql_string_to_hex_literal",
+            1,
+            sql_string_to_hex_literal,
+        ),
+        // VBA
+        entry(Vba, "vba_obfuscate", 1, vba_obfuscate),
+        // XML
+        entry(Xml, "xml_obfuscate", 1, xml_obfuscate),
+    ]
+};
+
+/// Separator between steps in a serialized recipe.
+const RECIPE_SEPARATOR: char = '|';
+
+/// Transforms whose output can be decoded back to the exact input.
+const REVERSIBLE_TRANSFORMS: &[&str] = &[
+    "base64_encode",
+    "hex_encode",
+    "hex_encode_mixed",
+    "reverse_string",
+    "rot13",
+    "saml_encode",
+    "url_encode",
+];
+
+/// Transforms whose output depends only on the input, never on the seed.
+const DETERMINISTIC_TRANSFORMS: &[&str] = &[
+    "alternate_case",
+    "base64_encode",
+    "bash_base64_wrap",
+    "bash_brace_expansion",
+    "bash_ifs_obfuscate",
+    "bash_rev_wrap",
+    "bash_wildcard_path",
+    "dynamodb_obfuscate",
+    "forge_jwt",
+    "hex_encode",
+    "inverse_case",
+    "jwt_strip_signature",
+    "mssql_bracket_identifiers",
+    "mssql_exec_string",
+    "mssql_unicode_literals",
+    "osascript_shell_wrap",
+    "reverse_string",
+    "rot13",
+    "saml_encode",
+    "sql_string_to_hex_literal",
+    "to_camel_case",
+    "to_kebab_case",
+    "to_snake_case",
+    "url_encode",
+    "vba_obfuscate",
+];
+
+/// Transforms that may still be renamed or removed in a minor release.
+const EXPERIMENTAL_TRANSFORMS: &[&str] = &[
+    "crontab_obfuscate",
+    "curl_obfuscate",
+    "osascript_obfuscate",
+    "osascript_shell_wrap",
+    "php_obfuscate",
+    "registry_run_key_obfuscate",
+    "vba_obfuscate",
+];
+
+/// Deprecated names that still resolve, with the registry name they map to.
+///
+/// When a transform is renamed its old name moves here, so recipes and
+/// configs that store it keep working and report through the deprecation
+/// hook.
+const ALIASES: &[(&str, &str)] = &[
+    ("cloudflare_challenge", "cloudflare_challenge_variation"),
+    ("cloudflare_turnstile", "cloudflare_turnstile_variation"),
+];
+
+/// Transforms that also accept input that is not valid UTF-8, by name and
+/// version, with their byte-level implementations.
+///
+/// Each byte function must agree with the string function on valid UTF-8.
+const BYTE_TRANSFORMS: &[(&str, u32, ByteTransformFn)] = &[
+    ("base64_encode", 1, base64_encode_bytes),
+    ("hex_encode", 1, hex_encode_bytes),
+    ("hex_encode_mixed", 1, hex_encode_mixed_bytes),
+    ("sql_string_to_hex_literal", 1, sql_bytes_to_hex_literal),
+    ("url_encode", 1, url_encode_bytes),
+];
+
+/// Transforms that can append their output to a caller's buffer instead of
+/// returning a new `String`, by name and version.
+///
+/// [`Transformer`](crate::Transformer) uses these to run pipeline steps
+/// without per-step allocations. Each write function must append exactly
+/// what the string function returns.
+const WRITE_TRANSFORMS: &[(&str, u32, WriteTransformFn)] = &[
+    ("base64_encode", 1, base64_encode_into),
+    ("hex_encode", 1, hex_encode_into),
+    ("reverse_string", 1, reverse_string_into),
+    ("rot13", 1, rot13_into),
+    ("url_encode", 1, url_encode_into),
+];
+
+/// Errors returned when resolving or applying a named transform.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum TransformError {
+    /// No transform is registered under the given name.
+    UnknownTransform(String),
+    /// The transform exists, but not with the requested behavior version.
+    UnsupportedVersion {
+        /// Name of the transform.
+        name: String,
+        /// Version that was requested.
+        version: u32,
+    },
+    /// The spec or recipe could not be parsed.
+    InvalidSpec(String),
+}
+
+impl fmt::Display for TransformError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            TransformError::UnknownTransform(name) => write!(f, "unknown transform: {}", name),
+            TransformError::UnsupportedVersion { name, version } => {
+                write!(f, "transform {} has no version {}", name, version)
+            }
+            TransformError::InvalidSpec(spec) => write!(f, "invalid transform spec: {}", spec),
+        }
+    }
+}
+
+impl std::error::Error for TransformError {}
+
+/// A transform reference of the form `name` or `name@version`.
+///
+/// Specs without a version resolve to the latest registered behavior. Pinning a
+/// version (`leetspeak@1`) keeps a recipe reproducible across library upgrades.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::TransformSpec;
+/// let spec = TransformSpec::parse("leetspeak@1").unwrap();
+/// assert_eq!(spec.name, "leetspeak");
+/// assert_eq!(spec.version, Some(1));
+/// assert_eq!(spec.to_string(), "leetspeak@1");
+/// ```
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TransformSpec {
+    /// Transform name, matching the library function name (e.g. `base64_encode`).
+    pub name: String,
+    /// Pinned behavior version, or `None` for the latest.
+    pub version: Option<u32>,
+}
+
+impl TransformSpec {
+    /// Parses a `name` or `name@version` spec.
+    pub fn parse(spec: &str) -> Result<Self, TransformError> {
+        let spec = spec.trim();
+        let (name, version) = match spec.split_once('@') {
+            Some((name, version)) => {
+                let version = version
+                    .trim()
+                    .parse::<u32>()
+                    .map_err(|_| TransformError::InvalidSpec(spec.to_string()))?;
+                (name.trim(), Some(version))
+            }
+            None => (spec, None),
+        };
+
+        if name.is_empty() {
+            return Err(TransformError::InvalidSpec(spec.to_string()));
+        }
+
+        Ok(TransformSpec {
+            name: name.to_string(),
+            version,
+        })
+    }
+}
+
+impl fmt::Display for TransformSpec {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self.version {
+            Some(version) => write!(f, "{}@{}", self.name, version),
+            None => write!(f, "{}", self.name),
+        }
+    }
+}
+
+/// Finds the entry for a name, picking the latest version when none is pinned.
+///
+/// Returns the entry with its index in the registry, which metrics use as
+/// the entry's counter slot.
+fn resolve(spec: &TransformSpec) -> Result<(usize, &'static TransformEntry), TransformError> {
+    let name = match deprecated_name(&spec.name) {
+        Some(deprecation) => {
+            notify(&deprecation);
+            deprecation.replacement
+        }
+        None => spec.name.as_str(),
+    };
+    lookup(TRANSFORMS, name, spec)
+}
+
+/// Finds `name` at the spec's version in `entries`, reporting errors under
+/// the name the spec was written with.
+fn lookup(
+    entries: &'static [TransformEntry],
+    name: &str,
+    spec: &TransformSpec,
+) -> Result<(usize, &'static TransformEntry), TransformError> {
+    let mut candidates = entries
+        .iter()
+        .enumerate()
+        .filter(|(_, entry)| entry.name == name);
+
+    let entry = match spec.version {
+        Some(version) => candidates.find(|(_, entry)| entry.version == version),
+        None => candidates.max_by_key(|(_, entry)| entry.version),
+    };
+
+    entry.ok_or_else(|| {
+        if entries.iter().any(|entry| entry.name == name) {
+            TransformError::UnsupportedVersion {
+                name: spec.name.clone(),
+                version: spec.version.unwrap_or_default(),
+            }
+        } else {
+            TransformError::UnknownTransform(spec.name.clone())
+        }
+    })
+}
+
+/// A resolved, version-pinned transform.
+///
+/// Resolving a spec once and reusing the handle avoids re-parsing and looking
+/// up the name on every call, which matters in hot loops and binding layers
+/// that process large batches.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::Transform;
+/// let transform = Transform::resolve("hex_encode").unwrap();
+/// assert_eq!(transform.version(), 1);
+/// let outputs: Vec<String> = ["a", "b"].iter().map(|s| transform.apply(s)).collect();
+/// assert_eq!(outputs, vec!["61", "62"]);
+/// ```
+#[derive(Debug, Clone, Copy)]
+pub struct Transform {
+    slot: usize,
+    entry: &'static TransformEntry,
+}
+
+impl Transform {
+    /// Resolves a `name` or `name@version` spec.
+    pub fn resolve(spec: &str) -> Result<Self, TransformError> {
+        let spec = TransformSpec::parse(spec)?;
+        let (slot, entry) = resolve(&spec)?;
+        Ok(Transform { slot, entry })
+    }
+
+    /// Returns the transform name.
+    pub fn name(&self) -> &'static str {
+        self.entry.name
+    }
+
+    /// Returns the behavior version this handle is pinned to.
+    pub fn version(&self) -> u32 {
+        self.entry.version
+    }
+
+    /// Returns the pinned spec for this transform.
+    pub fn spec(&self) -> TransformSpec {
+        TransformSpec {
+            name: self.entry.name.to_string(),
+            version: Some(self.entry.version),
+        }
+    }
+
+    /// Applies the transform to `input`.
+    pub fn apply(&self, input: &str) -> String {
+        observe(
+            self.slot,
+            self.entry.name,
+            self.entry.version,
+            input.len(),
+            || (self.entry.apply)(input),
+        )
+    }
+
+    /// Appends the transform of `input` to `out`.
+    ///
+    /// Transforms listed in `WRITE_TRANSFORMS` write straight into `out`;
+    /// the rest return a `String` that is copied in.
+    pub(crate) fn apply_into(&self, input: &str, out: &mut String) {
+        let write = WRITE_TRANSFORMS
+            .iter()
+            .find(|(name, version, _)| *name == self.entry.name && *version == self.entry.version)
+            .map(|(_, _, write)| *write);
+        observe_into(
+            self.slot,
+            self.entry.name,
+            self.entry.version,
+            input.len(),
+            out,
+            |out| match write {
+                Some(write) => write(input, out),
+                None => out.push_str(&(self.entry.apply)(input)),
+            },
+        )
+    }
+
+    /// Applies the transform and reports it as a [`TransformResult`].
+    ///
+    /// The technique is the transform name and the pinned version is
+    /// recorded as the `version` parameter. Functions with a
+    /// `..._detailed` variant report finer-grained techniques when called
+    /// directly.
+    ///
+    /// # Examples
+    ///
+    /// ```
+    /// use redstr::Transform;
+    /// let result = Transform::resolve("rot13").unwrap().apply_detailed("hello");
+    /// assert_eq!(result.output, "uryyb");
+    /// assert_eq!(result.technique, "rot13");
+    /// assert_eq!(result.parameter("version"), Some("1"));
+    /// assert!(result.reversible);
+    /// ```
+    pub fn apply_detailed(&self, input: &str) -> TransformResult {
+        TransformResult {
+            output: self.apply(input),
+            technique: self.entry.name,
+            parameters: vec![("version", self.entry.version.to_string())],
+            reversible: REVERSIBLE_TRANSFORMS.contains(&self.entry.name),
+        }
+    }
+
+    /// Returns whether the transform also accepts input that is not valid UTF-8.
+    pub fn supports_bytes(&self) -> bool {
+        self.byte_fn().is_some()
+    }
+
+    /// Applies the transform to raw bytes.
+    ///
+    /// Valid UTF-8 is transformed exactly like [`apply`](Self::apply). Other
+    /// input is only accepted by transforms that
+    /// [`support bytes`](Self::supports_bytes), such as the hex, Base64 and
+    /// percent encoders; for the rest this returns `None`.
+    ///
+    /// # Examples
+    ///
+    /// ```
+    /// use redstr::Transform;
+    /// let hex = Transform::resolve("hex_encode").unwrap();
+    /// assert_eq!(hex.apply_bytes(&[0xff, 0x00]).unwrap(), "ff00");
+    ///
+    /// let leet = Transform::resolve("leetspeak").unwrap();
+    /// assert!(leet.apply_bytes(&[0xff]).is_none());
+    /// ```
+    pub fn apply_bytes(&self, input: &[u8]) -> Option<String> {
+        match std::str::from_utf8(input) {
+            Ok(text) => Some(self.apply(text)),
+            Err(_) => self.byte_fn().map(|apply| {
+                observe(
+                    self.slot,
+                    self.entry.name,
+                    self.entry.version,
+                    input.len(),
+                    || apply(input),
+                )
+            }),
+        }
+    }
+
+    /// Describes the transform for catalogs and generated documentation.
+    pub fn info(&self) -> TransformInfo {
+        let name = self.entry.name;
+        TransformInfo {
+            name,
+            version: self.entry.version,
+            category: self.entry.category,
+            stability: if EXPERIMENTAL_TRANSFORMS.contains(&name) {
+                Stability::Experimental
+            } else {
+                Stability::Stable
+            },
+            deterministic: DETERMINISTIC_TRANSFORMS.contains(&name),
+            reversible: REVERSIBLE_TRANSFORMS.contains(&name),
+            input: if self.supports_bytes() {
+                TransformInput::TextOrBytes
+            } else {
+                TransformInput::Text
+            },
+        }
+    }
+
+    fn byte_fn(&self) -> Option<ByteTransformFn> {
+        BYTE_TRANSFORMS
+            .iter()
+            .find(|(name, version, _)| *name == self.entry.name && *version == self.entry.version)
+            .map(|(_, _, apply)| *apply)
+    }
+}
+
+/// Returns the latest behavior version of a named transform.
+///
+/// Returns `None` if no transform is registered under `name`.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::transform_version;
+/// assert_eq!(transform_version("leetspeak"), Some(1));
+/// assert_eq!(transform_version("no_such_transform"), None);
+/// ```
+pub fn transform_version(name: &str) -> Option<u32> {
+    TRANSFORMS
+        .iter()
+        .filter(|entry| entry.name == name)
+        .map(|entry| entry.version)
+        .max()
+}
+
+/// Returns the replacement for a deprecated transform name.
+///
+/// Deprecated names are still accepted everywhere a spec is, but are not
+/// listed by [`transform_names`]. Use this to lint stored recipes without
+/// installing a [`set_deprecation_hook`](crate::set_deprecation_hook).
+///
+/// # Examples
+///
+/// ```
+/// use redstr::deprecated_name;
+/// assert_eq!(
+///     deprecated_name("cloudflare_challenge").unwrap().replacement,
+///     "cloudflare_challenge_variation"
+/// );
+/// assert!(deprecated_name("cloudflare_challenge_variation").is_none());
+/// ```
+pub fn deprecated_name(name: &str) -> Option<Deprecation> {
+    ALIASES
+        .iter()
+        .find(|(alias, _)| *alias == name)
+        .map(|&(name, replacement)| Deprecation { name, replacement })
+}
+
+/// Returns the names of all registered transforms, in registry order.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::transform_names;
+/// let names = transform_names();
+/// assert!(names.contains(&"leetspeak"));
+/// ```
+pub fn transform_names() -> Vec<&'static str> {
+    let mut names: Vec<&'static str> = Vec::new();
+    for entry in TRANSFORMS {
+        if !names.contains(&entry.name) {
+            names.push(entry.name);
+        }
+    }
+    names
+}
+
+/// Number of registered entries, counting each version of a transform.
+pub(crate) fn entry_count() -> usize {
+    TRANSFORMS.len()
+}
+
+/// Registry index of `name` at `version`, if it is registered.
+pub(crate) fn entry_slot(name: &str, version: u32) -> Option<usize> {
+    TRANSFORMS
+        .iter()
+        .position(|entry| entry.name == name && entry.version == version)
+}
+
+/// Name of the entry at a registry index from [`entry_slot`].
+pub(crate) fn slot_name(slot: usize) -> &'static str {
+    TRANSFORMS[slot].name
+}
+
+/// Applies a single named transform, optionally pinned to a behavior version.
+///
+/// Useful for pipelines that store transforms by name (config files, CLI flags,
+/// binding layers) instead of calling functions directly.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::apply_transform;
+/// assert_eq!(apply_transform("base64_encode@1", "hello").unwrap(), "aGVsbG8=");
+/// assert!(apply_transform("base64_encode@99", "hello").is_err());
+/// ```
+pub fn apply_transform(spec: &str, input: &str) -> Result<String, TransformError> {
+    Ok(Transform::resolve(spec)?.apply(input))
+}
+
+/// Applies a serialized recipe of `|`-separated transform specs in order.
+///
+/// Every step is resolved before any of them runs, so a recipe with an unknown
+/// step fails without doing partial work. An empty recipe returns the input.
+///
+/// Recipes produced by [`TransformBuilder::recipe`](crate::TransformBuilder::recipe)
+/// pin every step to the version that was used, so replaying them after an
+/// upgrade yields the same behavior.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::apply_recipe;
+/// let result = apply_recipe("rot13@1 | base64_encode@1", "hello").unwrap();
+/// assert_eq!(result, "dXJ5eWI=");
+/// ```
+pub fn apply_recipe(recipe: &str, input: &str) -> Result<String, TransformError> {
+    let steps = resolve_recipe(recipe)?;
+    let span = TraceSpan::start(Operation::Pipeline, || steps_recipe(&steps), 1);
+    let output = run_steps(&steps, input);
+    span.finish_counts(SpanStatus::Ok, 1, output.len());
+    Ok(output)
+}
+
+/// Resolves every step of a recipe; an empty recipe has no steps.
+pub(crate) fn resolve_recipe(recipe: &str) -> Result<Vec<Transform>, TransformError> {
+    if recipe.trim().is_empty() {
+        return Ok(Vec::new());
+    }
+    recipe
+        .split(RECIPE_SEPARATOR)
+        .map(Transform::resolve)
+        .collect()
+}
+
+/// Applies resolved steps to `input` in order.
+pub(crate) fn run_steps(steps: &[Transform], input: &str) -> String {
+    let mut text = input.to_string();
+    for transform in steps {
+        text = transform.apply(&text);
+    }
+    text
+}
+
+/// Formats resolved steps as a recipe with every step pinned.
+pub(crate) fn steps_recipe(steps: &[Transform]) -> String {
+    let specs: Vec<_> = steps.iter().map(Transform::spec).collect();
+    format_recipe(&specs)
+}
+
+/// Joins pinned step specs into a recipe string.
+pub(crate) fn format_recipe(steps: &[TransformSpec]) -> String {
+    steps
+        .iter()
+        .map(|spec| spec.to_string())
+        .collect::<Vec<_>>()
+        .join(&format!(" {} ", RECIPE_SEPARATOR))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_registry_names_are_unique_per_version() {
+        for (i, a) in TRANSFORMS.iter().enumerate() {
+            for b in &TRANSFORMS[i + 1..] {
+                assert!(a.name != b.name || a.version != b.version, "{}", a.name);
+            }
+        }
+    }
+
+    #[test]
+    fn test_transform_spec_parse() {
+        let spec = TransformSpec::parse(" rot13 ").unwrap();
+        assert_eq!(spec.name, "rot13");
+        assert_eq!(spec.version, None);
+
+        let spec = TransformSpec::parse("rot13@1").unwrap();
+        assert_eq!(spec.version, Some(1));
+    }
+
+    #[test]
+    fn test_transform_spec_parse_invalid() {
+        assert!(matches!(
+            TransformSpec::parse("rot13@x"),
+            Err(TransformError::InvalidSpec(_))
+        ));
+        assert!(matches!(
+            TransformSpec::parse("@1"),
+            Err(TransformError::InvalidSpec(_))
+        ));
+    }
+
+    #[test]
+    fn test_transform_version() {
+        assert_eq!(transform_version("rot13"), Some(1));
+        assert_eq!(transform_version("rot14"), None);
+    }
+
+    #[test]
+    fn test_transform_names_resolve() {
+        let names = transform_names();
+        for name in names {
+            assert!(Transform::resolve(name).is_ok(), "{}", name);
+        }
+    }
+
+    #[test]
+    fn test_apply_transform_latest_and_pinned() {
+        assert_eq!(apply_transform("rot13", "abc").unwrap(), "nop");
+        assert_eq!(apply_transform("rot13@1", "abc").unwrap(), "nop");
+    }
+
+    #[test]
+    fn test_apply_transform_errors() {
+        assert_eq!(
+            apply_transform("rot14", "abc"),
+            Err(TransformError::UnknownTransform("rot14".to_string()))
+        );
+        assert_eq!(
+            apply_transform("rot13@7", "abc"),
+            Err(TransformError::UnsupportedVersion {
+                name: "rot13".to_string(),
+                version: 7
+            })
+        );
+    }
+
+    fn shout_v1(input: &str) -> String {
+        input.to_uppercase()
+    }
+
+    fn shout_v2(input: &str) -> String {
+        format!("{}!", input.to_uppercase())
+    }
+
+    /// A transform whose behavior changed once, registered under both versions.
+    const VERSIONED: &[TransformEntry] = &[
+        entry(TransformCategory::Case, "shout", 1, shout_v1),
+        entry(TransformCategory::Case, "shout", 2, shout_v2),
+    ];
+
+    #[test]
+    fn test_lookup_picks_latest_or_pinned_version() {
+        let latest = TransformSpec::parse("shout").unwrap();
+        let (slot, entry) = lookup(VERSIONED, "shout", &latest).unwrap();
+        assert_eq!((slot, entry.version), (1, 2));
+        assert_eq!((entry.apply)("hi"), "HI!");
+
+        let pinned = TransformSpec::parse("shout@1").unwrap();
+        let (slot, entry) = lookup(VERSIONED, "shout", &pinned).unwrap();
+        assert_eq!((slot, entry.version), (0, 1));
+        assert_eq!((entry.apply)("hi"), "HI");
+    }
+
+    #[test]
+    fn test_lookup_errors() {
+        let unknown_version = TransformSpec::parse("shout@3").unwrap();
+        assert_eq!(
+            lookup(VERSIONED, "shout", &unknown_version).unwrap_err(),
+            TransformError::UnsupportedVersion {
+                name: "shout".to_string(),
+                version: 3
+            }
+        );
+        let unknown_name = TransformSpec::parse("whisper@1").unwrap();
+        assert_eq!(
+            lookup(VERSIONED, "whisper", &unknown_name).unwrap_err(),
+            TransformError::UnknownTransform("whisper".to_string())
+        );
+    }
+
+    #[test]
+    fn test_transform_resolve() {
+        let transform = Transform::resolve("rot13").unwrap();
+        assert_eq!(transform.name(), "rot13");
+        assert_eq!(transform.version(), 1);
+        assert_eq!(transform.spec().to_string(), "rot13@1");
+        assert_eq!(transform.apply("abc"), "nop");
+        assert!(Transform::resolve("rot13@2").is_err());
+    }
+
+    #[test]
+    fn test_apply_recipe() {
+        let result = apply_recipe("reverse_string|to_snake_case@1", "olleH").unwrap();
+        assert_eq!(result, "hello");
+    }
+
+    #[test]
+    fn test_apply_recipe_validates_before_running() {
+        assert!(apply_recipe("rot13|nope", "abc").is_err());
+        assert!(apply_recipe("rot13||rot13", "abc").is_err());
+    }
+
+    #[test]
+    fn test_apply_recipe_empty() {
+        assert_eq!(apply_recipe("", "abc").unwrap(), "abc");
+        assert_eq!(apply_recipe("  ", "abc").unwrap(), "abc");
+    }
+
+    #[test]
+    fn test_format_recipe_roundtrip() {
+        let steps = vec![
+            TransformSpec::parse("rot13@1").unwrap(),
+            TransformSpec::parse("hex_encode@1").unwrap(),
+        ];
+        let recipe = format_recipe(&steps);
+        assert_eq!(recipe, "rot13@1 | hex_encode@1");
+        assert_eq!(apply_recipe(&recipe, "a").unwrap(), "6e");
+    }
+
+    #[test]
+    fn test_error_display() {
+        let err = TransformError::UnsupportedVersion {
+            name: "leetspeak".to_string(),
+            version: 2,
+        };
+        assert_eq!(err.to_string(), "transform leetspeak has no version 2");
+    }
+
+    #[test]
+    fn test_transform_apply_detailed() {
+        let transform = Transform::resolve("hex_encode@1").unwrap();
+        let result = transform.apply_detailed("hi");
+        assert_eq!(result.output, "6869");
+        assert_eq!(result.technique, "hex_encode");
+        assert_eq!(result.parameters, vec![("version", "1".to_string())]);
+        assert!(result.reversible);
+
+        let result = Transform::resolve("leetspeak")
+            .unwrap()
+            .apply_detailed("hi");
+        assert!(!result.reversible);
+    }
+
+    #[test]
+    fn test_reversible_transforms_are_registered() {
+        for name in REVERSIBLE_TRANSFORMS {
+            assert!(transform_version(name).is_some(), "{}", name);
+        }
+    }
+
+    #[test]
+    fn test_aliases_resolve_to_registered_transforms() {
+        for (alias, target) in ALIASES {
+            assert!(
+                transform_version(alias).is_none(),
+                "{} shadows a transform",
+                alias
+            );
+            let transform = Transform::resolve(alias).unwrap();
+            assert_eq!(transform.name(), *target);
+        }
+        let pinned = Transform::resolve("cloudflare_turnstile@1").unwrap();
+        assert_eq!(
+            pinned.spec().to_string(),
+            "cloudflare_turnstile_variation@1"
+        );
+        assert!(matches!(
+            Transform::resolve("cloudflare_challenge@9"),
+            Err(TransformError::UnsupportedVersion { .. })
+        ));
+        assert!(matches!(
+            Transform::resolve("base64"),
+            Err(TransformError::UnknownTransform(_))
+        ));
+    }
+
+    #[test]
+    fn test_catalog_lists_are_registered() {
+        for name in DETERMINISTIC_TRANSFORMS
+            .iter()
+            .chain(EXPERIMENTAL_TRANSFORMS)
+        {
+            assert!(transform_version(name).is_some(), "{}", name);
+        }
+    }
+
+    #[test]
+    fn test_byte_transforms_agree_with_string_transforms() {
+        let text = "p\u{e4}ss w0rd/\u{1f600}~";
+        for (name, version, apply) in BYTE_TRANSFORMS {
+            let entry = TRANSFORMS
+                .iter()
+                .find(|e| e.name == *name && e.version == *version)
+                .unwrap_or_else(|| panic!("{} is not registered", name));
+            if *name != "hex_encode_mixed" {
+                assert_eq!(apply(text.as_bytes()), (entry.apply)(text), "{}", name);
+            }
+        }
+    }
+
+    #[test]
+    fn test_write_transforms_agree_with_string_transforms() {
+        let text = "p\u{e4}ss w0rd/\u{1f600}~";
+        for (name, version, write) in WRITE_TRANSFORMS {
+            let entry = TRANSFORMS
+                .iter()
+                .find(|e| e.name == *name && e.version == *version)
+                .unwrap_or_else(|| panic!("{} is not registered", name));
+            let mut out = String::from("prefix:");
+            write(text, &mut out);
+            assert_eq!(out, format!("prefix:{}", (entry.apply)(text)), "{}", name);
+        }
+    }
+
+    #[test]
+    fn test_apply_bytes() {
+        let invalid = [0x41, 0xff, 0x00, 0x7e];
+        let apply = |name: &str| Transform::resolve(name).unwrap().apply_bytes(&invalid);
+        assert_eq!(apply("hex_encode").unwrap(), "41ff007e");
+        assert_eq!(apply("base64_encode").unwrap(), "Qf8Afg==");
+        assert_eq!(apply("url_encode").unwrap(), "A%FF%00~");
+        assert_eq!(apply("sql_string_to_hex_literal").unwrap(), "0x41ff007e");
+        assert_eq!(apply("hex_encode_mixed").unwrap().matches("ff").count(), 1);
+        assert_eq!(apply("rot13"), None);
+
+        let rot13 = Transform::resolve("rot13").unwrap();
+        assert!(!rot13.supports_bytes());
+        assert_eq!(rot13.apply_bytes(b"abc").unwrap(), "nop");
+        assert!(Transform::resolve("hex_encode").unwrap().supports_bytes());
+    }
+}
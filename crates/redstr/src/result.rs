@@ -0,0 +1,70 @@
+use std::fmt;
+
+/// A transform output together with the technique that produced it.
+///
+/// Returned by the `..._detailed` variants of functions that pick a technique
+/// at random (which comment style, which host encoding, ...) and by
+/// [`Transform::apply_detailed`](crate::Transform::apply_detailed), so
+/// pipelines can log exactly how each payload was built.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::registry_run_key_obfuscate_detailed;
+/// let result = registry_run_key_obfuscate_detailed("calc");
+/// println!("{} via {}", result.output, result.technique);
+/// assert!(result.output.len() > 4);
+/// ```
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TransformResult {
+    /// The transformed text, identical to what the plain function returns.
+    pub output: String,
+    /// Short snake_case name of the technique that was applied.
+    pub technique: &'static str,
+    /// Technique-specific choices, in the order they were made.
+    pub parameters: Vec<(&'static str, String)>,
+    /// Whether the original input can be recovered from `output` alone.
+    pub reversible: bool,
+}
+
+impl TransformResult {
+    /// Returns the value of a named parameter, if it was recorded.
+    pub fn parameter(&self, name: &str) -> Option<&str> {
+        self.parameters
+            .iter()
+            .find(|(key, _)| *key == name)
+            .map(|(_, value)| value.as_str())
+    }
+}
+
+impl fmt::Display for TransformResult {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(&self.output)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn sample() -> TransformResult {
+        TransformResult {
+            output: "aGk=".to_string(),
+            technique: "base64",
+            parameters: vec![("alphabet", "standard".to_string())],
+            reversible: true,
+        }
+    }
+
+    #[test]
+    fn test_transform_result_parameter() {
+        let result = sample();
+        assert_eq!(result.parameter("alphabet"), Some("standard"));
+        assert_eq!(result.parameter("padding"), None);
+    }
+
+    #[test]
+    fn test_transform_result_display() {
+        assert_eq!(sample().to_string(), "aGk=");
+    }
+}
@@ -1,4 +1,9 @@
+use std::cell::Cell;
+use std::collections::hash_map::RandomState;
+use std::fmt;
+use std::hash::{BuildHasher, Hasher};
 use std::sync::atomic::{AtomicU64, Ordering};
+use std::sync::{Arc, RwLock};
 use std::time::{SystemTime, UNIX_EPOCH};
 
 /// Simple pseudo-random number generator using LCG algorithm
@@ -8,16 +13,201 @@ pub(crate) struct SimpleRng {
 
 static RNG_SEED_COUNTER: AtomicU64 = AtomicU64::new(0);
 
+/// Increment of the SplitMix64 sequence (the 64-bit golden ratio).
+const GOLDEN_GAMMA: u64 = 0x9E37_79B9_7F4A_7C15;
+
+/// Where generators get their seeds from.
+///
+/// Every randomized transform creates a generator per call and seeds it from
+/// the process-wide source chosen with [`set_rand_source`]. The source only
+/// provides seeds: draws within one call always come from redstr's own
+/// generator, which is fast but not cryptographically secure.
+///
+/// [`Session`](crate::Session) calls ignore the source and use the session
+/// seed, so sessions stay replayable whatever the policy.
+#[derive(Clone, Default)]
+pub enum RandSource {
+    /// Seeds from the clock mixed with a per-process counter (the default).
+    ///
+    /// On `wasm32-unknown-unknown`, which has no clock, only the counter is
+    /// used, so hosts there should install [`Custom`](RandSource::Custom).
+    #[default]
+    Time,
+    /// Seeds from the operating system's random source, through the
+    /// per-thread keys std draws for `RandomState`.
+    Os,
+    /// Seeds from one stream started at a fixed seed.
+    ///
+    /// Single-threaded programs that make the same calls in the same order
+    /// get the same output; with several threads the order in which they
+    /// take seeds decides which call gets which.
+    Seeded(u64),
+    /// Seeds from a caller-supplied function, for example an application's
+    /// CSPRNG or a source shared with another language through the FFI.
+    Custom(Arc<dyn Fn() -> u64 + Send + Sync>),
+}
+
+impl fmt::Debug for RandSource {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            RandSource::Time => f.write_str("Time"),
+            RandSource::Os => f.write_str("Os"),
+            RandSource::Seeded(seed) => f.debug_tuple("Seeded").field(seed).finish(),
+            RandSource::Custom(_) => f.write_str("Custom(..)"),
+        }
+    }
+}
+
+/// The process-wide seed source.
+static RAND_SOURCE: RwLock<RandSource> = RwLock::new(RandSource::Time);
+
+/// Current position of the [`RandSource::Seeded`] stream.
+static SEEDED_STATE: AtomicU64 = AtomicU64::new(0);
+
+/// Chooses where every subsequently created generator takes its seed from.
+///
+/// The setting is process-wide and applies to all threads; embedding
+/// applications typically call it once at startup.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Switch to OS entropy so generated payloads are not predictable from timing
+/// - **Blue Team**: Fix a seed so a test corpus is identical across CI runs
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{leetspeak, set_rand_source, RandSource};
+///
+/// set_rand_source(RandSource::Seeded(7));
+/// let first = leetspeak("password");
+/// set_rand_source(RandSource::Seeded(7));
+/// assert_eq!(leetspeak("password"), first);
+///
+/// set_rand_source(RandSource::Time);
+/// ```
+pub fn set_rand_source(source: RandSource) {
+    let mut current = RAND_SOURCE.write().unwrap_or_else(|e| e.into_inner());
+    if let RandSource::Seeded(seed) = source {
+        SEEDED_STATE.store(seed, Ordering::SeqCst);
+    }
+    *current = source;
+}
+
+/// Draws the next generator seed from the process-wide source.
+fn source_seed() -> u64 {
+    // Cloned out of the lock, so a custom source may itself draw seeds or
+    // replace the source.
+    let source = RAND_SOURCE
+        .read()
+        .unwrap_or_else(|e| e.into_inner())
+        .clone();
+    match source {
+        RandSource::Time => {
+            let time_seed = clock_nanos();
+            let counter_seed = RNG_SEED_COUNTER.fetch_add(1, Ordering::Relaxed);
+            time_seed ^ counter_seed.rotate_left(17) ^ GOLDEN_GAMMA
+        }
+        RandSource::Os => {
+            let mut hasher = RandomState::new().build_hasher();
+            hasher.write_u64(RNG_SEED_COUNTER.fetch_add(1, Ordering::Relaxed));
+            hasher.finish()
+        }
+        RandSource::Seeded(_) => {
+            let state = SEEDED_STATE
+                .fetch_add(GOLDEN_GAMMA, Ordering::Relaxed)
+                .wrapping_add(GOLDEN_GAMMA);
+            mix64(state)
+        }
+        RandSource::Custom(source) => source(),
+    }
+}
+
+/// Nanoseconds since the Unix epoch, or 0 where there is no clock.
+///
+/// `wasm32-unknown-unknown` has no clock and `SystemTime::now` panics there;
+/// seeds then come from the counter alone unless the host installs a source.
+fn clock_nanos() -> u64 {
+    if cfg!(all(target_arch = "wasm32", target_os = "unknown")) {
+        return 0;
+    }
+    SystemTime::now()
+        .duration_since(UNIX_EPOCH)
+        .map(|duration| duration.as_nanos() as u64)
+        .unwrap_or(0)
+}
+
+thread_local! {
+    /// Seed stream for generators created inside [`with_seed`], if any.
+    static SEED_STREAM: Cell<Option<u64>> = const { Cell::new(None) };
+
+    /// Inside [`capture_seed`]: the first seed drawn so far, if any.
+    static SEED_CAPTURE: Cell<Option<Option<u64>>> = const { Cell::new(None) };
+}
+
+/// SplitMix64 output function.
+fn mix64(mut z: u64) -> u64 {
+    z = (z ^ (z >> 30)).wrapping_mul(0xBF58_476D_1CE4_E5B9);
+    z = (z ^ (z >> 27)).wrapping_mul(0x94D0_49BB_1331_11EB);
+    z ^ (z >> 31)
+}
+
+/// SplitMix64 step, used to derive independent seeds from one stream.
+fn splitmix64(state: &mut u64) -> u64 {
+    *state = state.wrapping_add(GOLDEN_GAMMA);
+    mix64(*state)
+}
+
+/// Runs `f` with every [`SimpleRng::new`] on this thread seeded from `seed`.
+///
+/// Generators are seeded in creation order, so the same `f` run with the
+/// same seed makes the same random choices. The previous seeding is
+/// restored afterwards, even if `f` panics.
+pub(crate) fn with_seed<T>(seed: u64, f: impl FnOnce() -> T) -> T {
+    struct Restore(Option<u64>);
+    impl Drop for Restore {
+        fn drop(&mut self) {
+            SEED_STREAM.with(|stream| stream.set(self.0));
+        }
+    }
+
+    let _restore = Restore(SEED_STREAM.with(|stream| stream.replace(Some(seed))));
+    f()
+}
+
+/// Runs `f` and returns the seed of the first generator it created on this
+/// thread, or `None` if it drew no randomness.
+pub(crate) fn capture_seed<T>(f: impl FnOnce() -> T) -> (T, Option<u64>) {
+    struct Restore(Option<Option<u64>>);
+    impl Drop for Restore {
+        fn drop(&mut self) {
+            SEED_CAPTURE.with(|capture| capture.set(self.0));
+        }
+    }
+
+    let _restore = Restore(SEED_CAPTURE.with(|capture| capture.replace(Some(None))));
+    let result = f();
+    let seed = SEED_CAPTURE.with(|capture| capture.get()).flatten();
+    (result, seed)
+}
+
 impl SimpleRng {
     pub(crate) fn new() -> Self {
-        let time_seed = SystemTime::now()
-            .duration_since(UNIX_EPOCH)
-            .map(|duration| duration.as_nanos() as u64)
-            .unwrap_or(0);
-        let counter_seed = RNG_SEED_COUNTER.fetch_add(1, Ordering::Relaxed);
-        let seed = time_seed ^ counter_seed.rotate_left(17) ^ 0x9E37_79B9_7F4A_7C15;
+        let seeded = SEED_STREAM.with(|stream| {
+            stream.get().map(|mut state| {
+                let seed = splitmix64(&mut state);
+                stream.set(Some(state));
+                seed
+            })
+        });
+        let state = seeded.unwrap_or_else(source_seed);
+        SEED_CAPTURE.with(|capture| {
+            if capture.get() == Some(None) {
+                capture.set(Some(Some(state)));
+            }
+        });
 
-        SimpleRng { state: seed }
+        SimpleRng { state }
     }
 
     pub(crate) fn next(&mut self) -> u64 {
@@ -32,3 +222,65 @@ impl SimpleRng {
         x ^ (x >> 33)
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn draw() -> Vec<u64> {
+        let mut a = SimpleRng::new();
+        let mut b = SimpleRng::new();
+        vec![a.next(), a.next(), b.next()]
+    }
+
+    #[test]
+    fn test_with_seed_is_deterministic() {
+        assert_eq!(with_seed(7, draw), with_seed(7, draw));
+        assert_ne!(with_seed(7, draw), with_seed(8, draw));
+    }
+
+    #[test]
+    fn test_capture_seed_reports_first_generator() {
+        let (values, seed) = capture_seed(|| with_seed(7, draw));
+        let mut replay = SimpleRng {
+            state: seed.unwrap(),
+        };
+        assert_eq!(replay.next(), values[0]);
+        assert_eq!(capture_seed(|| 1), (1, None));
+    }
+
+    #[test]
+    fn test_with_seed_generators_differ() {
+        let values = with_seed(7, draw);
+        assert_ne!(values[0], values[2]);
+    }
+
+    #[test]
+    fn test_with_seed_restores_previous_stream() {
+        let (inner, outer) = with_seed(1, || {
+            let _ = with_seed(2, draw);
+            (with_seed(2, draw), draw())
+        });
+        assert_eq!(inner, with_seed(2, draw));
+        assert_eq!(outer, with_seed(1, draw));
+        assert!(SEED_STREAM.with(|stream| stream.get()).is_none());
+    }
+
+    #[test]
+    fn test_rand_source_debug() {
+        assert_eq!(format!("{:?}", RandSource::default()), "Time");
+        assert_eq!(format!("{:?}", RandSource::Seeded(3)), "Seeded(3)");
+        assert_eq!(
+            format!("{:?}", RandSource::Custom(Arc::new(|| 0))),
+            "Custom(..)"
+        );
+    }
+
+    #[test]
+    fn test_mix64_spreads_sequential_states() {
+        let a = mix64(GOLDEN_GAMMA);
+        let b = mix64(GOLDEN_GAMMA.wrapping_mul(2));
+        assert_ne!(a, b);
+        assert!((a ^ b).count_ones() > 16);
+    }
+}
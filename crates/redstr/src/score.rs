@@ -0,0 +1,448 @@
+use std::cmp::Ordering;
+use std::fmt;
+
+use crate::transformations::encoding::{base64url_decode, percent_decode};
+
+/// Most encoding layers peeled off when measuring encoding depth.
+const MAX_ENCODING_DEPTH: usize = 8;
+
+/// Keywords in the spirit of the OWASP CRS SQL injection rules.
+const SQL_TOKENS: &[&str] = &[
+    "union",
+    "select",
+    "insert",
+    "update",
+    "delete",
+    "drop",
+    "information_schema",
+    "sleep(",
+    "benchmark(",
+    "waitfor delay",
+    "xp_cmdshell",
+    "' or",
+    "or 1=1",
+    "--",
+    "/*",
+];
+
+/// Keywords in the spirit of the OWASP CRS XSS rules.
+const XSS_TOKENS: &[&str] = &[
+    "<script",
+    "javascript:",
+    "onerror",
+    "onload",
+    "onmouseover",
+    "alert(",
+    "prompt(",
+    "confirm(",
+    "eval(",
+    "document.cookie",
+    "fromcharcode",
+    "<iframe",
+    "<svg",
+];
+
+/// Keywords in the spirit of the OWASP CRS remote command execution rules.
+const COMMAND_TOKENS: &[&str] = &[
+    "$(",
+    "`",
+    "&&",
+    "||",
+    "/bin/sh",
+    "/bin/bash",
+    "cmd.exe",
+    "powershell",
+    "wget ",
+    "curl ",
+    "nc ",
+    "whoami",
+    "base64 -d",
+    "iex",
+];
+
+/// Keywords in the spirit of the OWASP CRS local file inclusion rules.
+const PATH_TRAVERSAL_TOKENS: &[&str] = &[
+    "../",
+    "..\\",
+    "%2e%2e",
+    "/etc/passwd",
+    "/etc/shadow",
+    "/proc/self",
+    "win.ini",
+    "boot.ini",
+];
+
+/// The kind of payload being scored, selecting which keywords count as suspicious.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum PayloadContext {
+    /// SQL injection.
+    Sql,
+    /// Cross-site scripting.
+    Xss,
+    /// OS command injection.
+    Command,
+    /// Path traversal and local file inclusion.
+    PathTraversal,
+    /// Unknown context: keywords of every other context apply.
+    Generic,
+}
+
+impl PayloadContext {
+    /// Returns the context name in lowercase (e.g. `"sql"`).
+    pub fn name(self) -> &'static str {
+        match self {
+            PayloadContext::Sql => "sql",
+            PayloadContext::Xss => "xss",
+            PayloadContext::Command => "command",
+            PayloadContext::PathTraversal => "path_traversal",
+            PayloadContext::Generic => "generic",
+        }
+    }
+
+    fn tokens(self) -> Vec<&'static str> {
+        match self {
+            PayloadContext::Sql => SQL_TOKENS.to_vec(),
+            PayloadContext::Xss => XSS_TOKENS.to_vec(),
+            PayloadContext::Command => COMMAND_TOKENS.to_vec(),
+            PayloadContext::PathTraversal => PATH_TRAVERSAL_TOKENS.to_vec(),
+            PayloadContext::Generic => [
+                SQL_TOKENS,
+                XSS_TOKENS,
+                COMMAND_TOKENS,
+                PATH_TRAVERSAL_TOKENS,
+            ]
+            .concat(),
+        }
+    }
+}
+
+impl fmt::Display for PayloadContext {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// How likely a payload is to be flagged, with the signals behind it.
+#[derive(Debug, Clone, PartialEq)]
+pub struct DetectabilityScore {
+    /// Combined score from `0.0` (unremarkable) to `1.0` (almost certainly flagged).
+    pub score: f64,
+    /// Shannon entropy of the payload bytes, in bits per byte (`0.0` to `8.0`).
+    pub entropy: f64,
+    /// Suspicious keywords visible to a WAF that lowercases and URL-decodes once.
+    pub matched_tokens: Vec<&'static str>,
+    /// Number of URL, HTML entity, hex or base64 layers wrapped around the payload.
+    pub encoding_depth: usize,
+}
+
+/// Shannon entropy of `bytes`, in bits per byte.
+fn shannon_entropy(bytes: &[u8]) -> f64 {
+    if bytes.is_empty() {
+        return 0.0;
+    }
+    let mut counts = [0usize; 256];
+    for &b in bytes {
+        counts[b as usize] += 1;
+    }
+    let len = bytes.len() as f64;
+    counts
+        .iter()
+        .filter(|&&n| n > 0)
+        .map(|&n| {
+            let p = n as f64 / len;
+            -p * p.log2()
+        })
+        .sum()
+}
+
+/// Returns `bytes` as text if it is UTF-8 without control characters.
+fn printable(bytes: Vec<u8>) -> Option<String> {
+    let text = String::from_utf8(bytes).ok()?;
+    let clean = !text.is_empty()
+        && text
+            .chars()
+            .all(|c| !c.is_control() || matches!(c, '\t' | '\n' | '\r'));
+    clean.then_some(text)
+}
+
+/// Decodes `&#NN;`, `&#xNN;` and the common named entities.
+fn html_entity_decode(text: &str) -> String {
+    let mut out = String::new();
+    let mut rest = text;
+    while let Some(amp) = rest.find('&') {
+        out.push_str(&rest[..amp]);
+        rest = &rest[amp..];
+        let decoded = rest.find(';').and_then(|semi| {
+            let entity = &rest[1..semi];
+            let c = match entity {
+                "lt" => Some('<'),
+                "gt" => Some('>'),
+                "amp" => Some('&'),
+                "quot" => Some('"'),
+                "apos" => Some('\''),
+                _ => {
+                    let code = match entity.strip_prefix("#x").or(entity.strip_prefix("#X")) {
+                        Some(hex) => u32::from_str_radix(hex, 16).ok(),
+                        None => entity.strip_prefix('#').and_then(|dec| dec.parse().ok()),
+                    };
+                    code.and_then(char::from_u32)
+                }
+            };
+            c.map(|c| (c, semi))
+        });
+        match decoded {
+            Some((c, semi)) => {
+                out.push(c);
+                rest = &rest[semi + 1..];
+            }
+            None => {
+                out.push('&');
+                rest = &rest[1..];
+            }
+        }
+    }
+    out.push_str(rest);
+    out
+}
+
+/// Peels one encoding layer off `text`, if it has one.
+fn decode_layer(text: &str) -> Option<String> {
+    let bytes = text.as_bytes();
+    let has_percent = bytes
+        .windows(3)
+        .any(|w| w[0] == b'%' && w[1].is_ascii_hexdigit() && w[2].is_ascii_hexdigit());
+    if has_percent {
+        return printable(percent_decode(text));
+    }
+    if text.contains("&#") || text.contains("&lt;") || text.contains("&quot;") {
+        let decoded = html_entity_decode(text);
+        return (decoded != text).then_some(decoded);
+    }
+    let compact = text.trim();
+    if compact.len() >= 4
+        && compact.len() % 2 == 0
+        && compact.bytes().all(|b| b.is_ascii_hexdigit())
+    {
+        let hex: Option<Vec<u8>> = (0..compact.len())
+            .step_by(2)
+            .map(|i| u8::from_str_radix(&compact[i..i + 2], 16).ok())
+            .collect();
+        if let Some(decoded) = hex.and_then(printable) {
+            return Some(decoded);
+        }
+    }
+    let base64_charset = compact
+        .bytes()
+        .all(|b| b.is_ascii_alphanumeric() || matches!(b, b'+' | b'/' | b'-' | b'_' | b'='));
+    if compact.len() >= 8 && compact.len() % 4 == 0 && base64_charset {
+        return base64url_decode(compact).and_then(printable);
+    }
+    None
+}
+
+/// Scores how likely a payload is to be flagged by signature-based defenses.
+///
+/// Three signals are combined into [`DetectabilityScore::score`]:
+///
+/// - Suspicious keywords for the [`PayloadContext`], modeled on OWASP CRS
+///   rules and matched the way a WAF sees the payload: lowercased and
+///   URL-decoded once (60% weight; each keyword halves the remaining distance to 1)
+/// - Shannon entropy, since encoded blobs stand out from ordinary parameters
+///   (20% weight, ramping from 3.5 to 6 bits per byte)
+/// - Encoding depth, the number of URL, HTML entity, hex or base64 layers
+///   that decode to printable text (20% weight, saturating at four layers)
+///
+/// The score is a heuristic for ranking large generated corpora before
+/// sending them, not a prediction for any specific product.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Send the least detectable variants of a payload first
+/// - **Blue Team**: Find which generated payloads slip past keyword-based rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{detectability_score, PayloadContext};
+/// let plain = detectability_score("' OR 1=1 UNION SELECT password FROM users--", PayloadContext::Sql);
+/// let quiet = detectability_score("42", PayloadContext::Sql);
+/// assert!(plain.score > quiet.score);
+/// assert!(plain.matched_tokens.contains(&"union"));
+/// ```
+pub fn detectability_score(payload: &str, context: PayloadContext) -> DetectabilityScore {
+    let lower = payload.to_lowercase();
+    let decoded = String::from_utf8_lossy(&percent_decode(&lower)).into_owned();
+    let matched_tokens: Vec<&'static str> = context
+        .tokens()
+        .into_iter()
+        .filter(|token| lower.contains(token) || decoded.contains(token))
+        .collect();
+
+    let entropy = shannon_entropy(payload.as_bytes());
+
+    let mut encoding_depth = 0;
+    let mut layer = payload.to_string();
+    while encoding_depth < MAX_ENCODING_DEPTH {
+        match decode_layer(&layer) {
+            Some(next) => {
+                layer = next;
+                encoding_depth += 1;
+            }
+            None => break,
+        }
+    }
+
+    let token_signal = 1.0 - 0.5f64.powi(matched_tokens.len() as i32);
+    let entropy_signal = ((entropy - 3.5) / 2.5).clamp(0.0, 1.0);
+    let depth_signal = (encoding_depth.min(4) as f64) / 4.0;
+
+    DetectabilityScore {
+        score: 0.6 * token_signal + 0.2 * entropy_signal + 0.2 * depth_signal,
+        entropy,
+        matched_tokens,
+        encoding_depth,
+    }
+}
+
+/// Scores every payload and sorts them from least to most detectable.
+///
+/// Ties keep their original order.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Order a generated corpus so quiet variants are tried first
+/// - **Blue Team**: Review the payloads most likely to evade rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{rank_by_detectability, PayloadContext};
+/// let payloads = ["<script>alert(1)</script>", "hello"];
+/// let ranked = rank_by_detectability(&payloads, PayloadContext::Xss);
+/// assert_eq!(ranked[0].0, "hello");
+/// ```
+pub fn rank_by_detectability<S: AsRef<str>>(
+    payloads: &[S],
+    context: PayloadContext,
+) -> Vec<(&str, DetectabilityScore)> {
+    let mut ranked: Vec<(&str, DetectabilityScore)> = payloads
+        .iter()
+        .map(|payload| {
+            let payload = payload.as_ref();
+            (payload, detectability_score(payload, context))
+        })
+        .collect();
+    ranked.sort_by(|a, b| a.1.score.partial_cmp(&b.1.score).unwrap_or(Ordering::Equal));
+    ranked
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::transformations::encoding::{base64_encode, hex_encode, url_encode};
+
+    #[test]
+    fn test_shannon_entropy() {
+        assert_eq!(shannon_entropy(b""), 0.0);
+        assert_eq!(shannon_entropy(b"aaaa"), 0.0);
+        assert!((shannon_entropy(b"abcd") - 2.0).abs() < 1e-9);
+        let all: Vec<u8> = (0..=255).collect();
+        assert!((shannon_entropy(&all) - 8.0).abs() < 1e-9);
+    }
+
+    #[test]
+    fn test_detectability_tokens_per_context() {
+        let payload = "<script>alert(1)</script>";
+        let xss = detectability_score(payload, PayloadContext::Xss);
+        assert_eq!(xss.matched_tokens, vec!["<script", "alert("]);
+        let sql = detectability_score(payload, PayloadContext::Sql);
+        assert!(sql.matched_tokens.is_empty());
+        let generic = detectability_score(payload, PayloadContext::Generic);
+        assert_eq!(generic.matched_tokens, xss.matched_tokens);
+        assert!(xss.score > sql.score);
+    }
+
+    #[test]
+    fn test_detectability_tokens_case_and_url_decoding() {
+        let score = detectability_score("UnIoN%20SeLeCt", PayloadContext::Sql);
+        assert_eq!(score.matched_tokens, vec!["union", "select"]);
+        let score = detectability_score("..%2f..%2fetc%2fpasswd", PayloadContext::PathTraversal);
+        assert!(score.matched_tokens.contains(&"../"));
+        assert!(score.matched_tokens.contains(&"/etc/passwd"));
+    }
+
+    #[test]
+    fn test_detectability_encoding_depth() {
+        let payload = "cat /etc/passwd";
+        assert_eq!(
+            detectability_score(payload, PayloadContext::Command).encoding_depth,
+            0
+        );
+        let once = base64_encode(payload);
+        assert_eq!(
+            detectability_score(&once, PayloadContext::Command).encoding_depth,
+            1
+        );
+        // 16 bytes of hex leave base64 padding for url_encode to escape
+        let layered = url_encode(&base64_encode(&hex_encode("cat /etc/passwd;")));
+        assert!(layered.contains("%3D"));
+        assert_eq!(
+            detectability_score(&layered, PayloadContext::Command).encoding_depth,
+            3
+        );
+        let entities = "&#60;script&#62;";
+        assert_eq!(
+            detectability_score(entities, PayloadContext::Xss).encoding_depth,
+            1
+        );
+    }
+
+    #[test]
+    fn test_detectability_encoding_hides_tokens() {
+        let payload = "<script>alert(document.cookie)</script>";
+        let plain = detectability_score(payload, PayloadContext::Xss);
+        let encoded = detectability_score(&base64_encode(payload), PayloadContext::Xss);
+        assert!(encoded.matched_tokens.is_empty());
+        assert!(encoded.score < plain.score);
+        assert!(encoded.entropy > 4.5);
+    }
+
+    #[test]
+    fn test_detectability_plain_words_not_decoded() {
+        for word in ["test", "password", "deadbeef!", "1234"] {
+            assert_eq!(
+                detectability_score(word, PayloadContext::Generic).encoding_depth,
+                0,
+                "{}",
+                word
+            );
+        }
+    }
+
+    #[test]
+    fn test_detectability_score_range() {
+        for payload in ["", "a", "' OR 1=1--", "%3Cscript%3E", &"x".repeat(1000)] {
+            let score = detectability_score(payload, PayloadContext::Generic);
+            assert!((0.0..=1.0).contains(&score.score), "{}", payload);
+        }
+    }
+
+    #[test]
+    fn test_rank_by_detectability() {
+        let payloads = vec![
+            "' UNION SELECT 1--".to_string(),
+            "1".to_string(),
+            "' OR 1=1".to_string(),
+        ];
+        let ranked = rank_by_detectability(&payloads, PayloadContext::Sql);
+        assert_eq!(ranked[0].0, "1");
+        assert_eq!(ranked[2].0, "' UNION SELECT 1--");
+        assert!(ranked[0].1.score <= ranked[1].1.score);
+    }
+
+    #[test]
+    fn test_payload_context_display() {
+        assert_eq!(PayloadContext::PathTraversal.to_string(), "path_traversal");
+        assert_eq!(PayloadContext::Sql.name(), "sql");
+    }
+}
@@ -0,0 +1,342 @@
+use std::fmt;
+
+use crate::json::{json_elements, json_members, json_string, json_unquote};
+use crate::registry::{apply_recipe, format_recipe, Transform, TransformError, TransformSpec};
+use crate::rng::with_seed;
+
+/// One recorded call in a [`Session`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SessionCall {
+    /// Version-pinned recipe that was applied (`leetspeak@1 | base64_encode@1`).
+    pub recipe: String,
+    /// Input text.
+    pub input: String,
+    /// Output text.
+    pub output: String,
+}
+
+/// Errors returned when replaying a session audit log.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum SessionError {
+    /// The log is not a session audit log; holds a description of the problem.
+    InvalidLog(String),
+    /// A recorded recipe no longer resolves.
+    Transform(TransformError),
+    /// A replayed call produced different output than was recorded.
+    OutputMismatch {
+        /// Position of the call in the log.
+        index: usize,
+        /// Output recorded in the log.
+        expected: String,
+        /// Output produced by the replay.
+        actual: String,
+    },
+}
+
+impl fmt::Display for SessionError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            SessionError::InvalidLog(reason) => write!(f, "invalid session log: {}", reason),
+            SessionError::Transform(err) => write!(f, "session replay failed: {}", err),
+            SessionError::OutputMismatch { index, .. } => {
+                write!(f, "replayed call {} produced different output", index)
+            }
+        }
+    }
+}
+
+impl std::error::Error for SessionError {}
+
+impl From<TransformError> for SessionError {
+    fn from(err: TransformError) -> Self {
+        SessionError::Transform(err)
+    }
+}
+
+/// A seeded run of named transforms, recorded for audit and replay.
+///
+/// Every call runs with randomness derived from the session seed and the
+/// call's position, so a session replayed with the same seed produces the
+/// same payloads. Calls go through the transform registry by name, like
+/// [`apply_transform`](crate::apply_transform) and
+/// [`apply_recipe`](crate::apply_recipe), and are recorded pinned to the
+/// behavior version that was used.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Attach an audit log to a report so every payload can be regenerated
+/// - **Blue Team**: Reproduce the exact payloads behind a detection finding
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{replay_session, Session};
+/// let mut session = Session::new(42);
+/// let payload = session.apply("leetspeak", "password").unwrap();
+///
+/// let log = session.to_json();
+/// let replayed = replay_session(&log).unwrap();
+/// assert_eq!(replayed.calls()[0].output, payload);
+/// ```
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Session {
+    seed: u64,
+    calls: Vec<SessionCall>,
+}
+
+impl Session {
+    /// Starts an empty session with the given seed.
+    pub fn new(seed: u64) -> Self {
+        Session {
+            seed,
+            calls: Vec::new(),
+        }
+    }
+
+    /// Returns the session seed.
+    pub fn seed(&self) -> u64 {
+        self.seed
+    }
+
+    /// Returns the calls recorded so far, in order.
+    pub fn calls(&self) -> &[SessionCall] {
+        &self.calls
+    }
+
+    /// Applies a single named transform (`name` or `name@version`) and records it.
+    pub fn apply(&mut self, spec: &str, input: &str) -> Result<String, TransformError> {
+        let transform = Transform::resolve(spec)?;
+        self.record(transform.spec().to_string(), input)
+    }
+
+    /// Applies a `|`-separated recipe and records it as one call.
+    pub fn apply_recipe(&mut self, recipe: &str, input: &str) -> Result<String, TransformError> {
+        let pinned = if recipe.trim().is_empty() {
+            String::new()
+        } else {
+            let steps = recipe
+                .split('|')
+                .map(|step| Transform::resolve(step).map(|t| t.spec()))
+                .collect::<Result<Vec<TransformSpec>, _>>()?;
+            format_recipe(&steps)
+        };
+        self.record(pinned, input)
+    }
+
+    /// Runs a pinned recipe with the next call seed and appends it to the log.
+    fn record(&mut self, recipe: String, input: &str) -> Result<String, TransformError> {
+        let output = self.run(self.calls.len(), &recipe, input)?;
+        self.calls.push(SessionCall {
+            recipe,
+            input: input.to_string(),
+            output: output.clone(),
+        });
+        Ok(output)
+    }
+
+    fn run(&self, index: usize, recipe: &str, input: &str) -> Result<String, TransformError> {
+        // Each call gets its own seed, so replay does not depend on how many
+        // random draws earlier calls made.
+        let call_seed = self.seed ^ (index as u64 + 1).wrapping_mul(0x9E37_79B9_7F4A_7C15);
+        with_seed(call_seed, || apply_recipe(recipe, input))
+    }
+
+    /// Serializes the session as a JSON audit log.
+    ///
+    /// The log has the form
+    /// `{"seed":42,"calls":[{"recipe":"leetspeak@1","input":"...","output":"..."}]}`
+    /// and can be passed to [`replay_session`].
+    pub fn to_json(&self) -> String {
+        let calls: Vec<String> = self
+            .calls
+            .iter()
+            .map(|call| {
+                format!(
+                    "{{\"recipe\":{},\"input\":{},\"output\":{}}}",
+                    json_string(&call.recipe),
+                    json_string(&call.input),
+                    json_string(&call.output)
+                )
+            })
+            .collect();
+        format!("{{\"seed\":{},\"calls\":[{}]}}", self.seed, calls.join(","))
+    }
+}
+
+/// Looks up a member of a parsed JSON object by its unquoted name.
+fn member<'a>(members: &'a [(String, String)], name: &str) -> Result<&'a str, SessionError> {
+    members
+        .iter()
+        .find(|(key, _)| *key == json_string(name))
+        .map(|(_, value)| value.as_str())
+        .ok_or_else(|| SessionError::InvalidLog(format!("missing {}", name)))
+}
+
+/// Decodes a string member of a parsed JSON object.
+fn string_member(members: &[(String, String)], name: &str) -> Result<String, SessionError> {
+    json_unquote(member(members, name)?)
+        .ok_or_else(|| SessionError::InvalidLog(format!("{} is not a string", name)))
+}
+
+/// Regenerates the payloads of a session from its JSON audit log.
+///
+/// Each recorded call is run again with the same seed, recipe and input.
+/// Returns the regenerated session, or
+/// [`SessionError::OutputMismatch`] if any call no longer produces the
+/// recorded output.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Regenerate report payloads exactly from the attached log
+/// - **Blue Team**: Confirm a log's payloads are reproducible before rule tuning
+///
+/// # Examples
+///
+/// ```
+/// use redstr::replay_session;
+/// let log = r#"{"seed":7,"calls":[{"recipe":"rot13@1","input":"hi","output":"uv"}]}"#;
+/// let session = replay_session(log).unwrap();
+/// assert_eq!(session.seed(), 7);
+/// assert_eq!(session.calls()[0].output, "uv");
+/// ```
+pub fn replay_session(log: &str) -> Result<Session, SessionError> {
+    let members =
+        json_members(log).ok_or_else(|| SessionError::InvalidLog("not an object".to_string()))?;
+    let seed = member(&members, "seed")?
+        .parse::<u64>()
+        .map_err(|_| SessionError::InvalidLog("seed is not an unsigned integer".to_string()))?;
+    let calls = json_elements(member(&members, "calls")?)
+        .ok_or_else(|| SessionError::InvalidLog("calls is not an array".to_string()))?;
+
+    let mut session = Session::new(seed);
+    for (index, call) in calls.iter().enumerate() {
+        let fields = json_members(call)
+            .ok_or_else(|| SessionError::InvalidLog(format!("call {} is not an object", index)))?;
+        let recipe = string_member(&fields, "recipe")?;
+        let input = string_member(&fields, "input")?;
+        let expected = string_member(&fields, "output")?;
+
+        let actual = session.record(recipe, &input)?;
+        if actual != expected {
+            return Err(SessionError::OutputMismatch {
+                index,
+                expected,
+                actual,
+            });
+        }
+    }
+    Ok(session)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::registry::transform_names;
+
+    #[test]
+    fn test_session_same_seed_same_outputs() {
+        let run = |seed| {
+            let mut session = Session::new(seed);
+            for _ in 0..3 {
+                session.apply("leetspeak", "password").unwrap();
+                session
+                    .apply("homoglyph_substitution", "paypal.com")
+                    .unwrap();
+            }
+            session
+        };
+        assert_eq!(run(1), run(1));
+        assert_ne!(run(1).calls, run(2).calls);
+    }
+
+    #[test]
+    fn test_session_every_transform_is_reproducible() {
+        let input = "SELECT * FROM users WHERE id = 'admin' <script>alert(1)</script>";
+        let mut first = Session::new(99);
+        for name in transform_names() {
+            first.apply(name, input).unwrap();
+        }
+        let replayed = replay_session(&first.to_json()).unwrap();
+        assert_eq!(replayed, first);
+    }
+
+    #[test]
+    fn test_session_records_pinned_specs() {
+        let mut session = Session::new(3);
+        session.apply("rot13", "abc").unwrap();
+        session
+            .apply_recipe("leetspeak | base64_encode@1", "abc")
+            .unwrap();
+        session.apply_recipe("", "abc").unwrap();
+        let recipes: Vec<&str> = session.calls().iter().map(|c| c.recipe.as_str()).collect();
+        assert_eq!(
+            recipes,
+            vec!["rot13@1", "leetspeak@1 | base64_encode@1", ""]
+        );
+        assert_eq!(session.calls()[2].output, "abc");
+    }
+
+    #[test]
+    fn test_session_errors_are_not_recorded() {
+        let mut session = Session::new(3);
+        assert!(session.apply("no_such_transform", "x").is_err());
+        assert!(session.apply_recipe("rot13 | nope", "x").is_err());
+        assert!(session.calls().is_empty());
+    }
+
+    #[test]
+    fn test_session_to_json_escapes() {
+        let mut session = Session::new(5);
+        session.apply("reverse_string", "a\"b\\c\nd").unwrap();
+        assert_eq!(
+            session.to_json(),
+            r#"{"seed":5,"calls":[{"recipe":"reverse_string@1","input":"a\"b\\c\nd","output":"d\nc\\b\"a"}]}"#
+        );
+        assert_eq!(replay_session(&session.to_json()).unwrap(), session);
+    }
+
+    #[test]
+    fn test_replay_session_detects_mismatch() {
+        let log = r#"{"seed":1,"calls":[{"recipe":"rot13@1","input":"hi","output":"hi"}]}"#;
+        assert_eq!(
+            replay_session(log),
+            Err(SessionError::OutputMismatch {
+                index: 0,
+                expected: "hi".to_string(),
+                actual: "uv".to_string(),
+            })
+        );
+    }
+
+    #[test]
+    fn test_replay_session_invalid_logs() {
+        let invalid = |log: &str| matches!(replay_session(log), Err(SessionError::InvalidLog(_)));
+        assert!(invalid("[]"));
+        assert!(invalid(r#"{"calls":[]}"#));
+        assert!(invalid(r#"{"seed":-1,"calls":[]}"#));
+        assert!(invalid(r#"{"seed":1,"calls":{}}"#));
+        assert!(invalid(
+            r#"{"seed":1,"calls":[{"recipe":"rot13@1","input":3,"output":""}]}"#
+        ));
+        assert_eq!(
+            replay_session(r#"{"seed":1,"calls":[{"recipe":"rot14","input":"","output":""}]}"#),
+            Err(SessionError::Transform(TransformError::UnknownTransform(
+                "rot14".to_string()
+            )))
+        );
+        assert_eq!(
+            replay_session(r#"{"seed":1,"calls":[]}"#).unwrap(),
+            Session::new(1)
+        );
+    }
+
+    #[test]
+    fn test_session_error_display() {
+        let err = SessionError::OutputMismatch {
+            index: 2,
+            expected: String::new(),
+            actual: String::new(),
+        };
+        assert_eq!(err.to_string(), "replayed call 2 produced different output");
+    }
+}
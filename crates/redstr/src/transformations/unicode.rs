@@ -1,3 +1,4 @@
+use crate::result::TransformResult;
 use crate::rng::SimpleRng;
 
 /// Replaces characters with random Unicode variations.
@@ -146,16 +147,37 @@ pub fn zalgo_text(input: &str) -> String {
 /// // Example: "2О2l" (Letter O and l instead of 0 and 1)
 /// ```
 pub fn homoglyph_substitution(input: &str) -> String {
+    homoglyph_substitution_detailed(input).output
+}
+
+/// Like [`homoglyph_substitution`], also reporting the lookalike table and
+/// how many characters were replaced.
+///
+/// The `table` parameter is `cyrillic` (letters swapped for Cyrillic
+/// lookalikes, digits for Latin letters) and `substitutions` counts the
+/// replaced characters. Case is not preserved, so the result is not
+/// reversible.
+///
+/// # Examples
+///
+/// ```
+/// use redstr::homoglyph_substitution_detailed;
+/// let result = homoglyph_substitution_detailed("paypal");
+/// assert_eq!(result.technique, "homoglyph");
+/// assert_eq!(result.parameter("table"), Some("cyrillic"));
+/// ```
+pub fn homoglyph_substitution_detailed(input: &str) -> TransformResult {
     let mut rng = SimpleRng::new();
+    let mut substitutions = 0;
 
-    input
+    let output = input
         .chars()
         .map(|c| {
             if rng.next() % 3 != 0 {
                 return c.to_string();
             }
 
-            match c {
+            let glyph = match c {
                 'a' | 'A' => "а", // Cyrillic а
                 'e' | 'E' => "е", // Cyrillic е
                 'o' | 'O' => "о", // Cyrillic о
@@ -166,10 +188,21 @@ pub fn homoglyph_substitution(input: &str) -> String {
                 '0' => "О",       // Letter O
                 '1' => "l",       // Letter l
                 _ => return c.to_string(),
-            }
-            .to_string()
+            };
+            substitutions += 1;
+            glyph.to_string()
         })
-        .collect()
+        .collect();
+
+    TransformResult {
+        output,
+        technique: "homoglyph",
+        parameters: vec![
+            ("table", "cyrillic".to_string()),
+            ("substitutions", substitutions.to_string()),
+        ],
+        reversible: false,
+    }
 }
 
 /// Replaces regular spaces with various Unicode space characters.
@@ -294,6 +327,25 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_homoglyph_substitution_detailed_counts() {
+        for _ in 0..20 {
+            let result = homoglyph_substitution_detailed("paypal.com");
+            let changed = result
+                .output
+                .chars()
+                .zip("paypal.com".chars())
+                .filter(|(a, b)| a != b)
+                .count();
+            assert_eq!(
+                result.parameter("substitutions"),
+                Some(&*changed.to_string())
+            );
+            assert_eq!(result.technique, "homoglyph");
+            assert!(!result.reversible);
+        }
+    }
+
     #[test]
     fn test_homoglyph_empty() {
         assert_eq!(homoglyph_substitution(""), "");
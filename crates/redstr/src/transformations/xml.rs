@@ -0,0 +1,445 @@
+use crate::rng::SimpleRng;
+
+/// Returns one past the `>` closing the tag that opens at `start`.
+pub(crate) fn tag_end(xml: &str, start: usize) -> Option<usize> {
+    let mut quote = None;
+    for (i, c) in xml[start..].char_indices() {
+        match (quote, c) {
+            (Some(q), c) if c == q => quote = None,
+            (None, '"' | '\'') => quote = Some(c),
+            (None, '>') => return Some(start + i + 1),
+            _ => {}
+        }
+    }
+    None
+}
+
+/// A slice of an XML document: markup (tags, comments, CDATA sections,
+/// processing instructions, DOCTYPE) or the character data between it.
+#[derive(Debug, PartialEq, Eq)]
+enum Node<'a> {
+    Markup(&'a str),
+    Text(&'a str),
+}
+
+/// Splits `xml` into markup and character data. Unterminated markup runs
+/// to the end of the input.
+fn split_nodes(xml: &str) -> Vec<Node<'_>> {
+    let mut nodes = Vec::new();
+    let mut i = 0;
+    while i < xml.len() {
+        let rest = &xml[i..];
+        if !rest.starts_with('<') {
+            let end = rest.find('<').map_or(xml.len(), |n| i + n);
+            nodes.push(Node::Text(&xml[i..end]));
+            i = end;
+            continue;
+        }
+
+        let terminated = |terminator: &str| rest.find(terminator).map(|n| i + n + terminator.len());
+        let end = if rest.starts_with("<!--") {
+            terminated("-->")
+        } else if rest.starts_with("<![CDATA[") {
+            terminated("]]>")
+        } else if rest.starts_with("<?") {
+            terminated("?>")
+        } else if rest.starts_with("<!") && rest.find('[') < rest.find('>') {
+            // DOCTYPE with an internal subset
+            terminated("]>")
+        } else {
+            tag_end(xml, i)
+        };
+        let end = end.unwrap_or(xml.len());
+        nodes.push(Node::Markup(&xml[i..end]));
+        i = end;
+    }
+    nodes
+}
+
+/// Whether `markup` is an element start or end tag.
+fn is_tag(markup: &str) -> bool {
+    !(markup.starts_with("<!") || markup.starts_with("<?"))
+}
+
+/// Splits character data into units that must stay intact: entity and
+/// character references, and single characters.
+fn text_units(text: &str) -> Vec<&str> {
+    let mut units = Vec::new();
+    let mut i = 0;
+    while i < text.len() {
+        let len = match text[i..].find(';') {
+            Some(semi) if text[i..].starts_with('&') => semi + 1,
+            _ => text[i..].chars().next().map_or(1, char::len_utf8),
+        };
+        units.push(&text[i..i + len]);
+        i += len;
+    }
+    units
+}
+
+/// Whether a text unit is a plain character that may be re-encoded.
+fn is_plain(unit: &str) -> bool {
+    !unit.starts_with('&') && !unit.trim().is_empty()
+}
+
+/// Replaces characters with decimal or hexadecimal character references.
+fn encode_char_refs(units: &[&str], rng: &mut SimpleRng) -> String {
+    let mut out = String::new();
+    let mut encoded_any = false;
+    for (i, unit) in units.iter().enumerate() {
+        let last_chance = !encoded_any && !units[i + 1..].iter().any(|u| is_plain(u));
+        match unit.chars().next() {
+            Some(c) if is_plain(unit) && (last_chance || rng.next() % 2 == 0) => {
+                if rng.next() % 2 == 0 {
+                    out.push_str(&format!("&#x{:x};", c as u32));
+                } else {
+                    out.push_str(&format!("&#{};", c as u32));
+                }
+                encoded_any = true;
+            }
+            _ => out.push_str(unit),
+        }
+    }
+    out
+}
+
+/// Moves the tail of every run of literal characters into a CDATA section,
+/// leaving at least the first character outside when the run has two.
+fn wrap_cdata(units: &[&str], rng: &mut SimpleRng) -> String {
+    let mut out = String::new();
+    let mut run: Vec<&str> = Vec::new();
+    let mut flush = |run: &mut Vec<&str>, out: &mut String| {
+        if !run.is_empty() {
+            let split = match run.len() {
+                1 => 0,
+                len => 1 + rng.next() as usize % (len - 1),
+            };
+            out.push_str(&run[..split].concat());
+            out.push_str("<![CDATA[");
+            out.push_str(&run[split..].concat());
+            out.push_str("]]>");
+            run.clear();
+        }
+    };
+    for unit in units {
+        if unit.starts_with('&') {
+            flush(&mut run, &mut out);
+            out.push_str(unit);
+        } else {
+            run.push(unit);
+        }
+    }
+    flush(&mut run, &mut out);
+    out
+}
+
+/// Inserts empty comments between units, at least once when there are two.
+fn split_with_comments(units: &[&str], rng: &mut SimpleRng) -> String {
+    let mut out = String::new();
+    let mut split_any = false;
+    for (i, unit) in units.iter().enumerate() {
+        if i > 0 && (rng.next() % 2 == 0 || (!split_any && i == units.len() - 1)) {
+            out.push_str("<!---->");
+            split_any = true;
+        }
+        out.push_str(unit);
+    }
+    out
+}
+
+/// Namespace prefixes declared with `xmlns:prefix` anywhere in the document.
+fn declared_prefixes(nodes: &[Node]) -> Vec<String> {
+    let mut prefixes: Vec<String> = Vec::new();
+    for node in nodes {
+        let Node::Markup(tag) = node else { continue };
+        if !is_tag(tag) {
+            continue;
+        }
+        for (at, _) in tag.match_indices("xmlns:") {
+            let name: String = tag[at + 6..]
+                .chars()
+                .take_while(|c| c.is_alphanumeric() || matches!(c, '_' | '-' | '.'))
+                .collect();
+            if !name.is_empty() && name != "xml" && !prefixes.contains(&name) {
+                prefixes.push(name);
+            }
+        }
+    }
+    prefixes
+}
+
+/// Matches a prefix to rename at the start of `rest`: a declaration
+/// (`xmlns:old=`) outside attribute values, or a use (`old:`) anywhere a
+/// name or QName value starts. Returns the bytes consumed and replacement.
+fn renamed_prefix(
+    rest: &str,
+    in_value: bool,
+    renames: &[(String, String)],
+) -> Option<(usize, String)> {
+    for (old, new) in renames {
+        let declared = rest
+            .strip_prefix("xmlns:")
+            .and_then(|r| r.strip_prefix(old.as_str()))
+            .is_some_and(|after| after.starts_with(|c: char| c == '=' || c.is_whitespace()));
+        if declared && !in_value {
+            return Some(("xmlns:".len() + old.len(), format!("xmlns:{}", new)));
+        }
+        if rest
+            .strip_prefix(old.as_str())
+            .is_some_and(|after| after.starts_with(':'))
+        {
+            return Some((old.len(), new.clone()));
+        }
+    }
+    None
+}
+
+/// Rewrites namespace prefixes in element names, attribute names, `xmlns`
+/// declarations and QName attribute values such as `xsi:type="soap:Fault"`.
+fn rename_prefixes_in_tag(tag: &str, renames: &[(String, String)]) -> String {
+    let mut out = String::with_capacity(tag.len());
+    let mut quote = None;
+    let mut token_start = false;
+    let mut i = 0;
+    while i < tag.len() {
+        if token_start {
+            token_start = false;
+            if let Some((consumed, replacement)) =
+                renamed_prefix(&tag[i..], quote.is_some(), renames)
+            {
+                out.push_str(&replacement);
+                i += consumed;
+                continue;
+            }
+        }
+        let c = tag[i..].chars().next().unwrap_or_default();
+        match (quote, c) {
+            (Some(q), c) if c == q => quote = None,
+            (None, '"' | '\'') => {
+                quote = Some(c);
+                token_start = true;
+            }
+            (None, '<' | '/') => token_start = true,
+            (None, c) if c.is_whitespace() => token_start = true,
+            _ => {}
+        }
+        out.push(c);
+        i += c.len_utf8();
+    }
+    out
+}
+
+/// Picks a fresh prefix for every declared one, avoiding names already in use.
+fn prefix_renames(xml: &str, prefixes: Vec<String>, rng: &mut SimpleRng) -> Vec<(String, String)> {
+    let mut renames: Vec<(String, String)> = Vec::new();
+    for old in prefixes {
+        let new = loop {
+            let len = 2 + rng.next() as usize % 4;
+            let candidate: String = (0..len)
+                .map(|_| (b'a' + (rng.next() % 26) as u8) as char)
+                .collect();
+            let taken = xml.contains(&format!("{}:", candidate))
+                || renames.iter().any(|(_, n)| *n == candidate);
+            if !taken && !candidate.starts_with("xml") {
+                break candidate;
+            }
+        };
+        renames.push((old, new));
+    }
+    renames
+}
+
+/// Obfuscates an XML or SOAP body while keeping it well-formed and equivalent.
+///
+/// Every declared namespace prefix is renamed throughout (`soap:Envelope`
+/// becomes e.g. `qvk:Envelope`), and each text node gets one of three
+/// encodings chosen at random: character references (`&#x61;dmin`), a CDATA
+/// section (`ad<![CDATA[min]]>`) or empty comments splitting the text
+/// (`ad<!---->min`). A conforming parser reports the same elements and text,
+/// while signatures that match literal element names or values do not.
+/// Attribute values, comments, CDATA, processing instructions and the
+/// DOCTYPE are left untouched.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Smuggle SOAP/XML payloads past WAF signatures on known elements and values
+/// - **Blue Team**: Check that XML inspection parses and normalizes before matching
+///
+/// # Examples
+///
+/// ```
+/// use redstr::xml_obfuscate;
+///
+/// let soap = r#"<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><cmd>id</cmd></soap:Body></soap:Envelope>"#;
+/// let result = xml_obfuscate(soap);
+/// assert!(!result.contains("<soap:"));
+/// assert!(result.contains(r#"="http://schemas.xmlsoap.org/soap/envelope/""#));
+/// assert!(!result.contains("<cmd>id</cmd>"));
+/// ```
+pub fn xml_obfuscate(xml: &str) -> String {
+    let mut rng = SimpleRng::new();
+    let nodes = split_nodes(xml);
+    let renames = prefix_renames(xml, declared_prefixes(&nodes), &mut rng);
+
+    let mut result = String::with_capacity(xml.len() * 2);
+    for node in nodes {
+        match node {
+            Node::Markup(tag) if is_tag(tag) && !renames.is_empty() => {
+                result.push_str(&rename_prefixes_in_tag(tag, &renames));
+            }
+            Node::Markup(markup) => result.push_str(markup),
+            Node::Text(text) if text.trim().is_empty() => result.push_str(text),
+            Node::Text(text) => {
+                let units = text_units(text);
+                let encoded = match rng.next() % 3 {
+                    0 => encode_char_refs(&units, &mut rng),
+                    1 => wrap_cdata(&units, &mut rng),
+                    _ => split_with_comments(&units, &mut rng),
+                };
+                result.push_str(&encoded);
+            }
+        }
+    }
+    result
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const SOAP: &str = r#"<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <soap:Body>
+    <m:GetUser xmlns:m="urn:users" xsi:type="m:Query">
+      <m:name>admin' or '1'='1</m:name>
+      <m:note>a &amp; b</m:note>
+    </m:GetUser>
+  </soap:Body>
+</soap:Envelope>"#;
+
+    /// Decodes numeric character references, leaving named entities as-is.
+    fn decode_char_refs(text: &str) -> String {
+        text_units(text)
+            .into_iter()
+            .map(|unit| {
+                let code = unit
+                    .strip_prefix("&#x")
+                    .and_then(|u| u.strip_suffix(';'))
+                    .and_then(|hex| u32::from_str_radix(hex, 16).ok())
+                    .or_else(|| {
+                        unit.strip_prefix("&#")
+                            .and_then(|u| u.strip_suffix(';'))
+                            .and_then(|dec| dec.parse().ok())
+                    });
+                match code.and_then(char::from_u32) {
+                    Some(c) => c.to_string(),
+                    None => unit.to_string(),
+                }
+            })
+            .collect()
+    }
+
+    /// What a parser reports: local element names and text, with comments
+    /// dropped and CDATA and character references resolved.
+    fn infoset(xml: &str) -> String {
+        let mut out = String::new();
+        for node in split_nodes(xml) {
+            match node {
+                Node::Text(text) => out.push_str(&decode_char_refs(text)),
+                Node::Markup(cdata) if cdata.starts_with("<![CDATA[") => {
+                    out.push_str(&cdata[9..cdata.len() - 3]);
+                }
+                Node::Markup(tag) if is_tag(tag) => {
+                    let close = if tag.starts_with("</") { "/" } else { "" };
+                    let name = tag
+                        .trim_start_matches(['<', '/'])
+                        .split(|c: char| c.is_whitespace() || c == '>' || c == '/')
+                        .next()
+                        .unwrap_or_default();
+                    let local = name.rsplit(':').next().unwrap_or_default();
+                    out.push_str(&format!("<{}{}>", close, local));
+                }
+                Node::Markup(_) => {}
+            }
+        }
+        out
+    }
+
+    #[test]
+    fn test_split_nodes() {
+        let xml =
+            r#"<!DOCTYPE a [<!ENTITY e "x">]><a t=">">x<!-- <b> --><![CDATA[<c>]]><?pi ?></a>"#;
+        assert_eq!(
+            split_nodes(xml),
+            vec![
+                Node::Markup(r#"<!DOCTYPE a [<!ENTITY e "x">]>"#),
+                Node::Markup(r#"<a t=">">"#),
+                Node::Text("x"),
+                Node::Markup("<!-- <b> -->"),
+                Node::Markup("<![CDATA[<c>]]>"),
+                Node::Markup("<?pi ?>"),
+                Node::Markup("</a>"),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_text_units_keep_references() {
+        assert_eq!(text_units("a&amp;b"), vec!["a", "&amp;", "b"]);
+        assert_eq!(text_units("é&#x41;"), vec!["é", "&#x41;"]);
+    }
+
+    #[test]
+    fn test_xml_obfuscate_preserves_infoset() {
+        for _ in 0..30 {
+            let result = xml_obfuscate(SOAP);
+            assert_eq!(infoset(&result), infoset(SOAP), "{}", result);
+        }
+    }
+
+    #[test]
+    fn test_xml_obfuscate_renames_prefixes() {
+        let result = xml_obfuscate(SOAP);
+        assert!(!result.contains("<soap:") && !result.contains("<m:name"));
+        assert!(result.contains(r#"="http://schemas.xmlsoap.org/soap/envelope/""#));
+        assert!(result.contains(r#"="urn:users""#));
+        assert!(result.starts_with(r#"<?xml version="1.0"?>"#));
+
+        let decl = result.find("=\"urn:users\"").unwrap();
+        let prefix = &result[result[..decl].rfind("xmlns:").unwrap() + 6..decl];
+        assert!(result.contains(&format!("<{}:name>", prefix)));
+        assert!(result.contains(&format!("</{}:GetUser>", prefix)));
+        assert!(result.contains(&format!("type=\"{}:Query\"", prefix)));
+    }
+
+    #[test]
+    fn test_xml_obfuscate_encodes_text() {
+        for _ in 0..20 {
+            let result = xml_obfuscate("<cmd>whoami</cmd>");
+            assert!(!result.contains("whoami"), "{}", result);
+            assert!(result.starts_with("<cmd>") && result.ends_with("</cmd>"));
+            assert!(
+                result.contains("&#") || result.contains("<![CDATA[") || result.contains("<!---->")
+            );
+        }
+    }
+
+    #[test]
+    fn test_xml_obfuscate_leaves_markup_alone() {
+        let xml = "<a x=\"v\">\n  <b/>\n  <!-- note --><![CDATA[raw]]>\n</a>";
+        assert_eq!(xml_obfuscate(xml), xml);
+        assert_eq!(xml_obfuscate(""), "");
+    }
+
+    #[test]
+    fn test_xml_obfuscate_keeps_entity_references() {
+        for _ in 0..20 {
+            let result = xml_obfuscate("<a>x &amp; y &lt;</a>");
+            assert!(
+                result.contains("&amp;") && result.contains("&lt;"),
+                "{}",
+                result
+            );
+        }
+    }
+}
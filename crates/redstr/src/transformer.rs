@@ -0,0 +1,244 @@
+use std::cell::RefCell;
+
+use crate::registry::{resolve_recipe, steps_recipe, Transform, TransformError};
+use crate::trace::{Operation, SpanStatus, TraceSpan};
+
+/// Scratch buffers above this capacity are released after a pipeline run,
+/// so one huge input does not pin its memory for the life of the thread.
+const SCRATCH_RETAIN: usize = 1 << 20;
+
+thread_local! {
+    /// Intermediate buffers for multi-step pipelines. Steps alternate
+    /// between the two, so their capacity is reused from call to call.
+    static SCRATCH: RefCell<[String; 2]> = const { RefCell::new([String::new(), String::new()]) };
+}
+
+/// A resolved recipe that appends its output to caller-owned buffers.
+///
+/// Resolving a recipe once and writing into a reused `String` keeps hot
+/// loops (fuzzers, wordlist mutation) from re-parsing the recipe and from
+/// receiving a fresh output string for every input. The caller clears or
+/// keeps the destination between calls, like `Vec::extend`.
+///
+/// Intermediate results of multi-step recipes go to two per-thread scratch
+/// buffers that alternate between steps, and the last step writes into the
+/// destination. Encoders such as `base64_encode`, `hex_encode`,
+/// `url_encode`, `rot13` and `reverse_string` append in place, so a
+/// pipeline of them allocates nothing once the buffers have grown; other
+/// transforms still build their own result, which is then copied.
+///
+/// # Use Cases
+///
+/// - **Red Team**: Drive a fuzzing loop with one buffer for millions of mutations
+/// - **Blue Team**: Feed a detection engine from a fixed pool of buffers
+///
+/// # Examples
+///
+/// ```
+/// use redstr::Transformer;
+/// let transformer = Transformer::new("rot13 | hex_encode").unwrap();
+///
+/// let mut buf = String::new();
+/// for word in ["a", "b"] {
+///     buf.clear();
+///     transformer.transform(&mut buf, word);
+///     assert_eq!(buf.len(), 2);
+/// }
+/// assert_eq!(buf, "6f");
+/// ```
+#[derive(Debug, Clone)]
+pub struct Transformer {
+    steps: Vec<Transform>,
+}
+
+impl Transformer {
+    /// Resolves a `|`-separated recipe. An empty recipe copies its input.
+    pub fn new(recipe: &str) -> Result<Self, TransformError> {
+        Ok(Transformer {
+            steps: resolve_recipe(recipe)?,
+        })
+    }
+
+    /// Returns the recipe with every step pinned to its resolved version.
+    pub fn recipe(&self) -> String {
+        steps_recipe(&self.steps)
+    }
+
+    /// Appends the transformed `src` to `dst`.
+    pub fn transform(&self, dst: &mut String, src: &str) {
+        let span = TraceSpan::start(Operation::Pipeline, || self.recipe(), 1);
+        let start = dst.len();
+        self.append(dst, src);
+        span.finish_counts(SpanStatus::Ok, 1, dst.len() - start);
+    }
+
+    /// Appends the transformed raw bytes to `dst`.
+    ///
+    /// The first step receives the bytes through
+    /// [`Transform::apply_bytes`]; later steps see its text output. Returns
+    /// `false`, leaving `dst` untouched, if `src` is not valid UTF-8 and the
+    /// first step does not accept bytes.
+    pub fn transform_bytes(&self, dst: &mut String, src: &[u8]) -> bool {
+        let span = TraceSpan::start(Operation::Pipeline, || self.recipe(), 1);
+        let start = dst.len();
+        let accepted = self.append_bytes(dst, src);
+        if accepted {
+            span.finish_counts(SpanStatus::Ok, 1, dst.len() - start);
+        } else {
+            span.finish_counts(SpanStatus::Rejected, 0, 0);
+        }
+        accepted
+    }
+
+    fn append(&self, dst: &mut String, src: &str) {
+        append_steps(&self.steps, dst, src);
+    }
+
+    fn append_bytes(&self, dst: &mut String, src: &[u8]) -> bool {
+        if let Ok(text) = std::str::from_utf8(src) {
+            self.append(dst, text);
+            return true;
+        }
+        let Some((first, rest)) = self.steps.split_first() else {
+            return false;
+        };
+        match first.apply_bytes(src) {
+            Some(text) => {
+                append_steps(rest, dst, &text);
+                true
+            }
+            None => false,
+        }
+    }
+}
+
+/// Runs `steps` over `src`, appending the result to `dst`.
+fn append_steps(steps: &[Transform], dst: &mut String, src: &str) {
+    let (first, middle, last) = match steps {
+        [] => return dst.push_str(src),
+        // A single step needs no intermediate string of its own.
+        [step] => return step.apply_into(src, dst),
+        [first, middle @ .., last] => (first, middle, last),
+    };
+    SCRATCH.with(|scratch| {
+        // An event hook that runs a transformer on this thread re-enters
+        // here while the buffers are in use; it gets fresh ones instead.
+        let mut fresh = Default::default();
+        let mut pooled = scratch.try_borrow_mut();
+        let buffers: &mut [String; 2] = match pooled {
+            Ok(ref mut pooled) => pooled,
+            Err(_) => &mut fresh,
+        };
+        let [current, next] = &mut *buffers;
+        let (mut current, mut next) = (current, next);
+        current.clear();
+        first.apply_into(src, current);
+        for step in middle {
+            next.clear();
+            step.apply_into(current, next);
+            std::mem::swap(&mut current, &mut next);
+        }
+        last.apply_into(current, dst);
+
+        for buffer in buffers {
+            if buffer.capacity() > SCRATCH_RETAIN {
+                *buffer = String::new();
+            }
+        }
+    });
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::registry::apply_recipe;
+
+    #[test]
+    fn test_transformer_appends() {
+        let transformer = Transformer::new("base64_encode").unwrap();
+        let mut buf = String::from("payload=");
+        transformer.transform(&mut buf, "hello");
+        assert_eq!(buf, "payload=aGVsbG8=");
+    }
+
+    #[test]
+    fn test_transformer_matches_apply_recipe() {
+        for recipe in ["", "rot13", "rot13 | base64_encode | url_encode"] {
+            let transformer = Transformer::new(recipe).unwrap();
+            let mut buf = String::new();
+            transformer.transform(&mut buf, "hello world");
+            assert_eq!(buf, apply_recipe(recipe, "hello world").unwrap());
+        }
+    }
+
+    #[test]
+    fn test_transformer_reuses_capacity() {
+        let transformer = Transformer::new("hex_encode").unwrap();
+        let mut buf = String::with_capacity(64);
+        let ptr = buf.as_ptr();
+        for word in ["alpha", "beta", "gamma"] {
+            buf.clear();
+            transformer.transform(&mut buf, word);
+        }
+        assert_eq!(buf, "67616d6d61");
+        assert_eq!(buf.as_ptr(), ptr);
+    }
+
+    #[test]
+    fn test_transformer_reuses_scratch_buffers() {
+        let recipe = "rot13 | reverse_string | url_encode | hex_encode";
+        let transformer = Transformer::new(recipe).unwrap();
+        let mut buf = String::new();
+        transformer.transform(&mut buf, "warm up both scratch buffers");
+        let pointers =
+            || SCRATCH.with(|s| s.borrow().iter().map(|b| b.as_ptr()).collect::<Vec<_>>());
+        let warm = pointers();
+        for word in ["alpha", "beta gamma", "d\u{e9}lta"] {
+            buf.clear();
+            transformer.transform(&mut buf, word);
+            assert_eq!(buf, apply_recipe(recipe, word).unwrap());
+        }
+        assert_eq!(pointers(), warm);
+    }
+
+    #[test]
+    fn test_transformer_mixes_write_and_string_steps() {
+        let recipe = "hex_encode | to_snake_case | base64_encode | inverse_case | rot13";
+        let transformer = Transformer::new(recipe).unwrap();
+        let mut buf = String::from(">");
+        transformer.transform(&mut buf, "Hello World");
+        assert_eq!(
+            buf,
+            format!(">{}", apply_recipe(recipe, "Hello World").unwrap())
+        );
+    }
+
+    #[test]
+    fn test_transformer_recipe_is_pinned() {
+        let transformer = Transformer::new("leetspeak | rot13@1").unwrap();
+        assert_eq!(transformer.recipe(), "leetspeak@1 | rot13@1");
+        assert_eq!(Transformer::new("").unwrap().recipe(), "");
+        assert!(Transformer::new("rot13 | nope").is_err());
+    }
+
+    #[test]
+    fn test_transformer_transform_bytes() {
+        let mut buf = String::new();
+        let hex_then_reverse = Transformer::new("hex_encode | reverse_string").unwrap();
+        assert!(hex_then_reverse.transform_bytes(&mut buf, &[0xff, 0x01]));
+        assert_eq!(buf, "10ff");
+
+        buf.clear();
+        assert!(!Transformer::new("rot13 | hex_encode")
+            .unwrap()
+            .transform_bytes(&mut buf, &[0xff]));
+        assert!(!Transformer::new("")
+            .unwrap()
+            .transform_bytes(&mut buf, &[0xff]));
+        assert!(buf.is_empty());
+        assert!(Transformer::new("rot13")
+            .unwrap()
+            .transform_bytes(&mut buf, b"abc"));
+        assert_eq!(buf, "nop");
+    }
+}
@@ -0,0 +1,45 @@
+//! Deprecation hook reporting.
+
+use std::sync::{Arc, Mutex};
+
+use redstr::{apply_recipe, set_deprecation_hook, Transformer};
+
+#[test]
+fn test_deprecation_hook() {
+    let seen = Arc::new(Mutex::new(Vec::new()));
+    let sink = Arc::clone(&seen);
+    set_deprecation_hook(Some(Arc::new(move |notice| {
+        sink.lock()
+            .unwrap()
+            .push(format!("{}->{}", notice.name, notice.replacement));
+    })));
+
+    // Canonical names are silent
+    assert_eq!(apply_recipe("rot13 | base64_encode", "hi").unwrap(), "dXY=");
+    assert!(seen.lock().unwrap().is_empty());
+
+    // Each deprecated step is reported when the recipe is resolved
+    apply_recipe("cloudflare_challenge@1 | cloudflare_turnstile", "cf").unwrap();
+    assert_eq!(
+        *seen.lock().unwrap(),
+        [
+            "cloudflare_challenge->cloudflare_challenge_variation",
+            "cloudflare_turnstile->cloudflare_turnstile_variation"
+        ]
+    );
+
+    // A transformer resolves once, however many inputs it processes
+    seen.lock().unwrap().clear();
+    let transformer = Transformer::new("cloudflare_turnstile").unwrap();
+    let mut buf = String::new();
+    for word in ["a", "b", "c"] {
+        transformer.transform(&mut buf, word);
+    }
+    assert_eq!(transformer.recipe(), "cloudflare_turnstile_variation@1");
+    assert_eq!(seen.lock().unwrap().len(), 1);
+
+    // Removing the hook silences reporting
+    set_deprecation_hook(None);
+    apply_recipe("cloudflare_challenge", "x").unwrap();
+    assert_eq!(seen.lock().unwrap().len(), 1);
+}
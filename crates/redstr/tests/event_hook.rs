@@ -0,0 +1,57 @@
+//! Per-call transform events.
+
+use std::sync::{Arc, Mutex};
+
+use redstr::{apply_batch, apply_recipe, leetspeak, set_event_hook, Transform, TransformEvent};
+
+#[test]
+fn test_event_hook() {
+    let events: Arc<Mutex<Vec<TransformEvent>>> = Arc::new(Mutex::new(Vec::new()));
+    let sink = Arc::clone(&events);
+    set_event_hook(Some(Arc::new(move |event| {
+        sink.lock().unwrap().push(*event)
+    })));
+
+    // One event per recipe step, in order, with lengths and versions
+    assert_eq!(
+        apply_recipe("rot13 | base64_encode", "hello").unwrap(),
+        "dXJ5eWI="
+    );
+    {
+        let events = events.lock().unwrap();
+        assert_eq!(events.len(), 2);
+        assert_eq!((events[0].name, events[0].version), ("rot13", 1));
+        assert_eq!((events[0].input_len, events[0].output_len), (5, 5));
+        assert_eq!((events[1].name, events[1].output_len), ("base64_encode", 8));
+        // Deterministic transforms draw no seed
+        assert!(events.iter().all(|event| event.seed.is_none()));
+    }
+
+    // Randomized transforms report the seed they drew
+    events.lock().unwrap().clear();
+    Transform::resolve("leetspeak").unwrap().apply("password");
+    assert!(events.lock().unwrap()[0].seed.is_some());
+
+    // Batches report every input; direct function calls are not reported
+    events.lock().unwrap().clear();
+    apply_batch("hex_encode", &["a", "bb", "ccc"]).unwrap();
+    leetspeak("password");
+    let lengths: Vec<usize> = events
+        .lock()
+        .unwrap()
+        .iter()
+        .map(|e| e.output_len)
+        .collect();
+    assert_eq!(lengths, [2, 4, 6]);
+
+    // Byte input that is not UTF-8 is reported too
+    events.lock().unwrap().clear();
+    Transform::resolve("hex_encode")
+        .unwrap()
+        .apply_bytes(&[0xff, 0xfe]);
+    assert_eq!(events.lock().unwrap()[0].input_len, 2);
+
+    set_event_hook(None);
+    apply_recipe("rot13", "x").unwrap();
+    assert_eq!(events.lock().unwrap().len(), 1);
+}
@@ -0,0 +1,43 @@
+//! Per-transform usage metrics.
+
+use std::sync::{Arc, Mutex};
+
+use redstr::{
+    apply_batch, apply_recipe, leetspeak, set_event_hook, set_metrics_registry, TransformMetrics,
+};
+
+#[test]
+fn test_metrics_registry() {
+    let metrics = Arc::new(TransformMetrics::new());
+    set_metrics_registry(Some(metrics.clone()));
+
+    // Every recipe step and batch input counts; direct calls do not
+    apply_recipe("rot13 | base64_encode", "hello").unwrap();
+    apply_batch("rot13", &["a", "bb"]).unwrap();
+    leetspeak("password");
+    let snapshot = metrics.snapshot();
+    let names: Vec<&str> = snapshot.iter().map(|stats| stats.name).collect();
+    assert_eq!(names, ["base64_encode", "rot13"]);
+    let rot13 = snapshot[1];
+    assert_eq!(
+        (rot13.calls, rot13.input_bytes, rot13.output_bytes),
+        (3, 8, 8)
+    );
+    assert_eq!(rot13.duration_buckets[6], 3);
+
+    // The event hook and the registry both see each call
+    let events = Arc::new(Mutex::new(0));
+    let sink = Arc::clone(&events);
+    set_event_hook(Some(Arc::new(move |_| *sink.lock().unwrap() += 1)));
+    apply_recipe("rot13", "x").unwrap();
+    assert_eq!(*events.lock().unwrap(), 1);
+    assert_eq!(metrics.snapshot()[1].calls, 4);
+    set_event_hook(None);
+
+    set_metrics_registry(None);
+    apply_recipe("rot13", "x").unwrap();
+    assert_eq!(metrics.snapshot()[1].calls, 4);
+    assert!(metrics
+        .to_prometheus()
+        .contains("redstr_transform_duration_seconds_count{name=\"rot13\"} 4\n"));
+}
@@ -0,0 +1,67 @@
+//! Process-wide randomness source selection.
+
+use std::sync::atomic::{AtomicBool, AtomicU64, Ordering};
+use std::sync::Arc;
+
+use redstr::{leetspeak, random_user_agent, set_rand_source, RandSource, Session};
+
+fn corpus() -> Vec<String> {
+    (0..20)
+        .map(|_| leetspeak("password") + &random_user_agent())
+        .collect()
+}
+
+#[test]
+fn test_rand_source_policies() {
+    // Seeded: the same seed replays the same outputs, another seed does not
+    set_rand_source(RandSource::Seeded(42));
+    let first = corpus();
+    set_rand_source(RandSource::Seeded(42));
+    assert_eq!(corpus(), first);
+    set_rand_source(RandSource::Seeded(43));
+    assert_ne!(corpus(), first);
+
+    // Custom: every generator seed comes from the caller
+    let calls = Arc::new(AtomicU64::new(0));
+    let counter = Arc::clone(&calls);
+    set_rand_source(RandSource::Custom(Arc::new(move || {
+        counter.fetch_add(1, Ordering::SeqCst) * 7919
+    })));
+    let custom = corpus();
+    assert!(calls.load(Ordering::SeqCst) >= 40);
+    calls.store(0, Ordering::SeqCst);
+    assert_eq!(corpus(), custom);
+
+    // Custom sources run without the source lock held, so they may draw
+    // seeds themselves or replace the source
+    let reentered = Arc::new(AtomicBool::new(false));
+    let flag = Arc::clone(&reentered);
+    set_rand_source(RandSource::Custom(Arc::new(move || {
+        if !flag.swap(true, Ordering::SeqCst) {
+            leetspeak("nested");
+            set_rand_source(RandSource::Seeded(5));
+        }
+        11
+    })));
+    leetspeak("password");
+    assert!(reentered.load(Ordering::SeqCst));
+    let after = corpus();
+    set_rand_source(RandSource::Seeded(5));
+    assert_eq!(corpus(), after);
+
+    // Os: outputs still vary
+    set_rand_source(RandSource::Os);
+    let os: std::collections::HashSet<String> = (0..20).map(|_| leetspeak("password")).collect();
+    assert!(os.len() > 1);
+
+    // Sessions keep their own seeding regardless of the source
+    let mut session = Session::new(1);
+    let payload = session.apply("leetspeak", "password").unwrap();
+    set_rand_source(RandSource::Seeded(99));
+    let mut again = Session::new(1);
+    assert_eq!(again.apply("leetspeak", "password").unwrap(), payload);
+
+    set_rand_source(RandSource::default());
+    let time: std::collections::HashSet<String> = (0..20).map(|_| leetspeak("password")).collect();
+    assert!(time.len() > 1);
+}
@@ -0,0 +1,150 @@
+//! Long-running soak test for leak detection.
+//!
+//! Runs randomized inputs through every registered transform while tracking
+//! live heap bytes (via a counting global allocator) and, on Linux, resident
+//! set size. The test fails if memory keeps growing across every sampling
+//! window instead of levelling off.
+//!
+//! Ignored by default. Run with:
+//!
+//! ```text
+//! cargo test -p redstr --release --test soak -- --ignored --nocapture
+//! REDSTR_SOAK_ITERATIONS=5000000 cargo test -p redstr --release --test soak -- --ignored
+//! ```
+
+use std::alloc::{GlobalAlloc, Layout, System};
+use std::sync::atomic::{AtomicUsize, Ordering};
+
+use redstr::{apply_transform, transform_names, TransformBuilder};
+
+struct CountingAllocator;
+
+static LIVE_BYTES: AtomicUsize = AtomicUsize::new(0);
+
+unsafe impl GlobalAlloc for CountingAllocator {
+    unsafe fn alloc(&self, layout: Layout) -> *mut u8 {
+        let ptr = System.alloc(layout);
+        if !ptr.is_null() {
+            LIVE_BYTES.fetch_add(layout.size(), Ordering::Relaxed);
+        }
+        ptr
+    }
+
+    unsafe fn dealloc(&self, ptr: *mut u8, layout: Layout) {
+        System.dealloc(ptr, layout);
+        LIVE_BYTES.fetch_sub(layout.size(), Ordering::Relaxed);
+    }
+}
+
+#[global_allocator]
+static ALLOCATOR: CountingAllocator = CountingAllocator;
+
+const DEFAULT_ITERATIONS: usize = 1_000_000;
+const WINDOWS: usize = 8;
+/// Growth below this many bytes over the whole run is treated as noise.
+const LIVE_BYTES_TOLERANCE: usize = 64 * 1024;
+const RSS_TOLERANCE: usize = 8 * 1024 * 1024;
+
+const ALPHABET: &[char] = &[
+    'a', 'b', 'c', 'x', 'y', 'z', 'A', 'Q', 'Z', '0', '7', ' ', '.', '/', '<', '>', '\'', '"', '{',
+    '}', '$', '@', '%', '=', 'é', 'ß', 'а', '😀', '\t', '\n',
+];
+
+/// Small deterministic xorshift so runs are reproducible.
+fn next(state: &mut u64) -> u64 {
+    *state ^= *state << 13;
+    *state ^= *state >> 7;
+    *state ^= *state << 17;
+    *state
+}
+
+fn random_input(state: &mut u64) -> String {
+    let len = (next(state) % 64) as usize;
+    (0..len)
+        .map(|_| ALPHABET[next(state) as usize % ALPHABET.len()])
+        .collect()
+}
+
+/// Resident set size in bytes, if the platform exposes it.
+///
+/// Reads `VmRSS` from `/proc/self/status`, which is reported in kB and so
+/// does not depend on the page size.
+fn rss_bytes() -> Option<usize> {
+    let status = std::fs::read_to_string("/proc/self/status").ok()?;
+    let line = status.lines().find(|line| line.starts_with("VmRSS:"))?;
+    let kb: usize = line.split_whitespace().nth(1)?.parse().ok()?;
+    Some(kb * 1024)
+}
+
+fn grows_every_window(samples: &[usize], tolerance: usize) -> bool {
+    let strictly_increasing = samples.windows(2).all(|pair| pair[1] > pair[0]);
+    let total_growth = samples
+        .last()
+        .unwrap_or(&0)
+        .saturating_sub(*samples.first().unwrap_or(&0));
+    strictly_increasing && total_growth > tolerance
+}
+
+#[test]
+#[ignore = "long-running soak test; run with --ignored"]
+fn soak_all_transforms() {
+    let iterations = std::env::var("REDSTR_SOAK_ITERATIONS")
+        .ok()
+        .and_then(|value| value.parse().ok())
+        .unwrap_or(DEFAULT_ITERATIONS);
+    let names = transform_names();
+    let window = (iterations / WINDOWS).max(1);
+    let mut state = 0x2545_F491_4F6C_DD1D_u64;
+
+    let mut live_samples = Vec::new();
+    let mut rss_samples = Vec::new();
+
+    for i in 0..iterations {
+        let input = random_input(&mut state);
+        let name = names[next(&mut state) as usize % names.len()];
+        apply_transform(name, &input).expect("registered transform");
+
+        if i % 64 == 0 {
+            let chained = TransformBuilder::new(&input)
+                .leetspeak()
+                .homoglyphs()
+                .url_encode()
+                .build();
+            drop(chained);
+        }
+
+        if (i + 1) % window == 0 {
+            live_samples.push(LIVE_BYTES.load(Ordering::Relaxed));
+            if let Some(rss) = rss_bytes() {
+                rss_samples.push(rss);
+            }
+        }
+    }
+
+    println!("live heap bytes per window: {:?}", live_samples);
+    println!("rss bytes per window: {:?}", rss_samples);
+
+    assert!(
+        !grows_every_window(&live_samples, LIVE_BYTES_TOLERANCE),
+        "live heap grew monotonically: {:?}",
+        live_samples
+    );
+    assert!(
+        !grows_every_window(&rss_samples, RSS_TOLERANCE),
+        "RSS grew monotonically: {:?}",
+        rss_samples
+    );
+}
+
+#[test]
+fn detects_monotonic_growth() {
+    assert!(grows_every_window(&[0, 100_000, 200_000], 1024));
+    assert!(!grows_every_window(&[0, 100_000, 50_000], 1024));
+    assert!(!grows_every_window(&[10, 11, 12], 1024));
+}
+
+#[test]
+#[cfg(target_os = "linux")]
+fn reads_rss() {
+    assert!(rss_bytes().is_some_and(|rss| rss > 0));
+}
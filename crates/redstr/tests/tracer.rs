@@ -0,0 +1,100 @@
+//! Pipeline and batch spans.
+
+use std::sync::{Arc, Mutex};
+
+use redstr::{
+    apply_batch, apply_batch_ctx, apply_recipe, apply_transform, parallel_apply, set_tracer,
+    BatchContext, Operation, SpanEnd, SpanStart, SpanStatus, Tracer, Transformer,
+};
+
+#[derive(Default)]
+struct Recorder {
+    started: Mutex<Vec<(Operation, String, usize)>>,
+    ended: Mutex<Vec<(u64, SpanEnd)>>,
+}
+
+impl Tracer for Recorder {
+    fn start(&self, span: &SpanStart<'_>) -> u64 {
+        let mut started = self.started.lock().unwrap();
+        started.push((span.operation, span.recipe.to_string(), span.inputs));
+        started.len() as u64
+    }
+
+    fn end(&self, token: u64, span: &SpanEnd) {
+        self.ended.lock().unwrap().push((token, *span));
+    }
+}
+
+#[test]
+fn test_tracer() {
+    let recorder = Arc::new(Recorder::default());
+    set_tracer(Some(recorder.clone()));
+
+    // A recipe is one pipeline span with the pinned chain and output size
+    apply_recipe("rot13 | base64_encode", "hello").unwrap();
+    {
+        let started = recorder.started.lock().unwrap();
+        assert_eq!(
+            started[0],
+            (
+                Operation::Pipeline,
+                "rot13@1 | base64_encode@1".to_string(),
+                1
+            )
+        );
+        let (token, end) = recorder.ended.lock().unwrap()[0];
+        assert_eq!(token, 1);
+        assert_eq!(
+            (end.status, end.outputs, end.output_bytes),
+            (SpanStatus::Ok, 1, 8)
+        );
+    }
+
+    // Transformers count only what they append
+    let transformer = Transformer::new("hex_encode").unwrap();
+    let mut buf = String::from("prefix");
+    transformer.transform(&mut buf, "ab");
+    assert_eq!(recorder.ended.lock().unwrap()[1].1.output_bytes, 4);
+    // Bytes a text-only first step cannot take are rejected
+    assert!(!Transformer::new("rot13")
+        .unwrap()
+        .transform_bytes(&mut buf, &[0xff]));
+    assert_eq!(
+        recorder.ended.lock().unwrap()[2].1.status,
+        SpanStatus::Rejected
+    );
+
+    // Batches, sequential and parallel
+    apply_batch("hex_encode", &["a", "bb"]).unwrap();
+    parallel_apply(&["a", "b", "c"], "rot13", 2).unwrap();
+    {
+        let started = recorder.started.lock().unwrap();
+        assert_eq!(
+            started[3],
+            (Operation::Batch, "hex_encode@1".to_string(), 2)
+        );
+        assert_eq!(
+            started[4],
+            (Operation::ParallelBatch, "rot13@1".to_string(), 3)
+        );
+        let ended = recorder.ended.lock().unwrap();
+        assert_eq!((ended[3].1.outputs, ended[3].1.output_bytes), (2, 6));
+        assert_eq!((ended[4].1.outputs, ended[4].1.output_bytes), (3, 3));
+    }
+
+    // A cancelled batch ends with the outputs it completed
+    let ctx = BatchContext::new();
+    ctx.cancel();
+    apply_batch_ctx(&ctx, "rot13", &["a"]).unwrap_err();
+    let (_, end) = recorder.ended.lock().unwrap()[5];
+    assert_eq!((end.status, end.outputs), (SpanStatus::Cancelled, 0));
+
+    // Single transforms are not spans, and unknown recipes never start one
+    apply_transform("rot13", "a").unwrap();
+    apply_recipe("nope", "a").unwrap_err();
+    assert_eq!(recorder.started.lock().unwrap().len(), 6);
+
+    set_tracer(None);
+    apply_recipe("rot13", "a").unwrap();
+    assert_eq!(recorder.ended.lock().unwrap().len(), 6);
+}
@@ -0,0 +1,67 @@
+//! Per-call FFI overhead compared with batched calls.
+//!
+//! Every group transforms the same inputs three ways: natively in Rust, with
+//! one `redstr_apply_transform` call per string, and with a single
+//! `redstr_transform_batch` call. The gap between `native` and `per_call` is
+//! what a binding pays for crossing the C boundary one string at a time.
+
+use criterion::{black_box, criterion_group, criterion_main, BenchmarkId, Criterion, Throughput};
+use redstr_ffi::{
+    redstr_apply_transform, redstr_free_string, redstr_free_string_array, redstr_transform_batch,
+};
+use std::ffi::CString;
+use std::os::raw::c_char;
+
+const SPEC: &str = "leetspeak@1";
+const BATCH_SIZES: [usize; 4] = [1, 16, 256, 4096];
+
+fn bench_ffi_overhead(c: &mut Criterion) {
+    let mut group = c.benchmark_group("ffi_overhead");
+    let spec = CString::new(SPEC).unwrap();
+    let transform = redstr::Transform::resolve(SPEC).unwrap();
+
+    for size in BATCH_SIZES {
+        let inputs: Vec<String> = (0..size).map(|i| format!("password{}", i)).collect();
+        let c_inputs: Vec<CString> = inputs
+            .iter()
+            .map(|input| CString::new(input.as_str()).unwrap())
+            .collect();
+        let ptrs: Vec<*const c_char> = c_inputs.iter().map(|input| input.as_ptr()).collect();
+        group.throughput(Throughput::Elements(size as u64));
+
+        group.bench_with_input(BenchmarkId::new("native", size), &inputs, |b, inputs| {
+            b.iter(|| {
+                inputs
+                    .iter()
+                    .map(|input| transform.apply(black_box(input)))
+                    .collect::<Vec<String>>()
+            })
+        });
+
+        group.bench_with_input(BenchmarkId::new("per_call", size), &ptrs, |b, ptrs| {
+            b.iter(|| unsafe {
+                for &input in ptrs {
+                    redstr_free_string(redstr_apply_transform(spec.as_ptr(), black_box(input)));
+                }
+            })
+        });
+
+        group.bench_with_input(BenchmarkId::new("batch", size), &ptrs, |b, ptrs| {
+            let mut outputs = vec![std::ptr::null_mut(); ptrs.len()];
+            b.iter(|| unsafe {
+                redstr_transform_batch(
+                    spec.as_ptr(),
+                    black_box(ptrs.as_ptr()),
+                    ptrs.len(),
+                    outputs.as_mut_ptr(),
+                );
+                redstr_free_string_array(outputs.as_mut_ptr(), outputs.len());
+            })
+        });
+    }
+
+    group.finish();
+}
+
+criterion_group!(benches, bench_ffi_overhead);
+criterion_main!(benches);
@@ -0,0 +1,10 @@
+use std::env;
+
+fn main() {
+    // Give the macOS dylib an @rpath install name instead of the absolute path
+    // of the build directory, so hosts can bundle it next to the executable or
+    // inside an app bundle and locate it through their own LC_RPATH entries.
+    if env::var("CARGO_CFG_TARGET_VENDOR").as_deref() == Ok("apple") {
+        println!("cargo:rustc-cdylib-link-arg=-Wl,-install_name,@rpath/libredstr_ffi.dylib");
+    }
+}
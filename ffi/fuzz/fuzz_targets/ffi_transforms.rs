@@ -0,0 +1,35 @@
+//! Fuzzes every registered transform through the C entry points.
+//!
+//! Run from `ffi/` with `cargo +nightly fuzz run ffi_transforms`. The first
+//! input byte picks the transform; the rest is passed both length-delimited
+//! (embedded NULs and invalid UTF-8 included) and as a C string.
+
+#![no_main]
+
+use libfuzzer_sys::fuzz_target;
+use redstr_ffi::{
+    redstr_apply_transform, redstr_buffer_release, redstr_free_string, redstr_transform_buffer,
+};
+use std::ffi::CString;
+
+fuzz_target!(|data: &[u8]| {
+    let Some((&pick, payload)) = data.split_first() else {
+        return;
+    };
+    let names = redstr::transform_names();
+    let spec = CString::new(names[pick as usize % names.len()]).unwrap();
+
+    unsafe {
+        let mut buffer = redstr_transform_buffer(spec.as_ptr(), payload.as_ptr(), payload.len());
+        assert_eq!(buffer.data.is_null(), std::str::from_utf8(payload).is_err());
+        if !buffer.data.is_null() {
+            let out = std::slice::from_raw_parts(buffer.data, buffer.len);
+            assert!(std::str::from_utf8(out).is_ok());
+        }
+        redstr_buffer_release(&mut buffer);
+
+        let prefix = payload.split(|&b| b == 0).next().unwrap_or_default();
+        let input = CString::new(prefix).unwrap();
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), input.as_ptr()));
+    }
+});
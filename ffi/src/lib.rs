@@ -10,6 +10,12 @@
 //! All strings returned by redstr functions are heap-allocated and must be freed using
 //! `redstr_free_string()` to avoid memory leaks.
 //!
+//! ## Error Handling
+//!
+//! Panics never unwind into the caller. A function that panics internally
+//! returns its failure value (usually null) and the panic message can be read
+//! with `redstr_last_error()`.
+//!
 //! ## Example (C)
 //!
 //! ```c
@@ -23,8 +29,14 @@
 //! }
 //! ```
 
+use std::borrow::Cow;
+use std::cell::RefCell;
 use std::ffi::{CStr, CString};
-use std::os::raw::c_char;
+use std::os::raw::{c_char, c_void};
+use std::panic::{self, AssertUnwindSafe};
+use std::sync::atomic::{AtomicU8, Ordering};
+use std::sync::{Arc, OnceLock};
+use std::time::Duration;
 
 // ============================================================================
 // Memory Management
@@ -38,8 +50,26 @@ use std::os::raw::c_char;
 /// been freed already. Passing a null pointer is safe (no-op).
 #[no_mangle]
 pub unsafe extern "C" fn redstr_free_string(s: *mut c_char) {
-    if !s.is_null() {
-        drop(CString::from_raw(s));
+    ffi_guard("redstr_free_string", (), || {
+        if !s.is_null() {
+            drop(CString::from_raw(s));
+        }
+    })
+}
+
+/// Take the message of the most recent panic caught on this thread.
+///
+/// No redstr function unwinds into the caller: a panic inside one is caught,
+/// the function returns its failure value (null, 0, -1 or a null buffer) and
+/// the message is kept until read here. Call this after a failed call to
+/// tell an internal error from bad input. Returns null if no panic was
+/// caught since the last call; otherwise the string must be freed with
+/// `redstr_free_string()`.
+#[no_mangle]
+pub extern "C" fn redstr_last_error() -> *mut c_char {
+    match LAST_ERROR.with(|slot| slot.borrow_mut().take()) {
+        Some(message) => message.into_raw(),
+        None => std::ptr::null_mut(),
     }
 }
 
@@ -47,6 +77,43 @@ pub unsafe extern "C" fn redstr_free_string(s: *mut c_char) {
 // Helper Functions
 // ============================================================================
 
+thread_local! {
+    static LAST_ERROR: RefCell<Option<CString>> = const { RefCell::new(None) };
+}
+
+#[cfg(test)]
+thread_local! {
+    /// Makes the next guarded call on this thread panic, for chaos tests.
+    static INJECT_PANIC: std::cell::Cell<bool> = const { std::cell::Cell::new(false) };
+}
+
+/// Run the body of an exported function, turning a panic into `fallback`.
+///
+/// Unwinding out of an `extern "C"` function aborts the process, which would
+/// take an embedding server down with it.
+fn ffi_guard<T>(name: &str, fallback: T, body: impl FnOnce() -> T) -> T {
+    let result = panic::catch_unwind(AssertUnwindSafe(|| {
+        #[cfg(test)]
+        if INJECT_PANIC.with(|inject| inject.replace(false)) {
+            panic!("injected failure");
+        }
+        body()
+    }));
+    match result {
+        Ok(value) => value,
+        Err(payload) => {
+            let reason = payload
+                .downcast_ref::<&str>()
+                .map(|s| s.to_string())
+                .or_else(|| payload.downcast_ref::<String>().cloned())
+                .unwrap_or_else(|| "unknown panic".to_string());
+            let message = format!("{} panicked: {}", name, reason).replace('\0', " ");
+            LAST_ERROR.with(|slot| *slot.borrow_mut() = CString::new(message).ok());
+            fallback
+        }
+    }
+}
+
 /// Convert a C string to a Rust &str, returning None if invalid
 unsafe fn c_str_to_str<'a>(s: *const c_char) -> Option<&'a str> {
     if s.is_null() {
@@ -55,6 +122,66 @@ unsafe fn c_str_to_str<'a>(s: *const c_char) -> Option<&'a str> {
     CStr::from_ptr(s).to_str().ok()
 }
 
+/// Input after applying the invalid UTF-8 policy.
+enum Input<'a> {
+    Text(Cow<'a, str>),
+    /// Invalid UTF-8 passed through under `PassThroughBytes`.
+    Bytes(&'a [u8]),
+}
+
+impl Input<'_> {
+    /// Apply a registry transform; raw bytes only reach byte-capable transforms.
+    fn apply(&self, transform: &redstr::Transform) -> Option<String> {
+        match self {
+            Input::Text(text) => Some(transform.apply(text)),
+            Input::Bytes(bytes) => transform.apply_bytes(bytes),
+        }
+    }
+
+    /// Run a resolved recipe, appending to `dst`.
+    fn run(&self, transformer: &redstr::Transformer, dst: &mut String) -> bool {
+        match self {
+            Input::Text(text) => {
+                transformer.transform(dst, text);
+                true
+            }
+            Input::Bytes(bytes) => transformer.transform_bytes(dst, bytes),
+        }
+    }
+}
+
+/// Decode input bytes according to the current invalid UTF-8 policy.
+fn decode_input(bytes: &[u8]) -> Option<Input<'_>> {
+    match std::str::from_utf8(bytes) {
+        Ok(text) => Some(Input::Text(Cow::Borrowed(text))),
+        Err(_) => match utf8_policy() {
+            RedstrUtf8Policy::Reject => None,
+            RedstrUtf8Policy::ReplaceLossy => Some(Input::Text(String::from_utf8_lossy(bytes))),
+            RedstrUtf8Policy::PassThroughBytes => Some(Input::Bytes(bytes)),
+        },
+    }
+}
+
+/// Decode a C string input according to the invalid UTF-8 policy.
+unsafe fn c_input<'a>(s: *const c_char) -> Option<Input<'a>> {
+    if s.is_null() {
+        return None;
+    }
+    decode_input(CStr::from_ptr(s).to_bytes())
+}
+
+/// Apply a named transform to a C string input, honoring the UTF-8 policy.
+unsafe fn apply_named(spec: &str, input: *const c_char) -> *mut c_char {
+    let transform = match redstr::Transform::resolve(spec) {
+        Ok(transform) => transform,
+        Err(_) => return std::ptr::null_mut(),
+    };
+    match c_input(input).and_then(|input| input.apply(&transform)) {
+        Some(result) => string_to_c_char(result),
+        None => std::ptr::null_mut(),
+    }
+}
+
 /// Convert a Rust String to a C string, returning null on failure
 fn string_to_c_char(s: String) -> *mut c_char {
     match CString::new(s) {
@@ -74,11 +201,11 @@ fn string_to_c_char(s: String) -> *mut c_char {
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_randomize_capitalization(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::randomize_capitalization(input_str))
+    ffi_guard(
+        "redstr_randomize_capitalization",
+        std::ptr::null_mut(),
+        || apply_named("randomize_capitalization@1", input),
+    )
 }
 
 /// Swap the case of each character.
@@ -88,11 +215,9 @@ pub unsafe extern "C" fn redstr_randomize_capitalization(input: *const c_char) -
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_case_swap(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::case_swap(input_str))
+    ffi_guard("redstr_case_swap", std::ptr::null_mut(), || {
+        apply_named("case_swap@1", input)
+    })
 }
 
 /// Alternate case for each character.
@@ -102,11 +227,9 @@ pub unsafe extern "C" fn redstr_case_swap(input: *const c_char) -> *mut c_char {
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_alternate_case(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::alternate_case(input_str))
+    ffi_guard("redstr_alternate_case", std::ptr::null_mut(), || {
+        apply_named("alternate_case@1", input)
+    })
 }
 
 /// Inverse case transformation.
@@ -116,11 +239,9 @@ pub unsafe extern "C" fn redstr_alternate_case(input: *const c_char) -> *mut c_c
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_inverse_case(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::inverse_case(input_str))
+    ffi_guard("redstr_inverse_case", std::ptr::null_mut(), || {
+        apply_named("inverse_case@1", input)
+    })
 }
 
 // ============================================================================
@@ -134,11 +255,9 @@ pub unsafe extern "C" fn redstr_inverse_case(input: *const c_char) -> *mut c_cha
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_base64_encode(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::base64_encode(input_str))
+    ffi_guard("redstr_base64_encode", std::ptr::null_mut(), || {
+        apply_named("base64_encode@1", input)
+    })
 }
 
 /// URL encode a string.
@@ -148,11 +267,9 @@ pub unsafe extern "C" fn redstr_base64_encode(input: *const c_char) -> *mut c_ch
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_url_encode(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::url_encode(input_str))
+    ffi_guard("redstr_url_encode", std::ptr::null_mut(), || {
+        apply_named("url_encode@1", input)
+    })
 }
 
 /// Hex encode a string.
@@ -162,11 +279,9 @@ pub unsafe extern "C" fn redstr_url_encode(input: *const c_char) -> *mut c_char
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_hex_encode(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::hex_encode(input_str))
+    ffi_guard("redstr_hex_encode", std::ptr::null_mut(), || {
+        apply_named("hex_encode@1", input)
+    })
 }
 
 /// HTML entity encode a string.
@@ -176,11 +291,9 @@ pub unsafe extern "C" fn redstr_hex_encode(input: *const c_char) -> *mut c_char
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_html_entity_encode(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::html_entity_encode(input_str))
+    ffi_guard("redstr_html_entity_encode", std::ptr::null_mut(), || {
+        apply_named("html_entity_encode@1", input)
+    })
 }
 
 // ============================================================================
@@ -194,11 +307,9 @@ pub unsafe extern "C" fn redstr_html_entity_encode(input: *const c_char) -> *mut
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_leetspeak(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::leetspeak(input_str))
+    ffi_guard("redstr_leetspeak", std::ptr::null_mut(), || {
+        apply_named("leetspeak@1", input)
+    })
 }
 
 /// Apply ROT13 cipher.
@@ -208,11 +319,9 @@ pub unsafe extern "C" fn redstr_leetspeak(input: *const c_char) -> *mut c_char {
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_rot13(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::rot13(input_str))
+    ffi_guard("redstr_rot13", std::ptr::null_mut(), || {
+        apply_named("rot13@1", input)
+    })
 }
 
 /// Reverse a string.
@@ -222,11 +331,9 @@ pub unsafe extern "C" fn redstr_rot13(input: *const c_char) -> *mut c_char {
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_reverse_string(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::reverse_string(input_str))
+    ffi_guard("redstr_reverse_string", std::ptr::null_mut(), || {
+        apply_named("reverse_string@1", input)
+    })
 }
 
 /// Double each character in the string.
@@ -236,11 +343,9 @@ pub unsafe extern "C" fn redstr_reverse_string(input: *const c_char) -> *mut c_c
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_double_characters(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::double_characters(input_str))
+    ffi_guard("redstr_double_characters", std::ptr::null_mut(), || {
+        apply_named("double_characters@1", input)
+    })
 }
 
 // ============================================================================
@@ -254,11 +359,11 @@ pub unsafe extern "C" fn redstr_double_characters(input: *const c_char) -> *mut
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_homoglyph_substitution(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::homoglyph_substitution(input_str))
+    ffi_guard(
+        "redstr_homoglyph_substitution",
+        std::ptr::null_mut(),
+        || apply_named("homoglyph_substitution@1", input),
+    )
 }
 
 /// Apply zalgo text effect.
@@ -268,11 +373,9 @@ pub unsafe extern "C" fn redstr_homoglyph_substitution(input: *const c_char) ->
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_zalgo_text(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::zalgo_text(input_str))
+    ffi_guard("redstr_zalgo_text", std::ptr::null_mut(), || {
+        apply_named("zalgo_text@1", input)
+    })
 }
 
 // ============================================================================
@@ -286,11 +389,9 @@ pub unsafe extern "C" fn redstr_zalgo_text(input: *const c_char) -> *mut c_char
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_domain_typosquat(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::domain_typosquat(input_str))
+    ffi_guard("redstr_domain_typosquat", std::ptr::null_mut(), || {
+        apply_named("domain_typosquat@1", input)
+    })
 }
 
 /// Obfuscate email address.
@@ -300,11 +401,9 @@ pub unsafe extern "C" fn redstr_domain_typosquat(input: *const c_char) -> *mut c
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_email_obfuscation(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::email_obfuscation(input_str))
+    ffi_guard("redstr_email_obfuscation", std::ptr::null_mut(), || {
+        apply_named("email_obfuscation@1", input)
+    })
 }
 
 // ============================================================================
@@ -318,11 +417,9 @@ pub unsafe extern "C" fn redstr_email_obfuscation(input: *const c_char) -> *mut
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_xss_tag_variations(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::xss_tag_variations(input_str))
+    ffi_guard("redstr_xss_tag_variations", std::ptr::null_mut(), || {
+        apply_named("xss_tag_variations@1", input)
+    })
 }
 
 /// Apply SQL comment injection.
@@ -332,11 +429,9 @@ pub unsafe extern "C" fn redstr_xss_tag_variations(input: *const c_char) -> *mut
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_sql_comment_injection(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::sql_comment_injection(input_str))
+    ffi_guard("redstr_sql_comment_injection", std::ptr::null_mut(), || {
+        apply_named("sql_comment_injection@1", input)
+    })
 }
 
 /// Apply command injection patterns.
@@ -346,11 +441,9 @@ pub unsafe extern "C" fn redstr_sql_comment_injection(input: *const c_char) -> *
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_command_injection(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::command_injection(input_str))
+    ffi_guard("redstr_command_injection", std::ptr::null_mut(), || {
+        apply_named("command_injection@1", input)
+    })
 }
 
 /// Apply path traversal patterns.
@@ -360,11 +453,9 @@ pub unsafe extern "C" fn redstr_command_injection(input: *const c_char) -> *mut
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_path_traversal(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::path_traversal(input_str))
+    ffi_guard("redstr_path_traversal", std::ptr::null_mut(), || {
+        apply_named("path_traversal@1", input)
+    })
 }
 
 // ============================================================================
@@ -378,7 +469,9 @@ pub unsafe extern "C" fn redstr_path_traversal(input: *const c_char) -> *mut c_c
 /// This function is always safe to call.
 #[no_mangle]
 pub extern "C" fn redstr_random_user_agent() -> *mut c_char {
-    string_to_c_char(redstr::random_user_agent())
+    ffi_guard("redstr_random_user_agent", std::ptr::null_mut(), || {
+        string_to_c_char(redstr::random_user_agent())
+    })
 }
 
 // ============================================================================
@@ -392,11 +485,9 @@ pub extern "C" fn redstr_random_user_agent() -> *mut c_char {
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_powershell_obfuscate(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::powershell_obfuscate(input_str))
+    ffi_guard("redstr_powershell_obfuscate", std::ptr::null_mut(), || {
+        apply_named("powershell_obfuscate@1", input)
+    })
 }
 
 /// Obfuscate Bash command.
@@ -406,70 +497,1987 @@ pub unsafe extern "C" fn redstr_powershell_obfuscate(input: *const c_char) -> *m
 /// `input` must be a valid null-terminated UTF-8 string.
 #[no_mangle]
 pub unsafe extern "C" fn redstr_bash_obfuscate(input: *const c_char) -> *mut c_char {
-    let input_str = match c_str_to_str(input) {
-        Some(s) => s,
-        None => return std::ptr::null_mut(),
-    };
-    string_to_c_char(redstr::bash_obfuscate(input_str))
+    ffi_guard("redstr_bash_obfuscate", std::ptr::null_mut(), || {
+        apply_named("bash_obfuscate@1", input)
+    })
 }
 
 // ============================================================================
-// Tests
+// Named Transforms
 // ============================================================================
 
-#[cfg(test)]
-mod tests {
-    use super::*;
-    use std::ffi::CString;
+/// Apply a transform by name, e.g. `"leetspeak"` or `"leetspeak@1"`.
+///
+/// Returns null if the spec is unknown or the input is rejected by the
+/// invalid UTF-8 policy (see `redstr_set_invalid_utf8_policy()`).
+///
+/// # Safety
+///
+/// `spec` and `input` must be valid null-terminated UTF-8 strings.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_apply_transform(
+    spec: *const c_char,
+    input: *const c_char,
+) -> *mut c_char {
+    ffi_guard(
+        "redstr_apply_transform",
+        std::ptr::null_mut(),
+        || match c_str_to_str(spec) {
+            Some(spec) => apply_named(spec, input),
+            None => std::ptr::null_mut(),
+        },
+    )
+}
 
-    #[test]
-    fn test_leetspeak_ffi() {
-        unsafe {
-            let input = CString::new("password").unwrap();
-            let result = redstr_leetspeak(input.as_ptr());
-            assert!(!result.is_null());
-            let result_str = CStr::from_ptr(result).to_str().unwrap();
-            assert!(!result_str.is_empty());
-            redstr_free_string(result);
+/// Apply a whole pipeline of transforms in one call.
+///
+/// `pipeline` is a serialized step list in recipe form, e.g.
+/// `"leetspeak@1 | base64_encode@1 | url_encode"`, as produced by the Rust
+/// `TransformBuilder::recipe()`. Intermediate results stay on the Rust side,
+/// so a binding's builder crosses the boundary once instead of once per step.
+/// An empty pipeline returns a copy of the input.
+///
+/// Returns null if any step is unknown (no step runs in that case) or the
+/// input is rejected by the invalid UTF-8 policy. Under `PassThroughBytes`
+/// the first step must accept raw bytes.
+///
+/// # Safety
+///
+/// `pipeline` and `input` must be valid null-terminated UTF-8 strings.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_apply_pipeline(
+    pipeline: *const c_char,
+    input: *const c_char,
+) -> *mut c_char {
+    ffi_guard("redstr_apply_pipeline", std::ptr::null_mut(), || {
+        let transformer = match c_str_to_str(pipeline).map(redstr::Transformer::new) {
+            Some(Ok(transformer)) => transformer,
+            _ => return std::ptr::null_mut(),
+        };
+        let mut result = String::new();
+        match c_input(input) {
+            Some(input) if input.run(&transformer, &mut result) => string_to_c_char(result),
+            _ => std::ptr::null_mut(),
+        }
+    })
+}
+
+/// Apply a transform by name to `count` inputs in a single call.
+///
+/// The spec is resolved once for the whole batch, so high-throughput callers
+/// pay the boundary crossing and lookup once per batch instead of once per
+/// string. One result is written to `outputs[i]` for each `inputs[i]`; entries
+/// whose input is null or rejected by the invalid UTF-8 policy are set to
+/// null. If the spec is
+/// unknown every output is null.
+///
+/// Returns the number of non-null outputs. Each output must be freed with
+/// `redstr_free_string()`, or all at once with `redstr_free_string_array()`.
+///
+/// # Safety
+///
+/// `spec` must be a valid null-terminated UTF-8 string. `inputs` must point to
+/// `count` string pointers and `outputs` must have room for `count` pointers.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transform_batch(
+    spec: *const c_char,
+    inputs: *const *const c_char,
+    count: usize,
+    outputs: *mut *mut c_char,
+) -> usize {
+    ffi_guard("redstr_transform_batch", 0, || {
+        run_batch(spec, inputs, count, outputs, None).written
+    })
+}
+
+/// Like `redstr_transform_batch()`, but stops once `timeout_ms` milliseconds
+/// have passed.
+///
+/// The deadline is checked before each input and outputs for inputs not
+/// reached in time are left null. The return value counts non-null outputs
+/// only, so it cannot tell a rejected input from one that was never reached;
+/// use `redstr_transform_batch_ctx()` when that matters. A `timeout_ms` of 0
+/// means no deadline.
+///
+/// # Safety
+///
+/// Same requirements as `redstr_transform_batch()`.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transform_batch_timeout(
+    spec: *const c_char,
+    inputs: *const *const c_char,
+    count: usize,
+    outputs: *mut *mut c_char,
+    timeout_ms: u64,
+) -> usize {
+    ffi_guard("redstr_transform_batch_timeout", 0, || {
+        let ctx = batch_context(timeout_ms);
+        run_batch(spec, inputs, count, outputs, Some(&ctx)).written
+    })
+}
+
+/// Opaque cancellation handle for `redstr_transform_batch_ctx()`.
+pub struct RedstrBatchContext {
+    ctx: redstr::BatchContext,
+}
+
+/// How a batch call ended.
+#[repr(C)]
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum RedstrBatchStatus {
+    /// Every input was reached. Null outputs mark inputs that were null or
+    /// rejected by the invalid UTF-8 policy.
+    Ok = 0,
+    /// `redstr_batch_context_cancel()` was called before every input was reached.
+    Cancelled = 1,
+    /// The context's deadline passed before every input was reached.
+    DeadlineExceeded = 2,
+    /// `spec` was null or unknown, or `inputs` or `outputs` was null; no input
+    /// was reached.
+    InvalidArgument = 3,
+}
+
+/// Create a batch context that expires after `timeout_ms` milliseconds, or
+/// never if `timeout_ms` is 0.
+///
+/// The context must be freed with `redstr_batch_context_free()`.
+#[no_mangle]
+pub extern "C" fn redstr_batch_context_new(timeout_ms: u64) -> *mut RedstrBatchContext {
+    ffi_guard("redstr_batch_context_new", std::ptr::null_mut(), || {
+        Box::into_raw(Box::new(RedstrBatchContext {
+            ctx: batch_context(timeout_ms),
+        }))
+    })
+}
+
+/// Cancel a batch context, stopping any batch running with it before its
+/// next input.
+///
+/// This may be called from any thread, including while another thread is
+/// inside `redstr_transform_batch_ctx()` with the same context. Cancelling
+/// null is a no-op.
+///
+/// # Safety
+///
+/// `ctx` must be null or come from `redstr_batch_context_new()` and not be
+/// freed.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_batch_context_cancel(ctx: *const RedstrBatchContext) {
+    ffi_guard("redstr_batch_context_cancel", (), || {
+        if let Some(handle) = ctx.as_ref() {
+            handle.ctx.cancel();
+        }
+    })
+}
+
+/// Free a context created by `redstr_batch_context_new()`. Freeing null is
+/// a no-op.
+///
+/// # Safety
+///
+/// `ctx` must be null or come from `redstr_batch_context_new()`, must not be
+/// freed twice, and no batch may still be running with it.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_batch_context_free(ctx: *mut RedstrBatchContext) {
+    ffi_guard("redstr_batch_context_free", (), || {
+        if !ctx.is_null() {
+            drop(Box::from_raw(ctx));
         }
+    })
+}
+
+/// Like `redstr_transform_batch()`, but stops early once `ctx` is cancelled
+/// or past its deadline, and reports how far it got.
+///
+/// The context is checked before each input. The number of inputs reached is
+/// stored in `*reached` when `reached` is not null: a null `outputs[i]` below
+/// that index means the input was null or rejected, and every output from
+/// that index on was never attempted. A null `ctx` never stops early.
+///
+/// # Safety
+///
+/// Same requirements as `redstr_transform_batch()`. `ctx` must be null or come
+/// from `redstr_batch_context_new()` and not be freed, and `reached` must be
+/// null or point to a writable `size_t`.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transform_batch_ctx(
+    spec: *const c_char,
+    inputs: *const *const c_char,
+    count: usize,
+    outputs: *mut *mut c_char,
+    ctx: *const RedstrBatchContext,
+    reached: *mut usize,
+) -> RedstrBatchStatus {
+    ffi_guard(
+        "redstr_transform_batch_ctx",
+        RedstrBatchStatus::InvalidArgument,
+        || {
+            let run = run_batch(spec, inputs, count, outputs, ctx.as_ref().map(|h| &h.ctx));
+            if let Some(reached) = reached.as_mut() {
+                *reached = run.reached;
+            }
+            run.status
+        },
+    )
+}
+
+/// Result of `run_batch()`.
+struct BatchRun {
+    status: RedstrBatchStatus,
+    reached: usize,
+    written: usize,
+}
+
+fn batch_context(timeout_ms: u64) -> redstr::BatchContext {
+    match timeout_ms {
+        0 => redstr::BatchContext::new(),
+        ms => redstr::BatchContext::new().with_timeout(Duration::from_millis(ms)),
     }
+}
 
-    #[test]
-    fn test_base64_encode_ffi() {
-        unsafe {
-            let input = CString::new("hello").unwrap();
-            let result = redstr_base64_encode(input.as_ptr());
-            assert!(!result.is_null());
-            let result_str = CStr::from_ptr(result).to_str().unwrap();
-            assert_eq!(result_str, "aGVsbG8=");
-            redstr_free_string(result);
+/// The loop shared by the batch exports, checking `ctx` before each input.
+unsafe fn run_batch(
+    spec: *const c_char,
+    inputs: *const *const c_char,
+    count: usize,
+    outputs: *mut *mut c_char,
+    ctx: Option<&redstr::BatchContext>,
+) -> BatchRun {
+    let mut run = BatchRun {
+        status: RedstrBatchStatus::InvalidArgument,
+        reached: 0,
+        written: 0,
+    };
+    if count == 0 {
+        run.status = RedstrBatchStatus::Ok;
+        return run;
+    }
+    if outputs.is_null() {
+        return run;
+    }
+    let outputs = std::slice::from_raw_parts_mut(outputs, count);
+    outputs.fill(std::ptr::null_mut());
+
+    if inputs.is_null() {
+        return run;
+    }
+    let transform = match c_str_to_str(spec).map(redstr::Transform::resolve) {
+        Some(Ok(transform)) => transform,
+        _ => return run,
+    };
+
+    let inputs = std::slice::from_raw_parts(inputs, count);
+    let span = redstr::TraceSpan::start(
+        redstr::Operation::Batch,
+        || transform.spec().to_string(),
+        count,
+    );
+    run.status = RedstrBatchStatus::Ok;
+    let mut written_bytes = 0;
+    for (input, output) in inputs.iter().zip(outputs.iter_mut()) {
+        if let Some(ctx) = ctx.filter(|ctx| ctx.is_done()) {
+            run.status = if ctx.is_cancelled() {
+                RedstrBatchStatus::Cancelled
+            } else {
+                RedstrBatchStatus::DeadlineExceeded
+            };
+            break;
+        }
+        run.reached += 1;
+        if let Some(result) = c_input(*input).and_then(|input| input.apply(&transform)) {
+            let len = result.len();
+            *output = string_to_c_char(result);
+            if !output.is_null() {
+                run.written += 1;
+                written_bytes += len;
+            }
         }
     }
+    let status = match run.status {
+        RedstrBatchStatus::Cancelled => redstr::SpanStatus::Cancelled,
+        RedstrBatchStatus::DeadlineExceeded => redstr::SpanStatus::DeadlineExceeded,
+        _ => redstr::SpanStatus::Ok,
+    };
+    span.finish_counts(status, run.written, written_bytes);
+    run
+}
 
-    #[test]
-    fn test_null_input() {
-        unsafe {
-            let result = redstr_leetspeak(std::ptr::null());
-            assert!(result.is_null());
+/// Free `count` strings in an array filled by `redstr_transform_batch()`.
+///
+/// Each freed slot is reset to null, so calling this twice is safe.
+///
+/// # Safety
+///
+/// `strings` must point to `count` pointers that are either null or were
+/// returned by a redstr function and not freed already.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_free_string_array(strings: *mut *mut c_char, count: usize) {
+    ffi_guard("redstr_free_string_array", (), || {
+        if strings.is_null() {
+            return;
+        }
+        for slot in std::slice::from_raw_parts_mut(strings, count) {
+            redstr_free_string(*slot);
+            *slot = std::ptr::null_mut();
+        }
+    })
+}
+
+// ============================================================================
+// Reusable Transformers
+// ============================================================================
+
+/// A resolved recipe plus reusable output buffers.
+///
+/// Created by `redstr_transformer_new()` and freed with
+/// `redstr_transformer_free()`. A handle is not thread-safe; use one per
+/// thread.
+pub struct RedstrTransformer {
+    transformer: redstr::Transformer,
+    output: String,
+    pending_input: Option<Vec<u8>>,
+}
+
+/// Resolve a `|`-separated recipe once for repeated use.
+///
+/// Returns null if the recipe is null, not valid UTF-8, or names an unknown
+/// transform.
+///
+/// # Safety
+///
+/// `recipe` must be a valid null-terminated string.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transformer_new(recipe: *const c_char) -> *mut RedstrTransformer {
+    ffi_guard(
+        "redstr_transformer_new",
+        std::ptr::null_mut(),
+        || match c_str_to_str(recipe).map(redstr::Transformer::new) {
+            Some(Ok(transformer)) => Box::into_raw(Box::new(RedstrTransformer {
+                transformer,
+                output: String::new(),
+                pending_input: None,
+            })),
+            _ => std::ptr::null_mut(),
+        },
+    )
+}
+
+/// Transform `input` into the caller-owned buffer `dst` of `dst_len` bytes.
+///
+/// Nothing is allocated for the caller and nothing needs to be freed, so a
+/// binding can pass the same pooled buffer on every call. Returns the output
+/// length in bytes, excluding the terminating null, like `snprintf`. If that
+/// is not less than `dst_len`, nothing is written and the output is kept: the
+/// next call with the same input returns it instead of transforming again, so
+/// retrying with a larger buffer yields the same randomized result.
+///
+/// Returns -1 if `transformer` or `input` is null or `input` is rejected by
+/// the invalid UTF-8 policy.
+///
+/// # Safety
+///
+/// `transformer` must come from `redstr_transformer_new()` and not be freed.
+/// `input` must be a valid null-terminated string. `dst` must be null or point
+/// to `dst_len` writable bytes.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transformer_apply_into(
+    transformer: *mut RedstrTransformer,
+    input: *const c_char,
+    dst: *mut c_char,
+    dst_len: usize,
+) -> isize {
+    ffi_guard("redstr_transformer_apply_into", -1, || {
+        let handle = match transformer.as_mut() {
+            Some(handle) if !input.is_null() => handle,
+            _ => return -1,
+        };
+        let input_bytes = CStr::from_ptr(input).to_bytes();
+
+        if handle.pending_input.as_deref() != Some(input_bytes) {
+            handle.output.clear();
+            let transformed = decode_input(input_bytes)
+                .is_some_and(|input| input.run(&handle.transformer, &mut handle.output));
+            if !transformed {
+                handle.pending_input = None;
+                return -1;
+            }
+        }
+
+        let len = handle.output.len();
+        if dst.is_null() || len >= dst_len {
+            let pending = handle.pending_input.get_or_insert_with(Vec::new);
+            pending.clear();
+            pending.extend_from_slice(input_bytes);
+            return len as isize;
+        }
+        std::ptr::copy_nonoverlapping(handle.output.as_ptr(), dst as *mut u8, len);
+        *dst.add(len) = 0;
+        handle.pending_input = None;
+        len as isize
+    })
+}
+
+/// Free a transformer created by `redstr_transformer_new()`.
+///
+/// # Safety
+///
+/// `transformer` must be null or come from `redstr_transformer_new()` and not
+/// be freed already.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transformer_free(transformer: *mut RedstrTransformer) {
+    ffi_guard("redstr_transformer_free", (), || {
+        if !transformer.is_null() {
+            drop(Box::from_raw(transformer));
+        }
+    })
+}
+
+// ============================================================================
+// Batching Clients
+// ============================================================================
+
+/// Callback receiving the outputs of one batch flushed by a `RedstrBatcher`.
+///
+/// `outputs[i]` is the null-terminated output for the `i`-th input pushed
+/// since the previous flush and `lens[i]` its length in bytes, or null and 0
+/// if that input was null or rejected. The strings live in the batcher's
+/// reused buffer and are only valid until the callback returns. Receives the
+/// `user_data` pointer the batcher was created with.
+pub type RedstrFlushCallback = extern "C" fn(
+    outputs: *const *const c_char,
+    lens: *const usize,
+    count: usize,
+    user_data: *mut c_void,
+);
+
+/// A resolved recipe that collects outputs in reused buffers and hands them
+/// to a callback in batches.
+///
+/// Created by `redstr_batcher_new()` and freed with `redstr_batcher_free()`.
+/// A handle is not thread-safe; use one per thread.
+pub struct RedstrBatcher {
+    transformer: redstr::Transformer,
+    max_items: usize,
+    max_bytes: usize,
+    flush: RedstrFlushCallback,
+    user_data: *mut c_void,
+    /// Pending outputs, each followed by a null terminator.
+    arena: String,
+    /// Offset and length in `arena` of each pending output, or `None`.
+    spans: Vec<Option<(usize, usize)>>,
+    outputs: Vec<*const c_char>,
+    lens: Vec<usize>,
+}
+
+impl RedstrBatcher {
+    /// Queue the output for `input`, flushing if a threshold is reached.
+    unsafe fn push(&mut self, input: *const c_char) -> usize {
+        let start = self.arena.len();
+        let transformed =
+            c_input(input).is_some_and(|input| input.run(&self.transformer, &mut self.arena));
+        // An output with an embedded null cannot be handed out as a C string
+        if transformed && !self.arena[start..].contains('\0') {
+            self.spans.push(Some((start, self.arena.len() - start)));
+            self.arena.push('\0');
+        } else {
+            self.arena.truncate(start);
+            self.spans.push(None);
+        }
+
+        let full_items = self.max_items != 0 && self.spans.len() >= self.max_items;
+        let full_bytes = self.max_bytes != 0 && self.arena.len() >= self.max_bytes;
+        if full_items || full_bytes {
+            self.flush()
+        } else {
+            0
         }
     }
 
-    #[test]
-    fn test_free_null() {
-        unsafe {
-            // Should not crash
-            redstr_free_string(std::ptr::null_mut());
+    /// Hand the pending outputs to the callback and reset the buffers,
+    /// keeping their capacity.
+    fn flush(&mut self) -> usize {
+        let count = self.spans.len();
+        if count == 0 {
+            return 0;
+        }
+        self.outputs.clear();
+        self.lens.clear();
+        for span in &self.spans {
+            match *span {
+                Some((start, len)) => {
+                    self.outputs
+                        .push(self.arena[start..].as_ptr() as *const c_char);
+                    self.lens.push(len);
+                }
+                None => {
+                    self.outputs.push(std::ptr::null());
+                    self.lens.push(0);
+                }
+            }
         }
+        (self.flush)(
+            self.outputs.as_ptr(),
+            self.lens.as_ptr(),
+            count,
+            self.user_data,
+        );
+        self.arena.clear();
+        self.spans.clear();
+        count
     }
+}
 
-    #[test]
-    fn test_random_user_agent_ffi() {
-        let result = redstr_random_user_agent();
-        assert!(!result.is_null());
-        unsafe {
-            let result_str = CStr::from_ptr(result).to_str().unwrap();
-            assert!(result_str.contains("Mozilla") || result_str.contains("Chrome"));
-            redstr_free_string(result);
+/// Resolve a recipe into a batcher that flushes to `flush` on a threshold.
+///
+/// Outputs accumulate in one buffer that is reused from batch to batch, so
+/// a steady stream of inputs costs no allocation per call and one callback
+/// per batch instead of one `redstr_free_string()` per output. A batch is
+/// flushed once `max_items` outputs are pending or the pending outputs,
+/// with their null terminators, reach `max_bytes` bytes; 0 disables that
+/// threshold.
+///
+/// Returns null if the recipe is null, not valid UTF-8, or names an unknown
+/// transform, or if `flush` is null.
+///
+/// # Safety
+///
+/// `recipe` must be a valid null-terminated string. `flush` is called on the
+/// thread that pushes or flushes, and it and `user_data` must stay valid
+/// until the batcher is freed.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_batcher_new(
+    recipe: *const c_char,
+    max_items: usize,
+    max_bytes: usize,
+    flush: Option<RedstrFlushCallback>,
+    user_data: *mut c_void,
+) -> *mut RedstrBatcher {
+    ffi_guard("redstr_batcher_new", std::ptr::null_mut(), || {
+        let flush = match flush {
+            Some(flush) => flush,
+            None => return std::ptr::null_mut(),
+        };
+        match c_str_to_str(recipe).map(redstr::Transformer::new) {
+            Some(Ok(transformer)) => Box::into_raw(Box::new(RedstrBatcher {
+                transformer,
+                max_items,
+                max_bytes,
+                flush,
+                user_data,
+                arena: String::new(),
+                spans: Vec::new(),
+                outputs: Vec::new(),
+                lens: Vec::new(),
+            })),
+            _ => std::ptr::null_mut(),
+        }
+    })
+}
+
+/// Transform `input` and queue its output for the next flush.
+///
+/// A null or rejected input still takes a slot, with a null output, so the
+/// outputs of a batch line up with the inputs pushed. Returns the number of
+/// outputs flushed by this call, 0 while the batch is still filling, or -1
+/// if `batcher` is null.
+///
+/// # Safety
+///
+/// `batcher` must come from `redstr_batcher_new()` and not be freed, and
+/// must not be used from inside its own flush callback. `input` must be null
+/// or a valid null-terminated string.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_batcher_push(
+    batcher: *mut RedstrBatcher,
+    input: *const c_char,
+) -> isize {
+    ffi_guard("redstr_batcher_push", -1, || match batcher.as_mut() {
+        Some(batcher) => batcher.push(input) as isize,
+        None => -1,
+    })
+}
+
+/// Flush the pending outputs now, for example at the end of the input.
+///
+/// Returns the number of outputs flushed. With nothing pending the callback
+/// is not called and 0 is returned.
+///
+/// # Safety
+///
+/// Same requirements as `redstr_batcher_push()`; `batcher` may also be null.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_batcher_flush(batcher: *mut RedstrBatcher) -> usize {
+    ffi_guard("redstr_batcher_flush", 0, || {
+        batcher.as_mut().map_or(0, RedstrBatcher::flush)
+    })
+}
+
+/// Free a batcher created by `redstr_batcher_new()`.
+///
+/// Outputs still pending are discarded without calling the flush callback;
+/// call `redstr_batcher_flush()` first to keep them.
+///
+/// # Safety
+///
+/// `batcher` must be null or come from `redstr_batcher_new()` and not be
+/// freed already.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_batcher_free(batcher: *mut RedstrBatcher) {
+    ffi_guard("redstr_batcher_free", (), || {
+        if !batcher.is_null() {
+            drop(Box::from_raw(batcher));
+        }
+    })
+}
+
+// ============================================================================
+// Zero-Copy Buffers
+// ============================================================================
+
+/// A Rust-allocated byte buffer handed to the caller without copying.
+///
+/// `data` points to `len` bytes of UTF-8 that are not null-terminated. The
+/// caller owns the buffer until it passes it to `redstr_buffer_release()`
+/// exactly once; the bytes may be read in place (for example wrapped as a
+/// slice by a binding) but not resized or freed any other way. A null `data`
+/// means the call failed; an empty result has a non-null `data` and `len` 0.
+#[repr(C)]
+pub struct RedstrBuffer {
+    pub data: *mut u8,
+    pub len: usize,
+}
+
+impl RedstrBuffer {
+    fn null() -> Self {
+        RedstrBuffer {
+            data: std::ptr::null_mut(),
+            len: 0,
+        }
+    }
+
+    fn from_string(s: String) -> Self {
+        let bytes = s.into_bytes().into_boxed_slice();
+        let len = bytes.len();
+        RedstrBuffer {
+            data: Box::into_raw(bytes) as *mut u8,
+            len,
+        }
+    }
+}
+
+/// Apply a transform by name to `input_len` bytes at `input`, returning the
+/// result as a `RedstrBuffer` instead of a copied C string.
+///
+/// Meant for multi-megabyte payloads: the input needs no null terminator and
+/// the output is not copied again on the way out. Returns a buffer with null
+/// `data` if the spec is unknown or the input is rejected by the invalid
+/// UTF-8 policy.
+///
+/// # Safety
+///
+/// `spec` must be a valid null-terminated string. `input` must point to
+/// `input_len` readable bytes (it may be null only if `input_len` is 0).
+#[no_mangle]
+pub unsafe extern "C" fn redstr_transform_buffer(
+    spec: *const c_char,
+    input: *const u8,
+    input_len: usize,
+) -> RedstrBuffer {
+    ffi_guard("redstr_transform_buffer", RedstrBuffer::null(), || {
+        let input = if input_len == 0 {
+            &[][..]
+        } else if input.is_null() {
+            return RedstrBuffer::null();
+        } else {
+            std::slice::from_raw_parts(input, input_len)
+        };
+        let transform = match c_str_to_str(spec).map(redstr::Transform::resolve) {
+            Some(Ok(transform)) => transform,
+            _ => return RedstrBuffer::null(),
+        };
+        match decode_input(input).and_then(|input| input.apply(&transform)) {
+            Some(result) => RedstrBuffer::from_string(result),
+            None => RedstrBuffer::null(),
+        }
+    })
+}
+
+/// Release a buffer returned by `redstr_transform_buffer()`.
+///
+/// The buffer is reset to null `data` and `len` 0, so releasing it twice
+/// through the same pointer is safe. Passing null is a no-op.
+///
+/// # Safety
+///
+/// `buffer` must be null or point to a `RedstrBuffer` returned by redstr whose
+/// `data` and `len` were not modified. Copies of the struct must not be
+/// released separately.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_buffer_release(buffer: *mut RedstrBuffer) {
+    ffi_guard("redstr_buffer_release", (), || {
+        let buffer = match buffer.as_mut() {
+            Some(buffer) => buffer,
+            None => return,
+        };
+        if !buffer.data.is_null() {
+            drop(Box::from_raw(std::ptr::slice_from_raw_parts_mut(
+                buffer.data,
+                buffer.len,
+            )));
+        }
+        *buffer = RedstrBuffer::null();
+    })
+}
+
+// ============================================================================
+// Invalid UTF-8 Policy
+// ============================================================================
+
+/// How redstr treats input that is not valid UTF-8.
+#[repr(C)]
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum RedstrUtf8Policy {
+    /// Fail the call: it returns null (or its other failure value). The default.
+    Reject = 0,
+    /// Replace each invalid sequence with U+FFFD and transform the result.
+    ReplaceLossy = 1,
+    /// Hand the raw bytes to transforms that accept them (`base64_encode`,
+    /// `hex_encode`, `hex_encode_mixed`, `sql_string_to_hex_literal`,
+    /// `url_encode`); every other transform rejects the input.
+    PassThroughBytes = 2,
+}
+
+static UTF8_POLICY: AtomicU8 = AtomicU8::new(RedstrUtf8Policy::Reject as u8);
+
+fn utf8_policy() -> RedstrUtf8Policy {
+    match UTF8_POLICY.load(Ordering::Relaxed) {
+        1 => RedstrUtf8Policy::ReplaceLossy,
+        2 => RedstrUtf8Policy::PassThroughBytes,
+        _ => RedstrUtf8Policy::Reject,
+    }
+}
+
+/// Set how every redstr function treats input that is not valid UTF-8.
+///
+/// `policy` is a `RedstrUtf8Policy` value. The policy is process-wide and
+/// applies to string inputs only; specs and recipes must always be UTF-8.
+/// Valid UTF-8 input behaves the same under every policy. Returns false,
+/// leaving the policy unchanged, if `policy` is not a known value.
+#[no_mangle]
+pub extern "C" fn redstr_set_invalid_utf8_policy(policy: u32) -> bool {
+    ffi_guard("redstr_set_invalid_utf8_policy", false, || {
+        let policy = match policy {
+            0 => RedstrUtf8Policy::Reject,
+            1 => RedstrUtf8Policy::ReplaceLossy,
+            2 => RedstrUtf8Policy::PassThroughBytes,
+            _ => return false,
+        };
+        UTF8_POLICY.store(policy as u8, Ordering::Relaxed);
+        true
+    })
+}
+
+// ============================================================================
+// Randomness Source
+// ============================================================================
+
+/// Callback returning a 64-bit seed for the next generator.
+///
+/// Receives the `user_data` pointer it was registered with.
+pub type RedstrSeedCallback = extern "C" fn(user_data: *mut c_void) -> u64;
+
+/// A registered seed callback and its user data.
+struct SeedCallback {
+    callback: RedstrSeedCallback,
+    user_data: *mut c_void,
+}
+
+// The caller of `redstr_set_rand_source_callback` guarantees the callback and
+// its user data may be used from any thread.
+unsafe impl Send for SeedCallback {}
+unsafe impl Sync for SeedCallback {}
+
+impl SeedCallback {
+    fn seed(&self) -> u64 {
+        (self.callback)(self.user_data)
+    }
+}
+
+/// Seed generators from the clock (the default).
+#[no_mangle]
+pub extern "C" fn redstr_set_rand_source_time() {
+    ffi_guard("redstr_set_rand_source_time", (), || {
+        redstr::set_rand_source(redstr::RandSource::Time);
+    })
+}
+
+/// Seed generators from the operating system's random source.
+#[no_mangle]
+pub extern "C" fn redstr_set_rand_source_os() {
+    ffi_guard("redstr_set_rand_source_os", (), || {
+        redstr::set_rand_source(redstr::RandSource::Os);
+    })
+}
+
+/// Seed generators from one stream started at `seed`, for reproducible runs.
+#[no_mangle]
+pub extern "C" fn redstr_set_rand_source_seeded(seed: u64) {
+    ffi_guard("redstr_set_rand_source_seeded", (), || {
+        redstr::set_rand_source(redstr::RandSource::Seeded(seed));
+    })
+}
+
+/// Seed generators from a caller-supplied callback.
+///
+/// Lets the embedding application apply its own randomness policy, for
+/// example its CSPRNG, across the FFI boundary. Passing a null callback
+/// restores the default clock-based source.
+///
+/// # Safety
+///
+/// `callback` may be invoked from any thread that calls into redstr, possibly
+/// concurrently, until another source is set. It and `user_data` must stay
+/// valid and thread-safe for that whole time.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_set_rand_source_callback(
+    callback: Option<RedstrSeedCallback>,
+    user_data: *mut c_void,
+) {
+    ffi_guard("redstr_set_rand_source_callback", (), || {
+        let source = match callback {
+            Some(callback) => {
+                let seed = SeedCallback {
+                    callback,
+                    user_data,
+                };
+                redstr::RandSource::Custom(Arc::new(move || seed.seed()))
+            }
+            None => redstr::RandSource::Time,
+        };
+        redstr::set_rand_source(source);
+    })
+}
+
+// ============================================================================
+// Deprecated Names
+// ============================================================================
+
+/// Callback told about a deprecated transform name and its replacement.
+///
+/// Both strings are only valid for the duration of the call. Receives the
+/// `user_data` pointer it was registered with.
+pub type RedstrDeprecationCallback =
+    extern "C" fn(name: *const c_char, replacement: *const c_char, user_data: *mut c_void);
+
+/// A registered deprecation callback and its user data.
+struct DeprecationCallback {
+    callback: RedstrDeprecationCallback,
+    user_data: *mut c_void,
+}
+
+// The caller of `redstr_set_deprecation_callback` guarantees the callback and
+// its user data may be used from any thread.
+unsafe impl Send for DeprecationCallback {}
+unsafe impl Sync for DeprecationCallback {}
+
+impl DeprecationCallback {
+    fn report(&self, deprecation: &redstr::Deprecation) {
+        let name = CString::new(deprecation.name).unwrap_or_default();
+        let replacement = CString::new(deprecation.replacement).unwrap_or_default();
+        (self.callback)(name.as_ptr(), replacement.as_ptr(), self.user_data);
+    }
+}
+
+/// Report every resolution of a deprecated transform name to a callback.
+///
+/// Deprecated names keep working in specs, pipelines and batches; the
+/// callback lets the host log them or fail a build. Passing a null callback
+/// stops reporting.
+///
+/// # Safety
+///
+/// `callback` may be invoked from any thread that resolves a transform spec,
+/// possibly concurrently, until it is replaced. It and `user_data` must stay
+/// valid and thread-safe for that whole time.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_set_deprecation_callback(
+    callback: Option<RedstrDeprecationCallback>,
+    user_data: *mut c_void,
+) {
+    ffi_guard("redstr_set_deprecation_callback", (), || {
+        let hook = callback.map(|callback| {
+            let report = DeprecationCallback {
+                callback,
+                user_data,
+            };
+            Arc::new(move |deprecation: &redstr::Deprecation| report.report(deprecation))
+                as redstr::DeprecationHook
+        });
+        redstr::set_deprecation_hook(hook);
+    })
+}
+
+/// Return the replacement for a deprecated transform name, or null if the
+/// name is not deprecated.
+///
+/// The string must be freed with `redstr_free_string()`.
+///
+/// # Safety
+///
+/// `name` must be a valid null-terminated string or null.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_deprecated_name(name: *const c_char) -> *mut c_char {
+    ffi_guard(
+        "redstr_deprecated_name",
+        std::ptr::null_mut(),
+        || match c_str_to_str(name).and_then(redstr::deprecated_name) {
+            Some(deprecation) => string_to_c_char(deprecation.replacement.to_string()),
+            None => std::ptr::null_mut(),
+        },
+    )
+}
+
+// ============================================================================
+// Transform Events
+// ============================================================================
+
+/// One named transform call, as passed to a `RedstrEventCallback`.
+#[repr(C)]
+#[derive(Debug, Clone, Copy)]
+pub struct RedstrTransformEvent {
+    /// Registry name of the transform; valid only during the callback.
+    pub name: *const c_char,
+    /// Behavior version that ran.
+    pub version: u32,
+    /// Input length in bytes.
+    pub input_len: usize,
+    /// Output length in bytes.
+    pub output_len: usize,
+    /// Time spent in the transform, in nanoseconds.
+    pub duration_ns: u64,
+    /// Whether the call drew randomness; `seed` is 0 otherwise.
+    pub has_seed: bool,
+    /// Seed of the first generator the call created.
+    pub seed: u64,
+}
+
+/// Callback receiving an event after every named transform call.
+///
+/// The event is only valid for the duration of the call. Receives the
+/// `user_data` pointer it was registered with.
+pub type RedstrEventCallback =
+    extern "C" fn(event: *const RedstrTransformEvent, user_data: *mut c_void);
+
+/// A registered event callback and its user data.
+struct EventCallback {
+    callback: RedstrEventCallback,
+    user_data: *mut c_void,
+}
+
+// The caller of `redstr_set_event_callback` guarantees the callback and its
+// user data may be used from any thread.
+unsafe impl Send for EventCallback {}
+unsafe impl Sync for EventCallback {}
+
+impl EventCallback {
+    fn report(&self, event: &redstr::TransformEvent) {
+        let name = CString::new(event.name).unwrap_or_default();
+        let event = RedstrTransformEvent {
+            name: name.as_ptr(),
+            version: event.version,
+            input_len: event.input_len,
+            output_len: event.output_len,
+            duration_ns: event.duration.as_nanos().min(u64::MAX as u128) as u64,
+            has_seed: event.seed.is_some(),
+            seed: event.seed.unwrap_or(0),
+        };
+        (self.callback)(&event, self.user_data);
+    }
+}
+
+/// Report every named transform call (name, input and output length,
+/// duration, seed) to a callback, for structured logging in the host.
+///
+/// Calls through `redstr_apply_transform()`, pipelines, batches, transformers,
+/// buffers and fixed-function exports such as `redstr_leetspeak()` are
+/// reported once per step. The callback runs on the calling thread after the
+/// step returns. Passing a null callback stops reporting.
+///
+/// # Safety
+///
+/// `callback` may be invoked from any thread that calls into redstr, possibly
+/// concurrently, until it is replaced. It and `user_data` must stay valid and
+/// thread-safe for that whole time.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_set_event_callback(
+    callback: Option<RedstrEventCallback>,
+    user_data: *mut c_void,
+) {
+    ffi_guard("redstr_set_event_callback", (), || {
+        let hook = callback.map(|callback| {
+            let report = EventCallback {
+                callback,
+                user_data,
+            };
+            Arc::new(move |event: &redstr::TransformEvent| report.report(event))
+                as redstr::EventHook
+        });
+        redstr::set_event_hook(hook);
+    })
+}
+
+// ============================================================================
+// Tracing
+// ============================================================================
+
+/// Attributes of a span that is starting, as passed to a `RedstrSpanStartCallback`.
+#[repr(C)]
+#[derive(Debug, Clone, Copy)]
+pub struct RedstrSpanStart {
+    /// `"pipeline"`, `"batch"` or `"parallel_batch"`; valid only during the callback.
+    pub operation: *const c_char,
+    /// The transform chain with every step pinned, e.g. `"rot13@1 | hex_encode@1"`;
+    /// valid only during the callback.
+    pub recipe: *const c_char,
+    /// Number of inputs.
+    pub inputs: usize,
+}
+
+/// Attributes of a span that is ending, as passed to a `RedstrSpanEndCallback`.
+#[repr(C)]
+#[derive(Debug, Clone, Copy)]
+pub struct RedstrSpanEnd {
+    /// `"ok"`, `"rejected"`, `"cancelled"` or `"deadline_exceeded"`; valid
+    /// only during the callback.
+    pub status: *const c_char,
+    /// Number of outputs produced.
+    pub outputs: usize,
+    /// Total size of the outputs in bytes.
+    pub output_bytes: usize,
+    /// Time spent in the operation, in nanoseconds.
+    pub duration_ns: u64,
+}
+
+/// Callback opening a span; returns a token that is passed to the matching
+/// `RedstrSpanEndCallback` on the same thread.
+pub type RedstrSpanStartCallback =
+    extern "C" fn(span: *const RedstrSpanStart, user_data: *mut c_void) -> u64;
+
+/// Callback closing the span opened with `token`.
+pub type RedstrSpanEndCallback =
+    extern "C" fn(token: u64, span: *const RedstrSpanEnd, user_data: *mut c_void);
+
+/// Registered span callbacks and their user data.
+struct TracerCallbacks {
+    start: RedstrSpanStartCallback,
+    end: RedstrSpanEndCallback,
+    user_data: *mut c_void,
+}
+
+// The caller of `redstr_set_tracer_callbacks` guarantees the callbacks and
+// their user data may be used from any thread.
+unsafe impl Send for TracerCallbacks {}
+unsafe impl Sync for TracerCallbacks {}
+
+impl redstr::Tracer for TracerCallbacks {
+    fn start(&self, span: &redstr::SpanStart<'_>) -> u64 {
+        let operation = CString::new(span.operation.name()).unwrap_or_default();
+        let recipe = CString::new(span.recipe).unwrap_or_default();
+        let span = RedstrSpanStart {
+            operation: operation.as_ptr(),
+            recipe: recipe.as_ptr(),
+            inputs: span.inputs,
+        };
+        (self.start)(&span, self.user_data)
+    }
+
+    fn end(&self, token: u64, span: &redstr::SpanEnd) {
+        let status = CString::new(span.status.name()).unwrap_or_default();
+        let span = RedstrSpanEnd {
+            status: status.as_ptr(),
+            outputs: span.outputs,
+            output_bytes: span.output_bytes,
+            duration_ns: span.duration.as_nanos().min(u64::MAX as u128) as u64,
+        };
+        (self.end)(token, &span, self.user_data)
+    }
+}
+
+/// Open and close spans around pipeline and batch calls, so a host can
+/// forward them to OpenTelemetry or another tracer.
+///
+/// `redstr_apply_pipeline()`, `redstr_transformer_apply_into()` and the
+/// `redstr_transform_batch*()` functions each produce one span. Both
+/// callbacks run on the calling thread, with the per-step
+/// `RedstrEventCallback` events in between, so the host can make the span
+/// current while the steps run. Passing a null `start` or `end` stops
+/// tracing.
+///
+/// # Safety
+///
+/// The callbacks may be invoked from any thread that calls into redstr,
+/// possibly concurrently, until they are replaced. They and `user_data` must
+/// stay valid and thread-safe for that whole time.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_set_tracer_callbacks(
+    start: Option<RedstrSpanStartCallback>,
+    end: Option<RedstrSpanEndCallback>,
+    user_data: *mut c_void,
+) {
+    ffi_guard("redstr_set_tracer_callbacks", (), || {
+        let tracer = start.zip(end).map(|(start, end)| {
+            Arc::new(TracerCallbacks {
+                start,
+                end,
+                user_data,
+            }) as Arc<dyn redstr::Tracer>
+        });
+        redstr::set_tracer(tracer);
+    })
+}
+
+// ============================================================================
+// Metrics
+// ============================================================================
+
+/// The registry the FFI installs, created on first use and kept for the
+/// life of the process so counters never reset.
+fn ffi_metrics() -> &'static Arc<redstr::TransformMetrics> {
+    static METRICS: OnceLock<Arc<redstr::TransformMetrics>> = OnceLock::new();
+    METRICS.get_or_init(|| Arc::new(redstr::TransformMetrics::new()))
+}
+
+/// Start or stop counting named transform calls.
+///
+/// While enabled, every call reported to `redstr_set_event_callback()` also
+/// updates per-transform counters and a latency histogram, which
+/// `redstr_metrics_prometheus()` and `redstr_metrics_json()` render. Counters
+/// keep their values while disabled and continue when re-enabled.
+#[no_mangle]
+pub extern "C" fn redstr_set_metrics_enabled(enabled: bool) {
+    ffi_guard("redstr_set_metrics_enabled", (), || {
+        redstr::set_metrics_registry(enabled.then(|| Arc::clone(ffi_metrics())));
+    })
+}
+
+/// Render the transform counters in the Prometheus text exposition format,
+/// for a host's `/metrics` endpoint.
+///
+/// Includes `redstr_transform_calls_total` and the
+/// `redstr_transform_duration_seconds` histogram, labelled by transform name.
+/// The string must be freed with `redstr_free_string()`.
+#[no_mangle]
+pub extern "C" fn redstr_metrics_prometheus() -> *mut c_char {
+    ffi_guard("redstr_metrics_prometheus", std::ptr::null_mut(), || {
+        string_to_c_char(ffi_metrics().to_prometheus())
+    })
+}
+
+/// Render the transform counters as a JSON object keyed by transform name,
+/// e.g. `{"rot13":{"calls":2,"input_bytes":10,"output_bytes":10,
+/// "duration_seconds":0.000003}}`. The string must be freed with
+/// `redstr_free_string()`.
+#[no_mangle]
+pub extern "C" fn redstr_metrics_json() -> *mut c_char {
+    ffi_guard("redstr_metrics_json", std::ptr::null_mut(), || {
+        string_to_c_char(ffi_metrics().to_json())
+    })
+}
+
+// ============================================================================
+// Feature Detection
+// ============================================================================
+
+/// `redstr_apply_transform()` and `redstr_has_transform()`.
+pub const REDSTR_CAP_NAMED_TRANSFORMS: u64 = 1 << 0;
+/// `redstr_apply_pipeline()`.
+pub const REDSTR_CAP_PIPELINE: u64 = 1 << 1;
+/// `redstr_transform_batch()` and `redstr_transform_batch_timeout()`.
+pub const REDSTR_CAP_BATCH: u64 = 1 << 2;
+/// `redstr_transformer_new()` and its companions.
+pub const REDSTR_CAP_TRANSFORMER: u64 = 1 << 3;
+/// `redstr_transform_buffer()` and `redstr_buffer_release()`.
+pub const REDSTR_CAP_BUFFER: u64 = 1 << 4;
+/// `redstr_last_error()`.
+pub const REDSTR_CAP_LAST_ERROR: u64 = 1 << 5;
+/// `redstr_set_invalid_utf8_policy()`.
+pub const REDSTR_CAP_UTF8_POLICY: u64 = 1 << 6;
+/// The `redstr_set_rand_source_*()` functions.
+pub const REDSTR_CAP_RAND_SOURCE: u64 = 1 << 7;
+/// `redstr_catalog_json()`.
+pub const REDSTR_CAP_CATALOG: u64 = 1 << 8;
+/// `redstr_set_deprecation_callback()` and `redstr_deprecated_name()`.
+pub const REDSTR_CAP_DEPRECATION: u64 = 1 << 9;
+/// `redstr_set_event_callback()`.
+pub const REDSTR_CAP_EVENTS: u64 = 1 << 10;
+/// `redstr_set_tracer_callbacks()`.
+pub const REDSTR_CAP_TRACING: u64 = 1 << 11;
+/// `redstr_set_metrics_enabled()`, `redstr_metrics_prometheus()` and
+/// `redstr_metrics_json()`.
+pub const REDSTR_CAP_METRICS: u64 = 1 << 12;
+/// `redstr_transform_batch_ctx()` and the `redstr_batch_context_*()` functions.
+pub const REDSTR_CAP_BATCH_CONTEXT: u64 = 1 << 13;
+/// `redstr_batcher_new()` and its companions.
+pub const REDSTR_CAP_BATCHER: u64 = 1 << 14;
+
+/// Report whether a transform spec, e.g. `"leetspeak"` or `"leetspeak@1"`,
+/// is known to this library.
+///
+/// Bindings that refer to transforms by name can check before calling
+/// instead of treating a null result as an unknown transform.
+///
+/// # Safety
+///
+/// `spec` must be a valid null-terminated string or null.
+#[no_mangle]
+pub unsafe extern "C" fn redstr_has_transform(spec: *const c_char) -> bool {
+    ffi_guard("redstr_has_transform", false, || {
+        c_str_to_str(spec).is_some_and(|spec| redstr::Transform::resolve(spec).is_ok())
+    })
+}
+
+/// Return the `REDSTR_CAP_*` flags for the features this library exports.
+///
+/// Libraries from before this function do not export it, so bindings should
+/// look it up dynamically (`dlsym`, `GetProcAddress`) and treat a missing
+/// symbol as "no optional features" rather than link against it directly.
+#[no_mangle]
+pub extern "C" fn redstr_capabilities() -> u64 {
+    REDSTR_CAP_NAMED_TRANSFORMS
+        | REDSTR_CAP_PIPELINE
+        | REDSTR_CAP_BATCH
+        | REDSTR_CAP_TRANSFORMER
+        | REDSTR_CAP_BUFFER
+        | REDSTR_CAP_LAST_ERROR
+        | REDSTR_CAP_UTF8_POLICY
+        | REDSTR_CAP_RAND_SOURCE
+        | REDSTR_CAP_CATALOG
+        | REDSTR_CAP_DEPRECATION
+        | REDSTR_CAP_EVENTS
+        | REDSTR_CAP_TRACING
+        | REDSTR_CAP_METRICS
+        | REDSTR_CAP_BATCH_CONTEXT
+        | REDSTR_CAP_BATCHER
+}
+
+/// Describe every registered transform as a JSON array.
+///
+/// Each element has the form `{"name":"rot13","version":1,
+/// "category":"obfuscation","stability":"stable","deterministic":true,
+/// "reversible":true,"input":"text"}`, in registry order, so bindings can
+/// build documentation or UIs from the library they actually loaded. The
+/// string must be freed with `redstr_free_string()`.
+#[no_mangle]
+pub extern "C" fn redstr_catalog_json() -> *mut c_char {
+    ffi_guard("redstr_catalog_json", std::ptr::null_mut(), || {
+        let entries: Vec<String> = redstr::catalog()
+            .iter()
+            .map(|info| info.to_json())
+            .collect();
+        string_to_c_char(format!("[{}]", entries.join(",")))
+    })
+}
+
+// ============================================================================
+// Tests
+// ============================================================================
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::ffi::CString;
+
+    #[test]
+    fn test_leetspeak_ffi() {
+        unsafe {
+            let input = CString::new("password").unwrap();
+            let result = redstr_leetspeak(input.as_ptr());
+            assert!(!result.is_null());
+            let result_str = CStr::from_ptr(result).to_str().unwrap();
+            assert!(!result_str.is_empty());
+            redstr_free_string(result);
+        }
+    }
+
+    #[test]
+    fn test_base64_encode_ffi() {
+        unsafe {
+            let input = CString::new("hello").unwrap();
+            let result = redstr_base64_encode(input.as_ptr());
+            assert!(!result.is_null());
+            let result_str = CStr::from_ptr(result).to_str().unwrap();
+            assert_eq!(result_str, "aGVsbG8=");
+            redstr_free_string(result);
+        }
+    }
+
+    #[test]
+    fn test_null_input() {
+        unsafe {
+            let result = redstr_leetspeak(std::ptr::null());
+            assert!(result.is_null());
+        }
+    }
+
+    #[test]
+    fn test_free_null() {
+        unsafe {
+            // Should not crash
+            redstr_free_string(std::ptr::null_mut());
+        }
+    }
+
+    #[test]
+    fn test_apply_transform_ffi() {
+        unsafe {
+            let spec = CString::new("base64_encode@1").unwrap();
+            let input = CString::new("hello").unwrap();
+            let result = redstr_apply_transform(spec.as_ptr(), input.as_ptr());
+            assert_eq!(CStr::from_ptr(result).to_str().unwrap(), "aGVsbG8=");
+            redstr_free_string(result);
+
+            let unknown = CString::new("no_such_transform").unwrap();
+            assert!(redstr_apply_transform(unknown.as_ptr(), input.as_ptr()).is_null());
+        }
+    }
+
+    #[test]
+    fn test_apply_pipeline_ffi() {
+        unsafe {
+            let pipeline = CString::new("rot13 | base64_encode@1").unwrap();
+            let input = CString::new("hello").unwrap();
+            let result = redstr_apply_pipeline(pipeline.as_ptr(), input.as_ptr());
+            assert_eq!(CStr::from_ptr(result).to_str().unwrap(), "dXJ5eWI=");
+            redstr_free_string(result);
+
+            let empty = CString::new("").unwrap();
+            let result = redstr_apply_pipeline(empty.as_ptr(), input.as_ptr());
+            assert_eq!(CStr::from_ptr(result).to_str().unwrap(), "hello");
+            redstr_free_string(result);
+
+            let unknown = CString::new("rot13 | nope").unwrap();
+            assert!(redstr_apply_pipeline(unknown.as_ptr(), input.as_ptr()).is_null());
+            assert!(redstr_apply_pipeline(std::ptr::null(), input.as_ptr()).is_null());
+        }
+    }
+
+    #[test]
+    fn test_transform_batch_ffi() {
+        unsafe {
+            let spec = CString::new("hex_encode").unwrap();
+            let a = CString::new("a").unwrap();
+            let b = CString::new("b").unwrap();
+            let inputs = [a.as_ptr(), std::ptr::null(), b.as_ptr()];
+            let mut outputs = [std::ptr::null_mut(); 3];
+
+            let written =
+                redstr_transform_batch(spec.as_ptr(), inputs.as_ptr(), 3, outputs.as_mut_ptr());
+            assert_eq!(written, 2);
+            assert_eq!(CStr::from_ptr(outputs[0]).to_str().unwrap(), "61");
+            assert!(outputs[1].is_null());
+            assert_eq!(CStr::from_ptr(outputs[2]).to_str().unwrap(), "62");
+
+            redstr_free_string_array(outputs.as_mut_ptr(), 3);
+            assert!(outputs.iter().all(|p| p.is_null()));
+        }
+    }
+
+    #[test]
+    fn test_transform_batch_timeout_ffi() {
+        unsafe {
+            let spec = CString::new("rot13").unwrap();
+            let a = CString::new("abc").unwrap();
+            let inputs = [a.as_ptr(), a.as_ptr()];
+            let mut outputs = [std::ptr::null_mut(); 2];
+
+            let written = redstr_transform_batch_timeout(
+                spec.as_ptr(),
+                inputs.as_ptr(),
+                2,
+                outputs.as_mut_ptr(),
+                0,
+            );
+            assert_eq!(written, 2);
+            assert_eq!(CStr::from_ptr(outputs[1]).to_str().unwrap(), "nop");
+            redstr_free_string_array(outputs.as_mut_ptr(), 2);
+
+            let written = redstr_transform_batch_timeout(
+                spec.as_ptr(),
+                inputs.as_ptr(),
+                2,
+                outputs.as_mut_ptr(),
+                60_000,
+            );
+            assert_eq!(written, 2);
+            redstr_free_string_array(outputs.as_mut_ptr(), 2);
+        }
+    }
+
+    #[test]
+    fn test_transform_batch_ctx_ffi() {
+        unsafe {
+            let spec = CString::new("rot13").unwrap();
+            let a = CString::new("abc").unwrap();
+            let inputs = [a.as_ptr(), std::ptr::null(), a.as_ptr()];
+            let mut outputs = [std::ptr::null_mut(); 3];
+            let mut reached = usize::MAX;
+
+            let ctx = redstr_batch_context_new(60_000);
+            assert!(!ctx.is_null());
+            let status = redstr_transform_batch_ctx(
+                spec.as_ptr(),
+                inputs.as_ptr(),
+                3,
+                outputs.as_mut_ptr(),
+                ctx,
+                &mut reached,
+            );
+            assert_eq!(status, RedstrBatchStatus::Ok);
+            // The null input was reached and rejected, not skipped.
+            assert_eq!(reached, 3);
+            assert!(outputs[1].is_null());
+            assert_eq!(CStr::from_ptr(outputs[2]).to_str().unwrap(), "nop");
+            redstr_free_string_array(outputs.as_mut_ptr(), 3);
+
+            redstr_batch_context_cancel(ctx);
+            let status = redstr_transform_batch_ctx(
+                spec.as_ptr(),
+                inputs.as_ptr(),
+                3,
+                outputs.as_mut_ptr(),
+                ctx,
+                &mut reached,
+            );
+            assert_eq!(status, RedstrBatchStatus::Cancelled);
+            assert_eq!(reached, 0);
+            assert!(outputs.iter().all(|output| output.is_null()));
+            redstr_batch_context_free(ctx);
+
+            let expired = redstr_batch_context_new(1);
+            std::thread::sleep(Duration::from_millis(5));
+            let status = redstr_transform_batch_ctx(
+                spec.as_ptr(),
+                inputs.as_ptr(),
+                3,
+                outputs.as_mut_ptr(),
+                expired,
+                std::ptr::null_mut(),
+            );
+            assert_eq!(status, RedstrBatchStatus::DeadlineExceeded);
+            redstr_batch_context_free(expired);
+
+            let unknown = CString::new("nope").unwrap();
+            let status = redstr_transform_batch_ctx(
+                unknown.as_ptr(),
+                inputs.as_ptr(),
+                3,
+                outputs.as_mut_ptr(),
+                std::ptr::null(),
+                &mut reached,
+            );
+            assert_eq!(status, RedstrBatchStatus::InvalidArgument);
+            assert_eq!(reached, 0);
+
+            redstr_batch_context_cancel(std::ptr::null());
+            redstr_batch_context_free(std::ptr::null_mut());
+        }
+    }
+
+    #[test]
+    fn test_transformer_apply_into() {
+        unsafe {
+            let recipe = CString::new("rot13 | hex_encode").unwrap();
+            let transformer = redstr_transformer_new(recipe.as_ptr());
+            assert!(!transformer.is_null());
+
+            let input = CString::new("abc").unwrap();
+            let mut buf = [0 as c_char; 16];
+            let len =
+                redstr_transformer_apply_into(transformer, input.as_ptr(), buf.as_mut_ptr(), 16);
+            assert_eq!(len, 6);
+            assert_eq!(CStr::from_ptr(buf.as_ptr()).to_str().unwrap(), "6e6f70");
+
+            // Exactly the output length leaves no room for the null terminator
+            let len =
+                redstr_transformer_apply_into(transformer, input.as_ptr(), buf.as_mut_ptr(), 6);
+            assert_eq!(len, 6);
+
+            assert_eq!(
+                redstr_transformer_apply_into(transformer, std::ptr::null(), buf.as_mut_ptr(), 16),
+                -1
+            );
+            redstr_transformer_free(transformer);
+
+            let unknown = CString::new("rot13 | nope").unwrap();
+            assert!(redstr_transformer_new(unknown.as_ptr()).is_null());
+            redstr_transformer_free(std::ptr::null_mut());
+        }
+    }
+
+    #[test]
+    fn test_transformer_apply_into_retry_keeps_random_output() {
+        unsafe {
+            let recipe = CString::new("randomize_capitalization").unwrap();
+            let transformer = redstr_transformer_new(recipe.as_ptr());
+            let input = CString::new("a much longer input string to randomize").unwrap();
+
+            let needed =
+                redstr_transformer_apply_into(transformer, input.as_ptr(), std::ptr::null_mut(), 0);
+            assert_eq!(needed, 39);
+            let pending = (*transformer).output.clone();
+
+            // The retry returns the output computed by the sizing call
+            let mut buf = vec![0 as c_char; 40];
+            redstr_transformer_apply_into(transformer, input.as_ptr(), buf.as_mut_ptr(), 40);
+            assert_eq!(CStr::from_ptr(buf.as_ptr()).to_str().unwrap(), pending);
+            assert!((*transformer).pending_input.is_none());
+            redstr_transformer_free(transformer);
+        }
+    }
+
+    /// Records every flushed batch as owned strings.
+    extern "C" fn collect_batch(
+        outputs: *const *const c_char,
+        lens: *const usize,
+        count: usize,
+        user_data: *mut c_void,
+    ) {
+        let batches = unsafe { &mut *(user_data as *mut Vec<Vec<Option<String>>>) };
+        let (outputs, lens) = unsafe {
+            (
+                std::slice::from_raw_parts(outputs, count),
+                std::slice::from_raw_parts(lens, count),
+            )
+        };
+        let batch = outputs
+            .iter()
+            .zip(lens)
+            .map(|(&output, &len)| {
+                (!output.is_null()).then(|| {
+                    let text = unsafe { CStr::from_ptr(output) }.to_str().unwrap();
+                    assert_eq!(text.len(), len);
+                    text.to_string()
+                })
+            })
+            .collect();
+        batches.push(batch);
+    }
+
+    #[test]
+    fn test_batcher_flushes_on_item_threshold() {
+        unsafe {
+            let mut batches: Vec<Vec<Option<String>>> = Vec::new();
+            let recipe = CString::new("rot13 | hex_encode").unwrap();
+            let batcher = redstr_batcher_new(
+                recipe.as_ptr(),
+                2,
+                0,
+                Some(collect_batch),
+                &mut batches as *mut _ as *mut c_void,
+            );
+            assert!(!batcher.is_null());
+
+            let inputs = [c"abc", c"xyz", c"q"];
+            assert_eq!(redstr_batcher_push(batcher, inputs[0].as_ptr()), 0);
+            assert_eq!(redstr_batcher_push(batcher, inputs[1].as_ptr()), 2);
+            assert_eq!(redstr_batcher_push(batcher, std::ptr::null()), 0);
+            assert_eq!(redstr_batcher_push(batcher, inputs[2].as_ptr()), 2);
+            assert_eq!(redstr_batcher_flush(batcher), 0);
+            assert_eq!(
+                batches,
+                vec![
+                    vec![Some("6e6f70".to_string()), Some("6b6c6d".to_string())],
+                    vec![None, Some("64".to_string())],
+                ]
+            );
+            redstr_batcher_free(batcher);
+        }
+    }
+
+    #[test]
+    fn test_batcher_flushes_on_byte_threshold() {
+        unsafe {
+            let mut batches: Vec<Vec<Option<String>>> = Vec::new();
+            let recipe = CString::new("base64_encode").unwrap();
+            let batcher = redstr_batcher_new(
+                recipe.as_ptr(),
+                0,
+                16,
+                Some(collect_batch),
+                &mut batches as *mut _ as *mut c_void,
+            );
+
+            // Each output is 8 bytes plus its terminator
+            assert_eq!(redstr_batcher_push(batcher, c"hello".as_ptr()), 0);
+            assert_eq!(redstr_batcher_push(batcher, c"world".as_ptr()), 2);
+            // The buffer is reused after a flush
+            let arena = (*batcher).arena.as_ptr();
+            assert_eq!(redstr_batcher_push(batcher, c"again".as_ptr()), 0);
+            assert_eq!((*batcher).arena.as_ptr(), arena);
+            assert_eq!(redstr_batcher_flush(batcher), 1);
+            assert_eq!(batches.len(), 2);
+            assert_eq!(batches[1], vec![Some("YWdhaW4=".to_string())]);
+
+            // Pending outputs are dropped by free
+            assert_eq!(redstr_batcher_push(batcher, c"lost".as_ptr()), 0);
+            redstr_batcher_free(batcher);
+            assert_eq!(batches.len(), 2);
+        }
+    }
+
+    #[test]
+    fn test_batcher_invalid_arguments() {
+        unsafe {
+            let recipe = CString::new("rot13").unwrap();
+            assert!(
+                redstr_batcher_new(recipe.as_ptr(), 1, 0, None, std::ptr::null_mut()).is_null()
+            );
+            let unknown = CString::new("rot13 | nope").unwrap();
+            assert!(redstr_batcher_new(
+                unknown.as_ptr(),
+                1,
+                0,
+                Some(collect_batch),
+                std::ptr::null_mut()
+            )
+            .is_null());
+            assert_eq!(
+                redstr_batcher_push(std::ptr::null_mut(), recipe.as_ptr()),
+                -1
+            );
+            assert_eq!(redstr_batcher_flush(std::ptr::null_mut()), 0);
+            redstr_batcher_free(std::ptr::null_mut());
+        }
+    }
+
+    #[test]
+    fn test_transform_buffer() {
+        unsafe {
+            let spec = CString::new("base64_encode").unwrap();
+            let payload = "A".repeat(3 * 1024 * 1024);
+            let mut buffer =
+                redstr_transform_buffer(spec.as_ptr(), payload.as_ptr(), payload.len());
+            assert_eq!(buffer.len, 4 * 1024 * 1024);
+            let bytes = std::slice::from_raw_parts(buffer.data, buffer.len);
+            assert!(bytes.starts_with(b"QUFB"));
+
+            redstr_buffer_release(&mut buffer);
+            assert!(buffer.data.is_null());
+            assert_eq!(buffer.len, 0);
+            redstr_buffer_release(&mut buffer);
+            redstr_buffer_release(std::ptr::null_mut());
+        }
+    }
+
+    #[test]
+    fn test_transform_buffer_edge_cases() {
+        unsafe {
+            let spec = CString::new("rot13").unwrap();
+            let mut empty = redstr_transform_buffer(spec.as_ptr(), std::ptr::null(), 0);
+            assert!(!empty.data.is_null());
+            assert_eq!(empty.len, 0);
+            redstr_buffer_release(&mut empty);
+
+            let invalid = [0xffu8, 0xfe];
+            let failed = redstr_transform_buffer(spec.as_ptr(), invalid.as_ptr(), 2);
+            assert!(failed.data.is_null());
+
+            let unknown = CString::new("nope").unwrap();
+            let failed = redstr_transform_buffer(unknown.as_ptr(), b"x".as_ptr(), 1);
+            assert!(failed.data.is_null());
+        }
+    }
+
+    #[test]
+    fn test_transform_batch_unknown_spec() {
+        unsafe {
+            let spec = CString::new("nope").unwrap();
+            let a = CString::new("a").unwrap();
+            let inputs = [a.as_ptr()];
+            let mut outputs = [std::ptr::null_mut(); 1];
+            let written =
+                redstr_transform_batch(spec.as_ptr(), inputs.as_ptr(), 1, outputs.as_mut_ptr());
+            assert_eq!(written, 0);
+            assert!(outputs[0].is_null());
+        }
+    }
+
+    #[test]
+    fn test_feature_detection() {
+        unsafe {
+            assert!(redstr_has_transform(c"leetspeak".as_ptr()));
+            assert!(redstr_has_transform(c"leetspeak@1".as_ptr()));
+            assert!(!redstr_has_transform(c"leetspeak@99".as_ptr()));
+            assert!(!redstr_has_transform(c"no_such_transform".as_ptr()));
+            assert!(!redstr_has_transform(std::ptr::null()));
+        }
+        let caps = redstr_capabilities();
+        assert_ne!(caps & REDSTR_CAP_PIPELINE, 0);
+        assert_ne!(caps & REDSTR_CAP_UTF8_POLICY, 0);
+        assert_ne!(caps & REDSTR_CAP_BATCH_CONTEXT, 0);
+        assert_ne!(caps & REDSTR_CAP_BATCHER, 0);
+        assert_eq!(caps >> 15, 0);
+    }
+
+    #[test]
+    fn test_deprecated_name_ffi() {
+        unsafe {
+            let result = redstr_deprecated_name(c"cloudflare_challenge".as_ptr());
+            assert_eq!(
+                CStr::from_ptr(result).to_str().unwrap(),
+                "cloudflare_challenge_variation"
+            );
+            redstr_free_string(result);
+            assert!(redstr_deprecated_name(c"cloudflare_challenge_variation".as_ptr()).is_null());
+            assert!(redstr_deprecated_name(std::ptr::null()).is_null());
+            assert!(redstr_has_transform(c"cloudflare_challenge".as_ptr()));
+        }
+    }
+
+    #[test]
+    fn test_catalog_json_ffi() {
+        let result = redstr_catalog_json();
+        let json = unsafe { CStr::from_ptr(result) }
+            .to_str()
+            .unwrap()
+            .to_string();
+        unsafe { redstr_free_string(result) };
+        assert!(json.starts_with(r#"[{"name":"alternate_case","version":1,"category":"case""#));
+        assert!(json.contains(r#""name":"hex_encode","version":1,"category":"encoding","stability":"stable","deterministic":true,"reversible":true,"input":"text_or_bytes"}"#));
+        assert_eq!(
+            json.matches("\"name\":").count(),
+            redstr::transform_names().len()
+        );
+    }
+
+    #[test]
+    fn test_random_user_agent_ffi() {
+        let result = redstr_random_user_agent();
+        assert!(!result.is_null());
+        unsafe {
+            let result_str = CStr::from_ptr(result).to_str().unwrap();
+            assert!(result_str.contains("Mozilla") || result_str.contains("Chrome"));
+            redstr_free_string(result);
+        }
+    }
+
+    #[test]
+    fn test_set_rand_source_callback_ffi() {
+        use std::sync::atomic::{AtomicU64, Ordering};
+
+        static CALLS: AtomicU64 = AtomicU64::new(0);
+        extern "C" fn seed(user_data: *mut c_void) -> u64 {
+            let calls = unsafe { &*(user_data as *const AtomicU64) };
+            calls
+                .fetch_add(1, Ordering::SeqCst)
+                .wrapping_mul(0x9E37_79B9)
+        }
+
+        unsafe {
+            let user_data = &CALLS as *const AtomicU64 as *mut c_void;
+            redstr_set_rand_source_callback(Some(seed), user_data);
+            let before = CALLS.load(Ordering::SeqCst);
+            let result = redstr_random_user_agent();
+            assert!(CALLS.load(Ordering::SeqCst) > before);
+            redstr_free_string(result);
+
+            redstr_set_rand_source_callback(None, std::ptr::null_mut());
+            let after = CALLS.load(Ordering::SeqCst);
+            redstr_free_string(redstr_random_user_agent());
+            assert_eq!(CALLS.load(Ordering::SeqCst), after);
+        }
+    }
+
+    /// Inputs that stress the C boundary: embedded NULs, invalid and truncated
+    /// UTF-8, surrogates, overlong encodings, multi-byte text and random bytes.
+    fn boundary_inputs() -> Vec<Vec<u8>> {
+        let mut inputs: Vec<Vec<u8>> = vec![
+            b"".to_vec(),
+            b"\0".to_vec(),
+            b"a\0b".to_vec(),
+            b"\0trailing".to_vec(),
+            vec![0xff],
+            vec![0xc3],
+            vec![0xe2, 0x82],
+            vec![0xed, 0xa0, 0x80],
+            vec![0xc0, 0x80],
+            vec![0xf4, 0x90, 0x80, 0x80],
+            "\u{fc}n\u{ef} \u{20ac} \u{1f600} \u{202e}"
+                .as_bytes()
+                .to_vec(),
+            "<script>alert(1)</script>' OR 1=1--".as_bytes().to_vec(),
+            b"%%%\\\"'`$(){}[]|&;\r\n\t".to_vec(),
+            "A".repeat(4096).into_bytes(),
+        ];
+        // xorshift64 so failures reproduce from the test alone
+        let mut state = 0x2545_f491_4f6c_dd1du64;
+        for _ in 0..48 {
+            state ^= state << 13;
+            state ^= state >> 7;
+            state ^= state << 17;
+            let len = (state % 64) as usize;
+            inputs.push(
+                (0..len)
+                    .map(|i| (state.rotate_left(i as u32 * 7) >> 3) as u8)
+                    .collect(),
+            );
+        }
+        inputs
+    }
+
+    #[test]
+    fn test_fuzz_transform_boundary() {
+        let inputs = boundary_inputs();
+        for name in redstr::transform_names() {
+            let spec = CString::new(name).unwrap();
+            for bytes in &inputs {
+                unsafe {
+                    // Length-delimited input: embedded NULs reach Rust intact
+                    let mut buffer =
+                        redstr_transform_buffer(spec.as_ptr(), bytes.as_ptr(), bytes.len());
+                    if std::str::from_utf8(bytes).is_ok() {
+                        assert!(!buffer.data.is_null(), "{} rejected valid UTF-8", name);
+                        let out = std::slice::from_raw_parts(buffer.data, buffer.len);
+                        assert!(
+                            std::str::from_utf8(out).is_ok(),
+                            "{} produced invalid UTF-8",
+                            name
+                        );
+                    } else {
+                        assert!(buffer.data.is_null(), "{} accepted invalid UTF-8", name);
+                    }
+                    redstr_buffer_release(&mut buffer);
+
+                    // C string input: everything after the first NUL is invisible
+                    let prefix = bytes.split(|&b| b == 0).next().unwrap();
+                    let c_input = CString::new(prefix).unwrap();
+                    let result = redstr_apply_transform(spec.as_ptr(), c_input.as_ptr());
+                    assert_eq!(
+                        result.is_null(),
+                        std::str::from_utf8(prefix).is_err(),
+                        "{}",
+                        name
+                    );
+                    redstr_free_string(result);
+                }
+            }
+        }
+    }
+
+    #[test]
+    fn test_fuzz_batch_and_transformer_boundary() {
+        let c_inputs: Vec<CString> = boundary_inputs()
+            .iter()
+            .map(|bytes| CString::new(bytes.split(|&b| b == 0).next().unwrap()).unwrap())
+            .collect();
+        let ptrs: Vec<*const c_char> = c_inputs.iter().map(|input| input.as_ptr()).collect();
+        let valid = c_inputs
+            .iter()
+            .filter(|input| input.to_str().is_ok())
+            .count();
+
+        for name in redstr::transform_names() {
+            let spec = CString::new(name).unwrap();
+            unsafe {
+                let mut outputs = vec![std::ptr::null_mut(); ptrs.len()];
+                let written = redstr_transform_batch(
+                    spec.as_ptr(),
+                    ptrs.as_ptr(),
+                    ptrs.len(),
+                    outputs.as_mut_ptr(),
+                );
+                assert_eq!(written, valid, "{}", name);
+                redstr_free_string_array(outputs.as_mut_ptr(), outputs.len());
+
+                let transformer = redstr_transformer_new(spec.as_ptr());
+                let mut small = [0 as c_char; 8];
+                for (input, ptr) in c_inputs.iter().zip(&ptrs) {
+                    let len =
+                        redstr_transformer_apply_into(transformer, *ptr, small.as_mut_ptr(), 8);
+                    if input.to_str().is_err() {
+                        assert_eq!(len, -1, "{}", name);
+                    } else if len < 8 {
+                        assert_eq!(
+                            CStr::from_ptr(small.as_ptr()).to_bytes().len(),
+                            len as usize
+                        );
+                    }
+                }
+                redstr_transformer_free(transformer);
+            }
+        }
+    }
+
+    #[test]
+    fn test_chaos_panics_do_not_escape() {
+        let spec = CString::new("rot13").unwrap();
+        let input = CString::new("abc").unwrap();
+        let inputs = [input.as_ptr()];
+        unsafe {
+            assert!(redstr_last_error().is_null());
+
+            // Fail a sample of entry points with different failure values
+            INJECT_PANIC.with(|inject| inject.set(true));
+            assert!(redstr_leetspeak(input.as_ptr()).is_null());
+            let error = redstr_last_error();
+            assert_eq!(
+                CStr::from_ptr(error).to_str().unwrap(),
+                "redstr_leetspeak panicked: injected failure"
+            );
+            redstr_free_string(error);
+            assert!(redstr_last_error().is_null());
+
+            INJECT_PANIC.with(|inject| inject.set(true));
+            let mut outputs = [std::ptr::null_mut(); 1];
+            assert_eq!(
+                redstr_transform_batch(spec.as_ptr(), inputs.as_ptr(), 1, outputs.as_mut_ptr()),
+                0
+            );
+            redstr_free_string(redstr_last_error());
+
+            let transformer = redstr_transformer_new(spec.as_ptr());
+            INJECT_PANIC.with(|inject| inject.set(true));
+            let mut buf = [0 as c_char; 8];
+            assert_eq!(
+                redstr_transformer_apply_into(transformer, input.as_ptr(), buf.as_mut_ptr(), 8),
+                -1
+            );
+            redstr_free_string(redstr_last_error());
+
+            INJECT_PANIC.with(|inject| inject.set(true));
+            let buffer = redstr_transform_buffer(spec.as_ptr(), b"abc".as_ptr(), 3);
+            assert!(buffer.data.is_null());
+            redstr_free_string(redstr_last_error());
+
+            // The library keeps working after caught failures
+            let mut buf = [0 as c_char; 8];
+            assert_eq!(
+                redstr_transformer_apply_into(transformer, input.as_ptr(), buf.as_mut_ptr(), 8),
+                3
+            );
+            redstr_transformer_free(transformer);
+            let result = redstr_apply_transform(spec.as_ptr(), input.as_ptr());
+            assert_eq!(CStr::from_ptr(result).to_str().unwrap(), "nop");
+            redstr_free_string(result);
+        }
+    }
+
+    #[test]
+    fn test_chaos_every_transform_survives_injected_panics() {
+        let input = CString::new("payload").unwrap();
+        for name in redstr::transform_names() {
+            let spec = CString::new(name).unwrap();
+            unsafe {
+                INJECT_PANIC.with(|inject| inject.set(true));
+                assert!(redstr_apply_transform(spec.as_ptr(), input.as_ptr()).is_null());
+                let error = redstr_last_error();
+                assert!(!error.is_null(), "{}", name);
+                redstr_free_string(error);
+
+                let result = redstr_apply_transform(spec.as_ptr(), input.as_ptr());
+                assert!(!result.is_null(), "{} failed after a caught panic", name);
+                redstr_free_string(result);
+            }
         }
     }
 }
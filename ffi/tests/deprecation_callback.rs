@@ -0,0 +1,60 @@
+//! Deprecated-name reporting across the C boundary.
+
+use std::ffi::{CStr, CString};
+use std::os::raw::{c_char, c_void};
+use std::sync::Mutex;
+
+use redstr_ffi::*;
+
+extern "C" fn record(name: *const c_char, replacement: *const c_char, user_data: *mut c_void) {
+    let seen = unsafe { &*(user_data as *const Mutex<Vec<String>>) };
+    let (name, replacement) = unsafe {
+        (
+            CStr::from_ptr(name).to_str().unwrap(),
+            CStr::from_ptr(replacement).to_str().unwrap(),
+        )
+    };
+    seen.lock()
+        .unwrap()
+        .push(format!("{}->{}", name, replacement));
+}
+
+unsafe fn apply(spec: &str, input: &str) -> Option<String> {
+    let spec = CString::new(spec).unwrap();
+    let input = CString::new(input).unwrap();
+    let result = redstr_apply_transform(spec.as_ptr(), input.as_ptr());
+    if result.is_null() {
+        return None;
+    }
+    let text = CStr::from_ptr(result).to_str().unwrap().to_string();
+    redstr_free_string(result);
+    Some(text)
+}
+
+#[test]
+fn test_deprecation_callback() {
+    let seen: &'static Mutex<Vec<String>> = Box::leak(Box::new(Mutex::new(Vec::new())));
+    unsafe {
+        redstr_set_deprecation_callback(Some(record), seen as *const _ as *mut c_void);
+
+        assert_eq!(apply("base64_encode", "hi"), Some("aGk=".to_string()));
+        assert!(seen.lock().unwrap().is_empty());
+
+        assert!(apply("cloudflare_challenge", "cf").is_some());
+        let pipeline = CString::new("cloudflare_turnstile | rot13").unwrap();
+        let result = redstr_apply_pipeline(pipeline.as_ptr(), c"".as_ptr());
+        assert!(!result.is_null());
+        redstr_free_string(result);
+        assert_eq!(
+            *seen.lock().unwrap(),
+            [
+                "cloudflare_challenge->cloudflare_challenge_variation",
+                "cloudflare_turnstile->cloudflare_turnstile_variation"
+            ]
+        );
+
+        redstr_set_deprecation_callback(None, std::ptr::null_mut());
+        assert!(apply("cloudflare_challenge", "cf").is_some());
+        assert_eq!(seen.lock().unwrap().len(), 2);
+    }
+}
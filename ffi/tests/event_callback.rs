@@ -0,0 +1,51 @@
+//! Per-call transform events across the C boundary.
+
+use std::ffi::{CStr, CString};
+use std::os::raw::c_void;
+use std::sync::Mutex;
+
+use redstr_ffi::*;
+
+/// Name, input length, output length and whether a seed was drawn.
+type Seen = Mutex<Vec<(String, usize, usize, bool)>>;
+
+extern "C" fn record(event: *const RedstrTransformEvent, user_data: *mut c_void) {
+    let seen = unsafe { &*(user_data as *const Seen) };
+    let event = unsafe { &*event };
+    let name = unsafe { CStr::from_ptr(event.name) }.to_str().unwrap();
+    seen.lock().unwrap().push((
+        name.to_string(),
+        event.input_len,
+        event.output_len,
+        event.has_seed,
+    ));
+}
+
+#[test]
+fn test_event_callback() {
+    let seen: &'static Seen = Box::leak(Box::new(Mutex::new(Vec::new())));
+    unsafe {
+        redstr_set_event_callback(Some(record), seen as *const _ as *mut c_void);
+
+        let pipeline = CString::new("leetspeak | hex_encode").unwrap();
+        let result = redstr_apply_pipeline(pipeline.as_ptr(), c"ab".as_ptr());
+        redstr_free_string(result);
+        redstr_free_string(redstr_rot13(c"ab".as_ptr()));
+        {
+            let seen = seen.lock().unwrap();
+            assert_eq!(seen.len(), 3);
+            assert_eq!(seen[2], ("rot13".to_string(), 2, 2, false));
+            assert_eq!(seen[0].0, "leetspeak");
+            assert!(seen[0].3);
+            assert_eq!(
+                seen[1],
+                ("hex_encode".to_string(), seen[0].2, 2 * seen[0].2, false)
+            );
+        }
+
+        redstr_set_event_callback(None, std::ptr::null_mut());
+        let spec = CString::new("rot13").unwrap();
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), c"ab".as_ptr()));
+        assert_eq!(seen.lock().unwrap().len(), 3);
+    }
+}
@@ -0,0 +1,39 @@
+//! Transform metrics across the C boundary.
+
+use std::ffi::{CStr, CString};
+
+use redstr_ffi::*;
+
+fn take(ptr: *mut std::os::raw::c_char) -> String {
+    let text = unsafe { CStr::from_ptr(ptr) }.to_str().unwrap().to_string();
+    unsafe { redstr_free_string(ptr) };
+    text
+}
+
+#[test]
+fn test_metrics() {
+    assert_eq!(take(redstr_metrics_json()), "{}");
+    unsafe {
+        // Nothing is counted until metrics are enabled
+        let spec = CString::new("rot13").unwrap();
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), c"ab".as_ptr()));
+        assert_eq!(take(redstr_metrics_json()), "{}");
+
+        redstr_set_metrics_enabled(true);
+        let pipeline = CString::new("rot13 | hex_encode").unwrap();
+        redstr_free_string(redstr_apply_pipeline(pipeline.as_ptr(), c"ab".as_ptr()));
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), c"abc".as_ptr()));
+        let text = take(redstr_metrics_prometheus());
+        assert!(text.contains("redstr_transform_calls_total{name=\"rot13\"} 2\n"));
+        assert!(text.contains("redstr_transform_input_bytes_total{name=\"hex_encode\"} 2\n"));
+        assert!(text.contains("redstr_transform_duration_seconds_count{name=\"hex_encode\"} 1\n"));
+
+        // Counters are kept while disabled
+        redstr_set_metrics_enabled(false);
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), c"ab".as_ptr()));
+        redstr_set_metrics_enabled(true);
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), c"ab".as_ptr()));
+        assert!(take(redstr_metrics_json()).contains(r#""rot13":{"calls":3,"input_bytes":7,"#));
+        redstr_set_metrics_enabled(false);
+    }
+}
@@ -0,0 +1,178 @@
+//! Long-running soak test for leaks across the C boundary.
+//!
+//! Mirrors `crates/redstr/tests/soak.rs` through the exported functions:
+//! every string a `redstr_*` call returns is released with
+//! `redstr_free_string`, `redstr_free_string_array` or
+//! `redstr_buffer_release`, while live heap bytes (via a counting global
+//! allocator) and, on Linux, resident set size are sampled. The test fails
+//! if memory keeps growing across every sampling window instead of
+//! levelling off.
+//!
+//! Ignored by default. Run with:
+//!
+//! ```text
+//! cargo test -p redstr-ffi --release --test soak -- --ignored --nocapture
+//! REDSTR_SOAK_ITERATIONS=5000000 cargo test -p redstr-ffi --release --test soak -- --ignored
+//! ```
+
+use std::alloc::{GlobalAlloc, Layout, System};
+use std::ffi::CString;
+use std::os::raw::c_char;
+use std::sync::atomic::{AtomicUsize, Ordering};
+
+use redstr::transform_names;
+use redstr_ffi::*;
+
+struct CountingAllocator;
+
+static LIVE_BYTES: AtomicUsize = AtomicUsize::new(0);
+
+unsafe impl GlobalAlloc for CountingAllocator {
+    unsafe fn alloc(&self, layout: Layout) -> *mut u8 {
+        let ptr = System.alloc(layout);
+        if !ptr.is_null() {
+            LIVE_BYTES.fetch_add(layout.size(), Ordering::Relaxed);
+        }
+        ptr
+    }
+
+    unsafe fn dealloc(&self, ptr: *mut u8, layout: Layout) {
+        System.dealloc(ptr, layout);
+        LIVE_BYTES.fetch_sub(layout.size(), Ordering::Relaxed);
+    }
+}
+
+#[global_allocator]
+static ALLOCATOR: CountingAllocator = CountingAllocator;
+
+const DEFAULT_ITERATIONS: usize = 1_000_000;
+const WINDOWS: usize = 8;
+/// Growth below this many bytes over the whole run is treated as noise.
+const LIVE_BYTES_TOLERANCE: usize = 64 * 1024;
+const RSS_TOLERANCE: usize = 8 * 1024 * 1024;
+const BATCH_SIZE: usize = 8;
+
+const ALPHABET: &[char] = &[
+    'a', 'b', 'c', 'x', 'y', 'z', 'A', 'Q', 'Z', '0', '7', ' ', '.', '/', '<', '>', '\'', '"', '{',
+    '}', '$', '@', '%', '=', 'é', 'ß', 'а', '😀', '\t', '\n',
+];
+
+/// Per-function exports, which allocate outside `redstr_apply_transform`.
+const EXPORTS: &[unsafe extern "C" fn(*const c_char) -> *mut c_char] = &[
+    redstr_leetspeak,
+    redstr_rot13,
+    redstr_base64_encode,
+    redstr_url_encode,
+    redstr_homoglyph_substitution,
+    redstr_zalgo_text,
+    redstr_xss_tag_variations,
+    redstr_bash_obfuscate,
+];
+
+/// Small deterministic xorshift so runs are reproducible.
+fn next(state: &mut u64) -> u64 {
+    *state ^= *state << 13;
+    *state ^= *state >> 7;
+    *state ^= *state << 17;
+    *state
+}
+
+fn random_input(state: &mut u64) -> CString {
+    let len = (next(state) % 64) as usize;
+    let text: String = (0..len)
+        .map(|_| ALPHABET[next(state) as usize % ALPHABET.len()])
+        .collect();
+    CString::new(text).unwrap()
+}
+
+/// Resident set size in bytes, if the platform exposes it.
+fn rss_bytes() -> Option<usize> {
+    let status = std::fs::read_to_string("/proc/self/status").ok()?;
+    let line = status.lines().find(|line| line.starts_with("VmRSS:"))?;
+    let kb: usize = line.split_whitespace().nth(1)?.parse().ok()?;
+    Some(kb * 1024)
+}
+
+fn grows_every_window(samples: &[usize], tolerance: usize) -> bool {
+    let strictly_increasing = samples.windows(2).all(|pair| pair[1] > pair[0]);
+    let total_growth = samples
+        .last()
+        .unwrap_or(&0)
+        .saturating_sub(*samples.first().unwrap_or(&0));
+    strictly_increasing && total_growth > tolerance
+}
+
+#[test]
+#[ignore = "long-running soak test; run with --ignored"]
+fn soak_ffi_exports() {
+    let iterations = std::env::var("REDSTR_SOAK_ITERATIONS")
+        .ok()
+        .and_then(|value| value.parse().ok())
+        .unwrap_or(DEFAULT_ITERATIONS);
+    let names: Vec<CString> = transform_names()
+        .into_iter()
+        .map(|name| CString::new(name).unwrap())
+        .collect();
+    let window = (iterations / WINDOWS).max(1);
+    let mut state = 0x2545_F491_4F6C_DD1D_u64;
+
+    let mut live_samples = Vec::new();
+    let mut rss_samples = Vec::new();
+
+    for i in 0..iterations {
+        let input = random_input(&mut state);
+        let name = &names[next(&mut state) as usize % names.len()];
+
+        unsafe {
+            let result = redstr_apply_transform(name.as_ptr(), input.as_ptr());
+            assert!(!result.is_null(), "{:?} returned null", name);
+            redstr_free_string(result);
+
+            let export = EXPORTS[next(&mut state) as usize % EXPORTS.len()];
+            redstr_free_string(export(input.as_ptr()));
+
+            let bytes = input.as_bytes();
+            let mut buffer = redstr_transform_buffer(name.as_ptr(), bytes.as_ptr(), bytes.len());
+            redstr_buffer_release(&mut buffer);
+
+            if i % 64 == 0 {
+                let batch: Vec<CString> =
+                    (0..BATCH_SIZE).map(|_| random_input(&mut state)).collect();
+                let pointers: Vec<*const c_char> =
+                    batch.iter().map(|input| input.as_ptr()).collect();
+                let mut outputs = vec![std::ptr::null_mut(); BATCH_SIZE];
+                redstr_transform_batch(
+                    name.as_ptr(),
+                    pointers.as_ptr(),
+                    BATCH_SIZE,
+                    outputs.as_mut_ptr(),
+                );
+                redstr_free_string_array(outputs.as_mut_ptr(), BATCH_SIZE);
+
+                let recipe = CString::new("leetspeak | url_encode").unwrap();
+                redstr_free_string(redstr_apply_pipeline(recipe.as_ptr(), input.as_ptr()));
+            }
+        }
+
+        if (i + 1) % window == 0 {
+            live_samples.push(LIVE_BYTES.load(Ordering::Relaxed));
+            if let Some(rss) = rss_bytes() {
+                rss_samples.push(rss);
+            }
+        }
+    }
+
+    println!("live heap bytes per window: {:?}", live_samples);
+    println!("rss bytes per window: {:?}", rss_samples);
+
+    assert!(
+        !grows_every_window(&live_samples, LIVE_BYTES_TOLERANCE),
+        "live heap grew monotonically: {:?}",
+        live_samples
+    );
+    assert!(
+        !grows_every_window(&rss_samples, RSS_TOLERANCE),
+        "RSS grew monotonically: {:?}",
+        rss_samples
+    );
+}
@@ -0,0 +1,79 @@
+//! Pipeline and batch spans across the C boundary.
+
+use std::ffi::{CStr, CString};
+use std::os::raw::c_void;
+use std::sync::Mutex;
+
+use redstr_ffi::*;
+
+/// Opened spans (operation, recipe, inputs) and closed spans
+/// (token, status, outputs, output bytes).
+struct Seen {
+    started: Mutex<Vec<(String, String, usize)>>,
+    ended: Mutex<Vec<(u64, String, usize, usize)>>,
+}
+
+extern "C" fn start(span: *const RedstrSpanStart, user_data: *mut c_void) -> u64 {
+    let seen = unsafe { &*(user_data as *const Seen) };
+    let span = unsafe { &*span };
+    let text = |ptr| unsafe { CStr::from_ptr(ptr) }.to_str().unwrap().to_string();
+    let mut started = seen.started.lock().unwrap();
+    started.push((text(span.operation), text(span.recipe), span.inputs));
+    started.len() as u64
+}
+
+extern "C" fn end(token: u64, span: *const RedstrSpanEnd, user_data: *mut c_void) {
+    let seen = unsafe { &*(user_data as *const Seen) };
+    let span = unsafe { &*span };
+    let status = unsafe { CStr::from_ptr(span.status) }.to_str().unwrap();
+    seen.ended
+        .lock()
+        .unwrap()
+        .push((token, status.to_string(), span.outputs, span.output_bytes));
+}
+
+#[test]
+fn test_tracer_callbacks() {
+    let seen: &'static Seen = Box::leak(Box::new(Seen {
+        started: Mutex::new(Vec::new()),
+        ended: Mutex::new(Vec::new()),
+    }));
+    unsafe {
+        redstr_set_tracer_callbacks(Some(start), Some(end), seen as *const _ as *mut c_void);
+
+        let pipeline = CString::new("rot13 | hex_encode").unwrap();
+        redstr_free_string(redstr_apply_pipeline(pipeline.as_ptr(), c"ab".as_ptr()));
+
+        let spec = CString::new("hex_encode").unwrap();
+        let inputs = [c"a".as_ptr(), std::ptr::null(), c"bc".as_ptr()];
+        let mut outputs = [std::ptr::null_mut(); 3];
+        let written =
+            redstr_transform_batch(spec.as_ptr(), inputs.as_ptr(), 3, outputs.as_mut_ptr());
+        assert_eq!(written, 2);
+        redstr_free_string_array(outputs.as_mut_ptr(), 3);
+
+        assert_eq!(
+            *seen.started.lock().unwrap(),
+            [
+                (
+                    "pipeline".to_string(),
+                    "rot13@1 | hex_encode@1".to_string(),
+                    1
+                ),
+                ("batch".to_string(), "hex_encode@1".to_string(), 3),
+            ]
+        );
+        assert_eq!(
+            *seen.ended.lock().unwrap(),
+            [(1, "ok".to_string(), 1, 4), (2, "ok".to_string(), 2, 6)]
+        );
+
+        // Single named transforms are events, not spans
+        redstr_free_string(redstr_apply_transform(spec.as_ptr(), c"a".as_ptr()));
+        assert_eq!(seen.started.lock().unwrap().len(), 2);
+
+        redstr_set_tracer_callbacks(None, Some(end), std::ptr::null_mut());
+        redstr_free_string(redstr_apply_pipeline(pipeline.as_ptr(), c"ab".as_ptr()));
+        assert_eq!(seen.ended.lock().unwrap().len(), 2);
+    }
+}
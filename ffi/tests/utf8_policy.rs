@@ -0,0 +1,111 @@
+//! Invalid UTF-8 handling policies at the C boundary.
+
+use std::ffi::{CStr, CString};
+use std::os::raw::c_char;
+
+use redstr_ffi::*;
+
+/// `"A" 0xFF "B"`: one invalid byte between two letters.
+const INVALID: &[u8] = b"A\xffB";
+
+unsafe fn apply(spec: &str, input: &CStr) -> Option<String> {
+    let spec = CString::new(spec).unwrap();
+    let result = redstr_apply_transform(spec.as_ptr(), input.as_ptr());
+    if result.is_null() {
+        return None;
+    }
+    let text = CStr::from_ptr(result).to_str().unwrap().to_string();
+    redstr_free_string(result);
+    Some(text)
+}
+
+unsafe fn buffer(spec: &str, input: &[u8]) -> Option<String> {
+    let spec = CString::new(spec).unwrap();
+    let mut buffer = redstr_transform_buffer(spec.as_ptr(), input.as_ptr(), input.len());
+    if buffer.data.is_null() {
+        return None;
+    }
+    let bytes = std::slice::from_raw_parts(buffer.data, buffer.len);
+    let text = std::str::from_utf8(bytes).unwrap().to_string();
+    redstr_buffer_release(&mut buffer);
+    Some(text)
+}
+
+unsafe fn pipeline(recipe: &str, input: &CStr) -> Option<String> {
+    let recipe = CString::new(recipe).unwrap();
+    let result = redstr_apply_pipeline(recipe.as_ptr(), input.as_ptr());
+    if result.is_null() {
+        return None;
+    }
+    let text = CStr::from_ptr(result).to_str().unwrap().to_string();
+    redstr_free_string(result);
+    Some(text)
+}
+
+#[test]
+fn test_invalid_utf8_policies() {
+    let input = CString::new(INVALID).unwrap();
+    unsafe {
+        // Reject (default): every entry point fails on invalid input
+        assert_eq!(apply("hex_encode", &input), None);
+        assert_eq!(apply("rot13", &input), None);
+        assert_eq!(buffer("hex_encode", INVALID), None);
+        assert!(redstr_rot13(input.as_ptr()).is_null());
+        assert_eq!(apply("rot13", c"ok"), Some("bx".to_string()));
+
+        // ReplaceLossy: invalid sequences become U+FFFD before transforming
+        assert!(redstr_set_invalid_utf8_policy(
+            RedstrUtf8Policy::ReplaceLossy as u32
+        ));
+        assert_eq!(apply("rot13", &input), Some("N\u{fffd}O".to_string()));
+        assert_eq!(apply("hex_encode", &input), Some("41efbfbd42".to_string()));
+        assert_eq!(
+            buffer("url_encode", INVALID),
+            Some("A%EF%BF%BDB".to_string())
+        );
+        let result = redstr_reverse_string(input.as_ptr());
+        assert_eq!(CStr::from_ptr(result).to_str().unwrap(), "B\u{fffd}A");
+        redstr_free_string(result);
+
+        // PassThroughBytes: byte-capable transforms see the raw bytes
+        assert!(redstr_set_invalid_utf8_policy(
+            RedstrUtf8Policy::PassThroughBytes as u32
+        ));
+        assert_eq!(apply("hex_encode", &input), Some("41ff42".to_string()));
+        assert_eq!(buffer("base64_encode", INVALID), Some("Qf9C".to_string()));
+        assert_eq!(
+            apply("sql_string_to_hex_literal", &input),
+            Some("0x41ff42".to_string())
+        );
+        let result = redstr_url_encode(input.as_ptr());
+        assert_eq!(CStr::from_ptr(result).to_str().unwrap(), "A%FFB");
+        redstr_free_string(result);
+        assert_eq!(
+            pipeline("hex_encode | reverse_string", &input),
+            Some("24ff14".to_string())
+        );
+        // ...and every other transform rejects them
+        assert_eq!(apply("rot13", &input), None);
+        assert!(redstr_leetspeak(input.as_ptr()).is_null());
+        assert_eq!(pipeline("rot13 | hex_encode", &input), None);
+
+        let inputs: [*const c_char; 2] = [input.as_ptr(), c"ok".as_ptr()];
+        let mut outputs = [std::ptr::null_mut(); 2];
+        let rot13 = CString::new("rot13").unwrap();
+        assert_eq!(
+            redstr_transform_batch(rot13.as_ptr(), inputs.as_ptr(), 2, outputs.as_mut_ptr()),
+            1
+        );
+        assert!(outputs[0].is_null());
+        redstr_free_string_array(outputs.as_mut_ptr(), 2);
+
+        // Unknown values leave the policy unchanged
+        assert!(!redstr_set_invalid_utf8_policy(7));
+        assert_eq!(apply("hex_encode", &input), Some("41ff42".to_string()));
+
+        assert!(redstr_set_invalid_utf8_policy(
+            RedstrUtf8Policy::Reject as u32
+        ));
+        assert_eq!(apply("hex_encode", &input), None);
+    }
+}
@@ -0,0 +1,89 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# Builds the C FFI library for one target and places it where the bindings
+# load it from, together with a SHA256SUMS file and the crate version.
+#
+# Usage:
+#   scripts/build-native.sh [--target <triple>] [--verify]
+#
+# --verify rebuilds nothing: it checks the placed artifacts against their
+# SHA256SUMS and the workspace version, and fails if either is stale.
+
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+cd "$ROOT_DIR"
+
+TARGET=""
+VERIFY=0
+while [[ $# -gt 0 ]]; do
+  case "$1" in
+    --target) TARGET="$2"; shift 2 ;;
+    --verify) VERIFY=1; shift ;;
+    -h|--help) sed -n '4,11p' "$0" | sed 's/^# \{0,1\}//'; exit 0 ;;
+    *) echo "unknown argument: $1" >&2; exit 2 ;;
+  esac
+done
+
+if [[ -z "$TARGET" ]]; then
+  TARGET="$(rustc -vV | sed -n 's/^host: //p')"
+fi
+
+VERSION="$(sed -n 's/^version = "\(.*\)"/\1/p' Cargo.toml | head -n 1)"
+
+# .NET runtime identifier and library file names for the target.
+case "$TARGET" in
+  x86_64-unknown-linux-gnu)  RID=linux-x64 ;;
+  aarch64-unknown-linux-gnu) RID=linux-arm64 ;;
+  x86_64-apple-darwin)       RID=osx-x64 ;;
+  aarch64-apple-darwin)      RID=osx-arm64 ;;
+  x86_64-pc-windows-msvc|x86_64-pc-windows-gnu) RID=win-x64 ;;
+  aarch64-pc-windows-msvc)   RID=win-arm64 ;;
+  *) echo "unsupported target: $TARGET" >&2; exit 2 ;;
+esac
+case "$TARGET" in
+  *-windows-*) LIBS=(redstr_ffi.dll) ;;
+  *-apple-*)   LIBS=(libredstr_ffi.dylib) ;;
+  *)           LIBS=(libredstr_ffi.so) ;;
+esac
+
+DEST="bindings/dotnet/src/Redstr/runtimes/$RID/native"
+
+sha256() {
+  if command -v sha256sum >/dev/null; then
+    sha256sum "$@"
+  else
+    shasum -a 256 "$@"
+  fi
+}
+
+if [[ "$VERIFY" -eq 1 ]]; then
+  if [[ ! -f "$DEST/SHA256SUMS" || ! -f "$DEST/VERSION" ]]; then
+    echo "no artifacts in $DEST; run scripts/build-native.sh --target $TARGET" >&2
+    exit 1
+  fi
+  if [[ "$(cat "$DEST/VERSION")" != "$VERSION" ]]; then
+    echo "$DEST holds redstr $(cat "$DEST/VERSION"), workspace is $VERSION" >&2
+    exit 1
+  fi
+  (cd "$DEST" && sha256 -c SHA256SUMS)
+  exit 0
+fi
+
+cargo build -p redstr-ffi --release --target "$TARGET"
+
+mkdir -p "$DEST"
+for lib in "${LIBS[@]}"; do
+  cp "target/$TARGET/release/$lib" "$DEST/"
+done
+(cd "$DEST" && sha256 "${LIBS[@]}" > SHA256SUMS)
+echo "$VERSION" > "$DEST/VERSION"
+
+if command -v cbindgen >/dev/null; then
+  (cd ffi && cbindgen --config cbindgen.toml --crate redstr-ffi --output include/redstr.h)
+fi
+
+echo "Placed redstr $VERSION for $TARGET:"
+for lib in "${LIBS[@]}"; do
+  echo "  $DEST/$lib"
+done
+echo "  $DEST/SHA256SUMS"